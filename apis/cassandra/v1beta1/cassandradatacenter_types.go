@@ -14,8 +14,11 @@ import (
 	"github.com/k8ssandra/cass-operator/pkg/serverconfig"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation"
 )
 
@@ -32,17 +35,31 @@ const (
 	// RackLabel is the operator's label for the rack name
 	RackLabel = "cassandra.datastax.com/rack"
 
+	// Deprecated: the operator no longer sets this label, the progress of the operator is
+	// tracked in the cassandraOperatorProgress field of the status instead
 	CassOperatorProgressLabel = "cassandra.datastax.com/operator-progress"
 
 	// PromMetricsLabel is a service label that can be selected for prometheus metrics scraping
 	PromMetricsLabel = "cassandra.datastax.com/prom-metrics"
 
+	// DseWorkloadLabel is the operator's label for the DSE workloads of the pods, added when the
+	// LabelPods field of the workloads is set
+	DseWorkloadLabel = "cassandra.datastax.com/dse-workload"
+
 	// DatacenterAnnotation is the operator's annotation for the datacenter name
 	DatacenterAnnotation = DatacenterLabel
 
 	// ConfigHashAnnotation is the operator's annotation for the hash of the ConfigSecret
 	ConfigHashAnnotation = "cassandra.datastax.com/config-hash"
 
+	// InternodeKeystoresHashAnnotation is the operator's annotation for the hash of the internode
+	// keystores, copied to the pods so that they restart when the keystores are renewed
+	InternodeKeystoresHashAnnotation = "cassandra.datastax.com/internode-keystores-hash"
+
+	// CertificatesHashAnnotation is the operator's annotation for the hash of the certificates issued
+	// by cert-manager, copied to the pods so that they restart when the certificates are renewed
+	CertificatesHashAnnotation = "cassandra.datastax.com/certificates-hash"
+
 	// SkipUserCreationAnnotation tells the operator to skip creating any Cassandra users
 	// including the default superuser. This is for multi-dc deployments when adding a
 	// DC to an existing cluster where the superuser has already been created.
@@ -56,6 +73,37 @@ const (
 	// removed. Removing finalizer means deletion is not processed as usual.
 	NoFinalizerAnnotation = "cassandra.datastax.com/no-finalizer"
 
+	// NoAutomatedCleanupAnnotation prevents cass-operator from running a cleanup on the existing nodes
+	// once new nodes were added to the Datacenter.
+	NoAutomatedCleanupAnnotation = "cassandra.datastax.com/no-automated-cleanup"
+
+	// RebuildFromAnnotation makes cass-operator rebuild the nodes of a Datacenter added to an existing cluster
+	// from the datacenter named in its value, once all the nodes are up. It is removed once the rebuild completed.
+	RebuildFromAnnotation = "cassandra.datastax.com/rebuild-from"
+
+	// BroadcastAddressAnnotation is set by cass-operator on the pods exposed by a LoadBalancer podExposure, or
+	// with a broadcastTemplate, to the IP the node broadcasts to the clients.
+	BroadcastAddressAnnotation = "cassandra.datastax.com/broadcast-address"
+
+	// RetainedFromAnnotation is set by cass-operator on the PersistentVolumeClaims kept by the Retain reclaimPolicy
+	// when their Datacenter is deleted, to the clusterName and Datacenter name they were created for, separated
+	// by a slash.
+	RetainedFromAnnotation = "cassandra.datastax.com/retained-from"
+
+	// DecommissionedAnnotation is set by cass-operator on the PersistentVolumeClaims of a node before removing
+	// it from its StatefulSet once its decommission completed, so that their deletion is retried if it was
+	// interrupted.
+	DecommissionedAnnotation = "cassandra.datastax.com/decommissioned"
+
+	// DrainedAnnotation is set by cass-operator on the pods it drained while deleting their Datacenter, so
+	// that the retries of the deletion do not drain them again.
+	DrainedAnnotation = "cassandra.datastax.com/drained"
+
+	// SnapshottedAnnotation is set by cass-operator, to the name of the snapshot, on the pods it snapshotted
+	// while deleting their Datacenter with SnapshotOnDelete, so that the retries of the deletion do not
+	// snapshot them again.
+	SnapshottedAnnotation = "cassandra.datastax.com/snapshotted"
+
 	// Finalizer is the finalizer set by cass-operator to the resources it wants to prevent from being deleted.
 	// If no finalizer is set, the cass-operator ProcessDeletion() is not run
 	Finalizer = "finalizer.cassandra.datastax.com"
@@ -67,11 +115,118 @@ const (
 
 	DefaultNativePort    = 9042
 	DefaultInternodePort = 7000
+
+	// InternodeEncryptionDir is where the keystore of the node and the truststore are mounted
+	InternodeEncryptionDir  = "/etc/internode-encryption"
+	InternodeKeystorePath   = InternodeEncryptionDir + "/keystore.jks"
+	InternodeTruststorePath = InternodeEncryptionDir + "/truststore.jks"
+
+	// ClientEncryptionDir is where the keystore of the client connections is mounted
+	ClientEncryptionDir  = "/etc/client-encryption"
+	ClientKeystorePath   = ClientEncryptionDir + "/keystore.jks"
+	ClientCACertKey      = "ca.crt"
+	ClientKeystoreKey    = "keystore.jks"
+	ClientKeystorePwdKey = "keystore-password"
+
+	// ClientKeystorePasswordPlaceholder is rendered as the password of a keystore of KeystoreSecretName, the
+	// pods replace it with the password of the secret to keep it out of the configuration
+	ClientKeystorePasswordPlaceholder = "client-keystore-password-placeholder"
+
+	// Purposes of the certificates issued by cert-manager when CertManager is set
+	InternodeCertificatePurpose     = "internode"
+	ClientCertificatePurpose        = "client"
+	MgmtApiServerCertificatePurpose = "mgmt-api-server"
+	MgmtApiClientCertificatePurpose = "mgmt-api-client"
+
+	// ConsistentRangeMovementOption must be disabled for several nodes to bootstrap at the same time
+	ConsistentRangeMovementOption = "-Dcassandra.consistent.rangemovement"
+
+	// DefaultCommitLogDir is the commit log directory of the server when it is not configured
+	DefaultCommitLogDir = "/var/lib/cassandra/commitlog"
+
+	// The volumes of the commit log and saved caches claims, and where they are mounted in the server container
+	CommitLogVolumeName   = "server-commitlog"
+	CommitLogDir          = "/var/lib/cassandra-commitlog"
+	SavedCachesVolumeName = "server-saved-caches"
+	SavedCachesDir        = "/var/lib/cassandra-saved-caches"
 )
 
 // ProgressState - this type exists so there's no chance of pushing random strings to our progress status
 type ProgressState string
 
+// ClientEncryptionSpec configures the keystore of the client connections
+type ClientEncryptionSpec struct {
+	// Name of a secret holding the keystore of the nodes in keystore.jks and its password in
+	// keystore-password. When empty, the operator generates a keystore signed by the CA of the
+	// datacenter.
+	// +optional
+	KeystoreSecretName string `json:"keystoreSecretName,omitempty"`
+}
+
+// ImageOverride replaces parts of the reference of a container image, the other parts are kept from
+// the image otherwise selected by the operator
+type ImageOverride struct {
+	// Registry of the image, e.g. registry.example.com:5000
+	// +optional
+	Registry string `json:"registry,omitempty"`
+	// Repository of the image in the registry, e.g. datastax/dse-server
+	// +optional
+	Repository string `json:"repository,omitempty"`
+	// Tag of the image. Setting it drops the digest of the image unless Digest is set too
+	// +optional
+	Tag string `json:"tag,omitempty"`
+	// Digest of the image, e.g. sha256:0123456789abcdef...
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]+:[a-f0-9]{32,}$`
+	// +optional
+	Digest string `json:"digest,omitempty"`
+}
+
+// ImageOverridesSpec overrides the images of the containers of the Cassandra pods
+type ImageOverridesSpec struct {
+	// Server overrides the image of the Cassandra container
+	// +optional
+	Server *ImageOverride `json:"server,omitempty"`
+	// ConfigBuilder overrides the image of the config builder init container
+	// +optional
+	ConfigBuilder *ImageOverride `json:"configBuilder,omitempty"`
+}
+
+// SecurityContextSpec configures the security context of the pods of the datacenter
+type SecurityContextSpec struct {
+	// UID running the containers. Defaults to 999 unless DockerImageRunsAsCassandra is false
+	// +optional
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+	// GID running the containers. Defaults to 999 unless DockerImageRunsAsCassandra is false
+	// +optional
+	RunAsGroup *int64 `json:"runAsGroup,omitempty"`
+	// Group owning the volumes. Defaults to 999 unless DockerImageRunsAsCassandra is false
+	// +optional
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+	// Requires the containers to run as a non-root user
+	// +optional
+	RunAsNonRoot *bool `json:"runAsNonRoot,omitempty"`
+	// Whether the processes of the containers can gain more privileges than their parent
+	// +optional
+	AllowPrivilegeEscalation *bool `json:"allowPrivilegeEscalation,omitempty"`
+	// Capabilities dropped from all the containers, e.g. ALL
+	// +optional
+	DropCapabilities []corev1.Capability `json:"dropCapabilities,omitempty"`
+	// Seccomp profile of the pods
+	// +kubebuilder:validation:Enum=RuntimeDefault;Unconfined
+	// +optional
+	SeccompProfile corev1.SeccompProfileType `json:"seccompProfile,omitempty"`
+}
+
+// CertManagerSpec references the cert-manager issuer of the certificates of the datacenter
+type CertManagerSpec struct {
+	// Name of the Issuer or ClusterIssuer
+	IssuerName string `json:"issuerName"`
+	// Kind of the issuer, Issuer by default
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	// +optional
+	IssuerKind string `json:"issuerKind,omitempty"`
+}
+
 type CassandraUser struct {
 	SecretName string `json:"secretName"`
 	Superuser  bool   `json:"superuser"`
@@ -86,6 +241,13 @@ type CassandraDatacenterSpec struct {
 	// +kubebuilder:validation:Minimum=1
 	Size int32 `json:"size"`
 
+	// MaxNodeDataSize caps the data each node may hold after a scale down. The load of the nodes is read
+	// through the management API before a node is decommissioned, and the scale down is refused with the
+	// ScaleDownBlocked condition while the data of the Datacenter spread over the new size would exceed
+	// it. Raising the size back or the cap resumes the reconciliation.
+	// +optional
+	MaxNodeDataSize *resource.Quantity `json:"maxNodeDataSize,omitempty"`
+
 	// Version string for config builder,
 	// used to generate Cassandra server configuration
 	// +kubebuilder:validation:Pattern=(6\.8\.\d+)|(3\.11\.\d+)|(4\.\d+\.\d+)
@@ -96,6 +258,39 @@ type CassandraDatacenterSpec struct {
 	// More info: https://kubernetes.io/docs/concepts/containers/images
 	ServerImage string `json:"serverImage,omitempty"`
 
+	// PreUpgradeSnapshot takes a snapshot of all the nodes with a CassandraBackup before the server version
+	// or image running on the nodes is upgraded. The upgrade is only rolled out once the backup completed,
+	// and the snapshot is reported in status.preUpgradeSnapshot to roll back to.
+	// +optional
+	PreUpgradeSnapshot bool `json:"preUpgradeSnapshot,omitempty"`
+
+	// SnapshotOnDelete takes a snapshot of every node, through the management API, when the Datacenter is
+	// deleted and before its nodes are drained. The snapshots are named after the deletion time of the
+	// Datacenter and kept on the data volumes, which requires the Retain reclaimPolicy.
+	// +optional
+	SnapshotOnDelete bool `json:"snapshotOnDelete,omitempty"`
+
+	// UpgradeSSTables rewrites the sstables of the nodes in the format of the new version, once a rolling
+	// upgrade to a new major version completed, with an upgradesstables CassandraTask running on one node
+	// at a time. The progress is reported in status.sstablesUpgrade.
+	// +optional
+	UpgradeSSTables *UpgradeSSTablesConfig `json:"upgradeSSTables,omitempty"`
+
+	// ImagePullSecrets used to pull the images of the Cassandra pods from private registries. They are
+	// added to the ones of the PodTemplateSpec and the ImageConfig.
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// ImageOverrides replaces the registry, repository, tag or digest of the server and config builder
+	// images, after ServerImage, ConfigBuilderImage and the ImageConfig are applied
+	// +optional
+	ImageOverrides *ImageOverridesSpec `json:"imageOverrides,omitempty"`
+
+	// ImagePullPolicy of the containers of the Cassandra pods. Overrides value from ImageConfig ImagePullPolicy
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
 	// Server type: "cassandra" or "dse"
 	// +kubebuilder:validation:Enum=cassandra;dse
 	ServerType string `json:"serverType"`
@@ -103,11 +298,22 @@ type CassandraDatacenterSpec struct {
 	// Does the Server Docker image run as the Cassandra user? Defaults to true
 	DockerImageRunsAsCassandra *bool `json:"dockerImageRunsAsCassandra,omitempty"`
 
+	// SecurityContext of the pods and of all their containers, including the init containers, e.g. to
+	// comply with the restricted Pod Security Standard. The security context of the PodTemplateSpec
+	// takes precedence.
+	// +optional
+	SecurityContext *SecurityContextSpec `json:"securityContext,omitempty"`
+
 	// Config for the server, in YAML format
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// +kubebuilder:validation:XPreserveUnknownFields
 	Config json.RawMessage `json:"config,omitempty"`
 
+	// JvmOptions sets the heap and garbage collection settings of the server JVM. They are
+	// rendered into the jvm-options (Cassandra 3.11) or jvm-server-options (DSE and Cassandra 4.0)
+	// config and take precedence over the same settings in Config.
+	JvmOptions *JvmOptions `json:"jvmOptions,omitempty"`
+
 	// ConfigSecret is the name of a secret that contains configuration for Cassandra. The
 	// secret is expected to have a property named config whose value should be a JSON
 	// formatted string that should look like this:
@@ -159,6 +365,13 @@ type CassandraDatacenterSpec struct {
 	// +kubebuilder:validation:MinLength=2
 	ClusterName string `json:"clusterName"`
 
+	// DatacenterName is the name of the datacenter in the Cassandra topology, which defaults to the
+	// name of the CassandraDatacenter. Unlike the latter it is not restricted to DNS-safe characters.
+	// The Kubernetes resources and their labels keep using the name of the CassandraDatacenter.
+	// Cannot be changed once the datacenter is created.
+	// +optional
+	DatacenterName string `json:"datacenterName,omitempty"`
+
 	// A stopped CassandraDatacenter will have no running server pods, like using "stop" with
 	// traditional System V init scripts. Other Kubernetes resources will be left intact, and volumes
 	// will re-attach when the CassandraDatacenter workload is resumed.
@@ -175,18 +388,93 @@ type CassandraDatacenterSpec struct {
 	// either 0 or greater than the rack size, then all nodes in the rack will get updated.
 	CanaryUpgradeCount int32 `json:"canaryUpgradeCount,omitempty"`
 
+	// The number of seconds, counted from the start of the update, after which the changes held
+	// back by CanaryUpgrade are pushed to the rest of the datacenter. If unset, the rollout stays
+	// paused until CanaryUpgrade is turned off.
+	// +kubebuilder:validation:Minimum=0
+	CanaryUpgradePauseSeconds int32 `json:"canaryUpgradePauseSeconds,omitempty"`
+
+	// Indicates that configuration and container image changes are rolled out to the nodes of a rack
+	// one at a time by the operator, with the partition of the StatefulSet, each node being updated once
+	// the previously updated ones have rejoined the ring. Racks are still updated one after the other.
+	// +optional
+	PartitionedUpdates bool `json:"partitionedUpdates,omitempty"`
+
+	// Indicates that the StatefulSets use the OnDelete update strategy and that the operator deletes the
+	// pods to update them itself, one at a time, non-seed nodes first, once all the nodes of the rack are
+	// up in the ring. Racks are updated one after the other.
+	// +optional
+	OnDeleteUpdates bool `json:"onDeleteUpdates,omitempty"`
+
+	// Indicates that the nodes which already joined the ring are started all at once, for instance when
+	// the datacenter is resumed or its pods were recreated, instead of one at a time. The nodes bootstrapping,
+	// replacing another node or which lost their data are still started one at a time.
+	// +optional
+	ParallelRestarts bool `json:"parallelRestarts,omitempty"`
+
+	// The maximum number of nodes started at the same time, at most one per rack, once every rack has a ready node
+	// and while the cluster is healthy. Nodes are started one at a time if unset, or while a keyspace has more
+	// replicas in the Datacenter than it has racks, or more than one with SimpleStrategy, which ignores the racks.
+	// Above 1, the operator adds -Dcassandra.consistent.rangemovement=false to the additional-jvm-opts for the nodes
+	// to bootstrap concurrently. As this option is part of the config of every node, raising it above 1 or lowering
+	// it back to 1 triggers a rolling restart of all the nodes of the Datacenter.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxNodesInFlight int32 `json:"maxNodesInFlight,omitempty"`
+
+	// The number of seconds to wait after a node became ready before starting the next one, for the
+	// streaming and compactions of a new node to settle. Nodes are started as soon as the previous ones
+	// are ready if unset.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	NodeStartDelaySeconds int32 `json:"nodeStartDelaySeconds,omitempty"`
+
 	// Turning this option on allows multiple server pods to be created on a k8s worker node.
 	// By default the operator creates just one server pod per k8s worker node using k8s
 	// podAntiAffinity and requiredDuringSchedulingIgnoredDuringExecution.
 	AllowMultipleNodesPerWorker bool `json:"allowMultipleNodesPerWorker,omitempty"`
 
+	// MovePodsFromCordonedWorkers evicts the pods running on cordoned k8s worker nodes, one at a time,
+	// only while the other pods are ready and as the PodDisruptionBudget allows, so that they are
+	// rescheduled before a drain of the worker races the StatefulSet controller. The pods whose volumes
+	// are pinned to their worker, like local persistent volumes, are not moved.
+	// +optional
+	MovePodsFromCordonedWorkers bool `json:"movePodsFromCordonedWorkers,omitempty"`
+
+	// LostWorkerPolicy recovers the pods of the lost k8s worker nodes, deleted or tainted with
+	// node.kubernetes.io/out-of-service, without further manual intervention. Reschedule force deletes
+	// the pods evicted from a lost worker, and the VolumeAttachments of their volumes to it, so that
+	// they restart on another worker with their data when the storage can follow them. Replace also
+	// replaces the Cassandra nodes of the pods left Pending because their data volume is pinned to a
+	// lost worker, like local volumes, by new nodes streaming the data from the other replicas. The
+	// lost workers are left alone by default.
+	// +kubebuilder:validation:Enum=Reschedule;Replace
+	// +optional
+	LostWorkerPolicy LostWorkerPolicy `json:"lostWorkerPolicy,omitempty"`
+
+	// PodDisruptionBudget configures the PodDisruptionBudget of the datacenter. By default, a single pod
+	// can be unavailable.
+	// +optional
+	PodDisruptionBudget *PodDisruptionBudgetConfig `json:"podDisruptionBudget,omitempty"`
+
 	// This secret defines the username and password for the Cassandra server superuser.
 	// If it is omitted, we will generate a secret instead.
 	SuperuserSecretName string `json:"superuserSecretName,omitempty"`
 
+	// Drops the default cassandra superuser once the superuser of the superuserSecretName was created,
+	// unless its username is cassandra as well.
+	// +optional
+	DisableDefaultSuperuser bool `json:"disableDefaultSuperuser,omitempty"`
+
 	// The k8s service account to use for the server pods
 	ServiceAccount string `json:"serviceAccount,omitempty"`
 
+	// CreateServiceAccount has the operator create the service account of the server pods, named by
+	// ServiceAccount or clusterName-dcName-service-account by default, with a role granting read access
+	// to the pods, endpoints and services of the namespace, e.g. for a Kubernetes aware seed provider
+	// +optional
+	CreateServiceAccount bool `json:"createServiceAccount,omitempty"`
+
 	// DEPRECATED. Use CassandraTask for rolling restarts. Whether to do a rolling restart at the next opportunity. The operator will set this back
 	// to false once the restart is in progress.
 	RollingRestartRequested bool `json:"rollingRestartRequested,omitempty"`
@@ -201,6 +489,16 @@ type CassandraDatacenterSpec struct {
 	// roll out.
 	ForceUpgradeRacks []string `json:"forceUpgradeRacks,omitempty"`
 
+	// The consistency level of the health check the operator runs on the nodes before starting
+	// the remaining nodes of the datacenter or restarting them. Defaults to LOCAL_QUORUM.
+	// +kubebuilder:validation:Enum=ONE;TWO;THREE;QUORUM;ALL;LOCAL_QUORUM;EACH_QUORUM;LOCAL_ONE
+	HealthCheckConsistencyLevel string `json:"healthCheckConsistencyLevel,omitempty"`
+
+	// The replication factor per datacenter assumed by the health check. Defaults to the number
+	// of racks.
+	// +kubebuilder:validation:Minimum=1
+	HealthCheckReplicationFactor int32 `json:"healthCheckReplicationFactor,omitempty"`
+
 	DseWorkloads *DseWorkloads `json:"dseWorkloads,omitempty"`
 
 	// PodTemplate provides customisation options (labels, annotations, affinity rules, resource requests, and so on) for the cassandra pods
@@ -213,6 +511,22 @@ type CassandraDatacenterSpec struct {
 
 	AdditionalSeeds []string `json:"additionalSeeds,omitempty"`
 
+	// The number of seed nodes of the datacenter, capped at its size. Defaults to three seeds, or
+	// one seed per rack when there are more than three racks.
+	// +kubebuilder:validation:Minimum=0
+	SeedCount int32 `json:"seedCount,omitempty"`
+
+	// The minimum number of seed nodes of each rack, capped at the number of nodes of the rack.
+	// Seeds are added on top of SeedCount to the racks that have fewer seeds.
+	// +kubebuilder:validation:Minimum=0
+	MinSeedsPerRack int32 `json:"minSeedsPerRack,omitempty"`
+
+	// ManagedSeedEndpoints makes the operator maintain the Endpoints of the seed service from the seeds it
+	// selects, instead of labeling the seed pods for the selector of the service. All the datacenters of a
+	// cluster must use the same setting, as they share the seed service.
+	// +optional
+	ManagedSeedEndpoints bool `json:"managedSeedEndpoints,omitempty"`
+
 	// Configuration for disabling the simple log tailing sidecar container. Our default is to have it enabled.
 	DisableSystemLoggerSidecar bool `json:"disableSystemLoggerSidecar,omitempty"`
 
@@ -226,16 +540,152 @@ type CassandraDatacenterSpec struct {
 	// Tolerations applied to the Cassandra pod. Note that these cannot be overridden with PodTemplateSpec.
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
 
+	// PriorityClassName of the Cassandra pods, e.g. to keep them from being preempted first under node
+	// pressure. The priority class of the PodTemplateSpec takes precedence.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// TopologySpreadConstraints of the Cassandra pods, e.g. to spread them across zones. A constraint
+	// without a labelSelector spreads the pods of the datacenter. The constraints of the PodTemplateSpec
+	// take precedence.
+	// +optional
+	// +listType=map
+	// +listMapKey=topologyKey
+	// +listMapKey=whenUnsatisfiable
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
 	// Additional Labels allows to define additional labels that will be included in all objects created by the operator. Note, user can override values set by default from the cass-operator and doing so could break cass-operator functionality.
 	AdditionalLabels map[string]string `json:"additionalLabels,omitempty"`
 
+	// Additional Annotations allows to define additional annotations that will be included in the StatefulSets, pods, PVCs, services and PodDisruptionBudget created by the operator, e.g. for cost attribution or policy engines.
+	AdditionalAnnotations map[string]string `json:"additionalAnnotations,omitempty"`
+
 	// CDC allows configuration of the change data capture agent which can run within the Management API container. Use it to send data to Pulsar.
 	CDC *CDCConfiguration `json:"cdc,omitempty"`
+
+	// Reaper deploys Cassandra Reaper for the cluster and registers the cluster in it, to schedule and run repairs
+	// +optional
+	Reaper *ReaperSpec `json:"reaper,omitempty"`
+
+	// Monitoring adds a Prometheus metrics exporter sidecar to the Cassandra pods
+	// +optional
+	Monitoring *MonitoringSpec `json:"monitoring,omitempty"`
+
+	// InternodeEncryption enables TLS between the nodes. The operator generates the keystore of each node,
+	// signed by the CA of the datacenter, and the truststore in the dcName-internode-keystores secret,
+	// and renders the matching server_encryption_options. The truststore trusts the CAs of all the
+	// datacenters of the cluster in the namespace.
+	// +optional
+	InternodeEncryption bool `json:"internodeEncryption,omitempty"`
+
+	// ClientEncryption enables TLS for the client connections. The CA certificate that clients need to
+	// trust is published in the dcName-client-ca secret.
+	// +optional
+	ClientEncryption *ClientEncryptionSpec `json:"clientEncryption,omitempty"`
+
+	// CertManager has cert-manager issue the certificates of InternodeEncryption, ClientEncryption and the
+	// management API instead of the operator. The operator creates the Certificate resources and converts
+	// the issued secrets into keystores. Requires cert-manager to be installed.
+	// +optional
+	CertManager *CertManagerSpec `json:"certManager,omitempty"`
 }
 
 type NetworkingConfig struct {
-	NodePort    *NodePortConfig `json:"nodePort,omitempty"`
-	HostNetwork bool            `json:"hostNetwork,omitempty"`
+	NodePort *NodePortConfig `json:"nodePort,omitempty"`
+	// HostNetwork runs the pods in the network namespace of their worker, with the ClusterFirstWithHostNet
+	// DNS policy. Only one node of the cluster is scheduled per worker, whatever AllowMultipleNodesPerWorker
+	// +optional
+	HostNetwork bool `json:"hostNetwork,omitempty"`
+	// NetworkPolicy creates a NetworkPolicy restricting the ingress traffic of the pods: the internode
+	// ports to the pods of the cluster in its namespaces, CQL to the CQLPeers and the management API to
+	// the operator
+	// +optional
+	NetworkPolicy *NetworkPolicyConfig `json:"networkPolicy,omitempty"`
+	// BroadcastAddress is the address the nodes broadcast to the other nodes and the clients: the IP of
+	// the pod, or the IP of its worker when the pods of other Kubernetes clusters cannot reach the pod IPs.
+	// Defaults to PodIP, NodePort implies HostIP. HostIP requires hostNetwork, nodePort or a HostPort
+	// podExposure, for the ports of the nodes to be bound on their worker
+	// +kubebuilder:validation:Enum=PodIP;HostIP
+	// +optional
+	BroadcastAddress string `json:"broadcastAddress,omitempty"`
+	// SeedExport exposes the seeds of the datacenter to the datacenters running in other Kubernetes
+	// clusters. The addresses they should list in their additionalSeeds are published in the
+	// exportedSeeds of the status
+	// +optional
+	SeedExport *SeedExportConfig `json:"seedExport,omitempty"`
+	// PodExposure exposes each node outside of the Kubernetes cluster, for the external clients and the
+	// datacenters of other Kubernetes clusters, and makes it broadcast its external address
+	// +optional
+	PodExposure *PodExposureConfig `json:"podExposure,omitempty"`
+	// BroadcastTemplate renders the addresses each node broadcasts, for the nodes behind a NAT or named
+	// by external DNS records. It takes precedence over BroadcastAddress
+	// +optional
+	BroadcastTemplate *BroadcastTemplateConfig `json:"broadcastTemplate,omitempty"`
+}
+
+const (
+	BroadcastAddressPodIP  = "PodIP"
+	BroadcastAddressHostIP = "HostIP"
+
+	SeedExportLoadBalancer = "LoadBalancer"
+	SeedExportStatic       = "Static"
+
+	PodExposureHostPort     = "HostPort"
+	PodExposureLoadBalancer = "LoadBalancer"
+)
+
+// BroadcastTemplateConfig holds Go templates rendered by the operator for each pod, once it is scheduled.
+// They have access to .PodName, .PodIP, .HostIP, .NodeName, .NodeLabels, .NodeAnnotations, .RackName,
+// .DatacenterName, .ClusterName and .Namespace, e.g. `{{ .PodName }}.cassandra.example.com` or
+// `{{ index .NodeLabels "example.com/public-ip" }}`
+type BroadcastTemplateConfig struct {
+	// Address is the template of the broadcast_address, the address of the node for the other nodes
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+	// RPCAddress is the template of the broadcast_rpc_address, the address of the node for the clients.
+	// Defaults to the rendered Address
+	// +optional
+	RPCAddress string `json:"rpcAddress,omitempty"`
+}
+
+type PodExposureConfig struct {
+	// Type of the exposure: HostPort binds the CQL and internode ports of each node on its worker, and the
+	// node broadcasts the IP of the worker. LoadBalancer creates a Service of type LoadBalancer per pod, and
+	// the node broadcasts the address of its Service
+	// +kubebuilder:validation:Enum=HostPort;LoadBalancer
+	Type string `json:"type"`
+}
+
+// PodDisruptionBudgetConfig sets the voluntary disruptions the PodDisruptionBudget of the datacenter allows.
+// Only one of MinAvailable and MaxUnavailable can be set.
+type PodDisruptionBudgetConfig struct {
+	// MinAvailable is the number or percentage of the pods of the datacenter that must stay available
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// MaxUnavailable is the number or percentage of the pods of the datacenter that can be unavailable
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+type SeedExportConfig struct {
+	// Type of the export: LoadBalancer creates a Service of type LoadBalancer in front of the seeds of
+	// the datacenter, Static publishes the Addresses, routed to the seeds outside of the operator
+	// +kubebuilder:validation:Enum=LoadBalancer;Static
+	Type string `json:"type"`
+	// Addresses of the seeds when Type is Static
+	// +optional
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+type NetworkPolicyConfig struct {
+	// Peers allowed to connect to the CQL ports, in addition to the pods of the cluster. Defaults to the
+	// pods of the namespace of the datacenter
+	// +optional
+	CQLPeers []networkingv1.NetworkPolicyPeer `json:"cqlPeers,omitempty"`
+	// Namespaces of the other datacenters of the cluster, whose pods are allowed to connect to the
+	// internode ports. The pods of the cluster in the namespace of the datacenter always are
+	// +optional
+	ClusterNamespaces []string `json:"clusterNamespaces,omitempty"`
 }
 
 type NodePortConfig struct {
@@ -245,6 +695,11 @@ type NodePortConfig struct {
 	InternodeSSL int `json:"internodeSSL,omitempty"`
 }
 
+// IsNetworkPolicyEnabled is the NetworkPolicy of the datacenter enabled?
+func (dc *CassandraDatacenter) IsNetworkPolicyEnabled() bool {
+	return dc.Spec.Networking != nil && dc.Spec.Networking.NetworkPolicy != nil
+}
+
 // IsNodePortEnabled is the NodePort service enabled?
 func (dc *CassandraDatacenter) IsNodePortEnabled() bool {
 	return dc.Spec.Networking != nil && dc.Spec.Networking.NodePort != nil
@@ -255,10 +710,70 @@ func (dc *CassandraDatacenter) IsHostNetworkEnabled() bool {
 	return networking != nil && networking.HostNetwork
 }
 
+// IsHostIPBroadcastEnabled do the nodes broadcast the IP of their worker?
+func (dc *CassandraDatacenter) IsHostIPBroadcastEnabled() bool {
+	networking := dc.Spec.Networking
+	return networking != nil && (networking.NodePort != nil || networking.BroadcastAddress == BroadcastAddressHostIP ||
+		dc.IsPodExposureEnabled(PodExposureHostPort))
+}
+
+// IsBroadcastTemplateEnabled are the addresses the nodes broadcast rendered from templates?
+func (dc *CassandraDatacenter) IsBroadcastTemplateEnabled() bool {
+	networking := dc.Spec.Networking
+	return networking != nil && networking.BroadcastTemplate != nil
+}
+
+// IsBroadcastAddressPublished do the nodes broadcast the address the operator publishes for them in the
+// broadcast addresses ConfigMap?
+func (dc *CassandraDatacenter) IsBroadcastAddressPublished() bool {
+	return dc.IsPodExposureEnabled(PodExposureLoadBalancer) || dc.IsBroadcastTemplateEnabled()
+}
+
+// IsPodExposureEnabled are the nodes exposed with the given type of podExposure?
+func (dc *CassandraDatacenter) IsPodExposureEnabled(exposureType string) bool {
+	networking := dc.Spec.Networking
+	return networking != nil && networking.PodExposure != nil && networking.PodExposure.Type == exposureType
+}
+
+// IsSeedExportLoadBalancerEnabled are the seeds exported through a Service of type LoadBalancer?
+func (dc *CassandraDatacenter) IsSeedExportLoadBalancerEnabled() bool {
+	networking := dc.Spec.Networking
+	return networking != nil && networking.SeedExport != nil && networking.SeedExport.Type == SeedExportLoadBalancer
+}
+
 type DseWorkloads struct {
 	AnalyticsEnabled bool `json:"analyticsEnabled,omitempty"`
 	GraphEnabled     bool `json:"graphEnabled,omitempty"`
 	SearchEnabled    bool `json:"searchEnabled,omitempty"`
+
+	// LabelPods adds the cassandra.datastax.com/dse-workload label to the pods, e.g. Analytics-Search,
+	// so that clients can select the nodes of a workload
+	// +optional
+	LabelPods bool `json:"labelPods,omitempty"`
+}
+
+// IsEnabled Returns true when any of the workloads is enabled
+func (w *DseWorkloads) IsEnabled() bool {
+	return w != nil && (w.AnalyticsEnabled || w.GraphEnabled || w.SearchEnabled)
+}
+
+// GetLabelValue Returns the value of the DseWorkloadLabel, the enabled workloads joined by dashes
+// or Cassandra when none is enabled
+func (w *DseWorkloads) GetLabelValue() string {
+	var workloads []string
+	if w != nil && w.AnalyticsEnabled {
+		workloads = append(workloads, "Analytics")
+	}
+	if w != nil && w.GraphEnabled {
+		workloads = append(workloads, "Graph")
+	}
+	if w != nil && w.SearchEnabled {
+		workloads = append(workloads, "Search")
+	}
+	if len(workloads) == 0 {
+		return "Cassandra"
+	}
+	return strings.Join(workloads, "-")
 }
 
 // AdditionalVolumes defines additional storage configurations
@@ -276,7 +791,184 @@ type AdditionalVolumesSlice []AdditionalVolumes
 
 type StorageConfig struct {
 	CassandraDataVolumeClaimSpec *corev1.PersistentVolumeClaimSpec `json:"cassandraDataVolumeClaimSpec,omitempty"`
-	AdditionalVolumes            AdditionalVolumesSlice            `json:"additionalVolumes,omitempty"`
+
+	// EphemeralDataVolume stores the data of each node in a volume living only as long as its pod, instead
+	// of a PersistentVolumeClaim of the CassandraDataVolumeClaimSpec, for development and for deployments
+	// relying on the replication of the data. A new pod replaces the node of the pod it succeeds and
+	// streams its data back from the other replicas.
+	// +optional
+	EphemeralDataVolume *EphemeralDataVolumeSource `json:"ephemeralDataVolume,omitempty"`
+	AdditionalVolumes   AdditionalVolumesSlice     `json:"additionalVolumes,omitempty"`
+
+	// CommitLogVolumeClaimSpec puts the commit log of each node on a PersistentVolumeClaim of its own,
+	// with its own StorageClass and size, instead of the server data volume. The claim is mounted at
+	// /var/lib/cassandra-commitlog and rendered as the commitlog_directory of the server.
+	// +optional
+	CommitLogVolumeClaimSpec *corev1.PersistentVolumeClaimSpec `json:"commitLogVolumeClaimSpec,omitempty"`
+
+	// SavedCachesVolumeClaimSpec puts the saved caches of each node on a PersistentVolumeClaim of its own,
+	// mounted at /var/lib/cassandra-saved-caches and rendered as the saved_caches_directory of the server.
+	// +optional
+	SavedCachesVolumeClaimSpec *corev1.PersistentVolumeClaimSpec `json:"savedCachesVolumeClaimSpec,omitempty"`
+
+	// ReclaimPolicy of the PersistentVolumeClaims of the nodes when the Datacenter is deleted. Delete, the
+	// default, deletes them once the pods are drained. Retain keeps them, annotated with the cluster and
+	// Datacenter they belong to, so that a Datacenter recreated with the same clusterName, name and racks
+	// adopts them again with their data. It can be changed at any time.
+	// +kubebuilder:validation:Enum=Retain;Delete
+	// +optional
+	ReclaimPolicy PVCReclaimPolicy `json:"reclaimPolicy,omitempty"`
+
+	// DataSourceBackup is the name of a CassandraBackup whose VolumeSnapshots provision the server data
+	// volumes of the Datacenter when it is created, to restore in place the Datacenter the backup was taken
+	// from after it was deleted. It must have the same clusterName, name, namespace and racks, so that each
+	// node starts on the snapshot of the node of the same name with its tokens and host ID. The nodes without
+	// a snapshot bootstrap empty.
+	// +optional
+	DataSourceBackup string `json:"dataSourceBackup,omitempty"`
+}
+
+// StorageMigrationStatus is the progress of the migration of the server data volumes to a new StorageClass
+type StorageMigrationStatus struct {
+	// The StorageClass the server data volumes are migrated to
+	StorageClassName string `json:"storageClassName"`
+
+	// The migration stage of the server data volume of each pod
+	// +optional
+	Nodes map[string]StorageMigrationStage `json:"nodes,omitempty"`
+}
+
+// PreUpgradeSnapshotStatus is the snapshot of the nodes taken before an upgrade of the server
+type PreUpgradeSnapshotStatus struct {
+	// The CassandraBackup taking the snapshot
+	BackupName string `json:"backupName"`
+
+	// The server version running on the nodes when the snapshot was taken
+	ServerVersion string `json:"serverVersion"`
+
+	// The server image running on the nodes when the snapshot was taken
+	ServerImage string `json:"serverImage"`
+
+	// The tag of the snapshots on the nodes, set once the snapshots of all the nodes were taken
+	// +optional
+	SnapshotName string `json:"snapshotName,omitempty"`
+}
+
+// UpgradeSSTablesConfig limits the upgrade of the sstables after a major upgrade
+type UpgradeSSTablesConfig struct {
+	// Jobs is the number of sstables rewritten concurrently on each node, all the compaction threads are
+	// used if unset. The throughput is limited by the compaction throughput of the nodes.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Jobs int `json:"jobs,omitempty"`
+}
+
+// SSTablesUpgradeStatus is the progress of the upgrade of the sstables after a major upgrade
+type SSTablesUpgradeStatus struct {
+	// The server version the sstables are upgraded to
+	ServerVersion string `json:"serverVersion"`
+
+	// The upgradesstables CassandraTask
+	// +optional
+	TaskName string `json:"taskName,omitempty"`
+
+	// The number of nodes which upgraded their sstables
+	// +optional
+	UpgradedNodes int `json:"upgradedNodes,omitempty"`
+
+	// The number of nodes which failed to upgrade their sstables in the last task, which is retried
+	// +optional
+	FailedNodes int `json:"failedNodes,omitempty"`
+
+	// Represents time when all the nodes upgraded their sstables
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+type StorageMigrationStage string
+
+const (
+	// StorageMigrationPending is the stage of the nodes still using a volume of another StorageClass
+	StorageMigrationPending StorageMigrationStage = "Pending"
+	// StorageMigrationMigrating is the stage of the nodes being replaced onto a new volume
+	StorageMigrationMigrating StorageMigrationStage = "Migrating"
+	// StorageMigrationMigrated is the stage of the nodes running on a volume of the new StorageClass
+	StorageMigrationMigrated StorageMigrationStage = "Migrated"
+)
+
+type LostWorkerPolicy string
+
+const (
+	// LostWorkerPolicyReschedule restarts the pods of a lost worker on other workers with their volumes
+	LostWorkerPolicyReschedule LostWorkerPolicy = "Reschedule"
+	// LostWorkerPolicyReplace also replaces the nodes whose data volume is pinned to a lost worker
+	LostWorkerPolicyReplace LostWorkerPolicy = "Replace"
+)
+
+type PVCReclaimPolicy string
+
+const (
+	// PVCReclaimPolicyRetain keeps the PersistentVolumeClaims of the nodes when the Datacenter is deleted
+	PVCReclaimPolicyRetain PVCReclaimPolicy = "Retain"
+	// PVCReclaimPolicyDelete deletes the PersistentVolumeClaims of the nodes when the Datacenter is deleted
+	PVCReclaimPolicyDelete PVCReclaimPolicy = "Delete"
+)
+
+// EphemeralDataVolumeSource is the volume of the data of the nodes in the ephemeral storage mode. Exactly one
+// of EmptyDir and Ephemeral must be set.
+type EphemeralDataVolumeSource struct {
+	// EmptyDir stores the data in a directory of the worker, or in memory with the Memory medium
+	// +optional
+	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+	// Ephemeral stores the data in a PersistentVolumeClaim created and deleted with the pod, e.g. on a
+	// local volume
+	// +optional
+	Ephemeral *corev1.EphemeralVolumeSource `json:"ephemeral,omitempty"`
+}
+
+type StorageMode string
+
+const (
+	// StorageModePersistent keeps the data of the nodes in the PersistentVolumeClaims of the StatefulSets
+	StorageModePersistent StorageMode = "Persistent"
+	// StorageModeEphemeral loses the data of a node when its pod is deleted
+	StorageModeEphemeral StorageMode = "Ephemeral"
+)
+
+// IsEphemeralStorageEnabled returns true when the data of the nodes is stored in an ephemeral volume
+func (dc *CassandraDatacenter) IsEphemeralStorageEnabled() bool {
+	return dc.Spec.StorageConfig.EphemeralDataVolume != nil
+}
+
+// IsRestoredFromBackup returns true when the server data volumes are provisioned from the VolumeSnapshots
+// of a CassandraBackup
+func (dc *CassandraDatacenter) IsRestoredFromBackup() bool {
+	return dc.Spec.StorageConfig.DataSourceBackup != "" && !dc.IsEphemeralStorageEnabled()
+}
+
+// GetStorageMode returns the storage mode of the data of the nodes
+func (dc *CassandraDatacenter) GetStorageMode() StorageMode {
+	if dc.IsEphemeralStorageEnabled() {
+		return StorageModeEphemeral
+	}
+	return StorageModePersistent
+}
+
+// GetDeletionSnapshotName returns the name of the snapshots taken with SnapshotOnDelete when the Datacenter
+// is deleted, derived from its deletion time so that it does not change between the retries
+func (dc *CassandraDatacenter) GetDeletionSnapshotName() string {
+	if dc.DeletionTimestamp == nil {
+		return ""
+	}
+	return fmt.Sprintf("deleted-%s", dc.DeletionTimestamp.UTC().Format("20060102150405"))
+}
+
+// GetPVCReclaimPolicy returns the reclaimPolicy of the PersistentVolumeClaims, Delete by default
+func (dc *CassandraDatacenter) GetPVCReclaimPolicy() PVCReclaimPolicy {
+	if dc.Spec.StorageConfig.ReclaimPolicy == "" {
+		return PVCReclaimPolicyDelete
+	}
+	return dc.Spec.StorageConfig.ReclaimPolicy
 }
 
 // GetRacks is a getter for the Rack slice in the spec
@@ -291,13 +983,52 @@ func (dc *CassandraDatacenter) GetRacks() []Rack {
 	}}
 }
 
+// GetRackDseWorkloads Returns the DSE workloads of the pods of the rack, the ones of the rack replace
+// the ones of the datacenter
+func (dc *CassandraDatacenter) GetRackDseWorkloads(rackName string) *DseWorkloads {
+	for _, rack := range dc.Spec.Racks {
+		if rack.Name == rackName && rack.DseWorkloads != nil {
+			return rack.DseWorkloads
+		}
+	}
+	return dc.Spec.DseWorkloads
+}
+
+// GetAllDseWorkloads Returns the DSE workloads enabled in the datacenter or any of its racks, nil when
+// the spec sets none. The services expose the ports of all of them.
+func (dc *CassandraDatacenter) GetAllDseWorkloads() *DseWorkloads {
+	var all *DseWorkloads
+	for _, rack := range dc.GetRacks() {
+		workloads := dc.GetRackDseWorkloads(rack.Name)
+		if workloads == nil {
+			continue
+		}
+		if all == nil {
+			all = &DseWorkloads{}
+		}
+		all.AnalyticsEnabled = all.AnalyticsEnabled || workloads.AnalyticsEnabled
+		all.GraphEnabled = all.GraphEnabled || workloads.GraphEnabled
+		all.SearchEnabled = all.SearchEnabled || workloads.SearchEnabled
+	}
+	return all
+}
+
 // ServiceConfig defines additional service configurations.
 type ServiceConfig struct {
-	DatacenterService     ServiceConfigAdditions `json:"dcService,omitempty"`
-	SeedService           ServiceConfigAdditions `json:"seedService,omitempty"`
-	AllPodsService        ServiceConfigAdditions `json:"allpodsService,omitempty"`
+	// Additions to the service of the CQL clients of the datacenter
+	DatacenterService ServiceConfigAdditions `json:"dcService,omitempty"`
+	// Additions to the headless service of the seeds of the cluster
+	SeedService ServiceConfigAdditions `json:"seedService,omitempty"`
+	// Additions to the headless service of all the pods of the datacenter, ready or not
+	AllPodsService ServiceConfigAdditions `json:"allpodsService,omitempty"`
+	// Additions to the service of the additional seeds
 	AdditionalSeedService ServiceConfigAdditions `json:"additionalSeedService,omitempty"`
-	NodePortService       ServiceConfigAdditions `json:"nodePortService,omitempty"`
+	// Additions to the service of networking.nodePort
+	NodePortService ServiceConfigAdditions `json:"nodePortService,omitempty"`
+	// Additions to the LoadBalancer service of networking.seedExport
+	SeedExportService ServiceConfigAdditions `json:"seedExportService,omitempty"`
+	// Additions to the LoadBalancer service of each pod exposed by networking.podExposure
+	PodService ServiceConfigAdditions `json:"podService,omitempty"`
 }
 
 // ServiceConfigAdditions exposes additional options for each service
@@ -317,14 +1048,66 @@ type Rack struct {
 
 	//NodeAffinityLabels to pin the rack, using node affinity
 	NodeAffinityLabels map[string]string `json:"nodeAffinityLabels,omitempty"`
+
+	// Tolerations applied to the Cassandra pods of this rack, in addition to the Datacenter tolerations
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// DseWorkloads of the pods of this rack, replacing the ones of the Datacenter. DataStax recommends
+	// running a single workload per datacenter.
+	// +optional
+	DseWorkloads *DseWorkloads `json:"dseWorkloads,omitempty"`
+}
+
+// JvmOptions are the first-class JVM settings of the server
+type JvmOptions struct {
+	// Initial and maximum heap size of the server JVM
+	HeapSize *resource.Quantity `json:"heapSize,omitempty"`
+
+	// Size of the young generation of the heap, only used with the CMS garbage collector
+	HeapNewGenSize *resource.Quantity `json:"heapNewGenSize,omitempty"`
+
+	// The garbage collector used by the server JVM
+	// +kubebuilder:validation:Enum=G1GC;CMS
+	GarbageCollector string `json:"garbageCollector,omitempty"`
 }
 
 type CassandraNodeStatus struct {
 	HostID string `json:"hostID,omitempty"`
+
+	// The operation mode of the node as seen by gossip, for example NORMAL, JOINING or LEAVING
+	// +optional
+	OperationMode string `json:"operationMode,omitempty"`
 }
 
 type CassandraStatusMap map[string]CassandraNodeStatus
 
+// RackStage - the step of the reconciliation a rack is currently going through
+type RackStage string
+
+const (
+	RackStagePending  RackStage = "Pending"
+	RackStageStopped  RackStage = "Stopped"
+	RackStageScaling  RackStage = "Scaling"
+	RackStageUpdating RackStage = "Updating"
+	RackStageStarting RackStage = "Starting"
+	RackStageReady    RackStage = "Ready"
+)
+
+// RackStatus is the progress of the reconciliation of a single rack
+type RackStatus struct {
+	// The number of nodes the rack should have
+	DesiredNodes int32 `json:"desiredNodes"`
+
+	// The number of nodes of the rack that are ready
+	ReadyNodes int32 `json:"readyNodes"`
+
+	// The number of nodes of the rack that run the latest pod template
+	UpdatedNodes int32 `json:"updatedNodes"`
+
+	// +optional
+	Stage RackStage `json:"stage,omitempty"`
+}
+
 type DatacenterConditionType string
 
 const (
@@ -340,6 +1123,40 @@ const (
 	DatacenterValid          DatacenterConditionType = "Valid"
 	DatacenterDecommission   DatacenterConditionType = "Decommission"
 
+	// DatacenterReaperRegistered indicates that the cluster is registered in the Reaper deployed
+	// for the datacenter.
+	DatacenterReaperRegistered DatacenterConditionType = "ReaperRegistered"
+
+	// DatacenterRackZoneMismatch indicates that some pods are running outside of the zone their
+	// rack is pinned to.
+	DatacenterRackZoneMismatch DatacenterConditionType = "RackZoneMismatch"
+
+	// DatacenterMovingPods indicates that some pods are running on cordoned workers and are being
+	// moved by movePodsFromCordonedWorkers.
+	DatacenterMovingPods DatacenterConditionType = "MovingPods"
+
+	// DatacenterMigratingStorage indicates that the server data volumes of some nodes are not yet in the
+	// storageClassName of the cassandraDataVolumeClaimSpec, and that the nodes are being replaced one at a
+	// time onto new volumes.
+	DatacenterMigratingStorage DatacenterConditionType = "MigratingStorage"
+
+	// DatacenterStorageMigrationBlocked indicates that the server data volumes are not migrated to the
+	// storageClassName of the cassandraDataVolumeClaimSpec, because replacing a node would lose the data
+	// of keyspaces with a single replica in the datacenter.
+	DatacenterStorageMigrationBlocked DatacenterConditionType = "StorageMigrationBlocked"
+
+	// DatacenterScaleDownBlocked indicates that a scale down is refused because the remaining nodes would
+	// hold more than the maxNodeDataSize.
+	DatacenterScaleDownBlocked DatacenterConditionType = "ScaleDownBlocked"
+
+	// DatacenterVolumeResizeBlocked indicates that the storage request of a volume claim was increased,
+	// but the StorageClass of the volumes does not allow volume expansion.
+	DatacenterVolumeResizeBlocked DatacenterConditionType = "VolumeResizeBlocked"
+
+	// DatacenterDefaultSuperuserKept indicates that disableDefaultSuperuser is set, but the management API
+	// of the pods can not drop roles, so the default cassandra superuser was kept.
+	DatacenterDefaultSuperuserKept DatacenterConditionType = "DefaultSuperuserKept"
+
 	// DatacenterHealthy indicates if QUORUM can be reached from all deployed nodes.
 	// If this check fails, certain operations such as scaling up will not proceed.
 	DatacenterHealthy DatacenterConditionType = "Healthy"
@@ -382,11 +1199,20 @@ type CassandraDatacenterStatus struct {
 	// +optional
 	SuperUserUpserted metav1.Time `json:"superUserUpserted,omitempty"`
 
+	// The name of the secret holding the credentials of the CQL superuser,
+	// either the one set in the spec or the one generated by the operator
+	// +optional
+	SuperUserSecretName string `json:"superUserSecretName,omitempty"`
+
 	// The timestamp at which managed cassandra users' credentials
 	// were last upserted to the management API
 	// +optional
 	UsersUpserted metav1.Time `json:"usersUpserted,omitempty"`
 
+	// The timestamp at which the default cassandra superuser was dropped, with disableDefaultSuperuser
+	// +optional
+	DefaultSuperuserDisabled metav1.Time `json:"defaultSuperuserDisabled,omitempty"`
+
 	// The timestamp when the operator last started a Server node
 	// with the management API
 	// +optional
@@ -405,22 +1231,78 @@ type CassandraDatacenterStatus struct {
 	// +optional
 	NodeReplacements []string `json:"nodeReplacements"`
 
+	// The addresses of the pods currently labeled as seeds in this datacenter
+	// +optional
+	Seeds []string `json:"seeds,omitempty"`
+
+	// The progress of the reconciliation of each rack, by rack name
+	// +optional
+	RackStatuses map[string]RackStatus `json:"rackStatuses,omitempty"`
+
+	// The number of nodes of the datacenter that are ready
+	// +optional
+	ReadyNodes int32 `json:"readyNodes"`
+
+	// The label selector of the pods of the datacenter, in the string form the scale subresource
+	// reports to autoscalers
+	// +optional
+	Selector string `json:"selector,omitempty"`
+
+	// The storage mode of the data of the nodes, Persistent or Ephemeral
+	// +optional
+	StorageMode StorageMode `json:"storageMode,omitempty"`
+
+	// The progress of the migration of the server data volumes to the storageClassName of the
+	// cassandraDataVolumeClaimSpec, once it was changed
+	// +optional
+	StorageMigration *StorageMigrationStatus `json:"storageMigration,omitempty"`
+
+	// The server version running on all the nodes, recorded once every rack is ready after an upgrade
+	// +optional
+	ServerVersion string `json:"serverVersion,omitempty"`
+
+	// The server image running on all the nodes, resolved from the serverVersion unless the serverImage
+	// is set
+	// +optional
+	ServerImage string `json:"serverImage,omitempty"`
+
+	// The snapshot taken before the last upgrade of the server, with preUpgradeSnapshot
+	// +optional
+	PreUpgradeSnapshot *PreUpgradeSnapshotStatus `json:"preUpgradeSnapshot,omitempty"`
+
+	// The progress of the upgrade of the sstables after the last major upgrade, with upgradeSSTables
+	// +optional
+	SSTablesUpgrade *SSTablesUpgradeStatus `json:"sstablesUpgrade,omitempty"`
+
 	// +optional
 	QuietPeriod metav1.Time `json:"quietPeriod,omitempty"`
 
+	// The generation of the spec the operator last reconciled
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 
 	// TrackedTasks tracks the tasks for completion that were created by the cass-operator
 	// +optional
 	TrackedTasks []corev1.ObjectReference `json:"trackedTasks,omitempty"`
+
+	// The addresses of the seeds exported by seedExport, to list in the additionalSeeds of the datacenters
+	// of the other Kubernetes clusters
+	// +optional
+	ExportedSeeds []string `json:"exportedSeeds,omitempty"`
 }
 
 // CassandraDatacenter is the Schema for the cassandradatacenters API
 // +k8s:openapi-gen=true
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.size,statuspath=.status.readyNodes,selectorpath=.status.selector
 // +kubebuilder:resource:path=cassandradatacenters,scope=Namespaced,shortName=cassdc;cassdcs
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=".spec.clusterName",description="The name of the cluster of the datacenter"
+// +kubebuilder:printcolumn:name="Size",type=integer,JSONPath=".spec.size",description="The desired number of nodes"
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=".status.serverVersion",description="The server version running on the nodes"
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=".status.readyNodes",description="The number of nodes that are ready"
+// +kubebuilder:printcolumn:name="Progress",type=string,JSONPath=".status.cassandraOperatorProgress",description="The progress of the operator"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
 type CassandraDatacenter struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -434,6 +1316,15 @@ type ManagementApiAuthManualConfig struct {
 	ServerSecretName string `json:"serverSecretName"`
 	// +optional
 	SkipSecretValidation bool `json:"skipSecretValidation,omitempty"`
+	// The name the server certificates must be valid for. When set, the operator
+	// verifies the server name of the management API certificates, otherwise it
+	// only verifies that they are signed by the CA.
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+	// The name of a secret holding a bearer token under the "token" key, sent
+	// with every request to the management API.
+	// +optional
+	TokenSecretName string `json:"tokenSecretName,omitempty"`
 }
 
 type ManagementApiAuthInsecureConfig struct {
@@ -442,7 +1333,8 @@ type ManagementApiAuthInsecureConfig struct {
 type ManagementApiAuthConfig struct {
 	Insecure *ManagementApiAuthInsecureConfig `json:"insecure,omitempty"`
 	Manual   *ManagementApiAuthManualConfig   `json:"manual,omitempty"`
-	// other strategy configs (e.g. Cert Manager) go here
+	// other strategy configs go here, the certificates are issued by cert-manager when Spec.CertManager
+	// is set and neither is
 }
 
 //+kubebuilder:object:root=true
@@ -541,6 +1433,16 @@ func (dc *CassandraDatacenter) SetCondition(condition DatacenterCondition) {
 	(&dc.Status).SetCondition(condition)
 }
 
+func (status *CassandraDatacenterStatus) RemoveCondition(conditionType DatacenterConditionType) {
+	conditions := status.Conditions[:0]
+	for _, condition := range status.Conditions {
+		if condition.Type != conditionType {
+			conditions = append(conditions, condition)
+		}
+	}
+	status.Conditions = conditions
+}
+
 // GetDatacenterLabels ...
 func (dc *CassandraDatacenter) GetDatacenterLabels() map[string]string {
 	labels := dc.GetClusterLabels()
@@ -548,6 +1450,14 @@ func (dc *CassandraDatacenter) GetDatacenterLabels() map[string]string {
 	return labels
 }
 
+// DatacenterName returns the name of the datacenter in the Cassandra topology
+func (dc *CassandraDatacenter) DatacenterName() string {
+	if dc.Spec.DatacenterName != "" {
+		return dc.Spec.DatacenterName
+	}
+	return dc.Name
+}
+
 // GetClusterLabels returns a new map with the cluster label key and cluster name value
 func (dc *CassandraDatacenter) GetClusterLabels() map[string]string {
 	return map[string]string{
@@ -564,9 +1474,14 @@ var whitelistRegex = regexp.MustCompile(`(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-
 // '-', '_' or '.', and must start and end with an alphanumeric.
 // Note: we apply a prefix of "cassandra-" to the cluster name value used as label name.
 // As such, empty string isn't a valid case.
+// Values longer than the 63 characters allowed are truncated.
 func CleanLabelValue(value string) string {
 	regexpResult := whitelistRegex.FindAllString(strings.Replace(value, " ", "", -1), -1)
-	return strings.Join(regexpResult, "")
+	cleaned := strings.Join(regexpResult, "")
+	if len(cleaned) > validation.LabelValueMaxLength {
+		cleaned = strings.TrimRight(cleaned[:validation.LabelValueMaxLength], "-_.")
+	}
+	return cleaned
 }
 
 func CleanupForKubernetes(input string) string {
@@ -583,6 +1498,57 @@ func CleanupForKubernetes(input string) string {
 	return input
 }
 
+// GetInternodeKeystoresSecretName returns the name of the secret of the keystores used by InternodeEncryption
+func (dc *CassandraDatacenter) GetInternodeKeystoresSecretName() string {
+	return dc.Name + "-internode-keystores"
+}
+
+// GetKeystorePassword returns the password of the keystores prepared by the operator for InternodeEncryption
+// and ClientEncryption. Like for the dcName-keystore secret, it is the name of the datacenter: the keystores
+// are only readable by those who can read their secret anyway.
+func (dc *CassandraDatacenter) GetKeystorePassword() string {
+	return dc.Name
+}
+
+// GetClientKeystoreSecretName returns the name of the secret of the keystore mounted by the pods for ClientEncryption
+func (dc *CassandraDatacenter) GetClientKeystoreSecretName() string {
+	return dc.Name + "-client-keystore"
+}
+
+// GetClientCASecretName returns the name of the secret of the CA certificate that the clients need to trust
+func (dc *CassandraDatacenter) GetClientCASecretName() string {
+	return dc.Name + "-client-ca"
+}
+
+// GetCertificateSecretName returns the name of the Certificate, and of its secret, issued by cert-manager for
+// the purpose, e.g. internode or client
+func (dc *CassandraDatacenter) GetCertificateSecretName(purpose string) string {
+	return dc.Name + "-" + purpose + "-certificate"
+}
+
+// IsManagementApiCertManagerEnabled returns whether the certificates of the management API are issued by
+// cert-manager, which is the case when CertManager is set and ManagementApiAuth does not configure them
+func (dc *CassandraDatacenter) IsManagementApiCertManagerEnabled() bool {
+	return dc.Spec.CertManager != nil && dc.Spec.ManagementApiAuth.Manual == nil && dc.Spec.ManagementApiAuth.Insecure == nil
+}
+
+// GetServiceAccountName returns the name of the service account of the server pods
+func (dc *CassandraDatacenter) GetServiceAccountName() string {
+	if dc.Spec.ServiceAccount != "" {
+		return dc.Spec.ServiceAccount
+	}
+	if dc.Spec.CreateServiceAccount {
+		return dc.GetServiceAccountRoleName()
+	}
+	return "default"
+}
+
+// GetServiceAccountRoleName returns the name of the Role and RoleBinding created for the service account,
+// which is per datacenter even when several datacenters share the service account
+func (dc *CassandraDatacenter) GetServiceAccountRoleName() string {
+	return CleanupForKubernetes(dc.Spec.ClusterName) + "-" + dc.Name + "-service-account"
+}
+
 func (dc *CassandraDatacenter) GetSeedServiceName() string {
 	return CleanupForKubernetes(dc.Spec.ClusterName) + "-seed-service"
 }
@@ -603,6 +1569,19 @@ func (dc *CassandraDatacenter) GetNodePortServiceName() string {
 	return CleanupForKubernetes(dc.Spec.ClusterName) + "-" + dc.Name + "-node-port-service"
 }
 
+func (dc *CassandraDatacenter) GetSeedExportServiceName() string {
+	return CleanupForKubernetes(dc.Spec.ClusterName) + "-" + dc.Name + "-seed-export-service"
+}
+
+// GetPodServiceName returns the name of the Service of a pod exposed by a LoadBalancer podExposure
+func (dc *CassandraDatacenter) GetPodServiceName(podName string) string {
+	return podName + "-service"
+}
+
+func (dc *CassandraDatacenter) GetBroadcastAddressesConfigMapName() string {
+	return CleanupForKubernetes(dc.Spec.ClusterName) + "-" + dc.Name + "-broadcast-addresses"
+}
+
 func (dc *CassandraDatacenter) ShouldGenerateSuperuserSecret() bool {
 	return len(dc.Spec.SuperuserSecretName) == 0
 }
@@ -620,8 +1599,19 @@ func (dc *CassandraDatacenter) GetSuperuserSecretNamespacedName() types.Namespac
 	}
 }
 
-// GetConfigAsJSON gets a JSON-encoded string suitable for passing to configBuilder
+// GetConfigAsJSON gets a JSON-encoded string suitable for passing to configBuilder, with the DSE workloads
+// of the datacenter
 func (dc *CassandraDatacenter) GetConfigAsJSON(config []byte) (string, error) {
+	return dc.getConfigAsJSON(dc.Spec.DseWorkloads, config)
+}
+
+// GetRackConfigAsJSON gets the JSON-encoded string passed to the configBuilder of the pods of the rack,
+// with the DSE workloads of the rack
+func (dc *CassandraDatacenter) GetRackConfigAsJSON(rackName string, config []byte) (string, error) {
+	return dc.getConfigAsJSON(dc.GetRackDseWorkloads(rackName), config)
+}
+
+func (dc *CassandraDatacenter) getConfigAsJSON(workloads *DseWorkloads, config []byte) (string, error) {
 
 	// We use the cluster seed-service name here for the seed list as it will
 	// resolve to the seed nodes. This obviates the need to update the
@@ -632,14 +1622,14 @@ func (dc *CassandraDatacenter) GetConfigAsJSON(config []byte) (string, error) {
 	solrEnabled := 0
 	sparkEnabled := 0
 
-	if dc.Spec.ServerType == "dse" && dc.Spec.DseWorkloads != nil {
-		if dc.Spec.DseWorkloads.AnalyticsEnabled {
+	if dc.Spec.ServerType == "dse" && workloads != nil {
+		if workloads.AnalyticsEnabled {
 			sparkEnabled = 1
 		}
-		if dc.Spec.DseWorkloads.GraphEnabled {
+		if workloads.GraphEnabled {
 			graphEnabled = 1
 		}
-		if dc.Spec.DseWorkloads.SearchEnabled {
+		if workloads.SearchEnabled {
 			solrEnabled = 1
 		}
 	}
@@ -658,7 +1648,7 @@ func (dc *CassandraDatacenter) GetConfigAsJSON(config []byte) (string, error) {
 	modelValues := serverconfig.GetModelValues(
 		seeds,
 		dc.Spec.ClusterName,
-		dc.Name,
+		dc.DatacenterName(),
 		graphEnabled,
 		solrEnabled,
 		sparkEnabled,
@@ -691,9 +1681,193 @@ func (dc *CassandraDatacenter) GetConfigAsJSON(config []byte) (string, error) {
 		}
 	}
 
+	if err := dc.addJvmOptions(modelParsed); err != nil {
+		return "", errors.Wrap(err, "Error adding Spec.JvmOptions for CassandraDatacenter resource")
+	}
+
+	if err := dc.addConsistentRangeMovementOption(modelParsed); err != nil {
+		return "", errors.Wrap(err, "Error adding Spec.MaxNodesInFlight for CassandraDatacenter resource")
+	}
+
+	if err := dc.addStorageDirectories(modelParsed); err != nil {
+		return "", errors.Wrap(err, "Error adding Spec.StorageConfig for CassandraDatacenter resource")
+	}
+
+	if err := dc.addServerEncryptionOptions(modelParsed); err != nil {
+		return "", errors.Wrap(err, "Error adding Spec.InternodeEncryption for CassandraDatacenter resource")
+	}
+
+	if err := dc.addClientEncryptionOptions(modelParsed); err != nil {
+		return "", errors.Wrap(err, "Error adding Spec.ClientEncryption for CassandraDatacenter resource")
+	}
+
 	return modelParsed.String(), nil
 }
 
+// addJvmOptions renders the JvmOptions of the spec into the config sections read by the config builder
+func (dc *CassandraDatacenter) addJvmOptions(config *gabs.Container) error {
+	opts := dc.Spec.JvmOptions
+	if opts == nil {
+		return nil
+	}
+
+	heapSection := "jvm-server-options"
+	var gcSection string
+	switch {
+	case dc.Spec.ServerType == "dse":
+		gcSection = "jvm8-server-options"
+	case strings.HasPrefix(dc.Spec.ServerVersion, "3."):
+		heapSection = "jvm-options"
+		gcSection = "jvm-options"
+	default:
+		gcSection = "jvm11-server-options"
+	}
+
+	if opts.HeapSize != nil {
+		heapSize := toMegabytes(*opts.HeapSize)
+		if _, err := config.Set(heapSize, heapSection, "initial_heap_size"); err != nil {
+			return err
+		}
+		if _, err := config.Set(heapSize, heapSection, "max_heap_size"); err != nil {
+			return err
+		}
+	}
+
+	if opts.HeapNewGenSize != nil {
+		if _, err := config.Set(toMegabytes(*opts.HeapNewGenSize), heapSection, "heap_size_young_generation"); err != nil {
+			return err
+		}
+	}
+
+	if opts.GarbageCollector != "" {
+		if _, err := config.Set(opts.GarbageCollector, gcSection, "garbage_collector"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// additionalJvmOptsSection returns the config section holding the additional-jvm-opts of the server
+func (dc *CassandraDatacenter) additionalJvmOptsSection() string {
+	if dc.Spec.ServerType == "cassandra" && strings.HasPrefix(dc.Spec.ServerVersion, "3.") {
+		return "jvm-options"
+	}
+	return "jvm-server-options"
+}
+
+// GetConsistentRangeMovementOption returns the cassandra.consistent.rangemovement option set in the
+// additional-jvm-opts of Spec.Config, or an empty string if it is not set
+func (dc *CassandraDatacenter) GetConsistentRangeMovementOption() string {
+	config, err := gabs.ParseJSON(dc.Spec.Config)
+	if err != nil {
+		return ""
+	}
+	return dc.findConsistentRangeMovementOption(config)
+}
+
+func (dc *CassandraDatacenter) findConsistentRangeMovementOption(config *gabs.Container) string {
+	opts, _ := config.Search(dc.additionalJvmOptsSection(), "additional-jvm-opts").Data().([]interface{})
+	for _, opt := range opts {
+		if s, ok := opt.(string); ok && strings.HasPrefix(s, ConsistentRangeMovementOption) {
+			return s
+		}
+	}
+	return ""
+}
+
+// addConsistentRangeMovementOption disables the consistent range movements when several nodes can bootstrap
+// at the same time, Cassandra would otherwise refuse to start a node while another one is joining the ring.
+// An option set by the user is left as is.
+func (dc *CassandraDatacenter) addConsistentRangeMovementOption(config *gabs.Container) error {
+	if dc.Spec.MaxNodesInFlight <= 1 || dc.findConsistentRangeMovementOption(config) != "" {
+		return nil
+	}
+
+	return config.ArrayAppend(ConsistentRangeMovementOption+"=false", dc.additionalJvmOptsSection(), "additional-jvm-opts")
+}
+
+// addStorageDirectories points the commit log and saved caches of the server at their own volumes when
+// the StorageConfig has a claim for them
+func (dc *CassandraDatacenter) addStorageDirectories(config *gabs.Container) error {
+	if dc.Spec.StorageConfig.CommitLogVolumeClaimSpec != nil {
+		if _, err := config.Set(CommitLogDir, "cassandra-yaml", "commitlog_directory"); err != nil {
+			return err
+		}
+	}
+
+	if dc.Spec.StorageConfig.SavedCachesVolumeClaimSpec != nil {
+		if _, err := config.Set(SavedCachesDir, "cassandra-yaml", "saved_caches_directory"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetCommitLogDirectory returns the commit log directory of the server: the one of the commit log claim,
+// else the commitlog_directory of Spec.Config, else the default one on the server data volume
+func (dc *CassandraDatacenter) GetCommitLogDirectory() string {
+	if dc.Spec.StorageConfig.CommitLogVolumeClaimSpec != nil {
+		return CommitLogDir
+	}
+
+	if config, err := gabs.ParseJSON(dc.Spec.Config); err == nil {
+		if dir, ok := config.Search("cassandra-yaml", "commitlog_directory").Data().(string); ok && dir != "" {
+			return dir
+		}
+	}
+
+	return DefaultCommitLogDir
+}
+
+// addServerEncryptionOptions renders the server_encryption_options pointing at the keystores generated
+// by the operator when InternodeEncryption is enabled
+func (dc *CassandraDatacenter) addServerEncryptionOptions(config *gabs.Container) error {
+	if !dc.Spec.InternodeEncryption {
+		return nil
+	}
+
+	options := map[string]interface{}{
+		"internode_encryption": "all",
+		"keystore":             InternodeKeystorePath,
+		"keystore_password":    dc.GetKeystorePassword(),
+		"truststore":           InternodeTruststorePath,
+		"truststore_password":  dc.GetKeystorePassword(),
+		"require_client_auth":  true,
+	}
+	_, err := config.Set(options, "cassandra-yaml", "server_encryption_options")
+	return err
+}
+
+// addClientEncryptionOptions renders the client_encryption_options pointing at the keystore prepared by the
+// operator when ClientEncryption is set
+func (dc *CassandraDatacenter) addClientEncryptionOptions(config *gabs.Container) error {
+	if dc.Spec.ClientEncryption == nil {
+		return nil
+	}
+
+	password := dc.GetKeystorePassword()
+	if dc.Spec.ClientEncryption.KeystoreSecretName != "" {
+		password = ClientKeystorePasswordPlaceholder
+	}
+	options := map[string]interface{}{
+		"enabled":           true,
+		"optional":          false,
+		"keystore":          ClientKeystorePath,
+		"keystore_password": password,
+	}
+	_, err := config.Set(options, "cassandra-yaml", "client_encryption_options")
+	return err
+}
+
+// toMegabytes formats a quantity the way the JVM size settings expect it, e.g. 1024M. Sizes which are not
+// a whole number of megabytes are rounded up, so that a size below 1Mi is not rendered as 0M.
+func toMegabytes(q resource.Quantity) string {
+	const megabyte = 1024 * 1024
+	return fmt.Sprintf("%dM", (q.Value()+megabyte-1)/megabyte)
+}
+
 // GetNodePortNativePort
 // Gets the defined CQL port for NodePort.
 // 0 will be returned if NodePort is not configured.
@@ -762,8 +1936,8 @@ func (dc *CassandraDatacenter) GetContainerPorts() ([]corev1.ContainerPort, erro
 		)
 	}
 
-	if dc.Spec.DseWorkloads != nil {
-		if dc.Spec.DseWorkloads.AnalyticsEnabled {
+	if workloads := dc.GetAllDseWorkloads(); workloads != nil {
+		if workloads.AnalyticsEnabled {
 			ports = append(
 				ports,
 				namedPort("spark-app-4040", 4040),
@@ -790,14 +1964,14 @@ func (dc *CassandraDatacenter) GetContainerPorts() ([]corev1.ContainerPort, erro
 			)
 		}
 
-		if dc.Spec.DseWorkloads.GraphEnabled {
+		if workloads.GraphEnabled {
 			ports = append(
 				ports,
 				namedPort("gremlin", 8182),
 			)
 		}
 
-		if dc.Spec.DseWorkloads.SearchEnabled {
+		if workloads.SearchEnabled {
 			ports = append(
 				ports,
 				namedPort("solr", 8983),
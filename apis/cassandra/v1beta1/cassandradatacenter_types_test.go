@@ -0,0 +1,286 @@
+package v1beta1
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestGetConfigAsJSONWithJvmOptions(t *testing.T) {
+	heapSize := resource.MustParse("2Gi")
+	newGenSize := resource.MustParse("512Mi")
+
+	tests := []struct {
+		name          string
+		serverType    string
+		serverVersion string
+		heapSection   string
+		gcSection     string
+	}{
+		{"DSE", "dse", "6.8.4", "jvm-server-options", "jvm8-server-options"},
+		{"Cassandra 3.11", "cassandra", "3.11.11", "jvm-options", "jvm-options"},
+		{"Cassandra 4.0", "cassandra", "4.0.1", "jvm-server-options", "jvm11-server-options"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := &CassandraDatacenter{
+				Spec: CassandraDatacenterSpec{
+					ClusterName:   "cluster1",
+					ServerType:    tt.serverType,
+					ServerVersion: tt.serverVersion,
+					JvmOptions: &JvmOptions{
+						HeapSize:         &heapSize,
+						HeapNewGenSize:   &newGenSize,
+						GarbageCollector: "G1GC",
+					},
+				},
+			}
+
+			// The spec settings take precedence over the raw config
+			configJson, err := dc.GetConfigAsJSON([]byte(`{"` + tt.heapSection + `": {"max_heap_size": "1024M"}}`))
+			assert.NoError(t, err)
+
+			var config map[string]map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(configJson), &config))
+			assert.Equal(t, "2048M", config[tt.heapSection]["initial_heap_size"])
+			assert.Equal(t, "2048M", config[tt.heapSection]["max_heap_size"])
+			assert.Equal(t, "512M", config[tt.heapSection]["heap_size_young_generation"])
+			assert.Equal(t, "G1GC", config[tt.gcSection]["garbage_collector"])
+		})
+	}
+}
+
+func TestGetConfigAsJSONWithMaxNodesInFlight(t *testing.T) {
+	tests := []struct {
+		name          string
+		serverType    string
+		serverVersion string
+		section       string
+	}{
+		{"DSE", "dse", "6.8.4", "jvm-server-options"},
+		{"Cassandra 3.11", "cassandra", "3.11.11", "jvm-options"},
+		{"Cassandra 4.0", "cassandra", "4.0.1", "jvm-server-options"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := &CassandraDatacenter{
+				Spec: CassandraDatacenterSpec{
+					ClusterName:      "cluster1",
+					ServerType:       tt.serverType,
+					ServerVersion:    tt.serverVersion,
+					MaxNodesInFlight: 3,
+				},
+			}
+
+			var config map[string]map[string]interface{}
+			configJson, err := dc.GetConfigAsJSON([]byte(`{"` + tt.section + `": {"additional-jvm-opts": ["-Dfoo=bar"]}}`))
+			assert.NoError(t, err)
+			assert.NoError(t, json.Unmarshal([]byte(configJson), &config))
+			assert.Equal(t, []interface{}{"-Dfoo=bar", "-Dcassandra.consistent.rangemovement=false"}, config[tt.section]["additional-jvm-opts"])
+
+			configJson, err = dc.GetConfigAsJSON(nil)
+			assert.NoError(t, err)
+			assert.NoError(t, json.Unmarshal([]byte(configJson), &config))
+			assert.Equal(t, []interface{}{"-Dcassandra.consistent.rangemovement=false"}, config[tt.section]["additional-jvm-opts"])
+
+			// Only crossing 1 changes the config, and restarts the nodes
+			dc.Spec.MaxNodesInFlight = 2
+			otherConfigJson, err := dc.GetConfigAsJSON(nil)
+			assert.NoError(t, err)
+			assert.Equal(t, configJson, otherConfigJson)
+			dc.Spec.MaxNodesInFlight = 3
+
+			// An option set by the user is left as is
+			configJson, err = dc.GetConfigAsJSON([]byte(`{"` + tt.section + `": {"additional-jvm-opts": ["-Dcassandra.consistent.rangemovement=true"]}}`))
+			assert.NoError(t, err)
+			assert.NoError(t, json.Unmarshal([]byte(configJson), &config))
+			assert.Equal(t, []interface{}{"-Dcassandra.consistent.rangemovement=true"}, config[tt.section]["additional-jvm-opts"])
+
+			dc.Spec.MaxNodesInFlight = 1
+			configJson, err = dc.GetConfigAsJSON(nil)
+			assert.NoError(t, err)
+			assert.NotContains(t, configJson, "consistent.rangemovement")
+		})
+	}
+}
+
+func TestToMegabytes(t *testing.T) {
+	assert.Equal(t, "2048M", toMegabytes(resource.MustParse("2Gi")))
+	assert.Equal(t, "1M", toMegabytes(resource.MustParse("512Ki")))
+	assert.Equal(t, "2M", toMegabytes(resource.MustParse("1500Ki")))
+}
+
+func TestGetConfigAsJSONWithInternodeEncryption(t *testing.T) {
+	dc := &CassandraDatacenter{
+		Spec: CassandraDatacenterSpec{
+			ClusterName:         "cluster1",
+			ServerType:          "cassandra",
+			ServerVersion:       "4.0.1",
+			InternodeEncryption: true,
+		},
+	}
+	dc.Name = "dc1"
+
+	configJson, err := dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+
+	var config map[string]map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(configJson), &config))
+	assert.Equal(t, map[string]interface{}{
+		"internode_encryption": "all",
+		"keystore":             "/etc/internode-encryption/keystore.jks",
+		"keystore_password":    "dc1",
+		"truststore":           "/etc/internode-encryption/truststore.jks",
+		"truststore_password":  "dc1",
+		"require_client_auth":  true,
+	}, config["cassandra-yaml"]["server_encryption_options"])
+
+	dc.Spec.InternodeEncryption = false
+	configJson, err = dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, configJson, "server_encryption_options")
+}
+
+func TestGetConfigAsJSONWithClientEncryption(t *testing.T) {
+	dc := &CassandraDatacenter{
+		Spec: CassandraDatacenterSpec{
+			ClusterName:      "cluster1",
+			ServerType:       "cassandra",
+			ServerVersion:    "4.0.1",
+			ClientEncryption: &ClientEncryptionSpec{KeystoreSecretName: "my-keystore"},
+		},
+	}
+	dc.Name = "dc1"
+
+	configJson, err := dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+
+	var config map[string]map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(configJson), &config))
+	assert.Equal(t, map[string]interface{}{
+		"enabled":           true,
+		"optional":          false,
+		"keystore":          "/etc/client-encryption/keystore.jks",
+		"keystore_password": ClientKeystorePasswordPlaceholder,
+	}, config["cassandra-yaml"]["client_encryption_options"])
+	assert.NotContains(t, configJson, "my-keystore")
+
+	// The generated keystores are protected by the password of the keystores of the operator
+	dc.Spec.ClientEncryption.KeystoreSecretName = ""
+	configJson, err = dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal([]byte(configJson), &config))
+	assert.Equal(t, "dc1", config["cassandra-yaml"]["client_encryption_options"].(map[string]interface{})["keystore_password"])
+}
+
+func TestGetConfigAsJSONWithStorageDirectories(t *testing.T) {
+	dc := &CassandraDatacenter{
+		Spec: CassandraDatacenterSpec{
+			ClusterName:   "cluster1",
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.1",
+			StorageConfig: StorageConfig{
+				CommitLogVolumeClaimSpec:   &corev1.PersistentVolumeClaimSpec{},
+				SavedCachesVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{},
+			},
+		},
+	}
+
+	configJson, err := dc.GetConfigAsJSON([]byte(`{"cassandra-yaml": {"commitlog_directory": "/var/lib/cassandra/commitlog"}}`))
+	assert.NoError(t, err)
+
+	var config map[string]map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(configJson), &config))
+	assert.Equal(t, "/var/lib/cassandra-commitlog", config["cassandra-yaml"]["commitlog_directory"])
+	assert.Equal(t, "/var/lib/cassandra-saved-caches", config["cassandra-yaml"]["saved_caches_directory"])
+
+	dc.Spec.StorageConfig = StorageConfig{}
+	configJson, err = dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, configJson, "commitlog_directory")
+	assert.NotContains(t, configJson, "saved_caches_directory")
+}
+
+func TestGetCommitLogDirectory(t *testing.T) {
+	dc := &CassandraDatacenter{}
+	assert.Equal(t, "/var/lib/cassandra/commitlog", dc.GetCommitLogDirectory())
+
+	dc.Spec.Config = []byte(`{"cassandra-yaml": {"commitlog_directory": "/commitlog"}}`)
+	assert.Equal(t, "/commitlog", dc.GetCommitLogDirectory())
+
+	dc.Spec.StorageConfig.CommitLogVolumeClaimSpec = &corev1.PersistentVolumeClaimSpec{}
+	assert.Equal(t, "/var/lib/cassandra-commitlog", dc.GetCommitLogDirectory())
+}
+
+func TestGetRackDseWorkloads(t *testing.T) {
+	dc := &CassandraDatacenter{
+		Spec: CassandraDatacenterSpec{
+			ServerType:    "dse",
+			ServerVersion: "6.8.4",
+			DseWorkloads:  &DseWorkloads{SearchEnabled: true},
+			Racks: []Rack{
+				{Name: "rack1"},
+				{Name: "rack2", DseWorkloads: &DseWorkloads{AnalyticsEnabled: true, LabelPods: true}},
+			},
+		},
+	}
+
+	assert.Equal(t, &DseWorkloads{SearchEnabled: true}, dc.GetRackDseWorkloads("rack1"))
+	assert.Equal(t, &DseWorkloads{AnalyticsEnabled: true, LabelPods: true}, dc.GetRackDseWorkloads("rack2"))
+	assert.Equal(t, &DseWorkloads{AnalyticsEnabled: true, SearchEnabled: true}, dc.GetAllDseWorkloads())
+	assert.Equal(t, "Analytics", dc.GetRackDseWorkloads("rack2").GetLabelValue())
+	assert.Equal(t, "Analytics-Search", dc.GetAllDseWorkloads().GetLabelValue())
+
+	// Each rack is configured with its own workloads
+	configJson, err := dc.GetRackConfigAsJSON("rack1", nil)
+	assert.NoError(t, err)
+	assert.Contains(t, configJson, `"solr-enabled":1`)
+	assert.Contains(t, configJson, `"spark-enabled":0`)
+	assert.Contains(t, configJson, `"graph-enabled":0`)
+
+	configJson, err = dc.GetRackConfigAsJSON("rack2", nil)
+	assert.NoError(t, err)
+	assert.Contains(t, configJson, `"solr-enabled":0`)
+	assert.Contains(t, configJson, `"spark-enabled":1`)
+
+	dc.Spec.DseWorkloads = nil
+	dc.Spec.Racks[1].DseWorkloads = nil
+	assert.Nil(t, dc.GetAllDseWorkloads())
+	assert.Equal(t, "Cassandra", dc.GetAllDseWorkloads().GetLabelValue())
+}
+
+func TestDatacenterName(t *testing.T) {
+	dc := &CassandraDatacenter{
+		Spec: CassandraDatacenterSpec{
+			ClusterName:   "cluster1",
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.1",
+		},
+	}
+	dc.Name = "dc1"
+	assert.Equal(t, "dc1", dc.DatacenterName())
+
+	dc.Spec.DatacenterName = "Main_DC"
+	assert.Equal(t, "Main_DC", dc.DatacenterName())
+	assert.Equal(t, "dc1", dc.GetDatacenterLabels()[DatacenterLabel])
+	assert.Equal(t, "cluster1-dc1-service", dc.GetDatacenterServiceName())
+
+	configJson, err := dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+	var config map[string]map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(configJson), &config))
+	assert.Equal(t, "Main_DC", config["datacenter-info"]["name"])
+}
+
+func TestCleanLabelValue(t *testing.T) {
+	assert.Equal(t, "MyCluster", CleanLabelValue("My Cluster"))
+	assert.Equal(t, "cluster_1.test", CleanLabelValue("cluster_1.test"))
+	assert.Equal(t, "cluster1", CleanLabelValue("-cluster1!"))
+
+	long := CleanLabelValue(strings.Repeat("a", 62) + "-" + strings.Repeat("b", 10))
+	assert.Equal(t, strings.Repeat("a", 62), long)
+}
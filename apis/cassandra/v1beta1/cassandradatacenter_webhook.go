@@ -21,10 +21,13 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/k8ssandra/cass-operator/pkg/images"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -44,14 +47,18 @@ func (dc *CassandraDatacenter) SetupWebhookWithManager(mgr ctrl.Manager) error {
 		Complete()
 }
 
-// kubebuilder:webhook:path=/mutate-cassandra-datastax-com-v1beta1-cassandradatacenter,mutating=true,failurePolicy=fail,sideEffects=None,groups=cassandra.datastax.com,resources=cassandradatacenters,verbs=create;update,versions=v1beta1,name=mcassandradatacenter.kb.io,admissionReviewVersions={v1,v1beta1}
+// +kubebuilder:webhook:path=/mutate-cassandra-datastax-com-v1beta1-cassandradatacenter,mutating=true,failurePolicy=fail,sideEffects=None,groups=cassandra.datastax.com,resources=cassandradatacenters,verbs=create;update,versions=v1beta1,name=mcassandradatacenter.kb.io,admissionReviewVersions={v1,v1beta1}
 // +kubebuilder:webhook:path=/validate-cassandra-datastax-com-v1beta1-cassandradatacenter,mutating=false,failurePolicy=fail,sideEffects=None,groups=cassandra.datastax.com,resources=cassandradatacenters,verbs=create;update,versions=v1beta1,name=vcassandradatacenter.kb.io,admissionReviewVersions={v1,v1beta1}
 
 var _ webhook.Defaulter = &CassandraDatacenter{}
 
 // Default implements webhook.Defaulter so a webhook will be registered for the type
 func (dc *CassandraDatacenter) Default() {
-	// No mutations at this point
+	// Make the implicit default rack explicit, GetRacks() still handles Datacenters created
+	// without the webhook
+	if len(dc.Spec.Racks) == 0 {
+		dc.Spec.Racks = dc.GetRacks()
+	}
 }
 
 func attemptedTo(action string, actionStrArgs ...interface{}) error {
@@ -74,9 +81,15 @@ func ValidateSingleDatacenter(dc CassandraDatacenter) error {
 		}
 	}
 
-	if dc.Spec.ServerType == "cassandra" && dc.Spec.DseWorkloads != nil {
-		if dc.Spec.DseWorkloads.AnalyticsEnabled || dc.Spec.DseWorkloads.GraphEnabled || dc.Spec.DseWorkloads.SearchEnabled {
-			return attemptedTo("enable DSE workloads if server type is Cassandra")
+	if dc.Spec.ServerType == "cassandra" && dc.GetAllDseWorkloads().IsEnabled() {
+		return attemptedTo("enable DSE workloads if server type is Cassandra")
+	}
+
+	if len(dc.Spec.ConfigSecret) > 0 {
+		for _, rack := range dc.Spec.Racks {
+			if rack.DseWorkloads != nil {
+				return attemptedTo("set the DSE workloads of rack %s with a configSecret, which configures all the racks", rack.Name)
+			}
 		}
 	}
 
@@ -119,13 +132,224 @@ func ValidateSingleDatacenter(dc CassandraDatacenter) error {
 		}
 	}
 
+	if ephemeral := dc.Spec.StorageConfig.EphemeralDataVolume; ephemeral != nil {
+		if dc.Spec.StorageConfig.CassandraDataVolumeClaimSpec != nil {
+			return attemptedTo("use both cassandraDataVolumeClaimSpec and ephemeralDataVolume in storageConfig")
+		}
+		if (ephemeral.EmptyDir == nil) == (ephemeral.Ephemeral == nil) {
+			return attemptedTo("use an ephemeralDataVolume without exactly one of emptyDir and ephemeral")
+		}
+		if dc.Spec.StorageConfig.DataSourceBackup != "" {
+			return attemptedTo("restore an ephemeralDataVolume from the dataSourceBackup in storageConfig")
+		}
+	}
+
+	if dc.Spec.SnapshotOnDelete {
+		if dc.IsEphemeralStorageEnabled() {
+			return attemptedTo("take a snapshotOnDelete of an ephemeralDataVolume")
+		}
+		if dc.GetPVCReclaimPolicy() != PVCReclaimPolicyRetain {
+			// the snapshots would be deleted with the volumes
+			return attemptedTo("take a snapshotOnDelete without the Retain reclaimPolicy in storageConfig")
+		}
+	}
+
+	if maxNodeDataSize := dc.Spec.MaxNodeDataSize; maxNodeDataSize != nil && maxNodeDataSize.Sign() <= 0 {
+		return attemptedTo("set a maxNodeDataSize that is not positive")
+	}
+
+	if dc.Spec.MaxNodesInFlight < 0 {
+		return attemptedTo("set a negative maxNodesInFlight")
+	}
+
+	if dc.Spec.MaxNodesInFlight > 1 && dc.GetConsistentRangeMovementOption() == ConsistentRangeMovementOption+"=true" {
+		return attemptedTo("use a maxNodesInFlight above 1 with %s=true", ConsistentRangeMovementOption)
+	}
+
+	if dc.Spec.OnDeleteUpdates && (dc.Spec.CanaryUpgrade || dc.Spec.PartitionedUpdates) {
+		return attemptedTo("use onDeleteUpdates with canaryUpgrade or partitionedUpdates")
+	}
+
+	if pdb := dc.Spec.PodDisruptionBudget; pdb != nil && pdb.MinAvailable != nil && pdb.MaxUnavailable != nil {
+		return attemptedTo("set both minAvailable and maxUnavailable in podDisruptionBudget")
+	}
+
+	// every rack needs at least one node
+	if len(dc.Spec.Racks) > int(dc.Spec.Size) {
+		return attemptedTo("use %d racks with a size of %d, the size can not be smaller than the number of racks", len(dc.Spec.Racks), dc.Spec.Size)
+	}
+
+	// a misspelled rack would be silently ignored, and the forced upgrade never happen
+	for _, rackName := range dc.Spec.ForceUpgradeRacks {
+		found := false
+		for _, rack := range dc.GetRacks() {
+			if rack.Name == rackName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return attemptedTo("force the upgrade of unknown rack '%s'", rackName)
+		}
+	}
+
+	if networking := dc.Spec.Networking; networking != nil && networking.SeedExport != nil {
+		seedExport := networking.SeedExport
+		if seedExport.Type == SeedExportStatic && len(seedExport.Addresses) == 0 {
+			return attemptedTo("export the seeds with the Static type without addresses")
+		}
+		if seedExport.Type != SeedExportStatic && len(seedExport.Addresses) > 0 {
+			return attemptedTo("set the addresses of the seed export with the %s type", seedExport.Type)
+		}
+		if seedExport.Type == SeedExportLoadBalancer && dc.Spec.ManagedSeedEndpoints {
+			// the service of the seed export selects the seed pods by their label
+			return attemptedTo("export the seeds with the LoadBalancer type along with managedSeedEndpoints")
+		}
+	}
+
+	if networking := dc.Spec.Networking; networking != nil && networking.PodExposure != nil {
+		if networking.NodePort != nil {
+			return attemptedTo("expose the pods with podExposure along with nodePort")
+		}
+		if networking.HostNetwork {
+			return attemptedTo("expose the pods with podExposure along with hostNetwork")
+		}
+	}
+
+	if networking := dc.Spec.Networking; networking != nil && networking.BroadcastAddress == BroadcastAddressHostIP {
+		// nothing would listen on the ports of the worker the nodes broadcast
+		if !networking.HostNetwork && networking.NodePort == nil && !dc.IsPodExposureEnabled(PodExposureHostPort) {
+			return attemptedTo("broadcast the IP of the worker without hostNetwork, nodePort or a HostPort podExposure")
+		}
+	}
+
+	if networking := dc.Spec.Networking; networking != nil && networking.BroadcastTemplate != nil {
+		if networking.PodExposure != nil && networking.PodExposure.Type == PodExposureLoadBalancer {
+			return attemptedTo("render the broadcast addresses with broadcastTemplate along with a LoadBalancer podExposure")
+		}
+		for _, text := range []string{networking.BroadcastTemplate.Address, networking.BroadcastTemplate.RPCAddress} {
+			if _, err := template.New("broadcast").Parse(text); err != nil {
+				return attemptedTo("use an invalid broadcastTemplate: %s", err)
+			}
+		}
+	}
+
 	if err := ValidateServiceLabelsAndAnnotations(dc); err != nil {
 		return err
 	}
 
+	if err := ValidateAdditionalVolumes(dc); err != nil {
+		return err
+	}
+
+	if err := ValidateResources(dc); err != nil {
+		return err
+	}
+
 	return ValidateFQLConfig(dc)
 }
 
+// validateStorageConfigChanges only lets the storage request of the volume claims grow, the
+// storageClassName of the server data volumes change to migrate them, and the reclaimPolicy
+// change, any other StorageConfig change is rejected.
+func validateStorageConfigChanges(oldConfig StorageConfig, newConfig StorageConfig) error {
+	// Compare the rest of the StorageConfig as if the reclaimPolicy had not changed
+	oldConfig = *oldConfig.DeepCopy()
+	oldConfig.ReclaimPolicy = newConfig.ReclaimPolicy
+
+	oldClaim := oldConfig.CassandraDataVolumeClaimSpec
+	newClaim := newConfig.CassandraDataVolumeClaimSpec
+	if oldClaim != nil && newClaim != nil && newClaim.StorageClassName != nil {
+		// The nodes are migrated to volumes of the new StorageClass one at a time
+		oldClaim.StorageClassName = newClaim.StorageClassName
+	}
+
+	ignoreStorageGrowth(oldConfig.CassandraDataVolumeClaimSpec, newConfig.CassandraDataVolumeClaimSpec)
+	ignoreStorageGrowth(oldConfig.CommitLogVolumeClaimSpec, newConfig.CommitLogVolumeClaimSpec)
+	ignoreStorageGrowth(oldConfig.SavedCachesVolumeClaimSpec, newConfig.SavedCachesVolumeClaimSpec)
+	for i := range oldConfig.AdditionalVolumes {
+		for j := range newConfig.AdditionalVolumes {
+			if oldConfig.AdditionalVolumes[i].Name == newConfig.AdditionalVolumes[j].Name {
+				ignoreStorageGrowth(&oldConfig.AdditionalVolumes[i].PVCSpec, &newConfig.AdditionalVolumes[j].PVCSpec)
+			}
+		}
+	}
+
+	if !reflect.DeepEqual(oldConfig, newConfig) {
+		return attemptedTo("change storageConfig")
+	}
+
+	return nil
+}
+
+// ignoreStorageGrowth sets the storage request of oldClaim to the one of newClaim when it grows, so
+// that the rest of the claims can be compared as if the size had not changed
+func ignoreStorageGrowth(oldClaim *corev1.PersistentVolumeClaimSpec, newClaim *corev1.PersistentVolumeClaimSpec) {
+	if oldClaim == nil || newClaim == nil {
+		return
+	}
+
+	oldSize := oldClaim.Resources.Requests[corev1.ResourceStorage]
+	newSize, found := newClaim.Resources.Requests[corev1.ResourceStorage]
+	if found && newSize.Cmp(oldSize) > 0 {
+		if oldClaim.Resources.Requests == nil {
+			oldClaim.Resources.Requests = corev1.ResourceList{}
+		}
+		oldClaim.Resources.Requests[corev1.ResourceStorage] = newSize
+	}
+}
+
+// ValidateServerVersionUpgrade checks that the nodes running the from version can be upgraded to the to
+// version. A node does not start on the data written by a later version, and only reads the sstables of
+// the previous major version, so downgrades and upgrades skipping a major version are rejected.
+func ValidateServerVersionUpgrade(from string, to string) error {
+	if from == "" || from == to {
+		return nil
+	}
+
+	// Malformed versions are rejected by ValidateSingleDatacenter
+	fromVersion, err := parseServerVersion(from)
+	if err != nil {
+		return nil
+	}
+	toVersion, err := parseServerVersion(to)
+	if err != nil {
+		return nil
+	}
+
+	for i := range fromVersion {
+		if toVersion[i] < fromVersion[i] {
+			return attemptedTo("downgrade serverVersion from %s to %s", from, to)
+		}
+		if toVersion[i] > fromVersion[i] {
+			break
+		}
+	}
+
+	if toVersion[0] > fromVersion[0]+1 {
+		return attemptedTo("upgrade serverVersion from %s to %s, skipping major version %d", from, to, fromVersion[0]+1)
+	}
+
+	return nil
+}
+
+// parseServerVersion returns the major, minor and patch numbers of a server version
+func parseServerVersion(version string) ([3]int, error) {
+	var numbers [3]int
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) != 3 {
+		return numbers, fmt.Errorf("invalid server version %s", version)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return numbers, fmt.Errorf("invalid server version %s", version)
+		}
+		numbers[i] = n
+	}
+	return numbers, nil
+}
+
 // ValidateDatacenterFieldChanges checks that no values are improperly changing while updating
 // a CassandraDatacenter
 func ValidateDatacenterFieldChanges(oldDc CassandraDatacenter, newDc CassandraDatacenter) error {
@@ -134,6 +358,24 @@ func ValidateDatacenterFieldChanges(oldDc CassandraDatacenter, newDc CassandraDa
 		return attemptedTo("change clusterName")
 	}
 
+	if oldDc.DatacenterName() != newDc.DatacenterName() {
+		return attemptedTo("change datacenterName")
+	}
+
+	if oldDc.Spec.ServerType != newDc.Spec.ServerType {
+		return attemptedTo("change serverType")
+	}
+
+	// The version the nodes run, which may still be an earlier one while the previous upgrade rolls out,
+	// or that was never rolled out. The spec is only used until the nodes reported their version.
+	runningVersion := oldDc.Status.ServerVersion
+	if runningVersion == "" {
+		runningVersion = oldDc.Spec.ServerVersion
+	}
+	if err := ValidateServerVersionUpgrade(runningVersion, newDc.Spec.ServerVersion); err != nil {
+		return err
+	}
+
 	if oldDc.Spec.AllowMultipleNodesPerWorker != newDc.Spec.AllowMultipleNodesPerWorker {
 		return attemptedTo("change allowMultipleNodesPerWorker")
 	}
@@ -142,13 +384,13 @@ func ValidateDatacenterFieldChanges(oldDc CassandraDatacenter, newDc CassandraDa
 		return attemptedTo("change superuserSecretName")
 	}
 
-	if oldDc.Spec.ServiceAccount != newDc.Spec.ServiceAccount {
+	if oldDc.GetServiceAccountName() != newDc.GetServiceAccountName() {
 		return attemptedTo("change serviceAccount")
 	}
 
-	// StorageConfig changes are disallowed
-	if !reflect.DeepEqual(oldDc.Spec.StorageConfig, newDc.Spec.StorageConfig) {
-		return attemptedTo("change storageConfig")
+	// StorageConfig changes are disallowed, except for growing the server data volumes
+	if err := validateStorageConfigChanges(oldDc.Spec.StorageConfig, newDc.Spec.StorageConfig); err != nil {
+		return err
 	}
 
 	// Topology changes - Racks
@@ -253,6 +495,58 @@ func ValidateFQLConfig(dc CassandraDatacenter) error {
 	return nil
 }
 
+// ValidateResources checks that no resource request exceeds its limit. Kubernetes would otherwise
+// reject the StatefulSet update only once the change is rolled out.
+func ValidateResources(dc CassandraDatacenter) error {
+	containers := map[string]corev1.ResourceRequirements{
+		"resources":              dc.Spec.Resources,
+		"systemLoggerResources":  dc.Spec.SystemLoggerResources,
+		"configBuilderResources": dc.Spec.ConfigBuilderResources,
+	}
+
+	for field, resources := range containers {
+		for name, request := range resources.Requests {
+			if limit, found := resources.Limits[name]; found && request.Cmp(limit) > 0 {
+				return attemptedTo("set %s %s request %s above its limit %s", field, name, request.String(), limit.String())
+			}
+		}
+	}
+
+	return nil
+}
+
+// reservedVolumeNames are the volumes the operator already adds to the server pods
+var reservedVolumeNames = []string{"server-data", "server-config", "server-config-data", "server-logs", "encryption-cred-storage",
+	"internode-keystores", "client-keystore", "metrics-exporter-config", "broadcast-addresses", CommitLogVolumeName, SavedCachesVolumeName}
+
+// ValidateAdditionalVolumes checks that the additional volumes do not clash with each other or with the
+// volumes added by the operator, including the commit log and saved caches claims.
+func ValidateAdditionalVolumes(dc CassandraDatacenter) error {
+	names := make(map[string]bool)
+	mountPaths := make(map[string]bool)
+	for _, volume := range dc.Spec.StorageConfig.AdditionalVolumes {
+		for _, reserved := range reservedVolumeNames {
+			if volume.Name == reserved {
+				return attemptedTo("use reserved name %s for an additional volume", volume.Name)
+			}
+		}
+		if names[volume.Name] {
+			return attemptedTo("define additional volume %s more than once", volume.Name)
+		}
+		if mountPaths[volume.MountPath] {
+			return attemptedTo("mount more than one additional volume at %s", volume.MountPath)
+		}
+		if (volume.MountPath == CommitLogDir && dc.Spec.StorageConfig.CommitLogVolumeClaimSpec != nil) ||
+			(volume.MountPath == SavedCachesDir && dc.Spec.StorageConfig.SavedCachesVolumeClaimSpec != nil) {
+			return attemptedTo("mount additional volume %s at the path of a storageConfig claim %s", volume.Name, volume.MountPath)
+		}
+		names[volume.Name] = true
+		mountPaths[volume.MountPath] = true
+	}
+
+	return nil
+}
+
 func ValidateServiceLabelsAndAnnotations(dc CassandraDatacenter) error {
 	// check each service
 	addSeedSvc := dc.Spec.AdditionalServiceConfig.AdditionalSeedService
@@ -260,6 +554,8 @@ func ValidateServiceLabelsAndAnnotations(dc CassandraDatacenter) error {
 	dcSvc := dc.Spec.AdditionalServiceConfig.DatacenterService
 	nodePortSvc := dc.Spec.AdditionalServiceConfig.NodePortService
 	seedSvc := dc.Spec.AdditionalServiceConfig.SeedService
+	seedExportSvc := dc.Spec.AdditionalServiceConfig.SeedExportService
+	podSvc := dc.Spec.AdditionalServiceConfig.PodService
 
 	services := map[string]ServiceConfigAdditions{
 		"AdditionalSeedService": addSeedSvc,
@@ -267,6 +563,8 @@ func ValidateServiceLabelsAndAnnotations(dc CassandraDatacenter) error {
 		"DatacenterService":     dcSvc,
 		"NodePOrtService":       nodePortSvc,
 		"SeedService":           seedSvc,
+		"SeedExportService":     seedExportSvc,
+		"PodService":            podSvc,
 	}
 
 	for svcName, config := range services {
@@ -0,0 +1,45 @@
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+const DefaultMetricsExporterPort = 9500
+
+// MonitoringSpec configures the Prometheus metrics exporter sidecar added to the Cassandra pods.
+// The exporter reads the metrics of the node with JMX on localhost, and its configuration is
+// kept in the clusterName-dcName-metrics-exporter-config config map managed by the operator.
+type MonitoringSpec struct {
+	// Container image of the metrics exporter. Overrides value from ImageConfig MetricsExporter
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Port of the pods serving the metrics. Defaults to 9500
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// Regular expressions of the metrics not exported, in addition to the default ones which exclude
+	// the less useful attributes and the per table metrics, for instance
+	// "org:apache:cassandra:metrics:threadpools:.*"
+	// +optional
+	ExcludedMetrics []string `json:"excludedMetrics,omitempty"`
+
+	// When true and the Prometheus Operator is installed, a PrometheusRule with alerts scoped to
+	// the datacenter is created: node down, pending compactions, dropped mutations and disk nearly full
+	// +optional
+	AlertingRules bool `json:"alertingRules,omitempty"`
+
+	// Kubernetes resource requests and limits of the metrics exporter container
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// GetMetricsExporterPort returns the port serving the metrics of the pods
+func (dc *CassandraDatacenter) GetMetricsExporterPort() int32 {
+	if dc.Spec.Monitoring == nil || dc.Spec.Monitoring.Port == 0 {
+		return DefaultMetricsExporterPort
+	}
+	return dc.Spec.Monitoring.Port
+}
@@ -0,0 +1,65 @@
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	DefaultReaperKeyspace = "reaper_db"
+	DefaultReaperJmxPort  = 7199
+)
+
+// ReaperSpec configures the Cassandra Reaper instance the operator deploys for the cluster of the
+// datacenter. The datacenters of a cluster enabling Reaper share a single instance, which is
+// removed once none of them enables it anymore. Reaper connects to the nodes with JMX, which must
+// accept remote connections.
+type ReaperSpec struct {
+	// Container image of Reaper. Overrides value from ImageConfig Reaper
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Keyspace storing the Reaper schema. It is created with a replication factor of up to 3 in
+	// the first datacenter deploying Reaper. Defaults to reaper_db
+	// +optional
+	Keyspace string `json:"keyspace,omitempty"`
+
+	// JMX port of the nodes Reaper connects to. Defaults to 7199
+	// +optional
+	JmxPort int `json:"jmxPort,omitempty"`
+
+	// Kubernetes resource requests and limits of the Reaper container
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// GetReaperKeyspace returns the keyspace of the Reaper schema
+func (dc *CassandraDatacenter) GetReaperKeyspace() string {
+	if dc.Spec.Reaper == nil || dc.Spec.Reaper.Keyspace == "" {
+		return DefaultReaperKeyspace
+	}
+	return dc.Spec.Reaper.Keyspace
+}
+
+// GetReaperJmxPort returns the JMX port Reaper connects to
+func (dc *CassandraDatacenter) GetReaperJmxPort() int {
+	if dc.Spec.Reaper == nil || dc.Spec.Reaper.JmxPort == 0 {
+		return DefaultReaperJmxPort
+	}
+	return dc.Spec.Reaper.JmxPort
+}
+
+// GetReaperDeploymentName returns the name of the Reaper deployment of the cluster
+func (dc *CassandraDatacenter) GetReaperDeploymentName() string {
+	return CleanupForKubernetes(dc.Spec.ClusterName) + "-reaper"
+}
+
+// GetReaperUISecretName returns the name of the secret holding the credentials of the Reaper UI and REST
+// API, generated by the operator
+func (dc *CassandraDatacenter) GetReaperUISecretName() string {
+	return CleanupForKubernetes(dc.Spec.ClusterName) + "-reaper-ui"
+}
+
+// GetReaperServiceName returns the name of the service of the Reaper REST API
+func (dc *CassandraDatacenter) GetReaperServiceName() string {
+	return CleanupForKubernetes(dc.Spec.ClusterName) + "-reaper-service"
+}
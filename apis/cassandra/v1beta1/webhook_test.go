@@ -13,6 +13,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func Test_ValidateSingleDatacenter(t *testing.T) {
@@ -128,6 +129,41 @@ func Test_ValidateSingleDatacenter(t *testing.T) {
 			},
 			errString: "CassandraDatacenter write rejected, attempted to enable DSE workloads if server type is Cassandra",
 		},
+		{
+			name: "Dse Workloads of rack in Cassandra Invalid",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					Racks: []Rack{{
+						Name:         "rack1",
+						DseWorkloads: &DseWorkloads{SearchEnabled: true},
+					}},
+				},
+			},
+			errString: "CassandraDatacenter write rejected, attempted to enable DSE workloads if server type is Cassandra",
+		},
+		{
+			name: "Dse Workloads of rack with a config secret",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "dse",
+					ServerVersion: "6.8.4",
+					ConfigSecret:  "dse-config",
+					Racks: []Rack{{
+						Name:         "rack1",
+						DseWorkloads: &DseWorkloads{SearchEnabled: true},
+					}},
+				},
+			},
+			errString: "set the DSE workloads of rack rack1 with a configSecret, which configures all the racks",
+		},
 		{
 			name: "Dse Workloads in Dse valid",
 			dc: &CassandraDatacenter{
@@ -266,66 +302,792 @@ func Test_ValidateSingleDatacenter(t *testing.T) {
 			},
 			errString: "configure DatacenterService with reserved annotations and/or labels (prefixes cassandra.datastax.com and/or k8ssandra.io)",
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := ValidateSingleDatacenter(*tt.dc)
-			if err == nil {
-				if tt.errString != "" {
-					t.Errorf("ValidateSingleDatacenter() err = %v, want %v", err, tt.errString)
-				}
-			} else {
-				if tt.errString == "" {
-					t.Errorf("ValidateSingleDatacenter() err = %v, should be valid", err)
-				} else if !strings.HasSuffix(err.Error(), tt.errString) {
-					t.Errorf("ValidateSingleDatacenter() err = %v, want suffix %v", err, tt.errString)
-				}
-			}
-		})
-	}
-}
-
-func Test_ValidateDatacenterFieldChanges(t *testing.T) {
-	storageSize := resource.MustParse("1Gi")
-	storageName := "server-data"
-
-	tests := []struct {
-		name      string
-		oldDc     *CassandraDatacenter
-		newDc     *CassandraDatacenter
-		errString string
-	}{
 		{
-			name: "No significant changes",
-			oldDc: &CassandraDatacenter{
+			name: "Separate commit log volume valid",
+			dc: &CassandraDatacenter{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					ClusterName:                 "oldname",
-					AllowMultipleNodesPerWorker: false,
-					SuperuserSecretName:         "hush",
-					ServiceAccount:              "admin",
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
 					StorageConfig: StorageConfig{
-						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
-							StorageClassName: &storageName,
-							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
-							Resources: corev1.ResourceRequirements{
-								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
-							},
+						AdditionalVolumes: AdditionalVolumesSlice{
+							{Name: "commitlog", MountPath: "/var/lib/cassandra/commitlog"},
+							{Name: "saved-caches", MountPath: "/var/lib/cassandra/saved_caches"},
+						},
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Additional volume with reserved name",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					StorageConfig: StorageConfig{
+						AdditionalVolumes: AdditionalVolumesSlice{
+							{Name: "server-data", MountPath: "/var/lib/cassandra/commitlog"},
+						},
+					},
+				},
+			},
+			errString: "use reserved name server-data for an additional volume",
+		},
+		{
+			name: "Additional volume with the reserved name of the metrics exporter config",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					StorageConfig: StorageConfig{
+						AdditionalVolumes: AdditionalVolumesSlice{
+							{Name: "metrics-exporter-config", MountPath: "/var/lib/cassandra/commitlog"},
+						},
+					},
+				},
+			},
+			errString: "use reserved name metrics-exporter-config for an additional volume",
+		},
+		{
+			name: "Additional volume with the reserved name of the broadcast addresses",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					StorageConfig: StorageConfig{
+						AdditionalVolumes: AdditionalVolumesSlice{
+							{Name: "broadcast-addresses", MountPath: "/var/lib/cassandra/commitlog"},
+						},
+					},
+				},
+			},
+			errString: "use reserved name broadcast-addresses for an additional volume",
+		},
+		{
+			name: "Additional volumes with the same mount path",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					StorageConfig: StorageConfig{
+						AdditionalVolumes: AdditionalVolumesSlice{
+							{Name: "commitlog", MountPath: "/var/lib/cassandra/commitlog"},
+							{Name: "commitlog2", MountPath: "/var/lib/cassandra/commitlog"},
+						},
+					},
+				},
+			},
+			errString: "mount more than one additional volume at /var/lib/cassandra/commitlog",
+		},
+		{
+			name: "Additional volume with the reserved name of the commit log claim",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					StorageConfig: StorageConfig{
+						AdditionalVolumes: AdditionalVolumesSlice{
+							{Name: "server-commitlog", MountPath: "/var/lib/cassandra/commitlog"},
+						},
+					},
+				},
+			},
+			errString: "use reserved name server-commitlog for an additional volume",
+		},
+		{
+			name: "Additional volume at the mount path of the commit log claim",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					StorageConfig: StorageConfig{
+						CommitLogVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{},
+						AdditionalVolumes: AdditionalVolumesSlice{
+							{Name: "commitlog", MountPath: "/var/lib/cassandra-commitlog"},
+						},
+					},
+				},
+			},
+			errString: "mount additional volume commitlog at the path of a storageConfig claim /var/lib/cassandra-commitlog",
+		},
+		{
+			name: "Resources request above limit",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+						Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+					},
+				},
+			},
+			errString: "set resources memory request 4Gi above its limit 2Gi",
+		},
+		{
+			name: "Config builder resources request above limit",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					ConfigBuilderResources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+						Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+					},
+				},
+			},
+			errString: "set configBuilderResources cpu request 2 above its limit 1",
+		},
+		{
+			name: "Fewer nodes than racks",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					Size:          2,
+					Racks:         []Rack{{Name: "rack1"}, {Name: "rack2"}, {Name: "rack3"}},
+				},
+			},
+			errString: "use 3 racks with a size of 2, the size can not be smaller than the number of racks",
+		},
+		{
+			name: "Force upgrade of an unknown rack",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:        "cassandra",
+					ServerVersion:     "4.0.1",
+					Size:              3,
+					Racks:             []Rack{{Name: "rack1"}, {Name: "rack2"}, {Name: "rack3"}},
+					ForceUpgradeRacks: []string{"rack2", "rack4"},
+				},
+			},
+			errString: "force the upgrade of unknown rack 'rack4'",
+		},
+		{
+			name: "Static seed export without addresses",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					Networking: &NetworkingConfig{
+						SeedExport: &SeedExportConfig{Type: SeedExportStatic},
+					},
+				},
+			},
+			errString: "export the seeds with the Static type without addresses",
+		},
+		{
+			name: "Load balancer seed export with addresses",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					Networking: &NetworkingConfig{
+						SeedExport: &SeedExportConfig{Type: SeedExportLoadBalancer, Addresses: []string{"10.1.0.1"}},
+					},
+				},
+			},
+			errString: "set the addresses of the seed export with the LoadBalancer type",
+		},
+		{
+			name: "Ephemeral data volume with a data volume claim",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{},
+						EphemeralDataVolume: &EphemeralDataVolumeSource{
+							EmptyDir: &corev1.EmptyDirVolumeSource{},
+						},
+					},
+				},
+			},
+			errString: "use both cassandraDataVolumeClaimSpec and ephemeralDataVolume in storageConfig",
+		},
+		{
+			name: "Snapshot on delete with the Retain reclaimPolicy",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:       "cassandra",
+					ServerVersion:    "4.0.1",
+					SnapshotOnDelete: true,
+					StorageConfig: StorageConfig{
+						ReclaimPolicy: PVCReclaimPolicyRetain,
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Snapshot on delete without the Retain reclaimPolicy",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:       "cassandra",
+					ServerVersion:    "4.0.1",
+					SnapshotOnDelete: true,
+				},
+			},
+			errString: "take a snapshotOnDelete without the Retain reclaimPolicy in storageConfig",
+		},
+		{
+			name: "Snapshot on delete of an ephemeral data volume",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:       "cassandra",
+					ServerVersion:    "4.0.1",
+					SnapshotOnDelete: true,
+					StorageConfig: StorageConfig{
+						EphemeralDataVolume: &EphemeralDataVolumeSource{
+							EmptyDir: &corev1.EmptyDirVolumeSource{},
+						},
+					},
+				},
+			},
+			errString: "take a snapshotOnDelete of an ephemeralDataVolume",
+		},
+		{
+			name: "Ephemeral data volume without a source",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					StorageConfig: StorageConfig{
+						EphemeralDataVolume: &EphemeralDataVolumeSource{},
+					},
+				},
+			},
+			errString: "use an ephemeralDataVolume without exactly one of emptyDir and ephemeral",
+		},
+		{
+			name: "Ephemeral data volume restored from a backup",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					StorageConfig: StorageConfig{
+						EphemeralDataVolume: &EphemeralDataVolumeSource{
+							EmptyDir: &corev1.EmptyDirVolumeSource{},
+						},
+						DataSourceBackup: "backup1",
+					},
+				},
+			},
+			errString: "restore an ephemeralDataVolume from the dataSourceBackup in storageConfig",
+		},
+		{
+			name: "Zero maxNodeDataSize",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:      "cassandra",
+					ServerVersion:   "4.0.1",
+					MaxNodeDataSize: resource.NewQuantity(0, resource.BinarySI),
+				},
+			},
+			errString: "set a maxNodeDataSize that is not positive",
+		},
+		{
+			name: "Negative maxNodesInFlight",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:       "cassandra",
+					ServerVersion:    "4.0.1",
+					MaxNodesInFlight: -1,
+				},
+			},
+			errString: "set a negative maxNodesInFlight",
+		},
+		{
+			name: "maxNodesInFlight with consistent range movements",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:       "cassandra",
+					ServerVersion:    "4.0.1",
+					MaxNodesInFlight: 2,
+					Config: json.RawMessage(`{
+						"jvm-server-options": {
+							"additional-jvm-opts": ["-Dcassandra.consistent.rangemovement=true"]
+						}
+					}`),
+				},
+			},
+			errString: "use a maxNodesInFlight above 1 with -Dcassandra.consistent.rangemovement=true",
+		},
+		{
+			name: "OnDeleteUpdates with PartitionedUpdates",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:         "cassandra",
+					ServerVersion:      "4.0.1",
+					OnDeleteUpdates:    true,
+					PartitionedUpdates: true,
+				},
+			},
+			errString: "use onDeleteUpdates with canaryUpgrade or partitionedUpdates",
+		},
+		{
+			name: "PodDisruptionBudget with minAvailable and maxUnavailable",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					PodDisruptionBudget: &PodDisruptionBudgetConfig{
+						MinAvailable:   intstrPtr(intstr.FromInt(2)),
+						MaxUnavailable: intstrPtr(intstr.FromInt(1)),
+					},
+				},
+			},
+			errString: "set both minAvailable and maxUnavailable in podDisruptionBudget",
+		},
+		{
+			name: "LoadBalancer seed export with managed seed endpoints",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:           "cassandra",
+					ServerVersion:        "4.0.1",
+					ManagedSeedEndpoints: true,
+					Networking: &NetworkingConfig{
+						SeedExport: &SeedExportConfig{Type: SeedExportLoadBalancer},
+					},
+				},
+			},
+			errString: "export the seeds with the LoadBalancer type along with managedSeedEndpoints",
+		},
+		{
+			name: "Invalid broadcast template",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					Networking: &NetworkingConfig{
+						BroadcastTemplate: &BroadcastTemplateConfig{Address: "{{ .PodName }.example.com"},
+					},
+				},
+			},
+			errString: `use an invalid broadcastTemplate: template: broadcast:1: unexpected "}" in operand`,
+		},
+		{
+			name: "Broadcast template with LoadBalancer pod exposure",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					Networking: &NetworkingConfig{
+						BroadcastTemplate: &BroadcastTemplateConfig{Address: "{{ .PodName }}.example.com"},
+						PodExposure:       &PodExposureConfig{Type: PodExposureLoadBalancer},
+					},
+				},
+			},
+			errString: "render the broadcast addresses with broadcastTemplate along with a LoadBalancer podExposure",
+		},
+		{
+			name: "Host IP broadcast without host ports",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					Networking: &NetworkingConfig{
+						BroadcastAddress: BroadcastAddressHostIP,
+					},
+				},
+			},
+			errString: "broadcast the IP of the worker without hostNetwork, nodePort or a HostPort podExposure",
+		},
+		{
+			name: "Host IP broadcast with host network",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					Networking: &NetworkingConfig{
+						BroadcastAddress: BroadcastAddressHostIP,
+						HostNetwork:      true,
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Pod exposure with node ports",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.1",
+					Networking: &NetworkingConfig{
+						NodePort:    &NodePortConfig{Native: 30001},
+						PodExposure: &PodExposureConfig{Type: PodExposureLoadBalancer},
+					},
+				},
+			},
+			errString: "expose the pods with podExposure along with nodePort",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSingleDatacenter(*tt.dc)
+			if err == nil {
+				if tt.errString != "" {
+					t.Errorf("ValidateSingleDatacenter() err = %v, want %v", err, tt.errString)
+				}
+			} else {
+				if tt.errString == "" {
+					t.Errorf("ValidateSingleDatacenter() err = %v, should be valid", err)
+				} else if !strings.HasSuffix(err.Error(), tt.errString) {
+					t.Errorf("ValidateSingleDatacenter() err = %v, want suffix %v", err, tt.errString)
+				}
+			}
+		})
+	}
+}
+
+func Test_ValidateDatacenterFieldChanges(t *testing.T) {
+	storageSize := resource.MustParse("1Gi")
+	storageName := "server-data"
+	newStorageName := "new-server-data"
+
+	tests := []struct {
+		name      string
+		oldDc     *CassandraDatacenter
+		newDc     *CassandraDatacenter
+		errString string
+	}{
+		{
+			name: "No significant changes",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName:                 "oldname",
+					AllowMultipleNodesPerWorker: false,
+					SuperuserSecretName:         "hush",
+					ServiceAccount:              "admin",
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
+					Racks: []Rack{{
+						Name: "rack0",
+						Zone: "zone0",
+					}, {
+						Name: "rack1",
+						Zone: "zone1",
+					}, {
+						Name: "rack2",
+						Zone: "zone2",
+					}},
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName:                 "oldname",
+					AllowMultipleNodesPerWorker: false,
+					SuperuserSecretName:         "hush",
+					ServiceAccount:              "admin",
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
+					Racks: []Rack{{
+						Name: "rack0",
+						Zone: "zone0",
+					}, {
+						Name: "rack1",
+						Zone: "zone1",
+					}, {
+						Name: "rack2",
+						Zone: "zone2",
+					}},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Clustername changed",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName: "oldname",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName: "newname",
+				},
+			},
+			errString: "change clusterName",
+		},
+		{
+			name: "ReclaimPolicy changed",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName: "cluster1",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName: "cluster1",
+					StorageConfig: StorageConfig{
+						ReclaimPolicy: PVCReclaimPolicyRetain,
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "DatacenterName changed",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName: "cluster1",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName:    "cluster1",
+					DatacenterName: "Example_DC",
+				},
+			},
+			errString: "change datacenterName",
+		},
+		{
+			name: "DatacenterName set to the name of the CassandraDatacenter",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName: "cluster1",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName:    "cluster1",
+					DatacenterName: "exampleDC",
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "AllowMultipleNodesPerWorker changed",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					AllowMultipleNodesPerWorker: false,
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					AllowMultipleNodesPerWorker: true,
+				},
+			},
+			errString: "change allowMultipleNodesPerWorker",
+		},
+		{
+			name: "SuperuserSecretName changed",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					SuperuserSecretName: "hush",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					SuperuserSecretName: "newsecret",
+				},
+			},
+			errString: "change superuserSecretName",
+		},
+		{
+			name: "ServiceAccount changed",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServiceAccount: "admin",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServiceAccount: "newadmin",
+				},
+			},
+			errString: "change serviceAccount",
+		},
+		{
+			name: "CreateServiceAccount changed",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName: "cluster1",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName:          "cluster1",
+					CreateServiceAccount: true,
+				},
+			},
+			errString: "change serviceAccount",
+		},
+		{
+			name: "StorageConfig changes",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
 						},
 					},
-					Racks: []Rack{{
-						Name: "rack0",
-						Zone: "zone0",
-					}, {
-						Name: "rack1",
-						Zone: "zone1",
-					}, {
-						Name: "rack2",
-						Zone: "zone2",
-					}},
 				},
 			},
 			newDc: &CassandraDatacenter{
@@ -333,41 +1095,35 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					ClusterName:                 "oldname",
-					AllowMultipleNodesPerWorker: false,
-					SuperuserSecretName:         "hush",
-					ServiceAccount:              "admin",
 					StorageConfig: StorageConfig{
 						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
 							StorageClassName: &storageName,
-							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteMany"},
 							Resources: corev1.ResourceRequirements{
 								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
 							},
 						},
 					},
-					Racks: []Rack{{
-						Name: "rack0",
-						Zone: "zone0",
-					}, {
-						Name: "rack1",
-						Zone: "zone1",
-					}, {
-						Name: "rack2",
-						Zone: "zone2",
-					}},
 				},
 			},
-			errString: "",
+			errString: "change storageConfig",
 		},
 		{
-			name: "Clustername changed",
+			name: "StorageConfig storageClassName change",
 			oldDc: &CassandraDatacenter{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					ClusterName: "oldname",
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
 				},
 			},
 			newDc: &CassandraDatacenter{
@@ -375,19 +1131,35 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					ClusterName: "newname",
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &newStorageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
 				},
 			},
-			errString: "change clusterName",
+			errString: "",
 		},
 		{
-			name: "AllowMultipleNodesPerWorker changed",
+			name: "StorageConfig size increase",
 			oldDc: &CassandraDatacenter{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					AllowMultipleNodesPerWorker: false,
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
 				},
 			},
 			newDc: &CassandraDatacenter{
@@ -395,19 +1167,35 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					AllowMultipleNodesPerWorker: true,
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": resource.MustParse("2Gi")},
+							},
+						},
+					},
 				},
 			},
-			errString: "change allowMultipleNodesPerWorker",
+			errString: "",
 		},
 		{
-			name: "SuperuserSecretName changed",
+			name: "StorageConfig size decrease",
 			oldDc: &CassandraDatacenter{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					SuperuserSecretName: "hush",
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
 				},
 			},
 			newDc: &CassandraDatacenter{
@@ -415,19 +1203,35 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					SuperuserSecretName: "newsecret",
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": resource.MustParse("512Mi")},
+							},
+						},
+					},
 				},
 			},
-			errString: "change superuserSecretName",
+			errString: "change storageConfig",
 		},
 		{
-			name: "ServiceAccount changed",
+			name: "StorageConfig commit log size increase",
 			oldDc: &CassandraDatacenter{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					ServiceAccount: "admin",
+					StorageConfig: StorageConfig{
+						CommitLogVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
 				},
 			},
 			newDc: &CassandraDatacenter{
@@ -435,20 +1239,28 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					ServiceAccount: "newadmin",
+					StorageConfig: StorageConfig{
+						CommitLogVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": resource.MustParse("2Gi")},
+							},
+						},
+					},
 				},
 			},
-			errString: "change serviceAccount",
+			errString: "",
 		},
 		{
-			name: "StorageConfig changes",
+			name: "StorageConfig commit log size decrease",
 			oldDc: &CassandraDatacenter{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
 					StorageConfig: StorageConfig{
-						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+						CommitLogVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
 							StorageClassName: &storageName,
 							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
 							Resources: corev1.ResourceRequirements{
@@ -464,11 +1276,11 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 				},
 				Spec: CassandraDatacenterSpec{
 					StorageConfig: StorageConfig{
-						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+						CommitLogVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
 							StorageClassName: &storageName,
-							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteMany"},
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
 							Resources: corev1.ResourceRequirements{
-								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": resource.MustParse("512Mi")},
 							},
 						},
 					},
@@ -776,6 +1588,166 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 			},
 			errString: "add racks without increasing size enough to prevent existing nodes from moving to new racks to maintain balance.\nNew racks added: 2, size increased by: 7. Expected size increase to be at least 8",
 		},
+		{
+			name: "Major version upgrade",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.14",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.7",
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Patch version downgrade",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.7",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.3",
+				},
+			},
+			errString: "downgrade serverVersion from 4.0.7 to 4.0.3",
+		},
+		{
+			name: "Major version downgrade",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.7",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.14",
+				},
+			},
+			errString: "downgrade serverVersion from 4.0.7 to 3.11.14",
+		},
+		{
+			name: "Upgrade skipping a major version",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.14",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "5.0.1",
+				},
+			},
+			errString: "upgrade serverVersion from 3.11.14 to 5.0.1, skipping major version 4",
+		},
+		{
+			name: "Upgrade skipping the major version still rolling out",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.7",
+				},
+				Status: CassandraDatacenterStatus{
+					ServerVersion: "3.11.14",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "5.0.1",
+				},
+			},
+			errString: "upgrade serverVersion from 3.11.14 to 5.0.1, skipping major version 4",
+		},
+		{
+			name: "Revert an upgrade that was never rolled out",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "dse",
+					ServerVersion: "6.8.26",
+				},
+				Status: CassandraDatacenterStatus{
+					ServerVersion: "6.8.25",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "dse",
+					ServerVersion: "6.8.25",
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Server type change",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "dse",
+					ServerVersion: "6.8.26",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.7",
+				},
+			},
+			errString: "change serverType",
+		},
 	}
 
 	for _, tt := range tests {
@@ -823,6 +1795,22 @@ func CreateCassDc(serverType string) CassandraDatacenter {
 	return dc
 }
 
+func Test_Default(t *testing.T) {
+	dc := CreateCassDc("cassandra")
+	dc.Default()
+	assert.Equal(t, []Rack{{Name: "default"}}, dc.Spec.Racks)
+
+	dc = CreateCassDc("cassandra")
+	dc.Spec.Racks = []Rack{{Name: "rack1"}, {Name: "rack2"}}
+	dc.Default()
+	assert.Equal(t, []Rack{{Name: "rack1"}, {Name: "rack2"}}, dc.Spec.Racks)
+
+	oldDc := CreateCassDc("cassandra")
+	dc = CreateCassDc("cassandra")
+	dc.Default()
+	assert.NoError(t, ValidateDatacenterFieldChanges(oldDc, dc), "defaulting the racks should not be rejected as a topology change")
+}
+
 func Test_parseFQLFromConfig_fqlEnabled(t *testing.T) {
 	// Test parsing when fql is set, should return (true, continue).
 	dc := CreateCassDc("cassandra")
@@ -909,3 +1897,7 @@ func Test_parseFQLConfigIsNotSet(t *testing.T) {
 	assert.False(t, parsedFQLisEnabled)
 	assert.NoError(t, err)
 }
+
+func intstrPtr(value intstr.IntOrString) *intstr.IntOrString {
+	return &value
+}
@@ -24,7 +24,9 @@ package v1beta1
 import (
 	"encoding/json"
 	"k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -64,6 +66,21 @@ func (in AdditionalVolumesSlice) DeepCopy() AdditionalVolumesSlice {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BroadcastTemplateConfig) DeepCopyInto(out *BroadcastTemplateConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BroadcastTemplateConfig.
+func (in *BroadcastTemplateConfig) DeepCopy() *BroadcastTemplateConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BroadcastTemplateConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CDCConfiguration) DeepCopyInto(out *CDCConfiguration) {
 	*out = *in
@@ -251,16 +268,46 @@ func (in *CassandraDatacenterList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CassandraDatacenterSpec) DeepCopyInto(out *CassandraDatacenterSpec) {
 	*out = *in
+	if in.MaxNodeDataSize != nil {
+		in, out := &in.MaxNodeDataSize, &out.MaxNodeDataSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.UpgradeSSTables != nil {
+		in, out := &in.UpgradeSSTables, &out.UpgradeSSTables
+		*out = new(UpgradeSSTablesConfig)
+		**out = **in
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImageOverrides != nil {
+		in, out := &in.ImageOverrides, &out.ImageOverrides
+		*out = new(ImageOverridesSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.DockerImageRunsAsCassandra != nil {
 		in, out := &in.DockerImageRunsAsCassandra, &out.DockerImageRunsAsCassandra
 		*out = new(bool)
 		**out = **in
 	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(SecurityContextSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Config != nil {
 		in, out := &in.Config, &out.Config
 		*out = make(json.RawMessage, len(*in))
 		copy(*out, *in)
 	}
+	if in.JvmOptions != nil {
+		in, out := &in.JvmOptions, &out.JvmOptions
+		*out = new(JvmOptions)
+		(*in).DeepCopyInto(*out)
+	}
 	in.ManagementApiAuth.DeepCopyInto(&out.ManagementApiAuth)
 	if in.NodeAffinityLabels != nil {
 		in, out := &in.NodeAffinityLabels, &out.NodeAffinityLabels
@@ -285,6 +332,11 @@ func (in *CassandraDatacenterSpec) DeepCopyInto(out *CassandraDatacenterSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(PodDisruptionBudgetConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
 		*out = make(map[string]string, len(*in))
@@ -330,6 +382,13 @@ func (in *CassandraDatacenterSpec) DeepCopyInto(out *CassandraDatacenterSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.AdditionalLabels != nil {
 		in, out := &in.AdditionalLabels, &out.AdditionalLabels
 		*out = make(map[string]string, len(*in))
@@ -337,11 +396,38 @@ func (in *CassandraDatacenterSpec) DeepCopyInto(out *CassandraDatacenterSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.AdditionalAnnotations != nil {
+		in, out := &in.AdditionalAnnotations, &out.AdditionalAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.CDC != nil {
 		in, out := &in.CDC, &out.CDC
 		*out = new(CDCConfiguration)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Reaper != nil {
+		in, out := &in.Reaper, &out.Reaper
+		*out = new(ReaperSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Monitoring != nil {
+		in, out := &in.Monitoring, &out.Monitoring
+		*out = new(MonitoringSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientEncryption != nil {
+		in, out := &in.ClientEncryption, &out.ClientEncryption
+		*out = new(ClientEncryptionSpec)
+		**out = **in
+	}
+	if in.CertManager != nil {
+		in, out := &in.CertManager, &out.CertManager
+		*out = new(CertManagerSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraDatacenterSpec.
@@ -366,6 +452,7 @@ func (in *CassandraDatacenterStatus) DeepCopyInto(out *CassandraDatacenterStatus
 	}
 	in.SuperUserUpserted.DeepCopyInto(&out.SuperUserUpserted)
 	in.UsersUpserted.DeepCopyInto(&out.UsersUpserted)
+	in.DefaultSuperuserDisabled.DeepCopyInto(&out.DefaultSuperuserDisabled)
 	in.LastServerNodeStarted.DeepCopyInto(&out.LastServerNodeStarted)
 	in.LastRollingRestart.DeepCopyInto(&out.LastRollingRestart)
 	if in.NodeStatuses != nil {
@@ -380,12 +467,44 @@ func (in *CassandraDatacenterStatus) DeepCopyInto(out *CassandraDatacenterStatus
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Seeds != nil {
+		in, out := &in.Seeds, &out.Seeds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RackStatuses != nil {
+		in, out := &in.RackStatuses, &out.RackStatuses
+		*out = make(map[string]RackStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StorageMigration != nil {
+		in, out := &in.StorageMigration, &out.StorageMigration
+		*out = new(StorageMigrationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreUpgradeSnapshot != nil {
+		in, out := &in.PreUpgradeSnapshot, &out.PreUpgradeSnapshot
+		*out = new(PreUpgradeSnapshotStatus)
+		**out = **in
+	}
+	if in.SSTablesUpgrade != nil {
+		in, out := &in.SSTablesUpgrade, &out.SSTablesUpgrade
+		*out = new(SSTablesUpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	in.QuietPeriod.DeepCopyInto(&out.QuietPeriod)
 	if in.TrackedTasks != nil {
 		in, out := &in.TrackedTasks, &out.TrackedTasks
 		*out = make([]v1.ObjectReference, len(*in))
 		copy(*out, *in)
 	}
+	if in.ExportedSeeds != nil {
+		in, out := &in.ExportedSeeds, &out.ExportedSeeds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraDatacenterStatus.
@@ -449,6 +568,36 @@ func (in *CassandraUser) DeepCopy() *CassandraUser {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertManagerSpec) DeepCopyInto(out *CertManagerSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerSpec.
+func (in *CertManagerSpec) DeepCopy() *CertManagerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertManagerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientEncryptionSpec) DeepCopyInto(out *ClientEncryptionSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientEncryptionSpec.
+func (in *ClientEncryptionSpec) DeepCopy() *ClientEncryptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientEncryptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DatacenterCondition) DeepCopyInto(out *DatacenterCondition) {
 	*out = *in
@@ -480,6 +629,96 @@ func (in *DseWorkloads) DeepCopy() *DseWorkloads {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EphemeralDataVolumeSource) DeepCopyInto(out *EphemeralDataVolumeSource) {
+	*out = *in
+	if in.EmptyDir != nil {
+		in, out := &in.EmptyDir, &out.EmptyDir
+		*out = new(v1.EmptyDirVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Ephemeral != nil {
+		in, out := &in.Ephemeral, &out.Ephemeral
+		*out = new(v1.EphemeralVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EphemeralDataVolumeSource.
+func (in *EphemeralDataVolumeSource) DeepCopy() *EphemeralDataVolumeSource {
+	if in == nil {
+		return nil
+	}
+	out := new(EphemeralDataVolumeSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageOverride) DeepCopyInto(out *ImageOverride) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageOverride.
+func (in *ImageOverride) DeepCopy() *ImageOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageOverridesSpec) DeepCopyInto(out *ImageOverridesSpec) {
+	*out = *in
+	if in.Server != nil {
+		in, out := &in.Server, &out.Server
+		*out = new(ImageOverride)
+		**out = **in
+	}
+	if in.ConfigBuilder != nil {
+		in, out := &in.ConfigBuilder, &out.ConfigBuilder
+		*out = new(ImageOverride)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageOverridesSpec.
+func (in *ImageOverridesSpec) DeepCopy() *ImageOverridesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageOverridesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JvmOptions) DeepCopyInto(out *JvmOptions) {
+	*out = *in
+	if in.HeapSize != nil {
+		in, out := &in.HeapSize, &out.HeapSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.HeapNewGenSize != nil {
+		in, out := &in.HeapNewGenSize, &out.HeapNewGenSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JvmOptions.
+func (in *JvmOptions) DeepCopy() *JvmOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(JvmOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ManagementApiAuthConfig) DeepCopyInto(out *ManagementApiAuthConfig) {
 	*out = *in
@@ -535,6 +774,54 @@ func (in *ManagementApiAuthManualConfig) DeepCopy() *ManagementApiAuthManualConf
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoringSpec) DeepCopyInto(out *MonitoringSpec) {
+	*out = *in
+	if in.ExcludedMetrics != nil {
+		in, out := &in.ExcludedMetrics, &out.ExcludedMetrics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringSpec.
+func (in *MonitoringSpec) DeepCopy() *MonitoringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicyConfig) DeepCopyInto(out *NetworkPolicyConfig) {
+	*out = *in
+	if in.CQLPeers != nil {
+		in, out := &in.CQLPeers, &out.CQLPeers
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ClusterNamespaces != nil {
+		in, out := &in.ClusterNamespaces, &out.ClusterNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicyConfig.
+func (in *NetworkPolicyConfig) DeepCopy() *NetworkPolicyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkingConfig) DeepCopyInto(out *NetworkingConfig) {
 	*out = *in
@@ -543,6 +830,26 @@ func (in *NetworkingConfig) DeepCopyInto(out *NetworkingConfig) {
 		*out = new(NodePortConfig)
 		**out = **in
 	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(NetworkPolicyConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SeedExport != nil {
+		in, out := &in.SeedExport, &out.SeedExport
+		*out = new(SeedExportConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodExposure != nil {
+		in, out := &in.PodExposure, &out.PodExposure
+		*out = new(PodExposureConfig)
+		**out = **in
+	}
+	if in.BroadcastTemplate != nil {
+		in, out := &in.BroadcastTemplate, &out.BroadcastTemplate
+		*out = new(BroadcastTemplateConfig)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkingConfig.
@@ -570,6 +877,61 @@ func (in *NodePortConfig) DeepCopy() *NodePortConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodDisruptionBudgetConfig) DeepCopyInto(out *PodDisruptionBudgetConfig) {
+	*out = *in
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodDisruptionBudgetConfig.
+func (in *PodDisruptionBudgetConfig) DeepCopy() *PodDisruptionBudgetConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PodDisruptionBudgetConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodExposureConfig) DeepCopyInto(out *PodExposureConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodExposureConfig.
+func (in *PodExposureConfig) DeepCopy() *PodExposureConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PodExposureConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreUpgradeSnapshotStatus) DeepCopyInto(out *PreUpgradeSnapshotStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreUpgradeSnapshotStatus.
+func (in *PreUpgradeSnapshotStatus) DeepCopy() *PreUpgradeSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PreUpgradeSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Rack) DeepCopyInto(out *Rack) {
 	*out = *in
@@ -580,6 +942,18 @@ func (in *Rack) DeepCopyInto(out *Rack) {
 			(*out)[key] = val
 		}
 	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DseWorkloads != nil {
+		in, out := &in.DseWorkloads, &out.DseWorkloads
+		*out = new(DseWorkloads)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Rack.
@@ -592,6 +966,121 @@ func (in *Rack) DeepCopy() *Rack {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RackStatus) DeepCopyInto(out *RackStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RackStatus.
+func (in *RackStatus) DeepCopy() *RackStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RackStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReaperSpec) DeepCopyInto(out *ReaperSpec) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReaperSpec.
+func (in *ReaperSpec) DeepCopy() *ReaperSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReaperSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSTablesUpgradeStatus) DeepCopyInto(out *SSTablesUpgradeStatus) {
+	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSTablesUpgradeStatus.
+func (in *SSTablesUpgradeStatus) DeepCopy() *SSTablesUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SSTablesUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecurityContextSpec) DeepCopyInto(out *SecurityContextSpec) {
+	*out = *in
+	if in.RunAsUser != nil {
+		in, out := &in.RunAsUser, &out.RunAsUser
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RunAsGroup != nil {
+		in, out := &in.RunAsGroup, &out.RunAsGroup
+		*out = new(int64)
+		**out = **in
+	}
+	if in.FSGroup != nil {
+		in, out := &in.FSGroup, &out.FSGroup
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RunAsNonRoot != nil {
+		in, out := &in.RunAsNonRoot, &out.RunAsNonRoot
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AllowPrivilegeEscalation != nil {
+		in, out := &in.AllowPrivilegeEscalation, &out.AllowPrivilegeEscalation
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DropCapabilities != nil {
+		in, out := &in.DropCapabilities, &out.DropCapabilities
+		*out = make([]v1.Capability, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecurityContextSpec.
+func (in *SecurityContextSpec) DeepCopy() *SecurityContextSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SecurityContextSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedExportConfig) DeepCopyInto(out *SeedExportConfig) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedExportConfig.
+func (in *SeedExportConfig) DeepCopy() *SeedExportConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedExportConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceConfig) DeepCopyInto(out *ServiceConfig) {
 	*out = *in
@@ -600,6 +1089,8 @@ func (in *ServiceConfig) DeepCopyInto(out *ServiceConfig) {
 	in.AllPodsService.DeepCopyInto(&out.AllPodsService)
 	in.AdditionalSeedService.DeepCopyInto(&out.AdditionalSeedService)
 	in.NodePortService.DeepCopyInto(&out.NodePortService)
+	in.SeedExportService.DeepCopyInto(&out.SeedExportService)
+	in.PodService.DeepCopyInto(&out.PodService)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceConfig.
@@ -649,6 +1140,11 @@ func (in *StorageConfig) DeepCopyInto(out *StorageConfig) {
 		*out = new(v1.PersistentVolumeClaimSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.EphemeralDataVolume != nil {
+		in, out := &in.EphemeralDataVolume, &out.EphemeralDataVolume
+		*out = new(EphemeralDataVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.AdditionalVolumes != nil {
 		in, out := &in.AdditionalVolumes, &out.AdditionalVolumes
 		*out = make(AdditionalVolumesSlice, len(*in))
@@ -656,6 +1152,16 @@ func (in *StorageConfig) DeepCopyInto(out *StorageConfig) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CommitLogVolumeClaimSpec != nil {
+		in, out := &in.CommitLogVolumeClaimSpec, &out.CommitLogVolumeClaimSpec
+		*out = new(v1.PersistentVolumeClaimSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SavedCachesVolumeClaimSpec != nil {
+		in, out := &in.SavedCachesVolumeClaimSpec, &out.SavedCachesVolumeClaimSpec
+		*out = new(v1.PersistentVolumeClaimSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageConfig.
@@ -667,3 +1173,40 @@ func (in *StorageConfig) DeepCopy() *StorageConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageMigrationStatus) DeepCopyInto(out *StorageMigrationStatus) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make(map[string]StorageMigrationStage, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageMigrationStatus.
+func (in *StorageMigrationStatus) DeepCopy() *StorageMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeSSTablesConfig) DeepCopyInto(out *UpgradeSSTablesConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeSSTablesConfig.
+func (in *UpgradeSSTablesConfig) DeepCopy() *UpgradeSSTablesConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeSSTablesConfig)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -55,6 +55,12 @@ type Images struct {
 	SystemLogger string `json:"system-logger"`
 
 	ConfigBuilder string `json:"config-builder"`
+
+	Reaper string `json:"reaper,omitempty"`
+
+	MetricsExporter string `json:"metrics-exporter,omitempty"`
+
+	BackupUploader string `json:"backup-uploader,omitempty"`
 }
 
 type DefaultImages struct {
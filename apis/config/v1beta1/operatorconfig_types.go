@@ -38,6 +38,10 @@ type OperatorConfig struct {
 
 	// ImageConfigFile indicates the path where to load the imageConfig from
 	ImageConfigFile string `json:"imageConfigFile,omitempty"`
+
+	// AllowSuperuserRoles lets CassandraRoles create superuser roles. Anyone allowed to create a CassandraRole
+	// in the namespace of a datacenter could otherwise take over its cluster.
+	AllowSuperuserRoles bool `json:"allowSuperuserRoles,omitempty"`
 }
 
 func init() {
@@ -0,0 +1,183 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// BackupLabel is the label of the VolumeSnapshots taken by a backup, with the name of the backup
+	BackupLabel = "control.k8ssandra.io/backup"
+)
+
+// CassandraBackupSpec defines the desired state of CassandraBackup
+type CassandraBackupSpec struct {
+
+	// Which datacenter is backed up. Note, this must be a datacenter which the current cass-operator
+	// can access
+	Datacenter corev1.ObjectReference `json:"datacenter"`
+
+	// Keyspaces to back up. If empty, all the keyspaces are backed up.
+	// +optional
+	Keyspaces []string `json:"keyspaces,omitempty"`
+
+	// Storage is where the files of the snapshot of every node are uploaded, by a job mounting the
+	// data volume of the node, followed by the manifest of the backup once all of them were uploaded.
+	// If unset, the backup only consists of the snapshots kept on the nodes.
+	// +optional
+	Storage *BackupStorage `json:"storage,omitempty"`
+
+	// VolumeSnapshots also backs up the server data volume of each node with a CSI VolumeSnapshot, taken
+	// once the snapshot of the node flushed its data to disk. The backup is complete once all the
+	// VolumeSnapshots are ready to use. The CSI driver of the volumes must support snapshots.
+	// +optional
+	VolumeSnapshots *VolumeSnapshotsConfig `json:"volumeSnapshots,omitempty"`
+}
+
+// VolumeSnapshotsConfig configures the VolumeSnapshots of a backup
+type VolumeSnapshotsConfig struct {
+	// The VolumeSnapshotClass of the VolumeSnapshots. The default class of the CSI driver is used if unset.
+	// +optional
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+}
+
+// BackupStorageType is the kind of object storage backups are uploaded to
+// +kubebuilder:validation:Enum=s3;gcs;azure
+type BackupStorageType string
+
+const (
+	// StorageS3 is Amazon S3 or an S3-compatible store. The credentials secret holds the
+	// access_key_id and secret_access_key keys.
+	StorageS3 BackupStorageType = "s3"
+
+	// StorageGCS is Google Cloud Storage. The credentials secret holds the service account key
+	// in its credentials.json key.
+	StorageGCS BackupStorageType = "gcs"
+
+	// StorageAzure is Azure Blob Storage. The credentials secret holds the account_name and
+	// account_key keys.
+	StorageAzure BackupStorageType = "azure"
+)
+
+// BackupStorage is the destination of a backup
+type BackupStorage struct {
+	// Type of the object storage
+	Type BackupStorageType `json:"type"`
+
+	// Bucket the objects are written to, the name of the container for Azure
+	Bucket string `json:"bucket"`
+
+	// Prefix of the keys of the objects
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Endpoint overrides the URL of the service, to use an S3-compatible store or an emulator
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region of the bucket, for S3. Defaults to us-east-1.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// CredentialsSecret is the secret, in the namespace of the backup, holding the credentials
+	// of the storage
+	CredentialsSecret corev1.LocalObjectReference `json:"credentialsSecret"`
+}
+
+// CassandraBackupStatus defines the observed state of CassandraBackup
+type CassandraBackupStatus struct {
+
+	// The latest available observations of the backup. When the snapshot of a node fails, the
+	// condition of type "Failed" is true until the snapshot is retried successfully. When the
+	// snapshots of all the nodes were taken, the condition of type "Complete" is true.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=atomic
+	Conditions []JobCondition `json:"conditions,omitempty"`
+
+	// The tag of the snapshots taken on the nodes. The snapshots are left on the data volumes of
+	// the nodes, where a backup sidecar can pick them up and upload them to an object storage.
+	// +optional
+	SnapshotName string `json:"snapshotName,omitempty"`
+
+	// Represents time when the operator started the backup.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Represents time when the snapshots of all the nodes were taken.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// The progress of the backup of each node, by pod name
+	// +optional
+	Nodes map[string]BackupNodeStatus `json:"nodes,omitempty"`
+}
+
+// BackupNodeStatus is the progress of the backup of a single node
+type BackupNodeStatus struct {
+	// Represents time when the snapshot of the node was taken.
+	// +optional
+	SnapshotTime *metav1.Time `json:"snapshotTime,omitempty"`
+
+	// The error of the last snapshot or upload attempt, if it failed
+	// +optional
+	Error string `json:"error,omitempty"`
+
+	// Represents time when the files of the snapshot of the node were uploaded to the storage of the
+	// backup, with storage
+	// +optional
+	UploadTime *metav1.Time `json:"uploadTime,omitempty"`
+
+	// The name of the VolumeSnapshot of the server data volume of the node, with volumeSnapshots
+	// +optional
+	VolumeSnapshotName string `json:"volumeSnapshotName,omitempty"`
+
+	// Whether the VolumeSnapshot of the server data volume of the node is ready to use
+	// +optional
+	VolumeSnapshotReady bool `json:"volumeSnapshotReady,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// +kubebuilder:printcolumn:name="Datacenter",type=string,JSONPath=".spec.datacenter.name",description="Datacenter which is backed up"
+// +kubebuilder:printcolumn:name="Started",type="date",JSONPath=".status.startTime",description="When the backup started"
+// +kubebuilder:printcolumn:name="Completed",type="date",JSONPath=".status.completionTime",description="When the snapshots of all the nodes were taken"
+// CassandraBackup is the Schema for the cassandrabackups API
+type CassandraBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CassandraBackupSpec   `json:"spec,omitempty"`
+	Status CassandraBackupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CassandraBackupList contains a list of CassandraBackup
+type CassandraBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CassandraBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CassandraBackup{}, &CassandraBackupList{})
+}
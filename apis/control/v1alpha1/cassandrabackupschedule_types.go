@@ -0,0 +1,84 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// BackupScheduleLabel is the label of the backups created by a schedule, with the name of the schedule
+	BackupScheduleLabel = "control.k8ssandra.io/backup-schedule"
+)
+
+// CassandraBackupScheduleSpec defines the desired state of CassandraBackupSchedule
+type CassandraBackupScheduleSpec struct {
+
+	// Schedule is a cron expression, such as "0 2 * * *", or one of the @yearly, @monthly, @weekly,
+	// @daily and @hourly descriptors. Times are in UTC.
+	Schedule string `json:"schedule"`
+
+	// Retention is the number of backups created by the schedule that are kept, the oldest ones are
+	// deleted along with their snapshots and the files they uploaded to their storage. If unset, all the
+	// backups are kept.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Retention *int32 `json:"retention,omitempty"`
+
+	// BackupTemplate is the spec of the backups created by the schedule
+	BackupTemplate CassandraBackupSpec `json:"backupTemplate"`
+}
+
+// CassandraBackupScheduleStatus defines the observed state of CassandraBackupSchedule
+type CassandraBackupScheduleStatus struct {
+
+	// The last time a backup was created by the schedule
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// The next time a backup will be created by the schedule
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// +kubebuilder:printcolumn:name="Datacenter",type=string,JSONPath=".spec.backupTemplate.datacenter.name",description="Datacenter which is backed up"
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=".spec.schedule",description="When the backups are created"
+// +kubebuilder:printcolumn:name="Last",type="date",JSONPath=".status.lastScheduleTime",description="When the last backup was created"
+// CassandraBackupSchedule is the Schema for the cassandrabackupschedules API
+type CassandraBackupSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CassandraBackupScheduleSpec   `json:"spec,omitempty"`
+	Status CassandraBackupScheduleStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CassandraBackupScheduleList contains a list of CassandraBackupSchedule
+type CassandraBackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CassandraBackupSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CassandraBackupSchedule{}, &CassandraBackupScheduleList{})
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CassandraRestoreSpec defines the desired state of CassandraRestore
+type CassandraRestoreSpec struct {
+
+	// The name of the CassandraBackup to restore, in the namespace of the restore. The datacenter
+	// the backup was taken from is restored in place.
+	Backup string `json:"backup"`
+}
+
+type RestoreStage string
+
+const (
+	// RestoreStopping - the datacenter is being stopped
+	RestoreStopping RestoreStage = "Stopping"
+	// RestoreCopying - the snapshot files are being copied back to the data of the nodes
+	RestoreCopying RestoreStage = "Copying"
+	// RestoreStarting - the datacenter is being started again, unless it was stopped before the restore
+	RestoreStarting RestoreStage = "Starting"
+	// RestoreDone - the datacenter was restored and is ready, or left stopped
+	RestoreDone RestoreStage = "Done"
+)
+
+// CassandraRestoreStatus defines the observed state of CassandraRestore
+type CassandraRestoreStatus struct {
+
+	// The latest available observations of the restore. When the snapshot files of a node could
+	// not be restored, the condition of type "Failed" is true and the datacenter is left stopped.
+	// When the datacenter was restored and is ready again, the condition of type "Complete" is true.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=atomic
+	Conditions []JobCondition `json:"conditions,omitempty"`
+
+	// The current stage of the restore
+	// +optional
+	Stage RestoreStage `json:"stage,omitempty"`
+
+	// Whether the datacenter was already stopped when the restore started, it is then left stopped
+	// once its data was restored
+	// +optional
+	DatacenterStopped bool `json:"datacenterStopped,omitempty"`
+
+	// Represents time when the operator started the restore.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Represents time when the datacenter was restored and ready again.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// The progress of the restore of each node, by pod name
+	// +optional
+	Nodes map[string]RestoreNodeStatus `json:"nodes,omitempty"`
+}
+
+// RestoreNodeStatus is the progress of the restore of a single node
+type RestoreNodeStatus struct {
+	// Represents time when the snapshot files of the node were restored.
+	// +optional
+	RestoreTime *metav1.Time `json:"restoreTime,omitempty"`
+
+	// The error of the restore, if it failed
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// +kubebuilder:printcolumn:name="Backup",type=string,JSONPath=".spec.backup",description="The backup which is restored"
+// +kubebuilder:printcolumn:name="Stage",type=string,JSONPath=".status.stage",description="The current stage of the restore"
+// +kubebuilder:printcolumn:name="Started",type="date",JSONPath=".status.startTime",description="When the restore started"
+// +kubebuilder:printcolumn:name="Completed",type="date",JSONPath=".status.completionTime",description="When the datacenter was restored"
+// CassandraRestore is the Schema for the cassandrarestores API
+type CassandraRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CassandraRestoreSpec   `json:"spec,omitempty"`
+	Status CassandraRestoreStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CassandraRestoreList contains a list of CassandraRestore
+type CassandraRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CassandraRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CassandraRestore{}, &CassandraRestoreList{})
+}
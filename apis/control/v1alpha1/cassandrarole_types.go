@@ -0,0 +1,108 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// RoleFinalizer is set on the roles so that they are dropped from the cluster before being deleted
+	RoleFinalizer = "control.k8ssandra.io/role"
+)
+
+// CassandraRoleSpec defines the desired state of CassandraRole
+type CassandraRoleSpec struct {
+
+	// Which datacenter the role is created in. The role is replicated to the other datacenters of
+	// the cluster with the system_auth keyspace. The datacenter must be in the namespace of the role.
+	Datacenter corev1.ObjectReference `json:"datacenter"`
+
+	// SecretName is the secret, in the namespace of the role, holding the name of the role in its
+	// username key and its password in its password key. The password of the role is rotated by
+	// changing the secret.
+	SecretName string `json:"secretName"`
+
+	// Whether the role is a superuser, which is refused unless allowSuperuserRoles is set in the
+	// config of the operator
+	// +optional
+	Superuser bool `json:"superuser,omitempty"`
+
+	// Whether the role can log in. Defaults to true.
+	// +optional
+	// +kubebuilder:default=true
+	Login *bool `json:"login,omitempty"`
+}
+
+// CassandraRoleStatus defines the observed state of CassandraRole
+type CassandraRoleStatus struct {
+
+	// The name of the role in the cluster, which was created by the CassandraRole and is dropped when
+	// it is deleted
+	// +optional
+	RoleName string `json:"roleName,omitempty"`
+
+	// The generation of the CassandraRole the role was last created or updated from
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The resourceVersion of the secret the role was last created or updated from
+	// +optional
+	SecretResourceVersion string `json:"secretResourceVersion,omitempty"`
+
+	// Represents time when the role was last created or updated in the cluster.
+	// +optional
+	UpsertedTime *metav1.Time `json:"upsertedTime,omitempty"`
+
+	// The error of the last attempt to create or update the role, if it failed
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// +kubebuilder:printcolumn:name="Datacenter",type=string,JSONPath=".spec.datacenter.name",description="Datacenter the role is created in"
+// +kubebuilder:printcolumn:name="Role",type=string,JSONPath=".status.roleName",description="Name of the role in the cluster"
+// +kubebuilder:printcolumn:name="Upserted",type="date",JSONPath=".status.upsertedTime",description="When the role was last created or updated"
+// CassandraRole is the Schema for the cassandraroles API
+type CassandraRole struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CassandraRoleSpec   `json:"spec,omitempty"`
+	Status CassandraRoleStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CassandraRoleList contains a list of CassandraRole
+type CassandraRoleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CassandraRole `json:"items"`
+}
+
+// CanLogin returns whether the role can log in, which it can unless login is explicitly disabled
+func (r *CassandraRole) CanLogin() bool {
+	return r.Spec.Login == nil || *r.Spec.Login
+}
+
+func init() {
+	SchemeBuilder.Register(&CassandraRole{}, &CassandraRoleList{})
+}
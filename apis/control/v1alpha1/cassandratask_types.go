@@ -71,6 +71,7 @@ const (
 	CommandReplaceNode     CassandraCommand = "replacenode"
 	CommandCompaction      CassandraCommand = "compact"
 	CommandScrub           CassandraCommand = "scrub"
+	CommandRepair          CassandraCommand = "repair"
 )
 
 type CassandraJob struct {
@@ -89,6 +90,17 @@ type JobArguments struct {
 	PodName          string `json:"pod_name,omitempty"`
 	RackName         string `json:"rack,omitempty"`
 
+	// Full runs a full repair instead of an incremental one, for the repair command
+	Full bool `json:"full,omitempty"`
+
+	// PauseSeconds is the pause between the repairs of two nodes, to throttle the load of the
+	// repair command on the cluster
+	PauseSeconds int `json:"pause_seconds,omitempty"`
+
+	// Jobs is the number of sstables rewritten concurrently on each node by the upgradesstables
+	// command, all the compaction threads are used if unset
+	Jobs int `json:"jobs,omitempty"`
+
 	// Add compaction arguments
 }
 
@@ -135,6 +147,42 @@ type CassandraTaskStatus struct {
 	// The number of pods which reached phase Failed.
 	// +optional
 	Failed int `json:"failed,omitempty"`
+
+	// The progress of the repair of each keyspace, for the repair command. The keyspaces are
+	// repaired one after the other, one node at a time.
+	// +optional
+	Keyspaces []KeyspaceRepairStatus `json:"keyspaces,omitempty"`
+}
+
+// KeyspaceRepairStatus is the progress of the repair of a keyspace
+type KeyspaceRepairStatus struct {
+	Name string `json:"name"`
+
+	// The pods which repaired the keyspace
+	// +optional
+	RepairedPods []string `json:"repairedPods,omitempty"`
+
+	// The pods whose repair of the keyspace failed
+	// +optional
+	FailedPods []string `json:"failedPods,omitempty"`
+
+	// The pod currently repairing the keyspace, and the id of its repair job
+	// +optional
+	CurrentPod string `json:"currentPod,omitempty"`
+	// +optional
+	JobId string `json:"jobId,omitempty"`
+
+	// Represents time when the repair of the last pod completed.
+	// +optional
+	LastRepairTime *metav1.Time `json:"lastRepairTime,omitempty"`
+
+	// Represents time when all the pods repaired the keyspace.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// The error of the last failed repair
+	// +optional
+	Error string `json:"error,omitempty"`
 }
 
 type JobConditionType string
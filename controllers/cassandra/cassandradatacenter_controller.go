@@ -23,6 +23,7 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/google/uuid"
 	"github.com/k8ssandra/cass-operator/pkg/dynamicwatch"
+	"github.com/k8ssandra/cass-operator/pkg/monitoring"
 	"github.com/k8ssandra/cass-operator/pkg/oplabels"
 	"github.com/k8ssandra/cass-operator/pkg/reconciliation"
 	appsv1 "k8s.io/api/apps/v1"
@@ -56,8 +57,23 @@ import (
 // +kubebuilder:rbac:groups=apps,namespace=cass-operator,resources=deployments/finalizers,verbs=update
 // +kubebuilder:rbac:groups=core,namespace=cass-operator,resources=pods;endpoints;services;configmaps;secrets;persistentvolumeclaims;events,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,namespace=cass-operator,resources=namespaces,verbs=get
+// +kubebuilder:rbac:groups=core,namespace=cass-operator,resources=pods/eviction,verbs=create
+// +kubebuilder:rbac:groups=core,namespace=cass-operator,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,namespace=cass-operator,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=persistentvolumes;nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=volumeattachments,verbs=get;list;watch;delete
 // +kubebuilder:rbac:groups=policy,namespace=cass-operator,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,namespace=cass-operator,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+
+// Prometheus Operator
+// +kubebuilder:rbac:groups=monitoring.coreos.com,namespace=cass-operator,resources=servicemonitors;prometheusrules,verbs=get;list;watch;create;update;patch;delete
+
+// CSI snapshots
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,namespace=cass-operator,resources=volumesnapshots,verbs=get;list;watch
+
+// cert-manager
+// +kubebuilder:rbac:groups=cert-manager.io,namespace=cass-operator,resources=certificates,verbs=get;list;watch;create;update;patch;delete
 
 // CassandraDatacenterReconciler reconciles a cassandraDatacenter object
 type CassandraDatacenterReconciler struct {
@@ -70,6 +86,11 @@ type CassandraDatacenterReconciler struct {
 	// during reconciliation where we update the mappings for the watches.
 	// Putting it here allows us to get it to both places.
 	SecretWatches dynamicwatch.DynamicWatches
+
+	// PodEvictor evicts the pods of cordoned workers, respecting the PodDisruptionBudget of their datacenter
+	PodEvictor reconciliation.PodEvictor
+
+	requeueBackoff requeueBackoff
 }
 
 // Reconcile reads that state of the cluster for a Datacenter object
@@ -115,6 +136,7 @@ func (r *CassandraDatacenterReconciler) Reconcile(ctx context.Context, request c
 		logger.Error(err, "Failed to get CassandraDatacenter.")
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, err
 	}
+	rc.PodEvictor = r.PodEvictor
 
 	if err := rc.IsValid(rc.Datacenter); err != nil {
 		logger.Error(err, "CassandraDatacenter resource is invalid")
@@ -146,10 +168,22 @@ func (r *CassandraDatacenterReconciler) Reconcile(ctx context.Context, request c
 		rc.Recorder.Eventf(rc.Datacenter, "Warning", "ReconcileFailed", err.Error())
 	}
 
-	// Prevent immediate requeue
-	if res.Requeue {
-		if res.RequeueAfter.Milliseconds() < 500 {
-			res.RequeueAfter = time.Duration(500 * time.Millisecond)
+	if rc.Datacenter.GetDeletionTimestamp() != nil {
+		monitoring.RemoveDatacenterMetrics(rc.Datacenter)
+	} else {
+		monitoring.ObserveReconcile(request.Namespace, request.Name, time.Since(startReconcile))
+		monitoring.UpdateDatacenterMetrics(rc.Datacenter)
+	}
+
+	// Back off while the datacenter waits for the cluster to be healthy again without making any progress.
+	// The other requeues wait for progress that is not recorded in the datacenter, and are not delayed.
+	if res.Requeue && err == nil && rc.IsBackoffRequested() {
+		res.RequeueAfter = r.requeueBackoff.next(request.NamespacedName, rc.Datacenter.ResourceVersion, res.RequeueAfter)
+	} else {
+		r.requeueBackoff.reset(request.NamespacedName)
+		// Prevent immediate requeue
+		if res.Requeue && err == nil && res.RequeueAfter < minRequeueDelay {
+			res.RequeueAfter = minRequeueDelay
 		}
 	}
 	return res, err
@@ -179,7 +213,8 @@ func (r *CassandraDatacenterReconciler) SetupWithManager(mgr ctrl.Manager) error
 		For(&api.CassandraDatacenter{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Owns(&appsv1.StatefulSet{}, builder.WithPredicates(managedByCassandraOperatorPredicate)).
 		Owns(&policyv1.PodDisruptionBudget{}, builder.WithPredicates(managedByCassandraOperatorPredicate)).
-		Owns(&corev1.Service{}, builder.WithPredicates(managedByCassandraOperatorPredicate))
+		Owns(&corev1.Service{}, builder.WithPredicates(managedByCassandraOperatorPredicate)).
+		Owns(&corev1.ConfigMap{}, builder.WithPredicates(managedByCassandraOperatorPredicate))
 
 	configSecretMapFn := func(mapObj client.Object) []reconcile.Request {
 		requests := make([]reconcile.Request, 0)
@@ -221,6 +256,58 @@ func (r *CassandraDatacenterReconciler) SetupWithManager(mgr ctrl.Manager) error
 
 	c = c.Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(configSecretMapFn), builder.WithPredicates(configSecretPredicate))
 
+	// Reconcile the datacenters of movePodsFromCordonedWorkers with pods on a worker that was just cordoned
+
+	cordonedWorkerMapFn := func(mapObj client.Object) []reconcile.Request {
+		requests := make([]reconcile.Request, 0)
+		ctx := context.Background()
+		dcList := &api.CassandraDatacenterList{}
+		if err := r.Client.List(ctx, dcList); err != nil {
+			r.Log.Error(err, "failed to list the datacenters of a cordoned worker", "node", mapObj.GetName())
+			return requests
+		}
+		podList := &corev1.PodList{}
+		if err := r.Client.List(ctx, podList, client.MatchingLabels{oplabels.ManagedByLabel: oplabels.ManagedByLabelValue}); err != nil {
+			r.Log.Error(err, "failed to list the pods of a cordoned worker", "node", mapObj.GetName())
+			return requests
+		}
+		for _, dc := range dcList.Items {
+			if !dc.Spec.MovePodsFromCordonedWorkers {
+				continue
+			}
+			for _, pod := range podList.Items {
+				if pod.Namespace == dc.Namespace && pod.Spec.NodeName == mapObj.GetName() &&
+					pod.Labels[api.DatacenterLabel] == api.CleanLabelValue(dc.Name) {
+					requests = append(requests, reconcile.Request{
+						NamespacedName: types.NamespacedName{Namespace: dc.Namespace, Name: dc.Name},
+					})
+					break
+				}
+			}
+		}
+		return requests
+	}
+
+	cordonedWorkerPredicate := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !e.ObjectOld.(*corev1.Node).Spec.Unschedulable && e.ObjectNew.(*corev1.Node).Spec.Unschedulable
+		},
+
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+
+	c = c.Watches(&source.Kind{Type: &corev1.Node{}}, handler.EnqueueRequestsFromMapFunc(cordonedWorkerMapFn), builder.WithPredicates(cordonedWorkerPredicate))
+
 	// TODO Add PSP stuff here if necessary
 
 	// Setup watches for Secrets. These secrets are often not owned by or created by
@@ -0,0 +1,81 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	minRequeueDelay = 500 * time.Millisecond
+	maxRequeueDelay = 2 * time.Minute
+)
+
+// requeueBackoff tracks the requeues of each datacenter waiting for its cluster to be healthy,
+// so that a datacenter that stays unhealthy is not reconciled in a hot loop. The zero value is
+// ready to use.
+type requeueBackoff struct {
+	mu     sync.Mutex
+	states map[types.NamespacedName]requeueState
+}
+
+type requeueState struct {
+	resourceVersion string
+	delay           time.Duration
+}
+
+// next returns the delay before the datacenter is reconciled again. The requested delay is
+// used as long as the datacenter changes between two requeues, otherwise the previous delay
+// is doubled, up to maxRequeueDelay.
+func (b *requeueBackoff) next(key types.NamespacedName, resourceVersion string, requested time.Duration) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if requested < minRequeueDelay {
+		requested = minRequeueDelay
+	}
+
+	state, found := b.states[key]
+	if !found || state.resourceVersion != resourceVersion {
+		state = requeueState{resourceVersion: resourceVersion, delay: requested}
+	} else {
+		state.delay *= 2
+		if state.delay < requested {
+			state.delay = requested
+		}
+		if state.delay > maxRequeueDelay {
+			state.delay = maxRequeueDelay
+		}
+	}
+
+	if b.states == nil {
+		b.states = map[types.NamespacedName]requeueState{}
+	}
+	b.states[key] = state
+	return state.delay
+}
+
+// reset forgets the requeues of the datacenter, once it is reconciled without requeue
+func (b *requeueBackoff) reset(key types.NamespacedName) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.states, key)
+}
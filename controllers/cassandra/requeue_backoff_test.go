@@ -0,0 +1,57 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestRequeueBackoff(t *testing.T) {
+	assert := assert.New(t)
+
+	var b requeueBackoff
+	dc1 := types.NamespacedName{Namespace: "ns", Name: "dc1"}
+	dc2 := types.NamespacedName{Namespace: "ns", Name: "dc2"}
+
+	// Immediate requeues are delayed
+	assert.Equal(minRequeueDelay, b.next(dc1, "1", 0))
+
+	// The delay doubles while the datacenter does not change
+	assert.Equal(2*time.Second, b.next(dc1, "2", 2*time.Second))
+	assert.Equal(4*time.Second, b.next(dc1, "2", 2*time.Second))
+	assert.Equal(8*time.Second, b.next(dc1, "2", 2*time.Second))
+	assert.Equal(16*time.Second, b.next(dc1, "2", 10*time.Second))
+
+	// Other datacenters are tracked separately
+	assert.Equal(2*time.Second, b.next(dc2, "1", 2*time.Second))
+
+	// Any change to the datacenter resets the delay
+	assert.Equal(2*time.Second, b.next(dc1, "3", 2*time.Second))
+
+	// The delay is capped
+	for i := 0; i < 10; i++ {
+		b.next(dc1, "3", 2*time.Second)
+	}
+	assert.Equal(maxRequeueDelay, b.next(dc1, "3", 2*time.Second))
+
+	b.reset(dc1)
+	assert.Equal(2*time.Second, b.next(dc1, "3", 2*time.Second))
+}
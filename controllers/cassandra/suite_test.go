@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	cassandradatastaxcomv1beta1 "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/reconciliation"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -76,11 +77,15 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).ToNot(HaveOccurred())
 
+	podEvictor, err := reconciliation.NewPodEvictor(cfg)
+	Expect(err).ToNot(HaveOccurred())
+
 	err = (&CassandraDatacenterReconciler{
-		Client:   k8sManager.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("CassandraDatacenter"),
-		Scheme:   k8sManager.GetScheme(),
-		Recorder: k8sManager.GetEventRecorderFor("cass-operator"),
+		Client:     k8sManager.GetClient(),
+		Log:        ctrl.Log.WithName("controllers").WithName("CassandraDatacenter"),
+		Scheme:     k8sManager.GetScheme(),
+		Recorder:   k8sManager.GetEventRecorderFor("cass-operator"),
+		PodEvictor: podEvictor,
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -0,0 +1,499 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	cassapi "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+	"github.com/k8ssandra/cass-operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/pkg/images"
+	"github.com/k8ssandra/cass-operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/pkg/reconciliation"
+	"github.com/k8ssandra/cass-operator/pkg/storage"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+var volumeSnapshotGVK = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+
+// CassandraBackupReconciler reconciles a CassandraBackup object
+type CassandraBackupReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=control.k8ssandra.io,namespace=cass-operator,resources=cassandrabackups,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=control.k8ssandra.io,namespace=cass-operator,resources=cassandrabackups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=control.k8ssandra.io,namespace=cass-operator,resources=cassandrabackups/finalizers,verbs=update
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,namespace=cass-operator,resources=volumesnapshots,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=batch,namespace=cass-operator,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile takes a snapshot, tagged with the name of the backup, on every node of the datacenter. The
+// nodes whose snapshot failed are retried until the snapshots of all the nodes were taken. With
+// volumeSnapshots, a VolumeSnapshot of the server data volume of each node is then taken, and the backup
+// waits for all of them to be ready to use. With storage, a job uploads the files of the snapshot of each
+// node to the storage, and the manifest of the backup is uploaded once all of them succeeded.
+func (r *CassandraBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var backup api.CassandraBackup
+	if err := r.Get(ctx, req.NamespacedName, &backup); err != nil {
+		logger.Error(err, "unable to fetch CassandraBackup", "Request", req.NamespacedName)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if backup.DeletionTimestamp != nil || backup.Status.CompletionTime != nil {
+		return ctrl.Result{}, nil
+	}
+
+	dc := &cassapi.CassandraDatacenter{}
+	dcNamespacedName := backupDatacenterName(&backup)
+	if err := r.Get(ctx, dcNamespacedName, dc); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "unable to fetch target CassandraDatacenter: %s", dcNamespacedName)
+	}
+
+	logger = log.FromContext(ctx, "datacenterName", dc.Name, "clusterName", dc.Spec.ClusterName)
+
+	if backup.Status.StartTime == nil {
+		// The backup is not owned by the datacenter, it must be kept to restore a deleted datacenter
+		if backup.Labels == nil {
+			backup.Labels = make(map[string]string)
+		}
+		utils.MergeMap(backup.Labels, dc.GetDatacenterLabels())
+		oplabels.AddOperatorLabels(backup.GetLabels(), dc)
+
+		if err := r.Client.Update(ctx, &backup); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		timeNow := metav1.Now()
+		backup.Status.StartTime = &timeNow
+		backup.Status.SnapshotName = backup.Name
+		setBackupCondition(&backup, api.JobRunning, corev1.ConditionTrue, "")
+	}
+
+	var pods corev1.PodList
+	if err := r.Client.List(ctx, &pods, client.InNamespace(dc.Namespace), client.MatchingLabels(dc.GetDatacenterLabels())); err != nil {
+		return ctrl.Result{}, err
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].Name < pods.Items[j].Name
+	})
+
+	nodeMgmtClient, err := httphelper.NewMgmtClient(ctx, r.Client, dc)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if backup.Status.Nodes == nil {
+		backup.Status.Nodes = make(map[string]api.BackupNodeStatus)
+	}
+
+	var failedPods, pendingPods []string
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		nodeStatus := backup.Status.Nodes[pod.Name]
+		if isNodeBackedUp(&backup, &nodeStatus) {
+			continue
+		}
+
+		if nodeStatus.SnapshotTime == nil {
+			if !isCassandraUp(pod) {
+				nodeStatus.Error = "the node is not ready"
+			} else if err := nodeMgmtClient.CallCreateSnapshotEndpoint(pod, backup.Status.SnapshotName, backup.Spec.Keyspaces); err != nil {
+				logger.Error(err, "Failed to take the snapshot of the node", "Pod", pod.Name)
+				nodeStatus.Error = err.Error()
+			} else {
+				timeNow := metav1.Now()
+				nodeStatus = api.BackupNodeStatus{SnapshotTime: &timeNow}
+			}
+		}
+
+		if nodeStatus.SnapshotTime != nil {
+			// The snapshot flushed the data of the node, its volume can be snapshotted and its files uploaded
+			nodeStatus.Error = ""
+			if err := r.checkNodeBackup(ctx, &backup, dc, pod, &nodeStatus); err != nil {
+				logger.Error(err, "Failed to back up the snapshot of the node", "Pod", pod.Name)
+				nodeStatus.Error = err.Error()
+			}
+		}
+
+		if nodeStatus.Error != "" {
+			failedPods = append(failedPods, pod.Name)
+		} else if !isNodeBackedUp(&backup, &nodeStatus) {
+			pendingPods = append(pendingPods, pod.Name)
+		}
+		backup.Status.Nodes[pod.Name] = nodeStatus
+	}
+
+	res := ctrl.Result{}
+	if len(pods.Items) == 0 {
+		setBackupCondition(&backup, api.JobFailed, corev1.ConditionTrue, "the datacenter has no pods")
+		res.RequeueAfter = jobRunningRequeue
+	} else if len(failedPods) > 0 {
+		setBackupCondition(&backup, api.JobFailed, corev1.ConditionTrue, fmt.Sprintf("the backup of pods %v failed", failedPods))
+		res.RequeueAfter = jobRunningRequeue
+	} else if len(pendingPods) > 0 {
+		logger.Info("Waiting for the VolumeSnapshots and the uploads of the nodes", "Pods", pendingPods)
+		setBackupCondition(&backup, api.JobFailed, corev1.ConditionFalse, "")
+		res.RequeueAfter = jobRunningRequeue
+	} else if err := r.uploadManifest(ctx, &backup, dc); err != nil {
+		logger.Error(err, "Failed to upload the manifest of the backup", "Backup", req.NamespacedName)
+		setBackupCondition(&backup, api.JobFailed, corev1.ConditionTrue, fmt.Sprintf("the upload of the manifest failed: %v", err))
+		res.RequeueAfter = jobRunningRequeue
+	} else {
+		timeNow := metav1.Now()
+		backup.Status.CompletionTime = &timeNow
+		setBackupCondition(&backup, api.JobFailed, corev1.ConditionFalse, "")
+		setBackupCondition(&backup, api.JobRunning, corev1.ConditionFalse, "")
+		setBackupCondition(&backup, api.JobComplete, corev1.ConditionTrue, "")
+		logger.Info("The snapshots of all the nodes were taken", "Backup", req.NamespacedName)
+	}
+
+	if err := r.Client.Status().Update(ctx, &backup); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return res, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CassandraBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&api.CassandraBackup{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
+
+// backupManifest describes the snapshots of a backup, it is uploaded to the storage of the backup
+type backupManifest struct {
+	Name         string                          `json:"name"`
+	Cluster      string                          `json:"cluster"`
+	Datacenter   string                          `json:"datacenter"`
+	Keyspaces    []string                        `json:"keyspaces,omitempty"`
+	SnapshotName string                          `json:"snapshotName"`
+	Nodes        map[string]api.BackupNodeStatus `json:"nodes"`
+}
+
+// uploadManifest writes the manifest of the backup to its storage, if it has one
+func (r *CassandraBackupReconciler) uploadManifest(ctx context.Context, backup *api.CassandraBackup, dc *cassapi.CassandraDatacenter) error {
+	if backup.Spec.Storage == nil {
+		return nil
+	}
+
+	// The manifest is small, unlike the files of the snapshots it has a bounded upload
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	secret := &corev1.Secret{}
+	secretName := types.NamespacedName{Namespace: backup.Namespace, Name: backup.Spec.Storage.CredentialsSecret.Name}
+	if err := r.Get(ctx, secretName, secret); err != nil {
+		return errors.Wrapf(err, "unable to fetch the credentials secret %s", secretName)
+	}
+
+	writer, err := storage.NewWriter(ctx, backup.Spec.Storage, secret.Data)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := json.Marshal(backupManifest{
+		Name:         backup.Name,
+		Cluster:      dc.Spec.ClusterName,
+		Datacenter:   dc.Name,
+		Keyspaces:    backup.Spec.Keyspaces,
+		SnapshotName: backup.Status.SnapshotName,
+		Nodes:        backup.Status.Nodes,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.Write(ctx, backup.Name+"/manifest.json", manifest)
+}
+
+// isNodeBackedUp returns whether the snapshot of the node was taken, and with volumeSnapshots and storage,
+// whether its VolumeSnapshot is ready to use and its files were uploaded
+func isNodeBackedUp(backup *api.CassandraBackup, nodeStatus *api.BackupNodeStatus) bool {
+	return nodeStatus.SnapshotTime != nil &&
+		(backup.Spec.VolumeSnapshots == nil || nodeStatus.VolumeSnapshotReady) &&
+		(backup.Spec.Storage == nil || nodeStatus.UploadTime != nil)
+}
+
+// checkNodeBackup takes the VolumeSnapshot of the node and uploads the files of its snapshot, if the
+// backup has volumeSnapshots and storage, once the snapshot of the node was taken
+func (r *CassandraBackupReconciler) checkNodeBackup(ctx context.Context, backup *api.CassandraBackup, dc *cassapi.CassandraDatacenter, pod *corev1.Pod, nodeStatus *api.BackupNodeStatus) error {
+	if backup.Spec.VolumeSnapshots != nil && !nodeStatus.VolumeSnapshotReady {
+		if err := r.checkVolumeSnapshot(ctx, backup, dc, pod, nodeStatus); err != nil {
+			return err
+		}
+	}
+	if backup.Spec.Storage != nil && nodeStatus.UploadTime == nil {
+		return r.checkUploadJob(ctx, backup, dc, pod, nodeStatus)
+	}
+	return nil
+}
+
+// checkUploadJob creates the job uploading the files of the snapshot of the node to the storage of the
+// backup, if it was not yet, and records in the status of the node when it succeeded. A failed job is
+// deleted, so that the upload is retried.
+func (r *CassandraBackupReconciler) checkUploadJob(ctx context.Context, backup *api.CassandraBackup, dc *cassapi.CassandraDatacenter, pod *corev1.Pod, nodeStatus *api.BackupNodeStatus) error {
+	if dc.IsEphemeralStorageEnabled() {
+		return fmt.Errorf("the datacenter has no persistent volumes to upload the snapshot from")
+	}
+	if dc.Namespace != backup.Namespace {
+		// The job mounts the credentials secret, which must be in its namespace
+		return fmt.Errorf("the backup must be in the namespace of the datacenter to be uploaded to a storage")
+	}
+
+	job := &batchv1.Job{}
+	jobName := types.NamespacedName{Namespace: dc.Namespace, Name: fmt.Sprintf("%s-upload-%s", backup.Name, pod.Name)}
+	if err := r.Get(ctx, jobName, job); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+		job, err = newUploadJob(jobName, backup, dc, pod)
+		if err != nil {
+			return err
+		}
+		if err := controllerutil.SetControllerReference(backup, job, r.Scheme); err != nil {
+			return err
+		}
+		return r.Create(ctx, job)
+	}
+
+	if job.Status.Succeeded > 0 {
+		timeNow := metav1.Now()
+		nodeStatus.UploadTime = &timeNow
+	} else if job.Status.Failed > 0 {
+		if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		return fmt.Errorf("the upload job %s failed", jobName.Name)
+	}
+	return nil
+}
+
+// newUploadJob returns the job uploading the files of the snapshot of the node with the operator image. It
+// runs on the worker of the pod, where the data volume of the node is attached, and mounts it read-only.
+func newUploadJob(name types.NamespacedName, backup *api.CassandraBackup, dc *cassapi.CassandraDatacenter, pod *corev1.Pod) (*batchv1.Job, error) {
+	backupStorage, err := json.Marshal(backup.Spec.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddOperatorLabels(labels, dc)
+	labels[api.BackupLabel] = backup.Name
+
+	backoffLimit := int32(2)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:   corev1.RestartPolicyNever,
+					SecurityContext: pod.Spec.SecurityContext,
+					Tolerations:     pod.Spec.Tolerations,
+					Affinity: &corev1.Affinity{
+						NodeAffinity: &corev1.NodeAffinity{
+							RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+								NodeSelectorTerms: []corev1.NodeSelectorTerm{
+									{
+										MatchFields: []corev1.NodeSelectorRequirement{
+											{
+												Key:      "metadata.name",
+												Operator: corev1.NodeSelectorOpIn,
+												Values:   []string{pod.Spec.NodeName},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:            "upload",
+							Image:           images.GetBackupUploaderImage(),
+							ImagePullPolicy: images.GetImageConfig().ImagePullPolicy,
+							Command:         []string{"/manager", storage.UploadSnapshotCommand},
+							Env: []corev1.EnvVar{
+								{Name: storage.StorageEnv, Value: string(backupStorage)},
+								{Name: storage.SnapshotEnv, Value: backup.Status.SnapshotName},
+								{Name: storage.KeyPrefixEnv, Value: backup.Name + "/" + pod.Name},
+								{Name: storage.DataDirEnv, Value: "/var/lib/cassandra/data"},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: reconciliation.PvcName, MountPath: "/var/lib/cassandra", ReadOnly: true},
+								{Name: "credentials", MountPath: storage.CredentialsDir, ReadOnly: true},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: reconciliation.PvcName,
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: fmt.Sprintf("%s-%s", reconciliation.PvcName, pod.Name),
+									ReadOnly:  true,
+								},
+							},
+						},
+						{
+							Name: "credentials",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{SecretName: backup.Spec.Storage.CredentialsSecret.Name},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	images.AddDefaultRegistryImagePullSecrets(&job.Spec.Template.Spec)
+	return job, nil
+}
+
+// checkVolumeSnapshot creates the VolumeSnapshot of the server data volume of the node, if it was not yet,
+// and records in the status of the node whether it is ready to use
+func (r *CassandraBackupReconciler) checkVolumeSnapshot(ctx context.Context, backup *api.CassandraBackup, dc *cassapi.CassandraDatacenter, pod *corev1.Pod, nodeStatus *api.BackupNodeStatus) error {
+	if dc.IsEphemeralStorageEnabled() {
+		return fmt.Errorf("the datacenter has no persistent volumes to snapshot")
+	}
+
+	desired := newVolumeSnapshot(backup, dc, pod)
+	nodeStatus.VolumeSnapshotName = desired.GetName()
+	// The VolumeSnapshots are deleted with the backup, owner references can not cross namespaces
+	if backup.Namespace == desired.GetNamespace() {
+		if err := controllerutil.SetControllerReference(backup, desired, r.Scheme); err != nil {
+			return err
+		}
+	}
+
+	volumeSnapshot := &unstructured.Unstructured{}
+	volumeSnapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	if err := r.Get(ctx, types.NamespacedName{Namespace: desired.GetNamespace(), Name: desired.GetName()}, volumeSnapshot); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+		return r.Create(ctx, desired)
+	}
+
+	if message, _, _ := unstructured.NestedString(volumeSnapshot.Object, "status", "error", "message"); message != "" {
+		return fmt.Errorf("the VolumeSnapshot %s failed: %s", volumeSnapshot.GetName(), message)
+	}
+	nodeStatus.VolumeSnapshotReady, _, _ = unstructured.NestedBool(volumeSnapshot.Object, "status", "readyToUse")
+	return nil
+}
+
+// newVolumeSnapshot returns the VolumeSnapshot of the server data volume of the node, named after the
+// backup and the pod and labeled with the name of the backup
+func newVolumeSnapshot(backup *api.CassandraBackup, dc *cassapi.CassandraDatacenter, pod *corev1.Pod) *unstructured.Unstructured {
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddOperatorLabels(labels, dc)
+	labels[api.BackupLabel] = backup.Name
+
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": fmt.Sprintf("%s-%s", reconciliation.PvcName, pod.Name),
+		},
+	}
+	if className := backup.Spec.VolumeSnapshots.VolumeSnapshotClassName; className != "" {
+		spec["volumeSnapshotClassName"] = className
+	}
+
+	volumeSnapshot := &unstructured.Unstructured{}
+	volumeSnapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	volumeSnapshot.SetNamespace(pod.Namespace)
+	volumeSnapshot.SetName(fmt.Sprintf("%s-%s", backup.Name, pod.Name))
+	volumeSnapshot.SetLabels(labels)
+	volumeSnapshot.Object["spec"] = spec
+	return volumeSnapshot
+}
+
+// backupDatacenterName returns the name of the datacenter of the backup, which defaults to the
+// namespace of the backup
+func backupDatacenterName(backup *api.CassandraBackup) types.NamespacedName {
+	name := types.NamespacedName{
+		Namespace: backup.Spec.Datacenter.Namespace,
+		Name:      backup.Spec.Datacenter.Name,
+	}
+	if name.Namespace == "" {
+		name.Namespace = backup.Namespace
+	}
+	return name
+}
+
+func setBackupCondition(backup *api.CassandraBackup, condition api.JobConditionType, status corev1.ConditionStatus, message string) {
+	backup.Status.Conditions = setJobCondition(backup.Status.Conditions, condition, status, message)
+}
+
+// setJobCondition updates the condition in place, recording the time of the transition if its status
+// changed. A condition that is not true is only recorded if it was true before.
+func setJobCondition(conditions []api.JobCondition, condition api.JobConditionType, status corev1.ConditionStatus, message string) []api.JobCondition {
+	for i := range conditions {
+		cond := &conditions[i]
+		if cond.Type == condition {
+			if cond.Status != status {
+				cond.Status = status
+				cond.LastTransitionTime = metav1.Now()
+			}
+			cond.Message = message
+			return conditions
+		}
+	}
+
+	if status == corev1.ConditionFalse {
+		return conditions
+	}
+
+	return append(conditions, api.JobCondition{
+		Type:               condition,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Message:            message,
+	})
+}
@@ -0,0 +1,351 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	cassapi "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+	"github.com/k8ssandra/cass-operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/pkg/images"
+	"github.com/k8ssandra/cass-operator/pkg/storage"
+)
+
+func init() {
+	imageConfigFile := filepath.Join("..", "..", "config", "manager", "image_config.yaml")
+	err := images.ParseImageConfig(imageConfigFile)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestCassandraBackupReconciler(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	callDetails := httphelper.NewCallDetails()
+	mockServer, err := httphelper.FakeExecutorServerWithDetails(callDetails)
+	require.NoError(err)
+	mockServer.Start()
+	defer mockServer.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(cassapi.AddToScheme(scheme))
+	require.NoError(api.AddToScheme(scheme))
+
+	dc := &cassapi.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "test"},
+		Spec:       cassapi.CassandraDatacenterSpec{ClusterName: "cluster1", Size: 3},
+	}
+	backup := &api.CassandraBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup1", Namespace: "test"},
+		Spec: api.CassandraBackupSpec{
+			Datacenter: corev1.ObjectReference{Name: "dc1"},
+			Keyspaces:  []string{"ks1"},
+		},
+	}
+	objs := []runtime.Object{dc, backup}
+	for i := 0; i < 3; i++ {
+		objs = append(objs, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", i),
+				Namespace: "test",
+				Labels:    dc.GetDatacenterLabels(),
+			},
+			Status: corev1.PodStatus{
+				PodIP: "127.0.0.1",
+				ContainerStatuses: []corev1.ContainerStatus{
+					// The last pod is not ready
+					{Name: "cassandra", Ready: i < 2},
+				},
+			},
+		})
+	}
+
+	r := &CassandraBackupReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objs...).Build(),
+		Scheme: scheme,
+	}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "backup1", Namespace: "test"}}
+
+	res, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(jobRunningRequeue, res.RequeueAfter)
+	assert.Equal(2, callDetails.URLCounts["/api/v0/ops/node/snapshots"])
+
+	require.NoError(r.Get(ctx, req.NamespacedName, backup))
+	assert.Equal("backup1", backup.Status.SnapshotName)
+	assert.NotNil(backup.Status.StartTime)
+	assert.Nil(backup.Status.CompletionTime)
+	assert.Equal("dc1", backup.Labels[cassapi.DatacenterLabel])
+	assert.NotNil(backup.Status.Nodes["pod-0"].SnapshotTime)
+	assert.NotNil(backup.Status.Nodes["pod-1"].SnapshotTime)
+	assert.Nil(backup.Status.Nodes["pod-2"].SnapshotTime)
+	assert.NotEmpty(backup.Status.Nodes["pod-2"].Error)
+	assert.True(hasBackupCondition(backup, api.JobFailed, corev1.ConditionTrue))
+
+	// Once the last pod is ready, only its snapshot is taken
+	pod := &corev1.Pod{}
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "pod-2", Namespace: "test"}, pod))
+	pod.Status.ContainerStatuses[0].Ready = true
+	require.NoError(r.Status().Update(ctx, pod))
+
+	res, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(ctrl.Result{}, res)
+	assert.Equal(3, callDetails.URLCounts["/api/v0/ops/node/snapshots"])
+
+	require.NoError(r.Get(ctx, req.NamespacedName, backup))
+	assert.NotNil(backup.Status.CompletionTime)
+	assert.NotNil(backup.Status.Nodes["pod-2"].SnapshotTime)
+	assert.Empty(backup.Status.Nodes["pod-2"].Error)
+	assert.True(hasBackupCondition(backup, api.JobComplete, corev1.ConditionTrue))
+	assert.True(hasBackupCondition(backup, api.JobFailed, corev1.ConditionFalse))
+	assert.True(hasBackupCondition(backup, api.JobRunning, corev1.ConditionFalse))
+
+	// A completed backup is left alone
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(3, callDetails.URLCounts["/api/v0/ops/node/snapshots"])
+}
+
+func TestCassandraBackupReconciler_Storage(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	callDetails := httphelper.NewCallDetails()
+	mockServer, err := httphelper.FakeExecutorServerWithDetails(callDetails)
+	require.NoError(err)
+	mockServer.Start()
+	defer mockServer.Close()
+
+	storageFails := true
+	var uploads map[string]string
+	storageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if storageFails {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		uploads[r.URL.Path] = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer storageServer.Close()
+	uploads = make(map[string]string)
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(cassapi.AddToScheme(scheme))
+	require.NoError(api.AddToScheme(scheme))
+
+	dc := &cassapi.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "test"},
+		Spec:       cassapi.CassandraDatacenterSpec{ClusterName: "cluster1", Size: 1},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: "test", Labels: dc.GetDatacenterLabels()},
+		Spec:       corev1.PodSpec{NodeName: "worker1"},
+		Status: corev1.PodStatus{
+			PodIP:             "127.0.0.1",
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "s3-credentials", Namespace: "test"},
+		Data: map[string][]byte{
+			storage.S3AccessKeyIDKey:     []byte("AKIDEXAMPLE"),
+			storage.S3SecretAccessKeyKey: []byte("secret"),
+		},
+	}
+	backup := &api.CassandraBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup1", Namespace: "test"},
+		Spec: api.CassandraBackupSpec{
+			Datacenter: corev1.ObjectReference{Name: "dc1"},
+			Storage: &api.BackupStorage{
+				Type:              api.StorageS3,
+				Bucket:            "bucket1",
+				Prefix:            "prod",
+				Endpoint:          storageServer.URL,
+				CredentialsSecret: corev1.LocalObjectReference{Name: "s3-credentials"},
+			},
+		},
+	}
+
+	r := &CassandraBackupReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(dc, pod, secret, backup).Build(),
+		Scheme: scheme,
+	}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "backup1", Namespace: "test"}}
+
+	// The snapshot of the node is uploaded by a job running on its worker
+	res, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(jobRunningRequeue, res.RequeueAfter)
+	require.NoError(r.Get(ctx, req.NamespacedName, backup))
+	assert.Nil(backup.Status.CompletionTime)
+	assert.NotNil(backup.Status.Nodes["pod-0"].SnapshotTime)
+	assert.Nil(backup.Status.Nodes["pod-0"].UploadTime)
+	assert.Empty(uploads)
+
+	job := &batchv1.Job{}
+	jobName := types.NamespacedName{Name: "backup1-upload-pod-0", Namespace: "test"}
+	require.NoError(r.Get(ctx, jobName, job))
+	podSpec := job.Spec.Template.Spec
+	assert.Equal([]string{"worker1"}, podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchFields[0].Values)
+	assert.Equal("server-data-pod-0", podSpec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Equal("s3-credentials", podSpec.Volumes[1].Secret.SecretName)
+	assert.Contains(podSpec.Containers[0].Env, corev1.EnvVar{Name: storage.KeyPrefixEnv, Value: "backup1/pod-0"})
+	assert.Contains(podSpec.Containers[0].Env, corev1.EnvVar{Name: storage.SnapshotEnv, Value: backup.Status.SnapshotName})
+
+	// A failed upload is retried with a new job
+	job.Status.Failed = 1
+	require.NoError(r.Status().Update(ctx, job))
+	res, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(jobRunningRequeue, res.RequeueAfter)
+	require.NoError(r.Get(ctx, req.NamespacedName, backup))
+	assert.True(hasBackupCondition(backup, api.JobFailed, corev1.ConditionTrue))
+	assert.NotEmpty(backup.Status.Nodes["pod-0"].Error)
+	assert.True(k8serrors.IsNotFound(r.Get(ctx, jobName, job)))
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	require.NoError(r.Get(ctx, jobName, job))
+	assert.Equal(1, callDetails.URLCounts["/api/v0/ops/node/snapshots"])
+
+	// The backup is not complete until its manifest was uploaded
+	job.Status.Succeeded = 1
+	require.NoError(r.Status().Update(ctx, job))
+	res, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(jobRunningRequeue, res.RequeueAfter)
+	require.NoError(r.Get(ctx, req.NamespacedName, backup))
+	assert.Nil(backup.Status.CompletionTime)
+	assert.NotNil(backup.Status.Nodes["pod-0"].UploadTime)
+	assert.True(hasBackupCondition(backup, api.JobFailed, corev1.ConditionTrue))
+
+	storageFails = false
+	res, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(ctrl.Result{}, res)
+	require.NoError(r.Get(ctx, req.NamespacedName, backup))
+	assert.NotNil(backup.Status.CompletionTime)
+	assert.Equal(1, callDetails.URLCounts["/api/v0/ops/node/snapshots"])
+
+	var manifest backupManifest
+	require.NoError(json.Unmarshal([]byte(uploads["/bucket1/prod/backup1/manifest.json"]), &manifest))
+	assert.Equal("cluster1", manifest.Cluster)
+	assert.Equal("dc1", manifest.Datacenter)
+	assert.Equal("backup1", manifest.SnapshotName)
+	assert.NotNil(manifest.Nodes["pod-0"].SnapshotTime)
+	assert.NotNil(manifest.Nodes["pod-0"].UploadTime)
+}
+
+func hasBackupCondition(backup *api.CassandraBackup, condition api.JobConditionType, status corev1.ConditionStatus) bool {
+	for _, cond := range backup.Status.Conditions {
+		if cond.Type == condition {
+			return cond.Status == status
+		}
+	}
+	return false
+}
+
+func TestCassandraBackupReconciler_VolumeSnapshots(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	callDetails := httphelper.NewCallDetails()
+	mockServer, err := httphelper.FakeExecutorServerWithDetails(callDetails)
+	require.NoError(err)
+	mockServer.Start()
+	defer mockServer.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(cassapi.AddToScheme(scheme))
+	require.NoError(api.AddToScheme(scheme))
+
+	dc := &cassapi.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "test"},
+		Spec:       cassapi.CassandraDatacenterSpec{ClusterName: "cluster1", Size: 1},
+	}
+	backup := &api.CassandraBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup1", Namespace: "test"},
+		Spec: api.CassandraBackupSpec{
+			Datacenter:      corev1.ObjectReference{Name: "dc1"},
+			VolumeSnapshots: &api.VolumeSnapshotsConfig{VolumeSnapshotClassName: "csi-snapclass"},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: "test", Labels: dc.GetDatacenterLabels()},
+		Status: corev1.PodStatus{
+			PodIP:             "127.0.0.1",
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}},
+		},
+	}
+
+	r := &CassandraBackupReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(dc, backup, pod).Build(),
+		Scheme: scheme,
+	}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "backup1", Namespace: "test"}}
+
+	// The VolumeSnapshot is created once the snapshot of the node was taken
+	res, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(jobRunningRequeue, res.RequeueAfter)
+	assert.Equal(1, callDetails.URLCounts["/api/v0/ops/node/snapshots"])
+
+	volumeSnapshot := &unstructured.Unstructured{}
+	volumeSnapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "backup1-pod-0", Namespace: "test"}, volumeSnapshot))
+	assert.Equal("backup1", volumeSnapshot.GetLabels()[api.BackupLabel])
+	claimName, _, _ := unstructured.NestedString(volumeSnapshot.Object, "spec", "source", "persistentVolumeClaimName")
+	assert.Equal("server-data-pod-0", claimName)
+	className, _, _ := unstructured.NestedString(volumeSnapshot.Object, "spec", "volumeSnapshotClassName")
+	assert.Equal("csi-snapclass", className)
+	require.Len(volumeSnapshot.GetOwnerReferences(), 1)
+	assert.Equal("backup1", volumeSnapshot.GetOwnerReferences()[0].Name)
+
+	require.NoError(r.Get(ctx, req.NamespacedName, backup))
+	assert.Nil(backup.Status.CompletionTime)
+	assert.Equal("backup1-pod-0", backup.Status.Nodes["pod-0"].VolumeSnapshotName)
+	assert.False(backup.Status.Nodes["pod-0"].VolumeSnapshotReady)
+
+	// The backup completes once the VolumeSnapshot is ready, without taking another snapshot
+	require.NoError(unstructured.SetNestedField(volumeSnapshot.Object, true, "status", "readyToUse"))
+	require.NoError(r.Update(ctx, volumeSnapshot))
+
+	res, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(ctrl.Result{}, res)
+	assert.Equal(1, callDetails.URLCounts["/api/v0/ops/node/snapshots"])
+
+	require.NoError(r.Get(ctx, req.NamespacedName, backup))
+	assert.NotNil(backup.Status.CompletionTime)
+	assert.True(backup.Status.Nodes["pod-0"].VolumeSnapshotReady)
+	assert.True(hasBackupCondition(backup, api.JobComplete, corev1.ConditionTrue))
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	cassapi "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+	"github.com/k8ssandra/cass-operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/pkg/storage"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// CassandraBackupScheduleReconciler reconciles a CassandraBackupSchedule object
+type CassandraBackupScheduleReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=control.k8ssandra.io,namespace=cass-operator,resources=cassandrabackupschedules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=control.k8ssandra.io,namespace=cass-operator,resources=cassandrabackupschedules/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=control.k8ssandra.io,namespace=cass-operator,resources=cassandrabackupschedules/finalizers,verbs=update
+
+// Reconcile creates a CassandraBackup when the schedule is due, and deletes the oldest completed backups
+// of the schedule beyond its retention, along with their snapshots. Runs missed while the operator was
+// down are caught up with a single backup.
+func (r *CassandraBackupScheduleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var backupSchedule api.CassandraBackupSchedule
+	if err := r.Get(ctx, req.NamespacedName, &backupSchedule); err != nil {
+		logger.Error(err, "unable to fetch CassandraBackupSchedule", "Request", req.NamespacedName)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if backupSchedule.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	schedule, err := utils.ParseCronSchedule(backupSchedule.Spec.Schedule)
+	if err != nil {
+		// Retrying will not help until the spec is fixed, which triggers a new reconcile
+		logger.Error(err, "Invalid schedule, no backup will be created", "BackupSchedule", req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	now := time.Now().UTC()
+	lastScheduleTime := backupSchedule.CreationTimestamp.Time
+	if backupSchedule.Status.LastScheduleTime != nil {
+		lastScheduleTime = backupSchedule.Status.LastScheduleTime.Time
+	}
+
+	if scheduledTime := schedule.Next(lastScheduleTime.UTC()); !scheduledTime.IsZero() && !scheduledTime.After(now) {
+		// The name only depends on the status, a backup created before a failed status update is not duplicated
+		backup := &api.CassandraBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%d", backupSchedule.Name, scheduledTime.Unix()),
+				Namespace: backupSchedule.Namespace,
+				Labels:    map[string]string{api.BackupScheduleLabel: backupSchedule.Name},
+			},
+			Spec: *backupSchedule.Spec.BackupTemplate.DeepCopy(),
+		}
+
+		// The backup is not owned by the schedule, it must be kept to restore the datacenter
+		if err := r.Client.Create(ctx, backup); err != nil && !k8serrors.IsAlreadyExists(err) {
+			return ctrl.Result{}, err
+		}
+		logger.Info("Created scheduled backup", "BackupSchedule", req.NamespacedName, "Backup", backup.Name)
+
+		backupSchedule.Status.LastScheduleTime = &metav1.Time{Time: now}
+	}
+
+	res := ctrl.Result{}
+	backupSchedule.Status.NextScheduleTime = nil
+	if nextScheduleTime := schedule.Next(now); !nextScheduleTime.IsZero() {
+		backupSchedule.Status.NextScheduleTime = &metav1.Time{Time: nextScheduleTime}
+		res.RequeueAfter = nextScheduleTime.Sub(now)
+	}
+
+	pruned, err := r.pruneBackups(ctx, &backupSchedule)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !pruned && (res.RequeueAfter == 0 || res.RequeueAfter > jobRunningRequeue) {
+		res.RequeueAfter = jobRunningRequeue
+	}
+
+	if err := r.Client.Status().Update(ctx, &backupSchedule); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return res, nil
+}
+
+// pruneBackups deletes the oldest completed backups of the schedule beyond its retention, after clearing
+// their snapshots on the nodes and their files in storage. It returns false if some backups could not be
+// pruned yet.
+func (r *CassandraBackupScheduleReconciler) pruneBackups(ctx context.Context, backupSchedule *api.CassandraBackupSchedule) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	if backupSchedule.Spec.Retention == nil {
+		return true, nil
+	}
+
+	var backups api.CassandraBackupList
+	if err := r.Client.List(ctx, &backups, client.InNamespace(backupSchedule.Namespace), client.MatchingLabels{api.BackupScheduleLabel: backupSchedule.Name}); err != nil {
+		return false, err
+	}
+
+	// Running backups are neither counted nor deleted
+	completed := make([]*api.CassandraBackup, 0, len(backups.Items))
+	for i := range backups.Items {
+		if backups.Items[i].Status.CompletionTime != nil {
+			completed = append(completed, &backups.Items[i])
+		}
+	}
+	sort.Slice(completed, func(i, j int) bool {
+		ti, tj := completed[i].Status.CompletionTime, completed[j].Status.CompletionTime
+		if ti.Equal(tj) {
+			return completed[i].Name < completed[j].Name
+		}
+		return ti.Before(tj)
+	})
+
+	pruned := true
+	for i := 0; i < len(completed)-int(*backupSchedule.Spec.Retention); i++ {
+		backup := completed[i]
+		if err := r.deleteSnapshots(ctx, backup); err != nil {
+			logger.Error(err, "Failed to clear the snapshots of the backup", "Backup", backup.Name)
+			pruned = false
+			continue
+		}
+		if err := r.deleteUploads(ctx, backup); err != nil {
+			logger.Error(err, "Failed to delete the files of the backup from its storage", "Backup", backup.Name)
+			pruned = false
+			continue
+		}
+		if err := r.Client.Delete(ctx, backup); err != nil && !k8serrors.IsNotFound(err) {
+			return false, err
+		}
+		logger.Info("Deleted backup beyond the retention of the schedule", "Backup", backup.Name)
+	}
+
+	return pruned, nil
+}
+
+// deleteSnapshots deletes the VolumeSnapshots of the backup and clears its snapshots on the nodes where
+// they were taken
+func (r *CassandraBackupScheduleReconciler) deleteSnapshots(ctx context.Context, backup *api.CassandraBackup) error {
+	dcName := backupDatacenterName(backup)
+	if backup.Spec.VolumeSnapshots != nil {
+		// The VolumeSnapshots outlive the datacenter, and are not owned by a backup of another namespace
+		var volumeSnapshots unstructured.UnstructuredList
+		volumeSnapshots.SetGroupVersionKind(volumeSnapshotGVK.GroupVersion().WithKind(volumeSnapshotGVK.Kind + "List"))
+		if err := r.Client.List(ctx, &volumeSnapshots, client.InNamespace(dcName.Namespace), client.MatchingLabels{api.BackupLabel: backup.Name}); err != nil {
+			return err
+		}
+		for i := range volumeSnapshots.Items {
+			if err := r.Client.Delete(ctx, &volumeSnapshots.Items[i]); err != nil && !k8serrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	dc := &cassapi.CassandraDatacenter{}
+	if err := r.Get(ctx, dcName, dc); err != nil {
+		// The snapshots went away with the datacenter
+		return client.IgnoreNotFound(err)
+	}
+
+	var pods corev1.PodList
+	if err := r.Client.List(ctx, &pods, client.InNamespace(dc.Namespace), client.MatchingLabels(dc.GetDatacenterLabels())); err != nil {
+		return err
+	}
+
+	nodeMgmtClient, err := httphelper.NewMgmtClient(ctx, r.Client, dc)
+	if err != nil {
+		return err
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if backup.Status.Nodes[pod.Name].SnapshotTime == nil {
+			continue
+		}
+		if !isCassandraUp(pod) {
+			return fmt.Errorf("the node of pod %s is not ready", pod.Name)
+		}
+		if err := nodeMgmtClient.CallDeleteSnapshotEndpoint(pod, backup.Status.SnapshotName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteUploads deletes the files the backup uploaded to its storage, if it has one
+func (r *CassandraBackupScheduleReconciler) deleteUploads(ctx context.Context, backup *api.CassandraBackup) error {
+	if backup.Spec.Storage == nil {
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	secretName := types.NamespacedName{Namespace: backup.Namespace, Name: backup.Spec.Storage.CredentialsSecret.Name}
+	if err := r.Get(ctx, secretName, secret); err != nil {
+		return errors.Wrapf(err, "unable to fetch the credentials secret %s", secretName)
+	}
+
+	writer, err := storage.NewWriter(ctx, backup.Spec.Storage, secret.Data)
+	if err != nil {
+		return err
+	}
+
+	// The trailing slash keeps the files of the backups whose name starts with this one
+	return writer.Delete(ctx, backup.Name+"/")
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CassandraBackupScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Completed backups are pruned without waiting for the next run of their schedule
+	toSchedule := func(obj client.Object) []reconcile.Request {
+		name, found := obj.GetLabels()[api.BackupScheduleLabel]
+		if !found {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}}}
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&api.CassandraBackupSchedule{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &api.CassandraBackup{}}, handler.EnqueueRequestsFromMapFunc(toSchedule)).
+		Complete(r)
+}
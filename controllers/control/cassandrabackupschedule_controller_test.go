@@ -0,0 +1,236 @@
+package control
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	cassapi "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+	"github.com/k8ssandra/cass-operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/pkg/storage"
+)
+
+func TestCassandraBackupScheduleReconciler(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	callDetails := httphelper.NewCallDetails()
+	mockServer, err := httphelper.FakeExecutorServerWithDetails(callDetails)
+	require.NoError(err)
+	mockServer.Start()
+	defer mockServer.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(cassapi.AddToScheme(scheme))
+	require.NoError(api.AddToScheme(scheme))
+
+	dc := &cassapi.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "test"},
+		Spec:       cassapi.CassandraDatacenterSpec{ClusterName: "cluster1", Size: 1},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: "test", Labels: dc.GetDatacenterLabels()},
+		Status: corev1.PodStatus{
+			PodIP:             "127.0.0.1",
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}},
+		},
+	}
+	retention := int32(2)
+	backupSchedule := &api.CassandraBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "schedule1",
+			Namespace: "test",
+			// Created long enough ago for the first run to be due
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+		},
+		Spec: api.CassandraBackupScheduleSpec{
+			Schedule:       "@hourly",
+			Retention:      &retention,
+			BackupTemplate: api.CassandraBackupSpec{Datacenter: corev1.ObjectReference{Name: "dc1"}},
+		},
+	}
+
+	// Three completed backups of the schedule, the oldest one is beyond the retention
+	objs := []runtime.Object{dc, pod, backupSchedule}
+	for i := 1; i <= 3; i++ {
+		completionTime := metav1.NewTime(time.Now().Add(time.Duration(i-5) * time.Hour))
+		name := fmt.Sprintf("schedule1-%d", i)
+		objs = append(objs, &api.CassandraBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Labels: map[string]string{api.BackupScheduleLabel: "schedule1"}},
+			Spec:       api.CassandraBackupSpec{Datacenter: corev1.ObjectReference{Name: "dc1"}},
+			Status: api.CassandraBackupStatus{
+				SnapshotName:   name,
+				CompletionTime: &completionTime,
+				Nodes:          map[string]api.BackupNodeStatus{"pod-0": {SnapshotTime: &completionTime}},
+			},
+		})
+	}
+
+	r := &CassandraBackupScheduleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objs...).Build(),
+		Scheme: scheme,
+	}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "schedule1", Namespace: "test"}}
+
+	res, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.True(res.RequeueAfter > 0 && res.RequeueAfter <= time.Hour)
+
+	require.NoError(r.Get(ctx, req.NamespacedName, backupSchedule))
+	require.NotNil(backupSchedule.Status.LastScheduleTime)
+	require.NotNil(backupSchedule.Status.NextScheduleTime)
+	assert.Equal(0, backupSchedule.Status.NextScheduleTime.Minute())
+
+	var backups api.CassandraBackupList
+	require.NoError(r.List(ctx, &backups, client.MatchingLabels{api.BackupScheduleLabel: "schedule1"}))
+	names := make([]string, 0, len(backups.Items))
+	for _, backup := range backups.Items {
+		names = append(names, backup.Name)
+	}
+	// The oldest backup was deleted along with its snapshot, the new one is running
+	assert.Len(names, 3)
+	assert.NotContains(names, "schedule1-1")
+	assert.Equal(1, callDetails.URLCounts["/api/v0/ops/node/snapshots"])
+
+	// The missed runs were caught up with a single backup, the next one is not due yet
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	require.NoError(r.List(ctx, &backups, client.MatchingLabels{api.BackupScheduleLabel: "schedule1"}))
+	assert.Len(backups.Items, 3)
+}
+
+func TestCassandraBackupScheduleReconciler_DeleteVolumeSnapshots(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(cassapi.AddToScheme(scheme))
+	require.NoError(api.AddToScheme(scheme))
+
+	backup := &api.CassandraBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup1", Namespace: "backups"},
+		Spec: api.CassandraBackupSpec{
+			Datacenter:      corev1.ObjectReference{Name: "dc1", Namespace: "test"},
+			VolumeSnapshots: &api.VolumeSnapshotsConfig{},
+		},
+	}
+	newSnapshot := func(name, backupName string) *unstructured.Unstructured {
+		volumeSnapshot := &unstructured.Unstructured{}
+		volumeSnapshot.SetGroupVersionKind(volumeSnapshotGVK)
+		volumeSnapshot.SetNamespace("test")
+		volumeSnapshot.SetName(name)
+		volumeSnapshot.SetLabels(map[string]string{api.BackupLabel: backupName})
+		return volumeSnapshot
+	}
+
+	r := &CassandraBackupScheduleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).
+			WithRuntimeObjects(backup, newSnapshot("backup1-pod-0", "backup1"), newSnapshot("backup2-pod-0", "backup2")).
+			Build(),
+		Scheme: scheme,
+	}
+	ctx := context.Background()
+
+	// The VolumeSnapshots of the backup are deleted even though the datacenter is gone
+	require.NoError(r.deleteSnapshots(ctx, backup))
+
+	volumeSnapshot := &unstructured.Unstructured{}
+	volumeSnapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	err := r.Get(ctx, types.NamespacedName{Name: "backup1-pod-0", Namespace: "test"}, volumeSnapshot)
+	assert.True(k8serrors.IsNotFound(err))
+	assert.NoError(r.Get(ctx, types.NamespacedName{Name: "backup2-pod-0", Namespace: "test"}, volumeSnapshot))
+}
+
+func TestCassandraBackupScheduleReconciler_InvalidSchedule(t *testing.T) {
+	require := require.New(t)
+
+	scheme := runtime.NewScheme()
+	require.NoError(api.AddToScheme(scheme))
+
+	backupSchedule := &api.CassandraBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "schedule1", Namespace: "test", CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour))},
+		Spec:       api.CassandraBackupScheduleSpec{Schedule: "every day"},
+	}
+	r := &CassandraBackupScheduleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(backupSchedule).Build(),
+		Scheme: scheme,
+	}
+	ctx := context.Background()
+
+	res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "schedule1", Namespace: "test"}})
+	require.NoError(err)
+	require.Equal(ctrl.Result{}, res)
+
+	var backups api.CassandraBackupList
+	require.NoError(r.List(ctx, &backups))
+	require.Empty(backups.Items)
+}
+
+func TestCassandraBackupScheduleReconciler_DeleteUploads(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			assert.Equal("backup1/", r.URL.Query().Get("prefix"))
+			_, _ = w.Write([]byte("<ListBucketResult><Contents><Key>backup1/manifest.json</Key></Contents></ListBucketResult>"))
+		case http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(api.AddToScheme(scheme))
+
+	backup := &api.CassandraBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup1", Namespace: "test"},
+		Spec: api.CassandraBackupSpec{
+			Datacenter: corev1.ObjectReference{Name: "dc1"},
+			Storage: &api.BackupStorage{
+				Type:              api.StorageS3,
+				Bucket:            "bucket1",
+				Endpoint:          server.URL,
+				CredentialsSecret: corev1.LocalObjectReference{Name: "s3-credentials"},
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "s3-credentials", Namespace: "test"},
+		Data: map[string][]byte{
+			storage.S3AccessKeyIDKey:     []byte("AKIDEXAMPLE"),
+			storage.S3SecretAccessKeyKey: []byte("secret"),
+		},
+	}
+
+	r := &CassandraBackupScheduleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(backup, secret).Build(),
+		Scheme: scheme,
+	}
+
+	require.NoError(r.deleteUploads(context.Background(), backup))
+	assert.Equal([]string{"/bucket1/backup1/manifest.json"}, deleted)
+}
@@ -0,0 +1,492 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	cassapi "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+	"github.com/k8ssandra/cass-operator/pkg/images"
+	"github.com/k8ssandra/cass-operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/pkg/reconciliation"
+	"github.com/k8ssandra/cass-operator/pkg/storage"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// restoreScript replaces the SSTables of every table, except the ones of the node local system keyspace,
+// with the files of the snapshot, and drops the commit log of COMMITLOG_DIR so that it is not replayed
+// over the snapshot. It fails, leaving the data untouched, when the node has no snapshot.
+const restoreScript = `set -e
+cd /var/lib/cassandra/data
+if ! ls -d */*/snapshots/"$SNAPSHOT_NAME" >/dev/null 2>&1; then
+  echo "the snapshot $SNAPSHOT_NAME is not in the data of the node" >&2
+  exit 1
+fi
+for snapshot in */*/snapshots/"$SNAPSHOT_NAME"; do
+  [ -d "$snapshot" ] || continue
+  table="${snapshot%/snapshots/*}"
+  case "$table" in system/*) continue ;; esac
+  find "$table" -maxdepth 1 -type f -delete
+  cp -a "$snapshot"/. "$table"/
+  rm -f "$table"/manifest.json "$table"/schema.cql
+done
+rm -rf "$COMMITLOG_DIR"/*
+`
+
+// CassandraRestoreReconciler reconciles a CassandraRestore object
+type CassandraRestoreReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=control.k8ssandra.io,namespace=cass-operator,resources=cassandrarestores,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=control.k8ssandra.io,namespace=cass-operator,resources=cassandrarestores/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=control.k8ssandra.io,namespace=cass-operator,resources=cassandrarestores/finalizers,verbs=update
+//+kubebuilder:rbac:groups=batch,namespace=cass-operator,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile restores the datacenter of a backup in place: the datacenter is stopped, a job copies the
+// snapshot files of the backup back to the data of each node, and the datacenter is started again, unless
+// it was stopped before. When the backup has a storage, the job first downloads the snapshot of the node
+// if it is not on its data volume anymore.
+func (r *CassandraRestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var restore api.CassandraRestore
+	if err := r.Get(ctx, req.NamespacedName, &restore); err != nil {
+		logger.Error(err, "unable to fetch CassandraRestore", "Request", req.NamespacedName)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if restore.DeletionTimestamp != nil || restore.Status.CompletionTime != nil || hasRestoreCondition(&restore, api.JobFailed) {
+		return ctrl.Result{}, nil
+	}
+
+	backup := &api.CassandraBackup{}
+	backupName := types.NamespacedName{Namespace: restore.Namespace, Name: restore.Spec.Backup}
+	if err := r.Get(ctx, backupName, backup); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "unable to fetch CassandraBackup: %s", backupName)
+	}
+
+	if backup.Status.CompletionTime == nil {
+		logger.V(1).Info("the backup to restore is not complete yet", "Backup", backupName)
+		return ctrl.Result{RequeueAfter: taskRunningRequeue}, nil
+	}
+
+	dc := &cassapi.CassandraDatacenter{}
+	dcName := backupDatacenterName(backup)
+	if err := r.Get(ctx, dcName, dc); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "unable to fetch target CassandraDatacenter: %s", dcName)
+	}
+
+	logger = log.FromContext(ctx, "datacenterName", dc.Name, "clusterName", dc.Spec.ClusterName)
+
+	if restore.Status.StartTime == nil {
+		if restore.Labels == nil {
+			restore.Labels = make(map[string]string)
+		}
+		utils.MergeMap(restore.Labels, dc.GetDatacenterLabels())
+		oplabels.AddOperatorLabels(restore.GetLabels(), dc)
+
+		if err := r.Client.Update(ctx, &restore); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		timeNow := metav1.Now()
+		restore.Status.StartTime = &timeNow
+		restore.Status.Stage = api.RestoreStopping
+		restore.Status.DatacenterStopped = dc.Spec.Stopped
+		setRestoreCondition(&restore, api.JobRunning, corev1.ConditionTrue, "")
+	}
+
+	if restore.Status.Stage == api.RestoreStopping || restore.Status.Stage == api.RestoreCopying {
+		if !isCommitLogRestorable(dc) {
+			// The commit log would be replayed over the restored data
+			message := fmt.Sprintf("the commit log directory %s is not on a volume of the datacenter", dc.GetCommitLogDirectory())
+			logger.Info("Refusing to restore the datacenter", "reason", message)
+			setRestoreCondition(&restore, api.JobRunning, corev1.ConditionFalse, "")
+			setRestoreCondition(&restore, api.JobFailed, corev1.ConditionTrue, message)
+			return ctrl.Result{}, r.Client.Status().Update(ctx, &restore)
+		}
+
+		if missingPods := getPodsWithoutSnapshot(backup); len(missingPods) > 0 {
+			// Restoring the other nodes only would leave the datacenter inconsistent
+			message := fmt.Sprintf("the backup has no snapshot of pods %v", missingPods)
+			logger.Info("Refusing to restore the datacenter", "reason", message)
+			setRestoreCondition(&restore, api.JobRunning, corev1.ConditionFalse, "")
+			setRestoreCondition(&restore, api.JobFailed, corev1.ConditionTrue, message)
+			return ctrl.Result{}, r.Client.Status().Update(ctx, &restore)
+		}
+	}
+
+	var res ctrl.Result
+	var err error
+	switch restore.Status.Stage {
+	case api.RestoreStopping:
+		res, err = r.stopDatacenter(ctx, dc, &restore)
+	case api.RestoreCopying:
+		res, err = r.copySnapshots(ctx, dc, backup, &restore)
+	case api.RestoreStarting:
+		res, err = r.startDatacenter(ctx, dc, &restore)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if restore.Status.Stage == api.RestoreDone {
+		logger.Info("The datacenter was restored", "Backup", backupName)
+	}
+
+	if err := r.Client.Status().Update(ctx, &restore); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return res, nil
+}
+
+// stopDatacenter parks the datacenter, so that the data of its nodes can be replaced
+func (r *CassandraRestoreReconciler) stopDatacenter(ctx context.Context, dc *cassapi.CassandraDatacenter, restore *api.CassandraRestore) (ctrl.Result, error) {
+	if !dc.Spec.Stopped {
+		patch := client.MergeFrom(dc.DeepCopy())
+		dc.Spec.Stopped = true
+		if err := r.Client.Patch(ctx, dc, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: jobRunningRequeue}, nil
+	}
+
+	var pods corev1.PodList
+	if err := r.Client.List(ctx, &pods, client.InNamespace(dc.Namespace), client.MatchingLabels(dc.GetDatacenterLabels())); err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(pods.Items) > 0 {
+		// Still draining
+		return ctrl.Result{RequeueAfter: jobRunningRequeue}, nil
+	}
+
+	restore.Status.Stage = api.RestoreCopying
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// copySnapshots runs a job restoring the snapshot files on the data volume of every node of the backup
+func (r *CassandraRestoreReconciler) copySnapshots(ctx context.Context, dc *cassapi.CassandraDatacenter, backup *api.CassandraBackup, restore *api.CassandraRestore) (ctrl.Result, error) {
+	template, err := r.getServerPodTemplate(ctx, dc)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if restore.Status.Nodes == nil {
+		restore.Status.Nodes = make(map[string]api.RestoreNodeStatus)
+	}
+
+	podNames := make([]string, 0, len(backup.Status.Nodes))
+	for podName := range backup.Status.Nodes {
+		podNames = append(podNames, podName)
+	}
+	sort.Strings(podNames)
+
+	var failedPods []string
+	pending := 0
+	for _, podName := range podNames {
+		nodeStatus := restore.Status.Nodes[podName]
+		if nodeStatus.RestoreTime != nil {
+			continue
+		}
+
+		job := &batchv1.Job{}
+		jobName := types.NamespacedName{Namespace: dc.Namespace, Name: fmt.Sprintf("%s-%s", restore.Name, podName)}
+		if err := r.Client.Get(ctx, jobName, job); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+			job, err = newRestoreJob(jobName, dc, template, podName, backup)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			// Owner references can not cross namespaces
+			if restore.Namespace == dc.Namespace {
+				if err := controllerutil.SetControllerReference(restore, job, r.Scheme); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			if err := r.Client.Create(ctx, job); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		if job.Status.Succeeded > 0 {
+			timeNow := metav1.Now()
+			nodeStatus = api.RestoreNodeStatus{RestoreTime: &timeNow}
+		} else if job.Status.Failed > 0 {
+			nodeStatus.Error = fmt.Sprintf("the restore job %s failed", jobName.Name)
+			failedPods = append(failedPods, podName)
+		} else {
+			pending++
+		}
+		restore.Status.Nodes[podName] = nodeStatus
+	}
+
+	if len(failedPods) > 0 {
+		// The datacenter is left stopped, its data is not consistent anymore
+		setRestoreCondition(restore, api.JobRunning, corev1.ConditionFalse, "")
+		setRestoreCondition(restore, api.JobFailed, corev1.ConditionTrue, fmt.Sprintf("the restore of pods %v failed", failedPods))
+		return ctrl.Result{}, nil
+	}
+
+	if pending > 0 {
+		return ctrl.Result{RequeueAfter: jobRunningRequeue}, nil
+	}
+
+	restore.Status.Stage = api.RestoreStarting
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// startDatacenter resumes the datacenter, and waits for it to be ready. A datacenter which was stopped
+// before the restore is left stopped.
+func (r *CassandraRestoreReconciler) startDatacenter(ctx context.Context, dc *cassapi.CassandraDatacenter, restore *api.CassandraRestore) (ctrl.Result, error) {
+	if restore.Status.DatacenterStopped {
+		timeNow := metav1.Now()
+		restore.Status.CompletionTime = &timeNow
+		restore.Status.Stage = api.RestoreDone
+		setRestoreCondition(restore, api.JobRunning, corev1.ConditionFalse, "")
+		setRestoreCondition(restore, api.JobComplete, corev1.ConditionTrue, "the datacenter was stopped before the restore, it is left stopped")
+		return ctrl.Result{}, nil
+	}
+
+	if dc.Spec.Stopped {
+		patch := client.MergeFrom(dc.DeepCopy())
+		dc.Spec.Stopped = false
+		if err := r.Client.Patch(ctx, dc, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: jobRunningRequeue}, nil
+	}
+
+	if dc.GetConditionStatus(cassapi.DatacenterStopped) == corev1.ConditionTrue ||
+		dc.GetConditionStatus(cassapi.DatacenterResuming) == corev1.ConditionTrue ||
+		dc.GetConditionStatus(cassapi.DatacenterReady) != corev1.ConditionTrue ||
+		dc.Status.CassandraOperatorProgress != cassapi.ProgressReady {
+		return ctrl.Result{RequeueAfter: jobRunningRequeue}, nil
+	}
+
+	timeNow := metav1.Now()
+	restore.Status.CompletionTime = &timeNow
+	restore.Status.Stage = api.RestoreDone
+	setRestoreCondition(restore, api.JobRunning, corev1.ConditionFalse, "")
+	setRestoreCondition(restore, api.JobComplete, corev1.ConditionTrue, "")
+	return ctrl.Result{}, nil
+}
+
+// getServerPodTemplate returns the pod template of the datacenter StatefulSets, the restore jobs
+// use the same server image and security context to read and write the data volumes
+func (r *CassandraRestoreReconciler) getServerPodTemplate(ctx context.Context, dc *cassapi.CassandraDatacenter) (*corev1.PodTemplateSpec, error) {
+	var sts appsv1.StatefulSetList
+	if err := r.Client.List(ctx, &sts, client.InNamespace(dc.Namespace), client.MatchingLabels(dc.GetDatacenterLabels())); err != nil {
+		return nil, err
+	}
+	if len(sts.Items) == 0 {
+		return nil, fmt.Errorf("the datacenter %s has no StatefulSet", dc.Name)
+	}
+	return &sts.Items[0].Spec.Template, nil
+}
+
+// getRestoreVolumes returns the volumes of the server pod of a node that the restore job mounts, its server
+// data volume, its commit log claim and its additional volumes, along with where the server mounts them
+func getRestoreVolumes(dc *cassapi.CassandraDatacenter, podName string) ([]corev1.Volume, []corev1.VolumeMount) {
+	claimVolume := func(name string) corev1.Volume {
+		return corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: fmt.Sprintf("%s-%s", name, podName),
+				},
+			},
+		}
+	}
+
+	volumes := []corev1.Volume{claimVolume(reconciliation.PvcName)}
+	volumeMounts := []corev1.VolumeMount{{Name: reconciliation.PvcName, MountPath: "/var/lib/cassandra"}}
+
+	if dc.Spec.StorageConfig.CommitLogVolumeClaimSpec != nil {
+		volumes = append(volumes, claimVolume(cassapi.CommitLogVolumeName))
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: cassapi.CommitLogVolumeName, MountPath: cassapi.CommitLogDir})
+	}
+
+	for _, volume := range dc.Spec.StorageConfig.AdditionalVolumes {
+		volumes = append(volumes, claimVolume(volume.Name))
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: volume.Name, MountPath: volume.MountPath})
+	}
+
+	return volumes, volumeMounts
+}
+
+// getPodsWithoutSnapshot returns the pods of the backup whose snapshot was not taken
+func getPodsWithoutSnapshot(backup *api.CassandraBackup) []string {
+	var podNames []string
+	for podName, nodeStatus := range backup.Status.Nodes {
+		if nodeStatus.SnapshotTime == nil {
+			podNames = append(podNames, podName)
+		}
+	}
+	sort.Strings(podNames)
+	return podNames
+}
+
+// isCommitLogRestorable checks that the commit log directory of the datacenter is on one of the volumes
+// mounted by the restore jobs, so that they can drop it
+func isCommitLogRestorable(dc *cassapi.CassandraDatacenter) bool {
+	dir := path.Clean(dc.GetCommitLogDirectory())
+	_, volumeMounts := getRestoreVolumes(dc, "")
+	for _, volumeMount := range volumeMounts {
+		mountPath := path.Clean(volumeMount.MountPath)
+		if dir == mountPath || strings.HasPrefix(dir, mountPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// newRestoreJob returns the job restoring the snapshot of the node. With the storage of the backup, an init
+// container running the operator image first downloads the snapshot if it is not on the data volume anymore.
+func newRestoreJob(name types.NamespacedName, dc *cassapi.CassandraDatacenter, template *corev1.PodTemplateSpec, podName string, backup *api.CassandraBackup) (*batchv1.Job, error) {
+	image := ""
+	var containerSecurityContext *corev1.SecurityContext
+	for _, container := range template.Spec.Containers {
+		if container.Name == "cassandra" {
+			image = container.Image
+			containerSecurityContext = container.SecurityContext
+		}
+	}
+
+	labels := utils.MergeMap(map[string]string{}, dc.GetDatacenterLabels())
+	oplabels.AddOperatorLabels(labels, dc)
+
+	volumes, volumeMounts := getRestoreVolumes(dc, podName)
+
+	var initContainers []corev1.Container
+	if backup.Spec.Storage != nil {
+		backupStorage, err := json.Marshal(backup.Spec.Storage)
+		if err != nil {
+			return nil, err
+		}
+		initContainers = append(initContainers, corev1.Container{
+			Name:            "download",
+			Image:           images.GetBackupUploaderImage(),
+			ImagePullPolicy: images.GetImageConfig().ImagePullPolicy,
+			Command:         []string{"/manager", storage.DownloadSnapshotCommand},
+			SecurityContext: containerSecurityContext,
+			Env: []corev1.EnvVar{
+				{Name: storage.StorageEnv, Value: string(backupStorage)},
+				{Name: storage.SnapshotEnv, Value: backup.Status.SnapshotName},
+				{Name: storage.KeyPrefixEnv, Value: backup.Name + "/" + podName},
+				{Name: storage.DataDirEnv, Value: "/var/lib/cassandra/data"},
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: reconciliation.PvcName, MountPath: "/var/lib/cassandra"},
+				{Name: "credentials", MountPath: storage.CredentialsDir, ReadOnly: true},
+			},
+		})
+		volumes = append(volumes, corev1.Volume{
+			Name: "credentials",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: backup.Spec.Storage.CredentialsSecret.Name},
+			},
+		})
+	}
+
+	backoffLimit := int32(2)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyNever,
+					SecurityContext:  template.Spec.SecurityContext,
+					ImagePullSecrets: template.Spec.ImagePullSecrets,
+					InitContainers:   initContainers,
+					Containers: []corev1.Container{
+						{
+							Name:            "restore",
+							Image:           image,
+							Command:         []string{"/bin/sh", "-c", restoreScript},
+							SecurityContext: containerSecurityContext,
+							Env: []corev1.EnvVar{
+								{Name: "SNAPSHOT_NAME", Value: backup.Status.SnapshotName},
+								{Name: "COMMITLOG_DIR", Value: dc.GetCommitLogDirectory()},
+							},
+							VolumeMounts: volumeMounts,
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+	if backup.Spec.Storage != nil {
+		images.AddDefaultRegistryImagePullSecrets(&job.Spec.Template.Spec)
+	}
+	return job, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CassandraRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&api.CassandraRestore{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
+
+func hasRestoreCondition(restore *api.CassandraRestore, condition api.JobConditionType) bool {
+	for _, cond := range restore.Status.Conditions {
+		if cond.Type == condition {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func setRestoreCondition(restore *api.CassandraRestore, condition api.JobConditionType, status corev1.ConditionStatus, message string) {
+	restore.Status.Conditions = setJobCondition(restore.Status.Conditions, condition, status, message)
+}
@@ -0,0 +1,323 @@
+package control
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	cassapi "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+	"github.com/k8ssandra/cass-operator/pkg/storage"
+)
+
+func setupRestoreTest(t *testing.T) (*CassandraRestoreReconciler, *cassapi.CassandraDatacenter) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, cassapi.AddToScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+
+	dc := &cassapi.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "test"},
+		Spec:       cassapi.CassandraDatacenterSpec{ClusterName: "cluster1", Size: 2},
+	}
+	timeNow := metav1.Now()
+	backup := &api.CassandraBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup1", Namespace: "test"},
+		Spec:       api.CassandraBackupSpec{Datacenter: corev1.ObjectReference{Name: "dc1"}},
+		Status: api.CassandraBackupStatus{
+			SnapshotName:   "backup1",
+			CompletionTime: &timeNow,
+			Nodes: map[string]api.BackupNodeStatus{
+				"cluster1-dc1-default-sts-0": {SnapshotTime: &timeNow},
+				"cluster1-dc1-default-sts-1": {SnapshotTime: &timeNow},
+			},
+		},
+	}
+	restore := &api.CassandraRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore1", Namespace: "test"},
+		Spec:       api.CassandraRestoreSpec{Backup: "backup1"},
+	}
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1-dc1-default-sts", Namespace: "test", Labels: dc.GetDatacenterLabels()},
+		Spec: appsv1.StatefulSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "cassandra", Image: "cassandra:4.0.1"}},
+				},
+			},
+		},
+	}
+
+	r := &CassandraRestoreReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(dc, backup, restore, sts).Build(),
+		Scheme: scheme,
+	}
+	return r, dc
+}
+
+func TestCassandraRestoreReconciler(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	r, dc := setupRestoreTest(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "restore1", Namespace: "test"}}
+	dcName := types.NamespacedName{Name: "dc1", Namespace: "test"}
+	restore := &api.CassandraRestore{}
+
+	// The datacenter is stopped first
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	require.NoError(r.Get(ctx, dcName, dc))
+	assert.True(dc.Spec.Stopped)
+	require.NoError(r.Get(ctx, req.NamespacedName, restore))
+	assert.Equal(api.RestoreStopping, restore.Status.Stage)
+	assert.NotNil(restore.Status.StartTime)
+
+	// Once its pods are gone, a job restores the data of each node
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	require.NoError(r.Get(ctx, req.NamespacedName, restore))
+	assert.Equal(api.RestoreCopying, restore.Status.Stage)
+
+	res, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(jobRunningRequeue, res.RequeueAfter)
+
+	for _, podName := range []string{"cluster1-dc1-default-sts-0", "cluster1-dc1-default-sts-1"} {
+		job := &batchv1.Job{}
+		require.NoError(r.Get(ctx, types.NamespacedName{Name: "restore1-" + podName, Namespace: "test"}, job))
+		container := job.Spec.Template.Spec.Containers[0]
+		assert.Equal("cassandra:4.0.1", container.Image)
+		assert.Equal([]corev1.EnvVar{
+			{Name: "SNAPSHOT_NAME", Value: "backup1"},
+			{Name: "COMMITLOG_DIR", Value: "/var/lib/cassandra/commitlog"},
+		}, container.Env)
+		assert.Equal("server-data-"+podName, job.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+
+		job.Status.Succeeded = 1
+		require.NoError(r.Status().Update(ctx, job))
+	}
+
+	// The datacenter is started again once all the jobs succeeded
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	require.NoError(r.Get(ctx, req.NamespacedName, restore))
+	assert.Equal(api.RestoreStarting, restore.Status.Stage)
+	assert.NotNil(restore.Status.Nodes["cluster1-dc1-default-sts-0"].RestoreTime)
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	require.NoError(r.Get(ctx, dcName, dc))
+	assert.False(dc.Spec.Stopped)
+
+	res, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(jobRunningRequeue, res.RequeueAfter)
+
+	dc.Status.CassandraOperatorProgress = cassapi.ProgressReady
+	dc.SetCondition(*cassapi.NewDatacenterCondition(cassapi.DatacenterReady, corev1.ConditionTrue))
+	require.NoError(r.Status().Update(ctx, dc))
+
+	res, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(ctrl.Result{}, res)
+	require.NoError(r.Get(ctx, req.NamespacedName, restore))
+	assert.Equal(api.RestoreDone, restore.Status.Stage)
+	assert.NotNil(restore.Status.CompletionTime)
+	assert.True(hasRestoreCondition(restore, api.JobComplete))
+	assert.False(hasRestoreCondition(restore, api.JobRunning))
+}
+
+func TestCassandraRestoreReconciler_JobFails(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	r, dc := setupRestoreTest(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "restore1", Namespace: "test"}}
+
+	for i := 0; i < 3; i++ {
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(err)
+	}
+
+	job := &batchv1.Job{}
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "restore1-cluster1-dc1-default-sts-1", Namespace: "test"}, job))
+	job.Status.Failed = 3
+	require.NoError(r.Status().Update(ctx, job))
+
+	res, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(ctrl.Result{}, res)
+
+	restore := &api.CassandraRestore{}
+	require.NoError(r.Get(ctx, req.NamespacedName, restore))
+	assert.True(hasRestoreCondition(restore, api.JobFailed))
+	assert.NotEmpty(restore.Status.Nodes["cluster1-dc1-default-sts-1"].Error)
+	assert.Nil(restore.Status.CompletionTime)
+
+	// The datacenter is left stopped
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "dc1", Namespace: "test"}, dc))
+	assert.True(dc.Spec.Stopped)
+}
+
+func TestCassandraRestoreReconciler_CommitLogVolume(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	r, dc := setupRestoreTest(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "restore1", Namespace: "test"}}
+
+	dc.Spec.StorageConfig.AdditionalVolumes = cassapi.AdditionalVolumesSlice{
+		{Name: "commitlog", MountPath: "/commitlog"},
+	}
+	dc.Spec.Config = []byte(`{"cassandra-yaml": {"commitlog_directory": "/commitlog/data"}}`)
+	require.NoError(r.Update(ctx, dc))
+
+	for i := 0; i < 3; i++ {
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(err)
+	}
+
+	// The commit log volume is mounted where the server mounts it, and cleared
+	job := &batchv1.Job{}
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "restore1-cluster1-dc1-default-sts-0", Namespace: "test"}, job))
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Contains(container.Env, corev1.EnvVar{Name: "COMMITLOG_DIR", Value: "/commitlog/data"})
+	assert.Contains(container.VolumeMounts, corev1.VolumeMount{Name: "commitlog", MountPath: "/commitlog"})
+	assert.Equal("commitlog-cluster1-dc1-default-sts-0", job.Spec.Template.Spec.Volumes[1].PersistentVolumeClaim.ClaimName)
+}
+
+func TestCassandraRestoreReconciler_CommitLogNotOnVolume(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	r, dc := setupRestoreTest(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "restore1", Namespace: "test"}}
+
+	dc.Spec.Config = []byte(`{"cassandra-yaml": {"commitlog_directory": "/commitlog"}}`)
+	require.NoError(r.Update(ctx, dc))
+
+	res, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(ctrl.Result{}, res)
+
+	// The restore is refused before the datacenter is stopped
+	restore := &api.CassandraRestore{}
+	require.NoError(r.Get(ctx, req.NamespacedName, restore))
+	assert.True(hasRestoreCondition(restore, api.JobFailed))
+	assert.False(hasRestoreCondition(restore, api.JobRunning))
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "dc1", Namespace: "test"}, dc))
+	assert.False(dc.Spec.Stopped)
+}
+
+func TestCassandraRestoreReconciler_MissingSnapshot(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	r, dc := setupRestoreTest(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "restore1", Namespace: "test"}}
+
+	backup := &api.CassandraBackup{}
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "backup1", Namespace: "test"}, backup))
+	backup.Status.Nodes["cluster1-dc1-default-sts-1"] = api.BackupNodeStatus{Error: "the snapshot failed"}
+	require.NoError(r.Status().Update(ctx, backup))
+
+	res, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(ctrl.Result{}, res)
+
+	// The restore is refused before the datacenter is stopped
+	restore := &api.CassandraRestore{}
+	require.NoError(r.Get(ctx, req.NamespacedName, restore))
+	assert.True(hasRestoreCondition(restore, api.JobFailed))
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "dc1", Namespace: "test"}, dc))
+	assert.False(dc.Spec.Stopped)
+}
+
+func TestCassandraRestoreReconciler_StoppedDatacenter(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	r, dc := setupRestoreTest(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "restore1", Namespace: "test"}}
+
+	dc.Spec.Stopped = true
+	require.NoError(r.Update(ctx, dc))
+
+	for i := 0; i < 3; i++ {
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(err)
+	}
+
+	for _, podName := range []string{"cluster1-dc1-default-sts-0", "cluster1-dc1-default-sts-1"} {
+		job := &batchv1.Job{}
+		require.NoError(r.Get(ctx, types.NamespacedName{Name: "restore1-" + podName, Namespace: "test"}, job))
+		job.Status.Succeeded = 1
+		require.NoError(r.Status().Update(ctx, job))
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(err)
+	}
+
+	// The restore completes without starting the datacenter
+	restore := &api.CassandraRestore{}
+	require.NoError(r.Get(ctx, req.NamespacedName, restore))
+	assert.Equal(api.RestoreDone, restore.Status.Stage)
+	assert.True(hasRestoreCondition(restore, api.JobComplete))
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "dc1", Namespace: "test"}, dc))
+	assert.True(dc.Spec.Stopped)
+}
+
+func TestCassandraRestoreReconciler_DownloadSnapshot(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	r, _ := setupRestoreTest(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "restore1", Namespace: "test"}}
+
+	backup := &api.CassandraBackup{}
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "backup1", Namespace: "test"}, backup))
+	backup.Spec.Storage = &api.BackupStorage{
+		Type:              api.StorageS3,
+		Bucket:            "backups",
+		CredentialsSecret: corev1.LocalObjectReference{Name: "s3-credentials"},
+	}
+	require.NoError(r.Update(ctx, backup))
+
+	for i := 0; i < 3; i++ {
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(err)
+	}
+
+	// An init container downloads the snapshot of the node from the storage of the backup
+	job := &batchv1.Job{}
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "restore1-cluster1-dc1-default-sts-0", Namespace: "test"}, job))
+	require.Len(job.Spec.Template.Spec.InitContainers, 1)
+	initContainer := job.Spec.Template.Spec.InitContainers[0]
+	assert.Equal([]string{"/manager", storage.DownloadSnapshotCommand}, initContainer.Command)
+	assert.Contains(initContainer.Env, corev1.EnvVar{Name: storage.KeyPrefixEnv, Value: "backup1/cluster1-dc1-default-sts-0"})
+	assert.Contains(initContainer.VolumeMounts, corev1.VolumeMount{Name: "server-data", MountPath: "/var/lib/cassandra"})
+	assert.Equal("s3-credentials", job.Spec.Template.Spec.Volumes[len(job.Spec.Template.Spec.Volumes)-1].Secret.SecretName)
+}
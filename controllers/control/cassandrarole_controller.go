@@ -0,0 +1,401 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	cassapi "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+	"github.com/k8ssandra/cass-operator/pkg/httphelper"
+	"github.com/pkg/errors"
+)
+
+// defaultSuperuserName is the superuser Cassandra creates when it starts without any role
+const defaultSuperuserName = "cassandra"
+
+// CassandraRoleReconciler reconciles a CassandraRole object
+type CassandraRoleReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// AllowSuperusers lets the CassandraRoles create superuser roles
+	AllowSuperusers bool
+}
+
+//+kubebuilder:rbac:groups=control.k8ssandra.io,namespace=cass-operator,resources=cassandraroles,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=control.k8ssandra.io,namespace=cass-operator,resources=cassandraroles/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=control.k8ssandra.io,namespace=cass-operator,resources=cassandraroles/finalizers,verbs=update
+
+// Reconcile creates the role in the cluster with the username and password of its secret, through the
+// management API of a ready node of the datacenter. The role is updated again whenever its spec or its
+// secret changes, which rotates its password. If the username of the secret changes, the previous role
+// is dropped once the new one exists. Deleting the CassandraRole drops its role from the cluster. The
+// datacenter must be in the namespace of the role, superuser roles must be allowed by the config of the
+// operator, the superusers and users of the cluster are refused, and so are the existing roles not created
+// by a CassandraRole of the namespace. The roles still used by another CassandraRole are kept.
+func (r *CassandraRoleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var role api.CassandraRole
+	if err := r.Get(ctx, req.NamespacedName, &role); err != nil {
+		logger.Error(err, "unable to fetch CassandraRole", "Request", req.NamespacedName)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if role.DeletionTimestamp != nil {
+		return r.deleteRole(ctx, &role)
+	}
+
+	if !controllerutil.ContainsFinalizer(&role, api.RoleFinalizer) {
+		controllerutil.AddFinalizer(&role, api.RoleFinalizer)
+		if err := r.Client.Update(ctx, &role); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	secret := &corev1.Secret{}
+	secretName := types.NamespacedName{Namespace: role.Namespace, Name: role.Spec.SecretName}
+	if err := r.Get(ctx, secretName, secret); err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "unable to fetch the secret of the role %s", secretName)
+	}
+
+	if role.Status.Error == "" && role.Status.ObservedGeneration == role.Generation && role.Status.SecretResourceVersion == secret.ResourceVersion {
+		return ctrl.Result{}, nil
+	}
+
+	res := ctrl.Result{}
+	username, password := string(secret.Data["username"]), string(secret.Data["password"])
+	dcName := roleDatacenterName(&role)
+	reserved, owner := false, ""
+	if dcName.Namespace == role.Namespace {
+		var err error
+		if reserved, owner, err = r.isReservedRoleName(ctx, &role, username); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if dcName.Namespace != role.Namespace {
+		// Anyone allowed to create a CassandraRole could otherwise create roles in the clusters of other namespaces
+		role.Status.Error = fmt.Sprintf("the datacenter %s is not in the namespace of the role", dcName)
+	} else if role.Spec.Superuser && !r.AllowSuperusers {
+		role.Status.Error = "superuser roles are not allowed, allowSuperuserRoles must be set in the config of the operator"
+	} else if username == "" || password == "" {
+		// The role is retried once its secret is fixed
+		role.Status.Error = fmt.Sprintf("the secret %s must have a username and a password", secretName)
+	} else if reserved {
+		role.Status.Error = fmt.Sprintf("the role %s is %s, it can not be managed by a CassandraRole", username, owner)
+	} else if err := r.upsertRole(ctx, &role, username, password); err != nil {
+		logger.Error(err, "Failed to create the role", "Role", req.NamespacedName)
+		role.Status.Error = err.Error()
+		res.RequeueAfter = jobRunningRequeue
+	} else {
+		timeNow := metav1.Now()
+		role.Status.RoleName = username
+		role.Status.ObservedGeneration = role.Generation
+		role.Status.SecretResourceVersion = secret.ResourceVersion
+		role.Status.UpsertedTime = &timeNow
+		role.Status.Error = ""
+		logger.Info("The role was created or updated", "Role", req.NamespacedName, "RoleName", username)
+	}
+
+	if err := r.Client.Status().Update(ctx, &role); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return res, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CassandraRoleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Changing the secret of a role rotates its password
+	toRoles := func(obj client.Object) []reconcile.Request {
+		var roles api.CassandraRoleList
+		if err := r.List(context.Background(), &roles, client.InNamespace(obj.GetNamespace())); err != nil {
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, role := range roles.Items {
+			if role.Spec.SecretName == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: role.Namespace, Name: role.Name}})
+			}
+		}
+		return requests
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&api.CassandraRole{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(toRoles)).
+		Complete(r)
+}
+
+// upsertRole creates or updates the role in the cluster, and drops the role it replaces if the username
+// of its secret changed
+func (r *CassandraRoleReconciler) upsertRole(ctx context.Context, role *api.CassandraRole, username, password string) error {
+	dc, pod, err := r.findReadyPod(ctx, role)
+	if err != nil {
+		return err
+	}
+
+	nodeMgmtClient, err := httphelper.NewMgmtClient(ctx, r.Client, dc)
+	if err != nil {
+		return err
+	}
+
+	// The role is only taken over from another CassandraRole of the namespace, an existing role created by
+	// anything else keeps its password and is never dropped
+	if username != role.Status.RoleName {
+		users, err := r.getRoleUsers(ctx, role, username)
+		if err != nil {
+			return err
+		}
+		shared := false
+		for _, other := range users {
+			shared = shared || other.Namespace == role.Namespace
+		}
+		if !shared {
+			roles, err := nodeMgmtClient.CallListRolesEndpoint(pod)
+			if err != nil {
+				return err
+			}
+			for _, existing := range roles {
+				if existing == username {
+					return fmt.Errorf("the role %s already exists in the cluster and was not created by a CassandraRole of the namespace %s", username, role.Namespace)
+				}
+			}
+		}
+	}
+
+	if err := nodeMgmtClient.CallCreateRoleWithLoginEndpoint(pod, username, password, role.Spec.Superuser, role.CanLogin()); err != nil {
+		return err
+	}
+
+	if previous := role.Status.RoleName; previous != "" && previous != username {
+		inUse, err := r.isRoleNameInUse(ctx, role, previous)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			log.FromContext(ctx).Info("The previous role is used by another CassandraRole, it is not dropped", "RoleName", previous)
+		} else if err := nodeMgmtClient.CallDropRoleEndpoint(pod, previous); err != nil {
+			return errors.Wrapf(err, "unable to drop the previous role %s", previous)
+		}
+	}
+	return nil
+}
+
+// deleteRole drops the role from the cluster and removes the finalizer of the CassandraRole. Nothing is
+// dropped if the datacenter no longer exists, or if another CassandraRole manages the same role.
+func (r *CassandraRoleReconciler) deleteRole(ctx context.Context, role *api.CassandraRole) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(role, api.RoleFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	inUse, err := r.isRoleNameInUse(ctx, role, role.Status.RoleName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if inUse {
+		log.FromContext(ctx).Info("The role is used by another CassandraRole, it is not dropped", "RoleName", role.Status.RoleName)
+	} else if role.Status.RoleName != "" {
+		dc, pod, err := r.findReadyPod(ctx, role)
+		if k8serrors.IsNotFound(errors.Cause(err)) {
+			log.FromContext(ctx).Info("The datacenter of the role no longer exists, nothing to drop", "RoleName", role.Status.RoleName)
+		} else if err != nil {
+			return ctrl.Result{}, err
+		} else {
+			nodeMgmtClient, err := httphelper.NewMgmtClient(ctx, r.Client, dc)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if err := nodeMgmtClient.CallDropRoleEndpoint(pod, role.Status.RoleName); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	controllerutil.RemoveFinalizer(role, api.RoleFinalizer)
+	return ctrl.Result{}, r.Client.Update(ctx, role)
+}
+
+// findReadyPod returns the datacenter of the role and the first of its pods whose node is ready
+func (r *CassandraRoleReconciler) findReadyPod(ctx context.Context, role *api.CassandraRole) (*cassapi.CassandraDatacenter, *corev1.Pod, error) {
+	dc := &cassapi.CassandraDatacenter{}
+	dcNamespacedName := roleDatacenterName(role)
+	if err := r.Get(ctx, dcNamespacedName, dc); err != nil {
+		return nil, nil, errors.Wrapf(err, "unable to fetch target CassandraDatacenter: %s", dcNamespacedName)
+	}
+
+	var pods corev1.PodList
+	if err := r.Client.List(ctx, &pods, client.InNamespace(dc.Namespace), client.MatchingLabels(dc.GetDatacenterLabels())); err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].Name < pods.Items[j].Name
+	})
+
+	for i := range pods.Items {
+		if isCassandraUp(&pods.Items[i]) {
+			return dc, &pods.Items[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no node of the datacenter %s is ready", dcNamespacedName)
+}
+
+// isReservedRoleName returns true, with the owner of the role, for the default cassandra superuser and
+// the superusers and the users of spec.users of every datacenter of the cluster of the role, which the
+// operator manages on its own
+func (r *CassandraRoleReconciler) isReservedRoleName(ctx context.Context, role *api.CassandraRole, username string) (bool, string, error) {
+	if username == defaultSuperuserName {
+		return true, "the superuser of the cluster", nil
+	}
+
+	dcs, err := r.getClusterDatacenters(ctx, role)
+	if err != nil {
+		return false, "", err
+	}
+
+	for _, dc := range dcs {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, dc.GetSuperuserSecretNamespacedName(), secret); client.IgnoreNotFound(err) != nil {
+			return false, "", err
+		} else if err == nil && string(secret.Data["username"]) == username {
+			return true, "the superuser of the cluster", nil
+		}
+
+		for _, user := range dc.Spec.Users {
+			// the operator reports the missing secrets of the users on the datacenter
+			secret := &corev1.Secret{}
+			if err := r.Get(ctx, types.NamespacedName{Name: user.SecretName, Namespace: dc.Namespace}, secret); client.IgnoreNotFound(err) != nil {
+				return false, "", err
+			} else if err == nil && string(secret.Data["username"]) == username {
+				return true, fmt.Sprintf("a user of the datacenter %s", dc.Name), nil
+			}
+		}
+	}
+	return false, "", nil
+}
+
+// getClusterDatacenters returns the datacenter of the role and the other datacenters of its cluster, as
+// the roles are shared by all the datacenters of a cluster. Nothing is returned if the datacenter of the
+// role does not exist.
+func (r *CassandraRoleReconciler) getClusterDatacenters(ctx context.Context, role *api.CassandraRole) ([]cassapi.CassandraDatacenter, error) {
+	dc := &cassapi.CassandraDatacenter{}
+	if err := r.Get(ctx, roleDatacenterName(role), dc); err != nil {
+		// upsertRole reports the missing datacenter
+		return nil, client.IgnoreNotFound(err)
+	}
+
+	var dcs cassapi.CassandraDatacenterList
+	if err := r.List(ctx, &dcs); err != nil {
+		return nil, err
+	}
+	clusterDcs := []cassapi.CassandraDatacenter{*dc}
+	for _, other := range dcs.Items {
+		if other.Spec.ClusterName == dc.Spec.ClusterName && (other.Namespace != dc.Namespace || other.Name != dc.Name) {
+			clusterDcs = append(clusterDcs, other)
+		}
+	}
+	return clusterDcs, nil
+}
+
+// getRoleClusterName returns the name of the cluster of the datacenter of the role, or an empty string if
+// the datacenter does not exist
+func (r *CassandraRoleReconciler) getRoleClusterName(ctx context.Context, role *api.CassandraRole) (string, error) {
+	dc := &cassapi.CassandraDatacenter{}
+	if err := r.Get(ctx, roleDatacenterName(role), dc); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	return dc.Spec.ClusterName, nil
+}
+
+// getRoleUsers returns the other CassandraRoles managing the role in the cluster of the role
+func (r *CassandraRoleReconciler) getRoleUsers(ctx context.Context, role *api.CassandraRole, roleName string) ([]api.CassandraRole, error) {
+	if roleName == "" {
+		return nil, nil
+	}
+
+	clusterName, err := r.getRoleClusterName(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	var roles api.CassandraRoleList
+	if err := r.List(ctx, &roles); err != nil {
+		return nil, err
+	}
+	var users []api.CassandraRole
+	for _, other := range roles.Items {
+		if (other.Namespace == role.Namespace && other.Name == role.Name) || other.DeletionTimestamp != nil {
+			continue
+		}
+		if other.Status.RoleName != roleName {
+			continue
+		}
+		if roleDatacenterName(&other) == roleDatacenterName(role) {
+			users = append(users, other)
+			continue
+		}
+		if clusterName == "" {
+			continue
+		}
+		otherClusterName, err := r.getRoleClusterName(ctx, &other)
+		if err != nil {
+			return nil, err
+		}
+		if otherClusterName == clusterName {
+			users = append(users, other)
+		}
+	}
+	return users, nil
+}
+
+// isRoleNameInUse returns true if another CassandraRole of the same cluster manages the role
+func (r *CassandraRoleReconciler) isRoleNameInUse(ctx context.Context, role *api.CassandraRole, roleName string) (bool, error) {
+	users, err := r.getRoleUsers(ctx, role, roleName)
+	return len(users) > 0, err
+}
+
+// roleDatacenterName returns the name of the datacenter of the role, which defaults to the namespace
+// of the role
+func roleDatacenterName(role *api.CassandraRole) types.NamespacedName {
+	name := types.NamespacedName{
+		Namespace: role.Spec.Datacenter.Namespace,
+		Name:      role.Spec.Datacenter.Name,
+	}
+	if name.Namespace == "" {
+		name.Namespace = role.Namespace
+	}
+	return name
+}
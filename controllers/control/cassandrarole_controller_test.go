@@ -0,0 +1,457 @@
+package control
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	cassapi "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+	"github.com/k8ssandra/cass-operator/pkg/httphelper"
+)
+
+func TestCassandraRoleReconciler(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	callDetails := httphelper.NewCallDetails()
+	mockServer, err := httphelper.FakeExecutorServerWithDetails(callDetails)
+	require.NoError(err)
+	mockServer.Start()
+	defer mockServer.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(cassapi.AddToScheme(scheme))
+	require.NoError(api.AddToScheme(scheme))
+
+	dc := &cassapi.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "test"},
+		Spec:       cassapi.CassandraDatacenterSpec{ClusterName: "cluster1", Size: 1},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: "test", Labels: dc.GetDatacenterLabels()},
+		Status: corev1.PodStatus{
+			PodIP:             "127.0.0.1",
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: false}},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-credentials", Namespace: "test"},
+		Data:       map[string][]byte{"username": []byte("app"), "password": []byte("password1")},
+	}
+	role := &api.CassandraRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"},
+		Spec: api.CassandraRoleSpec{
+			Datacenter: corev1.ObjectReference{Name: "dc1"},
+			SecretName: "app-credentials",
+		},
+	}
+
+	r := &CassandraRoleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(dc, pod, secret, role).Build(),
+		Scheme: scheme,
+	}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "app", Namespace: "test"}}
+
+	// The role waits for a node to be ready
+	res, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(jobRunningRequeue, res.RequeueAfter)
+	assert.Equal(0, callDetails.URLCounts["/api/v0/ops/auth/role"])
+
+	require.NoError(r.Get(ctx, req.NamespacedName, role))
+	assert.True(controllerutil.ContainsFinalizer(role, api.RoleFinalizer))
+	assert.NotEmpty(role.Status.Error)
+	assert.Nil(role.Status.UpsertedTime)
+
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "pod-0", Namespace: "test"}, pod))
+	pod.Status.ContainerStatuses[0].Ready = true
+	require.NoError(r.Status().Update(ctx, pod))
+
+	// The roles of the cluster are listed before the role is created
+	res, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(ctrl.Result{}, res)
+	assert.Equal(2, callDetails.URLCounts["/api/v0/ops/auth/role"])
+
+	require.NoError(r.Get(ctx, req.NamespacedName, role))
+	assert.Equal("app", role.Status.RoleName)
+	assert.NotNil(role.Status.UpsertedTime)
+	assert.Empty(role.Status.Error)
+
+	// An up to date role is left alone
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(2, callDetails.URLCounts["/api/v0/ops/auth/role"])
+
+	// Changing the password of the secret rotates the password of the role
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "app-credentials", Namespace: "test"}, secret))
+	secret.Data["password"] = []byte("password2")
+	require.NoError(r.Update(ctx, secret))
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(3, callDetails.URLCounts["/api/v0/ops/auth/role"])
+
+	// Changing the username of the secret creates the new role and drops the previous one
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "app-credentials", Namespace: "test"}, secret))
+	secret.Data["username"] = []byte("app2")
+	require.NoError(r.Update(ctx, secret))
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(6, callDetails.URLCounts["/api/v0/ops/auth/role"])
+
+	require.NoError(r.Get(ctx, req.NamespacedName, role))
+	assert.Equal("app2", role.Status.RoleName)
+
+	// Deleting the CassandraRole drops its role
+	require.NoError(r.Delete(ctx, role))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(7, callDetails.URLCounts["/api/v0/ops/auth/role"])
+	assert.True(k8serrors.IsNotFound(r.Get(ctx, req.NamespacedName, role)))
+}
+
+func TestCassandraRoleReconciler_MissingDatacenter(t *testing.T) {
+	require := require.New(t)
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(cassapi.AddToScheme(scheme))
+	require.NoError(api.AddToScheme(scheme))
+
+	now := metav1.Now()
+	role := &api.CassandraRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "app",
+			Namespace:         "test",
+			Finalizers:        []string{api.RoleFinalizer},
+			DeletionTimestamp: &now,
+		},
+		Spec: api.CassandraRoleSpec{
+			Datacenter: corev1.ObjectReference{Name: "dc1"},
+			SecretName: "app-credentials",
+		},
+		Status: api.CassandraRoleStatus{RoleName: "app"},
+	}
+
+	r := &CassandraRoleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(role).Build(),
+		Scheme: scheme,
+	}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "app", Namespace: "test"}}
+
+	// The role of a deleted datacenter has nothing to drop, its finalizer is removed
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	require.True(k8serrors.IsNotFound(r.Get(ctx, req.NamespacedName, role)))
+}
+
+func TestCassandraRoleReconciler_Superusers(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	callDetails := httphelper.NewCallDetails()
+	mockServer, err := httphelper.FakeExecutorServerWithDetails(callDetails)
+	require.NoError(err)
+	mockServer.Start()
+	defer mockServer.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(cassapi.AddToScheme(scheme))
+	require.NoError(api.AddToScheme(scheme))
+
+	dc := &cassapi.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "test"},
+		Spec: cassapi.CassandraDatacenterSpec{
+			ClusterName: "cluster1",
+			Size:        1,
+			Users:       []cassapi.CassandraUser{{SecretName: "reporting-user"}},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: "test", Labels: dc.GetDatacenterLabels()},
+		Status: corev1.PodStatus{
+			PodIP:             "127.0.0.1",
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}},
+		},
+	}
+	superuserSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1-superuser", Namespace: "test"},
+		Data:       map[string][]byte{"username": []byte("admin"), "password": []byte("password1")},
+	}
+	userSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "reporting-user", Namespace: "test"},
+		Data:       map[string][]byte{"username": []byte("reporting"), "password": []byte("password2")},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-credentials", Namespace: "test"},
+		Data:       map[string][]byte{"username": []byte("admin"), "password": []byte("password1")},
+	}
+	role := &api.CassandraRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"},
+		Spec: api.CassandraRoleSpec{
+			Datacenter: corev1.ObjectReference{Name: "dc1"},
+			SecretName: "app-credentials",
+		},
+	}
+
+	r := &CassandraRoleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(dc, pod, superuserSecret, userSecret, secret, role).Build(),
+		Scheme: scheme,
+	}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "app", Namespace: "test"}}
+
+	// The superuser of the operator is refused
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(0, callDetails.URLCounts["/api/v0/ops/auth/role"])
+	require.NoError(r.Get(ctx, req.NamespacedName, role))
+	assert.Contains(role.Status.Error, "the role admin is the superuser of the cluster")
+
+	// and so is the default superuser
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "app-credentials", Namespace: "test"}, secret))
+	secret.Data["username"] = []byte("cassandra")
+	require.NoError(r.Update(ctx, secret))
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(0, callDetails.URLCounts["/api/v0/ops/auth/role"])
+	require.NoError(r.Get(ctx, req.NamespacedName, role))
+	assert.Contains(role.Status.Error, "the role cassandra is the superuser of the cluster")
+	assert.Empty(role.Status.RoleName)
+
+	// and so are the users of the datacenter
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "app-credentials", Namespace: "test"}, secret))
+	secret.Data["username"] = []byte("reporting")
+	require.NoError(r.Update(ctx, secret))
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(0, callDetails.URLCounts["/api/v0/ops/auth/role"])
+	require.NoError(r.Get(ctx, req.NamespacedName, role))
+	assert.Contains(role.Status.Error, "the role reporting is a user of the datacenter dc1")
+	assert.Empty(role.Status.RoleName)
+}
+
+func TestCassandraRoleReconciler_SharedRole(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	callDetails := httphelper.NewCallDetails()
+	mockServer, err := httphelper.FakeExecutorServerWithDetails(callDetails)
+	require.NoError(err)
+	mockServer.Start()
+	defer mockServer.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(cassapi.AddToScheme(scheme))
+	require.NoError(api.AddToScheme(scheme))
+
+	dc := &cassapi.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "test"},
+		Spec:       cassapi.CassandraDatacenterSpec{ClusterName: "cluster1", Size: 1},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: "test", Labels: dc.GetDatacenterLabels()},
+		Status: corev1.PodStatus{
+			PodIP:             "127.0.0.1",
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-credentials", Namespace: "test"},
+		Data:       map[string][]byte{"username": []byte("app"), "password": []byte("password1")},
+	}
+	otherSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-credentials", Namespace: "test"},
+		Data:       map[string][]byte{"username": []byte("app"), "password": []byte("password1")},
+	}
+	role := &api.CassandraRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"},
+		Spec: api.CassandraRoleSpec{
+			Datacenter: corev1.ObjectReference{Name: "dc1"},
+			SecretName: "app-credentials",
+		},
+	}
+	otherRole := &api.CassandraRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "test"},
+		Spec: api.CassandraRoleSpec{
+			Datacenter: corev1.ObjectReference{Name: "dc1"},
+			SecretName: "other-credentials",
+		},
+	}
+
+	r := &CassandraRoleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(dc, pod, secret, otherSecret, role, otherRole).Build(),
+		Scheme: scheme,
+	}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "app", Namespace: "test"}}
+	otherReq := ctrl.Request{NamespacedName: types.NamespacedName{Name: "other", Namespace: "test"}}
+
+	// Both CassandraRoles manage the app role, the second one takes it over from the first one without
+	// listing the roles of the cluster
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	_, err = r.Reconcile(ctx, otherReq)
+	require.NoError(err)
+	assert.Equal(3, callDetails.URLCounts["/api/v0/ops/auth/role"])
+	require.NoError(r.Get(ctx, otherReq.NamespacedName, otherRole))
+	assert.Equal("app", otherRole.Status.RoleName)
+
+	// Renaming the role of one of them keeps the app role of the other one
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "app-credentials", Namespace: "test"}, secret))
+	secret.Data["username"] = []byte("app2")
+	require.NoError(r.Update(ctx, secret))
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(5, callDetails.URLCounts["/api/v0/ops/auth/role"], "only app2 should be listed and created")
+
+	require.NoError(r.Get(ctx, req.NamespacedName, role))
+	assert.Equal("app2", role.Status.RoleName)
+
+	// Deleting a CassandraRole keeps the role another one still manages
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "app-credentials", Namespace: "test"}, secret))
+	secret.Data["username"] = []byte("app")
+	require.NoError(r.Update(ctx, secret))
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(7, callDetails.URLCounts["/api/v0/ops/auth/role"], "app should be updated and app2 dropped")
+
+	require.NoError(r.Delete(ctx, otherRole))
+	_, err = r.Reconcile(ctx, otherReq)
+	require.NoError(err)
+	assert.Equal(7, callDetails.URLCounts["/api/v0/ops/auth/role"])
+	assert.True(k8serrors.IsNotFound(r.Get(ctx, otherReq.NamespacedName, otherRole)))
+}
+
+func TestCassandraRoleReconciler_Restrictions(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	callDetails := httphelper.NewCallDetails()
+	mockServer, err := httphelper.FakeExecutorServerWithDetails(callDetails)
+	require.NoError(err)
+	mockServer.Start()
+	defer mockServer.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(clientgoscheme.AddToScheme(scheme))
+	require.NoError(cassapi.AddToScheme(scheme))
+	require.NoError(api.AddToScheme(scheme))
+
+	// Two datacenters of the same cluster in two namespaces
+	dc1 := &cassapi.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "test"},
+		Spec:       cassapi.CassandraDatacenterSpec{ClusterName: "cluster1", Size: 1},
+	}
+	dc2 := &cassapi.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc2", Namespace: "other"},
+		Spec:       cassapi.CassandraDatacenterSpec{ClusterName: "cluster1", Size: 1},
+	}
+	newPod := func(dc *cassapi.CassandraDatacenter) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: dc.Name + "-pod-0", Namespace: dc.Namespace, Labels: dc.GetDatacenterLabels()},
+			Status: corev1.PodStatus{
+				PodIP:             "127.0.0.1",
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}},
+			},
+		}
+	}
+	newSecret := func(namespace, name, username string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       map[string][]byte{"username": []byte(username), "password": []byte("password1")},
+		}
+	}
+	newRole := func(namespace, name string, dc corev1.ObjectReference) *api.CassandraRole {
+		return &api.CassandraRole{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       api.CassandraRoleSpec{Datacenter: dc, SecretName: name + "-credentials"},
+		}
+	}
+
+	otherRole := newRole("other", "app", corev1.ObjectReference{Name: "dc2"})
+	superRole := newRole("test", "super", corev1.ObjectReference{Name: "dc1"})
+	superRole.Spec.Superuser = true
+	r := &CassandraRoleReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(
+			dc1, dc2, newPod(dc1), newPod(dc2),
+			newSecret("other", "cluster1-superuser", "other-admin"),
+			newSecret("other", "app-credentials", "app"),
+			newSecret("test", "app-credentials", "app"),
+			newSecret("test", "admin-credentials", "other-admin"),
+			newSecret("test", "foreign-credentials", "foreign"),
+			newSecret("test", "super-credentials", "super"),
+			otherRole,
+			newRole("test", "app", corev1.ObjectReference{Name: "dc1"}),
+			newRole("test", "admin", corev1.ObjectReference{Name: "dc1"}),
+			newRole("test", "foreign", corev1.ObjectReference{Name: "dc2", Namespace: "other"}),
+			superRole,
+		).Build(),
+		Scheme: scheme,
+	}
+	ctx := context.Background()
+	reconcileRole := func(namespace, name string) *api.CassandraRole {
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(err)
+		role := &api.CassandraRole{}
+		require.NoError(r.Get(ctx, req.NamespacedName, role))
+		return role
+	}
+
+	// The datacenter must be in the namespace of the role
+	role := reconcileRole("test", "foreign")
+	assert.Contains(role.Status.Error, "the datacenter other/dc2 is not in the namespace of the role")
+	assert.Equal(0, callDetails.URLCounts["/api/v0/ops/auth/role"])
+
+	// The superuser of another datacenter of the cluster is reserved
+	role = reconcileRole("test", "admin")
+	assert.Contains(role.Status.Error, "the role other-admin is the superuser of the cluster")
+	assert.Equal(0, callDetails.URLCounts["/api/v0/ops/auth/role"])
+
+	// Superuser roles must be allowed by the operator
+	role = reconcileRole("test", "super")
+	assert.Contains(role.Status.Error, "superuser roles are not allowed")
+	assert.Empty(role.Status.RoleName)
+
+	r.AllowSuperusers = true
+	role = reconcileRole("test", "super")
+	assert.Empty(role.Status.Error)
+	assert.Equal("super", role.Status.RoleName)
+
+	// A role created in the cluster by a CassandraRole of another namespace is not taken over
+	role = reconcileRole("other", "app")
+	assert.Equal("app", role.Status.RoleName)
+
+	role = reconcileRole("test", "app")
+	assert.Contains(role.Status.Error, "the role app already exists in the cluster and was not created by a CassandraRole of the namespace test")
+	assert.Empty(role.Status.RoleName)
+
+	// and it is still in use when deleting a CassandraRole of the same cluster that managed it
+	inUse, err := r.isRoleNameInUse(ctx, newRole("test", "app", corev1.ObjectReference{Name: "dc1"}), "app")
+	require.NoError(err)
+	assert.True(inUse)
+}
@@ -292,6 +292,14 @@ JobDefinition:
 			// res, failed, completed, err = r.reconcileDatacenter(ctx, &dc, forceupgrade(taskConfigProto))
 		case api.CommandUpgradeSSTables:
 			upgradesstables(taskConfig)
+		case api.CommandRepair:
+			// This job iterates over the keyspaces, then the pods, and tracks its progress in the task status
+			SetCondition(&cassTask, api.JobRunning, corev1.ConditionTrue)
+			res, failed, completed, err = r.repair(ctx, dc, taskConfig, &cassTask.Status)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			break JobDefinition
 		case api.CommandScrub:
 			// res, failed, completed, err = r.reconcileEveryPodTask(ctx, &dc, scrub(taskConfigProto))
 		case api.CommandCompaction:
@@ -541,6 +549,20 @@ func (r *CassandraTaskReconciler) cleanupJobAnnotations(ctx context.Context, dc
 	return nil
 }
 
+// sortPodsByRack sorts the pods by rack, then by name
+func sortPodsByRack(pods []corev1.Pod) {
+	sort.Slice(pods, func(i, j int) bool {
+		rackI := pods[i].Labels[cassapi.RackLabel]
+		rackJ := pods[j].Labels[cassapi.RackLabel]
+
+		if rackI != rackJ {
+			return rackI < rackJ
+		}
+
+		return pods[i].Name < pods[j].Name
+	})
+}
+
 // reconcileEveryPodTask executes the given task against all the Datacenter pods
 func (r *CassandraTaskReconciler) reconcileEveryPodTask(ctx context.Context, dc *cassapi.CassandraDatacenter, taskConfig *TaskConfiguration) (ctrl.Result, int, int, error) {
 	logger := log.FromContext(ctx)
@@ -551,16 +573,7 @@ func (r *CassandraTaskReconciler) reconcileEveryPodTask(ctx context.Context, dc
 		return ctrl.Result{}, 0, 0, err
 	}
 
-	sort.Slice(dcPods, func(i, j int) bool {
-		rackI := dcPods[i].Labels[cassapi.RackLabel]
-		rackJ := dcPods[j].Labels[cassapi.RackLabel]
-
-		if rackI != rackJ {
-			return rackI < rackJ
-		}
-
-		return dcPods[i].Name < dcPods[j].Name
-	})
+	sortPodsByRack(dcPods)
 
 	nodeMgmtClient, err := httphelper.NewMgmtClient(ctx, r.Client, dc)
 	if err != nil {
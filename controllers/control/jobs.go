@@ -7,12 +7,14 @@ import (
 	"time"
 
 	"github.com/k8ssandra/cass-operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/pkg/reconciliation"
 	"github.com/k8ssandra/cass-operator/pkg/utils"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	cassapi "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
 	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
@@ -77,12 +79,7 @@ func (r *CassandraTaskReconciler) restartSts(ctx context.Context, sts []appsv1.S
 		if st.Spec.Template.ObjectMeta.Annotations[api.RestartedAtAnnotation] == restartTime {
 			// This one has been called to restart already - is it ready?
 
-			status := st.Status
-			if status.CurrentRevision == status.UpdateRevision &&
-				status.UpdatedReplicas == status.Replicas &&
-				status.CurrentReplicas == status.Replicas &&
-				status.ReadyReplicas == status.Replicas &&
-				status.ObservedGeneration == st.GetObjectMeta().GetGeneration() {
+			if reconciliation.IsStatefulSetUpdated(&st) && st.Status.ReadyReplicas == st.Status.Replicas {
 				// This one has been updated, move on to the next one
 				continue
 			}
@@ -105,15 +102,21 @@ func (r *CassandraTaskReconciler) restartSts(ctx context.Context, sts []appsv1.S
 // UpgradeSSTables functionality
 
 func callUpgradeSSTables(nodeMgmtClient httphelper.NodeMgmtClient, pod *corev1.Pod, taskConfig *TaskConfiguration) (string, error) {
-	// TODO Add more arguments configurations
 	keyspaceName := taskConfig.Arguments.KeyspaceName
-	return nodeMgmtClient.CallUpgradeSSTables(pod, -1, keyspaceName, nil)
+	return nodeMgmtClient.CallUpgradeSSTables(pod, upgradeSSTablesJobs(taskConfig), keyspaceName, nil)
 }
 
 func callUpgradeSSTablesSync(nodeMgmtClient httphelper.NodeMgmtClient, pod *corev1.Pod, taskConfig *TaskConfiguration) error {
-	// TODO Add more arguments configurations
 	keyspaceName := taskConfig.Arguments.KeyspaceName
-	return nodeMgmtClient.CallUpgradeSSTablesEndpoint(pod, -1, keyspaceName, nil)
+	return nodeMgmtClient.CallUpgradeSSTablesEndpoint(pod, upgradeSSTablesJobs(taskConfig), keyspaceName, nil)
+}
+
+// upgradeSSTablesJobs returns the jobs argument of the management API, -1 to leave it to the node
+func upgradeSSTablesJobs(taskConfig *TaskConfiguration) int {
+	if taskConfig.Arguments.Jobs > 0 {
+		return taskConfig.Arguments.Jobs
+	}
+	return -1
 }
 
 func upgradesstables(taskConfig *TaskConfiguration) {
@@ -207,6 +210,180 @@ func (r *CassandraTaskReconciler) replace(taskConfig *TaskConfiguration) {
 	taskConfig.PreProcessFunc = r.replacePreProcess
 }
 
+// Repair functionality
+
+// localSystemKeyspaces are not replicated, there is nothing to repair
+var localSystemKeyspaces = map[string]bool{
+	"system":                true,
+	"system_schema":         true,
+	"system_views":          true,
+	"system_virtual_schema": true,
+}
+
+// repair runs the repair of the keyspaces one after the other, and of each keyspace one node at a time. Its
+// progress is tracked in the status of the task, the pods which are not ready are waited for.
+func (r *CassandraTaskReconciler) repair(ctx context.Context, dc *cassapi.CassandraDatacenter, taskConfig *TaskConfiguration, taskStatus *api.CassandraTaskStatus) (ctrl.Result, int, int, error) {
+	logger := log.FromContext(ctx)
+
+	pods, err := r.getDatacenterPods(ctx, dc)
+	if err != nil {
+		return ctrl.Result{}, 0, 0, err
+	}
+	sortPodsByRack(pods)
+
+	nodeMgmtClient, err := httphelper.NewMgmtClient(ctx, r.Client, dc)
+	if err != nil {
+		return ctrl.Result{}, 0, 0, err
+	}
+
+	if taskStatus.Keyspaces == nil {
+		keyspaces, err := repairedKeyspaces(nodeMgmtClient, pods, taskConfig.Arguments.KeyspaceName)
+		if err != nil {
+			return ctrl.Result{}, 0, 0, err
+		}
+		taskStatus.Keyspaces = make([]api.KeyspaceRepairStatus, 0, len(keyspaces))
+		for _, keyspace := range keyspaces {
+			taskStatus.Keyspaces = append(taskStatus.Keyspaces, api.KeyspaceRepairStatus{Name: keyspace})
+		}
+	}
+
+	pause := time.Duration(taskConfig.Arguments.PauseSeconds) * time.Second
+	var lastRepairTime *metav1.Time
+
+	for i := range taskStatus.Keyspaces {
+		keyspace := &taskStatus.Keyspaces[i]
+		if keyspace.CompletionTime != nil {
+			lastRepairTime = keyspace.CompletionTime
+			continue
+		}
+		if keyspace.LastRepairTime != nil {
+			lastRepairTime = keyspace.LastRepairTime
+		}
+
+		if keyspace.JobId != "" {
+			pod := findPod(pods, keyspace.CurrentPod)
+			if pod == nil {
+				keyspace.FailedPods = append(keyspace.FailedPods, keyspace.CurrentPod)
+				keyspace.Error = fmt.Sprintf("pod %s was deleted during the repair", keyspace.CurrentPod)
+				keyspace.CurrentPod, keyspace.JobId = "", ""
+			} else if details, err := nodeMgmtClient.JobDetails(pod, keyspace.JobId); err != nil {
+				return ctrl.Result{}, 0, 0, err
+			} else {
+				switch {
+				case details.Id == "":
+					// The pod most likely restarted, its repair is retried
+					logger.V(1).Info("The repair job was not found, restarting it", "Pod", pod.Name, "Keyspace", keyspace.Name)
+					keyspace.JobId = ""
+				case details.Status == podJobError:
+					logger.Error(fmt.Errorf("repair failed: %s", details.Error), "Failed to repair the keyspace", "Pod", pod.Name, "Keyspace", keyspace.Name)
+					keyspace.FailedPods = append(keyspace.FailedPods, pod.Name)
+					keyspace.Error = details.Error
+					keyspace.CurrentPod, keyspace.JobId = "", ""
+				case details.Status == podJobCompleted:
+					timeNow := metav1.Now()
+					keyspace.RepairedPods = append(keyspace.RepairedPods, pod.Name)
+					keyspace.LastRepairTime = &timeNow
+					keyspace.CurrentPod, keyspace.JobId = "", ""
+					lastRepairTime = keyspace.LastRepairTime
+				default:
+					return ctrl.Result{RequeueAfter: jobRunningRequeue}, 0, 0, nil
+				}
+			}
+		}
+
+		if keyspace.JobId == "" {
+			pod := nextRepairedPod(pods, keyspace)
+			if pod == nil {
+				timeNow := metav1.Now()
+				keyspace.CompletionTime = &timeNow
+				continue
+			}
+
+			if lastRepairTime != nil && pause > 0 {
+				if remaining := time.Until(lastRepairTime.Add(pause)); remaining > 0 {
+					return ctrl.Result{RequeueAfter: remaining}, 0, 0, nil
+				}
+			}
+
+			if !isCassandraUp(pod) {
+				logger.V(1).Info("Waiting for the pod to be ready to repair it", "Pod", pod.Name)
+				return ctrl.Result{RequeueAfter: jobRunningRequeue}, 0, 0, nil
+			}
+
+			jobId, err := nodeMgmtClient.CallRepair(pod, keyspace.Name, taskConfig.Arguments.Full)
+			if err != nil {
+				return ctrl.Result{}, 0, 0, err
+			}
+			keyspace.CurrentPod, keyspace.JobId = pod.Name, jobId
+			return ctrl.Result{RequeueAfter: jobRunningRequeue}, 0, 0, nil
+		}
+	}
+
+	// Every keyspace was repaired, the pods which failed any of them are failed
+	failed, completed := 0, 0
+	for _, pod := range pods {
+		podFailed := false
+		for _, keyspace := range taskStatus.Keyspaces {
+			podFailed = podFailed || utils.IndexOfString(keyspace.FailedPods, pod.Name) >= 0
+		}
+		if podFailed {
+			failed++
+		} else {
+			completed++
+		}
+	}
+
+	return ctrl.Result{}, failed, completed, nil
+}
+
+// repairedKeyspaces returns the keyspace of the task if it has one, otherwise all the replicated keyspaces
+func repairedKeyspaces(nodeMgmtClient httphelper.NodeMgmtClient, pods []corev1.Pod, keyspaceName string) ([]string, error) {
+	if keyspaceName != "" {
+		return []string{keyspaceName}, nil
+	}
+
+	for i := range pods {
+		if !isCassandraUp(&pods[i]) {
+			continue
+		}
+		keyspaces, err := nodeMgmtClient.ListKeyspaces(&pods[i])
+		if err != nil {
+			return nil, err
+		}
+
+		repaired := make([]string, 0, len(keyspaces))
+		for _, keyspace := range keyspaces {
+			if !localSystemKeyspaces[keyspace] {
+				repaired = append(repaired, keyspace)
+			}
+		}
+		sort.Strings(repaired)
+		return repaired, nil
+	}
+
+	return nil, fmt.Errorf("no pod is ready to list the keyspaces to repair")
+}
+
+// nextRepairedPod returns the first pod which did not repair the keyspace yet
+func nextRepairedPod(pods []corev1.Pod, keyspace *api.KeyspaceRepairStatus) *corev1.Pod {
+	for i := range pods {
+		name := pods[i].Name
+		if utils.IndexOfString(keyspace.RepairedPods, name) < 0 && utils.IndexOfString(keyspace.FailedPods, name) < 0 {
+			return &pods[i]
+		}
+	}
+	return nil
+}
+
+func findPod(pods []corev1.Pod, name string) *corev1.Pod {
+	for i := range pods {
+		if pods[i].Name == name {
+			return &pods[i]
+		}
+	}
+	return nil
+}
+
 // Common functions
 
 func isCassandraUp(pod *corev1.Pod) bool {
@@ -0,0 +1,164 @@
+package control
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	cassapi "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+	"github.com/k8ssandra/cass-operator/pkg/httphelper"
+)
+
+const repairEndpoint = "/api/v1/ops/node/repair"
+
+func setupRepairTest(t *testing.T, args api.JobArguments) (*CassandraTaskReconciler, *httphelper.CallDetails) {
+	callDetails := httphelper.NewCallDetails()
+	mockServer, err := httphelper.FakeExecutorServerWithDetails(callDetails)
+	require.NoError(t, err)
+	mockServer.Start()
+	t.Cleanup(mockServer.Close)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, cassapi.AddToScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+
+	dc := &cassapi.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "test"},
+		Spec:       cassapi.CassandraDatacenterSpec{ClusterName: "cluster1", Size: 2},
+	}
+	task := &api.CassandraTask{
+		ObjectMeta: metav1.ObjectMeta{Name: "repair1", Namespace: "test", UID: "repair1-uid"},
+		Spec: api.CassandraTaskSpec{
+			Datacenter: corev1.ObjectReference{Name: "dc1", Namespace: "test"},
+			Jobs:       []api.CassandraJob{{Name: "repair", Command: api.CommandRepair, Arguments: args}},
+		},
+	}
+	objs := []runtime.Object{dc, task}
+	for i := 0; i < 2; i++ {
+		objs = append(objs, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), Namespace: "test", Labels: dc.GetDatacenterLabels()},
+			Status: corev1.PodStatus{
+				PodIP:             "127.0.0.1",
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}},
+			},
+		})
+	}
+
+	r := &CassandraTaskReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objs...).Build(),
+		Scheme: scheme,
+	}
+	return r, callDetails
+}
+
+func TestRepair(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	r, callDetails := setupRepairTest(t, api.JobArguments{})
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "repair1", Namespace: "test"}}
+	task := &api.CassandraTask{}
+
+	// The replicated keyspaces are repaired one node at a time
+	for i := 1; i <= 4; i++ {
+		res, err := r.Reconcile(ctx, req)
+		require.NoError(err)
+		assert.Equal(jobRunningRequeue, res.RequeueAfter)
+		assert.Equal(i, callDetails.URLCounts[repairEndpoint])
+	}
+
+	require.NoError(r.Get(ctx, req.NamespacedName, task))
+	require.Len(task.Status.Keyspaces, 2)
+	assert.Equal("ks1", task.Status.Keyspaces[0].Name)
+	assert.Equal([]string{"pod-0", "pod-1"}, task.Status.Keyspaces[0].RepairedPods)
+	assert.NotNil(task.Status.Keyspaces[0].CompletionTime)
+	assert.Equal("system_auth", task.Status.Keyspaces[1].Name)
+	assert.Equal([]string{"pod-0"}, task.Status.Keyspaces[1].RepairedPods)
+	assert.Equal("pod-1", task.Status.Keyspaces[1].CurrentPod)
+	assert.Nil(task.Status.Keyspaces[1].CompletionTime)
+
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(4, callDetails.URLCounts[repairEndpoint])
+
+	require.NoError(r.Get(ctx, req.NamespacedName, task))
+	assert.NotNil(task.Status.CompletionTime)
+	assert.NotNil(task.Status.Keyspaces[1].CompletionTime)
+	assert.Equal(2, task.Status.Succeeded)
+	assert.Equal(0, task.Status.Failed)
+}
+
+func TestRepair_Pause(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	r, callDetails := setupRepairTest(t, api.JobArguments{KeyspaceName: "ks1", Full: true, PauseSeconds: 60})
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "repair1", Namespace: "test"}}
+
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(1, callDetails.URLCounts[repairEndpoint])
+
+	// The next node waits for the pause after the repair of the first one
+	res, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(1, callDetails.URLCounts[repairEndpoint])
+	assert.True(res.RequeueAfter > 50*time.Second && res.RequeueAfter <= time.Minute)
+
+	task := &api.CassandraTask{}
+	require.NoError(r.Get(ctx, req.NamespacedName, task))
+	require.Len(task.Status.Keyspaces, 1)
+	assert.Equal([]string{"pod-0"}, task.Status.Keyspaces[0].RepairedPods)
+	assert.NotNil(task.Status.Keyspaces[0].LastRepairTime)
+}
+
+func TestRepair_DeletedPod(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	r, callDetails := setupRepairTest(t, api.JobArguments{KeyspaceName: "ks1"})
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "repair1", Namespace: "test"}}
+
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(1, callDetails.URLCounts[repairEndpoint])
+
+	// The repair of the deleted pod is failed and the next pod is repaired in the same pass
+	pod := &corev1.Pod{}
+	require.NoError(r.Get(ctx, types.NamespacedName{Name: "pod-0", Namespace: "test"}, pod))
+	require.NoError(r.Delete(ctx, pod))
+
+	res, err := r.Reconcile(ctx, req)
+	require.NoError(err)
+	assert.Equal(jobRunningRequeue, res.RequeueAfter)
+	assert.Equal(2, callDetails.URLCounts[repairEndpoint])
+
+	task := &api.CassandraTask{}
+	require.NoError(r.Get(ctx, req.NamespacedName, task))
+	require.Len(task.Status.Keyspaces, 1)
+	assert.Equal([]string{"pod-0"}, task.Status.Keyspaces[0].FailedPods)
+	assert.Equal("pod-1", task.Status.Keyspaces[0].CurrentPod)
+	assert.Nil(task.Status.Keyspaces[0].CompletionTime)
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(err)
+	require.NoError(r.Get(ctx, req.NamespacedName, task))
+	assert.Equal([]string{"pod-1"}, task.Status.Keyspaces[0].RepairedPods)
+	assert.NotNil(task.Status.Keyspaces[0].CompletionTime)
+}
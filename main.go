@@ -17,7 +17,9 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"os"
 	"strings"
 
@@ -39,6 +41,8 @@ import (
 	controllers "github.com/k8ssandra/cass-operator/controllers/cassandra"
 	controlcontrollers "github.com/k8ssandra/cass-operator/controllers/control"
 	"github.com/k8ssandra/cass-operator/pkg/images"
+	"github.com/k8ssandra/cass-operator/pkg/reconciliation"
+	"github.com/k8ssandra/cass-operator/pkg/storage"
 	"github.com/k8ssandra/cass-operator/pkg/utils"
 	//+kubebuilder:scaffold:imports
 )
@@ -58,6 +62,24 @@ func init() {
 }
 
 func main() {
+	// The upload jobs of the backups run the operator image to upload the snapshot of a node
+	if len(os.Args) > 1 && os.Args[1] == storage.UploadSnapshotCommand {
+		if err := storage.RunUploadSnapshot(context.Background()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// The restore jobs of the backups with a storage run it to download the snapshot of a node
+	if len(os.Args) > 1 && os.Args[1] == storage.DownloadSnapshotCommand {
+		if err := storage.RunDownloadSnapshot(context.Background()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var configFile string
 	flag.StringVar(&configFile, "config", "",
 		"The controller will load its initial configuration from this file. "+
@@ -112,11 +134,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	podEvictor, err := reconciliation.NewPodEvictor(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create pod evictor")
+		os.Exit(1)
+	}
+
 	if err = (&controllers.CassandraDatacenterReconciler{
-		Client:   mgr.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("CassandraDatacenter"),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("cass-operator"),
+		Client:     mgr.GetClient(),
+		Log:        ctrl.Log.WithName("controllers").WithName("CassandraDatacenter"),
+		Scheme:     mgr.GetScheme(),
+		Recorder:   mgr.GetEventRecorderFor("cass-operator"),
+		PodEvictor: podEvictor,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CassandraDatacenter")
 		os.Exit(1)
@@ -135,6 +164,35 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "CassandraTask")
 		os.Exit(1)
 	}
+	if err = (&controlcontrollers.CassandraBackupReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CassandraBackup")
+		os.Exit(1)
+	}
+	if err = (&controlcontrollers.CassandraRestoreReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CassandraRestore")
+		os.Exit(1)
+	}
+	if err = (&controlcontrollers.CassandraBackupScheduleReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CassandraBackupSchedule")
+		os.Exit(1)
+	}
+	if err = (&controlcontrollers.CassandraRoleReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		AllowSuperusers: operConfig.AllowSuperuserRoles,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CassandraRole")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
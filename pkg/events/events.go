@@ -31,6 +31,24 @@ const (
 	StartingCassandra                 string = "StartingCassandra"
 	DecommissionDatacenter            string = "DecommissionDatacenter"
 	UnhealthyDatacenter               string = "UnhealthyDatacenter"
+	InvalidDatacenterSpec             string = "InvalidDatacenterSpec"
+	ResizingVolumes                   string = "ResizingVolumes"
+	RackZoneMismatch                  string = "RackZoneMismatch"
+	UpdatedConfig                     string = "UpdatedConfig"
+	LostReadiness                     string = "LostReadiness"
+	RebuildingDatacenter              string = "RebuildingDatacenter"
+	RegisteredInReaper                string = "RegisteredInReaper"
+	UpdatedKeyspaceReplication        string = "UpdatedKeyspaceReplication"
+	UpdatedSeedEndpoints              string = "UpdatedSeedEndpoints"
+	MovingPod                         string = "MovingPod"
+	ReschedulingPod                   string = "ReschedulingPod"
+	MigratingStorage                  string = "MigratingStorage"
+	ScaleDownBlocked                  string = "ScaleDownBlocked"
+	SnapshottingBeforeUpgrade         string = "SnapshottingBeforeUpgrade"
+	UpgradingSSTables                 string = "UpgradingSSTables"
+	UpdatingPod                       string = "UpdatingPod"
+	DisabledDefaultSuperuser          string = "DisabledDefaultSuperuser"
+	KeptDefaultSuperuser              string = "KeptDefaultSuperuser"
 )
 
 type LoggingEventRecorder struct {
@@ -20,7 +20,9 @@ import (
 	"github.com/go-logr/logr"
 
 	cassdcapi "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/monitoring"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -29,6 +31,9 @@ type NodeMgmtClient struct {
 	Client   HttpClient
 	Log      logr.Logger
 	Protocol string
+
+	// Ctx cancels the requests and their retries, context.Background() if nil
+	Ctx context.Context
 }
 
 type nodeMgmtRequest struct {
@@ -84,6 +89,20 @@ func (e *EndpointState) HasStatus(status EndpointStateStatus) bool {
 	return strings.HasPrefix(e.Status, string(status)) || strings.HasPrefix(e.StatusWithPort, string(status))
 }
 
+// GetOperationMode returns the operation mode of the endpoint, as reported by nodetool, from
+// its gossip status. Bootstrapping nodes are reported as JOINING.
+func (e *EndpointState) GetOperationMode() string {
+	status := e.Status
+	if status == "" {
+		status = e.StatusWithPort
+	}
+	mode := strings.ToUpper(strings.SplitN(status, ",", 2)[0])
+	if strings.HasPrefix(mode, "BOOT") {
+		return "JOINING"
+	}
+	return mode
+}
+
 func (x *EndpointState) GetRpcAddress() string {
 	if x.NativeAddressAndPort != "" {
 		// Cassandra 4.0+
@@ -136,6 +155,8 @@ const (
 	AsyncScrubTask          Feature = "async_scrub_task"
 	FullQuerySupport        Feature = "full_query_logging"
 	Rebuild                 Feature = "rebuild"
+	// DropRole is the DELETE method of /api/v0/ops/auth/role
+	DropRole Feature = "drop_role"
 )
 
 func (f *FeatureSet) UnmarshalJSON(b []byte) error {
@@ -180,6 +201,7 @@ func NewMgmtClient(ctx context.Context, client client.Client, dc *cassdcapi.Cass
 		Client:   httpClient,
 		Log:      logger,
 		Protocol: protocol,
+		Ctx:      ctx,
 	}, nil
 }
 
@@ -187,14 +209,22 @@ func BuildPodHostFromPod(pod *corev1.Pod) (string, error) {
 	// This function previously returned the dns hostname which includes the StatefulSet's headless service,
 	// which is the datacenter service. There are times though that we want to make a mgmt api call to the pod
 	// before the dns hostnames are available. It is therefore more reliable to simply use the PodIP.
+	// With host networking the pod shares the IP of its worker, which is known first.
 
 	if len(pod.Status.PodIP) == 0 {
+		if pod.Spec.HostNetwork && len(pod.Status.HostIP) > 0 {
+			return pod.Status.HostIP, nil
+		}
 		return "", newNoPodIPError(pod)
 	}
 
 	return pod.Status.PodIP, nil
 }
 
+// GetPodHost returns the DNS name of the pod in the headless service of the datacenter.
+//
+// Deprecated: the management API calls target the pod IP returned by BuildPodHostFromPod,
+// which does not depend on the cluster DNS suffix nor on the headless service being ready.
 func GetPodHost(podName, clusterName, dcName, namespace string) string {
 	nodeServicePattern := "%s.%s-%s-service.%s"
 
@@ -273,6 +303,11 @@ func (client *NodeMgmtClient) CallSchemaVersionsEndpoint(pod *corev1.Pod) (map[s
 
 // Create a new superuser with the given username and password
 func (client *NodeMgmtClient) CallCreateRoleEndpoint(pod *corev1.Pod, username string, password string, superuser bool) error {
+	return client.CallCreateRoleWithLoginEndpoint(pod, username, password, superuser, true)
+}
+
+// Create or update the role with the given username, which can only log in if login is true
+func (client *NodeMgmtClient) CallCreateRoleWithLoginEndpoint(pod *corev1.Pod, username string, password string, superuser bool, login bool) error {
 	client.Log.Info(
 		"calling Management API create role - POST /api/v0/ops/auth/role",
 		"pod", pod.Name,
@@ -281,7 +316,7 @@ func (client *NodeMgmtClient) CallCreateRoleEndpoint(pod *corev1.Pod, username s
 	postData := url.Values{}
 	postData.Set("username", username)
 	postData.Set("password", password)
-	postData.Set("can_login", "true")
+	postData.Set("can_login", strconv.FormatBool(login))
 	postData.Set("is_superuser", strconv.FormatBool(superuser))
 
 	podHost, err := BuildPodHostFromPod(pod)
@@ -303,6 +338,67 @@ func (client *NodeMgmtClient) CallCreateRoleEndpoint(pod *corev1.Pod, username s
 	return nil
 }
 
+// CallListRolesEndpoint returns the names of the roles of the cluster
+func (client *NodeMgmtClient) CallListRolesEndpoint(pod *corev1.Pod) ([]string, error) {
+	client.Log.Info(
+		"calling Management API list roles - GET /api/v0/ops/auth/role",
+		"pod", pod.Name,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	request := nodeMgmtRequest{
+		endpoint: "/api/v0/ops/auth/role",
+		host:     podHost,
+		method:   http.MethodGet,
+		timeout:  60 * time.Second,
+	}
+
+	body, err := callNodeMgmtEndpoint(client, request, "application/json")
+	if err != nil {
+		return nil, err
+	}
+
+	var roles []map[string]interface{}
+	if err := json.Unmarshal(body, &roles); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if name, ok := role["name"].(string); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Drop the role with the given username, which succeeds if the role does not exist
+func (client *NodeMgmtClient) CallDropRoleEndpoint(pod *corev1.Pod, username string) error {
+	client.Log.Info(
+		"calling Management API drop role - DELETE /api/v0/ops/auth/role",
+		"pod", pod.Name,
+		"username", username,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return err
+	}
+
+	request := nodeMgmtRequest{
+		endpoint: buildEndpoint("/api/v0/ops/auth/role", "username", username),
+		host:     podHost,
+		method:   http.MethodDelete,
+		timeout:  60 * time.Second,
+	}
+
+	_, err = callNodeMgmtEndpoint(client, request, "")
+	return err
+}
+
 func (client *NodeMgmtClient) CallProbeClusterEndpoint(pod *corev1.Pod, consistencyLevel string, rfPerDc int) error {
 	client.Log.Info(
 		"calling Management API cluster health - GET /api/v0/probes/cluster",
@@ -347,6 +443,68 @@ func (client *NodeMgmtClient) CallDrainEndpoint(pod *corev1.Pod) error {
 	return err
 }
 
+// CallCreateSnapshotEndpoint takes a snapshot of the given keyspaces, or of all of them if none is given,
+// tagged with snapshotName
+func (client *NodeMgmtClient) CallCreateSnapshotEndpoint(pod *corev1.Pod, snapshotName string, keyspaces []string) error {
+	client.Log.Info(
+		"calling Management API create snapshot - POST /api/v0/ops/node/snapshots",
+		"pod", pod.Name,
+		"snapshotName", snapshotName,
+	)
+
+	postData := map[string]interface{}{
+		"snapshot_name": snapshotName,
+	}
+	if len(keyspaces) > 0 {
+		postData["keyspaces"] = keyspaces
+	}
+
+	body, err := json.Marshal(postData)
+	if err != nil {
+		return err
+	}
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return err
+	}
+
+	request := nodeMgmtRequest{
+		endpoint: "/api/v0/ops/node/snapshots",
+		host:     podHost,
+		method:   http.MethodPost,
+		timeout:  time.Minute * 2,
+		body:     body,
+	}
+
+	_, err = callNodeMgmtEndpoint(client, request, "application/json")
+	return err
+}
+
+// CallDeleteSnapshotEndpoint clears the snapshots tagged with snapshotName
+func (client *NodeMgmtClient) CallDeleteSnapshotEndpoint(pod *corev1.Pod, snapshotName string) error {
+	client.Log.Info(
+		"calling Management API delete snapshot - DELETE /api/v0/ops/node/snapshots",
+		"pod", pod.Name,
+		"snapshotName", snapshotName,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return err
+	}
+
+	request := nodeMgmtRequest{
+		endpoint: buildEndpoint("/api/v0/ops/node/snapshots", "snapshotNames", snapshotName),
+		host:     podHost,
+		method:   http.MethodDelete,
+		timeout:  time.Minute * 2,
+	}
+
+	_, err = callNodeMgmtEndpoint(client, request, "")
+	return err
+}
+
 // CallKeyspaceCleanupEndpoint is deprecated. Use it only when accessing old management-api versions. Otherwise, use CallKeyspaceCleanup
 func (client *NodeMgmtClient) CallKeyspaceCleanupEndpoint(pod *corev1.Pod, jobs int, keyspaceName string, tables []string) error {
 	client.Log.Info(
@@ -447,6 +605,44 @@ func (client *NodeMgmtClient) CallDatacenterRebuild(pod *corev1.Pod, sourceDatac
 	return string(jobId), nil
 }
 
+// CallRepair starts a repair of the keyspace on the node, incremental unless full is set, and returns
+// the id of the repair job
+func (client *NodeMgmtClient) CallRepair(pod *corev1.Pod, keyspaceName string, full bool) (string, error) {
+	client.Log.Info(
+		"calling Management API repair - POST /api/v1/ops/node/repair",
+		"pod", pod.Name,
+		"keyspace", keyspaceName,
+		"full", full,
+	)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"keyspace_name": keyspaceName,
+		"full":          full,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return "", err
+	}
+
+	req := nodeMgmtRequest{
+		endpoint: "/api/v1/ops/node/repair",
+		host:     podHost,
+		method:   http.MethodPost,
+		timeout:  60 * time.Second,
+		body:     body,
+	}
+	jobId, err := callNodeMgmtEndpoint(client, req, "application/json")
+	if err != nil {
+		return "", err
+	}
+
+	return string(jobId), nil
+}
+
 // CallUpgradeSSTables calls the v1 version of upgradeSSTables, returning the jobId
 func (client *NodeMgmtClient) CallUpgradeSSTables(pod *corev1.Pod, jobs int, keyspaceName string, tables []string) (string, error) {
 	client.Log.Info(
@@ -1056,9 +1252,70 @@ func (client *NodeMgmtClient) JobDetails(pod *corev1.Pod, jobId string) (*JobDet
 	return job, nil
 }
 
+// retryBackoff is the backoff between the attempts of idempotent requests failing
+// because the management API could not be reached
+var retryBackoff = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.5,
+	Steps:    3,
+}
+
 func callNodeMgmtEndpoint(client *NodeMgmtClient, request nodeMgmtRequest, contentType string) ([]byte, error) {
 	client.Log.Info("client::callNodeMgmtEndpoint")
 
+	// Only GET requests are idempotent, the other ones are sent once
+	backoff := retryBackoff
+	if request.method != http.MethodGet {
+		backoff.Steps = 1
+	}
+
+	for {
+		body, err := doNodeMgmtRequest(client, request, contentType)
+		if err == nil || !isRetriableRequestError(err) || backoff.Steps <= 1 {
+			return body, err
+		}
+		delay := backoff.Step()
+		client.Log.Info("retrying request to Node Management Endpoint",
+			"pod", request.host,
+			"endpoint", request.endpoint,
+			"delay", delay,
+			"error", err.Error())
+		select {
+		case <-time.After(delay):
+		case <-client.context().Done():
+			return nil, client.context().Err()
+		}
+	}
+}
+
+func (client *NodeMgmtClient) context() context.Context {
+	if client.Ctx == nil {
+		return context.Background()
+	}
+	return client.Ctx
+}
+
+// isRetriableRequestError returns true for the errors where the management API could
+// not be reached. Timeouts and cancelled requests are not retried, a hung pod should
+// not stall the reconcile any longer, and neither are error responses since the request
+// was processed.
+func isRetriableRequestError(err error) bool {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+	return true
+}
+
+func doNodeMgmtRequest(client *NodeMgmtClient, request nodeMgmtRequest, contentType string) ([]byte, error) {
 	url := fmt.Sprintf("%s://%s:8080%s", client.Protocol, request.host, request.endpoint)
 
 	var reqBody io.Reader
@@ -1066,23 +1323,30 @@ func callNodeMgmtEndpoint(client *NodeMgmtClient, request nodeMgmtRequest, conte
 		reqBody = bytes.NewBuffer(request.body)
 	}
 
-	req, err := http.NewRequest(request.method, url, reqBody)
+	ctx := client.context()
+	if request.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, request.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, request.method, url, reqBody)
 	if err != nil {
 		return nil, err
 	}
 	req.Close = true
 
-	if request.timeout > 0 {
-		ctx, cancel := context.WithTimeout(context.Background(), request.timeout)
-		defer cancel()
-		req = req.WithContext(ctx)
-	}
-
 	if contentType != "" {
 		req.Header.Set("Content-Type", contentType)
 	}
 
+	start := time.Now()
 	res, err := client.Client.Do(req)
+	statusCode := 0
+	if res != nil {
+		statusCode = res.StatusCode
+	}
+	monitoring.ObserveManagementAPIRequest(request.method, strings.SplitN(request.endpoint, "?", 2)[0], statusCode, time.Since(start))
 	if err != nil {
 		return nil, err
 	}
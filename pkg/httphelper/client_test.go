@@ -5,15 +5,18 @@ package httphelper
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/k8ssandra/cass-operator/pkg/mocks"
 	"github.com/stretchr/testify/mock"
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/stretchr/testify/assert"
 
@@ -46,6 +49,29 @@ func Test_BuildPodHostFromPod(t *testing.T) {
 	assert.Equal(t, expected, result)
 }
 
+func Test_BuildPodHostFromPod_hostNetwork(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod-foo",
+			Namespace: "somenamespace",
+		},
+		Spec: corev1.PodSpec{
+			HostNetwork: true,
+		},
+		Status: corev1.PodStatus{
+			HostIP: "192.168.1.2",
+		},
+	}
+
+	result, err := BuildPodHostFromPod(pod)
+	assert.NoError(t, err)
+	assert.Equal(t, "192.168.1.2", result)
+
+	pod.Spec.HostNetwork = false
+	_, err = BuildPodHostFromPod(pod)
+	assert.Error(t, err)
+}
+
 func Test_parseMetadataEndpointsResponseBody(t *testing.T) {
 	endpoints, err := parseMetadataEndpointsResponseBody([]byte(`{
 		"entity": [
@@ -168,6 +194,23 @@ func Test_parseMetadataEndpointsResponseBody_Cassandra4(t *testing.T) {
 	assert.Equal(t, "10.244.1.4", endpoints.Entity[0].GetRpcAddress())
 }
 
+func TestEndpointState_GetOperationMode(t *testing.T) {
+	tests := []struct {
+		state EndpointState
+		want  string
+	}{
+		{EndpointState{Status: "NORMAL,-1234"}, "NORMAL"},
+		{EndpointState{StatusWithPort: "LEAVING,5678"}, "LEAVING"},
+		{EndpointState{Status: "BOOT,42"}, "JOINING"},
+		{EndpointState{Status: "BOOT_REPLACE,10.0.0.1"}, "JOINING"},
+		{EndpointState{Status: "shutdown,true"}, "SHUTDOWN"},
+		{EndpointState{}, ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.state.GetOperationMode())
+	}
+}
+
 func Test_parseListKeyspacesEndpointsResponseBody(t *testing.T) {
 	keyspaces, err := parseListKeyspacesEndpointsResponseBody([]byte(`["keyspace1", "keyspace2"]`))
 
@@ -443,6 +486,115 @@ func TestNodeMgmtClient_CreateTable(t *testing.T) {
 	}
 }
 
+func TestNodeMgmtClient_RetriesUnreachableGetRequests(t *testing.T) {
+	defer func(backoff wait.Backoff) { retryBackoff = backoff }(retryBackoff)
+	retryBackoff.Duration = time.Millisecond
+
+	httpClient := new(mocks.HttpClient)
+	httpClient.On("Do", mock.Anything).Return(nil, errors.New("connection refused")).Once()
+	httpClient.On("Do", mock.Anything).Return(newHttpResponse([]string{"ks1"}, http.StatusOK), nil).Once()
+
+	keyspaces, err := newMockMgmtClient(httpClient).GetKeyspace(goodPod, "ks1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ks1"}, keyspaces)
+	httpClient.AssertNumberOfCalls(t, "Do", 2)
+
+	// Attempts are bounded
+	httpClient = newMockHttpClient(nil, errors.New("connection refused"))
+	_, err = newMockMgmtClient(httpClient).GetKeyspace(goodPod, "ks1")
+	assert.Error(t, err)
+	httpClient.AssertNumberOfCalls(t, "Do", retryBackoff.Steps)
+
+	// Timeouts and error responses are not retried
+	httpClient = newMockHttpClient(nil, context.DeadlineExceeded)
+	_, err = newMockMgmtClient(httpClient).GetKeyspace(goodPod, "ks1")
+	assert.Error(t, err)
+	httpClient.AssertNumberOfCalls(t, "Do", 1)
+
+	httpClient = newMockHttpClient(newHttpResponse("failed", http.StatusInternalServerError), nil)
+	_, err = newMockMgmtClient(httpClient).GetKeyspace(goodPod, "ks1")
+	assert.Error(t, err)
+	httpClient.AssertNumberOfCalls(t, "Do", 1)
+
+	// Non idempotent requests are not retried
+	httpClient = newMockHttpClient(nil, errors.New("connection refused"))
+	err = newMockMgmtClient(httpClient).CreateKeyspace(goodPod, "ks1", []map[string]string{{"dc_name": "dc1", "replication_factor": "3"}})
+	assert.Error(t, err)
+	httpClient.AssertNumberOfCalls(t, "Do", 1)
+}
+
+func TestNodeMgmtClient_CancelledRetries(t *testing.T) {
+	defer func(backoff wait.Backoff) { retryBackoff = backoff }(retryBackoff)
+	retryBackoff.Duration = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	httpClient := new(mocks.HttpClient)
+	httpClient.On("Do", mock.Anything).Run(func(args mock.Arguments) {
+		// The request is cancelled with the context of the client
+		cancel()
+		assert.ErrorIs(t, args.Get(0).(*http.Request).Context().Err(), context.Canceled)
+	}).Return(nil, errors.New("connection refused"))
+
+	mgmtClient := newMockMgmtClient(httpClient)
+	mgmtClient.Ctx = ctx
+
+	// The backoff is interrupted by the cancellation instead of waiting an hour
+	_, err := mgmtClient.GetKeyspace(goodPod, "ks1")
+	assert.ErrorIs(t, err, context.Canceled)
+	httpClient.AssertNumberOfCalls(t, "Do", 1)
+}
+
+func TestNodeMgmtClient_CallDropRoleEndpoint(t *testing.T) {
+	httpClient := new(mocks.HttpClient)
+	httpClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == http.MethodDelete &&
+			req.URL.Path == "/api/v0/ops/auth/role" &&
+			req.URL.Query().Get("username") == "cassandra"
+	})).Return(newHttpResponse("OK", http.StatusOK), nil).Once()
+
+	assert.NoError(t, newMockMgmtClient(httpClient).CallDropRoleEndpoint(goodPod, "cassandra"))
+	httpClient.AssertExpectations(t)
+
+	httpClient = newMockHttpClient(newHttpResponse("failed", http.StatusInternalServerError), nil)
+	assert.Error(t, newMockMgmtClient(httpClient).CallDropRoleEndpoint(goodPod, "cassandra"))
+}
+
+func TestNodeMgmtClient_CallListRolesEndpoint(t *testing.T) {
+	httpClient := new(mocks.HttpClient)
+	httpClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == http.MethodGet && req.URL.Path == "/api/v0/ops/auth/role"
+	})).Return(newHttpResponse([]map[string]string{
+		{"name": "cassandra", "super": "true", "login": "true"},
+		{"name": "app", "super": "false", "login": "true"},
+	}, http.StatusOK), nil).Once()
+
+	roles, err := newMockMgmtClient(httpClient).CallListRolesEndpoint(goodPod)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cassandra", "app"}, roles)
+	httpClient.AssertExpectations(t)
+}
+
+func TestNodeMgmtClient_CallCreateRoleWithLoginEndpoint(t *testing.T) {
+	httpClient := new(mocks.HttpClient)
+	httpClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		query := req.URL.Query()
+		return req.Method == http.MethodPost &&
+			req.URL.Path == "/api/v0/ops/auth/role" &&
+			query.Get("username") == "app" &&
+			query.Get("is_superuser") == "false" &&
+			query.Get("can_login") == "false"
+	})).Return(newHttpResponse("OK", http.StatusCreated), nil).Once()
+
+	assert.NoError(t, newMockMgmtClient(httpClient).CallCreateRoleWithLoginEndpoint(goodPod, "app", "secret", false, false))
+	httpClient.AssertExpectations(t)
+
+	// The password is not leaked in the error
+	httpClient = newMockHttpClient(newHttpResponse("failed", http.StatusInternalServerError), nil)
+	err := newMockMgmtClient(httpClient).CallCreateRoleWithLoginEndpoint(goodPod, "app", "secret", false, true)
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+}
+
 func newMockMgmtClient(httpClient *mocks.HttpClient) *NodeMgmtClient {
 	return &NodeMgmtClient{
 		Client:   httpClient,
@@ -8,3 +8,14 @@ import "net/http"
 type HttpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
+
+// BearerTokenHttpClient adds a bearer token to the requests of the wrapped client
+type BearerTokenHttpClient struct {
+	Client HttpClient
+	Token  string
+}
+
+func (c *BearerTokenHttpClient) Do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	return c.Client.Do(req)
+}
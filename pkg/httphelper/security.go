@@ -60,6 +60,7 @@ func AddManagementApiServerSecurity(dc *api.CassandraDatacenter, pod *corev1.Pod
 func BuildManagementApiSecurityProvider(dc *api.CassandraDatacenter) (ManagementApiSecurityProvider, error) {
 	options := []func(*api.CassandraDatacenter) (ManagementApiSecurityProvider, error){
 		buildManualApiSecurityProvider,
+		buildCertManagerApiSecurityProvider,
 		buildInsecureManagementApiSecurityProvider,
 	}
 
@@ -108,8 +109,8 @@ type InsecureManagementApiSecurityProvider struct {
 }
 
 func buildInsecureManagementApiSecurityProvider(dc *api.CassandraDatacenter) (ManagementApiSecurityProvider, error) {
-	// If both are nil, then default to insecure
-	if dc.Spec.ManagementApiAuth.Insecure != nil || (dc.Spec.ManagementApiAuth.Manual == nil && dc.Spec.ManagementApiAuth.Insecure == nil) {
+	// If both are nil, then default to insecure, unless cert-manager issues the certificates
+	if dc.Spec.ManagementApiAuth.Insecure != nil || (dc.Spec.ManagementApiAuth.Manual == nil && dc.Spec.ManagementApiAuth.Insecure == nil && dc.Spec.CertManager == nil) {
 		return &InsecureManagementApiSecurityProvider{}, nil
 	}
 	return nil, nil
@@ -146,6 +147,22 @@ func buildManualApiSecurityProvider(dc *api.CassandraDatacenter) (ManagementApiS
 	return nil, nil
 }
 
+// buildCertManagerApiSecurityProvider The secrets issued by cert-manager have the same structure as the
+// manual ones, they are validated by cert-manager and only exist once issued.
+func buildCertManagerApiSecurityProvider(dc *api.CassandraDatacenter) (ManagementApiSecurityProvider, error) {
+	if dc.IsManagementApiCertManagerEnabled() {
+		provider := &ManualManagementApiSecurityProvider{}
+		provider.Config = &api.ManagementApiAuthManualConfig{
+			ClientSecretName:     dc.GetCertificateSecretName(api.MgmtApiClientCertificatePurpose),
+			ServerSecretName:     dc.GetCertificateSecretName(api.MgmtApiServerCertificatePurpose),
+			SkipSecretValidation: true,
+		}
+		provider.Namespace = dc.ObjectMeta.Namespace
+		return provider, nil
+	}
+	return nil, nil
+}
+
 func (provider *ManualManagementApiSecurityProvider) GetProtocol() string {
 	return "https"
 }
@@ -689,6 +706,19 @@ func (provider *ManualManagementApiSecurityProvider) ValidateConfig(client clien
 		},
 	}
 
+	if tokenSecretName := provider.Config.TokenSecretName; tokenSecretName != "" {
+		tokenSecret, err := loadSecret(client, ctx, provider.Namespace, tokenSecretName)
+		if err == nil {
+			_, err = getBearerToken(tokenSecret)
+		}
+		if err != nil {
+			validationErrors = append(
+				validationErrors,
+				fmt.Errorf("failed to load Management API token secret specified at .managementApiAuth.manual.tokenSecretName with value '%s'. %w",
+					tokenSecretName, err))
+		}
+	}
+
 	for _, check := range certificateSigningChecks {
 		var err error
 		secretName := check.peerAsecret.ObjectMeta.Name
@@ -746,16 +776,42 @@ func (provider *ManualManagementApiSecurityProvider) BuildHttpClient(client clie
 	tlsConfig := &tls.Config{
 		Certificates: []tls.Certificate{cert},
 		RootCAs:      caCertPool,
-		// TODO: ...we should probably verify something here...
-		InsecureSkipVerify:    true,
-		VerifyPeerCertificate: buildVerifyPeerCertificateNoHostCheck(caCertPool),
+	}
+	if provider.Config.ServerName != "" {
+		// Pods are reached by IP, so the certificates are verified against the
+		// configured server name instead
+		tlsConfig.ServerName = provider.Config.ServerName
+	} else {
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = buildVerifyPeerCertificateNoHostCheck(caCertPool)
 	}
 	transport := &http.Transport{TLSClientConfig: tlsConfig}
 	httpClient := &http.Client{Transport: transport}
 
+	if provider.Config.TokenSecretName != "" {
+		tokenSecret, err := loadSecret(client, ctx, provider.Namespace, provider.Config.TokenSecretName)
+		if err != nil {
+			return nil, err
+		}
+		token, err := getBearerToken(tokenSecret)
+		if err != nil {
+			return nil, err
+		}
+		return &BearerTokenHttpClient{Client: httpClient, Token: token}, nil
+	}
+
 	return httpClient, nil
 }
 
+func getBearerToken(secret *corev1.Secret) (string, error) {
+	token, ok := secret.Data["token"]
+	if !ok || len(token) == 0 {
+		return "", fmt.Errorf("expected Secret %s to have data key 'token' but was not found",
+			types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}.String())
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
 // Below implementation modified from:
 //
 // https://go-review.googlesource.com/c/go/+/193620/5/src/crypto/tls/example_test.go#210
@@ -4,13 +4,20 @@
 package httphelper
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/pem"
+	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
 )
 
 func helperLoadBytes(t *testing.T, name string) []byte {
@@ -98,3 +105,131 @@ func Test_validatePrivateKey(t *testing.T) {
 		t, 1, len(errs),
 		"Should consider an empty key as an invalid key")
 }
+
+func Test_ManualManagementApiSecurityProvider_BuildHttpClient(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mgmt-api-client",
+			Namespace: "default",
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"ca.crt":  helperLoadBytes(t, "ca.crt"),
+			"tls.crt": helperLoadBytes(t, "client.crt"),
+			"tls.key": helperLoadBytes(t, "client.key"),
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithRuntimeObjects(secret).Build()
+
+	provider := &ManualManagementApiSecurityProvider{
+		Namespace: "default",
+		Config: &api.ManagementApiAuthManualConfig{
+			ClientSecretName: "mgmt-api-client",
+			ServerSecretName: "mgmt-api-server",
+		},
+	}
+
+	httpClient, err := provider.BuildHttpClient(k8sClient, context.Background())
+	assert.NoError(t, err)
+	tlsConfig := httpClient.(*http.Client).Transport.(*http.Transport).TLSClientConfig
+	assert.True(t, tlsConfig.InsecureSkipVerify)
+	assert.NotNil(t, tlsConfig.VerifyPeerCertificate)
+
+	// With a server name, the standard verification applies
+	provider.Config.ServerName = "cassandra.example.com"
+	httpClient, err = provider.BuildHttpClient(k8sClient, context.Background())
+	assert.NoError(t, err)
+	tlsConfig = httpClient.(*http.Client).Transport.(*http.Transport).TLSClientConfig
+	assert.False(t, tlsConfig.InsecureSkipVerify)
+	assert.Nil(t, tlsConfig.VerifyPeerCertificate)
+	assert.Equal(t, "cassandra.example.com", tlsConfig.ServerName)
+}
+
+func Test_ManualManagementApiSecurityProvider_BuildHttpClient_Token(t *testing.T) {
+	clientSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mgmt-api-client",
+			Namespace: "default",
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"ca.crt":  helperLoadBytes(t, "ca.crt"),
+			"tls.crt": helperLoadBytes(t, "client.crt"),
+			"tls.key": helperLoadBytes(t, "client.key"),
+		},
+	}
+	tokenSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mgmt-api-token",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			"token": []byte("s3cr3t\n"),
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithRuntimeObjects(clientSecret, tokenSecret).Build()
+
+	provider := &ManualManagementApiSecurityProvider{
+		Namespace: "default",
+		Config: &api.ManagementApiAuthManualConfig{
+			ClientSecretName: "mgmt-api-client",
+			ServerSecretName: "mgmt-api-server",
+			TokenSecretName:  "mgmt-api-token",
+		},
+	}
+
+	httpClient, err := provider.BuildHttpClient(k8sClient, context.Background())
+	assert.NoError(t, err)
+	tokenClient, ok := httpClient.(*BearerTokenHttpClient)
+	assert.True(t, ok)
+	assert.Equal(t, "s3cr3t", tokenClient.Token)
+
+	provider.Config.TokenSecretName = "missing"
+	_, err = provider.BuildHttpClient(k8sClient, context.Background())
+	assert.Error(t, err)
+}
+
+func Test_BearerTokenHttpClient(t *testing.T) {
+	var authorization string
+	tokenClient := &BearerTokenHttpClient{
+		Client: httpClientFunc(func(req *http.Request) (*http.Response, error) {
+			authorization = req.Header.Get("Authorization")
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		}),
+		Token: "s3cr3t",
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v0/probes/liveness", nil)
+	assert.NoError(t, err)
+	_, err = tokenClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer s3cr3t", authorization)
+}
+
+type httpClientFunc func(req *http.Request) (*http.Response, error)
+
+func (f httpClientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func Test_BuildManagementApiSecurityProvider_CertManager(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "default"},
+		Spec: api.CassandraDatacenterSpec{
+			CertManager: &api.CertManagerSpec{IssuerName: "my-issuer"},
+		},
+	}
+
+	provider, err := BuildManagementApiSecurityProvider(dc)
+	assert.NoError(t, err)
+	assert.Equal(t, "https", provider.GetProtocol())
+	manual := provider.(*ManualManagementApiSecurityProvider)
+	assert.Equal(t, "dc1-mgmt-api-client-certificate", manual.Config.ClientSecretName)
+	assert.Equal(t, "dc1-mgmt-api-server-certificate", manual.Config.ServerSecretName)
+
+	// The insecure setting still applies
+	dc.Spec.ManagementApiAuth.Insecure = &api.ManagementApiAuthInsecureConfig{}
+	provider, err = BuildManagementApiSecurityProvider(dc)
+	assert.NoError(t, err)
+	assert.Equal(t, "http", provider.GetProtocol())
+}
@@ -1,6 +1,7 @@
 package httphelper
 
 import (
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
@@ -18,6 +19,8 @@ var featuresReply = `{
 	]
 	}`
 
+var keyspacesReply = `["system","system_auth","system_schema","ks1"]`
+
 var jobDetailsCompleted = `{"submit_time":"1638545895255","end_time":"1638545895255","id":"%s","type":"Cleanup","status":"COMPLETED"}`
 
 var jobDetailsFailed = `{"submit_time":"1638545895255","end_time":"1638545895255","id":"%s","type":"Cleanup","status":"ERROR"}`
@@ -51,6 +54,7 @@ func (c *CallDetails) incr(url string) {
 
 func FakeExecutorServerWithDetails(callDetails *CallDetails) (*httptest.Server, error) {
 	jobId := 0
+	roles := map[string]bool{}
 
 	return FakeMgmtApiServer(callDetails, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		query, err := url.ParseQuery(r.URL.RawQuery)
@@ -65,11 +69,32 @@ func FakeExecutorServerWithDetails(callDetails *CallDetails) (*httptest.Server,
 			w.WriteHeader(http.StatusOK)
 			jobId := query.Get("job_id")
 			_, err = w.Write([]byte(fmt.Sprintf(jobDetailsCompleted, jobId)))
-		} else if r.Method == http.MethodPost && (r.URL.Path == "/api/v1/ops/keyspace/cleanup" || r.URL.Path == "/api/v1/ops/node/rebuild" || r.URL.Path == "/api/v1/ops/tables/sstables/upgrade") {
+		} else if r.Method == http.MethodPost && (r.URL.Path == "/api/v1/ops/keyspace/cleanup" || r.URL.Path == "/api/v1/ops/node/rebuild" || r.URL.Path == "/api/v1/ops/tables/sstables/upgrade" || r.URL.Path == "/api/v1/ops/node/repair") {
 			w.WriteHeader(http.StatusOK)
 			// Write jobId
 			jobId++
 			_, err = w.Write([]byte(strconv.Itoa(jobId)))
+		} else if r.Method == http.MethodGet && r.URL.Path == "/api/v0/ops/keyspace" {
+			w.WriteHeader(http.StatusOK)
+			_, err = w.Write([]byte(keyspacesReply))
+		} else if (r.Method == http.MethodPost || r.Method == http.MethodDelete) && r.URL.Path == "/api/v0/ops/node/snapshots" {
+			w.WriteHeader(http.StatusOK)
+		} else if r.Method == http.MethodPost && r.URL.Path == "/api/v0/ops/auth/role" {
+			roles[query.Get("username")] = true
+			w.WriteHeader(http.StatusOK)
+		} else if r.Method == http.MethodDelete && r.URL.Path == "/api/v0/ops/auth/role" {
+			delete(roles, query.Get("username"))
+			w.WriteHeader(http.StatusOK)
+		} else if r.Method == http.MethodGet && r.URL.Path == "/api/v0/ops/auth/role" {
+			rolesReply := []map[string]string{}
+			for name := range roles {
+				rolesReply = append(rolesReply, map[string]string{"name": name})
+			}
+			var body []byte
+			if body, err = json.Marshal(rolesReply); err == nil {
+				w.WriteHeader(http.StatusOK)
+				_, err = w.Write(body)
+			}
 		} else {
 			w.WriteHeader(http.StatusNotFound)
 		}
@@ -99,7 +124,7 @@ func FakeExecutorServerWithDetailsFails(callDetails *CallDetails) (*httptest.Ser
 			w.WriteHeader(http.StatusOK)
 			jobId := query.Get("job_id")
 			_, err = w.Write([]byte(fmt.Sprintf(jobDetailsFailed, jobId)))
-		} else if r.Method == http.MethodPost && (r.URL.Path == "/api/v1/ops/keyspace/cleanup" || r.URL.Path == "/api/v1/ops/node/rebuild" || r.URL.Path == "/api/v1/ops/tables/sstables/upgrade") {
+		} else if r.Method == http.MethodPost && (r.URL.Path == "/api/v1/ops/keyspace/cleanup" || r.URL.Path == "/api/v1/ops/node/rebuild" || r.URL.Path == "/api/v1/ops/tables/sstables/upgrade" || r.URL.Path == "/api/v1/ops/node/repair") {
 			w.WriteHeader(http.StatusOK)
 			// Write jobId
 			jobId++
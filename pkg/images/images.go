@@ -23,10 +23,13 @@ var (
 )
 
 const (
-	ValidDseVersionRegexp      = "6\\.8\\.\\d+"
-	ValidOssVersionRegexp      = "(3\\.11\\.\\d+)|(4\\.\\d+\\.\\d+)"
-	DefaultCassandraRepository = "k8ssandra/cass-management-api"
-	DefaultDSERepository       = "datastax/dse-server"
+	ValidDseVersionRegexp       = "6\\.8\\.\\d+"
+	ValidOssVersionRegexp       = "(3\\.11\\.\\d+)|(4\\.\\d+\\.\\d+)"
+	DefaultCassandraRepository  = "k8ssandra/cass-management-api"
+	DefaultDSERepository        = "datastax/dse-server"
+	DefaultReaperImage          = "thelastpickle/cassandra-reaper:3.2.1"
+	DefaultMetricsExporterImage = "criteord/cassandra_exporter:2.3.8"
+	DefaultBackupUploaderImage  = "k8ssandra/cass-operator:v1.13.0"
 )
 
 func init() {
@@ -169,6 +172,74 @@ func GetSystemLoggerImage() string {
 	return ApplyRegistry(GetImageConfig().Images.SystemLogger)
 }
 
+func GetReaperImage() string {
+	image := GetImageConfig().Images.Reaper
+	if image == "" {
+		image = DefaultReaperImage
+	}
+	return ApplyRegistry(image)
+}
+
+func GetMetricsExporterImage() string {
+	image := GetImageConfig().Images.MetricsExporter
+	if image == "" {
+		image = DefaultMetricsExporterImage
+	}
+	return ApplyRegistry(image)
+}
+
+// GetBackupUploaderImage returns the image of the jobs uploading the snapshots of the backups, the operator
+// image whose binary has the upload-snapshot command
+func GetBackupUploaderImage() string {
+	image := GetImageConfig().Images.BackupUploader
+	if image == "" {
+		image = DefaultBackupUploaderImage
+	}
+	return ApplyRegistry(image)
+}
+
+// ImageComponents are the parts of an image reference of the form [registry/]repository[:tag][@digest]
+type ImageComponents struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseImage splits an image reference into its components. The first path component is the registry
+// when it looks like a hostname, like in stripRegistry.
+func ParseImage(image string) ImageComponents {
+	components := ImageComponents{}
+	if i := strings.Index(image, "@"); i >= 0 {
+		image, components.Digest = image[:i], image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image, components.Tag = image[:i], image[i+1:]
+	}
+	if i := strings.Index(image, "/"); i >= 0 {
+		first := image[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			image, components.Registry = image[i+1:], first
+		}
+	}
+	components.Repository = image
+	return components
+}
+
+func (c ImageComponents) String() string {
+	image := c.Repository
+	if c.Registry != "" {
+		image = c.Registry + "/" + image
+	}
+	if c.Tag != "" {
+		image += ":" + c.Tag
+	}
+	if c.Digest != "" {
+		image += "@" + c.Digest
+	}
+	return image
+}
+
 func AddDefaultRegistryImagePullSecrets(podSpec *corev1.PodSpec) bool {
 	secretName := GetImageConfig().ImagePullSecret.Name
 	if secretName != "" {
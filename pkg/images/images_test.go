@@ -58,6 +58,9 @@ func TestDefaultImageConfigParsing(t *testing.T) {
 	assert.NotNil(GetImageConfig().Images)
 	assert.True(strings.HasPrefix(GetImageConfig().Images.SystemLogger, "k8ssandra/system-logger:"))
 	assert.True(strings.HasPrefix(GetImageConfig().Images.ConfigBuilder, "datastax/cass-config-builder:"))
+	assert.True(strings.HasPrefix(GetReaperImage(), "thelastpickle/cassandra-reaper:"))
+	assert.True(strings.HasPrefix(GetMetricsExporterImage(), "criteord/cassandra_exporter:"))
+	assert.True(strings.HasPrefix(GetBackupUploaderImage(), "k8ssandra/cass-operator:"))
 
 	assert.Equal("k8ssandra/cass-management-api", GetImageConfig().DefaultImages.CassandraImageComponent.Repository)
 	assert.Equal("datastax/dse-server", GetImageConfig().DefaultImages.DSEImageComponent.Repository)
@@ -97,6 +100,11 @@ func TestImageConfigParsing(t *testing.T) {
 	path, err = GetCassandraImage("cassandra", "4.0.0")
 	assert.NoError(err)
 	assert.Equal("localhost:5000/k8ssandra/cassandra-ubi:latest", path)
+
+	// Not set in the image config
+	assert.Equal("localhost:5000/"+DefaultReaperImage, GetReaperImage())
+	assert.Equal("localhost:5000/"+DefaultMetricsExporterImage, GetMetricsExporterImage())
+	assert.Equal("localhost:5000/"+DefaultBackupUploaderImage, GetBackupUploaderImage())
 }
 
 func TestDefaultRepositories(t *testing.T) {
@@ -121,3 +129,22 @@ func TestOssValidVersions(t *testing.T) {
 	assert.False(IsOssVersionSupported("4.1"))
 	assert.False(IsOssVersionSupported("6.8.0"))
 }
+
+func TestParseImage(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected ImageComponents
+	}{
+		{"cassandra", ImageComponents{Repository: "cassandra"}},
+		{"datastax/dse-server:6.8.4", ImageComponents{Repository: "datastax/dse-server", Tag: "6.8.4"}},
+		{"localhost/dse-server", ImageComponents{Registry: "localhost", Repository: "dse-server"}},
+		{"localhost:5000/datastax/dse-server:6.8.4", ImageComponents{Registry: "localhost:5000", Repository: "datastax/dse-server", Tag: "6.8.4"}},
+		{"registry.example.com/dse:6.8.4@sha256:abc", ImageComponents{Registry: "registry.example.com", Repository: "dse", Tag: "6.8.4", Digest: "sha256:abc"}},
+		{"registry.example.com/dse@sha256:abc", ImageComponents{Registry: "registry.example.com", Repository: "dse", Digest: "sha256:abc"}},
+	}
+	for _, tt := range tests {
+		components := ParseImage(tt.image)
+		assert.Equal(t, tt.expected, components, tt.image)
+		assert.Equal(t, tt.image, components.String())
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package monitoring
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+)
+
+const (
+	namespaceLabel  = "namespace"
+	datacenterLabel = "datacenter"
+)
+
+var (
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cass_operator_datacenter_reconcile_duration_seconds",
+		Help:    "Duration of the reconciles of the datacenter",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{namespaceLabel, datacenterLabel})
+
+	datacenterProgress = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cass_operator_datacenter_progress",
+		Help: "Progress state of the datacenter, 1 for the current state and 0 for the other ones",
+	}, []string{namespaceLabel, datacenterLabel, "progress"})
+
+	rackStage = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cass_operator_rack_stage",
+		Help: "Stage of the rack, 1 for the current stage and 0 for the other ones",
+	}, []string{namespaceLabel, datacenterLabel, "rack", "stage"})
+
+	nodesPending = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cass_operator_datacenter_nodes_pending",
+		Help: "Number of nodes of the datacenter which are not ready yet",
+	}, []string{namespaceLabel, datacenterLabel})
+
+	healthCheckFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cass_operator_datacenter_health_check_failures_total",
+		Help: "Number of health checks of the datacenter which found it unhealthy",
+	}, []string{namespaceLabel, datacenterLabel})
+
+	managementAPIRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cass_operator_management_api_request_duration_seconds",
+		Help:    "Duration of the requests to the management API of the nodes",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "endpoint", "code"})
+
+	progressStates = []api.ProgressState{api.ProgressUpdating, api.ProgressReady}
+	rackStages     = []api.RackStage{api.RackStagePending, api.RackStageScaling, api.RackStageStopped, api.RackStageUpdating,
+		api.RackStageStarting, api.RackStageReady}
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		reconcileDuration,
+		datacenterProgress,
+		rackStage,
+		nodesPending,
+		healthCheckFailures,
+		managementAPIRequestDuration,
+	)
+}
+
+// ObserveReconcile records the duration of a reconcile of the datacenter
+func ObserveReconcile(namespace, datacenter string, duration time.Duration) {
+	reconcileDuration.WithLabelValues(namespace, datacenter).Observe(duration.Seconds())
+}
+
+// UpdateDatacenterMetrics sets the gauges of the datacenter from its status
+func UpdateDatacenterMetrics(dc *api.CassandraDatacenter) {
+	for _, state := range progressStates {
+		value := 0.0
+		if dc.Status.CassandraOperatorProgress == state {
+			value = 1
+		}
+		datacenterProgress.WithLabelValues(dc.Namespace, dc.Name, string(state)).Set(value)
+	}
+
+	pending := int32(0)
+	for rack, status := range dc.Status.RackStatuses {
+		if status.DesiredNodes > status.ReadyNodes {
+			pending += status.DesiredNodes - status.ReadyNodes
+		}
+		for _, stage := range rackStages {
+			value := 0.0
+			if status.Stage == stage {
+				value = 1
+			}
+			rackStage.WithLabelValues(dc.Namespace, dc.Name, rack, string(stage)).Set(value)
+		}
+	}
+	nodesPending.WithLabelValues(dc.Namespace, dc.Name).Set(float64(pending))
+}
+
+// RemoveDatacenterMetrics deletes the series of a datacenter which is being deleted
+func RemoveDatacenterMetrics(dc *api.CassandraDatacenter) {
+	reconcileDuration.DeleteLabelValues(dc.Namespace, dc.Name)
+	nodesPending.DeleteLabelValues(dc.Namespace, dc.Name)
+	healthCheckFailures.DeleteLabelValues(dc.Namespace, dc.Name)
+	for _, state := range progressStates {
+		datacenterProgress.DeleteLabelValues(dc.Namespace, dc.Name, string(state))
+	}
+
+	racks := map[string]bool{}
+	for _, rack := range dc.GetRacks() {
+		racks[rack.Name] = true
+	}
+	for rack := range dc.Status.RackStatuses {
+		racks[rack] = true
+	}
+	for rack := range racks {
+		for _, stage := range rackStages {
+			rackStage.DeleteLabelValues(dc.Namespace, dc.Name, rack, string(stage))
+		}
+	}
+}
+
+// IncHealthCheckFailures counts a health check which found the datacenter unhealthy
+func IncHealthCheckFailures(namespace, datacenter string) {
+	healthCheckFailures.WithLabelValues(namespace, datacenter).Inc()
+}
+
+// ObserveManagementAPIRequest records the duration of a request to the management API. The
+// status code is 0 when no response was received.
+func ObserveManagementAPIRequest(method, endpoint string, statusCode int, duration time.Duration) {
+	code := "error"
+	if statusCode > 0 {
+		code = strconv.Itoa(statusCode)
+	}
+	managementAPIRequestDuration.WithLabelValues(method, endpoint, code).Observe(duration.Seconds())
+}
@@ -0,0 +1,60 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+)
+
+func TestUpdateDatacenterMetrics(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "test"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "cluster1",
+			Size:        5,
+			Racks:       []api.Rack{{Name: "r1"}, {Name: "r2"}},
+		},
+		Status: api.CassandraDatacenterStatus{
+			CassandraOperatorProgress: api.ProgressUpdating,
+			RackStatuses: map[string]api.RackStatus{
+				"r1": {DesiredNodes: 3, ReadyNodes: 1, Stage: api.RackStageStarting},
+				"r2": {DesiredNodes: 2, ReadyNodes: 2, Stage: api.RackStageReady},
+			},
+		},
+	}
+
+	UpdateDatacenterMetrics(dc)
+	ObserveReconcile(dc.Namespace, dc.Name, time.Second)
+	IncHealthCheckFailures(dc.Namespace, dc.Name)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(datacenterProgress.WithLabelValues("test", "dc1", "Updating")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(datacenterProgress.WithLabelValues("test", "dc1", "Ready")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(rackStage.WithLabelValues("test", "dc1", "r1", "Starting")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(rackStage.WithLabelValues("test", "dc1", "r1", "Ready")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(rackStage.WithLabelValues("test", "dc1", "r2", "Ready")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(nodesPending.WithLabelValues("test", "dc1")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(healthCheckFailures.WithLabelValues("test", "dc1")))
+	assert.Equal(t, 1, testutil.CollectAndCount(reconcileDuration))
+
+	RemoveDatacenterMetrics(dc)
+	assert.Equal(t, 0, testutil.CollectAndCount(datacenterProgress))
+	assert.Equal(t, 0, testutil.CollectAndCount(rackStage))
+	assert.Equal(t, 0, testutil.CollectAndCount(nodesPending))
+	assert.Equal(t, 0, testutil.CollectAndCount(healthCheckFailures))
+	assert.Equal(t, 0, testutil.CollectAndCount(reconcileDuration))
+}
+
+func TestObserveManagementAPIRequest(t *testing.T) {
+	ObserveManagementAPIRequest("POST", "/api/v0/ops/keyspace/cleanup", 200, time.Second)
+	ObserveManagementAPIRequest("POST", "/api/v0/ops/keyspace/cleanup", 0, time.Second)
+
+	assert.Equal(t, 2, testutil.CollectAndCount(managementAPIRequestDuration))
+}
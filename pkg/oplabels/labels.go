@@ -34,6 +34,13 @@ func AddOperatorLabels(m map[string]string, dc *api.CassandraDatacenter) {
 	}
 }
 
+// AddOperatorAnnotations adds the additional annotations of the datacenter to the annotations m of a resource
+func AddOperatorAnnotations(m map[string]string, dc *api.CassandraDatacenter) {
+	for key, value := range dc.Spec.AdditionalAnnotations {
+		m[key] = value
+	}
+}
+
 func HasManagedByCassandraOperatorLabel(m map[string]string) bool {
 	v, ok := m[ManagedByLabel]
 	return ok && v == ManagedByLabelValue
@@ -0,0 +1,136 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reaper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client registers clusters in Reaper through its REST API
+type Client interface {
+	// RegisterCluster adds the cluster to Reaper, or updates its seed hosts when it is already
+	// registered
+	RegisterCluster(ctx context.Context, clusterName, seedHost string, jmxPort int) error
+	// UnregisterCluster removes the cluster and its repair schedules from Reaper
+	UnregisterCluster(ctx context.Context, clusterName string) error
+}
+
+type restClient struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+	loggedIn bool
+}
+
+// NewClient returns a client of the Reaper REST API served at the given URL, which logs in with the
+// given credentials before its first request. Reaper keeps the session in a cookie.
+func NewClient(baseURL, username, password string) Client {
+	jar, _ := cookiejar.New(nil)
+	return &restClient{
+		baseURL:  baseURL,
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: 30 * time.Second, Jar: jar},
+	}
+}
+
+func (c *restClient) RegisterCluster(ctx context.Context, clusterName, seedHost string, jmxPort int) error {
+	query := url.Values{}
+	query.Set("seedHost", seedHost)
+	query.Set("jmxPort", strconv.Itoa(jmxPort))
+	return c.do(ctx, http.MethodPut, clusterName, query)
+}
+
+func (c *restClient) UnregisterCluster(ctx context.Context, clusterName string) error {
+	query := url.Values{}
+	query.Set("force", "true")
+	err := c.do(ctx, http.MethodDelete, clusterName, query)
+	if e, ok := err.(*responseError); ok && e.statusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// do sends the cluster request, logging in first, and again once when the session expired
+func (c *restClient) do(ctx context.Context, method, clusterName string, query url.Values) error {
+	if !c.loggedIn {
+		if err := c.login(ctx); err != nil {
+			return err
+		}
+	}
+
+	err := c.doCluster(ctx, method, clusterName, query)
+	if e, ok := err.(*responseError); ok && (e.statusCode == http.StatusUnauthorized || e.statusCode == http.StatusForbidden) {
+		if err := c.login(ctx); err != nil {
+			return err
+		}
+		err = c.doCluster(ctx, method, clusterName, query)
+	}
+	return err
+}
+
+func (c *restClient) doCluster(ctx context.Context, method, clusterName string, query url.Values) error {
+	endpoint := fmt.Sprintf("%s/cluster/%s?%s", c.baseURL, url.PathEscape(clusterName), query.Encode())
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return c.send(req, method+" cluster")
+}
+
+// login opens a session with the credentials of the client, when it has some
+func (c *restClient) login(ctx context.Context) error {
+	if c.username == "" {
+		return nil
+	}
+
+	form := url.Values{}
+	form.Set("username", c.username)
+	form.Set("password", c.password)
+	form.Set("rememberMe", "false")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/login", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if err := c.send(req, "login"); err != nil {
+		return err
+	}
+	c.loggedIn = true
+	return nil
+}
+
+func (c *restClient) send(req *http.Request, operation string) error {
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return &responseError{statusCode: res.StatusCode, operation: operation, body: string(body)}
+	}
+	return nil
+}
+
+type responseError struct {
+	statusCode int
+	operation  string
+	body       string
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("reaper %s request failed with status %d: %s", e.operation, e.statusCode, e.body)
+}
@@ -0,0 +1,98 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reaper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAuthServer serves the login endpoint of Reaper, and handles the other requests of the sessions it
+// opened with the handler
+func newAuthServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *int) {
+	logins := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			require.NoError(t, r.ParseForm())
+			if r.PostForm.Get("username") != "admin" || r.PostForm.Get("password") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			logins++
+			http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: "session", Path: "/"})
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if cookie, err := r.Cookie("JSESSIONID"); err != nil || cookie.Value != "session" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &logins
+}
+
+func TestRegisterCluster(t *testing.T) {
+	var method, uri string
+	server, logins := newAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		method, uri = r.Method, r.RequestURI
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	client := NewClient(server.URL, "admin", "secret")
+	err := client.RegisterCluster(context.Background(), "cluster 1", "cluster1-seed-service", 7199)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/cluster/cluster%201?jmxPort=7199&seedHost=cluster1-seed-service", uri)
+
+	// The session is reused
+	require.NoError(t, client.RegisterCluster(context.Background(), "cluster 1", "cluster1-seed-service", 7199))
+	assert.Equal(t, 1, *logins)
+}
+
+func TestRegisterCluster_wrongCredentials(t *testing.T) {
+	server, _ := newAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	err := NewClient(server.URL, "admin", "wrong").RegisterCluster(context.Background(), "cluster1", "cluster1-seed-service", 7199)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "login")
+	assert.Contains(t, err.Error(), "401")
+}
+
+func TestUnregisterCluster(t *testing.T) {
+	status := http.StatusAccepted
+	var method, uri string
+	server, logins := newAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		method, uri = r.Method, r.RequestURI
+		w.WriteHeader(status)
+	})
+
+	client := NewClient(server.URL, "admin", "secret")
+	require.NoError(t, client.UnregisterCluster(context.Background(), "cluster1"))
+	assert.Equal(t, http.MethodDelete, method)
+	assert.Equal(t, "/cluster/cluster1?force=true", uri)
+
+	// The cluster is already unregistered
+	status = http.StatusNotFound
+	assert.NoError(t, client.UnregisterCluster(context.Background(), "cluster1"))
+
+	// An expired session is opened again
+	status = http.StatusUnauthorized
+	err := client.UnregisterCluster(context.Background(), "cluster1")
+	require.Error(t, err)
+	assert.Equal(t, 2, *logins)
+
+	status = http.StatusInternalServerError
+	err = client.UnregisterCluster(context.Background(), "cluster1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "500")
+}
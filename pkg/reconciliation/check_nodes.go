@@ -31,6 +31,9 @@ func (rc *ReconciliationContext) GetPodPVC(podNamespace string, podName string)
 
 func (rc *ReconciliationContext) getPodsPVCs(pods []*corev1.Pod) ([]*corev1.PersistentVolumeClaim, error) {
 	pvcs := []*corev1.PersistentVolumeClaim{}
+	if rc.Datacenter.IsEphemeralStorageEnabled() {
+		return pvcs, nil
+	}
 	for _, pod := range pods {
 		pvc, err := rc.GetPodPVC(pod.Namespace, pod.Name)
 		if err != nil {
@@ -158,6 +161,9 @@ func (rc *ReconciliationContext) GetAllPodsNotReadyInDC() []*corev1.Pod {
 }
 
 func (rc *ReconciliationContext) GetPodPVCs(pod *corev1.Pod) ([]*corev1.PersistentVolumeClaim, error) {
+	if rc.Datacenter.IsEphemeralStorageEnabled() {
+		return []*corev1.PersistentVolumeClaim{}, nil
+	}
 	pvc, err := rc.GetPodPVC(pod.Namespace, pod.Name)
 	if err != nil {
 		return nil, err
@@ -171,31 +177,38 @@ func (rc *ReconciliationContext) StartNodeReplace(podName string) error {
 		return fmt.Errorf("Pod with name '%s' not part of datacenter", podName)
 	}
 
-	pvc, err := rc.GetPodPVC(pod.Namespace, pod.Name)
-	if err != nil {
-		return err
-	}
-	if pvc == nil {
-		return fmt.Errorf("Pod with name '%s' does not have a PVC", podName)
+	// The data volume of the ephemeral storage mode is deleted with the pod
+	var pvc *corev1.PersistentVolumeClaim
+	if !rc.Datacenter.IsEphemeralStorageEnabled() {
+		var err error
+		pvc, err = rc.GetPodPVC(pod.Namespace, pod.Name)
+		if err != nil {
+			return err
+		}
+		if pvc == nil {
+			return fmt.Errorf("Pod with name '%s' does not have a PVC", podName)
+		}
 	}
 
-	// Add the cassandra node to replace nodes
-	rc.Datacenter.Spec.ReplaceNodes = append(rc.Datacenter.Spec.ReplaceNodes, podName)
+	// Add the cassandra node to replace nodes, unless a previous attempt did
+	if utils.IndexOfString(rc.Datacenter.Spec.ReplaceNodes, podName) < 0 {
+		rc.Datacenter.Spec.ReplaceNodes = append(rc.Datacenter.Spec.ReplaceNodes, podName)
 
-	// Update CassandraDatacenter
-	if err := rc.Client.Update(rc.Ctx, rc.Datacenter); err != nil {
-		rc.ReqLogger.Error(err, "Failed to update CassandraDatacenter with removed finalizers")
-		return err
+		// Update CassandraDatacenter
+		if err := rc.Client.Update(rc.Ctx, rc.Datacenter); err != nil {
+			rc.ReqLogger.Error(err, "Failed to update CassandraDatacenter with removed finalizers")
+			return err
+		}
 	}
 
 	// delete pod and pvc
-	err = rc.removePVC(pvc)
-	if err != nil {
-		return err
+	if pvc != nil {
+		if err := rc.removePVC(pvc); err != nil {
+			return err
+		}
 	}
 
-	err = rc.RemovePod(pod)
-	if err != nil {
+	if err := rc.RemovePod(pod); err != nil {
 		return err
 	}
 
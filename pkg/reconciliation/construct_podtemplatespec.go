@@ -6,7 +6,6 @@ package reconciliation
 // This file defines constructors for k8s objects
 
 import (
-	"encoding/json"
 	"fmt"
 	"reflect"
 	"sort"
@@ -14,7 +13,6 @@ import (
 	"github.com/pkg/errors"
 
 	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
-	"github.com/k8ssandra/cass-operator/pkg/cdc"
 	"github.com/k8ssandra/cass-operator/pkg/httphelper"
 	"github.com/k8ssandra/cass-operator/pkg/images"
 	"github.com/k8ssandra/cass-operator/pkg/oplabels"
@@ -97,6 +95,23 @@ func calculatePodAntiAffinity(allowMultipleNodesPerWorker bool) *corev1.PodAntiA
 	}
 }
 
+// calculateTopologySpreadConstraints returns the topology spread constraints of the datacenter, the ones
+// without a label selector counting the pods of the datacenter
+func calculateTopologySpreadConstraints(dc *api.CassandraDatacenter) []corev1.TopologySpreadConstraint {
+	if len(dc.Spec.TopologySpreadConstraints) == 0 {
+		return nil
+	}
+	constraints := make([]corev1.TopologySpreadConstraint, 0, len(dc.Spec.TopologySpreadConstraints))
+	for _, constraint := range dc.Spec.TopologySpreadConstraints {
+		constraint = *constraint.DeepCopy()
+		if constraint.LabelSelector == nil {
+			constraint.LabelSelector = &metav1.LabelSelector{MatchLabels: dc.GetDatacenterLabels()}
+		}
+		constraints = append(constraints, constraint)
+	}
+	return constraints
+}
+
 func selectorFromFieldPath(fieldPath string) *corev1.EnvVarSource {
 	return &corev1.EnvVarSource{
 		FieldRef: &corev1.ObjectFieldSelector{
@@ -123,16 +138,16 @@ func probe(port int, path string, initDelay int, period int, timeout int) *corev
 	}
 }
 
-func getJvmExtraOpts(dc *api.CassandraDatacenter) string {
+func getJvmExtraOpts(workloads *api.DseWorkloads) string {
 	flags := ""
 
-	if dc.Spec.DseWorkloads.AnalyticsEnabled {
+	if workloads.AnalyticsEnabled {
 		flags += "-Dspark-trackers=true "
 	}
-	if dc.Spec.DseWorkloads.GraphEnabled {
+	if workloads.GraphEnabled {
 		flags += "-Dgraph-enabled=true "
 	}
-	if dc.Spec.DseWorkloads.SearchEnabled {
+	if workloads.SearchEnabled {
 		flags += "-Dsearch-service=true"
 	}
 	return flags
@@ -206,6 +221,19 @@ func generateStorageConfigVolumesMount(cc *api.CassandraDatacenter) []corev1.Vol
 	return vms
 }
 
+// getStorageDirectoriesVolumeMounts mounts the commit log and saved caches claims of the StorageConfig
+// where the rendered config expects them
+func getStorageDirectoriesVolumeMounts(dc *api.CassandraDatacenter) []corev1.VolumeMount {
+	var vms []corev1.VolumeMount
+	if dc.Spec.StorageConfig.CommitLogVolumeClaimSpec != nil {
+		vms = append(vms, corev1.VolumeMount{Name: api.CommitLogVolumeName, MountPath: api.CommitLogDir})
+	}
+	if dc.Spec.StorageConfig.SavedCachesVolumeClaimSpec != nil {
+		vms = append(vms, corev1.VolumeMount{Name: api.SavedCachesVolumeName, MountPath: api.SavedCachesDir})
+	}
+	return vms
+}
+
 func generateStorageConfigEmptyVolumes(cc *api.CassandraDatacenter) []corev1.Volume {
 	var volumes []corev1.Volume
 	for _, storage := range cc.Spec.StorageConfig.AdditionalVolumes {
@@ -214,7 +242,7 @@ func generateStorageConfigEmptyVolumes(cc *api.CassandraDatacenter) []corev1.Vol
 	return volumes
 }
 
-func addVolumes(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTemplateSpec) {
+func addVolumes(dc *api.CassandraDatacenter, rackName string, baseTemplate *corev1.PodTemplateSpec) error {
 	vServerConfig := corev1.Volume{
 		Name: "server-config",
 		VolumeSource: corev1.VolumeSource{
@@ -240,10 +268,56 @@ func addVolumes(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTemplateSpe
 
 	volumeDefaults := []corev1.Volume{vServerConfig, vServerLogs, vServerEncryption}
 
+	if len(dc.Spec.ConfigSecret) == 0 {
+		vServerConfigData, err := getServerConfigDataVolume(dc, rackName)
+		if err != nil {
+			return err
+		}
+		volumeDefaults = append(volumeDefaults, vServerConfigData)
+	}
+
+	if dc.Spec.Monitoring != nil {
+		volumeDefaults = append(volumeDefaults, getMetricsExporterVolume(dc))
+	}
+
+	if dc.Spec.InternodeEncryption {
+		volumeDefaults = append(volumeDefaults, getInternodeKeystoresVolume(dc), getInternodeEncryptionVolume())
+	}
+
+	if dc.Spec.ClientEncryption != nil {
+		volumeDefaults = append(volumeDefaults, getClientKeystoreVolume(dc))
+	}
+
+	if dc.IsBroadcastAddressPublished() {
+		volumeDefaults = append(volumeDefaults, getBroadcastAddressesVolume(dc))
+	}
+
+	if dc.IsEphemeralStorageEnabled() {
+		volumeDefaults = append(volumeDefaults, getEphemeralDataVolume(dc))
+	}
+
 	volumeDefaults = combineVolumeSlices(
 		volumeDefaults, baseTemplate.Spec.Volumes)
 
 	baseTemplate.Spec.Volumes = symmetricDifference(volumeDefaults, generateStorageConfigEmptyVolumes(dc))
+	return nil
+}
+
+// getEphemeralDataVolume returns the data volume of the ephemeral storage mode, in place of the
+// PersistentVolumeClaim of the StatefulSet
+func getEphemeralDataVolume(dc *api.CassandraDatacenter) corev1.Volume {
+	source := dc.Spec.StorageConfig.EphemeralDataVolume.DeepCopy()
+	volume := corev1.Volume{Name: PvcName}
+	if source.Ephemeral != nil {
+		volume.Ephemeral = source.Ephemeral
+		if template := volume.Ephemeral.VolumeClaimTemplate; template != nil {
+			template.Labels = utils.MergeMap(dc.GetDatacenterLabels(), template.Labels)
+			oplabels.AddOperatorLabels(template.Labels, dc)
+		}
+	} else {
+		volume.EmptyDir = source.EmptyDir
+	}
+	return volume
 }
 
 func symmetricDifference(list1 []corev1.Volume, list2 []corev1.Volume) []corev1.Volume {
@@ -286,8 +360,11 @@ func buildInitContainers(dc *api.CassandraDatacenter, rackName string, baseTempl
 		} else {
 			serverCfg.Image = images.GetConfigBuilderImage()
 		}
-		if images.GetImageConfig() != nil && images.GetImageConfig().ImagePullPolicy != "" {
-			serverCfg.ImagePullPolicy = images.GetImageConfig().ImagePullPolicy
+		if dc.Spec.ImageOverrides != nil {
+			serverCfg.Image = applyImageOverride(serverCfg.Image, dc.Spec.ImageOverrides.ConfigBuilder)
+		}
+		if pullPolicy := getImagePullPolicy(dc); pullPolicy != "" {
+			serverCfg.ImagePullPolicy = pullPolicy
 		}
 	}
 
@@ -296,17 +373,27 @@ func buildInitContainers(dc *api.CassandraDatacenter, rackName string, baseTempl
 		MountPath: "/config",
 	}
 
-	serverCfg.VolumeMounts = combineVolumeMountSlices([]corev1.VolumeMount{serverCfgMount}, serverCfg.VolumeMounts)
+	serverCfgMounts := []corev1.VolumeMount{serverCfgMount}
+
+	if len(dc.Spec.ConfigSecret) == 0 {
+		serverCfgMounts = append(serverCfgMounts, corev1.VolumeMount{
+			Name:      serverConfigDataVolumeName,
+			MountPath: serverConfigDataPath,
+			ReadOnly:  true,
+		})
+	}
+
+	serverCfg.VolumeMounts = combineVolumeMountSlices(serverCfgMounts, serverCfg.VolumeMounts)
 
 	serverCfg.Resources = *getResourcesOrDefault(&dc.Spec.ConfigBuilderResources, &DefaultsConfigInitContainer)
 
 	// Convert the bool to a string for the env var setting
 	useHostIpForBroadcast := "false"
-	if dc.IsNodePortEnabled() {
+	if dc.IsHostIPBroadcastEnabled() {
 		useHostIpForBroadcast = "true"
 	}
 
-	configEnvVar, err := getConfigDataEnVars(dc)
+	configEnvVar, err := getConfigDataEnVars(dc, rackName)
 	if err != nil {
 		return errors.Wrap(err, "failed to get config env vars")
 	}
@@ -334,10 +421,67 @@ func buildInitContainers(dc *api.CassandraDatacenter, rackName string, baseTempl
 		baseTemplate.Spec.InitContainers = append(baseTemplate.Spec.InitContainers, *serverCfg)
 	}
 
+	if dc.IsBroadcastAddressPublished() {
+		found := false
+		for _, c := range baseTemplate.Spec.InitContainers {
+			if c.Name == broadcastAddressContainer {
+				found = true
+				break
+			}
+		}
+		if !found {
+			for i, c := range baseTemplate.Spec.InitContainers {
+				if c.Name == ServerConfigContainerName {
+					broadcastAddress := newBroadcastAddressInitContainer(&c)
+					initContainers := append([]corev1.Container{}, baseTemplate.Spec.InitContainers[:i+1]...)
+					initContainers = append(initContainers, broadcastAddress)
+					baseTemplate.Spec.InitContainers = append(initContainers, baseTemplate.Spec.InitContainers[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+
+	if dc.Spec.InternodeEncryption {
+		found := false
+		for _, c := range baseTemplate.Spec.InitContainers {
+			if c.Name == internodeKeystoresContainer {
+				found = true
+				break
+			}
+		}
+		if !found {
+			for _, c := range baseTemplate.Spec.InitContainers {
+				if c.Name == ServerConfigContainerName {
+					baseTemplate.Spec.InitContainers = append(baseTemplate.Spec.InitContainers, newInternodeKeystoresInitContainer(&c))
+					break
+				}
+			}
+		}
+	}
+
+	if dc.Spec.ClientEncryption != nil && dc.Spec.ClientEncryption.KeystoreSecretName != "" {
+		found := false
+		for _, c := range baseTemplate.Spec.InitContainers {
+			if c.Name == clientKeystorePasswordContainer {
+				found = true
+				break
+			}
+		}
+		if !found {
+			for _, c := range baseTemplate.Spec.InitContainers {
+				if c.Name == ServerConfigContainerName {
+					baseTemplate.Spec.InitContainers = append(baseTemplate.Spec.InitContainers, newClientKeystorePasswordInitContainer(&c))
+					break
+				}
+			}
+		}
+	}
+
 	return nil
 }
 
-func getConfigDataEnVars(dc *api.CassandraDatacenter) ([]corev1.EnvVar, error) {
+func getConfigDataEnVars(dc *api.CassandraDatacenter, rackName string) ([]corev1.EnvVar, error) {
 	envVars := make([]corev1.EnvVar, 0)
 
 	if len(dc.Spec.ConfigSecret) > 0 {
@@ -364,15 +508,23 @@ func getConfigDataEnVars(dc *api.CassandraDatacenter) ([]corev1.EnvVar, error) {
 		return nil, fmt.Errorf("datacenter %s is missing %s annotation", dc.Name, api.ConfigHashAnnotation)
 	}
 
-	configData, err := dc.GetConfigAsJSON(dc.Spec.Config)
+	// The config is read from the rack config map written by CheckConfigMap, its name changes with
+	// the config so that the pods are restarted when it changes
+	configData, err := getConfigData(dc, rackName)
 	if err != nil {
 		return envVars, err
 	}
-	cdcAdded, err := cdc.UpdateConfig(json.RawMessage(configData), *dc)
-	if err != nil {
-		return envVars, err
-	}
-	envVars = append(envVars, corev1.EnvVar{Name: "CONFIG_FILE_DATA", Value: string(cdcAdded)})
+	envVars = append(envVars, corev1.EnvVar{
+		Name: "CONFIG_FILE_DATA",
+		ValueFrom: &corev1.EnvVarSource{
+			ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: getRackConfigMapName(dc, rackName, configData),
+				},
+				Key: "config",
+			},
+		},
+	})
 
 	return envVars, nil
 }
@@ -384,23 +536,66 @@ func getConfigDataEnVars(dc *api.CassandraDatacenter) ([]corev1.EnvVar, error) {
 // If serverImage is empty, we attempt to find an appropriate container image based on the serverVersion
 // In the event that no image is found, an error is returned
 func makeImage(dc *api.CassandraDatacenter) (string, error) {
-	if dc.GetServerImage() == "" {
-		return images.GetCassandraImage(dc.Spec.ServerType, dc.Spec.ServerVersion)
+	image := dc.GetServerImage()
+	if image == "" {
+		var err error
+		if image, err = images.GetCassandraImage(dc.Spec.ServerType, dc.Spec.ServerVersion); err != nil {
+			return "", err
+		}
+	}
+	if dc.Spec.ImageOverrides != nil {
+		image = applyImageOverride(image, dc.Spec.ImageOverrides.Server)
+	}
+	return image, nil
+}
+
+// applyImageOverride Replaces the components of the image that the override sets. A new tag drops the
+// digest of the image, which would otherwise take precedence when pulling it.
+func applyImageOverride(image string, override *api.ImageOverride) string {
+	if override == nil {
+		return image
+	}
+	components := images.ParseImage(image)
+	if override.Registry != "" {
+		components.Registry = override.Registry
+	}
+	if override.Repository != "" {
+		components.Repository = override.Repository
 	}
-	return dc.GetServerImage(), nil
+	if override.Tag != "" {
+		components.Tag = override.Tag
+		components.Digest = ""
+	}
+	if override.Digest != "" {
+		components.Digest = override.Digest
+	}
+	return components.String()
+}
+
+// getImagePullPolicy Returns the pull policy of the spec, or else the one of the ImageConfig
+func getImagePullPolicy(dc *api.CassandraDatacenter) corev1.PullPolicy {
+	if dc.Spec.ImagePullPolicy != "" {
+		return dc.Spec.ImagePullPolicy
+	}
+	if images.GetImageConfig() != nil {
+		return images.GetImageConfig().ImagePullPolicy
+	}
+	return ""
 }
 
 // If values are provided in the matching containers in the
 // PodTemplateSpec field of the dc, they will override defaults.
-func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTemplateSpec) error {
+func buildContainers(dc *api.CassandraDatacenter, rackName string, baseTemplate *corev1.PodTemplateSpec) error {
 
 	// Create new Container structs or get references to existing ones
 
 	cassContainer := &corev1.Container{}
 	loggerContainer := &corev1.Container{}
+	exporterContainer := &corev1.Container{}
 
 	foundCass := false
 	foundLogger := false
+	foundExporter := false
 	for i, c := range baseTemplate.Spec.Containers {
 		if c.Name == CassandraContainerName {
 			foundCass = true
@@ -408,6 +603,9 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 		} else if c.Name == SystemLoggerContainerName {
 			foundLogger = true
 			loggerContainer = &baseTemplate.Spec.Containers[i]
+		} else if c.Name == MetricsExporterContainerName {
+			foundExporter = true
+			exporterContainer = &baseTemplate.Spec.Containers[i]
 		}
 	}
 
@@ -421,14 +619,18 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 		}
 
 		cassContainer.Image = serverImage
-		if images.GetImageConfig() != nil && images.GetImageConfig().ImagePullPolicy != "" {
-			cassContainer.ImagePullPolicy = images.GetImageConfig().ImagePullPolicy
+		if pullPolicy := getImagePullPolicy(dc); pullPolicy != "" {
+			cassContainer.ImagePullPolicy = pullPolicy
 		}
 	}
 
 	if reflect.DeepEqual(cassContainer.Resources, corev1.ResourceRequirements{}) {
 		cassContainer.Resources = dc.Spec.Resources
 	}
+	if reflect.DeepEqual(cassContainer.Resources, corev1.ResourceRequirements{}) &&
+		dc.Spec.ServerType == "dse" && dc.GetRackDseWorkloads(rackName).IsEnabled() {
+		cassContainer.Resources = *DefaultsDseWorkloadsContainer.DeepCopy()
+	}
 
 	if cassContainer.LivenessProbe == nil {
 		cassContainer.LivenessProbe = probe(8080, httphelper.LivenessEndpoint, 15, 15, 10)
@@ -463,10 +665,10 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 		{Name: "DSE_MGMT_EXPLICIT_START", Value: "true"},
 	}
 
-	if dc.Spec.ServerType == "dse" && dc.Spec.DseWorkloads != nil {
+	if workloads := dc.GetRackDseWorkloads(rackName); dc.Spec.ServerType == "dse" && workloads != nil {
 		envDefaults = append(
 			envDefaults,
-			corev1.EnvVar{Name: "JVM_EXTRA_OPTS", Value: getJvmExtraOpts(dc)})
+			corev1.EnvVar{Name: "JVM_EXTRA_OPTS", Value: getJvmExtraOpts(workloads)})
 	}
 
 	cassContainer.Env = combineEnvSlices(envDefaults, cassContainer.Env)
@@ -478,6 +680,10 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 		return err
 	}
 
+	if dc.IsPodExposureEnabled(api.PodExposureHostPort) {
+		portDefaults = getPodExposureHostPorts(portDefaults)
+	}
+
 	cassContainer.Ports = combinePortSlices(portDefaults, cassContainer.Ports)
 
 	// Combine volumeMounts
@@ -507,6 +713,20 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 			},
 		})
 
+	if dc.Spec.InternodeEncryption {
+		volumeMounts = combineVolumeMountSlices(volumeMounts, getInternodeKeystoresVolumeMounts())
+	}
+
+	if dc.Spec.ClientEncryption != nil {
+		volumeMounts = combineVolumeMountSlices(volumeMounts, []corev1.VolumeMount{{
+			Name:      clientKeystoreVolumeName,
+			MountPath: api.ClientEncryptionDir,
+			ReadOnly:  true,
+		}})
+	}
+
+	volumeMounts = combineVolumeMountSlices(volumeMounts, getStorageDirectoriesVolumeMounts(dc))
+
 	volumeMounts = combineVolumeMountSlices(volumeMounts, cassContainer.VolumeMounts)
 	cassContainer.VolumeMounts = combineVolumeMountSlices(volumeMounts, generateStorageConfigVolumesMount(dc))
 
@@ -521,8 +741,8 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 		} else {
 			loggerContainer.Image = images.GetSystemLoggerImage()
 		}
-		if images.GetImageConfig() != nil && images.GetImageConfig().ImagePullPolicy != "" {
-			loggerContainer.ImagePullPolicy = images.GetImageConfig().ImagePullPolicy
+		if pullPolicy := getImagePullPolicy(dc); pullPolicy != "" {
+			loggerContainer.ImagePullPolicy = pullPolicy
 		}
 	}
 
@@ -532,6 +752,12 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 
 	loggerContainer.Resources = *getResourcesOrDefault(&dc.Spec.SystemLoggerResources, &DefaultsLoggerContainer)
 
+	// Metrics Exporter Container
+
+	if dc.Spec.Monitoring != nil {
+		buildMetricsExporterContainer(dc, exporterContainer)
+	}
+
 	// Note that append() can make copies of each element,
 	// so we call it after modifying any existing elements.
 
@@ -545,6 +771,10 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 		}
 	}
 
+	if dc.Spec.Monitoring != nil && !foundExporter {
+		baseTemplate.Spec.Containers = append(baseTemplate.Spec.Containers, *exporterContainer)
+	}
+
 	return nil
 }
 
@@ -559,11 +789,7 @@ func buildPodTemplateSpec(dc *api.CassandraDatacenter, nodeAffinityLabels map[st
 
 	// Service Account
 
-	serviceAccount := "default"
-	if dc.Spec.ServiceAccount != "" {
-		serviceAccount = dc.Spec.ServiceAccount
-	}
-	baseTemplate.Spec.ServiceAccountName = serviceAccount
+	baseTemplate.Spec.ServiceAccountName = dc.GetServiceAccountName()
 
 	// Host networking
 
@@ -572,6 +798,14 @@ func buildPodTemplateSpec(dc *api.CassandraDatacenter, nodeAffinityLabels map[st
 		baseTemplate.Spec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
 	}
 
+	if baseTemplate.Spec.PriorityClassName == "" {
+		baseTemplate.Spec.PriorityClassName = dc.Spec.PriorityClassName
+	}
+
+	if len(baseTemplate.Spec.TopologySpreadConstraints) == 0 {
+		baseTemplate.Spec.TopologySpreadConstraints = calculateTopologySpreadConstraints(dc)
+	}
+
 	if baseTemplate.Spec.TerminationGracePeriodSeconds == nil {
 		// Note: we cannot take the address of a constant
 		gracePeriodSeconds := int64(DefaultTerminationGracePeriodSeconds)
@@ -588,6 +822,20 @@ func buildPodTemplateSpec(dc *api.CassandraDatacenter, nodeAffinityLabels map[st
 				FSGroup:    &userID,
 			}
 		}
+		if dc.Spec.SecurityContext != nil {
+			if baseTemplate.Spec.SecurityContext == nil {
+				baseTemplate.Spec.SecurityContext = &corev1.PodSecurityContext{}
+			}
+			applyPodSecurityContext(dc.Spec.SecurityContext, baseTemplate.Spec.SecurityContext)
+		}
+	}
+
+	// Image pull secrets of the datacenter
+
+	for _, secret := range dc.Spec.ImagePullSecrets {
+		if !hasImagePullSecret(baseTemplate.Spec.ImagePullSecrets, secret.Name) {
+			baseTemplate.Spec.ImagePullSecrets = append(baseTemplate.Spec.ImagePullSecrets, secret)
+		}
 	}
 
 	// Adds custom registry pull secret if needed
@@ -599,6 +847,9 @@ func buildPodTemplateSpec(dc *api.CassandraDatacenter, nodeAffinityLabels map[st
 	podLabels := dc.GetRackLabels(rackName)
 	oplabels.AddOperatorLabels(podLabels, dc)
 	podLabels[api.CassNodeState] = stateReadyToStart
+	if workloads := dc.GetRackDseWorkloads(rackName); dc.Spec.ServerType == "dse" && workloads != nil && workloads.LabelPods {
+		podLabels[api.DseWorkloadLabel] = workloads.GetLabelValue()
+	}
 
 	if baseTemplate.Labels == nil {
 		baseTemplate.Labels = make(map[string]string)
@@ -608,25 +859,52 @@ func buildPodTemplateSpec(dc *api.CassandraDatacenter, nodeAffinityLabels map[st
 	// Annotations
 
 	podAnnotations := map[string]string{}
+	oplabels.AddOperatorAnnotations(podAnnotations, dc)
+
+	if hash, found := dc.Annotations[api.InternodeKeystoresHashAnnotation]; found && dc.Spec.InternodeEncryption {
+		podAnnotations[api.InternodeKeystoresHashAnnotation] = hash
+	}
+	if hash, found := dc.Annotations[api.CertificatesHashAnnotation]; found && dc.Spec.CertManager != nil {
+		podAnnotations[api.CertificatesHashAnnotation] = hash
+	}
 
 	if baseTemplate.Annotations == nil {
 		baseTemplate.Annotations = make(map[string]string)
 	}
 	baseTemplate.Annotations = utils.MergeMap(baseTemplate.Annotations, podAnnotations)
 
-	// Affinity
+	// Affinity, the rules defined in the PodTemplateSpec are kept unless the operator has its own
 
-	affinity := &corev1.Affinity{}
-	affinity.NodeAffinity = calculateNodeAffinity(nodeAffinityLabels)
-	affinity.PodAntiAffinity = calculatePodAntiAffinity(dc.Spec.AllowMultipleNodesPerWorker)
+	affinity := baseTemplate.Spec.Affinity
+	if affinity == nil {
+		affinity = &corev1.Affinity{}
+	}
+	if nodeAffinity := calculateNodeAffinity(nodeAffinityLabels); nodeAffinity != nil {
+		affinity.NodeAffinity = nodeAffinity
+	}
+	// The pods of the host network would all bind the same ports of the worker
+	allowMultipleNodesPerWorker := dc.Spec.AllowMultipleNodesPerWorker && !dc.IsHostNetworkEnabled() &&
+		!dc.IsPodExposureEnabled(api.PodExposureHostPort)
+	if podAntiAffinity := calculatePodAntiAffinity(allowMultipleNodesPerWorker); podAntiAffinity != nil {
+		affinity.PodAntiAffinity = podAntiAffinity
+	}
 	baseTemplate.Spec.Affinity = affinity
 
 	// Tolerations
 	baseTemplate.Spec.Tolerations = dc.Spec.Tolerations
+	for _, rack := range dc.GetRacks() {
+		if rack.Name == rackName && len(rack.Tolerations) > 0 {
+			tolerations := make([]corev1.Toleration, 0, len(dc.Spec.Tolerations)+len(rack.Tolerations))
+			tolerations = append(tolerations, dc.Spec.Tolerations...)
+			baseTemplate.Spec.Tolerations = append(tolerations, rack.Tolerations...)
+		}
+	}
 
 	// Volumes
 
-	addVolumes(dc, baseTemplate)
+	if err := addVolumes(dc, rackName, baseTemplate); err != nil {
+		return nil, err
+	}
 
 	// Init Containers
 
@@ -637,10 +915,65 @@ func buildPodTemplateSpec(dc *api.CassandraDatacenter, nodeAffinityLabels map[st
 
 	// Containers
 
-	err = buildContainers(dc, baseTemplate)
+	err = buildContainers(dc, rackName, baseTemplate)
 	if err != nil {
 		return nil, err
 	}
 
+	if dc.Spec.SecurityContext != nil {
+		addContainerSecurityContexts(dc.Spec.SecurityContext, baseTemplate.Spec.InitContainers)
+		addContainerSecurityContexts(dc.Spec.SecurityContext, baseTemplate.Spec.Containers)
+	}
+
 	return baseTemplate, nil
 }
+
+// applyPodSecurityContext Overrides the defaults of the pod security context with the fields set in the spec
+func applyPodSecurityContext(securityContext *api.SecurityContextSpec, podSecurityContext *corev1.PodSecurityContext) {
+	if securityContext.RunAsUser != nil {
+		podSecurityContext.RunAsUser = securityContext.RunAsUser
+	}
+	if securityContext.RunAsGroup != nil {
+		podSecurityContext.RunAsGroup = securityContext.RunAsGroup
+	}
+	if securityContext.FSGroup != nil {
+		podSecurityContext.FSGroup = securityContext.FSGroup
+	}
+	if securityContext.RunAsNonRoot != nil {
+		podSecurityContext.RunAsNonRoot = securityContext.RunAsNonRoot
+	}
+	if securityContext.SeccompProfile != "" {
+		podSecurityContext.SeccompProfile = &corev1.SeccompProfile{Type: securityContext.SeccompProfile}
+	}
+}
+
+// addContainerSecurityContexts Sets the container level fields of the spec on the containers that do not
+// have their own security context
+func addContainerSecurityContexts(securityContext *api.SecurityContextSpec, containers []corev1.Container) {
+	if securityContext.AllowPrivilegeEscalation == nil && len(securityContext.DropCapabilities) == 0 {
+		return
+	}
+	for i := range containers {
+		if containers[i].SecurityContext != nil {
+			continue
+		}
+		containerSecurityContext := &corev1.SecurityContext{
+			AllowPrivilegeEscalation: securityContext.AllowPrivilegeEscalation,
+		}
+		if len(securityContext.DropCapabilities) > 0 {
+			containerSecurityContext.Capabilities = &corev1.Capabilities{
+				Drop: append([]corev1.Capability{}, securityContext.DropCapabilities...),
+			}
+		}
+		containers[i].SecurityContext = containerSecurityContext
+	}
+}
+
+func hasImagePullSecret(secrets []corev1.LocalObjectReference, name string) bool {
+	for _, secret := range secrets {
+		if secret.Name == name {
+			return true
+		}
+	}
+	return false
+}
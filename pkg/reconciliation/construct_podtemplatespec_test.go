@@ -14,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/httphelper"
 	"github.com/k8ssandra/cass-operator/pkg/images"
 	"github.com/k8ssandra/cass-operator/pkg/oplabels"
 	"github.com/stretchr/testify/assert"
@@ -157,6 +158,27 @@ func TestCassandraDatacenter_buildInitContainer_with_overrides(t *testing.T) {
 		fmt.Sprintf("Unexpected env vars allocated for the init container: %v", initContainers[0].Env))
 }
 
+func TestCassandraDatacenter_buildInitContainer_hostIPBroadcast(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+			Networking:    &api.NetworkingConfig{BroadcastAddress: api.BroadcastAddressHostIP},
+		},
+	}
+
+	podTemplateSpec := &corev1.PodTemplateSpec{}
+	err := buildInitContainers(dc, "testRack", podTemplateSpec)
+	assert.NoError(t, err)
+
+	initContainers := podTemplateSpec.Spec.InitContainers
+	assert.Contains(t, initContainers[0].Env, corev1.EnvVar{Name: "USE_HOST_IP_FOR_BROADCAST", Value: "true"})
+
+	pod := &corev1.Pod{Status: corev1.PodStatus{PodIP: "10.0.0.1", HostIP: "192.168.0.1"}}
+	assert.Equal(t, "192.168.0.1", getRpcAddress(dc, pod))
+}
+
 func TestCassandraDatacenter_buildContainers_systemlogger_resources_set(t *testing.T) {
 	dc := &api.CassandraDatacenter{
 		Spec: api.CassandraDatacenterSpec{
@@ -177,7 +199,7 @@ func TestCassandraDatacenter_buildContainers_systemlogger_resources_set(t *testi
 	}
 
 	podTemplateSpec := &corev1.PodTemplateSpec{}
-	err := buildContainers(dc, podTemplateSpec)
+	err := buildContainers(dc, "testRack", podTemplateSpec)
 	containers := podTemplateSpec.Spec.Containers
 	assert.NotNil(t, containers, "Unexpected containers containers received")
 	assert.Nil(t, err, "Unexpected error encountered")
@@ -197,7 +219,7 @@ func TestCassandraDatacenter_buildContainers_systemlogger_resources_set_when_not
 	}
 
 	podTemplateSpec := &corev1.PodTemplateSpec{}
-	err := buildContainers(dc, podTemplateSpec)
+	err := buildContainers(dc, "testRack", podTemplateSpec)
 	containers := podTemplateSpec.Spec.Containers
 	assert.NotNil(t, containers, "Unexpected containers containers received")
 	assert.Nil(t, err, "Unexpected error encountered")
@@ -230,7 +252,7 @@ func TestCassandraDatacenter_buildContainers_use_cassandra_settings(t *testing.T
 	podTemplateSpec := &corev1.PodTemplateSpec{}
 	podTemplateSpec.Spec.Containers = append(podTemplateSpec.Spec.Containers, cassContainer)
 
-	err := buildContainers(dc, podTemplateSpec)
+	err := buildContainers(dc, "testRack", podTemplateSpec)
 	containers := podTemplateSpec.Spec.Containers
 	assert.NotNil(t, containers, "Unexpected containers containers received")
 	assert.Nil(t, err, "Unexpected error encountered")
@@ -331,7 +353,7 @@ func TestServerConfigInitContainerEnvVars(t *testing.T) {
 			},
 		}
 
-		configEnVars, err := getConfigDataEnVars(dc)
+		configEnVars, err := getConfigDataEnVars(dc, rack)
 		assert.NoError(t, err, "failed to get config env vars")
 
 		tt.want = append(tt.want, configEnVars...)
@@ -349,6 +371,38 @@ func TestServerConfigInitContainerEnvVars(t *testing.T) {
 	}
 }
 
+func TestCassandraDatacenter_buildContainers_preStop_drain(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+		},
+	}
+
+	podTemplateSpec := &corev1.PodTemplateSpec{}
+	err := buildContainers(dc, "testRack", podTemplateSpec)
+	assert.NoError(t, err)
+
+	preStop := podTemplateSpec.Spec.Containers[0].Lifecycle.PreStop
+	assert.NotNil(t, preStop, "the cassandra container should drain the node before stopping")
+	assert.Contains(t, preStop.Exec.Command, "wget")
+	assert.Contains(t, preStop.Exec.Command[len(preStop.Exec.Command)-1], httphelper.NodeDrainEndpoint)
+
+	// A preStop hook defined in the podTemplateSpec is kept
+	userPreStop := &corev1.LifecycleHandler{
+		Exec: &corev1.ExecAction{Command: []string{"/bin/sh", "-c", "nodetool drain"}},
+	}
+	podTemplateSpec = &corev1.PodTemplateSpec{}
+	podTemplateSpec.Spec.Containers = []corev1.Container{{
+		Name:      "cassandra",
+		Lifecycle: &corev1.Lifecycle{PreStop: userPreStop},
+	}}
+	err = buildContainers(dc, "testRack", podTemplateSpec)
+	assert.NoError(t, err)
+	assert.Equal(t, userPreStop, podTemplateSpec.Spec.Containers[0].Lifecycle.PreStop)
+}
+
 func TestCassandraDatacenter_buildContainers_override_other_containers(t *testing.T) {
 	dc := &api.CassandraDatacenter{
 		Spec: api.CassandraDatacenterSpec{
@@ -374,7 +428,7 @@ func TestCassandraDatacenter_buildContainers_override_other_containers(t *testin
 		},
 	}
 
-	err := buildContainers(dc, podTemplateSpec)
+	err := buildContainers(dc, "testRack", podTemplateSpec)
 	containers := podTemplateSpec.Spec.Containers
 	assert.NotNil(t, containers, "Unexpected containers containers received")
 	assert.Nil(t, err, "Unexpected error encountered")
@@ -553,14 +607,16 @@ func TestCassandraDatacenter_buildPodTemplateSpec_add_initContainer_with_volumes
 	assert.True(t, volumeMountsContains(initContainers[0].VolumeMounts, volumeMountNameMatcher("server-config")))
 
 	assert.Equal(t, ServerConfigContainerName, initContainers[1].Name)
-	assert.Equal(t, 1, len(initContainers[1].VolumeMounts))
+	assert.Equal(t, 2, len(initContainers[1].VolumeMounts))
 	// We use a contains check here because the ordering is not important
 	assert.True(t, volumeMountsContains(initContainers[1].VolumeMounts, volumeMountNameMatcher("server-config")))
+	assert.True(t, volumeMountsContains(initContainers[1].VolumeMounts, volumeMountNameMatcher(serverConfigDataVolumeName)))
 
 	volumes := podTemplateSpec.Spec.Volumes
-	assert.Equal(t, 4, len(volumes))
+	assert.Equal(t, 5, len(volumes))
 	// We use a contains check here because the ordering is not important
 	assert.True(t, volumesContains(volumes, volumeNameMatcher("server-config")))
+	assert.True(t, volumesContains(volumes, volumeNameMatcher(serverConfigDataVolumeName)))
 	assert.True(t, volumesContains(volumes, volumeNameMatcher("test-data")))
 	assert.True(t, volumesContains(volumes, volumeNameMatcher("server-logs")))
 	assert.True(t, volumesContains(volumes, volumeNameMatcher("encryption-cred-storage")))
@@ -652,14 +708,16 @@ func TestCassandraDatacenter_buildPodTemplateSpec_add_container_with_volumes(t *
 	assert.Equal(t, ServerConfigContainerName, initContainers[0].Name)
 
 	serverConfigInitContainer := initContainers[0]
-	assert.Equal(t, 1, len(serverConfigInitContainer.VolumeMounts))
+	assert.Equal(t, 2, len(serverConfigInitContainer.VolumeMounts))
 	// We use a contains check here because the ordering is not important
 	assert.True(t, volumeMountsContains(serverConfigInitContainer.VolumeMounts, volumeMountNameMatcher("server-config")))
+	assert.True(t, volumeMountsContains(serverConfigInitContainer.VolumeMounts, volumeMountNameMatcher(serverConfigDataVolumeName)))
 
 	volumes := podTemplateSpec.Spec.Volumes
-	assert.Equal(t, 4, len(volumes))
+	assert.Equal(t, 5, len(volumes))
 	// We use a contains check here because the ordering is not important
 	assert.True(t, volumesContains(volumes, volumeNameMatcher("server-config")))
+	assert.True(t, volumesContains(volumes, volumeNameMatcher(serverConfigDataVolumeName)))
 	assert.True(t, volumesContains(volumes, volumeNameMatcher("test-data")))
 	assert.True(t, volumesContains(volumes, volumeNameMatcher("server-logs")))
 	assert.True(t, volumesContains(volumes, volumeNameMatcher("encryption-cred-storage")))
@@ -852,6 +910,48 @@ func TestCassandraDatacenter_buildPodTemplateSpec_overrideSecurityContext(t *tes
 	assert.True(t, reflect.DeepEqual(expected, actual), "SecurityContext does not match expected value")
 }
 
+func TestCassandraDatacenter_buildPodTemplateSpec_securityContextSpec(t *testing.T) {
+	uid := int64(1111)
+	nonRoot := true
+	noEscalation := false
+
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "test",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+			SecurityContext: &api.SecurityContextSpec{
+				RunAsUser:                &uid,
+				RunAsNonRoot:             &nonRoot,
+				AllowPrivilegeEscalation: &noEscalation,
+				DropCapabilities:         []corev1.Capability{"ALL"},
+				SeccompProfile:           corev1.SeccompProfileTypeRuntimeDefault,
+			},
+		},
+	}
+
+	spec, err := buildPodTemplateSpec(dc, map[string]string{zoneLabel: "testzone"}, "rack1")
+	assert.NoError(t, err, "should not have gotten an error when building podTemplateSpec")
+
+	defaultID := int64(999)
+	assert.Equal(t, &corev1.PodSecurityContext{
+		RunAsUser:      &uid,
+		RunAsGroup:     &defaultID,
+		FSGroup:        &defaultID,
+		RunAsNonRoot:   &nonRoot,
+		SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}, spec.Spec.SecurityContext)
+
+	expected := &corev1.SecurityContext{
+		AllowPrivilegeEscalation: &noEscalation,
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+	}
+	assert.NotEmpty(t, spec.Spec.InitContainers)
+	for _, container := range append(spec.Spec.InitContainers, spec.Spec.Containers...) {
+		assert.Equal(t, expected, container.SecurityContext, "container %s", container.Name)
+	}
+}
+
 func TestCassandraDatacenter_buildPodTemplateSpec_do_not_propagate_volumes(t *testing.T) {
 	dc := &api.CassandraDatacenter{
 		Spec: api.CassandraDatacenterSpec{
@@ -893,9 +993,10 @@ func TestCassandraDatacenter_buildPodTemplateSpec_do_not_propagate_volumes(t *te
 	assert.Equal(t, ServerConfigContainerName, initContainers[0].Name)
 
 	serverConfigInitContainer := initContainers[0]
-	assert.Equal(t, 2, len(serverConfigInitContainer.VolumeMounts))
+	assert.Equal(t, 3, len(serverConfigInitContainer.VolumeMounts))
 	// We use a contains check here because the ordering is not important
 	assert.True(t, volumeMountsContains(serverConfigInitContainer.VolumeMounts, volumeMountNameMatcher("server-config")))
+	assert.True(t, volumeMountsContains(serverConfigInitContainer.VolumeMounts, volumeMountNameMatcher(serverConfigDataVolumeName)))
 	assert.True(t, volumeMountsContains(serverConfigInitContainer.VolumeMounts, volumeMountNameMatcher("extra")))
 
 	containers := spec.Spec.Containers
@@ -931,7 +1032,7 @@ func TestCassandraDatacenter_buildContainers_DisableSystemLoggerSidecar(t *testi
 
 	podTemplateSpec := &corev1.PodTemplateSpec{}
 
-	err := buildContainers(dc, podTemplateSpec)
+	err := buildContainers(dc, "testRack", podTemplateSpec)
 
 	assert.NoError(t, err, "should not have gotten error from calling buildContainers()")
 
@@ -953,7 +1054,7 @@ func TestCassandraDatacenter_buildContainers_EnableSystemLoggerSidecar_CustomIma
 
 	podTemplateSpec := &corev1.PodTemplateSpec{}
 
-	err := buildContainers(dc, podTemplateSpec)
+	err := buildContainers(dc, "testRack", podTemplateSpec)
 
 	assert.NoError(t, err, "should not have gotten error from calling buildContainers()")
 
@@ -964,6 +1065,143 @@ func TestCassandraDatacenter_buildContainers_EnableSystemLoggerSidecar_CustomIma
 	assert.Equal(t, "alpine", podTemplateSpec.Spec.Containers[1].Image)
 }
 
+func TestCassandraDatacenter_buildContainers_MetricsExporter(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+			Monitoring:    &api.MonitoringSpec{Image: "exporter:1.0", Port: 9600},
+		},
+	}
+
+	podTemplateSpec := &corev1.PodTemplateSpec{}
+	err := buildContainers(dc, "testRack", podTemplateSpec)
+	assert.NoError(t, err, "should not have gotten error from calling buildContainers()")
+	assert.NoError(t, addVolumes(dc, "rack1", podTemplateSpec))
+
+	assert.Len(t, podTemplateSpec.Spec.Containers, 3, "should have three containers in the podTemplateSpec")
+	exporter := podTemplateSpec.Spec.Containers[2]
+	assert.Equal(t, MetricsExporterContainerName, exporter.Name)
+	assert.Equal(t, "exporter:1.0", exporter.Image)
+	assert.Equal(t, []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9600, Protocol: corev1.ProtocolTCP}}, exporter.Ports)
+	assert.Equal(t, "CONFIG_HASH", exporter.Env[0].Name)
+	assert.Equal(t, "/etc/cassandra_exporter", exporter.VolumeMounts[0].MountPath)
+
+	volume := podTemplateSpec.Spec.Volumes[len(podTemplateSpec.Spec.Volumes)-1]
+	assert.Equal(t, exporter.VolumeMounts[0].Name, volume.Name)
+	assert.Equal(t, "bob-dc1-metrics-exporter-config", volume.ConfigMap.Name)
+}
+
+func TestCassandraDatacenter_buildContainers_InternodeEncryption(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:         "bob",
+			ServerType:          "cassandra",
+			ServerVersion:       "3.11.7",
+			InternodeEncryption: true,
+		},
+	}
+
+	podTemplateSpec := &corev1.PodTemplateSpec{}
+	err := buildContainers(dc, "testRack", podTemplateSpec)
+	assert.NoError(t, err, "should not have gotten error from calling buildContainers()")
+	assert.NoError(t, addVolumes(dc, "rack1", podTemplateSpec))
+
+	cassContainer := podTemplateSpec.Spec.Containers[0]
+	assert.Contains(t, cassContainer.VolumeMounts, corev1.VolumeMount{
+		Name:      "internode-encryption",
+		MountPath: "/etc/internode-encryption",
+	})
+	assert.Contains(t, cassContainer.VolumeMounts, corev1.VolumeMount{
+		Name:      "internode-keystores",
+		MountPath: "/etc/internode-encryption/keystores",
+		ReadOnly:  true,
+	})
+
+	volumes := podTemplateSpec.Spec.Volumes
+	assert.True(t, volumesContains(volumes, volumeNameMatcher("internode-encryption")))
+	volume := volumes[len(volumes)-2]
+	assert.Equal(t, "internode-keystores", volume.Name)
+	assert.Equal(t, "dc1-internode-keystores", volume.Secret.SecretName)
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_InternodeEncryption(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dc1",
+			Annotations: map[string]string{api.InternodeKeystoresHashAnnotation: "somehash"},
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:         "bob",
+			ServerType:          "cassandra",
+			ServerVersion:       "3.11.7",
+			InternodeEncryption: true,
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, nil, "rack1")
+	assert.NoError(t, err)
+	assert.Equal(t, "somehash", podTemplateSpec.Annotations[api.InternodeKeystoresHashAnnotation])
+
+	initContainer := podTemplateSpec.Spec.InitContainers[len(podTemplateSpec.Spec.InitContainers)-1]
+	assert.Equal(t, internodeKeystoresContainer, initContainer.Name)
+	assert.Contains(t, initContainer.Env, corev1.EnvVar{Name: "POD_NAME", ValueFrom: selectorFromFieldPath("metadata.name")})
+	assert.Contains(t, initContainer.Command[2], "/etc/internode-encryption/keystore.jks")
+}
+
+func TestCassandraDatacenter_buildContainers_ClientEncryption(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:      "bob",
+			ServerType:       "cassandra",
+			ServerVersion:    "3.11.7",
+			ClientEncryption: &api.ClientEncryptionSpec{},
+		},
+	}
+
+	podTemplateSpec := &corev1.PodTemplateSpec{}
+	err := buildContainers(dc, "testRack", podTemplateSpec)
+	assert.NoError(t, err, "should not have gotten error from calling buildContainers()")
+	assert.NoError(t, addVolumes(dc, "rack1", podTemplateSpec))
+
+	cassContainer := podTemplateSpec.Spec.Containers[0]
+	assert.Contains(t, cassContainer.VolumeMounts, corev1.VolumeMount{
+		Name:      "client-keystore",
+		MountPath: "/etc/client-encryption",
+		ReadOnly:  true,
+	})
+
+	volume := podTemplateSpec.Spec.Volumes[len(podTemplateSpec.Spec.Volumes)-1]
+	assert.Equal(t, "client-keystore", volume.Name)
+	assert.Equal(t, "dc1-client-keystore", volume.Secret.SecretName)
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_ClientEncryptionFromSecret(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:      "bob",
+			ServerType:       "cassandra",
+			ServerVersion:    "3.11.7",
+			ClientEncryption: &api.ClientEncryptionSpec{KeystoreSecretName: "my-keystore"},
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, nil, "rack1")
+	assert.NoError(t, err)
+
+	// The password of the keystore is rendered in the configuration by the pods
+	initContainer := podTemplateSpec.Spec.InitContainers[len(podTemplateSpec.Spec.InitContainers)-1]
+	assert.Equal(t, clientKeystorePasswordContainer, initContainer.Name)
+	assert.Contains(t, initContainer.Command[2], "/etc/client-encryption/keystore-password")
+	assert.Contains(t, initContainer.Command[2], api.ClientKeystorePasswordPlaceholder)
+	assert.Contains(t, initContainer.VolumeMounts, corev1.VolumeMount{Name: "client-keystore", MountPath: "/etc/client-encryption", ReadOnly: true})
+}
+
 func Test_makeImage(t *testing.T) {
 	type args struct {
 		serverType    string
@@ -1073,6 +1311,251 @@ func Test_makeImage(t *testing.T) {
 	}
 }
 
+func TestCassandraDatacenter_buildPodTemplateSpec_priorityClassName(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:       "test",
+			ServerType:        "cassandra",
+			ServerVersion:     "3.11.7",
+			PriorityClassName: "cassandra-priority",
+		},
+	}
+
+	spec, err := buildPodTemplateSpec(dc, map[string]string{}, "rack1")
+	assert.NoError(t, err)
+	assert.Equal(t, "cassandra-priority", spec.Spec.PriorityClassName)
+
+	// The PodTemplateSpec takes precedence
+	dc.Spec.PodTemplateSpec = &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{PriorityClassName: "template-priority"},
+	}
+	spec, err = buildPodTemplateSpec(dc, map[string]string{}, "rack1")
+	assert.NoError(t, err)
+	assert.Equal(t, "template-priority", spec.Spec.PriorityClassName)
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_topologySpreadConstraints(t *testing.T) {
+	zoneSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "cassandra"}}
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "test",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+			TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+				{
+					MaxSkew:           1,
+					TopologyKey:       "kubernetes.io/hostname",
+					WhenUnsatisfiable: corev1.ScheduleAnyway,
+				},
+				{
+					MaxSkew:           1,
+					TopologyKey:       "topology.kubernetes.io/zone",
+					WhenUnsatisfiable: corev1.DoNotSchedule,
+					LabelSelector:     zoneSelector,
+				},
+			},
+		},
+	}
+
+	spec, err := buildPodTemplateSpec(dc, map[string]string{}, "rack1")
+	assert.NoError(t, err)
+	assert.Len(t, spec.Spec.TopologySpreadConstraints, 2)
+	// The pods of the datacenter are counted by default
+	assert.Equal(t, dc.GetDatacenterLabels(), spec.Spec.TopologySpreadConstraints[0].LabelSelector.MatchLabels)
+	assert.Equal(t, zoneSelector, spec.Spec.TopologySpreadConstraints[1].LabelSelector)
+	assert.Nil(t, dc.Spec.TopologySpreadConstraints[0].LabelSelector)
+
+	// The PodTemplateSpec takes precedence
+	templateConstraints := []corev1.TopologySpreadConstraint{
+		{
+			MaxSkew:           2,
+			TopologyKey:       "topology.kubernetes.io/region",
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector:     zoneSelector,
+		},
+	}
+	dc.Spec.PodTemplateSpec = &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{TopologySpreadConstraints: templateConstraints},
+	}
+	spec, err = buildPodTemplateSpec(dc, map[string]string{}, "rack1")
+	assert.NoError(t, err)
+	assert.Equal(t, templateConstraints, spec.Spec.TopologySpreadConstraints)
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_hostNetwork(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:                 "test",
+			ServerType:                  "cassandra",
+			ServerVersion:               "3.11.7",
+			AllowMultipleNodesPerWorker: true,
+		},
+	}
+
+	spec, err := buildPodTemplateSpec(dc, map[string]string{}, "rack1")
+	assert.NoError(t, err)
+	assert.False(t, spec.Spec.HostNetwork)
+	assert.Nil(t, spec.Spec.Affinity.PodAntiAffinity)
+
+	// The pods can not share a worker with the host network
+	dc.Spec.Networking = &api.NetworkingConfig{HostNetwork: true}
+	spec, err = buildPodTemplateSpec(dc, map[string]string{}, "rack1")
+	assert.NoError(t, err)
+	assert.True(t, spec.Spec.HostNetwork)
+	assert.Equal(t, corev1.DNSClusterFirstWithHostNet, spec.Spec.DNSPolicy)
+	assert.NotNil(t, spec.Spec.Affinity.PodAntiAffinity)
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_podExposure(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:                 "test",
+			ServerType:                  "cassandra",
+			ServerVersion:               "3.11.7",
+			AllowMultipleNodesPerWorker: true,
+			Networking: &api.NetworkingConfig{
+				PodExposure: &api.PodExposureConfig{Type: api.PodExposureHostPort},
+			},
+		},
+	}
+
+	spec, err := buildPodTemplateSpec(dc, map[string]string{}, "rack1")
+	assert.NoError(t, err)
+	assert.NotNil(t, spec.Spec.Affinity.PodAntiAffinity)
+	cassContainer := findContainer(spec.Spec.Containers, CassandraContainerName)
+	hostPorts := map[string]int32{}
+	for _, port := range cassContainer.Ports {
+		hostPorts[port.Name] = port.HostPort
+	}
+	assert.Equal(t, int32(9042), hostPorts["native"])
+	assert.Equal(t, int32(7000), hostPorts["internode"])
+	assert.Equal(t, int32(0), hostPorts["mgmt-api-http"])
+
+	// The address of the load balancer is rendered after the configuration
+	dc.Spec.Networking.PodExposure.Type = api.PodExposureLoadBalancer
+	spec, err = buildPodTemplateSpec(dc, map[string]string{}, "rack1")
+	assert.NoError(t, err)
+	assert.Nil(t, spec.Spec.Affinity.PodAntiAffinity)
+	if !assert.Len(t, spec.Spec.InitContainers, 2) {
+		return
+	}
+	assert.Equal(t, ServerConfigContainerName, spec.Spec.InitContainers[0].Name)
+	assert.Equal(t, broadcastAddressContainer, spec.Spec.InitContainers[1].Name)
+	assert.Equal(t, spec.Spec.InitContainers[0].Image, spec.Spec.InitContainers[1].Image)
+	assert.Contains(t, spec.Spec.Volumes, getBroadcastAddressesVolume(dc))
+	cassContainer = findContainer(spec.Spec.Containers, CassandraContainerName)
+	for _, port := range cassContainer.Ports {
+		assert.Equal(t, int32(0), port.HostPort)
+	}
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_imagePullSecrets(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "test",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+			ImagePullSecrets: []corev1.LocalObjectReference{
+				{Name: "registry-credentials"},
+				{Name: "template-credentials"},
+			},
+			PodTemplateSpec: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					ImagePullSecrets: []corev1.LocalObjectReference{{Name: "template-credentials"}},
+				},
+			},
+		},
+	}
+
+	spec, err := buildPodTemplateSpec(dc, map[string]string{}, "rack1")
+	assert.NoError(t, err)
+	assert.Equal(t, []corev1.LocalObjectReference{
+		{Name: "template-credentials"},
+		{Name: "registry-credentials"},
+	}, spec.Spec.ImagePullSecrets)
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_imageOverrides(t *testing.T) {
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:     "test",
+			ServerType:      "dse",
+			ServerVersion:   "6.8.4",
+			ImagePullPolicy: corev1.PullAlways,
+			ImageOverrides: &api.ImageOverridesSpec{
+				Server: &api.ImageOverride{
+					Registry: "registry.example.com",
+					Tag:      "6.8.4-custom",
+				},
+				ConfigBuilder: &api.ImageOverride{
+					Registry:   "registry.example.com:5000",
+					Repository: "mirror/cass-config-builder",
+					Digest:     digest,
+				},
+			},
+		},
+	}
+
+	spec, err := buildPodTemplateSpec(dc, map[string]string{}, "rack1")
+	assert.NoError(t, err)
+
+	cassContainer := findContainer(spec.Spec.Containers, CassandraContainerName)
+	assert.NotNil(t, cassContainer)
+	assert.Equal(t, "registry.example.com/datastax/dse-server:6.8.4-custom", cassContainer.Image)
+	assert.Equal(t, corev1.PullAlways, cassContainer.ImagePullPolicy)
+
+	configContainer := findContainer(spec.Spec.InitContainers, ServerConfigContainerName)
+	assert.NotNil(t, configContainer)
+	assert.Equal(t, "registry.example.com:5000/mirror/cass-config-builder:1.0.4-ubi7@"+digest, configContainer.Image)
+	assert.Equal(t, corev1.PullAlways, configContainer.ImagePullPolicy)
+
+	// A new tag drops the digest of the server image
+	dc.Spec.ServerImage = "datastax/dse-server:6.8.4@" + digest
+	spec, err = buildPodTemplateSpec(dc, map[string]string{}, "rack1")
+	assert.NoError(t, err)
+	cassContainer = findContainer(spec.Spec.Containers, CassandraContainerName)
+	assert.Equal(t, "registry.example.com/datastax/dse-server:6.8.4-custom", cassContainer.Image)
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_rackDseWorkloads(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "test",
+			ServerType:    "dse",
+			ServerVersion: "6.8.4",
+			DseWorkloads:  &api.DseWorkloads{GraphEnabled: true},
+			Racks: []api.Rack{
+				{Name: "rack1"},
+				{Name: "rack2", DseWorkloads: &api.DseWorkloads{SearchEnabled: true, LabelPods: true}},
+			},
+		},
+	}
+
+	spec, err := buildPodTemplateSpec(dc, map[string]string{}, "rack1")
+	assert.NoError(t, err)
+	cassContainer := findContainer(spec.Spec.Containers, CassandraContainerName)
+	assert.Contains(t, cassContainer.Env, corev1.EnvVar{Name: "JVM_EXTRA_OPTS", Value: "-Dgraph-enabled=true "})
+	assert.Equal(t, DefaultsDseWorkloadsContainer, cassContainer.Resources)
+	assert.NotContains(t, spec.Labels, api.DseWorkloadLabel)
+
+	spec, err = buildPodTemplateSpec(dc, map[string]string{}, "rack2")
+	assert.NoError(t, err)
+	cassContainer = findContainer(spec.Spec.Containers, CassandraContainerName)
+	assert.Contains(t, cassContainer.Env, corev1.EnvVar{Name: "JVM_EXTRA_OPTS", Value: "-Dsearch-service=true"})
+	assert.Equal(t, "Search", spec.Labels[api.DseWorkloadLabel])
+
+	// The resources of the spec replace the defaults
+	dc.Spec.Resources = buildResourceRequirements(4000, 16000)
+	spec, err = buildPodTemplateSpec(dc, map[string]string{}, "rack2")
+	assert.NoError(t, err)
+	cassContainer = findContainer(spec.Spec.Containers, CassandraContainerName)
+	assert.Equal(t, dc.Spec.Resources, cassContainer.Resources)
+}
+
 func TestTolerations(t *testing.T) {
 	tolerations := []corev1.Toleration{
 		{
@@ -1140,3 +1623,96 @@ func TestTolerations(t *testing.T) {
 	// using ElementsMatch instead of Equal because we do not really care about ordering.
 	assert.ElementsMatch(t, tolerations, spec.Spec.Tolerations, "tolerations do not match")
 }
+
+func TestRackTolerations(t *testing.T) {
+	dcToleration := corev1.Toleration{
+		Key:      "cassandra-node",
+		Operator: corev1.TolerationOpExists,
+		Effect:   corev1.TaintEffectNoSchedule,
+	}
+	rackToleration := corev1.Toleration{
+		Key:      "dedicated-pool",
+		Operator: corev1.TolerationOpEqual,
+		Value:    "rack1",
+		Effect:   corev1.TaintEffectNoSchedule,
+	}
+
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "test",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "test",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.10",
+			Tolerations:   []corev1.Toleration{dcToleration},
+			Racks: []api.Rack{
+				{Name: "rack1", Tolerations: []corev1.Toleration{rackToleration}},
+				{Name: "rack2"},
+			},
+		},
+	}
+
+	spec, err := buildPodTemplateSpec(dc, nil, "rack1")
+	assert.NoError(t, err, "failed to build PodTemplateSpec")
+	assert.ElementsMatch(t, []corev1.Toleration{dcToleration, rackToleration}, spec.Spec.Tolerations)
+
+	spec, err = buildPodTemplateSpec(dc, nil, "rack2")
+	assert.NoError(t, err, "failed to build PodTemplateSpec")
+	assert.ElementsMatch(t, []corev1.Toleration{dcToleration}, spec.Spec.Tolerations)
+
+	// The rack tolerations must not leak into the Datacenter ones
+	assert.Len(t, dc.Spec.Tolerations, 1)
+}
+
+func TestPodTemplateSpecAffinity(t *testing.T) {
+	podAffinity := &corev1.PodAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+			{
+				Weight: 100,
+				PodAffinityTerm: corev1.PodAffinityTerm{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "cache"}},
+					TopologyKey:   "kubernetes.io/hostname",
+				},
+			},
+		},
+	}
+	userNodeAffinity := calculateNodeAffinity(map[string]string{"disktype": "ssd"})
+
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "test",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:                 "test",
+			ServerType:                  "cassandra",
+			ServerVersion:               "3.11.10",
+			AllowMultipleNodesPerWorker: true,
+			PodTemplateSpec: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Affinity: &corev1.Affinity{
+						NodeAffinity: userNodeAffinity,
+						PodAffinity:  podAffinity,
+					},
+				},
+			},
+		},
+	}
+
+	spec, err := buildPodTemplateSpec(dc, nil, "rack1")
+	assert.NoError(t, err, "failed to build PodTemplateSpec")
+	assert.Equal(t, podAffinity, spec.Spec.Affinity.PodAffinity)
+	assert.Equal(t, userNodeAffinity, spec.Spec.Affinity.NodeAffinity)
+	assert.Nil(t, spec.Spec.Affinity.PodAntiAffinity)
+
+	// The operator's own rules take precedence
+	dc.Spec.AllowMultipleNodesPerWorker = false
+	nodeAffinityLabels := map[string]string{zoneLabel: "zone1"}
+	spec, err = buildPodTemplateSpec(dc, nodeAffinityLabels, "rack1")
+	assert.NoError(t, err, "failed to build PodTemplateSpec")
+	assert.Equal(t, podAffinity, spec.Spec.Affinity.PodAffinity)
+	assert.Equal(t, calculateNodeAffinity(nodeAffinityLabels), spec.Spec.Affinity.NodeAffinity)
+	assert.Equal(t, calculatePodAntiAffinity(false), spec.Spec.Affinity.PodAntiAffinity)
+}
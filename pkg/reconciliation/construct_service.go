@@ -35,8 +35,8 @@ func newServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev1.Servi
 		namedServicePort("thrift", 9160, 9160),
 	}
 
-	if dc.Spec.DseWorkloads != nil {
-		if dc.Spec.DseWorkloads.AnalyticsEnabled {
+	if workloads := dc.GetAllDseWorkloads(); workloads != nil {
+		if workloads.AnalyticsEnabled {
 			ports = append(
 				ports,
 				namedServicePort("dsefs-public", 5598, 5598),
@@ -48,14 +48,14 @@ func newServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev1.Servi
 			)
 		}
 
-		if dc.Spec.DseWorkloads.GraphEnabled {
+		if workloads.GraphEnabled {
 			ports = append(
 				ports,
 				namedServicePort("gremlin", 8182, 8182),
 			)
 		}
 
-		if dc.Spec.DseWorkloads.SearchEnabled {
+		if workloads.SearchEnabled {
 			ports = append(
 				ports,
 				namedServicePort("solr", 8983, 8983),
@@ -115,7 +115,12 @@ func newSeedServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev1.S
 	oplabels.AddOperatorLabels(labels, dc)
 	service.ObjectMeta.Labels = labels
 
-	service.Spec.Selector = buildLabelSelectorForSeedService(dc)
+	if dc.Spec.ManagedSeedEndpoints {
+		// Without a selector, Kubernetes leaves the Endpoints of the service to the operator
+		service.Spec.Selector = nil
+	} else {
+		service.Spec.Selector = buildLabelSelectorForSeedService(dc)
+	}
 	service.Spec.PublishNotReadyAddresses = true
 
 	addAdditionalOptions(service, &dc.Spec.AdditionalServiceConfig.SeedService)
@@ -134,6 +139,8 @@ func newAdditionalSeedServiceForCassandraDatacenter(dc *api.CassandraDatacenter)
 	service.ObjectMeta.Name = dc.GetAdditionalSeedsServiceName()
 	service.ObjectMeta.Namespace = dc.Namespace
 	service.ObjectMeta.Labels = labels
+	service.ObjectMeta.Annotations = map[string]string{}
+	oplabels.AddOperatorAnnotations(service.ObjectMeta.Annotations, dc)
 	// We omit the label selector because we will create the endpoints manually
 	service.Spec.Type = "ClusterIP"
 	service.Spec.ClusterIP = "None"
@@ -235,6 +242,29 @@ func newNodePortServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *core
 	return service
 }
 
+// newSeedExportServiceForCassandraDatacenter creates a LoadBalancer service owned by the CassandraDatacenter
+// in front of its seeds, for the nodes of the datacenters running in other Kubernetes clusters
+func newSeedExportServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev1.Service {
+	service := makeGenericHeadlessService(dc)
+	service.ObjectMeta.Name = dc.GetSeedExportServiceName()
+
+	service.Spec.Type = corev1.ServiceTypeLoadBalancer
+	// Note: ClusterIp = "None" is not valid for LoadBalancer
+	service.Spec.ClusterIP = ""
+	service.Spec.Selector[api.SeedNodeLabel] = "true"
+
+	service.Spec.Ports = []corev1.ServicePort{
+		namedServicePort("internode", 7000, 7000),
+		namedServicePort("tls-internode", 7001, 7001),
+	}
+
+	addAdditionalOptions(service, &dc.Spec.AdditionalServiceConfig.SeedExportService)
+
+	utils.AddHashAnnotation(service)
+
+	return service
+}
+
 // newAllPodsServiceForCassandraDatacenter creates a headless service owned by the CassandraDatacenter,
 // which covers all server pods in the datacenter, whether they are ready or not
 func newAllPodsServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev1.Service {
@@ -260,6 +290,13 @@ func newAllPodsServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev
 		},
 	}
 
+	if dc.Spec.Monitoring != nil {
+		port := dc.GetMetricsExporterPort()
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name: metricsExporterPortName, Port: port, TargetPort: intstr.FromInt(int(port)),
+		})
+	}
+
 	addAdditionalOptions(service, &dc.Spec.AdditionalServiceConfig.AllPodsService)
 
 	utils.AddHashAnnotation(service)
@@ -278,6 +315,8 @@ func makeGenericHeadlessService(dc *api.CassandraDatacenter) *corev1.Service {
 	var service corev1.Service
 	service.ObjectMeta.Namespace = dc.Namespace
 	service.ObjectMeta.Labels = labels
+	service.ObjectMeta.Annotations = map[string]string{}
+	oplabels.AddOperatorAnnotations(service.ObjectMeta.Annotations, dc)
 	service.Spec.Selector = selector
 	service.Spec.Type = "ClusterIP"
 	service.Spec.ClusterIP = "None"
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
@@ -62,6 +63,26 @@ func TestCassandraDatacenter_allPodsServiceLabels(t *testing.T) {
 	}
 }
 
+func TestCassandraDatacenter_allPodsServiceMetricsPort(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "bob",
+		},
+	}
+
+	service := newAllPodsServiceForCassandraDatacenter(dc)
+	assert.Len(t, service.Spec.Ports, 3)
+
+	dc.Spec.Monitoring = &api.MonitoringSpec{Port: 9600}
+	service = newAllPodsServiceForCassandraDatacenter(dc)
+	assert.Len(t, service.Spec.Ports, 4)
+	assert.Equal(t, "metrics", service.Spec.Ports[3].Name)
+	assert.Equal(t, int32(9600), service.Spec.Ports[3].Port)
+}
+
 func TestServiceNameGeneration(t *testing.T) {
 	dc := &api.CassandraDatacenter{
 		ObjectMeta: metav1.ObjectMeta{
@@ -407,3 +428,36 @@ func TestAddingAdditionalLabels(t *testing.T) {
 		t.Errorf("service labels = %v, want %v", service.Labels, expected)
 	}
 }
+
+func TestNewSeedExportServiceForCassandraDatacenter(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dc1",
+			Namespace: "test",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "piclem",
+			ServerVersion: "4.0.1",
+			Networking: &api.NetworkingConfig{
+				SeedExport: &api.SeedExportConfig{Type: api.SeedExportLoadBalancer},
+			},
+			AdditionalServiceConfig: api.ServiceConfig{
+				SeedExportService: api.ServiceConfigAdditions{
+					Annotations: map[string]string{
+						"service.beta.kubernetes.io/aws-load-balancer-internal": "true",
+					},
+				},
+			},
+		},
+	}
+
+	service := newSeedExportServiceForCassandraDatacenter(dc)
+
+	assert.Equal(t, "piclem-dc1-seed-export-service", service.Name)
+	assert.Equal(t, corev1.ServiceTypeLoadBalancer, service.Spec.Type)
+	assert.Empty(t, service.Spec.ClusterIP)
+	assert.Equal(t, "true", service.Spec.Selector[api.SeedNodeLabel])
+	assert.Equal(t, "dc1", service.Spec.Selector[api.DatacenterLabel])
+	assert.Len(t, service.Spec.Ports, 2)
+	assert.Equal(t, "true", service.Annotations["service.beta.kubernetes.io/aws-load-balancer-internal"])
+}
@@ -21,7 +21,10 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 )
 
-const zoneLabel = "failure-domain.beta.kubernetes.io/zone"
+const (
+	zoneLabel         = "failure-domain.beta.kubernetes.io/zone"
+	topologyZoneLabel = "topology.kubernetes.io/zone"
+)
 
 func newNamespacedNameForStatefulSet(
 	dc *api.CassandraDatacenter,
@@ -91,6 +94,9 @@ func newStatefulSetForCassandraDatacenter(
 	pvcLabels := dc.GetRackLabels(rackName)
 	oplabels.AddOperatorLabels(pvcLabels, dc)
 
+	pvcAnnotations := map[string]string{}
+	oplabels.AddOperatorAnnotations(pvcAnnotations, dc)
+
 	statefulSetLabels := dc.GetRackLabels(rackName)
 	oplabels.AddOperatorLabels(statefulSetLabels, dc)
 
@@ -103,25 +109,51 @@ func newStatefulSetForCassandraDatacenter(
 		return nil, nodeAffinityLabelsConfigurationError
 	}
 
-	// Add storage
-	if dc.Spec.StorageConfig.CassandraDataVolumeClaimSpec == nil {
-		err := fmt.Errorf("StorageConfig.cassandraDataVolumeClaimSpec is required")
-		return nil, err
+	// Add storage, the ephemeral data volume is a volume of the pod template
+	if !dc.IsEphemeralStorageEnabled() {
+		if dc.Spec.StorageConfig.CassandraDataVolumeClaimSpec == nil {
+			err := fmt.Errorf("StorageConfig.cassandraDataVolumeClaimSpec is required")
+			return nil, err
+		}
+
+		volumeClaimTemplates = []corev1.PersistentVolumeClaim{{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      pvcLabels,
+				Annotations: pvcAnnotations,
+				Name:        PvcName,
+			},
+			Spec: *dc.Spec.StorageConfig.CassandraDataVolumeClaimSpec,
+		}}
 	}
 
-	volumeClaimTemplates = []corev1.PersistentVolumeClaim{{
-		ObjectMeta: metav1.ObjectMeta{
-			Labels: pvcLabels,
-			Name:   PvcName,
-		},
-		Spec: *dc.Spec.StorageConfig.CassandraDataVolumeClaimSpec,
-	}}
+	if claim := dc.Spec.StorageConfig.CommitLogVolumeClaimSpec; claim != nil {
+		volumeClaimTemplates = append(volumeClaimTemplates, corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      pvcLabels,
+				Annotations: pvcAnnotations,
+				Name:        api.CommitLogVolumeName,
+			},
+			Spec: *claim,
+		})
+	}
+
+	if claim := dc.Spec.StorageConfig.SavedCachesVolumeClaimSpec; claim != nil {
+		volumeClaimTemplates = append(volumeClaimTemplates, corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      pvcLabels,
+				Annotations: pvcAnnotations,
+				Name:        api.SavedCachesVolumeName,
+			},
+			Spec: *claim,
+		})
+	}
 
 	for _, storage := range dc.Spec.StorageConfig.AdditionalVolumes {
 		pvc := corev1.PersistentVolumeClaim{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:   storage.Name,
-				Labels: pvcLabels,
+				Name:        storage.Name,
+				Labels:      pvcLabels,
+				Annotations: pvcAnnotations,
 			},
 			Spec: storage.PVCSpec,
 		}
@@ -161,6 +193,7 @@ func newStatefulSetForCassandraDatacenter(
 		},
 	}
 	result.Annotations = map[string]string{}
+	oplabels.AddOperatorAnnotations(result.Annotations, dc)
 
 	if sts != nil && sts.Spec.ServiceName != "" && sts.Spec.ServiceName != result.Spec.ServiceName {
 		result.Spec.ServiceName = sts.Spec.ServiceName
@@ -80,6 +80,9 @@ func Test_newStatefulSetForCassandraDatacenter_additionalLabels(t *testing.T) {
 			AdditionalLabels: map[string]string{
 				"Add": "label",
 			},
+			AdditionalAnnotations: map[string]string{
+				"Add": "annotation",
+			},
 		},
 	}
 
@@ -119,8 +122,12 @@ func Test_newStatefulSetForCassandraDatacenter_additionalLabels(t *testing.T) {
 
 	for _, volumeClaim := range statefulset.Spec.VolumeClaimTemplates {
 		assert.Equal(t, expectedStatefulsetLabels, volumeClaim.Labels)
+		assert.Equal(t, map[string]string{"Add": "annotation"}, volumeClaim.Annotations)
 	}
 
+	assert.Equal(t, "annotation", statefulset.Annotations["Add"])
+	assert.Equal(t, "annotation", statefulset.Spec.Template.Annotations["Add"])
+
 }
 
 func Test_newStatefulSetForCassandraDatacenter_rackNodeAffinitylabels(t *testing.T) {
@@ -257,9 +264,10 @@ func Test_newStatefulSetForCassandraDatacenterWithAdditionalVolumes(t *testing.T
 		assert.Equal(t, "cassandra-commitlogs", got.Spec.VolumeClaimTemplates[2].Name)
 		assert.Equal(t, customCassandraCommitLogsStorageClass, *got.Spec.VolumeClaimTemplates[2].Spec.StorageClassName)
 
-		assert.Equal(t, 2, len(got.Spec.Template.Spec.Volumes))
+		assert.Equal(t, 3, len(got.Spec.Template.Spec.Volumes))
 		assert.Equal(t, "server-config", got.Spec.Template.Spec.Volumes[0].Name)
 		assert.Equal(t, "encryption-cred-storage", got.Spec.Template.Spec.Volumes[1].Name)
+		assert.Equal(t, serverConfigDataVolumeName, got.Spec.Template.Spec.Volumes[2].Name)
 
 		assert.Equal(t, 2, len(got.Spec.Template.Spec.Containers))
 
@@ -281,10 +289,50 @@ func Test_newStatefulSetForCassandraDatacenterWithAdditionalVolumes(t *testing.T
 
 		assert.Equal(t, "server-config-init", got.Spec.Template.Spec.InitContainers[1].Name)
 		assert.Equal(t, "datastax/cass-config-builder:1.0.4-ubi7", got.Spec.Template.Spec.InitContainers[1].Image)
-		assert.Equal(t, 1, len(got.Spec.Template.Spec.InitContainers[1].VolumeMounts))
+		assert.Equal(t, 2, len(got.Spec.Template.Spec.InitContainers[1].VolumeMounts))
 		assert.Equal(t, "server-config", got.Spec.Template.Spec.InitContainers[1].VolumeMounts[0].Name)
 		assert.Equal(t, "/config", got.Spec.Template.Spec.InitContainers[1].VolumeMounts[0].MountPath)
+		assert.Equal(t, serverConfigDataVolumeName, got.Spec.Template.Spec.InitContainers[1].VolumeMounts[1].Name)
+		assert.Equal(t, serverConfigDataPath, got.Spec.Template.Spec.InitContainers[1].VolumeMounts[1].MountPath)
+	}
+}
+
+func Test_newStatefulSetForCassandraDatacenterWithCommitLogAndSavedCachesClaims(t *testing.T) {
+	dataStorageClass := "data"
+	commitLogStorageClass := "commitlog"
+	savedCachesStorageClass := "saved-caches"
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "c1",
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.1",
+			StorageConfig: api.StorageConfig{
+				CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+					StorageClassName: &dataStorageClass,
+				},
+				CommitLogVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+					StorageClassName: &commitLogStorageClass,
+				},
+				SavedCachesVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+					StorageClassName: &savedCachesStorageClass,
+				},
+			},
+		},
 	}
+
+	got, err := newStatefulSetForCassandraDatacenter(nil, "r1", dc, 1)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3, len(got.Spec.VolumeClaimTemplates))
+	assert.Equal(t, "server-data", got.Spec.VolumeClaimTemplates[0].Name)
+	assert.Equal(t, "server-commitlog", got.Spec.VolumeClaimTemplates[1].Name)
+	assert.Equal(t, commitLogStorageClass, *got.Spec.VolumeClaimTemplates[1].Spec.StorageClassName)
+	assert.Equal(t, "server-saved-caches", got.Spec.VolumeClaimTemplates[2].Name)
+	assert.Equal(t, savedCachesStorageClass, *got.Spec.VolumeClaimTemplates[2].Spec.StorageClassName)
+
+	cassContainer := got.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, cassContainer.VolumeMounts, corev1.VolumeMount{Name: "server-commitlog", MountPath: "/var/lib/cassandra-commitlog"})
+	assert.Contains(t, cassContainer.VolumeMounts, corev1.VolumeMount{Name: "server-saved-caches", MountPath: "/var/lib/cassandra-saved-caches"})
 }
 
 func Test_newStatefulSetForCassandraPodSecurityContext(t *testing.T) {
@@ -457,3 +505,56 @@ func int64Ptr(n int64) *int64 {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+func Test_newStatefulSetForCassandraDatacenter_ephemeralDataVolume(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "dc1",
+			Namespace: "test",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "cluster1",
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.1",
+			StorageConfig: api.StorageConfig{
+				EphemeralDataVolume: &api.EphemeralDataVolumeSource{
+					EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory},
+				},
+			},
+		},
+	}
+
+	sts, err := newStatefulSetForCassandraDatacenter(nil, "default", dc, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, sts.Spec.VolumeClaimTemplates)
+	volume := findPodTemplateVolume(sts, PvcName)
+	if assert.NotNil(t, volume) {
+		assert.Equal(t, corev1.StorageMediumMemory, volume.EmptyDir.Medium)
+	}
+
+	storageClassName := "local"
+	dc.Spec.StorageConfig.EphemeralDataVolume = &api.EphemeralDataVolumeSource{
+		Ephemeral: &corev1.EphemeralVolumeSource{
+			VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+				Spec: corev1.PersistentVolumeClaimSpec{StorageClassName: &storageClassName},
+			},
+		},
+	}
+	sts, err = newStatefulSetForCassandraDatacenter(nil, "default", dc, 1)
+	assert.NoError(t, err)
+	volume = findPodTemplateVolume(sts, PvcName)
+	if assert.NotNil(t, volume) && assert.NotNil(t, volume.Ephemeral) {
+		assert.Equal(t, "local", *volume.Ephemeral.VolumeClaimTemplate.Spec.StorageClassName)
+		assert.Equal(t, "dc1", volume.Ephemeral.VolumeClaimTemplate.Labels[api.DatacenterLabel])
+	}
+	assert.Nil(t, dc.Spec.StorageConfig.EphemeralDataVolume.Ephemeral.VolumeClaimTemplate.Labels)
+}
+
+func findPodTemplateVolume(sts *appsv1.StatefulSet, name string) *corev1.Volume {
+	for i := range sts.Spec.Template.Spec.Volumes {
+		if sts.Spec.Template.Spec.Volumes[i].Name == name {
+			return &sts.Spec.Template.Spec.Volumes[i]
+		}
+	}
+	return nil
+}
@@ -18,7 +18,6 @@ import (
 
 // Create a PodDisruptionBudget object for the Datacenter
 func newPodDisruptionBudgetForDatacenter(dc *api.CassandraDatacenter) *policyv1.PodDisruptionBudget {
-	minAvailable := intstr.FromInt(int(dc.Spec.Size - 1))
 	labels := dc.GetDatacenterLabels()
 	oplabels.AddOperatorLabels(labels, dc)
 	selectorLabels := dc.GetDatacenterLabels()
@@ -33,10 +32,22 @@ func newPodDisruptionBudgetForDatacenter(dc *api.CassandraDatacenter) *policyv1.
 			Selector: &metav1.LabelSelector{
 				MatchLabels: selectorLabels,
 			},
-			MinAvailable: &minAvailable,
 		},
 	}
 
+	if config := dc.Spec.PodDisruptionBudget; config != nil && config.MaxUnavailable != nil {
+		maxUnavailable := *config.MaxUnavailable
+		pdb.Spec.MaxUnavailable = &maxUnavailable
+	} else if config != nil && config.MinAvailable != nil {
+		minAvailable := *config.MinAvailable
+		pdb.Spec.MinAvailable = &minAvailable
+	} else {
+		minAvailable := intstr.FromInt(int(dc.Spec.Size - 1))
+		pdb.Spec.MinAvailable = &minAvailable
+	}
+
+	oplabels.AddOperatorAnnotations(pdb.Annotations, dc)
+
 	// add a hash here to facilitate checking if updates are needed
 	utils.AddHashAnnotation(pdb)
 
@@ -52,10 +63,6 @@ func setOperatorProgressStatus(rc *ReconciliationContext, newState api.ProgressS
 
 	patch := client.MergeFrom(rc.Datacenter.DeepCopy())
 	rc.Datacenter.Status.CassandraOperatorProgress = newState
-	// TODO there may be a better place to push status.observedGeneration in the reconcile loop
-	if newState == api.ProgressReady {
-		rc.Datacenter.Status.ObservedGeneration = rc.Datacenter.Generation
-	}
 	if err := rc.Client.Status().Patch(rc.Ctx, rc.Datacenter, patch); err != nil {
 		rc.ReqLogger.Error(err, "error updating the Cassandra Operator Progress state")
 		return err
@@ -9,8 +9,11 @@ import (
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	runtimeClient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -24,13 +27,67 @@ import (
 	"github.com/k8ssandra/cass-operator/pkg/utils"
 )
 
+// NodeMgmtClient is the management API access needed by the reconciliation, implemented by
+// httphelper.NodeMgmtClient. Tests can replace it with fakes, and other transports can be
+// plugged in.
+type NodeMgmtClient interface {
+	CallMetadataEndpointsEndpoint(pod *corev1.Pod) (httphelper.CassMetadataEndpoints, error)
+	CallCreateRoleEndpoint(pod *corev1.Pod, username string, password string, superuser bool) error
+	CallDropRoleEndpoint(pod *corev1.Pod, username string) error
+	CallProbeClusterEndpoint(pod *corev1.Pod, consistencyLevel string, rfPerDc int) error
+	CallDrainEndpoint(pod *corev1.Pod) error
+	CallCreateSnapshotEndpoint(pod *corev1.Pod, snapshotName string, keyspaces []string) error
+	CallLifecycleStartEndpointWithReplaceIp(pod *corev1.Pod, replaceIp string) error
+	CallLifecycleStartEndpoint(pod *corev1.Pod) error
+	CallReloadSeedsEndpoint(pod *corev1.Pod) error
+	CallDecommissionNodeEndpoint(pod *corev1.Pod) error
+	CallDecommissionNode(pod *corev1.Pod, force bool) (string, error)
+	FeatureSet(pod *corev1.Pod) (*httphelper.FeatureSet, error)
+	CallIsFullQueryLogEnabledEndpoint(pod *corev1.Pod) (bool, error)
+	CallSetFullQueryLog(pod *corev1.Pod, enableFullQueryLogging bool) error
+	GetKeyspace(pod *corev1.Pod, keyspaceName string) ([]string, error)
+	ListKeyspaces(pod *corev1.Pod) ([]string, error)
+	CreateKeyspace(pod *corev1.Pod, keyspaceName string, replicationSettings []map[string]string) error
+	AlterKeyspace(pod *corev1.Pod, keyspaceName string, replicationSettings []map[string]string) error
+	GetKeyspaceReplication(pod *corev1.Pod, keyspaceName string) (map[string]string, error)
+}
+
+var _ NodeMgmtClient = &httphelper.NodeMgmtClient{}
+
+// PodEvictor evicts pods with the Eviction API, which refuses the evictions the PodDisruptionBudget of the
+// datacenter does not allow. Tests can replace it with fakes.
+type PodEvictor interface {
+	EvictPod(ctx context.Context, pod *corev1.Pod) error
+}
+
+type podEvictor struct {
+	clientset kubernetes.Interface
+}
+
+// NewPodEvictor returns a PodEvictor sending the evictions to the API server of the config, the client of
+// controller-runtime does not support the eviction subresource
+func NewPodEvictor(config *rest.Config) (PodEvictor, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &podEvictor{clientset: clientset}, nil
+}
+
+func (e *podEvictor) EvictPod(ctx context.Context, pod *corev1.Pod) error {
+	return e.clientset.PolicyV1().Evictions(pod.Namespace).Evict(ctx, &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+	})
+}
+
 // ReconciliationContext contains all of the input necessary to calculate a list of ReconciliationActions
 type ReconciliationContext struct {
 	Request          *reconcile.Request
 	Client           runtimeClient.Client
 	Scheme           *runtime.Scheme
 	Datacenter       *api.CassandraDatacenter
-	NodeMgmtClient   httphelper.NodeMgmtClient
+	NodeMgmtClient   NodeMgmtClient
+	PodEvictor       PodEvictor
 	Recorder         record.EventRecorder
 	ReqLogger        logr.Logger
 	PSPHealthUpdater psp.HealthStatusUpdater
@@ -47,6 +104,13 @@ type ReconciliationContext struct {
 	statefulSets           []*appsv1.StatefulSet
 	dcPods                 []*corev1.Pod
 	clusterPods            []*corev1.Pod
+	healthCache            *PodHealthCache
+	// gossipDatacenters are the datacenters of the cluster as seen by gossip
+	gossipDatacenters []string
+	// seedEndpointPods are the pods of the Endpoints of the seed service, when the operator manages them
+	seedEndpointPods map[string]bool
+	// backoffRequested is set when the reconciliation requeues to wait for the cluster to be healthy
+	backoffRequested bool
 }
 
 // CreateReconciliationContext gathers all information needed for computeReconciliationActions into a struct.
@@ -65,6 +129,7 @@ func CreateReconciliationContext(
 	rc.Scheme = scheme
 	rc.Recorder = &events.LoggingEventRecorder{EventRecorder: rec, ReqLogger: reqLogger}
 	rc.SecretWatches = secretWatches
+	rc.healthCache = clusterHealthCache
 	rc.ReqLogger = reqLogger
 	rc.Ctx = ctx
 
@@ -111,12 +176,12 @@ func CreateReconciliationContext(
 
 	log.IntoContext(ctx, rc.ReqLogger)
 
-	var err error
-	rc.NodeMgmtClient, err = httphelper.NewMgmtClient(rc.Ctx, cli, dc)
+	mgmtClient, err := httphelper.NewMgmtClient(rc.Ctx, cli, dc)
 	if err != nil {
 		rc.ReqLogger.Error(err, "failed to build NodeMgmtClient")
 		return nil, err
 	}
+	rc.NodeMgmtClient = &mgmtClient
 
 	return rc, nil
 }
@@ -7,6 +7,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/k8ssandra/cass-operator/pkg/events"
 	"github.com/k8ssandra/cass-operator/pkg/httphelper"
 	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -61,6 +63,10 @@ func (rc *ReconciliationContext) DecommissionNodes(epData httphelper.CassMetadat
 		targetSize = 0
 	}
 
+	if recResult := rc.checkScaleDownDataSize(epData, currentSize, targetSize); recResult.Completed() {
+		return recResult
+	}
+
 	if currentSize <= targetSize {
 		return result.Continue()
 	}
@@ -76,7 +82,7 @@ func (rc *ReconciliationContext) DecommissionNodes(epData httphelper.CassMetadat
 		statefulSet := rc.statefulSets[idx]
 		desiredNodeCount := int32(rackInfo.NodeCount)
 		maxReplicas := *statefulSet.Spec.Replicas
-		lastPodSuffix := stsLastPodSuffix(maxReplicas)
+		lastPodName := getStatefulSetPodNameForIdx(statefulSet, maxReplicas-1)
 
 		if maxReplicas > desiredNodeCount {
 			logger.V(1).Info("reconcile_racks::DecommissionNodes::scaleDownRack", "Rack", rackInfo.RackName, "maxReplicas", maxReplicas, "desiredNodeCount", desiredNodeCount)
@@ -110,7 +116,7 @@ func (rc *ReconciliationContext) DecommissionNodes(epData httphelper.CassMetadat
 				return result.Error(err)
 			}
 
-			err := rc.DecommissionNodeOnRack(rackInfo.RackName, epData, lastPodSuffix)
+			err := rc.DecommissionNodeOnRack(rackInfo.RackName, epData, lastPodName)
 			if err != nil {
 				return result.Error(err)
 			}
@@ -122,10 +128,68 @@ func (rc *ReconciliationContext) DecommissionNodes(epData httphelper.CassMetadat
 	return result.Continue()
 }
 
-func (rc *ReconciliationContext) DecommissionNodeOnRack(rackName string, epData httphelper.CassMetadataEndpoints, lastPodSuffix string) error {
+// checkScaleDownDataSize refuses to scale down while the load of the nodes of the Datacenter, spread over
+// the target size, would exceed the maxNodeDataSize. The check is done before each node is decommissioned,
+// and the ScaleDownBlocked condition is cleared once the scale down is allowed or no longer requested.
+func (rc *ReconciliationContext) checkScaleDownDataSize(epData httphelper.CassMetadataEndpoints, currentSize, targetSize int32) result.ReconcileResult {
+	dc := rc.Datacenter
+	maxNodeDataSize := dc.Spec.MaxNodeDataSize
+	if maxNodeDataSize == nil || currentSize <= targetSize || targetSize == 0 {
+		return rc.clearScaleDownBlocked()
+	}
+
+	podsUsedStorage, err := rc.GetUsedStorageForPods(epData)
+	if err != nil {
+		return result.Error(err)
+	}
+
+	var totalDataSize float64
+	for _, used := range podsUsedStorage {
+		totalDataSize += used
+	}
+
+	nodeDataSize := int64(totalDataSize / float64(targetSize))
+	if nodeDataSize <= maxNodeDataSize.Value() {
+		return rc.clearScaleDownBlocked()
+	}
+
+	msg := fmt.Sprintf("Not scaling down to %d nodes, each node would hold %s of data, more than the maxNodeDataSize of %s",
+		targetSize, resource.NewQuantity(nodeDataSize, resource.BinarySI), maxNodeDataSize)
+	rc.ReqLogger.Info(msg)
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	if rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterScaleDownBlocked,
+		corev1.ConditionTrue, "maxNodeDataSizeExceeded", msg)) {
+		rc.Recorder.Event(dc, corev1.EventTypeWarning, events.ScaleDownBlocked, msg)
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	return result.RequeueSoon(60)
+}
+
+func (rc *ReconciliationContext) clearScaleDownBlocked() result.ReconcileResult {
+	dc := rc.Datacenter
+	if dc.GetConditionStatus(api.DatacenterScaleDownBlocked) != corev1.ConditionTrue {
+		return result.Continue()
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	rc.setCondition(api.NewDatacenterCondition(api.DatacenterScaleDownBlocked, corev1.ConditionFalse))
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
+
+// DecommissionNodeOnRack decommissions the pod with the highest ordinal in the rack. The StatefulSet
+// is only scaled down once the node has left the ring, see CheckDecommissioningNodes.
+func (rc *ReconciliationContext) DecommissionNodeOnRack(rackName string, epData httphelper.CassMetadataEndpoints, lastPodName string) error {
 	for _, pod := range rc.dcPods {
 		podRack := pod.Labels[api.RackLabel]
-		if podRack == rackName && strings.HasSuffix(pod.Name, lastPodSuffix) {
+		if podRack == rackName && pod.Name == lastPodName {
 			mgmtApiUp := isMgmtApiRunning(pod)
 			if !mgmtApiUp {
 				return fmt.Errorf("management API is not up on node that we are trying to decommission")
@@ -235,7 +299,91 @@ func (rc *ReconciliationContext) CheckDecommissioningNodes(epData httphelper.Cas
 	return result.Continue()
 }
 
+// CheckOrphanedPVCs Deletes the PVCs of the decommissioned nodes whose ordinal is beyond the size of their
+// rack, left behind when cleanUpAfterDecommissionedPod was interrupted after marking them. The PVCs
+// without the DecommissionedAnnotation are never deleted, nor anything while a decommission is still
+// running, while the StatefulSet has not caught up with its replicas, or while the datacenter is stopped,
+// whose StatefulSets are scaled to zero but keep the data of all the nodes.
+func (rc *ReconciliationContext) CheckOrphanedPVCs() result.ReconcileResult {
+	dc := rc.Datacenter
+	if dc.Spec.Stopped ||
+		dc.GetConditionStatus(api.DatacenterStopped) == corev1.ConditionTrue ||
+		dc.GetConditionStatus(api.DatacenterResuming) == corev1.ConditionTrue ||
+		dc.GetConditionStatus(api.DatacenterScalingDown) == corev1.ConditionTrue ||
+		dc.GetConditionStatus(api.DatacenterDecommission) == corev1.ConditionTrue {
+		return result.Continue()
+	}
+
+	logger := rc.ReqLogger
+	logger.Info("reconcile_racks::CheckOrphanedPVCs")
+
+	// The size of the racks is computed from the spec, the desired rack information of a stopped
+	// datacenter has no nodes
+	rackSizes := api.SplitRacks(int(dc.Spec.Size), len(rc.desiredRackInformation))
+
+	var pvcs *corev1.PersistentVolumeClaimList
+	for idx := range rc.desiredRackInformation {
+		statefulSet := rc.statefulSets[idx]
+		if statefulSet == nil || statefulSet.Status.Replicas != *statefulSet.Spec.Replicas {
+			continue
+		}
+
+		size := int(*statefulSet.Spec.Replicas)
+		if rackSizes[idx] > size {
+			size = rackSizes[idx]
+		}
+
+		if pvcs == nil {
+			var err error
+			if pvcs, err = rc.listPVCs(); err != nil {
+				return result.Error(err)
+			}
+		}
+
+		for i := range pvcs.Items {
+			pvc := &pvcs.Items[i]
+			if pvc.DeletionTimestamp != nil || getPVCOrdinal(statefulSet, pvc) < size {
+				continue
+			}
+			if _, found := pvc.Annotations[api.DecommissionedAnnotation]; !found {
+				// Not left behind by a decommission of the operator
+				continue
+			}
+
+			if err := rc.Client.Delete(rc.Ctx, pvc); err != nil {
+				logger.Error(err, "Failed to delete orphaned PVC", "Claim Name", pvc.Name)
+				return result.Error(err)
+			}
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.DeletedPvc,
+				"Claim Name: %s", pvc.Name)
+		}
+	}
+
+	return result.Continue()
+}
+
+// getPVCOrdinal Returns the ordinal of the pod of the StatefulSet the PVC was created for from one of its
+// volumeClaimTemplates, or -1 if it was not
+func getPVCOrdinal(sts *appsv1.StatefulSet, pvc *corev1.PersistentVolumeClaim) int {
+	for _, template := range sts.Spec.VolumeClaimTemplates {
+		prefix := fmt.Sprintf("%s-%s-", template.Name, sts.Name)
+		if !strings.HasPrefix(pvc.Name, prefix) {
+			continue
+		}
+		if ordinal, err := strconv.Atoi(strings.TrimPrefix(pvc.Name, prefix)); err == nil && ordinal >= 0 {
+			return ordinal
+		}
+	}
+	return -1
+}
+
 func (rc *ReconciliationContext) cleanUpAfterDecommissionedPod(pod *corev1.Pod) result.ReconcileResult {
+	// The PVCs are marked first, so that CheckOrphanedPVCs can still delete them if their deletion below
+	// does not happen
+	if err := rc.markPodPvcsDecommissioned(pod); err != nil {
+		return result.Error(err)
+	}
+
 	rc.ReqLogger.Info("Scaling down statefulset")
 	err := rc.RemoveDecommissionedPodFromSts(pod)
 	if err != nil {
@@ -315,6 +463,35 @@ func isPodUp(pod *corev1.Pod) bool {
 	return ready
 }
 
+// markPodPvcsDecommissioned sets the DecommissionedAnnotation on the PVCs of a decommissioned pod
+func (rc *ReconciliationContext) markPodPvcsDecommissioned(pod *corev1.Pod) error {
+	for _, v := range pod.Spec.Volumes {
+		if v.PersistentVolumeClaim == nil {
+			continue
+		}
+
+		podPvc := &corev1.PersistentVolumeClaim{}
+		name := types.NamespacedName{Name: v.PersistentVolumeClaim.ClaimName, Namespace: rc.Datacenter.Namespace}
+		if err := rc.Client.Get(rc.Ctx, name, podPvc); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if _, found := podPvc.Annotations[api.DecommissionedAnnotation]; found {
+			continue
+		}
+
+		pvcPatch := client.MergeFrom(podPvc.DeepCopy())
+		metav1.SetMetaDataAnnotation(&podPvc.ObjectMeta, api.DecommissionedAnnotation, "true")
+		if err := rc.Client.Patch(rc.Ctx, podPvc, pvcPatch); err != nil {
+			rc.ReqLogger.Error(err, "Failed to mark pod PVC as decommissioned", "Claim Name", podPvc.Name)
+			return err
+		}
+	}
+	return nil
+}
+
 func (rc *ReconciliationContext) DeletePodPvcs(pod *corev1.Pod) error {
 	for _, v := range pod.Spec.Volumes {
 		if v.PersistentVolumeClaim == nil {
@@ -364,12 +541,12 @@ func (rc *ReconciliationContext) RemoveDecommissionedPodFromSts(pod *corev1.Pod)
 	}
 
 	maxReplicas := *sts.Spec.Replicas
-	lastPodSuffix := stsLastPodSuffix(maxReplicas)
-	if strings.HasSuffix(pod.Name, lastPodSuffix) {
+	lastPodName := getStatefulSetPodNameForIdx(sts, maxReplicas-1)
+	if pod.Name == lastPodName {
 		rc.ReqLogger.Info(fmt.Sprintf("UpdateRackNodeCount in STS %s to %d", sts.Name, *sts.Spec.Replicas-1))
 		return rc.UpdateRackNodeCount(sts, *sts.Spec.Replicas-1)
 	} else {
-		rc.ReqLogger.Error(fmt.Errorf("pod does not match the last pod in the STS"), "Could not find last matching pod", "PodName", pod.Name, "lastPodSuffix", lastPodSuffix)
+		rc.ReqLogger.Error(fmt.Errorf("pod does not match the last pod in the STS"), "Could not find last matching pod", "PodName", pod.Name, "lastPodName", lastPodName)
 		// Pod does not match the last pod in statefulSet
 		// This scenario should only happen if the pod
 		// has already been terminated
@@ -377,11 +554,12 @@ func (rc *ReconciliationContext) RemoveDecommissionedPodFromSts(pod *corev1.Pod)
 	}
 }
 
-func stsLastPodSuffix(maxReplicas int32) string {
-	return fmt.Sprintf("sts-%v", maxReplicas-1)
-}
-
 func (rc *ReconciliationContext) EnsurePodsCanAbsorbDecommData(decommPod *corev1.Pod, epData httphelper.CassMetadataEndpoints) error {
+	// The capacity of the ephemeral data volumes is unknown
+	if rc.Datacenter.IsEphemeralStorageEnabled() {
+		return nil
+	}
+
 	podsUsedStorage, err := rc.GetUsedStorageForPods(epData)
 	if err != nil {
 		return err
@@ -488,7 +666,7 @@ func (rc *ReconciliationContext) getClusterDatacenters(pods []*corev1.Pod) ([]st
 	// We need the result from at least one pod
 	clusterDcs := make(map[string]bool)
 	for _, pod := range pods {
-		if val, found := pod.GetAnnotations()[api.CassNodeState]; found && val == stateDecommissioning {
+		if val, found := pod.GetLabels()[api.CassNodeState]; found && val == stateDecommissioning {
 			// Do not poll a node that is decommissioning
 			continue
 		}
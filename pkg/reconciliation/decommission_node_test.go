@@ -5,6 +5,7 @@ package reconciliation
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -14,10 +15,13 @@ import (
 	"github.com/k8ssandra/cass-operator/pkg/httphelper"
 	"github.com/k8ssandra/cass-operator/pkg/internal/result"
 	"github.com/k8ssandra/cass-operator/pkg/mocks"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -60,7 +64,7 @@ func TestRetryDecommissionNode(t *testing.T) {
 		Return(resFeatureSet, nil).
 		Once()
 
-	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+	rc.NodeMgmtClient = &httphelper.NodeMgmtClient{
 		Client:   mockHttpClient,
 		Log:      rc.ReqLogger,
 		Protocol: "http",
@@ -165,3 +169,236 @@ func (s *statusMock) Patch(ctx context.Context, obj client.Object, patch client.
 	s.called = s.called + 1
 	return nil
 }
+
+func TestDecommissionNodeOnRackMatchesPodName(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rackLabels := map[string]string{api.RackLabel: "rack1"}
+	rc.dcPods = []*v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "cluster1-dc1-rack1-sts-0",
+				Labels: rackLabels,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "cluster1-dc1-rack1-sts-10",
+				Labels: rackLabels,
+			},
+		},
+	}
+
+	err := rc.DecommissionNodeOnRack("rack1", httphelper.CassMetadataEndpoints{}, "cluster1-dc1-rack1-sts-1")
+	if err == nil || !strings.Contains(err.Error(), "could not find pod") {
+		t.Fatalf("expected pod lookup to fail, got %v", err)
+	}
+
+	// The pod exists, but the management API is not up, so the lookup succeeded
+	err = rc.DecommissionNodeOnRack("rack1", httphelper.CassMetadataEndpoints{}, "cluster1-dc1-rack1-sts-10")
+	if err == nil || !strings.Contains(err.Error(), "management API is not up") {
+		t.Fatalf("expected management API error, got %v", err)
+	}
+}
+
+func TestGetClusterDatacentersSkipsDecommissioningPods(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	var pods []*v1.Pod
+	for _, name := range []string{"pod-0", "pod-1"} {
+		pods = append(pods, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{api.CassNodeState: stateStarted},
+			},
+			Status: v1.PodStatus{
+				ContainerStatuses: []v1.ContainerStatus{{Name: "cassandra", Ready: true}},
+			},
+		})
+	}
+	pods[0].Labels[api.CassNodeState] = stateDecommissioning
+
+	mgmtClient := &fakeNodeMgmtClient{
+		endpoints: httphelper.CassMetadataEndpoints{
+			Entity: []httphelper.EndpointState{
+				{Datacenter: "dc1", IsAlive: "true", Status: "NORMAL"},
+				{Datacenter: "dc2", IsAlive: "true", Status: "NORMAL"},
+			},
+		},
+	}
+	rc.NodeMgmtClient = mgmtClient
+
+	dcs, err := rc.getClusterDatacenters(pods)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"dc1", "dc2"}, dcs)
+	assert.Equal(t, []string{"pod-1"}, mgmtClient.metadataPods)
+}
+
+func TestCheckOrphanedPVCs(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	sts, err := newStatefulSetForCassandraDatacenter(nil, "default", rc.Datacenter, 2)
+	assert.NoError(t, err)
+	sts.Status.Replicas = 2
+	rc.statefulSets = []*appsv1.StatefulSet{sts}
+	rc.desiredRackInformation = []*RackInformation{{RackName: "default", NodeCount: 2}}
+
+	// The PVC of the last ordinal was not left behind by a decommission of the operator, it may hold the
+	// data of a node the user wants to keep
+	for _, ordinal := range []string{"0", "1", "2", "3", "4"} {
+		pvc := &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      PvcName + "-" + sts.Name + "-" + ordinal,
+				Namespace: rc.Datacenter.Namespace,
+				Labels:    rc.Datacenter.GetRackLabels("default"),
+			},
+		}
+		if ordinal != "4" {
+			pvc.Annotations = map[string]string{api.DecommissionedAnnotation: "true"}
+		}
+		assert.NoError(t, rc.Client.Create(rc.Ctx, pvc))
+	}
+
+	// Nothing is deleted while scaling down
+	rc.Datacenter.SetCondition(*api.NewDatacenterCondition(api.DatacenterScalingDown, v1.ConditionTrue))
+	assert.Equal(t, result.Continue(), rc.CheckOrphanedPVCs())
+	pvcs, err := rc.listPVCs()
+	assert.NoError(t, err)
+	assert.Len(t, pvcs.Items, 5)
+
+	// The decommissioned PVCs beyond the size of the rack are deleted once the decommission completed
+	rc.Datacenter.SetCondition(*api.NewDatacenterCondition(api.DatacenterScalingDown, v1.ConditionFalse))
+	assert.Equal(t, result.Continue(), rc.CheckOrphanedPVCs())
+	pvcs, err = rc.listPVCs()
+	assert.NoError(t, err)
+	var names []string
+	for _, pvc := range pvcs.Items {
+		names = append(names, pvc.Name)
+	}
+	assert.ElementsMatch(t, []string{PvcName + "-" + sts.Name + "-0", PvcName + "-" + sts.Name + "-1", PvcName + "-" + sts.Name + "-4"}, names)
+}
+
+func TestCheckOrphanedPVCsStoppedDatacenter(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Size = 2
+	rc.Datacenter.Spec.Stopped = true
+
+	// A stopped datacenter has its StatefulSets scaled to zero
+	sts, err := newStatefulSetForCassandraDatacenter(nil, "default", rc.Datacenter, 0)
+	assert.NoError(t, err)
+	rc.statefulSets = []*appsv1.StatefulSet{sts}
+	rc.desiredRackInformation = []*RackInformation{{RackName: "default", NodeCount: 0}}
+
+	for _, ordinal := range []string{"0", "1"} {
+		pvc := &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        PvcName + "-" + sts.Name + "-" + ordinal,
+				Namespace:   rc.Datacenter.Namespace,
+				Labels:      rc.Datacenter.GetRackLabels("default"),
+				Annotations: map[string]string{api.DecommissionedAnnotation: "true"},
+			},
+		}
+		assert.NoError(t, rc.Client.Create(rc.Ctx, pvc))
+	}
+
+	assert.Equal(t, result.Continue(), rc.CheckOrphanedPVCs())
+	pvcs, err := rc.listPVCs()
+	assert.NoError(t, err)
+	assert.Len(t, pvcs.Items, 2)
+
+	// While resuming, the size of the racks still comes from the spec
+	rc.Datacenter.Spec.Stopped = false
+	rc.Datacenter.SetCondition(*api.NewDatacenterCondition(api.DatacenterResuming, v1.ConditionTrue))
+	assert.Equal(t, result.Continue(), rc.CheckOrphanedPVCs())
+	pvcs, err = rc.listPVCs()
+	assert.NoError(t, err)
+	assert.Len(t, pvcs.Items, 2)
+
+	rc.Datacenter.SetCondition(*api.NewDatacenterCondition(api.DatacenterResuming, v1.ConditionFalse))
+	assert.Equal(t, result.Continue(), rc.CheckOrphanedPVCs())
+	pvcs, err = rc.listPVCs()
+	assert.NoError(t, err)
+	assert.Len(t, pvcs.Items, 2)
+}
+
+func TestCheckScaleDownDataSize(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.MaxNodeDataSize = resource.NewQuantity(8<<30, resource.BinarySI)
+	assert.NoError(t, rc.Client.Update(rc.Ctx, rc.Datacenter))
+
+	epData := httphelper.CassMetadataEndpoints{}
+	for i, podIP := range []string{"192.168.101.11", "192.168.101.12", "192.168.101.13"} {
+		rc.dcPods = append(rc.dcPods, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i)},
+			Status:     v1.PodStatus{PodIP: podIP},
+		})
+		epData.Entity = append(epData.Entity, httphelper.EndpointState{
+			RpcAddress: podIP,
+			Load:       fmt.Sprintf("%d", 6<<30),
+		})
+	}
+
+	// 18Gi over 2 nodes exceeds the cap
+	assert.Equal(t, result.RequeueSoon(60), rc.checkScaleDownDataSize(epData, 3, 2))
+	cond, found := rc.Datacenter.GetCondition(api.DatacenterScaleDownBlocked)
+	assert.True(t, found)
+	assert.Equal(t, v1.ConditionTrue, cond.Status)
+	assert.Equal(t, "maxNodeDataSizeExceeded", cond.Reason)
+
+	// The condition is cleared once the size is restored
+	assert.Equal(t, result.Continue(), rc.checkScaleDownDataSize(epData, 3, 3))
+	assert.Equal(t, v1.ConditionFalse, rc.Datacenter.GetConditionStatus(api.DatacenterScaleDownBlocked))
+
+	// or the cap raised
+	assert.Equal(t, result.RequeueSoon(60), rc.checkScaleDownDataSize(epData, 3, 2))
+	rc.Datacenter.Spec.MaxNodeDataSize = resource.NewQuantity(10<<30, resource.BinarySI)
+	assert.NoError(t, rc.Client.Update(rc.Ctx, rc.Datacenter))
+	assert.Equal(t, result.Continue(), rc.checkScaleDownDataSize(epData, 3, 2))
+	assert.Equal(t, v1.ConditionFalse, rc.Datacenter.GetConditionStatus(api.DatacenterScaleDownBlocked))
+
+	// The decommission of the Datacenter is never blocked
+	rc.Datacenter.Spec.MaxNodeDataSize = resource.NewQuantity(1<<10, resource.BinarySI)
+	assert.Equal(t, result.Continue(), rc.checkScaleDownDataSize(epData, 3, 0))
+}
+
+func TestMarkPodPvcsDecommissioned(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "server-data-pod-1", Namespace: rc.Datacenter.Namespace},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pvc))
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: rc.Datacenter.Namespace},
+		Spec: v1.PodSpec{
+			Volumes: []v1.Volume{
+				{
+					Name: "server-data",
+					VolumeSource: v1.VolumeSource{
+						PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "server-data-pod-1"},
+					},
+				},
+				{
+					// Already deleted
+					Name: "other",
+					VolumeSource: v1.VolumeSource{
+						PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "other-pod-1"},
+					},
+				},
+			},
+		},
+	}
+	assert.NoError(t, rc.markPodPvcsDecommissioned(pod))
+
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: "server-data-pod-1", Namespace: rc.Datacenter.Namespace}, pvc))
+	assert.Equal(t, "true", pvc.Annotations[api.DecommissionedAnnotation])
+}
@@ -1,9 +1,23 @@
 package reconciliation
 
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
 var (
 	// Provides reasonable defaults for the logger container.
 	DefaultsLoggerContainer = buildResourceRequirements(100, 64)
 
 	// Provides reasonable defaults for the configuration container.
 	DefaultsConfigInitContainer = buildResourceRequirements(1000, 256)
+
+	// Provides the minimum requests of the server container when DSE workloads are enabled and the
+	// resources are not set. Analytics, Search and Graph need a lot more memory than Cassandra alone.
+	DefaultsDseWorkloadsContainer = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("2"),
+			corev1.ResourceMemory: resource.MustParse("8Gi"),
+		},
+	}
 )
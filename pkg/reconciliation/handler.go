@@ -147,6 +147,10 @@ func (rc *ReconciliationContext) CalculateReconciliationActions() (reconcile.Res
 		return result.Output()
 	}
 
+	if result := rc.CheckSeedExport(); result.Completed() {
+		return result.Output()
+	}
+
 	if utils.IsPSPEnabled() {
 		if result := psp.CheckNetworkPolicies(rc); result.Completed() {
 			return result.Output()
@@ -159,6 +163,13 @@ func (rc *ReconciliationContext) CalculateReconciliationActions() (reconcile.Res
 
 	result, err := rc.ReconcileAllRacks()
 
+	if statusErr := rc.UpdateRackStatuses(); statusErr != nil {
+		rc.ReqLogger.Error(statusErr, "Failed to update the rack statuses")
+		if err == nil {
+			return reconcile.Result{}, statusErr
+		}
+	}
+
 	if err == nil {
 		// Update PSP status
 		// Always sync the datacenter status with the PSP health status
@@ -216,6 +227,11 @@ func (rc *ReconciliationContext) IsValid(dc *api.CassandraDatacenter) error {
 		return errs[0]
 	}
 
+	// The ephemeral storage mode has no PersistentVolumeClaim of the data volume
+	if dc.IsEphemeralStorageEnabled() {
+		return nil
+	}
+
 	claim := dc.Spec.StorageConfig.CassandraDataVolumeClaimSpec
 	if claim == nil {
 		err := fmt.Errorf("storageConfig.cassandraDataVolumeClaimSpec is required")
@@ -94,14 +94,19 @@ func TestProcessDeletion_FailedDelete(t *testing.T) {
 
 	k8sMockClientList(mockClient, nil).
 		Run(func(args mock.Arguments) {
-			arg := args.Get(1).(*v1.PersistentVolumeClaimList)
-			arg.Items = []v1.PersistentVolumeClaim{{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "pvc-1",
-				},
-			}}
-		})
-
+			// The pods are listed to be drained first, there are none here
+			if arg, ok := args.Get(1).(*v1.PersistentVolumeClaimList); ok {
+				arg.Items = []v1.PersistentVolumeClaim{{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "pvc-1",
+					},
+				}}
+			}
+		}).
+		Twice()
+
+	// The PodDisruptionBudget is deleted, not the PVC
+	k8sMockClientDelete(mockClient, nil).Once()
 	k8sMockClientDelete(mockClient, fmt.Errorf(""))
 	// k8sMockClientUpdate(mockClient, nil).Times(0)
 
@@ -132,15 +137,18 @@ func TestProcessDeletion(t *testing.T) {
 
 	k8sMockClientList(mockClient, nil).
 		Run(func(args mock.Arguments) {
-			arg := args.Get(1).(*v1.PersistentVolumeClaimList)
-			arg.Items = []v1.PersistentVolumeClaim{{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "pvc-1",
-				},
-			}}
-		})
-
-	k8sMockClientDelete(mockClient, nil)
+			// The pods are listed to be drained first, there are none here
+			if arg, ok := args.Get(1).(*v1.PersistentVolumeClaimList); ok {
+				arg.Items = []v1.PersistentVolumeClaim{{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "pvc-1",
+					},
+				}}
+			}
+		}).
+		Twice()
+
+	k8sMockClientDelete(mockClient, nil).Twice()  // The PodDisruptionBudget and the PVC
 	k8sMockClientUpdate(mockClient, nil).Times(1) // Remove finalizer
 
 	emptySecretWatcher(rc)
@@ -0,0 +1,104 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// podHealthTTL is how long a successful cluster health probe of a pod is reused
+const podHealthTTL = 10 * time.Second
+
+// clusterHealthCache is shared by the reconciliation contexts of all the datacenters
+var clusterHealthCache = NewPodHealthCache(podHealthTTL)
+
+// PodHealthCache remembers the pods that recently passed the cluster health probe, so
+// that scaling a large datacenter does not probe every pod again on each reconcile pass.
+// Entries are keyed by the pod UID and hold the state of all the pods of the cluster when
+// the probe passed. The probe answers for the whole cluster, so any change to one of its
+// pods, such as another node going down, invalidates every entry. Failed probes are never
+// cached, and a nil cache caches nothing.
+type PodHealthCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[types.UID]podHealthEntry
+}
+
+type podHealthEntry struct {
+	clusterState     string
+	consistencyLevel string
+	rfPerDc          int
+	expires          time.Time
+}
+
+func NewPodHealthCache(ttl time.Duration) *PodHealthCache {
+	return &PodHealthCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[types.UID]podHealthEntry{},
+	}
+}
+
+// ClusterPodsState returns the UID, resource version and readiness of the pods of the cluster,
+// which must be unchanged for a cached probe to be reused
+func ClusterPodsState(pods []*corev1.Pod) string {
+	states := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		states = append(states, fmt.Sprintf("%s/%s/%t", pod.UID, pod.ResourceVersion, isServerReady(pod)))
+	}
+	sort.Strings(states)
+	return strings.Join(states, ",")
+}
+
+// IsHealthy returns true if the pod passed the probe with the same consistency level and
+// replication factor less than ttl ago, while the pods of the cluster were in the same state
+func (c *PodHealthCache) IsHealthy(pod *corev1.Pod, clusterState string, consistencyLevel string, rfPerDc int) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, found := c.entries[pod.UID]
+	if !found {
+		return false
+	}
+	if c.now().After(entry.expires) || entry.clusterState != clusterState {
+		delete(c.entries, pod.UID)
+		return false
+	}
+	return entry.consistencyLevel == consistencyLevel && entry.rfPerDc == rfPerDc
+}
+
+// SetHealthy records that the pod passed the probe while the pods of the cluster were in
+// the given state, and drops the expired entries of the pods that were not probed since
+func (c *PodHealthCache) SetHealthy(pod *corev1.Pod, clusterState string, consistencyLevel string, rfPerDc int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for uid, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, uid)
+		}
+	}
+
+	c.entries[pod.UID] = podHealthEntry{
+		clusterState:     clusterState,
+		consistencyLevel: consistencyLevel,
+		rfPerDc:          rfPerDc,
+		expires:          now.Add(c.ttl),
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+)
+
+func TestPodHealthCache(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	cache := NewPodHealthCache(10 * time.Second)
+	cache.now = func() time.Time { return now }
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-0", UID: "uid-0", ResourceVersion: "1"}}
+	otherPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-1", UID: "uid-1", ResourceVersion: "1"}}
+	state := ClusterPodsState([]*corev1.Pod{pod, otherPod})
+
+	assert.False(cache.IsHealthy(pod, state, "LOCAL_QUORUM", 3))
+	cache.SetHealthy(pod, state, "LOCAL_QUORUM", 3)
+	assert.True(cache.IsHealthy(pod, state, "LOCAL_QUORUM", 3))
+
+	// A different probe is not answered from the cache
+	assert.False(cache.IsHealthy(pod, state, "LOCAL_QUORUM", 1))
+	assert.False(cache.IsHealthy(pod, state, "ALL", 3))
+
+	// Any change to a pod of the cluster invalidates the entry, not only a change to the probed pod
+	updatedPod := otherPod.DeepCopy()
+	updatedPod.ResourceVersion = "2"
+	assert.False(cache.IsHealthy(pod, ClusterPodsState([]*corev1.Pod{pod, updatedPod}), "LOCAL_QUORUM", 3))
+	assert.False(cache.IsHealthy(pod, state, "LOCAL_QUORUM", 3))
+
+	// So does a pod leaving the cluster
+	cache.SetHealthy(pod, state, "LOCAL_QUORUM", 3)
+	assert.False(cache.IsHealthy(pod, ClusterPodsState([]*corev1.Pod{pod}), "LOCAL_QUORUM", 3))
+
+	// Entries expire
+	cache.SetHealthy(pod, state, "LOCAL_QUORUM", 3)
+	now = now.Add(11 * time.Second)
+	assert.False(cache.IsHealthy(pod, state, "LOCAL_QUORUM", 3))
+
+	// A nil cache caches nothing
+	var nilCache *PodHealthCache
+	nilCache.SetHealthy(pod, state, "LOCAL_QUORUM", 3)
+	assert.False(nilCache.IsHealthy(pod, state, "LOCAL_QUORUM", 3))
+}
+
+func TestClusterPodsState(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-0", UID: "uid-0", ResourceVersion: "1"}}
+	otherPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-1", UID: "uid-1", ResourceVersion: "1"}}
+	state := ClusterPodsState([]*corev1.Pod{pod, otherPod})
+
+	assert.Equal(t, state, ClusterPodsState([]*corev1.Pod{otherPod, pod}))
+
+	readyPod := otherPod.DeepCopy()
+	readyPod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "cassandra", Ready: true}}
+	assert.NotEqual(t, state, ClusterPodsState([]*corev1.Pod{pod, readyPod}))
+}
+
+func TestIsClusterHealthy_Cached(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.healthCache = NewPodHealthCache(time.Minute)
+
+	rc.clusterPods = nil
+	for _, name := range []string{"pod-0", "pod-1"} {
+		pod := makeReloadTestPod()
+		pod.Name = name
+		pod.UID = types.UID("uid-" + name)
+		pod.Labels[api.CassNodeState] = stateStarted
+		rc.clusterPods = append(rc.clusterPods, pod)
+	}
+
+	mgmtClient := &fakeNodeMgmtClient{unhealthyPods: map[string]bool{"pod-1": true}}
+	rc.NodeMgmtClient = mgmtClient
+	assert.False(t, rc.isClusterHealthy())
+	assert.ElementsMatch(t, []string{"pod-0", "pod-1"}, mgmtClient.probedPods)
+
+	// Only the pod that failed is probed again
+	mgmtClient = &fakeNodeMgmtClient{}
+	rc.NodeMgmtClient = mgmtClient
+	assert.True(t, rc.isClusterHealthy())
+	assert.Equal(t, []string{"pod-1"}, mgmtClient.probedPods)
+}
+
+func TestIsClusterHealthy_OtherPodChanged(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.healthCache = NewPodHealthCache(time.Minute)
+
+	rc.clusterPods = nil
+	for _, name := range []string{"pod-0", "pod-1"} {
+		pod := makeReloadTestPod()
+		pod.Name = name
+		pod.UID = types.UID("uid-" + name)
+		pod.ResourceVersion = "1"
+		pod.Labels[api.CassNodeState] = stateStarted
+		rc.clusterPods = append(rc.clusterPods, pod)
+	}
+
+	mgmtClient := &fakeNodeMgmtClient{}
+	rc.NodeMgmtClient = mgmtClient
+	assert.True(t, rc.isClusterHealthy())
+
+	// Another pod of the cluster going down is not answered from the cache
+	downPod := rc.clusterPods[1].DeepCopy()
+	downPod.ResourceVersion = "2"
+	downPod.Labels[api.CassNodeState] = stateStarting
+	rc.clusterPods = []*corev1.Pod{rc.clusterPods[0], downPod}
+
+	mgmtClient = &fakeNodeMgmtClient{unhealthyPods: map[string]bool{"pod-0": true}}
+	rc.NodeMgmtClient = mgmtClient
+	assert.False(t, rc.isClusterHealthy())
+	assert.Equal(t, []string{"pod-0"}, mgmtClient.probedPods)
+}
@@ -0,0 +1,288 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+)
+
+const (
+	broadcastAddressesVolumeName = "broadcast-addresses"
+	broadcastAddressesPath       = "/broadcast-addresses"
+	broadcastAddressContainer    = "server-broadcast-address"
+
+	// rpcAddressKeySuffix is appended to the UID of the pod for its broadcast_rpc_address, when it
+	// differs from its broadcast_address
+	rpcAddressKeySuffix = ".rpc"
+)
+
+// broadcastAddressScript waits for the operator to publish the addresses of the pod, and makes the node
+// broadcast them. They are keyed by the UID of the pod, a pod recreated with the same name does not start
+// with the addresses of the previous one.
+const broadcastAddressScript = `until [ -s ` + broadcastAddressesPath + `/${POD_UID} ]; do
+  echo "waiting for the broadcast address of ${POD_NAME}"
+  sleep 5
+done
+address=$(cat ` + broadcastAddressesPath + `/${POD_UID})
+rpc_address=${address}
+if [ -s ` + broadcastAddressesPath + `/${POD_UID}` + rpcAddressKeySuffix + ` ]; then
+  rpc_address=$(cat ` + broadcastAddressesPath + `/${POD_UID}` + rpcAddressKeySuffix + `)
+fi
+sed -i -e '/^broadcast_address:/d' -e '/^broadcast_rpc_address:/d' /config/cassandra.yaml
+printf 'broadcast_address: %s\nbroadcast_rpc_address: %s\n' "${address}" "${rpc_address}" >> /config/cassandra.yaml
+`
+
+// broadcastTemplateData is the data the templates of the broadcastTemplate are rendered with
+type broadcastTemplateData struct {
+	PodName         string
+	PodIP           string
+	HostIP          string
+	NodeName        string
+	NodeLabels      map[string]string
+	NodeAnnotations map[string]string
+	RackName        string
+	DatacenterName  string
+	ClusterName     string
+	Namespace       string
+}
+
+// CheckBroadcastAddresses publishes the addresses the nodes broadcast in the broadcast addresses ConfigMap
+// read by the pods before they start: the addresses of the Services of a LoadBalancer podExposure, or the
+// addresses rendered from the broadcastTemplate for each scheduled pod, by pod UID. The pods are annotated with the IP
+// they broadcast to the clients, which gossip reports for them. A DNS name is only resolved when the address
+// of the pod changes. The ConfigMap is deleted when the addresses are no longer published.
+func (rc *ReconciliationContext) CheckBroadcastAddresses() result.ReconcileResult {
+	dc := rc.Datacenter
+	if !dc.IsBroadcastAddressPublished() {
+		return rc.deleteBroadcastAddressesConfigMap()
+	}
+
+	rc.ReqLogger.Info("reconcile_broadcast_addresses::CheckBroadcastAddresses")
+
+	configMap, exists, err := rc.getDatacenterConfigMap(dc.GetBroadcastAddressesConfigMapName())
+	if err != nil {
+		return result.Error(err)
+	}
+	published := configMap.Data
+
+	var addresses map[string]string
+	if dc.IsPodExposureEnabled(api.PodExposureLoadBalancer) {
+		addresses, err = rc.getPodServiceAddresses()
+	} else {
+		addresses, err = rc.renderBroadcastAddresses(published)
+	}
+	if err != nil {
+		return result.Error(err)
+	}
+
+	if err := rc.applyBroadcastAddressesConfigMap(configMap, exists, addresses); err != nil {
+		return result.Error(err)
+	}
+
+	for _, pod := range rc.dcPods {
+		key := string(pod.UID) + rpcAddressKeySuffix
+		address, found := addresses[key]
+		if !found {
+			key = string(pod.UID)
+			address, found = addresses[key]
+		}
+		if !found || (pod.Annotations[api.BroadcastAddressAnnotation] != "" && published[key] == address) {
+			continue
+		}
+		ip := resolveBroadcastAddress(address)
+		if ip == "" || pod.Annotations[api.BroadcastAddressAnnotation] == ip {
+			continue
+		}
+		patch := client.MergeFrom(pod.DeepCopy())
+		metav1.SetMetaDataAnnotation(&pod.ObjectMeta, api.BroadcastAddressAnnotation, ip)
+		if err := rc.Client.Patch(rc.Ctx, pod, patch); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	return result.Continue()
+}
+
+// renderBroadcastAddresses Returns the addresses rendered from the broadcastTemplate for the pods scheduled on
+// a worker, by pod UID, and by pod UID with the rpcAddressKeySuffix for their broadcast_rpc_address. A
+// template which can not be rendered for a pod falls back to the IP the pod would broadcast without the
+// template, its HostIP or its PodIP, with a warning when the published address changes.
+func (rc *ReconciliationContext) renderBroadcastAddresses(published map[string]string) (map[string]string, error) {
+	dc := rc.Datacenter
+	broadcastTemplate := dc.Spec.Networking.BroadcastTemplate
+	addressTemplate, err := template.New("address").Parse(broadcastTemplate.Address)
+	if err != nil {
+		return nil, err
+	}
+	var rpcAddressTemplate *template.Template
+	if broadcastTemplate.RPCAddress != "" {
+		if rpcAddressTemplate, err = template.New("rpcAddress").Parse(broadcastTemplate.RPCAddress); err != nil {
+			return nil, err
+		}
+	}
+
+	addresses := map[string]string{}
+	for _, pod := range rc.dcPods {
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		node := &corev1.Node{}
+		if err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+			return nil, err
+		}
+		data := broadcastTemplateData{
+			PodName:         pod.Name,
+			PodIP:           pod.Status.PodIP,
+			HostIP:          pod.Status.HostIP,
+			NodeName:        node.Name,
+			NodeLabels:      node.Labels,
+			NodeAnnotations: node.Annotations,
+			RackName:        pod.Labels[api.RackLabel],
+			DatacenterName:  dc.DatacenterName(),
+			ClusterName:     dc.Spec.ClusterName,
+			Namespace:       pod.Namespace,
+		}
+		fallback := pod.Status.PodIP
+		if dc.IsHostIPBroadcastEnabled() {
+			fallback = pod.Status.HostIP
+		}
+
+		renderAddress := func(tmpl *template.Template, key string) {
+			address, err := renderBroadcastTemplate(tmpl, data)
+			if err != nil {
+				address = fallback
+				if address != "" && published[key] != address {
+					rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.InvalidDatacenterSpec,
+						"Could not render the broadcast %s of pod %s, falling back to %s: %v", tmpl.Name(), pod.Name, address, err)
+				}
+			}
+			if address != "" {
+				addresses[key] = address
+			}
+		}
+
+		renderAddress(addressTemplate, string(pod.UID))
+		if rpcAddressTemplate != nil {
+			renderAddress(rpcAddressTemplate, string(pod.UID)+rpcAddressKeySuffix)
+		}
+	}
+	return addresses, nil
+}
+
+func renderBroadcastTemplate(tmpl *template.Template, data broadcastTemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	address := strings.TrimSpace(buf.String())
+	if address == "" {
+		return "", fmt.Errorf("the template %s rendered an empty address", tmpl.Name())
+	}
+	return address, nil
+}
+
+// resolveBroadcastAddress Returns the IP of the address, resolving a DNS name. An empty string is returned
+// if the name does not resolve.
+func resolveBroadcastAddress(address string) string {
+	if ip := net.ParseIP(address); ip != nil {
+		return address
+	}
+	ips, err := resolveAddress(address)
+	if err != nil || len(ips) == 0 {
+		return ""
+	}
+	return ips[0]
+}
+
+func (rc *ReconciliationContext) applyBroadcastAddressesConfigMap(configMap *corev1.ConfigMap, exists bool, addresses map[string]string) error {
+	dc := rc.Datacenter
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddOperatorLabels(labels, dc)
+
+	if exists && mapContains(configMap.Labels, labels) && mapContains(configMap.Data, addresses) && len(configMap.Data) == len(addresses) {
+		return nil
+	}
+
+	configMap.Labels = utils.MergeMap(map[string]string{}, configMap.Labels, labels)
+	configMap.Data = addresses
+
+	if exists {
+		rc.ReqLogger.Info("updating broadcast addresses config map", "ConfigMap", configMap.Name)
+		return rc.Client.Update(rc.Ctx, configMap)
+	}
+
+	rc.ReqLogger.Info("creating broadcast addresses config map", "ConfigMap", configMap.Name)
+	if err := rc.Client.Create(rc.Ctx, configMap); err != nil {
+		return err
+	}
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource, "Created config map %s", configMap.Name)
+	return nil
+}
+
+func (rc *ReconciliationContext) deleteBroadcastAddressesConfigMap() result.ReconcileResult {
+	dc := rc.Datacenter
+	configMap := &corev1.ConfigMap{}
+	key := types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetBroadcastAddressesConfigMapName()}
+	if err := rc.Client.Get(rc.Ctx, key, configMap); errors.IsNotFound(err) {
+		return result.Continue()
+	} else if err != nil {
+		return result.Error(err)
+	}
+
+	rc.ReqLogger.Info("deleting broadcast addresses config map", "ConfigMap", configMap.Name)
+	if err := rc.Client.Delete(rc.Ctx, configMap); err != nil && !errors.IsNotFound(err) {
+		return result.Error(err)
+	}
+	return result.Continue()
+}
+
+func getBroadcastAddressesVolume(dc *api.CassandraDatacenter) corev1.Volume {
+	optional := true
+	return corev1.Volume{
+		Name: broadcastAddressesVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: dc.GetBroadcastAddressesConfigMapName()},
+				// The init container waits for the address of the pod
+				Optional: &optional,
+			},
+		},
+	}
+}
+
+// newBroadcastAddressInitContainer The container runs after the config builder, in its image, and renders
+// the addresses published for the pod in the configuration
+func newBroadcastAddressInitContainer(configBuilder *corev1.Container) corev1.Container {
+	return corev1.Container{
+		Name:            broadcastAddressContainer,
+		Image:           configBuilder.Image,
+		ImagePullPolicy: configBuilder.ImagePullPolicy,
+		Command:         []string{"/bin/sh", "-c", broadcastAddressScript},
+		Env: []corev1.EnvVar{
+			{Name: "POD_NAME", ValueFrom: selectorFromFieldPath("metadata.name")},
+			{Name: "POD_UID", ValueFrom: selectorFromFieldPath("metadata.uid")},
+		},
+		Resources: configBuilder.Resources,
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "server-config", MountPath: "/config"},
+			{Name: broadcastAddressesVolumeName, MountPath: broadcastAddressesPath, ReadOnly: true},
+		},
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+)
+
+func TestCheckBroadcastAddresses_template(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Networking = &api.NetworkingConfig{
+		BroadcastTemplate: &api.BroadcastTemplateConfig{
+			Address:    `{{ index .NodeLabels "example.com/public-ip" }}`,
+			RPCAddress: `{{ .HostIP }}`,
+		},
+	}
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node-1",
+			Labels: map[string]string{"example.com/public-ip": "203.0.113.10"},
+		},
+	}
+	scheduled := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: dc.Namespace, UID: "uid-0", Labels: dc.GetRackLabels("default")},
+		Spec:       corev1.PodSpec{NodeName: node.Name},
+		Status:     corev1.PodStatus{HostIP: "10.0.0.1", PodIP: "10.1.0.1"},
+	}
+	pending := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: dc.Namespace, UID: "uid-1", Labels: dc.GetRackLabels("default")},
+	}
+	rc.dcPods = []*corev1.Pod{scheduled, pending}
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(dc, node, scheduled, pending).Build()
+
+	recResult := rc.CheckBroadcastAddresses()
+	assert.False(t, recResult.Completed())
+
+	configMap := &corev1.ConfigMap{}
+	configMapKey := types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetBroadcastAddressesConfigMapName()}
+	require.NoError(t, rc.Client.Get(rc.Ctx, configMapKey, configMap))
+	assert.Equal(t, map[string]string{"uid-0": "203.0.113.10", "uid-0.rpc": "10.0.0.1"}, configMap.Data)
+	assert.Equal(t, "10.0.0.1", scheduled.Annotations[api.BroadcastAddressAnnotation])
+	assert.NotContains(t, pending.Annotations, api.BroadcastAddressAnnotation)
+
+	// The pods start with the address they would broadcast without the template if the label is missing,
+	// which is only reported once
+	fakeRecorder := record.NewFakeRecorder(10)
+	rc.Recorder = fakeRecorder
+	delete(node.Labels, "example.com/public-ip")
+	require.NoError(t, rc.Client.Update(rc.Ctx, node))
+	for i := 0; i < 2; i++ {
+		recResult = rc.CheckBroadcastAddresses()
+		assert.False(t, recResult.Completed())
+	}
+	require.NoError(t, rc.Client.Get(rc.Ctx, configMapKey, configMap))
+	assert.Equal(t, map[string]string{"uid-0": "10.1.0.1", "uid-0.rpc": "10.0.0.1"}, configMap.Data)
+	assert.Equal(t, 1, len(fakeRecorder.Events))
+
+	// A pod recreated with the same name on another worker does not get the addresses of the previous one
+	rescheduled := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: dc.Namespace, UID: "uid-2", Labels: dc.GetRackLabels("default")},
+	}
+	require.NoError(t, rc.Client.Delete(rc.Ctx, scheduled))
+	require.NoError(t, rc.Client.Create(rc.Ctx, rescheduled))
+	rc.dcPods = []*corev1.Pod{rescheduled, pending}
+	recResult = rc.CheckBroadcastAddresses()
+	assert.False(t, recResult.Completed())
+	require.NoError(t, rc.Client.Get(rc.Ctx, configMapKey, configMap))
+	assert.Empty(t, configMap.Data)
+}
+
+func TestBuildPodTemplateSpec_broadcastTemplate(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "test",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+			Networking: &api.NetworkingConfig{
+				BroadcastTemplate: &api.BroadcastTemplateConfig{Address: "{{ .PodName }}.cassandra.example.com"},
+			},
+		},
+	}
+
+	spec, err := buildPodTemplateSpec(dc, map[string]string{}, "rack1")
+	require.NoError(t, err)
+	require.Len(t, spec.Spec.InitContainers, 2)
+	assert.Equal(t, broadcastAddressContainer, spec.Spec.InitContainers[1].Name)
+	assert.Contains(t, spec.Spec.Volumes, getBroadcastAddressesVolume(dc))
+}
@@ -0,0 +1,201 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+)
+
+const keystorePasswordKey = "password"
+
+var certificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+// CheckCertificates When CertManager is set, creates the Certificate resources of the TLS material of
+// the datacenter and waits for cert-manager to issue their secrets. The keystores of the nodes are then
+// prepared from the secrets by CheckInternodeKeystores and CheckClientKeystore. The hash of the issued
+// certificates is copied to the pods, so that they restart when cert-manager renews them.
+func (rc *ReconciliationContext) CheckCertificates() result.ReconcileResult {
+	dc := rc.Datacenter
+	if dc.Spec.CertManager == nil {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_cert_manager::CheckCertificates")
+
+	installed, err := rc.isKindInstalled(certificateGVK)
+	if err != nil {
+		return result.Error(err)
+	} else if !installed {
+		err := fmt.Errorf("certManager is set but cert-manager is not installed")
+		rc.Recorder.Event(dc, corev1.EventTypeWarning, events.InvalidDatacenterSpec, err.Error())
+		return result.Error(err)
+	}
+
+	// cert-manager also stores the certificates in PKCS12 keystores, protected by this password
+	passwordName := types.NamespacedName{Namespace: dc.Namespace, Name: getKeystorePasswordSecretName(dc)}
+	if _, err := rc.retrieveSecret(passwordName); errors.IsNotFound(err) {
+		data := map[string][]byte{keystorePasswordKey: []byte(dc.GetKeystorePassword())}
+		if err := rc.applyOperatorSecret(passwordName.Name, data, nil); err != nil {
+			return result.Error(err)
+		}
+	} else if err != nil {
+		return result.Error(err)
+	}
+
+	issued := true
+	var hashes strings.Builder
+	for _, certificate := range newCertificatesForCassandraDatacenter(dc) {
+		if recResult := rc.reconcileOwnedObject(certificate); recResult.Completed() {
+			return recResult
+		}
+		secret, err := rc.retrieveSecret(types.NamespacedName{Namespace: dc.Namespace, Name: certificate.GetName()})
+		if errors.IsNotFound(err) {
+			issued = false
+		} else if err != nil {
+			return result.Error(err)
+		} else {
+			fmt.Fprintf(&hashes, "%s\n", getCertificateSecretHash(secret))
+		}
+	}
+
+	if !issued {
+		rc.ReqLogger.Info("waiting for cert-manager to issue the certificates")
+		return result.RequeueSoon(2)
+	}
+
+	return rc.checkHashAnnotation(api.CertificatesHashAnnotation, getConfigDataHash(hashes.String()))
+}
+
+// getIssuedCertificateSecret Returns the secret issued by cert-manager for the purpose, which must
+// hold the CA certificate along with the key and certificate
+func (rc *ReconciliationContext) getIssuedCertificateSecret(purpose string) (*corev1.Secret, error) {
+	dc := rc.Datacenter
+	name := dc.GetCertificateSecretName(purpose)
+	secret, err := rc.retrieveSecret(types.NamespacedName{Namespace: dc.Namespace, Name: name})
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"tls.crt", "tls.key", "ca.crt"} {
+		if len(secret.Data[key]) == 0 {
+			err := fmt.Errorf("certificate secret %s has no %s, the issuer of certManager must provide it", name, key)
+			rc.Recorder.Event(dc, corev1.EventTypeWarning, events.InvalidDatacenterSpec, err.Error())
+			return nil, err
+		}
+	}
+	return secret, nil
+}
+
+// getCertificateSecretNames Returns the names of the secrets issued by cert-manager for the datacenter
+func getCertificateSecretNames(dc *api.CassandraDatacenter) []string {
+	var names []string
+	for _, certificate := range newCertificatesForCassandraDatacenter(dc) {
+		names = append(names, certificate.GetName())
+	}
+	return names
+}
+
+func getCertificateSecretHash(secret *corev1.Secret) string {
+	return getConfigDataHash(fmt.Sprintf("%s\n%s\n%s", secret.Name, secret.Data["tls.crt"], secret.Data["ca.crt"]))
+}
+
+// getKeystorePasswordSecretName The format is dcName-keystore-password
+func getKeystorePasswordSecretName(dc *api.CassandraDatacenter) string {
+	return dc.Name + "-keystore-password"
+}
+
+// newCertificatesForCassandraDatacenter Returns the Certificate resources of the features of the
+// datacenter that need one. The nodes are reached through the all pods service, the clients connect
+// through the datacenter service.
+func newCertificatesForCassandraDatacenter(dc *api.CassandraDatacenter) []*unstructured.Unstructured {
+	if dc.Spec.CertManager == nil {
+		return nil
+	}
+
+	podsDNSName := fmt.Sprintf("*.%s.%s.svc", dc.GetAllPodsServiceName(), dc.Namespace)
+	var certificates []*unstructured.Unstructured
+	if dc.Spec.InternodeEncryption {
+		certificates = append(certificates, newCertificate(dc, api.InternodeCertificatePurpose,
+			map[string]interface{}{"dnsNames": []interface{}{podsDNSName}},
+			"server auth", "client auth"))
+	}
+	if dc.Spec.ClientEncryption != nil && dc.Spec.ClientEncryption.KeystoreSecretName == "" {
+		var dnsNames []interface{}
+		for _, dnsName := range getClientCertificateDNSNames(dc) {
+			dnsNames = append(dnsNames, dnsName)
+		}
+		certificates = append(certificates, newCertificate(dc, api.ClientCertificatePurpose,
+			map[string]interface{}{"dnsNames": dnsNames},
+			"server auth"))
+	}
+	if dc.IsManagementApiCertManagerEnabled() {
+		// The probes of the pods authenticate with the server certificate
+		certificates = append(certificates, newCertificate(dc, api.MgmtApiServerCertificatePurpose,
+			map[string]interface{}{"dnsNames": []interface{}{podsDNSName}},
+			"server auth", "client auth"))
+		certificates = append(certificates, newCertificate(dc, api.MgmtApiClientCertificatePurpose,
+			map[string]interface{}{"commonName": oplabels.ManagedByLabelValue},
+			"client auth"))
+	}
+	return certificates
+}
+
+func newCertificate(dc *api.CassandraDatacenter, purpose string, subject map[string]interface{}, usages ...string) *unstructured.Unstructured {
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddOperatorLabels(labels, dc)
+
+	issuerKind := dc.Spec.CertManager.IssuerKind
+	if issuerKind == "" {
+		issuerKind = "Issuer"
+	}
+
+	usagesList := make([]interface{}, 0, len(usages))
+	for _, usage := range usages {
+		usagesList = append(usagesList, usage)
+	}
+
+	spec := map[string]interface{}{
+		"secretName": dc.GetCertificateSecretName(purpose),
+		"issuerRef": map[string]interface{}{
+			"name":  dc.Spec.CertManager.IssuerName,
+			"kind":  issuerKind,
+			"group": certificateGVK.Group,
+		},
+		"usages": usagesList,
+		"keystores": map[string]interface{}{
+			"pkcs12": map[string]interface{}{
+				"create": true,
+				"passwordSecretRef": map[string]interface{}{
+					"name": getKeystorePasswordSecretName(dc),
+					"key":  keystorePasswordKey,
+				},
+			},
+		},
+	}
+	for k, v := range subject {
+		spec[k] = v
+	}
+
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certificateGVK)
+	certificate.SetNamespace(dc.Namespace)
+	certificate.SetName(dc.GetCertificateSecretName(purpose))
+	certificate.SetLabels(labels)
+	certificate.Object["spec"] = spec
+
+	utils.AddHashAnnotation(certificate)
+	return certificate
+}
@@ -0,0 +1,133 @@
+package reconciliation
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pavel-v-chernykh/keystore-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+)
+
+func TestCheckCertificates(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.CertManager = &api.CertManagerSpec{IssuerName: "my-issuer", IssuerKind: "ClusterIssuer"}
+	dc.Spec.InternodeEncryption = true
+	dc.Spec.ClientEncryption = &api.ClientEncryptionSpec{}
+
+	// cert-manager is not installed
+	result := rc.CheckCertificates()
+	assert.True(t, result.Completed())
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{})
+	mapper.Add(certificateGVK, meta.RESTScopeNamespace)
+	rc.Client = fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithRuntimeObjects(dc).Build()
+
+	// The certificates are not issued yet
+	result = rc.CheckCertificates()
+	assert.True(t, result.Completed())
+	res, err := result.Output()
+	require.NoError(t, err)
+	assert.True(t, res.Requeue || res.RequeueAfter > 0)
+
+	purposes := []string{
+		api.InternodeCertificatePurpose,
+		api.ClientCertificatePurpose,
+		api.MgmtApiServerCertificatePurpose,
+		api.MgmtApiClientCertificatePurpose,
+	}
+	assert.Len(t, getCertificateSecretNames(dc), len(purposes))
+
+	keyPem, certPem, err := utils.GetNewCAandKey("my-issuer", dc.Namespace)
+	require.NoError(t, err)
+	for _, purpose := range purposes {
+		certificate := &unstructured.Unstructured{}
+		certificate.SetGroupVersionKind(certificateGVK)
+		require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetCertificateSecretName(purpose)}, certificate))
+		issuerRef, _, err := unstructured.NestedStringMap(certificate.Object, "spec", "issuerRef")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"name": "my-issuer", "kind": "ClusterIssuer", "group": "cert-manager.io"}, issuerRef)
+		secretName, _, err := unstructured.NestedString(certificate.Object, "spec", "secretName")
+		require.NoError(t, err)
+
+		// cert-manager issues the certificate
+		require.NoError(t, rc.Client.Create(rc.Ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: dc.Namespace},
+			Data:       map[string][]byte{"tls.crt": []byte(certPem), "tls.key": []byte(keyPem), "ca.crt": []byte(certPem)},
+		}))
+	}
+
+	result = rc.CheckCertificates()
+	assert.False(t, result.Completed())
+
+	// The pods restart when cert-manager renews a certificate
+	hash := dc.Annotations[api.CertificatesHashAnnotation]
+	assert.NotEmpty(t, hash)
+	renewed := &corev1.Secret{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetCertificateSecretName(api.ClientCertificatePurpose)}, renewed))
+	renewed.Data["tls.crt"] = []byte(certPem + "\n")
+	require.NoError(t, rc.Client.Update(rc.Ctx, renewed))
+	result = rc.CheckCertificates()
+	assert.False(t, result.Completed())
+	assert.NotEqual(t, hash, dc.Annotations[api.CertificatesHashAnnotation])
+	podTemplateSpec, err := buildPodTemplateSpec(dc, nil, "default")
+	require.NoError(t, err)
+	assert.Equal(t, dc.Annotations[api.CertificatesHashAnnotation], podTemplateSpec.Annotations[api.CertificatesHashAnnotation])
+
+	// The keystores are converted from the issued certificates
+	result = rc.CheckInternodeKeystores()
+	assert.False(t, result.Completed())
+	secret := &corev1.Secret{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetInternodeKeystoresSecretName()}, secret))
+	assert.Len(t, secret.Data, 3)
+	truststore, err := keystore.Decode(bytes.NewReader(secret.Data["truststore.jks"]), []byte(dc.Name))
+	require.NoError(t, err)
+	assert.Contains(t, truststore, "ca")
+
+	result = rc.CheckClientKeystore()
+	assert.False(t, result.Completed())
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetClientCASecretName()}, secret))
+	assert.Equal(t, certPem, string(secret.Data["ca.crt"]))
+}
+
+func TestNewCertificatesForCassandraDatacenter(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "ns"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "cluster1",
+			CertManager: &api.CertManagerSpec{IssuerName: "my-issuer"},
+			ManagementApiAuth: api.ManagementApiAuthConfig{
+				Insecure: &api.ManagementApiAuthInsecureConfig{},
+			},
+			ClientEncryption: &api.ClientEncryptionSpec{KeystoreSecretName: "my-keystore"},
+		},
+	}
+	assert.Empty(t, newCertificatesForCassandraDatacenter(dc))
+
+	dc.Spec.InternodeEncryption = true
+	certificates := newCertificatesForCassandraDatacenter(dc)
+	require.Len(t, certificates, 1)
+	assert.Equal(t, "dc1-internode-certificate", certificates[0].GetName())
+	kind, _, _ := unstructured.NestedString(certificates[0].Object, "spec", "issuerRef", "kind")
+	assert.Equal(t, "Issuer", kind)
+	dnsNames, _, _ := unstructured.NestedStringSlice(certificates[0].Object, "spec", "dnsNames")
+	assert.Equal(t, []string{"*.cluster1-dc1-all-pods-service.ns.svc"}, dnsNames)
+}
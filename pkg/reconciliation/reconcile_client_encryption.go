@@ -0,0 +1,200 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+)
+
+const (
+	clientKeystoreVolumeName        = "client-keystore"
+	clientKeystorePasswordContainer = "server-client-keystore-password"
+
+	// keystoreSourceHashAnnotation records what the keystore was prepared from, the JKS encoding itself
+	// is not stable
+	keystoreSourceHashAnnotation = "cassandra.datastax.com/keystore-source-hash"
+)
+
+// clientKeystorePasswordScript replaces the placeholder of the configuration with the password of the keystore
+// of the KeystoreSecretName secret, quoted for YAML and escaped for sed
+const clientKeystorePasswordScript = `password=$(sed -e "s/'/''/g" -e 's/[\&|]/\\&/g' ` + api.ClientEncryptionDir + `/` + api.ClientKeystorePwdKey + `)
+sed -i "s|[\"']\{0,1\}` + api.ClientKeystorePasswordPlaceholder + `[\"']\{0,1\}|'${password}'|" /config/cassandra.yaml
+`
+
+// CheckClientKeystore When ClientEncryption is set, prepares the keystore mounted by the pods, either from
+// the keystore secret of the spec, from the certificate issued by cert-manager or generated with the CA of
+// the datacenter, and publishes the CA certificate that the clients need to trust. The keystore of the
+// secret is copied with its password, which the pods substitute in their configuration. The generated
+// keystores are valid for the datacenter service and the pods.
+func (rc *ReconciliationContext) CheckClientKeystore() result.ReconcileResult {
+	dc := rc.Datacenter
+	if dc.Spec.ClientEncryption == nil {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_client_encryption::CheckClientKeystore")
+
+	keystoreName := types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetClientKeystoreSecretName()}
+	current, err := rc.retrieveSecret(keystoreName)
+	if err != nil && !errors.IsNotFound(err) {
+		return result.Error(err)
+	}
+
+	var keystore, password, caCert []byte
+	var sourceHash string
+	if secretName := dc.Spec.ClientEncryption.KeystoreSecretName; secretName != "" {
+		source, err := rc.retrieveSecret(types.NamespacedName{Namespace: dc.Namespace, Name: secretName})
+		if err != nil {
+			rc.ReqLogger.Error(err, "failed to get client keystore secret", "Secret", secretName)
+			return result.Error(err)
+		}
+		var foundKeystore, foundPassword bool
+		keystore, foundKeystore = source.Data[api.ClientKeystoreKey]
+		password, foundPassword = source.Data[api.ClientKeystorePwdKey]
+		if !foundKeystore || !foundPassword {
+			err := fmt.Errorf("client keystore secret %s must have the %s and %s keys", secretName, api.ClientKeystoreKey, api.ClientKeystorePwdKey)
+			rc.Recorder.Event(dc, corev1.EventTypeWarning, events.InvalidDatacenterSpec, err.Error())
+			return result.Error(err)
+		}
+
+		sourceHash = getConfigDataHash(fmt.Sprintf("%s\n%s\n%s", secretName, keystore, password))
+		if current != nil && current.Annotations[keystoreSourceHashAnnotation] == sourceHash {
+			return result.Continue()
+		}
+
+		if caCert, err = utils.GetJKSRootCertificate(keystore, string(password)); err != nil {
+			return result.Error(fmt.Errorf("failed to read client keystore secret %s: %w", secretName, err))
+		}
+	} else if dc.Spec.CertManager != nil {
+		certificate, err := rc.getIssuedCertificateSecret(api.ClientCertificatePurpose)
+		if err != nil {
+			return result.Error(err)
+		}
+
+		sourceHash = getCertificateSecretHash(certificate)
+		if current != nil && current.Annotations[keystoreSourceHashAnnotation] == sourceHash {
+			return result.Continue()
+		}
+
+		if keystore, err = utils.GenerateJKSFromPEM(getClientCertificateDNSNames(dc)[0], certificate.Data["tls.crt"],
+			certificate.Data["tls.key"], certificate.Data["ca.crt"], dc.GetKeystorePassword()); err != nil {
+			return result.Error(fmt.Errorf("failed to read certificate secret %s: %w", certificate.Name, err))
+		}
+		caCert = certificate.Data["ca.crt"]
+	} else {
+		ca, err := rc.retrieveInternodeCredentialSecretOrCreateDefault()
+		if err != nil {
+			rc.ReqLogger.Error(err, "error retrieving InternodeCredential for CassandraDatacenter.")
+			return result.Error(err)
+		}
+
+		sourceHash = getConfigDataHash(fmt.Sprintf("%s\n%s", ca.Name, ca.Data["cert"]))
+		if current != nil && current.Annotations[keystoreSourceHashAnnotation] == sourceHash {
+			return result.Continue()
+		}
+
+		if keystore, err = utils.GenerateServerJKS(ca, getClientCertificateDNSNames(dc), dc.GetKeystorePassword()); err != nil {
+			return result.Error(err)
+		}
+		caCert = ca.Data["cert"]
+	}
+
+	if err := rc.applyOperatorSecret(dc.GetClientCASecretName(), map[string][]byte{api.ClientCACertKey: caCert}, nil); err != nil {
+		return result.Error(err)
+	}
+	data := map[string][]byte{api.ClientKeystoreKey: keystore}
+	if password != nil {
+		data[api.ClientKeystorePwdKey] = password
+	}
+	if err := rc.applyOperatorSecret(dc.GetClientKeystoreSecretName(), data,
+		map[string]string{keystoreSourceHashAnnotation: sourceHash}); err != nil {
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
+
+// applyOperatorSecret Creates or updates a secret of the datacenter with the data
+func (rc *ReconciliationContext) applyOperatorSecret(name string, data map[string][]byte, annotations map[string]string) error {
+	dc := rc.Datacenter
+	secret, err := rc.retrieveSecret(types.NamespacedName{Namespace: dc.Namespace, Name: name})
+	exists := err == nil
+	if errors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: dc.Namespace,
+				Labels:    dc.GetDatacenterLabels(),
+			},
+		}
+		oplabels.AddOperatorLabels(secret.Labels, dc)
+		if err := rc.SetDatacenterAsOwner(secret); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	secret.Data = data
+	for k, v := range annotations {
+		metav1.SetMetaDataAnnotation(&secret.ObjectMeta, k, v)
+	}
+
+	if exists {
+		rc.ReqLogger.Info("updating secret", "Secret", name)
+		return rc.Client.Update(rc.Ctx, secret)
+	}
+
+	rc.ReqLogger.Info("creating secret", "Secret", name)
+	if err := rc.Client.Create(rc.Ctx, secret); err != nil {
+		return err
+	}
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource, "Created secret %s", name)
+	return nil
+}
+
+func getClientKeystoreVolume(dc *api.CassandraDatacenter) corev1.Volume {
+	return corev1.Volume{
+		Name: clientKeystoreVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: dc.GetClientKeystoreSecretName()},
+		},
+	}
+}
+
+// getClientCertificateDNSNames Returns the names the clients reach the nodes with, the datacenter service and
+// the pods
+func getClientCertificateDNSNames(dc *api.CassandraDatacenter) []string {
+	return []string{
+		fmt.Sprintf("%s.%s.svc", dc.GetDatacenterServiceName(), dc.Namespace),
+		fmt.Sprintf("*.%s.%s.svc", dc.GetAllPodsServiceName(), dc.Namespace),
+	}
+}
+
+// newClientKeystorePasswordInitContainer The container runs after the config builder, in its image, and
+// renders the password of the keystore of the KeystoreSecretName secret in the configuration
+func newClientKeystorePasswordInitContainer(configBuilder *corev1.Container) corev1.Container {
+	return corev1.Container{
+		Name:            clientKeystorePasswordContainer,
+		Image:           configBuilder.Image,
+		ImagePullPolicy: configBuilder.ImagePullPolicy,
+		Command:         []string{"/bin/sh", "-c", clientKeystorePasswordScript},
+		Resources:       configBuilder.Resources,
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "server-config", MountPath: "/config"},
+			{Name: clientKeystoreVolumeName, MountPath: api.ClientEncryptionDir, ReadOnly: true},
+		},
+	}
+}
@@ -0,0 +1,105 @@
+package reconciliation
+
+import (
+	"bytes"
+	"crypto/x509"
+	"testing"
+
+	"github.com/pavel-v-chernykh/keystore-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+)
+
+func TestCheckClientKeystoreGenerated(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.ClientEncryption = &api.ClientEncryptionSpec{}
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(dc).Build()
+
+	result := rc.CheckClientKeystore()
+	assert.False(t, result.Completed())
+
+	ca := &corev1.Secret{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.Name + "-ca-keystore"}, ca))
+
+	caSecret := &corev1.Secret{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetClientCASecretName()}, caSecret))
+	assert.Equal(t, ca.Data["cert"], caSecret.Data["ca.crt"])
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetClientKeystoreSecretName()}
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, secret))
+	store, err := keystore.Decode(bytes.NewReader(secret.Data["keystore.jks"]), []byte(dc.Name))
+	require.NoError(t, err)
+	serviceName := dc.GetDatacenterServiceName() + "." + dc.Namespace + ".svc"
+	require.IsType(t, &keystore.PrivateKeyEntry{}, store[serviceName])
+	certificate, err := x509.ParseCertificate(store[serviceName].(*keystore.PrivateKeyEntry).CertChain[0].Content)
+	require.NoError(t, err)
+	assert.Equal(t, serviceName, certificate.Subject.CommonName)
+	assert.Equal(t, []string{serviceName, "*." + dc.GetAllPodsServiceName() + "." + dc.Namespace + ".svc"}, certificate.DNSNames)
+
+	// The keystore is generated once
+	resourceVersion := secret.ResourceVersion
+	result = rc.CheckClientKeystore()
+	assert.False(t, result.Completed())
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, secret))
+	assert.Equal(t, resourceVersion, secret.ResourceVersion)
+}
+
+func TestCheckClientKeystoreFromSecret(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	keyPem, certPem, err := utils.GetNewCAandKey("my-ca", "default")
+	require.NoError(t, err)
+	ca := &corev1.Secret{Data: map[string][]byte{"key": []byte(keyPem), "cert": []byte(certPem)}}
+	jks, err := utils.GenerateJKS(ca, "cassandra", "secret")
+	require.NoError(t, err)
+
+	dc := rc.Datacenter
+	dc.Spec.ClientEncryption = &api.ClientEncryptionSpec{KeystoreSecretName: "my-keystore"}
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-keystore", Namespace: dc.Namespace},
+		Data:       map[string][]byte{"keystore.jks": jks, "keystore-password": []byte("secret")},
+	}
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(dc, source).Build()
+
+	result := rc.CheckClientKeystore()
+	assert.False(t, result.Completed())
+
+	caSecret := &corev1.Secret{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetClientCASecretName()}, caSecret))
+	assert.Equal(t, certPem, string(caSecret.Data["ca.crt"]))
+
+	// The keystore is copied with its password
+	secret := &corev1.Secret{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetClientKeystoreSecretName()}, secret))
+	assert.Equal(t, source.Data, secret.Data)
+}
+
+func TestCheckClientKeystoreInvalidSecret(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.ClientEncryption = &api.ClientEncryptionSpec{KeystoreSecretName: "my-keystore"}
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-keystore", Namespace: dc.Namespace},
+		Data:       map[string][]byte{"keystore.jks": []byte("not a keystore")},
+	}
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(dc, source).Build()
+
+	result := rc.CheckClientKeystore()
+	assert.True(t, result.Completed())
+	_, err := result.Output()
+	assert.Error(t, err)
+}
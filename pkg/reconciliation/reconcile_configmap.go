@@ -0,0 +1,320 @@
+package reconciliation
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/cdc"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	serverConfigDataVolumeName = "server-config-data"
+	serverConfigDataPath       = "/config-data"
+)
+
+// CheckConfigMap When the ConfigSecret property is not set, writes the rendered server
+// configuration of each rack to an immutable config map named after the hash of its content.
+// The config builder init container reads its configuration from the config map referenced by
+// the pod template, so that a configuration change creates a new config map and rolls the pods
+// to it, instead of changing the configuration under the pods which were not restarted yet. The
+// config maps which are no longer referenced are deleted once the racks are rolled out.
+func (rc *ReconciliationContext) CheckConfigMap() result.ReconcileResult {
+	rc.ReqLogger.Info("reconcile_racks::CheckConfigMap")
+
+	if len(rc.Datacenter.Spec.ConfigSecret) > 0 {
+		return result.Continue()
+	}
+
+	desired := map[string]bool{}
+	for _, rackInfo := range rc.desiredRackInformation {
+		config, err := getConfigData(rc.Datacenter, rackInfo.RackName)
+		if err != nil {
+			rc.ReqLogger.Error(err, "failed to render rack config", "rack", rackInfo.RackName)
+			return result.Error(err)
+		}
+
+		name := getRackConfigMapName(rc.Datacenter, rackInfo.RackName, config)
+		desired[name] = true
+
+		_, exists, err := rc.getDatacenterConfigMap(name)
+		if err != nil {
+			rc.ReqLogger.Error(err, "failed to get rack config map")
+			return result.Error(err)
+		}
+		if exists {
+			continue
+		}
+
+		configMap, err := rc.newRackConfigMap(name, rackInfo.RackName, config)
+		if err != nil {
+			return result.Error(err)
+		}
+
+		rc.ReqLogger.Info("creating rack config map", "ConfigMap", configMap.Name)
+		if err := rc.Client.Create(rc.Ctx, configMap); err != nil {
+			rc.ReqLogger.Error(err, "failed to create rack config map", "ConfigMap", configMap.Name)
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.CreatedResource,
+			"Created config map %s", configMap.Name)
+	}
+
+	if err := rc.pruneConfigMaps(desired); err != nil {
+		rc.ReqLogger.Error(err, "failed to delete the unused config maps")
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
+
+// newRackConfigMap Builds the immutable config map holding the configuration of a rack
+func (rc *ReconciliationContext) newRackConfigMap(name, rackName, config string) (*corev1.ConfigMap, error) {
+	labels := rc.Datacenter.GetRackLabels(rackName)
+	oplabels.AddOperatorLabels(labels, rc.Datacenter)
+
+	immutable := true
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: rc.Datacenter.Namespace,
+			Name:      name,
+			Labels:    labels,
+		},
+		Immutable: &immutable,
+		Data:      map[string]string{"config": config},
+	}
+
+	if err := rc.SetDatacenterAsOwner(configMap); err != nil {
+		return nil, err
+	}
+	return configMap, nil
+}
+
+// pruneConfigMaps Deletes the config maps of the datacenter which are neither desired nor referenced
+// by a StatefulSet or a pod. Nothing is deleted while a StatefulSet is rolling out, as its pods can
+// still be recreated from the previous revision.
+func (rc *ReconciliationContext) pruneConfigMaps(desired map[string]bool) error {
+	selector := rc.Datacenter.GetDatacenterLabels()
+	listOptions := &client.ListOptions{
+		Namespace:     rc.Datacenter.Namespace,
+		LabelSelector: labels.SelectorFromSet(selector),
+	}
+
+	stsList := &appsv1.StatefulSetList{}
+	if err := rc.Client.List(rc.Ctx, stsList, listOptions); err != nil {
+		return err
+	}
+
+	referenced := map[string]bool{}
+	for i := range stsList.Items {
+		sts := &stsList.Items[i]
+		if !IsStatefulSetUpdated(sts) {
+			return nil
+		}
+		addReferencedConfigMaps(referenced, &sts.Spec.Template.Spec)
+	}
+	for _, pod := range rc.dcPods {
+		addReferencedConfigMaps(referenced, &pod.Spec)
+	}
+
+	configMaps := &corev1.ConfigMapList{}
+	if err := rc.Client.List(rc.Ctx, configMaps, listOptions); err != nil {
+		return err
+	}
+
+	prefix := getDatacenterConfigMapName(rc.Datacenter)
+	for i := range configMaps.Items {
+		configMap := &configMaps.Items[i]
+		if configMap.Name != prefix && !strings.HasPrefix(configMap.Name, prefix+"-") {
+			continue
+		}
+		if desired[configMap.Name] || referenced[configMap.Name] {
+			continue
+		}
+		rc.ReqLogger.Info("deleting unused config map", "ConfigMap", configMap.Name)
+		if err := rc.Client.Delete(rc.Ctx, configMap); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addReferencedConfigMaps Adds the config maps mounted by the pod spec, or read by the env vars of its
+// init containers, to the referenced set
+func addReferencedConfigMaps(referenced map[string]bool, podSpec *corev1.PodSpec) {
+	for _, volume := range podSpec.Volumes {
+		if volume.ConfigMap != nil {
+			referenced[volume.ConfigMap.Name] = true
+		}
+	}
+	for _, c := range podSpec.InitContainers {
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
+				referenced[env.ValueFrom.ConfigMapKeyRef.Name] = true
+			}
+		}
+	}
+}
+
+// getConfigData Generates the JSON configuration of the pods of the rack, including the
+// properties added by cass-operator.
+func getConfigData(dc *api.CassandraDatacenter, rackName string) (string, error) {
+	configData, err := dc.GetRackConfigAsJSON(rackName, dc.Spec.Config)
+	if err != nil {
+		return "", err
+	}
+	cdcAdded, err := cdc.UpdateConfig(json.RawMessage(configData), *dc)
+	if err != nil {
+		return "", err
+	}
+	return string(cdcAdded), nil
+}
+
+// getConfigDataHash Returns a hash of the configuration, used to restart the pods when it
+// changes.
+func getConfigDataHash(config string) string {
+	hash := sha256.Sum256([]byte(config))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// getDatacenterConfigMapName The format is clusterName-dcName-config, it prefixes the names of the
+// rack config maps
+func getDatacenterConfigMapName(dc *api.CassandraDatacenter) string {
+	return api.CleanupForKubernetes(dc.Spec.ClusterName) + "-" + dc.Name + "-config"
+}
+
+// getRackConfigMapName The format is clusterName-dcName-config-rackName-hash, where hash is the
+// start of the hash of the configuration
+func getRackConfigMapName(dc *api.CassandraDatacenter, rackName, config string) string {
+	hash := sha256.Sum256([]byte(config))
+	return getDatacenterConfigMapName(dc) + "-" + rackName + "-" + hex.EncodeToString(hash[:])[:10]
+}
+
+// getDatacenterConfigMap Fetches the config map from the api server or creates a new one
+// if it is not already stored. The bool return parameter is true if the api server has
+// the config map, false if it has to be created. This function does not persist the new
+// config map. That is the caller's responsibility.
+func (rc *ReconciliationContext) getDatacenterConfigMap(name string) (*corev1.ConfigMap, bool, error) {
+	key := types.NamespacedName{Namespace: rc.Datacenter.Namespace, Name: name}
+	configMap := &corev1.ConfigMap{}
+	err := rc.Client.Get(rc.Ctx, key, configMap)
+
+	if err == nil {
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		return configMap, true, nil
+	} else if errors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: key.Namespace,
+				Name:      name,
+			},
+			Data: map[string]string{},
+		}
+
+		if err = rc.SetDatacenterAsOwner(configMap); err != nil {
+			return nil, false, err
+		}
+
+		return configMap, false, nil
+	} else {
+		return nil, false, err
+	}
+}
+
+func getServerConfigDataVolume(dc *api.CassandraDatacenter, rackName string) (corev1.Volume, error) {
+	config, err := getConfigData(dc, rackName)
+	if err != nil {
+		return corev1.Volume{}, err
+	}
+	return corev1.Volume{
+		Name: serverConfigDataVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: getRackConfigMapName(dc, rackName, config)},
+			},
+		},
+	}, nil
+}
+
+// usesInlineConfig Returns true if the config builder of the StatefulSet still has the configuration
+// inlined in its CONFIG_FILE_DATA env var, as the StatefulSets created by previous versions of the
+// operator do.
+func usesInlineConfig(sts *appsv1.StatefulSet) bool {
+	for _, c := range sts.Spec.Template.Spec.InitContainers {
+		if c.Name != ServerConfigContainerName {
+			continue
+		}
+		for _, env := range c.Env {
+			if env.Name == "CONFIG_FILE_DATA" && env.Value != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withInlineConfig Returns a copy of the desired StatefulSet with the configuration inlined in the
+// CONFIG_FILE_DATA env var of the config builder, instead of read from the rack config map.
+// CheckRackPodTemplate keeps this layout for the StatefulSets which have it, until they are updated
+// for another reason, so that upgrading the operator does not restart every datacenter at once.
+func withInlineConfig(desiredSts *appsv1.StatefulSet, dc *api.CassandraDatacenter, rackName string) (*appsv1.StatefulSet, error) {
+	config, err := getConfigData(dc, rackName)
+	if err != nil {
+		return nil, err
+	}
+
+	sts := desiredSts.DeepCopy()
+	delete(sts.Annotations, utils.ResourceHashAnnotationKey)
+
+	podSpec := &sts.Spec.Template.Spec
+	for i := range podSpec.InitContainers {
+		c := &podSpec.InitContainers[i]
+		if c.Name != ServerConfigContainerName {
+			continue
+		}
+		for j := range c.Env {
+			if c.Env[j].Name == "CONFIG_FILE_DATA" {
+				c.Env[j] = corev1.EnvVar{Name: "CONFIG_FILE_DATA", Value: config}
+			}
+		}
+		c.VolumeMounts = removeVolumeMount(c.VolumeMounts, serverConfigDataVolumeName)
+	}
+
+	volumes := make([]corev1.Volume, 0, len(podSpec.Volumes))
+	for _, volume := range podSpec.Volumes {
+		if volume.Name != serverConfigDataVolumeName {
+			volumes = append(volumes, volume)
+		}
+	}
+	podSpec.Volumes = volumes
+
+	utils.AddHashAnnotation(sts)
+	return sts, nil
+}
+
+func removeVolumeMount(mounts []corev1.VolumeMount, name string) []corev1.VolumeMount {
+	out := make([]corev1.VolumeMount, 0, len(mounts))
+	for _, mount := range mounts {
+		if mount.Name != name {
+			out = append(out, mount)
+		}
+	}
+	return out
+}
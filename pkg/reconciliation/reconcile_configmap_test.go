@@ -0,0 +1,170 @@
+package reconciliation
+
+import (
+	"encoding/json"
+	"testing"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/httphelper"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestCheckConfigMap(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	fakeRecorder := record.NewFakeRecorder(5)
+	rc.Recorder = fakeRecorder
+
+	dc := rc.Datacenter
+	dc.Spec.Racks = []api.Rack{{Name: "rack1"}}
+	assert.NoError(t, rc.CalculateRackInformation())
+
+	result := rc.CheckConfigMap()
+	assert.False(t, result.Completed())
+
+	config, err := getConfigData(dc, "rack1")
+	assert.NoError(t, err)
+	key := types.NamespacedName{Namespace: dc.Namespace, Name: getRackConfigMapName(dc, "rack1", config)}
+	configMap := &corev1.ConfigMap{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, configMap))
+	assert.Equal(t, config, configMap.Data["config"])
+	assert.True(t, *configMap.Immutable)
+	assert.Equal(t, dc.Name, configMap.Labels["cassandra.datastax.com/datacenter"])
+	assert.Equal(t, "rack1", configMap.Labels["cassandra.datastax.com/rack"])
+
+	envVars, err := getConfigDataEnVars(dc, "rack1")
+	assert.NoError(t, err)
+	assert.Equal(t, "CONFIG_FILE_DATA", envVars[0].Name)
+	assert.Equal(t, key.Name, envVars[0].ValueFrom.ConfigMapKeyRef.Name)
+
+	// A new config goes to a new config map, the previous one is deleted as nothing references it
+	dc.Spec.Config = json.RawMessage(`{"cassandra-yaml": {"num_tokens": 16}}`)
+	result = rc.CheckConfigMap()
+	assert.False(t, result.Completed())
+
+	newConfig, err := getConfigData(dc, "rack1")
+	assert.NoError(t, err)
+	newKey := types.NamespacedName{Namespace: dc.Namespace, Name: getRackConfigMapName(dc, "rack1", newConfig)}
+	assert.NotEqual(t, key, newKey)
+	assert.NoError(t, rc.Client.Get(rc.Ctx, newKey, configMap))
+	assert.Contains(t, configMap.Data["config"], `"num_tokens":16`)
+	assert.True(t, errors.IsNotFound(rc.Client.Get(rc.Ctx, key, configMap)))
+
+	result = rc.CheckConfigMap()
+	assert.False(t, result.Completed())
+
+	close(fakeRecorder.Events)
+	// Should have 2 events, one for the creation of each config map
+	assert.Equal(t, 2, len(fakeRecorder.Events))
+	assert.Contains(t, <-fakeRecorder.Events, events.CreatedResource)
+	assert.Contains(t, <-fakeRecorder.Events, events.CreatedResource)
+}
+
+func TestCheckConfigMap_keepsReferencedConfigMaps(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Racks = []api.Rack{{Name: "rack1"}}
+	assert.NoError(t, rc.CalculateRackInformation())
+	assert.False(t, rc.CheckConfigMap().Completed())
+	assert.False(t, rc.CheckRackCreation().Completed())
+
+	config, err := getConfigData(dc, "rack1")
+	assert.NoError(t, err)
+	key := types.NamespacedName{Namespace: dc.Namespace, Name: getRackConfigMapName(dc, "rack1", config)}
+
+	// The StatefulSet still references the previous config map
+	dc.Spec.Config = json.RawMessage(`{"cassandra-yaml": {"num_tokens": 16}}`)
+	assert.False(t, rc.CheckConfigMap().Completed())
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, &corev1.ConfigMap{}))
+}
+
+func TestCheckConfigMap_rackDseWorkloads(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.ServerType = "dse"
+	dc.Spec.ServerVersion = "6.8.4"
+	dc.Spec.Racks = []api.Rack{
+		{Name: "rack1", DseWorkloads: &api.DseWorkloads{SearchEnabled: true}},
+		{Name: "rack2", DseWorkloads: &api.DseWorkloads{AnalyticsEnabled: true}},
+	}
+	assert.NoError(t, rc.CalculateRackInformation())
+	assert.False(t, rc.CheckConfigMap().Completed())
+
+	// Each rack enables its own workloads only
+	for rackName, enabled := range map[string]string{"rack1": `"solr-enabled":1`, "rack2": `"spark-enabled":1`} {
+		config, err := getConfigData(dc, rackName)
+		assert.NoError(t, err)
+		assert.Contains(t, config, enabled)
+		configMap := &corev1.ConfigMap{}
+		key := types.NamespacedName{Namespace: dc.Namespace, Name: getRackConfigMapName(dc, rackName, config)}
+		assert.NoError(t, rc.Client.Get(rc.Ctx, key, configMap))
+		assert.Equal(t, config, configMap.Data["config"])
+	}
+	config, err := getConfigData(dc, "rack1")
+	assert.NoError(t, err)
+	assert.Contains(t, config, `"spark-enabled":0`)
+}
+
+func TestConfigDataHashIgnoresKeyOrdering(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Config = json.RawMessage(`{"cassandra-yaml": {"num_tokens": 16, "concurrent_reads": 32}, "jvm-server-options": {"max_heap_size": "1024M"}}`)
+	config, err := getConfigData(dc, "rack1")
+	assert.NoError(t, err)
+
+	dc.Spec.Config = json.RawMessage(`{"jvm-server-options": {"max_heap_size": "1024M"},
+		"cassandra-yaml": {"concurrent_reads": 32, "num_tokens": 16}}`)
+	reorderedConfig, err := getConfigData(dc, "rack1")
+	assert.NoError(t, err)
+
+	assert.Equal(t, getConfigDataHash(config), getConfigDataHash(reorderedConfig))
+}
+
+func TestCheckRackPodTemplate_inlineConfig(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Racks = []api.Rack{{Name: "rack1"}}
+	assert.NoError(t, rc.CalculateRackInformation())
+	assert.False(t, rc.CheckRackCreation().Completed())
+
+	// A StatefulSet created by a previous version of the operator, with the config inlined
+	sts := rc.statefulSets[0]
+	desiredSts, err := newStatefulSetForCassandraDatacenter(sts, "rack1", dc, int(*sts.Spec.Replicas))
+	assert.NoError(t, err)
+	inlineSts, err := withInlineConfig(desiredSts, dc, "rack1")
+	assert.NoError(t, err)
+	sts.Spec.Template = inlineSts.Spec.Template
+	sts.Annotations = inlineSts.Annotations
+	assert.NoError(t, rc.Client.Update(rc.Ctx, sts))
+	assert.True(t, usesInlineConfig(sts))
+
+	// It is left alone as long as it is up to date
+	result := rc.CheckRackPodTemplate(httphelper.CassMetadataEndpoints{})
+	assert.False(t, result.Completed())
+	assert.True(t, usesInlineConfig(rc.statefulSets[0]))
+
+	// and switches to the rack config map when it is updated anyway
+	dc.Spec.Config = json.RawMessage(`{"cassandra-yaml": {"num_tokens": 16}}`)
+	result = rc.CheckRackPodTemplate(httphelper.CassMetadataEndpoints{})
+	assert.True(t, result.Completed())
+
+	sts = &appsv1.StatefulSet{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: rc.statefulSets[0].Name}, sts))
+	assert.False(t, usesInlineConfig(sts))
+	assert.True(t, volumesContains(sts.Spec.Template.Spec.Volumes, volumeNameMatcher(serverConfigDataVolumeName)))
+}
@@ -2,15 +2,13 @@ package reconciliation
 
 import (
 	"bytes"
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 
 	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
 	"github.com/k8ssandra/cass-operator/pkg/cdc"
+	"github.com/k8ssandra/cass-operator/pkg/events"
 	"github.com/k8ssandra/cass-operator/pkg/internal/result"
-	"github.com/k8ssandra/cass-operator/pkg/utils"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -57,7 +55,7 @@ func (rc *ReconciliationContext) CheckConfigSecret() result.ReconcileResult {
 
 	storedConfig, found := dcConfigSecret.Data["config"]
 	if !(found && bytes.Equal(storedConfig, config)) {
-		if err := rc.updateConfigHashAnnotation(dcConfigSecret); err != nil {
+		if err := rc.updateConfigHashAnnotation(config); err != nil {
 			rc.ReqLogger.Error(err, "failed to update config hash annotation")
 			return result.Error(err)
 		}
@@ -76,6 +74,8 @@ func (rc *ReconciliationContext) CheckConfigSecret() result.ReconcileResult {
 				return result.Error(err)
 			}
 		}
+		rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.UpdatedConfig,
+			"Updated config secret %s", dcConfigSecret.Name)
 	}
 
 	return result.Continue()
@@ -95,16 +95,12 @@ func (rc *ReconciliationContext) checkDatacenterNameAnnotation(secret *corev1.Se
 }
 
 // updateConfigHashAnnotation Adds the config hash annotation to the datacenter. The value
-// of the annotation is a hash of the secret. The datacenter is then patched. Note that the
-// secret should be the datacenter secret created by the operator, NOT the secret specified
-// by ConfigSecret.
-func (rc *ReconciliationContext) updateConfigHashAnnotation(secret *corev1.Secret) error {
+// of the annotation is a hash of the generated configuration, so that it only changes when
+// the configuration does. The datacenter is then patched.
+func (rc *ReconciliationContext) updateConfigHashAnnotation(config []byte) error {
 	rc.ReqLogger.Info("updating config hash annotation")
 
-	hasher := sha256.New()
-	utils.DeepHashObject(hasher, secret)
-	hashBytes := hasher.Sum([]byte{})
-	b64Hash := base64.StdEncoding.EncodeToString(hashBytes)
+	b64Hash := getConfigDataHash(string(config))
 
 	patch := client.MergeFrom(rc.Datacenter.DeepCopy())
 	metav1.SetMetaDataAnnotation(&rc.Datacenter.ObjectMeta, api.ConfigHashAnnotation, b64Hash)
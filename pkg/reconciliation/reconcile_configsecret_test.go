@@ -0,0 +1,21 @@
+package reconciliation
+
+import (
+	"testing"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigSecretHashOnlyDependsOnConfig(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	config := []byte(`{"cassandra-yaml": {"num_tokens": 16}}`)
+	assert.NoError(t, rc.updateConfigHashAnnotation(config))
+	hash := rc.Datacenter.Annotations[api.ConfigHashAnnotation]
+	assert.Equal(t, getConfigDataHash(string(config)), hash)
+
+	assert.NoError(t, rc.updateConfigHashAnnotation(config))
+	assert.Equal(t, hash, rc.Datacenter.Annotations[api.ConfigHashAnnotation])
+}
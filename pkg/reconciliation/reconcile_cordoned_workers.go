@@ -0,0 +1,193 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+)
+
+// CheckCordonedWorkers When movePodsFromCordonedWorkers is set, evicts the pods running on cordoned
+// workers so that the StatefulSet controller reschedules them on other workers. Only one pod is moved
+// at a time, once all the other pods are ready, and the Eviction API refuses it when the
+// PodDisruptionBudget does not allow it. The preStop hook of the pod drains its node. The pods whose
+// volumes are pinned to their worker, like local persistent volumes, are not moved since they could not
+// start anywhere else. The pods still to move, or that can not move, are reported by the MovingPods
+// condition.
+func (rc *ReconciliationContext) CheckCordonedWorkers() result.ReconcileResult {
+	logger := rc.ReqLogger
+	dc := rc.Datacenter
+
+	var podsToMove, pinnedPods []*corev1.Pod
+	if dc.Spec.MovePodsFromCordonedWorkers {
+		logger.Info("reconcile_cordoned_workers::CheckCordonedWorkers")
+		var err error
+		if podsToMove, pinnedPods, err = rc.getPodsOnCordonedWorkers(); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	var updated bool
+	if len(podsToMove) > 0 || len(pinnedPods) > 0 {
+		var messages []string
+		if len(podsToMove) > 0 {
+			messages = append(messages, fmt.Sprintf("Pods %s are running on cordoned workers", joinPodNames(podsToMove)))
+		}
+		if len(pinnedPods) > 0 {
+			messages = append(messages, fmt.Sprintf("Pods %s can not move from cordoned workers, their volumes are pinned to them",
+				joinPodNames(pinnedPods)))
+		}
+		updated = rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterMovingPods, corev1.ConditionTrue,
+			"PodsOnCordonedWorkers", strings.Join(messages, ". ")))
+		if updated && len(pinnedPods) > 0 {
+			rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.MovingPod,
+				"Not moving pods %s from cordoned workers, their volumes are pinned to them, replace their nodes instead",
+				joinPodNames(pinnedPods))
+		}
+	} else if dc.GetConditionStatus(api.DatacenterMovingPods) == corev1.ConditionTrue {
+		updated = rc.setCondition(api.NewDatacenterCondition(api.DatacenterMovingPods, corev1.ConditionFalse))
+	}
+
+	if updated {
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			logger.Error(err, "error patching datacenter status for cordoned workers")
+			return result.Error(err)
+		}
+	}
+
+	if len(podsToMove) == 0 {
+		return result.Continue()
+	}
+
+	if notReady := findAllPodsNotReady(rc.dcPods); len(notReady) > 0 {
+		logger.Info("Waiting for all the pods to be ready before moving the next pod from a cordoned worker",
+			"notReady", len(notReady))
+		return result.RequeueSoon(10)
+	}
+
+	pod := podsToMove[0]
+	if err := rc.PodEvictor.EvictPod(rc.Ctx, pod); err != nil {
+		if errors.IsTooManyRequests(err) {
+			logger.Info("The PodDisruptionBudget does not allow evicting the pod from its cordoned worker yet",
+				"pod", pod.Name)
+			return result.RequeueSoon(10)
+		}
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "error evicting pod from cordoned worker", "pod", pod.Name)
+			return result.Error(err)
+		}
+	}
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.MovingPod,
+		"Evicted pod %s from cordoned worker %s", pod.Name, pod.Spec.NodeName)
+
+	return result.RequeueSoon(10)
+}
+
+// getPodsOnCordonedWorkers Returns the pods of the datacenter running on a cordoned worker, sorted by
+// name, split between the pods which can move and the pods whose volumes are pinned to the worker. The
+// pods already being deleted are skipped.
+func (rc *ReconciliationContext) getPodsOnCordonedWorkers() ([]*corev1.Pod, []*corev1.Pod, error) {
+	cordoned := map[string]*corev1.Node{}
+	var pods, pinnedPods []*corev1.Pod
+	for _, pod := range rc.dcPods {
+		if pod.Spec.NodeName == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		node, found := cordoned[pod.Spec.NodeName]
+		if !found {
+			var err error
+			node, err = rc.getNode(pod.Spec.NodeName)
+			if err != nil {
+				rc.ReqLogger.Error(err, "error retrieving k8s node of pod", "pod", pod.Name)
+				return nil, nil, err
+			}
+			if !isWorkerCordoned(node) {
+				node = nil
+			}
+			cordoned[pod.Spec.NodeName] = node
+		}
+		if node == nil {
+			continue
+		}
+
+		pinned, err := rc.isPodPinnedToWorker(pod, node)
+		if err != nil {
+			return nil, nil, err
+		}
+		if pinned {
+			pinnedPods = append(pinnedPods, pod)
+		} else {
+			pods = append(pods, pod)
+		}
+	}
+
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	sort.Slice(pinnedPods, func(i, j int) bool { return pinnedPods[i].Name < pinnedPods[j].Name })
+	return pods, pinnedPods, nil
+}
+
+// isPodPinnedToWorker Returns true when a PersistentVolume of the pod can only be used on the worker, see
+// getPersistentVolumeWorker
+func (rc *ReconciliationContext) isPodPinnedToWorker(pod *corev1.Pod, node *corev1.Node) (bool, error) {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc := &corev1.PersistentVolumeClaim{}
+		if err := rc.Client.Get(rc.Ctx, client.ObjectKey{Namespace: pod.Namespace, Name: volume.PersistentVolumeClaim.ClaimName}, pvc); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		if pvc.Spec.VolumeName == "" {
+			continue
+		}
+		pv := &corev1.PersistentVolume{}
+		if err := rc.Client.Get(rc.Ctx, client.ObjectKey{Name: pvc.Spec.VolumeName}, pv); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			rc.ReqLogger.Error(err, "error retrieving PersistentVolume", "pv", pvc.Spec.VolumeName)
+			return false, err
+		}
+		if worker := getPersistentVolumeWorker(pv); worker != "" &&
+			(worker == node.Name || worker == node.Labels[corev1.LabelHostname]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func joinPodNames(pods []*corev1.Pod) string {
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// isWorkerCordoned Returns true when no new pod can be scheduled on the worker, because it was
+// cordoned, e.g. by kubectl drain
+func isWorkerCordoned(node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return true
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == corev1.TaintNodeUnschedulable {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+)
+
+func TestIsWorkerCordoned(t *testing.T) {
+	assert.False(t, isWorkerCordoned(&corev1.Node{}))
+	assert.True(t, isWorkerCordoned(&corev1.Node{Spec: corev1.NodeSpec{Unschedulable: true}}))
+	assert.True(t, isWorkerCordoned(&corev1.Node{Spec: corev1.NodeSpec{
+		Taints: []corev1.Taint{{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}},
+	}}))
+}
+
+// fakePodEvictor deletes the pods it evicts, unless the PodDisruptionBudget is set to refuse them
+type fakePodEvictor struct {
+	client  client.Client
+	refused bool
+	evicted []string
+}
+
+func (e *fakePodEvictor) EvictPod(ctx context.Context, pod *corev1.Pod) error {
+	if e.refused {
+		return errors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 10)
+	}
+	e.evicted = append(e.evicted, pod.Name)
+	return e.client.Delete(ctx, pod)
+}
+
+func TestCheckCordonedWorkers(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+	evictor := &fakePodEvictor{client: rc.Client, refused: true}
+	rc.PodEvictor = evictor
+
+	rc.Datacenter.Spec.MovePodsFromCordonedWorkers = true
+	require.NoError(t, rc.Client.Update(rc.Ctx, rc.Datacenter))
+
+	for _, node := range []*corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "worker1"}, Spec: corev1.NodeSpec{Unschedulable: true}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "worker2"}},
+	} {
+		require.NoError(t, rc.Client.Create(rc.Ctx, node))
+	}
+
+	rc.dcPods = nil
+	for _, podNode := range [][]string{{"pod-a", "worker1"}, {"pod-b", "worker2"}} {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      podNode[0],
+				Namespace: rc.Datacenter.Namespace,
+				Labels:    rc.Datacenter.GetRackLabels("default"),
+			},
+			Spec: corev1.PodSpec{NodeName: podNode[1]},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: false}},
+			},
+		}
+		require.NoError(t, rc.Client.Create(rc.Ctx, pod))
+		rc.dcPods = append(rc.dcPods, pod)
+	}
+
+	// The pods are not moved while one of them is not ready
+	recResult := rc.CheckCordonedWorkers()
+	assert.True(t, recResult.Completed())
+	cond, found := rc.Datacenter.GetCondition(api.DatacenterMovingPods)
+	assert.True(t, found)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "Pods pod-a are running on cordoned workers", cond.Message)
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: rc.Datacenter.Namespace, Name: "pod-a"}, &corev1.Pod{}))
+
+	// Once all the pods are ready, the pod of the cordoned worker is evicted when the PodDisruptionBudget
+	// allows it
+	for _, pod := range rc.dcPods {
+		pod.Status.ContainerStatuses[0].Ready = true
+	}
+	recResult = rc.CheckCordonedWorkers()
+	assert.True(t, recResult.Completed())
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: rc.Datacenter.Namespace, Name: "pod-a"}, &corev1.Pod{}))
+
+	evictor.refused = false
+	recResult = rc.CheckCordonedWorkers()
+	assert.True(t, recResult.Completed())
+	assert.Equal(t, []string{"pod-a"}, evictor.evicted)
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: rc.Datacenter.Namespace, Name: "pod-a"}, &corev1.Pod{})
+	assert.True(t, errors.IsNotFound(err))
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: rc.Datacenter.Namespace, Name: "pod-b"}, &corev1.Pod{}))
+
+	// The condition is cleared once no pod is left on a cordoned worker
+	rc.dcPods = rc.dcPods[1:]
+	recResult = rc.CheckCordonedWorkers()
+	assert.False(t, recResult.Completed())
+	assert.Equal(t, corev1.ConditionFalse, rc.Datacenter.GetConditionStatus(api.DatacenterMovingPods))
+}
+
+func TestCheckCordonedWorkersDisabled(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.dcPods = []*corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-a", Namespace: rc.Datacenter.Namespace},
+		Spec:       corev1.PodSpec{NodeName: "missing-worker"},
+	}}
+
+	// The workers are not even looked up
+	recResult := rc.CheckCordonedWorkers()
+	assert.False(t, recResult.Completed())
+	_, found := rc.Datacenter.GetCondition(api.DatacenterMovingPods)
+	assert.False(t, found)
+}
+
+func TestCheckCordonedWorkersPinnedVolume(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+	evictor := &fakePodEvictor{client: rc.Client}
+	rc.PodEvictor = evictor
+
+	rc.Datacenter.Spec.MovePodsFromCordonedWorkers = true
+	require.NoError(t, rc.Client.Update(rc.Ctx, rc.Datacenter))
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker1", Labels: map[string]string{corev1.LabelHostname: "worker1"}},
+		Spec:       corev1.NodeSpec{Unschedulable: true},
+	}
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "local-pv"},
+		Spec: corev1.PersistentVolumeSpec{
+			NodeAffinity: &corev1.VolumeNodeAffinity{Required: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{{
+					Key: corev1.LabelHostname, Operator: corev1.NodeSelectorOpIn, Values: []string{"worker1"},
+				}}}},
+			}},
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "server-data-pod-a", Namespace: rc.Datacenter.Namespace},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "local-pv"},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod-a",
+			Namespace: rc.Datacenter.Namespace,
+			Labels:    rc.Datacenter.GetRackLabels("default"),
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "worker1",
+			Volumes: []corev1.Volume{{
+				Name: "server-data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "server-data-pod-a"},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}},
+		},
+	}
+	for _, obj := range []client.Object{node, pv, pvc, pod} {
+		require.NoError(t, rc.Client.Create(rc.Ctx, obj))
+	}
+	rc.dcPods = []*corev1.Pod{pod}
+
+	// The pod would stay Pending on another worker, it is only reported
+	recResult := rc.CheckCordonedWorkers()
+	assert.False(t, recResult.Completed())
+	assert.Empty(t, evictor.evicted)
+	cond, found := rc.Datacenter.GetCondition(api.DatacenterMovingPods)
+	assert.True(t, found)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "Pods pod-a can not move from cordoned workers, their volumes are pinned to them", cond.Message)
+}
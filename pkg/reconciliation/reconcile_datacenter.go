@@ -89,7 +89,39 @@ func (rc *ReconciliationContext) ProcessDeletion() result.ReconcileResult {
 		rc.ReqLogger.Error(err, "Failed to remove dynamic secret watches for CassandraDatacenter")
 	}
 
-	if err := rc.deletePVCs(); err != nil {
+	// The reaper objects are released first, so that the cluster is unregistered while Reaper still runs
+	if _, found := rc.Datacenter.GetCondition(api.DatacenterReaperRegistered); found {
+		if err := rc.removeReaper(); err != nil {
+			rc.ReqLogger.Error(err, "Failed to remove reaper for CassandraDatacenter")
+		}
+	}
+
+	// The nodes are snapshotted before the drain, so that the snapshots hold every write they acknowledged.
+	// The seed labels go away with the pods.
+	if rc.Datacenter.Spec.SnapshotOnDelete {
+		if err := rc.snapshotPods(); err != nil {
+			rc.ReqLogger.Error(err, "Failed to snapshot pods for CassandraDatacenter")
+			return result.Error(err)
+		}
+	}
+
+	if err := rc.drainPods(); err != nil {
+		rc.ReqLogger.Error(err, "Failed to drain pods for CassandraDatacenter")
+		return result.Error(err)
+	}
+
+	// The PodDisruptionBudget would otherwise block the drain of the workers until the pods are collected
+	if err := rc.deletePodDisruptionBudget(); err != nil {
+		rc.ReqLogger.Error(err, "Failed to delete the PodDisruptionBudget of the CassandraDatacenter")
+		return result.Error(err)
+	}
+
+	if rc.Datacenter.GetPVCReclaimPolicy() == api.PVCReclaimPolicyRetain {
+		if err := rc.retainPVCs(); err != nil {
+			rc.ReqLogger.Error(err, "Failed to retain PVCs for CassandraDatacenter")
+			return result.Error(err)
+		}
+	} else if err := rc.deletePVCs(); err != nil {
 		rc.ReqLogger.Error(err, "Failed to delete PVCs for CassandraDatacenter")
 		return result.Error(err)
 	}
@@ -112,6 +144,117 @@ func (rc *ReconciliationContext) ProcessDeletion() result.ReconcileResult {
 	return result.Done()
 }
 
+// listClusterDatacenters Returns the datacenters in the namespace that share the clusterName of the
+// datacenter, including itself
+func (rc *ReconciliationContext) listClusterDatacenters() ([]api.CassandraDatacenter, error) {
+	dcList := &api.CassandraDatacenterList{}
+	if err := rc.Client.List(rc.Ctx, dcList, client.InNamespace(rc.Datacenter.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var dcs []api.CassandraDatacenter
+	for _, dc := range dcList.Items {
+		if dc.Spec.ClusterName == rc.Datacenter.Spec.ClusterName {
+			dcs = append(dcs, dc)
+		}
+	}
+	return dcs, nil
+}
+
+// snapshotPods takes the snapshotOnDelete snapshot of every running node that was neither snapshotted nor
+// drained yet. A failed snapshot holds the deletion back, so that it is retried before the nodes are drained.
+func (rc *ReconciliationContext) snapshotPods() error {
+	rc.ReqLogger.Info("reconciler::snapshotPods")
+
+	podList, err := rc.listPods(rc.Datacenter.GetDatacenterLabels())
+	if err != nil {
+		return err
+	}
+
+	snapshotName := rc.Datacenter.GetDeletionSnapshotName()
+	nodesSnapshotted := 0
+
+	for _, pod := range PodPtrsFromPodList(podList) {
+		if _, snapshotted := pod.Annotations[api.SnapshottedAnnotation]; snapshotted {
+			continue
+		}
+		if _, drained := pod.Annotations[api.DrainedAnnotation]; drained {
+			continue
+		}
+		if !isMgmtApiRunning(pod) {
+			rc.ReqLogger.Info("Not snapshotting the pod, its management API is not running", "pod", pod.Name)
+			continue
+		}
+
+		if err := rc.NodeMgmtClient.CallCreateSnapshotEndpoint(pod, snapshotName, nil); err != nil {
+			return err
+		}
+		nodesSnapshotted++
+
+		podPatch := client.MergeFrom(pod.DeepCopy())
+		metav1.SetMetaDataAnnotation(&pod.ObjectMeta, api.SnapshottedAnnotation, snapshotName)
+		if err := rc.Client.Patch(rc.Ctx, pod, podPatch); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	rc.ReqLogger.Info("datacenter snapshots done",
+		"snapshotName", snapshotName,
+		"nodesSnapshotted", nodesSnapshotted,
+	)
+
+	return nil
+}
+
+// drainPods Drains the Cassandra nodes of the datacenter before its resources are garbage
+// collected, so that the nodes stop accepting traffic and flush their memtables instead
+// of being killed. Drain errors are only logged, since the pods are going away anyway.
+// The drained pods are annotated with DrainedAnnotation, and skipped by the retries.
+func (rc *ReconciliationContext) drainPods() error {
+	rc.ReqLogger.Info("reconciler::drainPods")
+
+	podList, err := rc.listPods(rc.Datacenter.GetDatacenterLabels())
+	if err != nil {
+		return err
+	}
+
+	nodesDrained := 0
+	nodeDrainErrors := 0
+
+	for _, pod := range PodPtrsFromPodList(podList) {
+		if _, drained := pod.Annotations[api.DrainedAnnotation]; drained || !isMgmtApiRunning(pod) {
+			continue
+		}
+
+		nodesDrained++
+		if err := rc.NodeMgmtClient.CallDrainEndpoint(pod); err != nil {
+			rc.ReqLogger.Error(err, "error during node drain", "pod", pod.Name)
+			nodeDrainErrors++
+			continue
+		}
+
+		podPatch := client.MergeFrom(pod.DeepCopy())
+		metav1.SetMetaDataAnnotation(&pod.ObjectMeta, api.DrainedAnnotation, "true")
+		if err := rc.Client.Patch(rc.Ctx, pod, podPatch); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	rc.ReqLogger.Info("datacenter drains done",
+		"nodesDrained", nodesDrained,
+		"nodeDrainErrors", nodeDrainErrors,
+	)
+
+	return nil
+}
+
+// deletePodDisruptionBudget Deletes the PodDisruptionBudget of the datacenter once its nodes are drained
+func (rc *ReconciliationContext) deletePodDisruptionBudget() error {
+	pdb := newPodDisruptionBudgetForDatacenter(rc.Datacenter)
+	rc.ReqLogger.Info("reconciler::deletePodDisruptionBudget", "pdb", pdb.Name)
+	return client.IgnoreNotFound(rc.Client.Delete(rc.Ctx, pdb))
+}
+
 func (rc *ReconciliationContext) deletePVCs() error {
 	rc.ReqLogger.Info("reconciler::deletePVCs")
 	logger := rc.ReqLogger.WithValues(
@@ -147,6 +290,52 @@ func (rc *ReconciliationContext) deletePVCs() error {
 	return nil
 }
 
+// retainPVCs Keeps the PVCs of the datacenter for the Retain reclaimPolicy, labeled with the cluster and
+// datacenter they belong to and annotated with RetainedFromAnnotation, so that they can be found and
+// adopted again by a datacenter recreated with the same names.
+func (rc *ReconciliationContext) retainPVCs() error {
+	rc.ReqLogger.Info("reconciler::retainPVCs")
+	logger := rc.ReqLogger.WithValues(
+		"cassandraDatacenterNamespace", rc.Datacenter.Namespace,
+		"cassandraDatacenterName", rc.Datacenter.Name,
+	)
+
+	persistentVolumeClaimList, err := rc.listPVCs()
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("No PVCs found for CassandraDatacenter")
+			return nil
+		}
+		logger.Error(err, "Failed to list PVCs for cassandraDatacenter")
+		return err
+	}
+
+	retainedFrom := rc.Datacenter.Spec.ClusterName + "/" + rc.Datacenter.Name
+	for i := range persistentVolumeClaimList.Items {
+		pvc := &persistentVolumeClaimList.Items[i]
+		if pvc.Annotations[api.RetainedFromAnnotation] == retainedFrom {
+			continue
+		}
+
+		pvcPatch := client.MergeFrom(pvc.DeepCopy())
+		pvc.Labels = utils.MergeMap(pvc.Labels, rc.Datacenter.GetDatacenterLabels())
+		if pvc.Annotations == nil {
+			pvc.Annotations = map[string]string{}
+		}
+		pvc.Annotations[api.RetainedFromAnnotation] = retainedFrom
+		if err := rc.Client.Patch(rc.Ctx, pvc, pvcPatch); err != nil {
+			logger.Error(err, "Failed to annotate retained PVC for cassandraDatacenter", "pvcName", pvc.Name)
+			return err
+		}
+		logger.Info(
+			"Retained PVC",
+			"pvcNamespace", pvc.Namespace,
+			"pvcName", pvc.Name)
+	}
+
+	return nil
+}
+
 func (rc *ReconciliationContext) listPVCs() (*corev1.PersistentVolumeClaimList, error) {
 	rc.ReqLogger.Info("reconciler::listPVCs")
 
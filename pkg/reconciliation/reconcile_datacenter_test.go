@@ -5,7 +5,11 @@ package reconciliation
 
 import (
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -13,7 +17,10 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/httphelper"
 	"github.com/k8ssandra/cass-operator/pkg/mocks"
 )
 
@@ -117,3 +124,169 @@ func TestDeletePVCs_FailedToDelete(t *testing.T) {
 
 	assert.EqualError(t, err, "failed to delete")
 }
+
+func TestDrainPods(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	startedAt := metav1.NewTime(time.Now().Add(-time.Minute))
+	for i := 0; i < 2; i++ {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", i),
+				Namespace: rc.Datacenter.Namespace,
+				Labels:    rc.Datacenter.GetDatacenterLabels(),
+			},
+			Status: v1.PodStatus{
+				PodIP: fmt.Sprintf("10.0.0.%d", i),
+				ContainerStatuses: []v1.ContainerStatus{{
+					Name: "cassandra",
+					State: v1.ContainerState{
+						Running: &v1.ContainerStateRunning{StartedAt: startedAt},
+					},
+				}},
+			},
+		}
+		assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+	}
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && strings.HasSuffix(req.URL.Path, "/ops/node/drain")
+			})).
+		Return(func(*http.Request) *http.Response {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader("OK")),
+			}
+		}, nil).
+		Times(2)
+
+	rc.NodeMgmtClient = &httphelper.NodeMgmtClient{
+		Client:   mockHttpClient,
+		Log:      rc.ReqLogger,
+		Protocol: "http",
+	}
+
+	assert.NoError(t, rc.drainPods())
+	mockHttpClient.AssertExpectations(t)
+
+	// The drained pods are not drained again by the retries of the deletion
+	assert.NoError(t, rc.drainPods())
+	mockHttpClient.AssertNumberOfCalls(t, "Do", 2)
+
+	podList, err := rc.listPods(rc.Datacenter.GetDatacenterLabels())
+	assert.NoError(t, err)
+	for _, pod := range podList.Items {
+		assert.Equal(t, "true", pod.Annotations[api.DrainedAnnotation])
+	}
+}
+
+func TestSnapshotPods(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	deletedAt := metav1.NewTime(time.Date(2022, time.June, 1, 12, 30, 0, 0, time.UTC))
+	rc.Datacenter.DeletionTimestamp = &deletedAt
+	rc.Datacenter.Spec.SnapshotOnDelete = true
+
+	startedAt := metav1.NewTime(time.Now().Add(-time.Minute))
+	for i := 0; i < 3; i++ {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", i),
+				Namespace: rc.Datacenter.Namespace,
+				Labels:    rc.Datacenter.GetDatacenterLabels(),
+			},
+			Status: v1.PodStatus{
+				PodIP: fmt.Sprintf("10.0.0.%d", i),
+				ContainerStatuses: []v1.ContainerStatus{{
+					Name: "cassandra",
+					State: v1.ContainerState{
+						Running: &v1.ContainerStateRunning{StartedAt: startedAt},
+					},
+				}},
+			},
+		}
+		if i == 2 {
+			// A drained node is not snapshotted anymore
+			pod.Annotations = map[string]string{api.DrainedAnnotation: "true"}
+		}
+		assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+	}
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/ops/node/snapshots")
+			})).
+		Return(func(*http.Request) *http.Response {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader("OK")),
+			}
+		}, nil).
+		Times(2)
+
+	rc.NodeMgmtClient = &httphelper.NodeMgmtClient{
+		Client:   mockHttpClient,
+		Log:      rc.ReqLogger,
+		Protocol: "http",
+	}
+
+	assert.NoError(t, rc.snapshotPods())
+	mockHttpClient.AssertExpectations(t)
+
+	// The snapshotted pods are not snapshotted again by the retries of the deletion
+	assert.NoError(t, rc.snapshotPods())
+	mockHttpClient.AssertNumberOfCalls(t, "Do", 2)
+
+	podList, err := rc.listPods(rc.Datacenter.GetDatacenterLabels())
+	assert.NoError(t, err)
+	for _, pod := range podList.Items {
+		if pod.Name == "pod-2" {
+			assert.NotContains(t, pod.Annotations, api.SnapshottedAnnotation)
+		} else {
+			assert.Equal(t, "deleted-20220601123000", pod.Annotations[api.SnapshottedAnnotation])
+		}
+	}
+}
+
+func TestDeletePodDisruptionBudget(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	pdb := newPodDisruptionBudgetForDatacenter(rc.Datacenter)
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pdb))
+
+	assert.NoError(t, rc.deletePodDisruptionBudget())
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: pdb.Namespace, Name: pdb.Name}, pdb)
+	assert.True(t, errors.IsNotFound(err))
+
+	// Retrying the deletion is fine
+	assert.NoError(t, rc.deletePodDisruptionBudget())
+}
+
+func TestRetainPVCs(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "server-data-pod-0",
+			Namespace: rc.Datacenter.Namespace,
+			Labels:    map[string]string{api.DatacenterLabel: rc.Datacenter.Name},
+		},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pvc))
+
+	assert.NoError(t, rc.retainPVCs())
+
+	retained := &v1.PersistentVolumeClaim{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: pvc.Namespace, Name: pvc.Name}, retained))
+	assert.Equal(t, rc.Datacenter.Spec.ClusterName+"/"+rc.Datacenter.Name, retained.Annotations[api.RetainedFromAnnotation])
+	assert.Equal(t, rc.Datacenter.Spec.ClusterName, retained.Labels[api.ClusterLabel])
+}
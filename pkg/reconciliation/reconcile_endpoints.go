@@ -48,7 +48,7 @@ func (rc *ReconciliationContext) CheckAdditionalSeedEndpoints() result.Reconcile
 	logger.Info("reconcile_endpoints::CheckAdditionalSeedEndpoints")
 
 	if len(dc.Spec.AdditionalSeeds) == 0 {
-		return result.Continue()
+		return rc.removeAdditionalSeedEndpoints()
 	}
 
 	desiredEndpoints, err := newEndpointsForAdditionalSeeds(dc)
@@ -121,6 +121,53 @@ func (rc *ReconciliationContext) CheckAdditionalSeedEndpoints() result.Reconcile
 	return result.Continue()
 }
 
+// removeAdditionalSeedEndpoints Removes the addresses of previously set additional seeds from
+// the endpoints of the additional seed service, once they are removed from the spec. Addresses
+// managed by something else are kept, the endpoints are deleted if none are left.
+func (rc *ReconciliationContext) removeAdditionalSeedEndpoints() result.ReconcileResult {
+	logger := rc.ReqLogger
+
+	currentEndpoints, err := rc.GetAdditionalSeedEndpoint()
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return result.Continue()
+		}
+		logger.Error(err, "Could not get endpoints for additional seed service")
+		return result.Error(err)
+	}
+
+	managedAddresses := 0
+	keptAddresses := make([]corev1.EndpointAddress, 0)
+	for _, subset := range currentEndpoints.Subsets {
+		for _, addr := range subset.Addresses {
+			managedAddresses++
+			if addr.TargetRef != nil {
+				keptAddresses = append(keptAddresses, addr)
+			}
+		}
+	}
+
+	if len(keptAddresses) == 0 {
+		logger.Info("Deleting endpoints for additional seed service", "endpoints", currentEndpoints.Name)
+		if err := rc.Client.Delete(rc.Ctx, currentEndpoints); err != nil && !errors.IsNotFound(err) {
+			logger.Error(err, "Unable to delete endpoints for additional seed service")
+			return result.Error(err)
+		}
+		return result.Continue()
+	}
+
+	if len(keptAddresses) < managedAddresses {
+		logger.Info("Removing additional seeds from endpoints for additional seed service", "endpoints", currentEndpoints.Name)
+		currentEndpoints.Subsets = []corev1.EndpointSubset{{Addresses: keptAddresses}}
+		if err := rc.Client.Update(rc.Ctx, currentEndpoints); err != nil {
+			logger.Error(err, "Unable to update endpoints for additional seed service")
+			return result.Error(err)
+		}
+	}
+
+	return result.Continue()
+}
+
 func (rc *ReconciliationContext) GetAdditionalSeedEndpoint() (*corev1.Endpoints, error) {
 	dc := rc.Datacenter
 	nsName := types.NamespacedName{Name: dc.GetAdditionalSeedsServiceName(), Namespace: dc.Namespace}
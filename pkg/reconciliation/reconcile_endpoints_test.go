@@ -0,0 +1,51 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestCheckAdditionalSeedEndpoints_RemovedSeeds(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.AdditionalSeeds = []string{"192.168.1.1", "192.168.1.2"}
+	result := rc.CheckAdditionalSeedEndpoints()
+	assert.False(t, result.Completed())
+
+	endpoints, err := rc.GetAdditionalSeedEndpoint()
+	assert.NoError(t, err)
+	assert.Len(t, endpoints.Subsets[0].Addresses, 2)
+
+	// Addresses managed by something else are kept
+	endpoints.Subsets[0].Addresses = append(endpoints.Subsets[0].Addresses, corev1.EndpointAddress{
+		IP:        "10.0.0.1",
+		TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "other-dc-pod"},
+	})
+	assert.NoError(t, rc.Client.Update(rc.Ctx, endpoints))
+
+	rc.Datacenter.Spec.AdditionalSeeds = nil
+	result = rc.CheckAdditionalSeedEndpoints()
+	assert.False(t, result.Completed())
+
+	endpoints, err = rc.GetAdditionalSeedEndpoint()
+	assert.NoError(t, err)
+	assert.Len(t, endpoints.Subsets[0].Addresses, 1)
+	assert.Equal(t, "10.0.0.1", endpoints.Subsets[0].Addresses[0].IP)
+
+	// The endpoints are deleted once no address is left
+	endpoints.Subsets[0].Addresses[0].TargetRef = nil
+	assert.NoError(t, rc.Client.Update(rc.Ctx, endpoints))
+
+	result = rc.CheckAdditionalSeedEndpoints()
+	assert.False(t, result.Completed())
+
+	_, err = rc.GetAdditionalSeedEndpoint()
+	assert.True(t, errors.IsNotFound(err))
+}
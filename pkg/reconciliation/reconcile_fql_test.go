@@ -142,7 +142,7 @@ func TestCheckFullQueryLoggingNoChangeEnabled(t *testing.T) {
 	// Enable FQL config in the Datacenter
 	rc.Datacenter.Spec.Config = json.RawMessage(fqlEnabledConfig)
 
-	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+	rc.NodeMgmtClient = &httphelper.NodeMgmtClient{
 		Client:   mockHttpClient,
 		Log:      rc.ReqLogger,
 		Protocol: "http",
@@ -169,7 +169,7 @@ func TestCheckFullQueryLoggingNoChangeDisabled(t *testing.T) {
 	// Don't enable FQL config in the Datacenter
 	// rc.Datacenter.Spec.Config = json.RawMessage(fqlDisabledConfig)
 
-	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+	rc.NodeMgmtClient = &httphelper.NodeMgmtClient{
 		Client:   mockHttpClient,
 		Log:      rc.ReqLogger,
 		Protocol: "http",
@@ -190,7 +190,7 @@ func TestCheckFullQueryNotSupported(t *testing.T) {
 	// Mock features request to not support FQL
 	mockFeaturesNotAvailable(mockHttpClient)
 
-	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+	rc.NodeMgmtClient = &httphelper.NodeMgmtClient{
 		Client:   mockHttpClient,
 		Log:      rc.ReqLogger,
 		Protocol: "http",
@@ -217,7 +217,7 @@ func TestCheckFullQueryLoggingChangeToEnabled(t *testing.T) {
 	// Enable FQL config in the Datacenter
 	rc.Datacenter.Spec.Config = json.RawMessage(fqlEnabledConfig)
 
-	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+	rc.NodeMgmtClient = &httphelper.NodeMgmtClient{
 		Client:   mockHttpClient,
 		Log:      rc.ReqLogger,
 		Protocol: "http",
@@ -243,7 +243,7 @@ func TestCheckFullQueryLoggingChangeToDisabled(t *testing.T) {
 
 	// Keep FQL config disabled in the Datacenter
 
-	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+	rc.NodeMgmtClient = &httphelper.NodeMgmtClient{
 		Client:   mockHttpClient,
 		Log:      rc.ReqLogger,
 		Protocol: "http",
@@ -267,7 +267,7 @@ func TestCheckFullQueryNotSupportedTriedToUse(t *testing.T) {
 	// Enable FQL config in the Datacenter
 	rc.Datacenter.Spec.Config = json.RawMessage(fqlEnabledConfig)
 
-	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+	rc.NodeMgmtClient = &httphelper.NodeMgmtClient{
 		Client:   mockHttpClient,
 		Log:      rc.ReqLogger,
 		Protocol: "http",
@@ -0,0 +1,199 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+)
+
+// GrafanaDashboardLabel is the label the Grafana sidecar looks for to load the dashboards of
+// config maps
+const GrafanaDashboardLabel = "grafana_dashboard"
+
+type grafanaPanel struct {
+	title string
+	unit  string
+	exprs []string
+}
+
+type grafanaDashboard struct {
+	key    string
+	title  string
+	panels []grafanaPanel
+}
+
+// CheckGrafanaDashboards When the Monitoring property is set, publishes the dashboards of the
+// datacenter in a config map labeled for the Grafana sidecar.
+func (rc *ReconciliationContext) CheckGrafanaDashboards() result.ReconcileResult {
+	dc := rc.Datacenter
+	if dc.Spec.Monitoring == nil {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_grafana::CheckGrafanaDashboards")
+
+	data, err := getGrafanaDashboardsData(dc)
+	if err != nil {
+		return result.Error(err)
+	}
+
+	configMap, exists, err := rc.getDatacenterConfigMap(getGrafanaDashboardsConfigMapName(dc))
+	if err != nil {
+		rc.ReqLogger.Error(err, "failed to get grafana dashboards config map")
+		return result.Error(err)
+	}
+
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddOperatorLabels(labels, dc)
+	labels[GrafanaDashboardLabel] = "1"
+
+	if exists && mapContains(configMap.Labels, labels) && mapContains(configMap.Data, data) && len(configMap.Data) == len(data) {
+		return result.Continue()
+	}
+
+	configMap.Labels = utils.MergeMap(map[string]string{}, configMap.Labels, labels)
+	configMap.Data = data
+
+	if exists {
+		rc.ReqLogger.Info("updating grafana dashboards config map", "ConfigMap", configMap.Name)
+		if err := rc.Client.Update(rc.Ctx, configMap); err != nil {
+			rc.ReqLogger.Error(err, "failed to update grafana dashboards config map", "ConfigMap", configMap.Name)
+			return result.Error(err)
+		}
+	} else {
+		rc.ReqLogger.Info("creating grafana dashboards config map", "ConfigMap", configMap.Name)
+		if err := rc.Client.Create(rc.Ctx, configMap); err != nil {
+			rc.ReqLogger.Error(err, "failed to create grafana dashboards config map", "ConfigMap", configMap.Name)
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+			"Created config map %s", configMap.Name)
+	}
+
+	return result.Continue()
+}
+
+// getGrafanaDashboardsConfigMapName The format is clusterName-dcName-grafana-dashboards
+func getGrafanaDashboardsConfigMapName(dc *api.CassandraDatacenter) string {
+	return api.CleanupForKubernetes(dc.Spec.ClusterName) + "-" + dc.Name + "-grafana-dashboards"
+}
+
+// getGrafanaDashboards The queries select the series of the datacenter with the labels added
+// by the metrics exporter.
+func getGrafanaDashboards(dc *api.CassandraDatacenter) []grafanaDashboard {
+	selector := fmt.Sprintf(`cluster="%s",datacenter="%s"`, dc.Spec.ClusterName, dc.DatacenterName())
+	stat := func(name string) string {
+		return fmt.Sprintf(`cassandra_stats{%s,name="%s"}`, selector, name)
+	}
+
+	return []grafanaDashboard{
+		{
+			key:   "ring-health.json",
+			title: "Ring Health",
+			panels: []grafanaPanel{
+				{title: "Nodes up", exprs: []string{
+					fmt.Sprintf(`sum(up{service="%s"})`, dc.GetAllPodsServiceName()),
+				}},
+				{title: "Nodes pending", exprs: []string{
+					fmt.Sprintf(`cass_operator_datacenter_nodes_pending{namespace="%s",datacenter="%s"}`, dc.Namespace, dc.Name),
+				}},
+				{title: "Down endpoints seen by the nodes", exprs: []string{
+					fmt.Sprintf(`max by (pod) (%s)`, stat("org:apache:cassandra:net:failuredetector:downendpointcount")),
+				}},
+				{title: "Dropped messages", unit: "ops", exprs: []string{
+					fmt.Sprintf(`sum by (pod) (rate(%s[5m]))`, stat("org:apache:cassandra:metrics:droppedmessage:dropped:count")),
+				}},
+			},
+		},
+		{
+			key:   "compactions.json",
+			title: "Compactions",
+			panels: []grafanaPanel{
+				{title: "Pending compactions", exprs: []string{
+					fmt.Sprintf(`sum by (pod) (%s)`, stat("org:apache:cassandra:metrics:compaction:pendingtasks:value")),
+				}},
+				{title: "Completed compactions", unit: "ops", exprs: []string{
+					fmt.Sprintf(`sum by (pod) (rate(%s[5m]))`, stat("org:apache:cassandra:metrics:compaction:completedtasks:value")),
+				}},
+				{title: "Compacted bytes", unit: "Bps", exprs: []string{
+					fmt.Sprintf(`sum by (pod) (rate(%s[5m]))`, stat("org:apache:cassandra:metrics:compaction:bytescompacted:count")),
+				}},
+			},
+		},
+		{
+			key:   "latencies.json",
+			title: "Latencies",
+			panels: []grafanaPanel{
+				{title: "Read latency (p99)", unit: "µs", exprs: []string{
+					fmt.Sprintf(`max by (pod) (%s)`, stat("org:apache:cassandra:metrics:clientrequest:read:latency:99thpercentile")),
+				}},
+				{title: "Write latency (p99)", unit: "µs", exprs: []string{
+					fmt.Sprintf(`max by (pod) (%s)`, stat("org:apache:cassandra:metrics:clientrequest:write:latency:99thpercentile")),
+				}},
+				{title: "Requests", unit: "ops", exprs: []string{
+					fmt.Sprintf(`sum(rate(%s[5m]))`, stat("org:apache:cassandra:metrics:clientrequest:read:latency:count")),
+					fmt.Sprintf(`sum(rate(%s[5m]))`, stat("org:apache:cassandra:metrics:clientrequest:write:latency:count")),
+				}},
+			},
+		},
+	}
+}
+
+func getGrafanaDashboardsData(dc *api.CassandraDatacenter) (map[string]string, error) {
+	data := map[string]string{}
+	for _, dashboard := range getGrafanaDashboards(dc) {
+		panels := make([]interface{}, 0, len(dashboard.panels))
+		for i, panel := range dashboard.panels {
+			targets := make([]interface{}, 0, len(panel.exprs))
+			for j, expr := range panel.exprs {
+				targets = append(targets, map[string]interface{}{
+					"expr":  expr,
+					"refId": string(rune('A' + j)),
+				})
+			}
+			panels = append(panels, map[string]interface{}{
+				"id":    i + 1,
+				"type":  "timeseries",
+				"title": panel.title,
+				"gridPos": map[string]interface{}{
+					"x": (i % 2) * 12, "y": (i / 2) * 8, "w": 12, "h": 8,
+				},
+				"fieldConfig": map[string]interface{}{
+					"defaults": map[string]interface{}{"unit": panel.unit},
+				},
+				"targets": targets,
+			})
+		}
+
+		// The sidecar loads the dashboards of all the datacenters in the same folder, by key, and Grafana
+		// identifies them by uid
+		key := api.CleanupForKubernetes(dc.Spec.ClusterName) + "-" + dc.Name + "-" + dashboard.key
+		uid := sha256.Sum256([]byte(dc.Namespace + "/" + key))
+		bytes, err := json.MarshalIndent(map[string]interface{}{
+			"uid":           hex.EncodeToString(uid[:])[:20],
+			"title":         fmt.Sprintf("Cassandra %s / %s - %s", dc.Spec.ClusterName, dc.Name, dashboard.title),
+			"tags":          []string{"cassandra", dc.Spec.ClusterName, dc.Name},
+			"schemaVersion": 36,
+			"refresh":       "30s",
+			"time":          map[string]interface{}{"from": "now-1h", "to": "now"},
+			"panels":        panels,
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		data[key] = string(bytes)
+	}
+	return data, nil
+}
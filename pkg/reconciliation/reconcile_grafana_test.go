@@ -0,0 +1,60 @@
+package reconciliation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+)
+
+func TestCheckGrafanaDashboards(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	key := types.NamespacedName{Namespace: dc.Namespace, Name: getGrafanaDashboardsConfigMapName(dc)}
+
+	// Nothing is created while monitoring is disabled
+	result := rc.CheckGrafanaDashboards()
+	assert.False(t, result.Completed())
+	assert.Error(t, rc.Client.Get(rc.Ctx, key, &corev1.ConfigMap{}))
+
+	dc.Spec.Monitoring = &api.MonitoringSpec{}
+	result = rc.CheckGrafanaDashboards()
+	assert.False(t, result.Completed())
+
+	configMap := &corev1.ConfigMap{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, configMap))
+	assert.Equal(t, "1", configMap.Labels[GrafanaDashboardLabel])
+	assert.Equal(t, dc.Name, configMap.Labels[api.DatacenterLabel])
+	require.Len(t, configMap.Data, 3)
+
+	// The keys and uids of the dashboards are unique to the datacenter
+	latenciesKey := api.CleanupForKubernetes(dc.Spec.ClusterName) + "-" + dc.Name + "-latencies.json"
+	dashboard := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(configMap.Data[latenciesKey]), &dashboard))
+	assert.Equal(t, "Cassandra "+dc.Spec.ClusterName+" / "+dc.Name+" - Latencies", dashboard["title"])
+	assert.Contains(t, configMap.Data[latenciesKey], `cluster=\"`+dc.Spec.ClusterName+`\",datacenter=\"`+dc.Name+`\"`)
+
+	otherDc := dc.DeepCopy()
+	otherDc.Name = "dc2"
+	otherData, err := getGrafanaDashboardsData(otherDc)
+	require.NoError(t, err)
+	otherDashboard := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(otherData[api.CleanupForKubernetes(dc.Spec.ClusterName)+"-dc2-latencies.json"]), &otherDashboard))
+	assert.NotEmpty(t, dashboard["uid"])
+	assert.NotEqual(t, dashboard["uid"], otherDashboard["uid"])
+
+	// Dashboards edited by hand are restored
+	configMap.Data[latenciesKey] = "{}"
+	require.NoError(t, rc.Client.Update(rc.Ctx, configMap))
+	result = rc.CheckGrafanaDashboards()
+	assert.False(t, result.Completed())
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, configMap))
+	assert.NotEqual(t, "{}", configMap.Data[latenciesKey])
+}
@@ -0,0 +1,334 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+)
+
+const (
+	internodeKeystoresVolumeName  = "internode-keystores"
+	internodeEncryptionVolumeName = "internode-encryption"
+	internodeKeystoresContainer   = "internode-keystores-init"
+	internodeTruststoreKey        = "truststore.jks"
+
+	// internodeKeystoresPath is where the secret of the keystores is mounted, as a directory so that the
+	// renewed keystores reach the running pods
+	internodeKeystoresPath = api.InternodeEncryptionDir + "/keystores"
+
+	// truststoreHashAnnotation records the CA certificates of the truststore, the JKS encoding itself
+	// is not stable
+	truststoreHashAnnotation = "cassandra.datastax.com/truststore-hash"
+
+	// keystoresRenewedAnnotation records when the keystores of the nodes were last renewed
+	keystoresRenewedAnnotation = "cassandra.datastax.com/keystores-renewed"
+
+	// keystoreRenewBefore is how long before its expiry the keystore of a node is renewed, the
+	// keystores are valid for a year
+	keystoreRenewBefore = 30 * 24 * time.Hour
+)
+
+// internodeKeystoresScript links the keystore of the pod and the truststore where the
+// server_encryption_options expect them
+const internodeKeystoresScript = `ln -sf keystores/"${POD_NAME}".jks ` + api.InternodeKeystorePath + `
+ln -sf keystores/` + internodeTruststoreKey + ` ` + api.InternodeTruststorePath + `
+`
+
+// CheckInternodeKeystores When InternodeEncryption is enabled, generates the keystores of the nodes
+// and the truststore before the pods need them. The keystore of each node is signed by the CA of the
+// datacenter, and the truststore trusts the CAs of all the datacenters of the cluster. The keystores
+// are renewed before they expire, and the hash annotation of the datacenter, copied to the pods,
+// restarts the pods when the keystores are renewed or the truststore changes.
+func (rc *ReconciliationContext) CheckInternodeKeystores() result.ReconcileResult {
+	dc := rc.Datacenter
+	if !dc.Spec.InternodeEncryption {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_internode_encryption::CheckInternodeKeystores")
+
+	if dc.Spec.CertManager != nil {
+		return rc.checkInternodeKeystoresFromCertificate()
+	}
+
+	ca, err := rc.retrieveInternodeCredentialSecretOrCreateDefault()
+	if err != nil {
+		rc.ReqLogger.Error(err, "error retrieving InternodeCredential for CassandraDatacenter.")
+		return result.Error(err)
+	}
+
+	cas, err := rc.getClusterInternodeCAs(ca)
+	if err != nil {
+		return result.Error(err)
+	}
+
+	secretName := types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetInternodeKeystoresSecretName()}
+	secret, err := rc.retrieveSecret(secretName)
+	exists := true
+	if errors.IsNotFound(err) {
+		exists = false
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName.Name,
+				Namespace: secretName.Namespace,
+				Labels:    dc.GetDatacenterLabels(),
+			},
+		}
+		oplabels.AddOperatorLabels(secret.Labels, dc)
+		if err := rc.SetDatacenterAsOwner(secret); err != nil {
+			return result.Error(err)
+		}
+	} else if err != nil {
+		return result.Error(err)
+	}
+	if _, found := secret.Annotations[keystoreSourceHashAnnotation]; found || secret.Data == nil {
+		// The keystores were converted from a certificate of cert-manager, they are generated again
+		delete(secret.Annotations, keystoreSourceHashAnnotation)
+		secret.Data = map[string][]byte{}
+	}
+
+	updated := false
+
+	truststoreHash := getTruststoreHash(cas)
+	if _, found := secret.Data[internodeTruststoreKey]; !found || secret.Annotations[truststoreHashAnnotation] != truststoreHash {
+		truststore, err := utils.GenerateTruststore(cas, dc.GetKeystorePassword())
+		if err != nil {
+			return result.Error(err)
+		}
+		secret.Data[internodeTruststoreKey] = truststore
+		metav1.SetMetaDataAnnotation(&secret.ObjectMeta, truststoreHashAnnotation, truststoreHash)
+		updated = true
+	}
+
+	renewed := false
+	for _, podName := range getDesiredPodNames(dc) {
+		key := podName + ".jks"
+		if keystore, found := secret.Data[key]; found {
+			if !keystoreNeedsRenewal(keystore, dc.GetKeystorePassword()) {
+				continue
+			}
+			renewed = true
+		}
+		keystore, err := utils.GenerateJKS(ca, podName, dc.GetKeystorePassword())
+		if err != nil {
+			return result.Error(err)
+		}
+		secret.Data[key] = keystore
+		updated = true
+	}
+	if renewed {
+		rc.ReqLogger.Info("renewing the internode keystores close to their expiry", "Secret", secret.Name)
+		metav1.SetMetaDataAnnotation(&secret.ObjectMeta, keystoresRenewedAnnotation, time.Now().UTC().Format(time.RFC3339))
+	}
+
+	keystoresHash := getConfigDataHash(secret.Annotations[truststoreHashAnnotation] + "\n" +
+		secret.Annotations[keystoresRenewedAnnotation])
+
+	if !updated {
+		return rc.checkHashAnnotation(api.InternodeKeystoresHashAnnotation, keystoresHash)
+	}
+
+	if exists {
+		rc.ReqLogger.Info("updating internode keystores secret", "Secret", secret.Name)
+		if err := rc.Client.Update(rc.Ctx, secret); err != nil {
+			rc.ReqLogger.Error(err, "failed to update internode keystores secret", "Secret", secret.Name)
+			return result.Error(err)
+		}
+	} else {
+		rc.ReqLogger.Info("creating internode keystores secret", "Secret", secret.Name)
+		if err := rc.Client.Create(rc.Ctx, secret); err != nil {
+			rc.ReqLogger.Error(err, "failed to create internode keystores secret", "Secret", secret.Name)
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+			"Created secret %s", secret.Name)
+	}
+
+	return rc.checkHashAnnotation(api.InternodeKeystoresHashAnnotation, keystoresHash)
+}
+
+// checkHashAnnotation Records the hash in the annotation of the datacenter, which the pod template
+// copies so that the pods are restarted when it changes
+func (rc *ReconciliationContext) checkHashAnnotation(annotation, hash string) result.ReconcileResult {
+	if rc.Datacenter.Annotations[annotation] == hash {
+		return result.Continue()
+	}
+
+	patch := client.MergeFrom(rc.Datacenter.DeepCopy())
+	metav1.SetMetaDataAnnotation(&rc.Datacenter.ObjectMeta, annotation, hash)
+	if err := rc.Client.Patch(rc.Ctx, rc.Datacenter, patch); err != nil {
+		rc.ReqLogger.Error(err, "failed to update the hash annotation", "annotation", annotation)
+		return result.Error(err)
+	}
+	return result.Continue()
+}
+
+// keystoreNeedsRenewal Returns true if the keystore expires soon, or cannot be read
+func keystoreNeedsRenewal(keystore []byte, password string) bool {
+	notAfter, err := utils.GetJKSNotAfter(keystore, password)
+	return err != nil || time.Until(notAfter) < keystoreRenewBefore
+}
+
+// checkInternodeKeystoresFromCertificate Converts the certificate issued by cert-manager into the
+// keystores of the nodes, which all hold it, and a truststore trusting its CA. The datacenters of a
+// cluster are expected to share the issuer.
+func (rc *ReconciliationContext) checkInternodeKeystoresFromCertificate() result.ReconcileResult {
+	dc := rc.Datacenter
+	certificate, err := rc.getIssuedCertificateSecret(api.InternodeCertificatePurpose)
+	if err != nil {
+		return result.Error(err)
+	}
+
+	podNames := getDesiredPodNames(dc)
+	sourceHash := getCertificateSecretHash(certificate)
+	current, err := rc.retrieveSecret(types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetInternodeKeystoresSecretName()})
+	if err == nil && current.Annotations[keystoreSourceHashAnnotation] == sourceHash {
+		upToDate := true
+		for _, podName := range podNames {
+			if _, found := current.Data[podName+".jks"]; !found {
+				upToDate = false
+			}
+		}
+		if upToDate {
+			return rc.checkHashAnnotation(api.InternodeKeystoresHashAnnotation, sourceHash)
+		}
+	} else if err != nil && !errors.IsNotFound(err) {
+		return result.Error(err)
+	}
+
+	keystore, err := utils.GenerateJKSFromPEM(dc.GetAllPodsServiceName(), certificate.Data["tls.crt"],
+		certificate.Data["tls.key"], certificate.Data["ca.crt"], dc.GetKeystorePassword())
+	if err != nil {
+		return result.Error(fmt.Errorf("failed to read certificate secret %s: %w", certificate.Name, err))
+	}
+	truststore, err := utils.GenerateTruststoreFromPEM(map[string][]byte{"ca": certificate.Data["ca.crt"]}, dc.GetKeystorePassword())
+	if err != nil {
+		return result.Error(err)
+	}
+
+	data := map[string][]byte{internodeTruststoreKey: truststore}
+	for _, podName := range podNames {
+		data[podName+".jks"] = keystore
+	}
+	if err := rc.applyOperatorSecret(dc.GetInternodeKeystoresSecretName(), data,
+		map[string]string{keystoreSourceHashAnnotation: sourceHash}); err != nil {
+		return result.Error(err)
+	}
+
+	return rc.checkHashAnnotation(api.InternodeKeystoresHashAnnotation, sourceHash)
+}
+
+// getClusterInternodeCAs Returns the CA of the datacenter and the ones of the other datacenters of
+// the cluster in the namespace, sorted by name.
+func (rc *ReconciliationContext) getClusterInternodeCAs(ca *corev1.Secret) ([]*corev1.Secret, error) {
+	dcs, err := rc.listClusterDatacenters()
+	if err != nil {
+		return nil, err
+	}
+
+	cas := []*corev1.Secret{ca}
+	for _, other := range dcs {
+		if other.Name == rc.Datacenter.Name {
+			continue
+		}
+		otherCA, err := rc.retrieveSecret(types.NamespacedName{Namespace: other.Namespace, Name: other.Name + "-ca-keystore"})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		cas = append(cas, otherCA)
+	}
+
+	sort.Slice(cas, func(i, j int) bool { return cas[i].Name < cas[j].Name })
+	return cas, nil
+}
+
+func getTruststoreHash(cas []*corev1.Secret) string {
+	var sb strings.Builder
+	for _, ca := range cas {
+		fmt.Fprintf(&sb, "%s\n%s\n", ca.Name, ca.Data["cert"])
+	}
+	return getConfigDataHash(sb.String())
+}
+
+// getDesiredPodNames Returns the names of the pods of the datacenter at its full size
+func getDesiredPodNames(dc *api.CassandraDatacenter) []string {
+	var podNames []string
+	racks := dc.GetRacks()
+	rackNodeCounts := api.SplitRacks(int(dc.Spec.Size), len(racks))
+	for i, rack := range racks {
+		statefulSetName := newNamespacedNameForStatefulSet(dc, rack.Name).Name
+		for ordinal := 0; ordinal < rackNodeCounts[i]; ordinal++ {
+			podNames = append(podNames, fmt.Sprintf("%s-%d", statefulSetName, ordinal))
+		}
+	}
+	return podNames
+}
+
+func getInternodeKeystoresVolume(dc *api.CassandraDatacenter) corev1.Volume {
+	return corev1.Volume{
+		Name: internodeKeystoresVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: dc.GetInternodeKeystoresSecretName()},
+		},
+	}
+}
+
+// getInternodeEncryptionVolume The directory holding the links to the keystore of the pod and the
+// truststore
+func getInternodeEncryptionVolume() corev1.Volume {
+	return corev1.Volume{
+		Name:         internodeEncryptionVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	}
+}
+
+// getInternodeKeystoresVolumeMounts The secret is mounted without a subPath, so that the renewed
+// keystores reach the running pods. The init container links the keystore of each pod, selected by
+// its POD_NAME environment variable.
+func getInternodeKeystoresVolumeMounts() []corev1.VolumeMount {
+	return []corev1.VolumeMount{
+		{
+			Name:      internodeEncryptionVolumeName,
+			MountPath: api.InternodeEncryptionDir,
+		},
+		{
+			Name:      internodeKeystoresVolumeName,
+			MountPath: internodeKeystoresPath,
+			ReadOnly:  true,
+		},
+	}
+}
+
+// newInternodeKeystoresInitContainer The container runs in the image of the config builder, and links the
+// keystore of the pod and the truststore
+func newInternodeKeystoresInitContainer(configBuilder *corev1.Container) corev1.Container {
+	return corev1.Container{
+		Name:            internodeKeystoresContainer,
+		Image:           configBuilder.Image,
+		ImagePullPolicy: configBuilder.ImagePullPolicy,
+		Command:         []string{"/bin/sh", "-c", internodeKeystoresScript},
+		Env: []corev1.EnvVar{
+			{Name: "POD_NAME", ValueFrom: selectorFromFieldPath("metadata.name")},
+		},
+		Resources:    configBuilder.Resources,
+		VolumeMounts: getInternodeKeystoresVolumeMounts(),
+	}
+}
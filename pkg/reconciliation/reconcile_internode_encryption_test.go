@@ -0,0 +1,86 @@
+package reconciliation
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pavel-v-chernykh/keystore-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+)
+
+func TestCheckInternodeKeystores(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.InternodeEncryption = true
+
+	// Another datacenter of the cluster, with its own CA
+	keyPem, certPem, err := utils.GetNewCAandKey("dc2-ca-keystore", dc.Namespace)
+	require.NoError(t, err)
+	otherDc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc2", Namespace: dc.Namespace},
+		Spec:       api.CassandraDatacenterSpec{ClusterName: dc.Spec.ClusterName, Size: 1},
+	}
+	otherCA := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc2-ca-keystore", Namespace: dc.Namespace},
+		Data:       map[string][]byte{"key": []byte(keyPem), "cert": []byte(certPem)},
+	}
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+	rc.Client = fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(dc, otherDc, otherCA).Build()
+
+	result := rc.CheckInternodeKeystores()
+	assert.False(t, result.Completed())
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetInternodeKeystoresSecretName()}
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, secret))
+	assert.Len(t, secret.Data, 3)
+	for _, podName := range []string{
+		"cassandradatacenter-example-cluster-cassandradatacenter-example-default-sts-0",
+		"cassandradatacenter-example-cluster-cassandradatacenter-example-default-sts-1",
+	} {
+		assert.Contains(t, secret.Data, podName+".jks")
+	}
+
+	truststore, err := keystore.Decode(bytes.NewReader(secret.Data["truststore.jks"]), []byte(dc.Name))
+	require.NoError(t, err)
+	assert.Contains(t, truststore, dc.Name+"-ca-keystore")
+	assert.Contains(t, truststore, "dc2-ca-keystore")
+
+	// The keystores are generated once
+	resourceVersion := secret.ResourceVersion
+	result = rc.CheckInternodeKeystores()
+	assert.False(t, result.Completed())
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, secret))
+	assert.Equal(t, resourceVersion, secret.ResourceVersion)
+
+	hash := dc.Annotations[api.InternodeKeystoresHashAnnotation]
+	assert.NotEmpty(t, hash)
+
+	// A keystore which cannot be read, as an expired one, is renewed, and the pods are restarted
+	podName := "cassandradatacenter-example-cluster-cassandradatacenter-example-default-sts-0"
+	secret.Data[podName+".jks"] = []byte("expired")
+	require.NoError(t, rc.Client.Update(rc.Ctx, secret))
+	result = rc.CheckInternodeKeystores()
+	assert.False(t, result.Completed())
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, secret))
+	notAfter, err := utils.GetJKSNotAfter(secret.Data[podName+".jks"], dc.Name)
+	require.NoError(t, err)
+	assert.True(t, notAfter.After(time.Now().Add(keystoreRenewBefore)))
+	assert.Contains(t, secret.Annotations, keystoresRenewedAnnotation)
+	assert.NotEqual(t, hash, dc.Annotations[api.InternodeKeystoresHashAnnotation])
+}
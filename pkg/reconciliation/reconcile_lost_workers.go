@@ -0,0 +1,207 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+)
+
+// CheckLostWorkers When a lostWorkerPolicy is set, recovers one pod of a lost k8s worker node per
+// reconciliation, see isWorkerLost. The pods evicted from a lost worker are force deleted, with the
+// VolumeAttachments of their volumes, so that the StatefulSet controller recreates them on another
+// worker. The pods Pending because their PVC was scheduled on a lost worker are deleted, after
+// releasing the PVC when no volume was bound yet, or replaced with the Replace policy when their volume
+// is pinned to it.
+func (rc *ReconciliationContext) CheckLostWorkers() result.ReconcileResult {
+	dc := rc.Datacenter
+	if dc.Spec.LostWorkerPolicy == "" {
+		return result.Continue()
+	}
+
+	logger := rc.ReqLogger
+	logger.Info("reconcile_lost_workers::CheckLostWorkers")
+
+	lostWorkers := map[string]bool{}
+	isLost := func(nodeName string) (bool, error) {
+		if lost, found := lostWorkers[nodeName]; found {
+			return lost, nil
+		}
+		node, err := rc.getNode(nodeName)
+		if err != nil && !errors.IsNotFound(err) {
+			logger.Error(err, "error retrieving k8s node", "node", nodeName)
+			return false, err
+		}
+		lostWorkers[nodeName] = errors.IsNotFound(err) || isWorkerLost(node)
+		return lostWorkers[nodeName], nil
+	}
+
+	for _, pod := range rc.dcPods {
+		if pod.DeletionTimestamp != nil && pod.Spec.NodeName != "" {
+			// The pod was evicted, but its kubelet is gone and will never confirm the deletion
+			if lost, err := isLost(pod.Spec.NodeName); err != nil {
+				return result.Error(err)
+			} else if !lost {
+				continue
+			}
+
+			rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.ReschedulingPod,
+				"Rescheduling pod %s from lost worker %s", pod.Name, pod.Spec.NodeName)
+			if err := rc.reschedulePodFromLostWorker(pod); err != nil {
+				return result.Error(err)
+			}
+			return result.RequeueSoon(10)
+		}
+
+		if pod.Status.Phase != corev1.PodPending || !utils.IsPodUnschedulable(pod) || dc.IsEphemeralStorageEnabled() {
+			continue
+		}
+
+		pvc, err := rc.GetPodPVC(pod.Namespace, pod.Name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				// See fixMissingPVC
+				continue
+			}
+			return result.Error(err)
+		}
+
+		if pvc.Spec.VolumeName == "" {
+			// The volume is only provisioned on the worker selected for the PVC
+			nodeName := utils.GetPVCSelectedNodeName(pvc)
+			if nodeName == "" {
+				continue
+			}
+			if lost, err := isLost(nodeName); err != nil {
+				return result.Error(err)
+			} else if !lost {
+				continue
+			}
+
+			rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.ReschedulingPod,
+				"Rescheduling pod %s, its PVC was scheduled on lost worker %s", pod.Name, nodeName)
+			pvcPatch := client.MergeFrom(pvc.DeepCopy())
+			delete(pvc.Annotations, "volume.kubernetes.io/selected-node")
+			if err := rc.Client.Patch(rc.Ctx, pvc, pvcPatch); err != nil {
+				logger.Error(err, "error releasing PVC from lost worker", "pvc", pvc.Name)
+				return result.Error(err)
+			}
+			if err := client.IgnoreNotFound(rc.Client.Delete(rc.Ctx, pod)); err != nil {
+				return result.Error(err)
+			}
+			return result.RequeueSoon(10)
+		}
+
+		if dc.Spec.LostWorkerPolicy != api.LostWorkerPolicyReplace {
+			continue
+		}
+
+		pv := &corev1.PersistentVolume{}
+		if err := rc.Client.Get(rc.Ctx, client.ObjectKey{Name: pvc.Spec.VolumeName}, pv); err != nil {
+			logger.Error(err, "error retrieving PersistentVolume", "pv", pvc.Spec.VolumeName)
+			return result.Error(err)
+		}
+		nodeName := getPersistentVolumeWorker(pv)
+		if nodeName == "" {
+			continue
+		}
+		if lost, err := isLost(nodeName); err != nil {
+			return result.Error(err)
+		} else if !lost {
+			continue
+		}
+
+		rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.ReplacingNode,
+			"Replacing node of pod %s, its data volume is pinned to lost worker %s", pod.Name, nodeName)
+		if err := rc.StartNodeReplace(pod.Name); err != nil {
+			return result.Error(err)
+		}
+		return result.RequeueSoon(10)
+	}
+
+	return result.Continue()
+}
+
+// reschedulePodFromLostWorker Deletes the VolumeAttachments of the volumes of the pod to its lost worker,
+// which would otherwise prevent attaching them to another worker, and force deletes the pod
+func (rc *ReconciliationContext) reschedulePodFromLostWorker(pod *corev1.Pod) error {
+	volumeNames := utils.StringSet{}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc := &corev1.PersistentVolumeClaim{}
+		key := client.ObjectKey{Namespace: pod.Namespace, Name: volume.PersistentVolumeClaim.ClaimName}
+		if err := rc.Client.Get(rc.Ctx, key, pvc); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if pvc.Spec.VolumeName != "" {
+			volumeNames[pvc.Spec.VolumeName] = true
+		}
+	}
+
+	if len(volumeNames) > 0 {
+		attachments := &storagev1.VolumeAttachmentList{}
+		if err := rc.Client.List(rc.Ctx, attachments); err != nil {
+			return err
+		}
+		for i := range attachments.Items {
+			attachment := &attachments.Items[i]
+			source := attachment.Spec.Source.PersistentVolumeName
+			if attachment.Spec.NodeName != pod.Spec.NodeName || source == nil || !volumeNames[*source] {
+				continue
+			}
+			if err := client.IgnoreNotFound(rc.Client.Delete(rc.Ctx, attachment)); err != nil {
+				rc.ReqLogger.Error(err, "error deleting VolumeAttachment", "volumeAttachment", attachment.Name)
+				return err
+			}
+		}
+	}
+
+	return client.IgnoreNotFound(rc.Client.Delete(rc.Ctx, pod, client.GracePeriodSeconds(0)))
+}
+
+// isWorkerLost Returns true when the worker is being deleted, or was tainted out-of-service by an
+// administrator who made sure it is shut down. A worker which is only not ready may come back with
+// its pods still running, and their volumes still attached.
+func isWorkerLost(node *corev1.Node) bool {
+	if node.DeletionTimestamp != nil {
+		return true
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == corev1.TaintNodeOutOfService {
+			return true
+		}
+	}
+	return false
+}
+
+// getPersistentVolumeWorker Returns the name of the worker the PersistentVolume is pinned to by its
+// node affinity, like local volumes, or an empty string
+func getPersistentVolumeWorker(pv *corev1.PersistentVolume) string {
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return ""
+	}
+	terms := pv.Spec.NodeAffinity.Required.NodeSelectorTerms
+	if len(terms) != 1 {
+		return ""
+	}
+	for _, expression := range terms[0].MatchExpressions {
+		if expression.Key == corev1.LabelHostname && expression.Operator == corev1.NodeSelectorOpIn &&
+			len(expression.Values) == 1 {
+			return expression.Values[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,158 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+)
+
+func TestIsWorkerLost(t *testing.T) {
+	now := metav1.Now()
+	notReady := &corev1.Node{Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{
+		Type:               corev1.NodeReady,
+		Status:             corev1.ConditionUnknown,
+		LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+	}}}}
+	assert.False(t, isWorkerLost(&corev1.Node{}))
+	assert.False(t, isWorkerLost(notReady), "a worker not ready may come back")
+
+	outOfService := notReady.DeepCopy()
+	outOfService.Spec.Taints = []corev1.Taint{{Key: corev1.TaintNodeOutOfService, Effect: corev1.TaintEffectNoExecute}}
+	assert.True(t, isWorkerLost(outOfService))
+
+	deleted := notReady.DeepCopy()
+	deleted.DeletionTimestamp = &now
+	assert.True(t, isWorkerLost(deleted))
+}
+
+func TestGetPersistentVolumeWorker(t *testing.T) {
+	assert.Equal(t, "", getPersistentVolumeWorker(&corev1.PersistentVolume{}))
+	assert.Equal(t, "worker1", getPersistentVolumeWorker(localPersistentVolume("pv-1", "worker1")))
+}
+
+func TestCheckLostWorkers_Reschedule(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.LostWorkerPolicy = api.LostWorkerPolicyReschedule
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: PvcName + "-pod-a", Namespace: rc.Datacenter.Namespace},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-a"},
+	}
+	require.NoError(t, rc.Client.Create(rc.Ctx, pvc))
+	pvName := "pv-a"
+	attachment := &storagev1.VolumeAttachment{
+		ObjectMeta: metav1.ObjectMeta{Name: "attachment-a"},
+		Spec: storagev1.VolumeAttachmentSpec{
+			Attacher: "csi.example.com",
+			NodeName: "lost-worker",
+			Source:   storagev1.VolumeAttachmentSource{PersistentVolumeName: &pvName},
+		},
+	}
+	require.NoError(t, rc.Client.Create(rc.Ctx, attachment))
+
+	now := metav1.Now()
+	rc.dcPods = []*corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-a", Namespace: rc.Datacenter.Namespace, DeletionTimestamp: &now},
+		Spec: corev1.PodSpec{
+			NodeName: "lost-worker",
+			Volumes: []corev1.Volume{{
+				Name: PvcName,
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.Name},
+				},
+			}},
+		},
+	}}
+
+	recResult := rc.CheckLostWorkers()
+	assert.True(t, recResult.Completed())
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: attachment.Name}, &storagev1.VolumeAttachment{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestCheckLostWorkers_Replace(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.LostWorkerPolicy = api.LostWorkerPolicyReplace
+	require.NoError(t, rc.Client.Update(rc.Ctx, rc.Datacenter))
+
+	require.NoError(t, rc.Client.Create(rc.Ctx, localPersistentVolume("pv-a", "lost-worker")))
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: PvcName + "-pod-a", Namespace: rc.Datacenter.Namespace},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-a"},
+	}
+	require.NoError(t, rc.Client.Create(rc.Ctx, pvc))
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-a", Namespace: rc.Datacenter.Namespace},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type:   corev1.PodScheduled,
+				Status: corev1.ConditionFalse,
+				Reason: corev1.PodReasonUnschedulable,
+			}},
+		},
+	}
+	require.NoError(t, rc.Client.Create(rc.Ctx, pod))
+	rc.dcPods = []*corev1.Pod{pod}
+
+	recResult := rc.CheckLostWorkers()
+	assert.True(t, recResult.Completed())
+	assert.Equal(t, []string{"pod-a"}, rc.Datacenter.Spec.ReplaceNodes)
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: pvc.Namespace, Name: pvc.Name}, &corev1.PersistentVolumeClaim{})
+	assert.True(t, errors.IsNotFound(err))
+
+	// A retried replacement does not list the node twice
+	pvc.ResourceVersion = ""
+	require.NoError(t, rc.Client.Create(rc.Ctx, pvc))
+	pod.ResourceVersion = ""
+	require.NoError(t, rc.Client.Create(rc.Ctx, pod))
+	require.NoError(t, rc.StartNodeReplace("pod-a"))
+	assert.Equal(t, []string{"pod-a"}, rc.Datacenter.Spec.ReplaceNodes)
+
+	// The Reschedule policy leaves the nodes pinned to a lost worker alone
+	rc.Datacenter.Spec.LostWorkerPolicy = api.LostWorkerPolicyReschedule
+	rc.Datacenter.Spec.ReplaceNodes = nil
+	require.NoError(t, rc.Client.Create(rc.Ctx, &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: PvcName + "-pod-a", Namespace: rc.Datacenter.Namespace},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-a"},
+	}))
+	recResult = rc.CheckLostWorkers()
+	assert.False(t, recResult.Completed())
+	assert.Empty(t, rc.Datacenter.Spec.ReplaceNodes)
+}
+
+func localPersistentVolume(name string, nodeName string) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeSpec{
+			NodeAffinity: &corev1.VolumeNodeAffinity{
+				Required: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{{
+							Key:      corev1.LabelHostname,
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{nodeName},
+						}},
+					}},
+				},
+			},
+		},
+	}
+}
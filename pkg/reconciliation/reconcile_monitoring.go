@@ -0,0 +1,388 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/images"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+)
+
+const (
+	MetricsExporterContainerName = "metrics-exporter"
+	metricsExporterVolumeName    = "metrics-exporter-config"
+	metricsExporterConfigDir     = "/etc/cassandra_exporter"
+	metricsExporterConfigKey     = "config.yml"
+	metricsExporterPortName      = "metrics"
+)
+
+// defaultExcludedMetrics Leaves out the attributes of little use and the per table metrics,
+// which are costly to collect on nodes with many tables.
+var defaultExcludedMetrics = []string{
+	"java:lang:memorypool:.*usagethreshold.*",
+	".*:999thpercentile",
+	".*:95thpercentile",
+	".*:fifteenminuterate",
+	".*:fiveminuterate",
+	".*:durationunit",
+	".*:rateunit",
+	".*:stddev",
+	".*:meanrate",
+	".*:mean",
+	".*:min",
+	"com:datastax:.*",
+	"org:apache:cassandra:metrics:table:.*",
+}
+
+// CheckMetricsExporterConfigMap When the Monitoring property is set, writes the configuration
+// of the metrics exporter sidecar to its config map. Like for the server configuration, the pod
+// template carries a hash of the configuration to restart the pods when it changes.
+func (rc *ReconciliationContext) CheckMetricsExporterConfigMap() result.ReconcileResult {
+	if rc.Datacenter.Spec.Monitoring == nil {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_monitoring::CheckMetricsExporterConfigMap")
+
+	config := getMetricsExporterConfig(rc.Datacenter)
+	configMap, exists, err := rc.getDatacenterConfigMap(getMetricsExporterConfigMapName(rc.Datacenter))
+	if err != nil {
+		rc.ReqLogger.Error(err, "failed to get metrics exporter config map")
+		return result.Error(err)
+	}
+
+	labels := rc.Datacenter.GetDatacenterLabels()
+	oplabels.AddOperatorLabels(labels, rc.Datacenter)
+
+	if exists && configMap.Data[metricsExporterConfigKey] == config && mapContains(configMap.Labels, labels) {
+		return result.Continue()
+	}
+
+	configMap.Labels = utils.MergeMap(map[string]string{}, configMap.Labels, labels)
+	configMap.Data[metricsExporterConfigKey] = config
+
+	if exists {
+		rc.ReqLogger.Info("updating metrics exporter config map", "ConfigMap", configMap.Name)
+		if err := rc.Client.Update(rc.Ctx, configMap); err != nil {
+			rc.ReqLogger.Error(err, "failed to update metrics exporter config map", "ConfigMap", configMap.Name)
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.UpdatedConfig,
+			"Updated config map %s", configMap.Name)
+	} else {
+		rc.ReqLogger.Info("creating metrics exporter config map", "ConfigMap", configMap.Name)
+		if err := rc.Client.Create(rc.Ctx, configMap); err != nil {
+			rc.ReqLogger.Error(err, "failed to create metrics exporter config map", "ConfigMap", configMap.Name)
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.CreatedResource,
+			"Created config map %s", configMap.Name)
+	}
+
+	return result.Continue()
+}
+
+// getMetricsExporterConfig Renders the configuration of the exporter, which scrapes the JMX
+// metrics of the node it runs next to.
+func getMetricsExporterConfig(dc *api.CassandraDatacenter) string {
+	var sb strings.Builder
+	sb.WriteString("host: localhost:7199\n")
+	sb.WriteString("ssl: False\n")
+	sb.WriteString("listenAddress: 0.0.0.0\n")
+	fmt.Fprintf(&sb, "listenPort: %d\n", dc.GetMetricsExporterPort())
+	sb.WriteString("maxScrapeFrequencyInSec:\n  50:\n    - .*\n")
+	sb.WriteString("blacklist:\n")
+	excludedMetrics := append(append([]string{}, defaultExcludedMetrics...), dc.Spec.Monitoring.ExcludedMetrics...)
+	for _, metric := range excludedMetrics {
+		fmt.Fprintf(&sb, "  - '%s'\n", strings.ReplaceAll(metric, "'", "''"))
+	}
+	return sb.String()
+}
+
+// getMetricsExporterConfigMapName The format is clusterName-dcName-metrics-exporter-config
+func getMetricsExporterConfigMapName(dc *api.CassandraDatacenter) string {
+	return api.CleanupForKubernetes(dc.Spec.ClusterName) + "-" + dc.Name + "-metrics-exporter-config"
+}
+
+// buildMetricsExporterContainer Sets the defaults of the metrics exporter sidecar, unless
+// they are overridden in the PodTemplateSpec.
+func buildMetricsExporterContainer(dc *api.CassandraDatacenter, container *corev1.Container) {
+	container.Name = MetricsExporterContainerName
+
+	if container.Image == "" {
+		container.Image = dc.Spec.Monitoring.Image
+		if container.Image == "" {
+			container.Image = images.GetMetricsExporterImage()
+		}
+		if pullPolicy := getImagePullPolicy(dc); pullPolicy != "" {
+			container.ImagePullPolicy = pullPolicy
+		}
+	}
+
+	container.Ports = combinePortSlices([]corev1.ContainerPort{{
+		Name:          metricsExporterPortName,
+		ContainerPort: dc.GetMetricsExporterPort(),
+		Protocol:      corev1.ProtocolTCP,
+	}}, container.Ports)
+
+	container.Env = combineEnvSlices([]corev1.EnvVar{{
+		Name:  "CONFIG_HASH",
+		Value: getConfigDataHash(getMetricsExporterConfig(dc)),
+	}}, container.Env)
+
+	container.VolumeMounts = combineVolumeMountSlices([]corev1.VolumeMount{{
+		Name:      metricsExporterVolumeName,
+		MountPath: metricsExporterConfigDir,
+	}}, container.VolumeMounts)
+
+	if container.Resources.Requests == nil && container.Resources.Limits == nil {
+		container.Resources = dc.Spec.Monitoring.Resources
+	}
+}
+
+func getMetricsExporterVolume(dc *api.CassandraDatacenter) corev1.Volume {
+	return corev1.Volume{
+		Name: metricsExporterVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: getMetricsExporterConfigMapName(dc)},
+			},
+		},
+	}
+}
+
+var (
+	serviceMonitorGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+	prometheusRuleGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PrometheusRule"}
+)
+
+// CheckServiceMonitor When the Monitoring property is set and the Prometheus Operator is
+// installed, creates a ServiceMonitor scraping the metrics exporters through the all pods
+// service. The ServiceMonitor is owned by the datacenter.
+func (rc *ReconciliationContext) CheckServiceMonitor() result.ReconcileResult {
+	dc := rc.Datacenter
+	if dc.Spec.Monitoring == nil {
+		return result.Continue()
+	}
+
+	installed, err := rc.isKindInstalled(serviceMonitorGVK)
+	if err != nil {
+		return result.Error(err)
+	} else if !installed {
+		// The Prometheus Operator is not installed
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_monitoring::CheckServiceMonitor")
+
+	return rc.reconcileOwnedObject(newServiceMonitorForCassandraDatacenter(dc))
+}
+
+// CheckPrometheusRule When the AlertingRules property of Monitoring is set and the Prometheus
+// Operator is installed, creates a PrometheusRule with the alerts of the datacenter. The rule is
+// deleted when the property is unset.
+func (rc *ReconciliationContext) CheckPrometheusRule() result.ReconcileResult {
+	dc := rc.Datacenter
+	if dc.Spec.Monitoring == nil {
+		return result.Continue()
+	}
+	enabled := dc.Spec.Monitoring.AlertingRules
+
+	installed, err := rc.isKindInstalled(prometheusRuleGVK)
+	if err != nil {
+		return result.Error(err)
+	} else if !installed {
+		return result.Continue()
+	}
+
+	if !enabled {
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(prometheusRuleGVK)
+		err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: getPrometheusRuleName(dc)}, current)
+		if errors.IsNotFound(err) {
+			return result.Continue()
+		} else if err != nil {
+			return result.Error(err)
+		}
+
+		rc.ReqLogger.Info("deleting prometheus rule", "PrometheusRule", current.GetName())
+		if err := rc.Client.Delete(rc.Ctx, current); err != nil && !errors.IsNotFound(err) {
+			return result.Error(err)
+		}
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_monitoring::CheckPrometheusRule")
+
+	return rc.reconcileOwnedObject(newPrometheusRuleForCassandraDatacenter(dc))
+}
+
+// isKindInstalled Checks whether the CRD of the kind is installed in the cluster
+func (rc *ReconciliationContext) isKindInstalled(gvk schema.GroupVersionKind) (bool, error) {
+	if _, err := rc.Client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// reconcileOwnedObject Creates the object owned by the datacenter, or updates it when it
+// differs from the desired one.
+func (rc *ReconciliationContext) reconcileOwnedObject(desired *unstructured.Unstructured) result.ReconcileResult {
+	dc := rc.Datacenter
+	kind := desired.GetKind()
+
+	if err := setControllerReference(dc, desired, rc.Scheme); err != nil {
+		return result.Error(err)
+	}
+
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(desired.GroupVersionKind())
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: desired.GetNamespace(), Name: desired.GetName()}, current)
+	if errors.IsNotFound(err) {
+		rc.ReqLogger.Info("creating "+kind, kind, desired.GetName())
+		if err := rc.Client.Create(rc.Ctx, desired); err != nil {
+			rc.ReqLogger.Error(err, "failed to create "+kind, kind, desired.GetName())
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+			"Created %s %s", kind, desired.GetName())
+		return result.Continue()
+	} else if err != nil {
+		return result.Error(err)
+	}
+
+	if utils.ResourcesHaveSameHash(current, desired) {
+		return result.Continue()
+	}
+
+	desired.SetResourceVersion(current.GetResourceVersion())
+	rc.ReqLogger.Info("updating "+kind, kind, desired.GetName())
+	if err := rc.Client.Update(rc.Ctx, desired); err != nil {
+		rc.ReqLogger.Error(err, "failed to update "+kind, kind, desired.GetName())
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
+
+// getServiceMonitorName The format is clusterName-dcName-service-monitor
+func getServiceMonitorName(dc *api.CassandraDatacenter) string {
+	return api.CleanupForKubernetes(dc.Spec.ClusterName) + "-" + dc.Name + "-service-monitor"
+}
+
+func newServiceMonitorForCassandraDatacenter(dc *api.CassandraDatacenter) *unstructured.Unstructured {
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddOperatorLabels(labels, dc)
+
+	selector := dc.GetDatacenterLabels()
+	selector[api.PromMetricsLabel] = "true"
+
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetGroupVersionKind(serviceMonitorGVK)
+	serviceMonitor.SetNamespace(dc.Namespace)
+	serviceMonitor.SetName(getServiceMonitorName(dc))
+	serviceMonitor.SetLabels(labels)
+	serviceMonitor.Object["spec"] = map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": stringMapToInterfaceMap(selector),
+		},
+		"namespaceSelector": map[string]interface{}{
+			"matchNames": []interface{}{dc.Namespace},
+		},
+		"endpoints": []interface{}{
+			map[string]interface{}{
+				"port": metricsExporterPortName,
+			},
+		},
+	}
+
+	utils.AddHashAnnotation(serviceMonitor)
+	return serviceMonitor
+}
+
+// getPrometheusRuleName The format is clusterName-dcName-prometheus-rule
+func getPrometheusRuleName(dc *api.CassandraDatacenter) string {
+	return api.CleanupForKubernetes(dc.Spec.ClusterName) + "-" + dc.Name + "-prometheus-rule"
+}
+
+// newPrometheusRuleForCassandraDatacenter The expressions select the series of the datacenter with
+// the labels added by the metrics exporter, the all pods service and the names of the volume claims.
+func newPrometheusRuleForCassandraDatacenter(dc *api.CassandraDatacenter) *unstructured.Unstructured {
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddOperatorLabels(labels, dc)
+
+	selector := fmt.Sprintf(`cluster="%s",datacenter="%s"`, dc.Spec.ClusterName, dc.DatacenterName())
+	pvcPrefix := fmt.Sprintf("%s-%s-%s-", PvcName, api.CleanupForKubernetes(dc.Spec.ClusterName), dc.Name)
+	alert := func(name, expr, duration, severity, summary string) interface{} {
+		return map[string]interface{}{
+			"alert": name,
+			"expr":  expr,
+			"for":   duration,
+			"labels": map[string]interface{}{
+				"severity":   severity,
+				"cluster":    dc.Spec.ClusterName,
+				"datacenter": dc.DatacenterName(),
+			},
+			"annotations": map[string]interface{}{
+				"summary": summary,
+			},
+		}
+	}
+
+	prometheusRule := &unstructured.Unstructured{}
+	prometheusRule.SetGroupVersionKind(prometheusRuleGVK)
+	prometheusRule.SetNamespace(dc.Namespace)
+	prometheusRule.SetName(getPrometheusRuleName(dc))
+	prometheusRule.SetLabels(labels)
+	prometheusRule.Object["spec"] = map[string]interface{}{
+		"groups": []interface{}{
+			map[string]interface{}{
+				"name": fmt.Sprintf("cassandra-%s-%s", api.CleanupForKubernetes(dc.Spec.ClusterName), dc.Name),
+				"rules": []interface{}{
+					alert("CassandraNodeDown",
+						fmt.Sprintf(`up{namespace="%s",service="%s"} == 0`, dc.Namespace, dc.GetAllPodsServiceName()),
+						"5m", "critical", "Cassandra node {{ $labels.pod }} is down"),
+					alert("CassandraPendingCompactions",
+						fmt.Sprintf(`cassandra_stats{%s,name="org:apache:cassandra:metrics:compaction:pendingtasks:value"} > 100`, selector),
+						"30m", "warning", "Cassandra node {{ $labels.pod }} has {{ $value }} pending compactions"),
+					alert("CassandraDroppedMutations",
+						fmt.Sprintf(`rate(cassandra_stats{%s,name="org:apache:cassandra:metrics:droppedmessage:mutation:dropped:count"}[5m]) > 0`, selector),
+						"10m", "warning", "Cassandra node {{ $labels.pod }} drops mutations"),
+					alert("CassandraDiskNearlyFull",
+						fmt.Sprintf(`kubelet_volume_stats_available_bytes{namespace="%s",persistentvolumeclaim=~"%s.*"}`+
+							` / kubelet_volume_stats_capacity_bytes{namespace="%s",persistentvolumeclaim=~"%s.*"} < 0.15`,
+							dc.Namespace, pvcPrefix, dc.Namespace, pvcPrefix),
+						"10m", "warning", "Volume {{ $labels.persistentvolumeclaim }} has less than 15% of free space"),
+				},
+			},
+		},
+	}
+
+	utils.AddHashAnnotation(prometheusRule)
+	return prometheusRule
+}
+
+func stringMapToInterfaceMap(m map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
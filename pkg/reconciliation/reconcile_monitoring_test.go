@@ -0,0 +1,133 @@
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+)
+
+func TestCheckMetricsExporterConfigMap(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	key := types.NamespacedName{Namespace: dc.Namespace, Name: getMetricsExporterConfigMapName(dc)}
+
+	// Nothing is created while monitoring is disabled
+	result := rc.CheckMetricsExporterConfigMap()
+	assert.False(t, result.Completed())
+	assert.Error(t, rc.Client.Get(rc.Ctx, key, &corev1.ConfigMap{}))
+
+	dc.Spec.Monitoring = &api.MonitoringSpec{}
+	result = rc.CheckMetricsExporterConfigMap()
+	assert.False(t, result.Completed())
+
+	configMap := &corev1.ConfigMap{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, configMap))
+	config := configMap.Data["config.yml"]
+	assert.Contains(t, config, "host: localhost:7199\n")
+	assert.Contains(t, config, "listenPort: 9500\n")
+	assert.Contains(t, config, "  - 'org:apache:cassandra:metrics:table:.*'\n")
+
+	dc.Spec.Monitoring.ExcludedMetrics = []string{"org:apache:cassandra:metrics:threadpools:.*"}
+	result = rc.CheckMetricsExporterConfigMap()
+	assert.False(t, result.Completed())
+
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, configMap))
+	assert.Contains(t, configMap.Data["config.yml"], "  - 'org:apache:cassandra:metrics:threadpools:.*'\n")
+	assert.NotEqual(t, getConfigDataHash(config), getConfigDataHash(getMetricsExporterConfig(dc)))
+}
+
+func TestCheckServiceMonitor(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Monitoring = &api.MonitoringSpec{}
+	key := types.NamespacedName{Namespace: dc.Namespace, Name: getServiceMonitorName(dc)}
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetGroupVersionKind(serviceMonitorGVK)
+
+	// The Prometheus Operator is not installed
+	result := rc.CheckServiceMonitor()
+	assert.False(t, result.Completed())
+	assert.Error(t, rc.Client.Get(rc.Ctx, key, serviceMonitor))
+
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{})
+	mapper.Add(serviceMonitorGVK, meta.RESTScopeNamespace)
+	rc.Client = fake.NewClientBuilder().WithRESTMapper(mapper).WithRuntimeObjects(dc).Build()
+
+	result = rc.CheckServiceMonitor()
+	assert.False(t, result.Completed())
+
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, serviceMonitor))
+	matchLabels, _, err := unstructured.NestedStringMap(serviceMonitor.Object, "spec", "selector", "matchLabels")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		api.ClusterLabel:     dc.Spec.ClusterName,
+		api.DatacenterLabel:  dc.Name,
+		api.PromMetricsLabel: "true",
+	}, matchLabels)
+	endpoints, _, err := unstructured.NestedSlice(serviceMonitor.Object, "spec", "endpoints")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"port": "metrics"}}, endpoints)
+
+	// The service monitor is up to date
+	resourceVersion := serviceMonitor.GetResourceVersion()
+	result = rc.CheckServiceMonitor()
+	assert.False(t, result.Completed())
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, serviceMonitor))
+	assert.Equal(t, resourceVersion, serviceMonitor.GetResourceVersion())
+}
+
+func TestCheckPrometheusRule(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Monitoring = &api.MonitoringSpec{AlertingRules: true}
+	key := types.NamespacedName{Namespace: dc.Namespace, Name: getPrometheusRuleName(dc)}
+	prometheusRule := &unstructured.Unstructured{}
+	prometheusRule.SetGroupVersionKind(prometheusRuleGVK)
+
+	// The Prometheus Operator is not installed
+	result := rc.CheckPrometheusRule()
+	assert.False(t, result.Completed())
+	assert.Error(t, rc.Client.Get(rc.Ctx, key, prometheusRule))
+
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{})
+	mapper.Add(prometheusRuleGVK, meta.RESTScopeNamespace)
+	rc.Client = fake.NewClientBuilder().WithRESTMapper(mapper).WithRuntimeObjects(dc).Build()
+
+	result = rc.CheckPrometheusRule()
+	assert.False(t, result.Completed())
+
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, prometheusRule))
+	groups, _, err := unstructured.NestedSlice(prometheusRule.Object, "spec", "groups")
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	rules := groups[0].(map[string]interface{})["rules"].([]interface{})
+	alerts := []string{}
+	for _, rule := range rules {
+		rule := rule.(map[string]interface{})
+		alerts = append(alerts, rule["alert"].(string))
+		assert.Equal(t, dc.Name, rule["labels"].(map[string]interface{})["datacenter"])
+	}
+	assert.Equal(t, []string{"CassandraNodeDown", "CassandraPendingCompactions", "CassandraDroppedMutations", "CassandraDiskNearlyFull"}, alerts)
+	assert.Contains(t, rules[3].(map[string]interface{})["expr"], `persistentvolumeclaim=~"server-data-`+dc.Spec.ClusterName+"-"+dc.Name+`-.*"`)
+
+	// The rule is deleted when the alerts are disabled
+	dc.Spec.Monitoring.AlertingRules = false
+	result = rc.CheckPrometheusRule()
+	assert.False(t, result.Completed())
+	assert.Error(t, rc.Client.Get(rc.Ctx, key, prometheusRule))
+}
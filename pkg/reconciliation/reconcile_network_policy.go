@@ -0,0 +1,183 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+)
+
+// operatorPodLabels are the labels of the pods of the operator deployment
+var operatorPodLabels = map[string]string{"name": "cass-operator"}
+
+// CheckNetworkPolicy When the NetworkPolicy property of Networking is set, creates the NetworkPolicy
+// restricting the ingress traffic of the pods of the datacenter. The policy is deleted when the
+// property is unset.
+func (rc *ReconciliationContext) CheckNetworkPolicy() result.ReconcileResult {
+	dc := rc.Datacenter
+	key := types.NamespacedName{Namespace: dc.Namespace, Name: getNetworkPolicyName(dc)}
+
+	current := &networkingv1.NetworkPolicy{}
+	err := rc.Client.Get(rc.Ctx, key, current)
+	exists := err == nil
+	if err != nil && !errors.IsNotFound(err) {
+		return result.Error(err)
+	}
+
+	if !dc.IsNetworkPolicyEnabled() {
+		if exists {
+			rc.ReqLogger.Info("deleting network policy", "NetworkPolicy", current.Name)
+			if err := rc.Client.Delete(rc.Ctx, current); err != nil && !errors.IsNotFound(err) {
+				return result.Error(err)
+			}
+		}
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_network_policy::CheckNetworkPolicy")
+
+	// Outside of a cluster, the operator pods are looked up in all the namespaces
+	operatorNamespace, err := utils.GetOperatorNamespace()
+	if err != nil {
+		operatorNamespace = ""
+	}
+
+	desired := newNetworkPolicyForCassandraDatacenter(dc, operatorNamespace)
+	if err := rc.SetDatacenterAsOwner(desired); err != nil {
+		return result.Error(err)
+	}
+
+	if !exists {
+		rc.ReqLogger.Info("creating network policy", "NetworkPolicy", desired.Name)
+		if err := rc.Client.Create(rc.Ctx, desired); err != nil {
+			rc.ReqLogger.Error(err, "failed to create network policy", "NetworkPolicy", desired.Name)
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+			"Created network policy %s", desired.Name)
+		return result.Continue()
+	}
+
+	if utils.ResourcesHaveSameHash(current, desired) {
+		return result.Continue()
+	}
+
+	// preserve any labels and annotations that were added to the policy post-creation
+	desired.Labels = utils.MergeMap(map[string]string{}, current.Labels, desired.Labels)
+	desired.Annotations = utils.MergeMap(map[string]string{}, current.Annotations, desired.Annotations)
+	desired.ResourceVersion = current.ResourceVersion
+
+	rc.ReqLogger.Info("updating network policy", "NetworkPolicy", desired.Name)
+	if err := rc.Client.Update(rc.Ctx, desired); err != nil {
+		rc.ReqLogger.Error(err, "failed to update network policy", "NetworkPolicy", desired.Name)
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
+
+// getNetworkPolicyName The format is clusterName-dcName-network-policy
+func getNetworkPolicyName(dc *api.CassandraDatacenter) string {
+	return api.CleanupForKubernetes(dc.Spec.ClusterName) + "-" + dc.Name + "-network-policy"
+}
+
+// newNetworkPolicyForCassandraDatacenter The pods of the cluster, in the namespaces of its datacenters,
+// reach the internode ports of the nodes, and Reaper their JMX and CQL ports. The other ingress rules
+// only open the CQL ports, the management API to the operator and the metrics exporter.
+func newNetworkPolicyForCassandraDatacenter(dc *api.CassandraDatacenter, operatorNamespace string) *networkingv1.NetworkPolicy {
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddOperatorLabels(labels, dc)
+
+	ports := func(numbers ...int) []networkingv1.NetworkPolicyPort {
+		var policyPorts []networkingv1.NetworkPolicyPort
+		for _, number := range numbers {
+			port := intstr.FromInt(number)
+			policyPorts = append(policyPorts, networkingv1.NetworkPolicyPort{Port: &port})
+		}
+		return policyPorts
+	}
+
+	clusterNamespaces := &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      corev1.LabelMetadataName,
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   getClusterNamespaces(dc),
+		}},
+	}
+	clusterPeer := networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: clusterNamespaces,
+		PodSelector:       &metav1.LabelSelector{MatchLabels: dc.GetClusterLabels()},
+	}
+
+	cqlPeers := dc.Spec.Networking.NetworkPolicy.CQLPeers
+	if len(cqlPeers) == 0 {
+		cqlPeers = []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}
+	}
+
+	operatorPeer := networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{},
+		PodSelector:       &metav1.LabelSelector{MatchLabels: operatorPodLabels},
+	}
+	if operatorNamespace != "" {
+		operatorPeer.NamespaceSelector.MatchLabels = map[string]string{corev1.LabelMetadataName: operatorNamespace}
+	}
+
+	ingress := []networkingv1.NetworkPolicyIngressRule{
+		{From: []networkingv1.NetworkPolicyPeer{clusterPeer}, Ports: ports(api.DefaultInternodePort, 7001)},
+		{From: cqlPeers, Ports: ports(api.DefaultNativePort, 9142)},
+		{From: []networkingv1.NetworkPolicyPeer{operatorPeer}, Ports: ports(8080)},
+	}
+	if dc.Spec.Reaper != nil {
+		reaperPeer := networkingv1.NetworkPolicyPeer{
+			NamespaceSelector: clusterNamespaces,
+			PodSelector:       &metav1.LabelSelector{MatchLabels: getReaperLabels(dc)},
+		}
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+			From:  []networkingv1.NetworkPolicyPeer{reaperPeer},
+			Ports: ports(dc.GetReaperJmxPort(), api.DefaultNativePort, 9142),
+		})
+	}
+	if dc.Spec.Monitoring != nil {
+		// Prometheus may run in any namespace
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{Ports: ports(int(dc.GetMetricsExporterPort()))})
+	}
+
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getNetworkPolicyName(dc),
+			Namespace: dc.Namespace,
+			Labels:    labels,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: dc.GetDatacenterLabels()},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     ingress,
+		},
+	}
+
+	utils.AddHashAnnotation(policy)
+	return policy
+}
+
+// getClusterNamespaces Returns the namespace of the datacenter and the ones of the other datacenters of
+// the cluster listed in the policy
+func getClusterNamespaces(dc *api.CassandraDatacenter) []string {
+	namespaces := []string{dc.Namespace}
+	for _, namespace := range dc.Spec.Networking.NetworkPolicy.ClusterNamespaces {
+		if namespace != dc.Namespace {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
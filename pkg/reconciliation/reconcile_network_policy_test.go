@@ -0,0 +1,96 @@
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+)
+
+func TestCheckNetworkPolicy(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Networking = &api.NetworkingConfig{NetworkPolicy: &api.NetworkPolicyConfig{}}
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(dc).Build()
+
+	result := rc.CheckNetworkPolicy()
+	assert.False(t, result.Completed())
+
+	policy := &networkingv1.NetworkPolicy{}
+	key := types.NamespacedName{Namespace: dc.Namespace, Name: getNetworkPolicyName(dc)}
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, policy))
+	assert.Equal(t, dc.GetDatacenterLabels(), policy.Spec.PodSelector.MatchLabels)
+	assert.Len(t, policy.Spec.Ingress, 3)
+
+	// The policy is up to date
+	resourceVersion := policy.ResourceVersion
+	result = rc.CheckNetworkPolicy()
+	assert.False(t, result.Completed())
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, policy))
+	assert.Equal(t, resourceVersion, policy.ResourceVersion)
+
+	// The policy is deleted when the property is unset
+	dc.Spec.Networking = nil
+	result = rc.CheckNetworkPolicy()
+	assert.False(t, result.Completed())
+	assert.Error(t, rc.Client.Get(rc.Ctx, key, policy))
+}
+
+func TestNewNetworkPolicyForCassandraDatacenter(t *testing.T) {
+	appPeer := networkingv1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "my-app"}},
+	}
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "ns"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "cluster1",
+			Networking: &api.NetworkingConfig{
+				NetworkPolicy: &api.NetworkPolicyConfig{
+					CQLPeers:          []networkingv1.NetworkPolicyPeer{appPeer},
+					ClusterNamespaces: []string{"ns", "ns2"},
+				},
+			},
+			Monitoring: &api.MonitoringSpec{},
+			Reaper:     &api.ReaperSpec{},
+		},
+	}
+
+	policy := newNetworkPolicyForCassandraDatacenter(dc, "cass-operator")
+	require.Len(t, policy.Spec.Ingress, 5)
+
+	internode, tlsInternode := intstr.FromInt(7000), intstr.FromInt(7001)
+	clusterRule := policy.Spec.Ingress[0]
+	assert.Equal(t, []networkingv1.NetworkPolicyPort{{Port: &internode}, {Port: &tlsInternode}}, clusterRule.Ports)
+	assert.Equal(t, map[string]string{api.ClusterLabel: "cluster1"}, clusterRule.From[0].PodSelector.MatchLabels)
+	assert.Equal(t, []metav1.LabelSelectorRequirement{{
+		Key:      "kubernetes.io/metadata.name",
+		Operator: metav1.LabelSelectorOpIn,
+		Values:   []string{"ns", "ns2"},
+	}}, clusterRule.From[0].NamespaceSelector.MatchExpressions)
+
+	native, tlsNative := intstr.FromInt(9042), intstr.FromInt(9142)
+	cqlRule := policy.Spec.Ingress[1]
+	assert.Equal(t, []networkingv1.NetworkPolicyPeer{appPeer}, cqlRule.From)
+	assert.Equal(t, []networkingv1.NetworkPolicyPort{{Port: &native}, {Port: &tlsNative}}, cqlRule.Ports)
+
+	operatorRule := policy.Spec.Ingress[2]
+	assert.Equal(t, map[string]string{"kubernetes.io/metadata.name": "cass-operator"}, operatorRule.From[0].NamespaceSelector.MatchLabels)
+	assert.Equal(t, map[string]string{"name": "cass-operator"}, operatorRule.From[0].PodSelector.MatchLabels)
+
+	// Reaper reaches JMX and CQL
+	reaperRule := policy.Spec.Ingress[3]
+	assert.Equal(t, getReaperLabels(dc), reaperRule.From[0].PodSelector.MatchLabels)
+	assert.Len(t, reaperRule.Ports, 3)
+
+	// The metrics are open to Prometheus
+	assert.Empty(t, policy.Spec.Ingress[4].From)
+}
@@ -0,0 +1,162 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+)
+
+// podNameLabel is set by the StatefulSet controller on each of its pods
+const podNameLabel = "statefulset.kubernetes.io/pod-name"
+
+// CheckPodServices When the pods are exposed by a LoadBalancer podExposure, creates the Service of each pod
+// of the datacenter at its full size, before the pods need them. Their addresses are published by
+// CheckBroadcastAddresses. The Services of the pods that no longer exist are deleted.
+func (rc *ReconciliationContext) CheckPodServices() result.ReconcileResult {
+	dc := rc.Datacenter
+	enabled := dc.IsPodExposureEnabled(api.PodExposureLoadBalancer)
+
+	desired := map[string]*corev1.Service{}
+	if enabled {
+		rc.ReqLogger.Info("reconcile_pod_exposure::CheckPodServices")
+		for _, podName := range getDesiredPodNames(dc) {
+			desired[dc.GetPodServiceName(podName)] = newPodServiceForCassandraDatacenter(dc, podName)
+		}
+	}
+
+	serviceList := &corev1.ServiceList{}
+	if err := rc.Client.List(rc.Ctx, serviceList, client.InNamespace(dc.Namespace),
+		client.MatchingLabels(dc.GetDatacenterLabels())); err != nil {
+		return result.Error(err)
+	}
+
+	current := map[string]*corev1.Service{}
+	for idx := range serviceList.Items {
+		service := &serviceList.Items[idx]
+		if _, isPodService := service.Spec.Selector[podNameLabel]; !isPodService {
+			continue
+		}
+		if _, found := desired[service.Name]; !found {
+			rc.ReqLogger.Info("deleting pod service", "service", service.Name)
+			if err := rc.Client.Delete(rc.Ctx, service); err != nil && !errors.IsNotFound(err) {
+				return result.Error(err)
+			}
+			continue
+		}
+		current[service.Name] = service
+	}
+
+	for name, service := range desired {
+		existing, found := current[name]
+		if !found {
+			if err := rc.SetDatacenterAsOwner(service); err != nil {
+				return result.Error(err)
+			}
+			rc.ReqLogger.Info("creating pod service", "service", name)
+			if err := rc.Client.Create(rc.Ctx, service); err != nil {
+				rc.ReqLogger.Error(err, "failed to create pod service", "service", name)
+				return result.Error(err)
+			}
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource, "Created service %s", name)
+			continue
+		}
+
+		if !utils.ResourcesHaveSameHash(existing, service) {
+			existing.Labels = utils.MergeMap(map[string]string{}, existing.Labels, service.Labels)
+			existing.Annotations = utils.MergeMap(map[string]string{}, existing.Annotations, service.Annotations)
+			existing.Spec.Ports = service.Spec.Ports
+			existing.Spec.Selector = service.Spec.Selector
+			existing.Spec.PublishNotReadyAddresses = service.Spec.PublishNotReadyAddresses
+			rc.ReqLogger.Info("updating pod service", "service", name)
+			if err := rc.Client.Update(rc.Ctx, existing); err != nil {
+				rc.ReqLogger.Error(err, "failed to update pod service", "service", name)
+				return result.Error(err)
+			}
+		}
+	}
+
+	return result.Continue()
+}
+
+// getPodServiceAddresses Returns the addresses of the load balancers of the Services of the pods, by pod
+// UID. The Services without an address yet are skipped.
+func (rc *ReconciliationContext) getPodServiceAddresses() (map[string]string, error) {
+	dc := rc.Datacenter
+	addresses := map[string]string{}
+	for _, pod := range rc.dcPods {
+		service := &corev1.Service{}
+		key := types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetPodServiceName(pod.Name)}
+		if err := rc.Client.Get(rc.Ctx, key, service); errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if address := getLoadBalancerAddress(service); address != "" {
+			addresses[string(pod.UID)] = address
+		}
+	}
+	return addresses, nil
+}
+
+// getLoadBalancerAddress Returns the IP of the load balancer of the Service, or its hostname, which the
+// node resolves when it starts
+func getLoadBalancerAddress(service *corev1.Service) string {
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			return ingress.IP
+		} else if ingress.Hostname != "" {
+			return ingress.Hostname
+		}
+	}
+	return ""
+}
+
+// newPodServiceForCassandraDatacenter creates a LoadBalancer service owned by the CassandraDatacenter in
+// front of the CQL and internode ports of a single pod, whether it is ready or not
+func newPodServiceForCassandraDatacenter(dc *api.CassandraDatacenter, podName string) *corev1.Service {
+	service := makeGenericHeadlessService(dc)
+	service.ObjectMeta.Name = dc.GetPodServiceName(podName)
+
+	service.Spec.Type = corev1.ServiceTypeLoadBalancer
+	// Note: ClusterIp = "None" is not valid for LoadBalancer
+	service.Spec.ClusterIP = ""
+	service.Spec.Selector = map[string]string{podNameLabel: podName}
+	// The nodes gossip before they are ready
+	service.Spec.PublishNotReadyAddresses = true
+
+	service.Spec.Ports = []corev1.ServicePort{
+		namedServicePort("native", api.DefaultNativePort, api.DefaultNativePort),
+		namedServicePort("tls-native", 9142, 9142),
+		namedServicePort("internode", api.DefaultInternodePort, api.DefaultInternodePort),
+		namedServicePort("tls-internode", 7001, 7001),
+	}
+
+	addAdditionalOptions(service, &dc.Spec.AdditionalServiceConfig.PodService)
+
+	utils.AddHashAnnotation(service)
+
+	return service
+}
+
+// getPodExposureHostPorts Returns the ports of the server container with the same port on the worker,
+// for a HostPort podExposure
+func getPodExposureHostPorts(ports []corev1.ContainerPort) []corev1.ContainerPort {
+	exposed := map[string]bool{"native": true, "tls-native": true, "internode": true, "tls-internode": true}
+	out := make([]corev1.ContainerPort, 0, len(ports))
+	for _, port := range ports {
+		if exposed[port.Name] && port.HostPort == 0 {
+			port.HostPort = port.ContainerPort
+		}
+		out = append(out, port)
+	}
+	return out
+}
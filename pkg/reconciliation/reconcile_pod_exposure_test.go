@@ -0,0 +1,96 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+)
+
+func TestNewPodServiceForCassandraDatacenter(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "test"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "cluster1",
+			AdditionalServiceConfig: api.ServiceConfig{
+				PodService: api.ServiceConfigAdditions{Annotations: map[string]string{"lb": "internal"}},
+			},
+		},
+	}
+
+	service := newPodServiceForCassandraDatacenter(dc, "cluster1-dc1-default-sts-0")
+	assert.Equal(t, "cluster1-dc1-default-sts-0-service", service.Name)
+	assert.Equal(t, corev1.ServiceTypeLoadBalancer, service.Spec.Type)
+	assert.Equal(t, "", service.Spec.ClusterIP)
+	assert.True(t, service.Spec.PublishNotReadyAddresses)
+	assert.Equal(t, map[string]string{podNameLabel: "cluster1-dc1-default-sts-0"}, service.Spec.Selector)
+	assert.Equal(t, "internal", service.Annotations["lb"])
+	assert.Len(t, service.Spec.Ports, 4)
+}
+
+func TestCheckPodServices(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Size = 2
+	dc.Spec.Networking = &api.NetworkingConfig{
+		PodExposure: &api.PodExposureConfig{Type: api.PodExposureLoadBalancer},
+	}
+	podNames := getDesiredPodNames(dc)
+	require.Len(t, podNames, 2)
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: podNames[0], Namespace: dc.Namespace, UID: "uid-0"}}
+	rc.dcPods = []*corev1.Pod{pod}
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(dc, pod).Build()
+
+	// The Services are created before the load balancers have addresses
+	recResult := rc.CheckPodServices()
+	assert.False(t, recResult.Completed())
+	recResult = rc.CheckBroadcastAddresses()
+	assert.False(t, recResult.Completed())
+	configMap := &corev1.ConfigMap{}
+	configMapKey := types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetBroadcastAddressesConfigMapName()}
+	require.NoError(t, rc.Client.Get(rc.Ctx, configMapKey, configMap))
+	assert.Empty(t, configMap.Data)
+
+	service := &corev1.Service{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetPodServiceName(podNames[0])}, service))
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}}
+	require.NoError(t, rc.Client.Status().Update(rc.Ctx, service))
+
+	recResult = rc.CheckBroadcastAddresses()
+	assert.False(t, recResult.Completed())
+	require.NoError(t, rc.Client.Get(rc.Ctx, configMapKey, configMap))
+	assert.Equal(t, map[string]string{"uid-0": "203.0.113.10"}, configMap.Data)
+	assert.Equal(t, "203.0.113.10", pod.Annotations[api.BroadcastAddressAnnotation])
+	assert.Equal(t, "203.0.113.10", getRpcAddress(dc, pod))
+
+	// Scaling down deletes the Service of the removed pod
+	dc.Spec.Size = 1
+	recResult = rc.CheckPodServices()
+	assert.False(t, recResult.Completed())
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetPodServiceName(podNames[1])}, &corev1.Service{})
+	assert.True(t, errors.IsNotFound(err))
+
+	// Disabling the exposure deletes everything
+	dc.Spec.Networking = nil
+	recResult = rc.CheckPodServices()
+	assert.False(t, recResult.Completed())
+	recResult = rc.CheckBroadcastAddresses()
+	assert.False(t, recResult.Completed())
+	err = rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetPodServiceName(podNames[0])}, &corev1.Service{})
+	assert.True(t, errors.IsNotFound(err))
+	err = rc.Client.Get(rc.Ctx, configMapKey, &corev1.ConfigMap{})
+	assert.True(t, errors.IsNotFound(err))
+}
@@ -8,11 +8,13 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,6 +28,7 @@ import (
 	"github.com/k8ssandra/cass-operator/pkg/events"
 	"github.com/k8ssandra/cass-operator/pkg/httphelper"
 	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/monitoring"
 	"github.com/k8ssandra/cass-operator/pkg/oplabels"
 	"github.com/k8ssandra/cass-operator/pkg/psp"
 	"github.com/k8ssandra/cass-operator/pkg/utils"
@@ -46,6 +49,25 @@ const (
 	stateDecommissioning = "Decommissioning"
 )
 
+// notEnoughNodesForRacks is the reason of the Valid condition when the size of the
+// datacenter is smaller than its number of racks
+const notEnoughNodesForRacks = "notEnoughNodesForRacks"
+
+// unsupportedServerUpgrade is the reason of the Valid condition when the serverVersion is not a
+// supported upgrade of the version running on the nodes
+const unsupportedServerUpgrade = "unsupportedServerUpgrade"
+
+// patchValidCondition updates the Valid condition of the datacenter if its status changed
+func (rc *ReconciliationContext) patchValidCondition(status corev1.ConditionStatus, reason string, message string) error {
+	dcPatch := client.MergeFrom(rc.Datacenter.DeepCopy())
+	updated := rc.setCondition(
+		api.NewDatacenterConditionWithReason(api.DatacenterValid, status, reason, message))
+	if !updated {
+		return nil
+	}
+	return rc.Client.Status().Patch(rc.Ctx, rc.Datacenter, dcPatch)
+}
+
 // CalculateRackInformation determine how many nodes per rack are needed
 func (rc *ReconciliationContext) CalculateRackInformation() error {
 
@@ -57,9 +79,21 @@ func (rc *ReconciliationContext) CalculateRackInformation() error {
 	racks := rc.Datacenter.GetRacks()
 	rackCount := len(racks)
 	if nodeCount < rackCount && rc.Datacenter.GetDeletionTimestamp() == nil {
+		msg := fmt.Sprintf("The size %d is smaller than the number of racks %d, each rack needs at least one node", nodeCount, rackCount)
+		rc.Recorder.Event(rc.Datacenter, corev1.EventTypeWarning, events.InvalidDatacenterSpec, msg)
+		if err := rc.patchValidCondition(corev1.ConditionFalse, notEnoughNodesForRacks, msg); err != nil {
+			rc.ReqLogger.Error(err, "error patching condition Valid for the number of racks")
+		}
 		return fmt.Errorf("the number of nodes cannot be smaller than the number of racks")
 	}
 
+	// The Valid condition would otherwise prevent any further reconciliation once the size is fixed
+	if cond, found := rc.Datacenter.GetCondition(api.DatacenterValid); found && cond.Reason == notEnoughNodesForRacks {
+		if err := rc.patchValidCondition(corev1.ConditionTrue, "", ""); err != nil {
+			return err
+		}
+	}
+
 	if rc.Datacenter.Spec.Stopped {
 		nodeCount = 0
 	}
@@ -68,12 +102,17 @@ func (rc *ReconciliationContext) CalculateRackInformation() error {
 	// and it's not easy for us to know if we're in a multi DC cluster in this part of the code)
 	// OR all of the nodes, if there's less than 3
 	// OR one per rack if there are four or more racks
+	// unless the spec sets its own seed count
 	seedCount := 3
-	if nodeCount < 3 {
-		seedCount = nodeCount
+	if rc.Datacenter.Spec.SeedCount > 0 {
+		seedCount = int(rc.Datacenter.Spec.SeedCount)
 	} else if rackCount > 3 {
 		seedCount = rackCount
 	}
+	if nodeCount < seedCount {
+		seedCount = nodeCount
+	}
+	minSeedsPerRack := int(rc.Datacenter.Spec.MinSeedsPerRack)
 
 	var desiredRackInformation []*RackInformation
 
@@ -89,6 +128,12 @@ func (rc *ReconciliationContext) CalculateRackInformation() error {
 		nextRack.RackName = currentRack.Name
 		nextRack.NodeCount = rackNodeCounts[rackIndex]
 		nextRack.SeedCount = rackSeedCounts[rackIndex]
+		if nextRack.SeedCount < minSeedsPerRack {
+			nextRack.SeedCount = minSeedsPerRack
+		}
+		if nextRack.SeedCount > nextRack.NodeCount {
+			nextRack.SeedCount = nextRack.NodeCount
+		}
 
 		desiredRackInformation = append(desiredRackInformation, nextRack)
 	}
@@ -104,12 +149,21 @@ func (rc *ReconciliationContext) CalculateRackInformation() error {
 func (rc *ReconciliationContext) CheckSuperuserSecretCreation() result.ReconcileResult {
 	rc.ReqLogger.Info("reconcile_racks::CheckSuperuserSecretCreation")
 
-	_, err := rc.retrieveSuperuserSecretOrCreateDefault()
+	secret, err := rc.retrieveSuperuserSecretOrCreateDefault()
 	if err != nil {
 		rc.ReqLogger.Error(err, "error retrieving SuperuserSecret for CassandraDatacenter.")
 		return result.Error(err)
 	}
 
+	if rc.Datacenter.Status.SuperUserSecretName != secret.Name {
+		patch := client.MergeFrom(rc.Datacenter.DeepCopy())
+		rc.Datacenter.Status.SuperUserSecretName = secret.Name
+		if err := rc.Client.Status().Patch(rc.Ctx, rc.Datacenter, patch); err != nil {
+			rc.ReqLogger.Error(err, "error updating the superuser secret name")
+			return result.Error(err)
+		}
+	}
+
 	return result.Continue()
 }
 
@@ -125,8 +179,14 @@ func (rc *ReconciliationContext) CheckInternodeCredentialCreation() result.Recon
 	return result.Continue()
 }
 
+// CheckRackCreation creates the StatefulSets of all the racks that do not have one yet
+// in a single pass, the nodes are then started by CheckPodsReady.
 func (rc *ReconciliationContext) CheckRackCreation() result.ReconcileResult {
 	rc.ReqLogger.Info("reconcile_racks::CheckRackCreation")
+	// a failure to create the StatefulSet of one rack should not hold back the
+	// creation of the other racks, the first error is returned once all the
+	// racks were visited
+	var firstErr error
 	for idx := range rc.desiredRackInformation {
 		rackInfo := rc.desiredRackInformation[idx]
 
@@ -151,23 +211,53 @@ func (rc *ReconciliationContext) CheckRackCreation() result.ReconcileResult {
 					err,
 					"error creating new StatefulSet",
 					"Rack", rackInfo.RackName)
-				return result.Error(err)
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
 			}
 		}
 		rc.statefulSets[idx] = statefulSet
 	}
 
+	if firstErr != nil {
+		return result.Error(firstErr)
+	}
+
 	return result.Continue()
 }
 
-func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
+// isCanaryUpgradeActive returns true if changes should still be held back to the canary nodes,
+// i.e. CanaryUpgrade is turned on and CanaryUpgradePauseSeconds has not yet elapsed.
+func (rc *ReconciliationContext) isCanaryUpgradeActive() bool {
+	dc := rc.Datacenter
+	if !dc.Spec.CanaryUpgrade {
+		return false
+	}
+
+	if dc.Spec.CanaryUpgradePauseSeconds <= 0 {
+		return true
+	}
+
+	updating, found := dc.GetCondition(api.DatacenterUpdating)
+	if !found || updating.Status != corev1.ConditionTrue {
+		return true
+	}
+
+	pause := time.Duration(dc.Spec.CanaryUpgradePauseSeconds) * time.Second
+	return time.Since(updating.LastTransitionTime.Time) < pause
+}
+
+func (rc *ReconciliationContext) CheckRackPodTemplate(endpointData httphelper.CassMetadataEndpoints) result.ReconcileResult {
 	logger := rc.ReqLogger
 	dc := rc.Datacenter
 	logger.Info("starting CheckRackPodTemplate()")
 
+	canaryUpgrade := rc.isCanaryUpgradeActive()
+
 	for idx := range rc.desiredRackInformation {
 		rackName := rc.desiredRackInformation[idx].RackName
-		if dc.Spec.CanaryUpgrade && idx > 0 {
+		if canaryUpgrade && idx > 0 {
 			logger.
 				WithValues("rackName", rackName).
 				Info("Skipping rack because CanaryUpgrade is turned on")
@@ -182,6 +272,17 @@ func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 			return result.Error(err)
 		}
 
+		if len(dc.Spec.ConfigSecret) == 0 && usesInlineConfig(statefulSet) {
+			// The StatefulSet only switches to the mounted config map when it is updated anyway
+			inlineSts, err := withInlineConfig(desiredSts, dc, rackName)
+			if err != nil {
+				return result.Error(err)
+			}
+			if utils.ResourcesHaveSameHash(statefulSet, inlineSts) {
+				desiredSts = inlineSts
+			}
+		}
+
 		// Set the CassandraDatacenter as the owner and controller
 		err = setControllerReference(
 			rc.Datacenter,
@@ -209,7 +310,7 @@ func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 			desiredSts.Spec.Selector = statefulSet.Spec.Selector
 			desiredSts.Spec.Template.Labels = statefulSet.Spec.Template.Labels
 
-			if dc.Spec.CanaryUpgrade {
+			if canaryUpgrade {
 				var partition int32
 				if dc.Spec.CanaryUpgradeCount == 0 || dc.Spec.CanaryUpgradeCount > int32(rc.desiredRackInformation[idx].NodeCount) {
 					partition = int32(rc.desiredRackInformation[idx].NodeCount)
@@ -223,6 +324,20 @@ func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 					},
 				}
 				desiredSts.Spec.UpdateStrategy = strategy
+			} else if dc.Spec.OnDeleteUpdates {
+				// the pods are deleted one at a time by deleteNextOutdatedPod
+				desiredSts.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+					Type: appsv1.OnDeleteStatefulSetStrategyType,
+				}
+			} else if dc.Spec.PartitionedUpdates {
+				// hold back all the pods, they are released one at a time by releaseNextPartitionedPod
+				partition := *desiredSts.Spec.Replicas
+				desiredSts.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+					Type: appsv1.RollingUpdateStatefulSetStrategyType,
+					RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
+						Partition: &partition,
+					},
+				}
 			}
 			stateMeta, err := meta.Accessor(statefulSet)
 			resVersion := stateMeta.GetResourceVersion()
@@ -279,15 +394,51 @@ func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 			return result.Done()
 		} else {
 
+			if statefulSet.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+				if !dc.Spec.OnDeleteUpdates {
+					// leave the pods not updated yet to the StatefulSet controller
+					logger.Info("switching back to the RollingUpdate strategy", "rackName", rackName)
+
+					stsPatch := client.MergeFrom(statefulSet.DeepCopy())
+					statefulSet.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+						Type: appsv1.RollingUpdateStatefulSetStrategyType,
+					}
+					if err := rc.Client.Patch(rc.Ctx, statefulSet, stsPatch); err != nil {
+						return result.Error(err)
+					}
+
+					return result.Done()
+				}
+
+				if recResult := rc.deleteNextOutdatedPod(rackName, statefulSet, endpointData); recResult.Completed() {
+					return recResult
+				}
+			}
+
+			if !canaryUpgrade && hasUpdatePartition(statefulSet) && dc.Spec.PartitionedUpdates {
+				return rc.releaseNextPartitionedPod(rackName, statefulSet, endpointData)
+			}
+
+			// the canary upgrade is over, release the pods held back by the partition
+			if !canaryUpgrade && hasUpdatePartition(statefulSet) {
+				logger.Info("releasing canary upgrade partition", "rackName", rackName)
+
+				stsPatch := client.MergeFrom(statefulSet.DeepCopy())
+				statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition = nil
+				if err := rc.Client.Patch(rc.Ctx, statefulSet, stsPatch); err != nil {
+					return result.Error(err)
+				}
+
+				return result.Done()
+			}
+
 			// the pod template is right, but if any pods don't match it,
 			// or are missing, we should not move onto the next rack,
 			// because there's an upgrade in progress
 
+			// with OnDelete, deleteNextOutdatedPod already made sure every pod runs the update revision
 			status := statefulSet.Status
-			if statefulSet.Generation != status.ObservedGeneration ||
-				status.Replicas != status.ReadyReplicas ||
-				status.Replicas != status.CurrentReplicas ||
-				status.Replicas != status.UpdatedReplicas {
+			if !IsStatefulSetUpdated(statefulSet) || status.Replicas != status.ReadyReplicas {
 
 				logger.Info(
 					"waiting for upgrade to finish on statefulset",
@@ -300,6 +451,21 @@ func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 
 				return result.RequeueSoon(10)
 			}
+
+			// Pod readiness only tells us the local node is up, make sure the upgraded nodes have
+			// rejoined the ring before moving on to the next rack
+			if dc.GetConditionStatus(api.DatacenterUpdating) == corev1.ConditionTrue && len(endpointData.Entity) > 0 {
+				rackPods := FilterPodListByLabels(rc.dcPods, dc.GetRackLabels(rackName))
+				if pod := findPodNotUpAndNormal(dc, rackPods, endpointData); pod != nil {
+					logger.Info(
+						"waiting for updated node to rejoin the ring",
+						"rackName", rackName,
+						"pod", pod.Name,
+					)
+
+					return result.RequeueSoon(10)
+				}
+			}
 		}
 	}
 
@@ -307,6 +473,111 @@ func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 	return result.Continue()
 }
 
+// deleteNextOutdatedPod deletes the next pod of the rack not running the update revision of its OnDelete
+// StatefulSet, for it to be recreated from the new pod template. Non-seed nodes are deleted first, from the
+// highest ordinal, and only once all the pods of the rack are ready and up in the ring.
+func (rc *ReconciliationContext) deleteNextOutdatedPod(rackName string, statefulSet *appsv1.StatefulSet, endpointData httphelper.CassMetadataEndpoints) result.ReconcileResult {
+	logger := rc.ReqLogger.WithValues("rackName", rackName)
+	dc := rc.Datacenter
+
+	status := statefulSet.Status
+	if statefulSet.Generation != status.ObservedGeneration || status.UpdateRevision == "" {
+		return result.RequeueSoon(10)
+	}
+
+	rackPods := FilterPodListByLabels(rc.dcPods, dc.GetRackLabels(rackName))
+	var outdatedPods []*corev1.Pod
+	for _, pod := range rackPods {
+		if pod.DeletionTimestamp != nil {
+			logger.Info("waiting for the deleted pod to be recreated", "pod", pod.Name)
+			return result.RequeueSoon(10)
+		}
+		if pod.Labels[appsv1.ControllerRevisionHashLabelKey] != status.UpdateRevision {
+			outdatedPods = append(outdatedPods, pod)
+		}
+	}
+	if len(outdatedPods) == 0 {
+		return result.Continue()
+	}
+
+	if int32(len(rackPods)) != status.Replicas || status.ReadyReplicas != status.Replicas {
+		logger.Info(
+			"waiting for the pods to be ready",
+			"replicas", status.Replicas,
+			"readyReplicas", status.ReadyReplicas,
+		)
+
+		return result.RequeueSoon(10)
+	}
+
+	if len(endpointData.Entity) > 0 {
+		if pod := findPodNotUpAndNormal(dc, rackPods, endpointData); pod != nil {
+			logger.Info("waiting for updated node to rejoin the ring", "pod", pod.Name)
+			return result.RequeueSoon(10)
+		}
+	}
+
+	sort.SliceStable(outdatedPods, func(i, j int) bool {
+		if seed := rc.isSeedPod(outdatedPods[i]); seed != rc.isSeedPod(outdatedPods[j]) {
+			return !seed
+		}
+		return getPodOrdinal(statefulSet, outdatedPods[i]) > getPodOrdinal(statefulSet, outdatedPods[j])
+	})
+	pod := outdatedPods[0]
+
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.UpdatingPod,
+		"Deleting pod %s to update it", pod.Name)
+
+	if err := rc.Client.Delete(rc.Ctx, pod); err != nil {
+		return result.Error(err)
+	}
+
+	return result.Done()
+}
+
+// releaseNextPartitionedPod lowers the partition of the StatefulSet by one, for the StatefulSet controller to
+// update the next pod, once the pods already updated are ready and have rejoined the ring.
+func (rc *ReconciliationContext) releaseNextPartitionedPod(rackName string, statefulSet *appsv1.StatefulSet, endpointData httphelper.CassMetadataEndpoints) result.ReconcileResult {
+	logger := rc.ReqLogger.WithValues("rackName", rackName)
+	dc := rc.Datacenter
+	partition := *statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition
+
+	status := statefulSet.Status
+	if statefulSet.Generation != status.ObservedGeneration ||
+		status.Replicas != status.ReadyReplicas ||
+		status.UpdatedReplicas < status.Replicas-partition {
+
+		logger.Info(
+			"waiting for the updated pods to be ready",
+			"partition", partition,
+			"replicas", status.Replicas,
+			"readyReplicas", status.ReadyReplicas,
+			"updatedReplicas", status.UpdatedReplicas,
+		)
+
+		return result.RequeueSoon(10)
+	}
+
+	if len(endpointData.Entity) > 0 {
+		rackPods := FilterPodListByLabels(rc.dcPods, dc.GetRackLabels(rackName))
+		if pod := findPodNotUpAndNormal(dc, rackPods, endpointData); pod != nil {
+			logger.Info("waiting for updated node to rejoin the ring", "pod", pod.Name)
+			return result.RequeueSoon(10)
+		}
+	}
+
+	logger.Info("releasing the next pod held back by the partition", "partition", partition-1)
+
+	stsPatch := client.MergeFrom(statefulSet.DeepCopy())
+	partition--
+	statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition = &partition
+	if err := rc.Client.Patch(rc.Ctx, statefulSet, stsPatch); err != nil {
+		return result.Error(err)
+	}
+
+	return result.Done()
+}
+
 func (rc *ReconciliationContext) CheckRackForceUpgrade() result.ReconcileResult {
 	// This code is *very* similar to CheckRackPodTemplate(), but it's not an exact
 	// copy. Some 3 to 5 line parts could maybe be extracted into functions.
@@ -407,6 +678,211 @@ func (rc *ReconciliationContext) deleteStatefulSet(statefulSet *appsv1.StatefulS
 	return nil
 }
 
+// CheckVolumeClaimSizes expands the PVCs of a rack when the storage request of a volume claim in the
+// Datacenter spec grows: the server data, commit log and saved caches claims, and the additional volumes.
+// Since volumeClaimTemplates are immutable, the StatefulSet is then deleted without its pods and recreated
+// by CheckRackCreation with the new templates. A rack is only resized once the StorageClasses of all its
+// growing claims allow volume expansion.
+func (rc *ReconciliationContext) CheckVolumeClaimSizes() result.ReconcileResult {
+	logger := rc.ReqLogger
+	dc := rc.Datacenter
+	logger.Info("reconcile_racks::CheckVolumeClaimSizes")
+
+	blockedRacks := []string{}
+	for idx := range rc.desiredRackInformation {
+		rackName := rc.desiredRackInformation[idx].RackName
+		statefulSet := rc.statefulSets[idx]
+
+		var growing []corev1.PersistentVolumeClaim
+		blocked := false
+		for _, claim := range getDesiredVolumeClaims(dc) {
+			desiredSize, found := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+			if !found {
+				continue
+			}
+			currentSize, found := getVolumeClaimTemplateSize(statefulSet, claim.Name)
+			if !found || desiredSize.Cmp(currentSize) <= 0 {
+				continue
+			}
+
+			supported, err := rc.storageClassAllowsVolumeExpansion(claim.Spec.StorageClassName)
+			if err != nil {
+				return result.Error(err)
+			}
+			if !supported {
+				blocked = true
+				break
+			}
+			growing = append(growing, claim)
+		}
+		if blocked {
+			blockedRacks = append(blockedRacks, rackName)
+			continue
+		}
+		if len(growing) == 0 {
+			continue
+		}
+
+		pvcs, err := rc.listPVCs()
+		if err != nil {
+			return result.Error(err)
+		}
+
+		for _, claim := range growing {
+			desiredSize := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+			for i := range pvcs.Items {
+				pvc := &pvcs.Items[i]
+				if pvc.Labels[api.RackLabel] != rackName || !strings.HasPrefix(pvc.Name, claim.Name+"-"+statefulSet.Name+"-") {
+					continue
+				}
+				if pvcSize := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; desiredSize.Cmp(pvcSize) <= 0 {
+					continue
+				}
+
+				pvcPatch := client.MergeFrom(pvc.DeepCopy())
+				pvc.Spec.Resources.Requests[corev1.ResourceStorage] = desiredSize
+				if err := rc.Client.Patch(rc.Ctx, pvc, pvcPatch); err != nil {
+					logger.Error(err, "error patching PVC size", "pvc", pvc.Name)
+					return result.Error(err)
+				}
+			}
+
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.ResizingVolumes,
+				"Resized %s PVCs of rack %s to %s", claim.Name, rackName, desiredSize.String())
+		}
+
+		// Recreate the StatefulSet to pick up the new volumeClaimTemplates, the pods are left running
+		if err := rc.deleteStatefulSet(statefulSet); err != nil {
+			return result.Error(err)
+		}
+
+		return result.Done()
+	}
+
+	return rc.checkVolumeResizeBlocked(blockedRacks)
+}
+
+// getDesiredVolumeClaims Returns the volume claims of the StatefulSets of the Datacenter, with the names
+// and specs of their volumeClaimTemplates
+func getDesiredVolumeClaims(dc *api.CassandraDatacenter) []corev1.PersistentVolumeClaim {
+	var claims []corev1.PersistentVolumeClaim
+	storageConfig := dc.Spec.StorageConfig
+	if claim := storageConfig.CassandraDataVolumeClaimSpec; claim != nil && !dc.IsEphemeralStorageEnabled() {
+		claims = append(claims, corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: PvcName}, Spec: *claim})
+	}
+	if claim := storageConfig.CommitLogVolumeClaimSpec; claim != nil {
+		claims = append(claims, corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: api.CommitLogVolumeName}, Spec: *claim})
+	}
+	if claim := storageConfig.SavedCachesVolumeClaimSpec; claim != nil {
+		claims = append(claims, corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: api.SavedCachesVolumeName}, Spec: *claim})
+	}
+	for _, volume := range storageConfig.AdditionalVolumes {
+		claims = append(claims, corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: volume.Name}, Spec: volume.PVCSpec})
+	}
+	return claims
+}
+
+// checkVolumeResizeBlocked sets the VolumeResizeBlocked condition while the PVCs of some racks can't be
+// resized, and records a warning when the racks change, instead of on every reconcile
+func (rc *ReconciliationContext) checkVolumeResizeBlocked(blockedRacks []string) result.ReconcileResult {
+	dc := rc.Datacenter
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	var updated bool
+	if len(blockedRacks) > 0 {
+		message := fmt.Sprintf("Storage class of racks %s does not allow volume expansion, PVCs can't be resized", strings.Join(blockedRacks, ", "))
+		updated = rc.setCondition(api.NewDatacenterConditionWithReason(
+			api.DatacenterVolumeResizeBlocked, corev1.ConditionTrue, "volumeExpansionNotAllowed", message))
+		if updated {
+			rc.Recorder.Event(dc, corev1.EventTypeWarning, events.InvalidDatacenterSpec, message)
+		}
+	} else if dc.GetConditionStatus(api.DatacenterVolumeResizeBlocked) == corev1.ConditionTrue {
+		updated = rc.setCondition(api.NewDatacenterCondition(api.DatacenterVolumeResizeBlocked, corev1.ConditionFalse))
+	}
+
+	if updated {
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			rc.ReqLogger.Error(err, "error patching datacenter status for volume resize")
+			return result.Error(err)
+		}
+	}
+
+	return result.Continue()
+}
+
+// storageClassAllowsVolumeExpansion returns true if the named StorageClass, or the default one
+// when name is not set, supports expanding volumes.
+func (rc *ReconciliationContext) storageClassAllowsVolumeExpansion(name *string) (bool, error) {
+	storageClasses := &storagev1.StorageClassList{}
+	if err := rc.Client.List(rc.Ctx, storageClasses); err != nil {
+		return false, err
+	}
+
+	for _, sc := range storageClasses.Items {
+		if (name != nil && sc.Name == *name) ||
+			(name == nil && sc.Annotations["storageclass.kubernetes.io/is-default-class"] == "true") {
+			return sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+		}
+	}
+
+	return false, nil
+}
+
+// CheckRackPodZones verifies that the pods of racks pinned to a zone are running on k8s nodes of
+// that zone and sets the RackZoneMismatch condition accordingly. This does not block reconciliation.
+func (rc *ReconciliationContext) CheckRackPodZones() result.ReconcileResult {
+	logger := rc.ReqLogger
+	dc := rc.Datacenter
+	logger.Info("reconcile_racks::CheckRackPodZones")
+
+	misplacedPods := []string{}
+	for _, rackInfo := range rc.desiredRackInformation {
+		nodeAffinityLabels, err := rackNodeAffinitylabels(dc, rackInfo.RackName)
+		if err != nil {
+			return result.Error(err)
+		}
+		zone := nodeAffinityLabels[zoneLabel]
+		if zone == "" {
+			continue
+		}
+
+		for _, pod := range FilterPodListByLabels(rc.dcPods, dc.GetRackLabels(rackInfo.RackName)) {
+			if pod.Spec.NodeName == "" {
+				continue
+			}
+			node, err := rc.getNode(pod.Spec.NodeName)
+			if err != nil {
+				logger.Error(err, "error retrieving k8s node of pod", "pod", pod.Name)
+				return result.Error(err)
+			}
+			if nodeZone := getNodeZone(node); nodeZone != "" && nodeZone != zone {
+				misplacedPods = append(misplacedPods, pod.Name)
+			}
+		}
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	var updated bool
+	if len(misplacedPods) > 0 {
+		message := fmt.Sprintf("Pods %s are not running in the zone of their rack", strings.Join(misplacedPods, ", "))
+		updated = rc.setCondition(api.NewDatacenterConditionWithReason(
+			api.DatacenterRackZoneMismatch, corev1.ConditionTrue, "PodsOutsideRackZone", message))
+		if updated {
+			rc.Recorder.Event(dc, corev1.EventTypeWarning, events.RackZoneMismatch, message)
+		}
+	} else if dc.GetConditionStatus(api.DatacenterRackZoneMismatch) == corev1.ConditionTrue {
+		updated = rc.setCondition(api.NewDatacenterCondition(api.DatacenterRackZoneMismatch, corev1.ConditionFalse))
+	}
+
+	if updated {
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			logger.Error(err, "error patching datacenter status for rack zones")
+			return result.Error(err)
+		}
+	}
+
+	return result.Continue()
+}
+
 func (rc *ReconciliationContext) CheckRackLabels() result.ReconcileResult {
 	rc.ReqLogger.Info("reconcile_racks::CheckRackLabels")
 
@@ -419,13 +895,15 @@ func (rc *ReconciliationContext) CheckRackLabels() result.ReconcileResult {
 
 		stsLabels := statefulSet.GetLabels()
 		shouldUpdateLabels, updatedLabels := shouldUpdateLabelsForRackResource(stsLabels, rc.Datacenter, rackInfo.RackName)
+		annotationsOutdated, updatedAnnotations := shouldUpdateAnnotations(statefulSet.GetAnnotations(), rc.Datacenter)
 
-		if shouldUpdateLabels {
+		if shouldUpdateLabels || annotationsOutdated {
 			rc.ReqLogger.Info("Updating labels",
 				"statefulSet", statefulSet,
 				"current", stsLabels,
 				"desired", updatedLabels)
 			statefulSet.SetLabels(updatedLabels)
+			statefulSet.SetAnnotations(updatedAnnotations)
 
 			if err := rc.Client.Patch(rc.Ctx, statefulSet, patch); err != nil {
 				return result.Error(err)
@@ -481,12 +959,18 @@ func (rc *ReconciliationContext) CheckRackStoppedState() result.ReconcileResult
 			}
 
 			rackPods := FilterPodListByLabels(rc.dcPods, rc.Datacenter.GetRackLabels(rackInfo.RackName))
+			rackPodsByName := make(map[string]*corev1.Pod, len(rackPods))
+			for _, pod := range rackPods {
+				rackPodsByName[pod.Name] = pod
+			}
 
 			nodesDrained := 0
 			nodeDrainErrors := 0
 
-			for _, pod := range rackPods {
-				if isMgmtApiRunning(pod) {
+			// drain the pods in the order the StatefulSet scales them down, highest ordinal first
+			for podIdx := currentPodCount - 1; podIdx >= 0; podIdx-- {
+				pod, found := rackPodsByName[getStatefulSetPodNameForIdx(statefulSet, podIdx)]
+				if found && isMgmtApiRunning(pod) {
 					nodesDrained++
 					err := rc.NodeMgmtClient.CallDrainEndpoint(pod)
 					// if we got an error during drain, just log it and count it
@@ -523,15 +1007,22 @@ func (rc *ReconciliationContext) CheckRackStoppedState() result.ReconcileResult
 // checkSeedLabels loops over all racks and makes sure that the proper pods are labelled as seeds.
 func (rc *ReconciliationContext) checkSeedLabels() (int, error) {
 	rc.ReqLogger.Info("reconcile_racks::CheckSeedLabels")
+	if rc.Datacenter.Spec.ManagedSeedEndpoints {
+		return rc.checkSeedEndpoints()
+	}
 	seedCount := 0
+	var firstErr error
 	for idx := range rc.desiredRackInformation {
 		rackInfo := rc.desiredRackInformation[idx]
 		n, err := rc.labelSeedPods(rackInfo)
 		seedCount += n
-		if err != nil {
-			return 0, err
+		if err != nil && firstErr == nil {
+			firstErr = err
 		}
 	}
+	if firstErr != nil {
+		return 0, firstErr
+	}
 	return seedCount, nil
 }
 
@@ -576,6 +1067,12 @@ func (rc *ReconciliationContext) CheckPodsReady(endpointData httphelper.CassMeta
 	if err != nil {
 		return result.Error(err)
 	}
+	dcPatch := client.MergeFrom(rc.Datacenter.DeepCopy())
+	if rc.updateSeedsStatus() {
+		if err := rc.Client.Status().Patch(rc.Ctx, rc.Datacenter, dcPatch); err != nil {
+			return result.Error(err)
+		}
+	}
 	err = rc.refreshSeeds()
 	if err != nil {
 		return result.Error(err)
@@ -589,6 +1086,9 @@ func (rc *ReconciliationContext) CheckPodsReady(endpointData httphelper.CassMeta
 		return result.Error(err)
 	}
 	if nodeIsStarting {
+		if _, err := rc.startNodesInFlight(endpointData); err != nil {
+			return result.Error(err)
+		}
 		return result.RequeueSoon(2)
 	}
 
@@ -603,6 +1103,18 @@ func (rc *ReconciliationContext) CheckPodsReady(endpointData httphelper.CassMeta
 		return result.RequeueSoon(2)
 	}
 
+	// the nodes which already joined the ring do not need to wait for the cluster to be healthy, and
+	// can be started together
+	if rc.Datacenter.Spec.ParallelRestarts {
+		startedNodes, err := rc.startBootstrappedNodes(endpointData)
+		if err != nil {
+			return result.Error(err)
+		}
+		if startedNodes {
+			return result.RequeueSoon(2)
+		}
+	}
+
 	// step 3 - get all nodes up
 	// if the cluster isn't healthy, that's ok, but go back to step 1
 	clusterHealthy := rc.isClusterHealthy()
@@ -614,7 +1126,7 @@ func (rc *ReconciliationContext) CheckPodsReady(endpointData httphelper.CassMeta
 		rc.ReqLogger.Info(
 			"cluster isn't healthy",
 		)
-		return result.RequeueSoon(5)
+		return rc.requeueWithBackoff(5)
 	}
 
 	needsMoreNodes, err := rc.startAllNodes(endpointData)
@@ -635,6 +1147,12 @@ func (rc *ReconciliationContext) CheckPodsReady(endpointData httphelper.CassMeta
 	readyPodCount, startedLabelCount := rc.countReadyAndStarted()
 	desiredSize := int(rc.Datacenter.Spec.Size)
 
+	// while resuming, the seed nodes are brought up first and the racks are only
+	// scaled to their full size by CheckRackScale once they are all ready
+	if readyPodCount < desiredSize && rc.isResuming() {
+		return result.RequeueSoon(2)
+	}
+
 	if desiredSize <= readyPodCount && desiredSize <= startedLabelCount {
 		return result.Continue()
 	} else {
@@ -732,13 +1250,48 @@ func hasStatefulSetControllerCaughtUp(statefulSets []*appsv1.StatefulSet, dcPods
 	return true
 }
 
+// isResuming returns true if the datacenter is coming back from a stopped state
+func (rc *ReconciliationContext) isResuming() bool {
+	dc := rc.Datacenter
+	if dc.Spec.Stopped {
+		return false
+	}
+	return dc.GetConditionStatus(api.DatacenterStopped) == corev1.ConditionTrue ||
+		dc.GetConditionStatus(api.DatacenterResuming) == corev1.ConditionTrue
+}
+
+// areSeedsReady returns true if every rack has at least as many ready pods as it has seeds
+func (rc *ReconciliationContext) areSeedsReady() bool {
+	for _, rackInfo := range rc.desiredRackInformation {
+		rackPods := FilterPodListByLabels(rc.dcPods, rc.Datacenter.GetRackLabels(rackInfo.RackName))
+		readyCount := 0
+		for _, pod := range rackPods {
+			if isServerReady(pod) {
+				readyCount++
+			}
+		}
+		if readyCount < rackInfo.SeedCount {
+			return false
+		}
+	}
+	return true
+}
+
 // CheckRackScale loops over each statefulset and makes sure that it has the right
-// amount of desired replicas. At this time we can only increase the amount of replicas.
+// amount of desired replicas. Only scaling up is handled here, scaling down is done
+// one node at a time by DecommissionNodes. All the racks are scaled in the same pass,
+// their nodes being started in turn by CheckPodsReady to keep the racks balanced.
+//
+// When resuming a stopped datacenter, the racks are first scaled to their seed nodes
+// only. The seed pods reattach their existing PVCs and get their seed label back in
+// CheckPodsReady, and the remaining nodes are added once the seeds of every rack are ready.
 func (rc *ReconciliationContext) CheckRackScale() result.ReconcileResult {
 	logger := rc.ReqLogger
 	logger.Info("reconcile_racks::CheckRackScale")
 	dc := rc.Datacenter
 
+	seedsFirst := rc.isResuming() && !rc.areSeedsReady()
+
 	for idx := range rc.desiredRackInformation {
 		rackInfo := rc.desiredRackInformation[idx]
 		statefulSet := rc.statefulSets[idx]
@@ -746,6 +1299,9 @@ func (rc *ReconciliationContext) CheckRackScale() result.ReconcileResult {
 		// By the time we get here we know all the racks are ready for that particular size
 
 		desiredNodeCount := int32(rackInfo.NodeCount)
+		if seedsFirst && int32(rackInfo.SeedCount) < desiredNodeCount {
+			desiredNodeCount = int32(rackInfo.SeedCount)
+		}
 		maxReplicas := *statefulSet.Spec.Replicas
 
 		if maxReplicas < desiredNodeCount {
@@ -860,6 +1416,10 @@ func (rc *ReconciliationContext) UpdateSecretWatches() error {
 		name := types.NamespacedName{Name: user.SecretName, Namespace: dc.Namespace}
 		names = append(names, name)
 	}
+	// The keystores follow the renewals of the certificates issued by cert-manager
+	for _, secretName := range getCertificateSecretNames(dc) {
+		names = append(names, types.NamespacedName{Name: secretName, Namespace: dc.Namespace})
+	}
 	dcNamespacedName := types.NamespacedName{Name: dc.Name, Namespace: dc.Namespace}
 	err := rc.SecretWatches.UpdateWatch(dcNamespacedName, names)
 
@@ -902,6 +1462,14 @@ func (rc *ReconciliationContext) CreateUsers() result.ReconcileResult {
 		}
 	}
 
+	patch := client.MergeFrom(rc.Datacenter.DeepCopy())
+
+	disabledDefaultSuperuser, err := rc.disableDefaultSuperuser()
+	if err != nil {
+		rc.ReqLogger.Error(err, "error dropping the default superuser")
+		return result.Error(err)
+	}
+
 	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedUsers,
 		"Created users")
 
@@ -909,12 +1477,15 @@ func (rc *ReconciliationContext) CreateUsers() result.ReconcileResult {
 	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedSuperuser,
 		"Created superuser")
 
-	patch := client.MergeFrom(rc.Datacenter.DeepCopy())
 	rc.Datacenter.Status.UsersUpserted = metav1.Now()
 
 	// For backwards compatibility
 	rc.Datacenter.Status.SuperUserUpserted = metav1.Now()
 
+	if disabledDefaultSuperuser {
+		rc.Datacenter.Status.DefaultSuperuserDisabled = metav1.Now()
+	}
+
 	if err = rc.Client.Status().Patch(rc.Ctx, rc.Datacenter, patch); err != nil {
 		rc.ReqLogger.Error(err, "error updating the users upsert timestamp")
 		return result.Error(err)
@@ -923,6 +1494,61 @@ func (rc *ReconciliationContext) CreateUsers() result.ReconcileResult {
 	return result.Continue()
 }
 
+// disableDefaultSuperuser drops the default cassandra superuser with disableDefaultSuperuser, once the
+// superuser of the operator was created, through the first ready pod. Returns whether it was dropped. When
+// the management API of the pod can not drop roles, the superuser is kept and the DefaultSuperuserKept
+// condition is set, to be patched by the caller along with the status.
+func (rc *ReconciliationContext) disableDefaultSuperuser() (bool, error) {
+	dc := rc.Datacenter
+	if !dc.Spec.DisableDefaultSuperuser || !dc.Status.DefaultSuperuserDisabled.IsZero() {
+		return false, nil
+	}
+
+	secret, err := rc.retrieveSuperuserSecret()
+	if err != nil {
+		return false, err
+	}
+	if string(secret.Data["username"]) == defaultSuperuserName {
+		rc.ReqLogger.Info("the superuser is the default one, not dropping it")
+		return false, nil
+	}
+
+	var readyPod *corev1.Pod
+	for _, pod := range rc.dcPods {
+		if isServerReady(pod) {
+			readyPod = pod
+			break
+		}
+	}
+	if readyPod == nil {
+		return false, fmt.Errorf("no ready pod to drop the default superuser %s", defaultSuperuserName)
+	}
+
+	features, err := rc.NodeMgmtClient.FeatureSet(readyPod)
+	if err != nil {
+		return false, err
+	}
+	if !features.Supports(httphelper.DropRole) {
+		message := fmt.Sprintf("The management API of the pods can not drop roles, the default superuser %s is kept", defaultSuperuserName)
+		if rc.setCondition(api.NewDatacenterConditionWithReason(
+			api.DatacenterDefaultSuperuserKept, corev1.ConditionTrue, "dropRoleNotSupported", message)) {
+			rc.Recorder.Event(dc, corev1.EventTypeWarning, events.KeptDefaultSuperuser, message)
+		}
+		return false, nil
+	}
+
+	if err := rc.NodeMgmtClient.CallDropRoleEndpoint(readyPod, defaultSuperuserName); err != nil {
+		return false, err
+	}
+	if dc.GetConditionStatus(api.DatacenterDefaultSuperuserKept) == corev1.ConditionTrue {
+		rc.setCondition(api.NewDatacenterCondition(api.DatacenterDefaultSuperuserKept, corev1.ConditionFalse))
+	}
+
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.DisabledDefaultSuperuser,
+		"Dropped the default superuser %s", defaultSuperuserName)
+	return true, nil
+}
+
 func findHostIdForIpFromEndpointsData(endpointsData []httphelper.EndpointState, ip string) string {
 	for _, data := range endpointsData {
 		if data.GetRpcAddress() == ip {
@@ -932,10 +1558,39 @@ func findHostIdForIpFromEndpointsData(endpointsData []httphelper.EndpointState,
 	return ""
 }
 
+// updateOperationModes sets the operation mode of the nodes from the gossip state of the
+// endpoints. The modes are left untouched when no endpoint data could be retrieved.
+func (rc *ReconciliationContext) updateOperationModes(endpointsData []httphelper.EndpointState) {
+	if len(endpointsData) == 0 {
+		return
+	}
+
+	dc := rc.Datacenter
+	for _, pod := range rc.dcPods {
+		nodeStatus, ok := dc.Status.NodeStatuses[pod.Name]
+		if !ok {
+			continue
+		}
+
+		nodeStatus.OperationMode = ""
+		ip := getRpcAddress(dc, pod)
+		for i := range endpointsData {
+			if ip != "" && endpointsData[i].GetRpcAddress() == ip {
+				nodeStatus.OperationMode = endpointsData[i].GetOperationMode()
+				break
+			}
+		}
+		dc.Status.NodeStatuses[pod.Name] = nodeStatus
+	}
+}
+
 func getRpcAddress(dc *api.CassandraDatacenter, pod *corev1.Pod) string {
+	if address, found := pod.Annotations[api.BroadcastAddressAnnotation]; found && address != "" {
+		return address
+	}
 	nc := dc.Spec.Networking
 	if nc != nil {
-		if nc.HostNetwork {
+		if nc.HostNetwork || nc.BroadcastAddress == api.BroadcastAddressHostIP || dc.IsPodExposureEnabled(api.PodExposureHostPort) {
 			return pod.Status.HostIP
 		}
 		if nc.NodePort != nil {
@@ -992,6 +1647,23 @@ func (rc *ReconciliationContext) UpdateCassandraNodeStatus(force bool) error {
 	return nil
 }
 
+// updateSeedsStatus sets the addresses of the seed pods, the ones resolved
+// by the seed service, in the datacenter status. Returns true if they changed.
+func (rc *ReconciliationContext) updateSeedsStatus() bool {
+	var seeds []string
+	for _, pod := range rc.dcPods {
+		if rc.isSeedPod(pod) && pod.Status.PodIP != "" {
+			seeds = append(seeds, pod.Status.PodIP)
+		}
+	}
+	sort.Strings(seeds)
+	if reflect.DeepEqual(seeds, rc.Datacenter.Status.Seeds) {
+		return false
+	}
+	rc.Datacenter.Status.Seeds = seeds
+	return true
+}
+
 func getTimePodCreated(pod *corev1.Pod) metav1.Time {
 	return pod.ObjectMeta.CreationTimestamp
 }
@@ -1058,14 +1730,18 @@ func (rc *ReconciliationContext) startReplacePodsIfReplacePodsSpecified() error
 
 		for _, podName := range dc.Spec.ReplaceNodes {
 			// Each podName has to be in the dcPods
+			found := false
 			for _, dcPod := range rc.dcPods {
 				if podName == dcPod.Name {
 					dc.Status.NodeReplacements = utils.AppendValuesToStringArrayIfNotPresent(
 						dc.Status.NodeReplacements, podName)
+					found = true
 					break
 				}
 			}
-			rc.ReqLogger.Error(fmt.Errorf("invalid pod name in ReplaceNodes"), "Rejected ReplaceNode entry, pod does not exist in the Datacenter", "PodName", podName)
+			if !found {
+				rc.ReqLogger.Error(fmt.Errorf("invalid pod name in ReplaceNodes"), "Rejected ReplaceNode entry, pod does not exist in the Datacenter", "PodName", podName)
+			}
 		}
 
 		if len(dc.Status.NodeReplacements) > 0 {
@@ -1102,7 +1778,7 @@ func (rc *ReconciliationContext) UpdateStatusForUserActions() error {
 	return nil
 }
 
-func (rc *ReconciliationContext) UpdateStatus() result.ReconcileResult {
+func (rc *ReconciliationContext) UpdateStatus(endpointData httphelper.CassMetadataEndpoints) result.ReconcileResult {
 	dc := rc.Datacenter
 	oldDc := rc.Datacenter.DeepCopy()
 
@@ -1111,11 +1787,15 @@ func (rc *ReconciliationContext) UpdateStatus() result.ReconcileResult {
 		return result.Error(err)
 	}
 
+	rc.updateOperationModes(endpointData.Entity)
+
 	err = rc.UpdateStatusForUserActions()
 	if err != nil {
 		return result.Error(err)
 	}
 
+	rc.updateSeedsStatus()
+
 	status := &api.CassandraDatacenterStatus{}
 	dc.Status.DeepCopyInto(status)
 	oldDc.Status.DeepCopyInto(&dc.Status)
@@ -1145,11 +1825,149 @@ func (rc *ReconciliationContext) UpdateStatus() result.ReconcileResult {
 	return result.Continue()
 }
 
+// UpdateRackStatuses records the generation of the spec that was just reconciled, the
+// progress of each rack, the number of ready nodes, the selector of the scale subresource, the storage mode and
+// the server version running on the nodes, so that users can tell whether their latest edit was acted on
+func (rc *ReconciliationContext) UpdateRackStatuses() error {
+	dc := rc.Datacenter
+	rackStatuses := make(map[string]api.RackStatus, len(rc.desiredRackInformation))
+	readyNodes := int32(0)
+	serverVersion, serverImage := dc.Status.ServerVersion, dc.Status.ServerImage
+	var statefulSets []*appsv1.StatefulSet
+	allReady := len(rc.desiredRackInformation) > 0
+
+	for _, rackInfo := range rc.desiredRackInformation {
+		statefulSet := &appsv1.StatefulSet{}
+		err := rc.Client.Get(rc.Ctx, newNamespacedNameForStatefulSet(dc, rackInfo.RackName), statefulSet)
+		if errors.IsNotFound(err) {
+			statefulSet = nil
+		} else if err != nil {
+			return err
+		}
+		rackStatus := newRackStatus(dc, rackInfo, statefulSet)
+		rackStatuses[rackInfo.RackName] = rackStatus
+		readyNodes += rackStatus.ReadyNodes
+		statefulSets = append(statefulSets, statefulSet)
+		allReady = allReady && rackStatus.Stage == api.RackStageReady
+	}
+
+	// The version is only recorded once it runs on every node, a rolling upgrade in progress
+	// keeps reporting the previous one
+	sstablesUpgrade := dc.Status.SSTablesUpgrade
+	if allReady {
+		if version, image, ok := getServerVersionAndImage(statefulSets); ok {
+			// The sstables are upgraded once the nodes all run a new major version
+			if dc.Spec.UpgradeSSTables != nil && serverVersion != "" && getMajorVersion(version) != getMajorVersion(serverVersion) {
+				sstablesUpgrade = &api.SSTablesUpgradeStatus{ServerVersion: version}
+			}
+			serverVersion, serverImage = version, image
+		}
+	}
+
+	selector := labels.SelectorFromSet(dc.GetDatacenterLabels()).String()
+	storageMode := dc.GetStorageMode()
+
+	if dc.Status.ObservedGeneration == dc.Generation &&
+		dc.Status.ReadyNodes == readyNodes &&
+		dc.Status.Selector == selector &&
+		dc.Status.StorageMode == storageMode &&
+		dc.Status.ServerVersion == serverVersion &&
+		dc.Status.ServerImage == serverImage &&
+		dc.Status.SSTablesUpgrade == sstablesUpgrade &&
+		reflect.DeepEqual(dc.Status.RackStatuses, rackStatuses) {
+		return nil
+	}
+
+	patch := client.MergeFrom(dc.DeepCopy())
+	dc.Status.ObservedGeneration = dc.Generation
+	dc.Status.RackStatuses = rackStatuses
+	dc.Status.ReadyNodes = readyNodes
+	dc.Status.Selector = selector
+	dc.Status.StorageMode = storageMode
+	dc.Status.ServerVersion = serverVersion
+	dc.Status.ServerImage = serverImage
+	dc.Status.SSTablesUpgrade = sstablesUpgrade
+	return rc.Client.Status().Patch(rc.Ctx, dc, patch)
+}
+
+// getMajorVersion returns the major number of a server version
+func getMajorVersion(version string) string {
+	return strings.Split(version, ".")[0]
+}
+
+// getServerVersionAndImage returns the server version and image of the pod templates of the StatefulSets,
+// the PRODUCT_VERSION of the config init container and the image of the cassandra container, if all the
+// StatefulSets agree on them
+func getServerVersionAndImage(statefulSets []*appsv1.StatefulSet) (string, string, bool) {
+	var serverVersion, serverImage string
+	for i, statefulSet := range statefulSets {
+		var version, image string
+		for _, c := range statefulSet.Spec.Template.Spec.InitContainers {
+			if c.Name == ServerConfigContainerName {
+				for _, env := range c.Env {
+					if env.Name == "PRODUCT_VERSION" {
+						version = env.Value
+					}
+				}
+			}
+		}
+		for _, c := range statefulSet.Spec.Template.Spec.Containers {
+			if c.Name == CassandraContainerName {
+				image = c.Image
+			}
+		}
+		if version == "" || image == "" {
+			return "", "", false
+		}
+		if i > 0 && (version != serverVersion || image != serverImage) {
+			return "", "", false
+		}
+		serverVersion, serverImage = version, image
+	}
+	return serverVersion, serverImage, serverVersion != ""
+}
+
+func newRackStatus(dc *api.CassandraDatacenter, rackInfo *RackInformation, statefulSet *appsv1.StatefulSet) api.RackStatus {
+	desiredNodes := int32(rackInfo.NodeCount)
+	if dc.Spec.Stopped {
+		desiredNodes = 0
+	}
+
+	rackStatus := api.RackStatus{DesiredNodes: desiredNodes, Stage: api.RackStagePending}
+	if statefulSet == nil {
+		return rackStatus
+	}
+
+	rackStatus.ReadyNodes = statefulSet.Status.ReadyReplicas
+	rackStatus.UpdatedNodes = statefulSet.Status.UpdatedReplicas
+
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+
+	switch {
+	case replicas != desiredNodes || statefulSet.Status.Replicas != replicas:
+		rackStatus.Stage = api.RackStageScaling
+	case desiredNodes == 0:
+		rackStatus.Stage = api.RackStageStopped
+	case statefulSet.Status.UpdatedReplicas < replicas || !IsStatefulSetUpdated(statefulSet):
+		rackStatus.Stage = api.RackStageUpdating
+	case statefulSet.Status.ReadyReplicas < desiredNodes:
+		rackStatus.Stage = api.RackStageStarting
+	default:
+		rackStatus.Stage = api.RackStageReady
+	}
+
+	return rackStatus
+}
+
 func (rc *ReconciliationContext) updateHealth(healthy bool) error {
 	updated := false
 	dcPatch := client.MergeFrom(rc.Datacenter.DeepCopy())
 
 	if !healthy {
+		monitoring.IncHealthCheckFailures(rc.Datacenter.Namespace, rc.Datacenter.Name)
 		updated = rc.setCondition(
 			api.NewDatacenterCondition(
 				api.DatacenterHealthy, corev1.ConditionFalse))
@@ -1247,7 +2065,7 @@ func (rc *ReconciliationContext) getCassMetadataEndpoints() httphelper.CassMetad
 // If we then delete this new pod, then the stateful will
 // properly recreate a pvc, pv, and pod.
 func (rc *ReconciliationContext) isNodeStuckWithoutPVC(pod *corev1.Pod) bool {
-	if pod.Status.Phase == corev1.PodPending {
+	if pod.Status.Phase == corev1.PodPending && !rc.Datacenter.IsEphemeralStorageEnabled() {
 		_, err := rc.GetPodPVC(pod.ObjectMeta.Namespace, pod.ObjectMeta.Name)
 		if err != nil {
 			if errors.IsNotFound(err) {
@@ -1286,25 +2104,153 @@ func (rc *ReconciliationContext) deleteStuckNodes() (bool, error) {
 	return false, nil
 }
 
-// isClusterHealthy does a LOCAL_QUORUM query to the Cassandra pods and returns true if all the pods were able to
-// respond without error.
+// healthCheckConcurrency is the maximum number of pods queried at the same time by isClusterHealthy
+const healthCheckConcurrency = 10
+
+// getHealthCheckSettings returns the consistency level and replication factor per datacenter of the health check
+func (rc *ReconciliationContext) getHealthCheckSettings() (string, int) {
+	consistencyLevel := "LOCAL_QUORUM"
+	if rc.Datacenter.Spec.HealthCheckConsistencyLevel != "" {
+		consistencyLevel = rc.Datacenter.Spec.HealthCheckConsistencyLevel
+	}
+
+	rfPerDc := len(rc.Datacenter.GetRacks())
+	dcs, err := rc.listClusterDatacenters()
+	if err != nil {
+		rc.ReqLogger.Error(err, "error listing the datacenters of the cluster, the health check only considers the racks of the datacenter")
+		dcs = []api.CassandraDatacenter{*rc.Datacenter}
+	}
+
+	// The operator can not tell whether the datacenters of the other Kubernetes clusters are reachable,
+	// the check then only needs the replicas of the local datacenter
+	if !strings.HasPrefix(consistencyLevel, "LOCAL_") && rc.hasRemoteDatacenters(dcs) {
+		localConsistencyLevel := "LOCAL_QUORUM"
+		if consistencyLevel == "ONE" {
+			localConsistencyLevel = "LOCAL_ONE"
+		}
+		rc.ReqLogger.Info("the cluster has remote datacenters, the health check uses a local consistency level",
+			"consistencyLevel", consistencyLevel, "localConsistencyLevel", localConsistencyLevel)
+		consistencyLevel = localConsistencyLevel
+	}
+
+	if rc.Datacenter.Spec.HealthCheckReplicationFactor > 0 {
+		return consistencyLevel, int(rc.Datacenter.Spec.HealthCheckReplicationFactor)
+	}
+
+	// The check applies the replication factor to every datacenter of the cluster, the one with the
+	// fewest racks sets it
+	for _, dc := range dcs {
+		if racks := len(dc.GetRacks()); racks < rfPerDc {
+			rfPerDc = racks
+		}
+	}
+	return consistencyLevel, rfPerDc
+}
+
+// hasRemoteDatacenters Returns true if gossip reports datacenters that are not among the datacenters of
+// the cluster in the namespace, which run in another Kubernetes cluster or outside of Kubernetes
+func (rc *ReconciliationContext) hasRemoteDatacenters(dcs []api.CassandraDatacenter) bool {
+	local := map[string]bool{}
+	for _, dc := range dcs {
+		local[dc.DatacenterName()] = true
+	}
+	for _, dcName := range rc.gossipDatacenters {
+		if !local[dcName] {
+			return true
+		}
+	}
+	return false
+}
+
+// isClusterHealthy does a LOCAL_QUORUM query, or a query at the consistency level set in the spec, to the
+// Cassandra pods and returns true if all the pods were able to respond without error. Pods that recently passed
+// the query, while no pod of the cluster changed, are not queried again, the other ones are queried in parallel.
 func (rc *ReconciliationContext) isClusterHealthy() bool {
 	pods := FilterPodListByCassNodeState(rc.clusterPods, stateStarted)
+	clusterState := ClusterPodsState(rc.clusterPods)
+
+	consistencyLevel, rfPerDc := rc.getHealthCheckSettings()
 
-	numRacks := len(rc.Datacenter.GetRacks())
+	var mu sync.Mutex
+	var unhealthyPods []string
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, healthCheckConcurrency)
 	for _, pod := range pods {
-		err := rc.NodeMgmtClient.CallProbeClusterEndpoint(pod, "LOCAL_QUORUM", numRacks)
-		if err != nil {
-			reason := fmt.Sprintf("Pod %s failed the LOCAL_QUORUM check", pod.Name)
-			rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeWarning, events.UnhealthyDatacenter,
-				reason)
-			return false
+		if rc.healthCache.IsHealthy(pod, clusterState, consistencyLevel, rfPerDc) {
+			continue
 		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(pod *corev1.Pod) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := rc.NodeMgmtClient.CallProbeClusterEndpoint(pod, consistencyLevel, rfPerDc); err != nil {
+				mu.Lock()
+				unhealthyPods = append(unhealthyPods, pod.Name)
+				mu.Unlock()
+				return
+			}
+			rc.healthCache.SetHealthy(pod, clusterState, consistencyLevel, rfPerDc)
+		}(pod)
+	}
+	wg.Wait()
+
+	if len(unhealthyPods) > 0 {
+		sort.Strings(unhealthyPods)
+		reason := fmt.Sprintf("Pods %s failed the %s check", strings.Join(unhealthyPods, ", "), consistencyLevel)
+		rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeWarning, events.UnhealthyDatacenter,
+			reason)
+		return false
 	}
 
 	return true
 }
 
+// requeueWithBackoff requeues the datacenter after secs seconds, to wait for the cluster to be healthy again.
+// Unlike the other requeues, which wait for progress the datacenter does not record, such as a decommission,
+// its delay is backed off by the controller while the datacenter stays unchanged.
+func (rc *ReconciliationContext) requeueWithBackoff(secs int) result.ReconcileResult {
+	rc.backoffRequested = true
+	return result.RequeueSoon(secs)
+}
+
+// IsBackoffRequested returns true if the reconciliation requeued to wait for the cluster to be healthy
+func (rc *ReconciliationContext) IsBackoffRequested() bool {
+	return rc.backoffRequested
+}
+
+// getGossipDatacenters Returns the sorted names of the datacenters known to gossip
+func getGossipDatacenters(epData httphelper.CassMetadataEndpoints) []string {
+	found := map[string]bool{}
+	var dcNames []string
+	for _, ep := range epData.Entity {
+		if ep.Datacenter != "" && !found[ep.Datacenter] {
+			found[ep.Datacenter] = true
+			dcNames = append(dcNames, ep.Datacenter)
+		}
+	}
+	sort.Strings(dcNames)
+	return dcNames
+}
+
+// countOtherDatacentersReadySeeds Returns the number of ready seeds of the other datacenters of the
+// cluster in the namespace. A new datacenter bootstraps from them instead of starting its own seed.
+func (rc *ReconciliationContext) countOtherDatacentersReadySeeds() int {
+	dcLabel := rc.Datacenter.GetDatacenterLabels()[api.DatacenterLabel]
+	count := 0
+	for _, pod := range rc.clusterPods {
+		if pod.Labels[api.DatacenterLabel] == dcLabel {
+			continue
+		}
+		if rc.isSeedPod(pod) && isServerReady(pod) {
+			count++
+		}
+	}
+	return count
+}
+
 // labelSeedPods iterates over all pods for a statefulset and makes sure the right number of
 // ready pods are labelled as seeds, so that they are picked up by the headless seed service
 // Returns the number of ready seeds.
@@ -1317,6 +2263,9 @@ func (rc *ReconciliationContext) labelSeedPods(rackInfo *RackInformation) (int,
 		return rackPods[i].Name < rackPods[j].Name
 	})
 	count := 0
+	// a failure to label one pod should not prevent the other pods from being
+	// labeled, the first error is returned once all the pods were visited
+	var firstErr error
 	for _, pod := range rackPods {
 		patch := client.MergeFrom(pod.DeepCopy())
 
@@ -1360,11 +2309,13 @@ func (rc *ReconciliationContext) labelSeedPods(rackInfo *RackInformation) (int,
 				logger.Error(
 					err, "Unable to update pod with seed label",
 					"pod", pod.Name)
-				return 0, err
+				if firstErr == nil {
+					firstErr = err
+				}
 			}
 		}
 	}
-	return count, nil
+	return count, firstErr
 }
 
 // GetStatefulSetForRack returns the statefulset for the rack
@@ -1464,19 +2415,24 @@ func (rc *ReconciliationContext) CheckDcPodDisruptionBudget() result.ReconcileRe
 		return result.Continue()
 	}
 
-	// it's not possible to update a PodDisruptionBudget, so we need to delete this one and remake it
+	// Budgets of policy/v1 can be updated, e.g. when the datacenter is resized
 	if found {
 		rc.ReqLogger.Info(
-			"Deleting and re-creating a PodDisruptionBudget",
+			"Updating the PodDisruptionBudget",
 			"pdbNamespace", desiredBudget.Namespace,
 			"pdbName", desiredBudget.Name,
 			"oldMinAvailable", currentBudget.Spec.MinAvailable,
 			"desiredMinAvailable", desiredBudget.Spec.MinAvailable,
+			"oldMaxUnavailable", currentBudget.Spec.MaxUnavailable,
+			"desiredMaxUnavailable", desiredBudget.Spec.MaxUnavailable,
 		)
-		err = rc.Client.Delete(ctx, currentBudget)
-		if err != nil {
+		currentBudget.Labels = utils.MergeMap(map[string]string{}, currentBudget.Labels, desiredBudget.Labels)
+		currentBudget.Annotations = utils.MergeMap(map[string]string{}, currentBudget.Annotations, desiredBudget.Annotations)
+		currentBudget.Spec = desiredBudget.Spec
+		if err := rc.Client.Update(ctx, currentBudget); err != nil {
 			return result.Error(err)
 		}
+		return result.Continue()
 	}
 
 	// Create the Budget
@@ -1547,7 +2503,8 @@ func (rc *ReconciliationContext) ReconcilePods(statefulSet *appsv1.StatefulSet)
 		podLabels := pod.GetLabels()
 		shouldUpdateLabels, updatedLabels := shouldUpdateLabelsForRackResource(podLabels,
 			rc.Datacenter, statefulSet.GetLabels()[api.RackLabel])
-		if shouldUpdateLabels {
+		annotationsOutdated, updatedAnnotations := shouldUpdateAnnotations(pod.GetAnnotations(), rc.Datacenter)
+		if shouldUpdateLabels || annotationsOutdated {
 			rc.ReqLogger.Info(
 				"Updating labels",
 				"Pod", podName,
@@ -1555,6 +2512,7 @@ func (rc *ReconciliationContext) ReconcilePods(statefulSet *appsv1.StatefulSet)
 				"desired", updatedLabels)
 
 			pod.SetLabels(updatedLabels)
+			pod.SetAnnotations(updatedAnnotations)
 
 			if err := rc.Client.Patch(rc.Ctx, pod, podPatch); err != nil {
 				rc.ReqLogger.Error(
@@ -1603,13 +2561,15 @@ func (rc *ReconciliationContext) ReconcilePods(statefulSet *appsv1.StatefulSet)
 		pvcLabels := pvc.GetLabels()
 		shouldUpdateLabels, updatedLabels = shouldUpdateLabelsForRackResource(pvcLabels,
 			rc.Datacenter, statefulSet.GetLabels()[api.RackLabel])
-		if shouldUpdateLabels {
+		annotationsOutdated, updatedAnnotations = shouldUpdateAnnotations(pvc.GetAnnotations(), rc.Datacenter)
+		if shouldUpdateLabels || annotationsOutdated {
 			rc.ReqLogger.Info("Updating labels",
 				"PVC", pvc,
 				"current", pvcLabels,
 				"desired", updatedLabels)
 
 			pvc.SetLabels(updatedLabels)
+			pvc.SetAnnotations(updatedAnnotations)
 
 			if err := rc.Client.Patch(rc.Ctx, pvc, pvcPatch); err != nil {
 				rc.ReqLogger.Error(
@@ -1636,6 +2596,15 @@ func mergeInLabelsIfDifferent(existingLabels, newLabels map[string]string) (bool
 	}
 }
 
+// shouldUpdateAnnotations will compare the annotations passed in with the additional annotations of the datacenter.
+// It will return the updated map and a boolean denoting whether the resource needs to be updated with the new annotations.
+func shouldUpdateAnnotations(resourceAnnotations map[string]string, dc *api.CassandraDatacenter) (bool, map[string]string) {
+	if mapContains(resourceAnnotations, dc.Spec.AdditionalAnnotations) {
+		return false, resourceAnnotations
+	}
+	return true, utils.MergeMap(map[string]string{}, resourceAnnotations, dc.Spec.AdditionalAnnotations)
+}
+
 // shouldUpdateLabelsForClusterResource will compare the labels passed in with what the labels should be for a cluster level
 // resource. It will return the updated map and a boolean denoting whether the resource needs to be updated with the new labels.
 func shouldUpdateLabelsForClusterResource(resourceLabels map[string]string, dc *api.CassandraDatacenter) (bool, map[string]string) {
@@ -1731,6 +2700,8 @@ func (rc *ReconciliationContext) findStartedNotReadyNodes() (bool, error) {
 
 	for _, pod := range rc.dcPods {
 		if didServerLoseReadiness(pod) {
+			rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeWarning, events.LostReadiness,
+				"Pod %s failed its readiness check", pod.Name)
 			if err := rc.labelServerPodStartedNotReady(pod); err != nil {
 				return false, err
 			}
@@ -1756,9 +2727,12 @@ func (rc *ReconciliationContext) startCassandra(endpointData httphelper.CassMeta
 	// Are we replacing this node?
 	shouldReplacePod := utils.IndexOfString(dc.Status.NodeReplacements, pod.Name) > -1
 
+	// A new pod of the ephemeral storage mode lost the data of the node of the pod it succeeds
+	lostData := !shouldReplacePod && dc.IsEphemeralStorageEnabled() && !hasServerContainerRestarted(pod)
+
 	replaceAddress := ""
 
-	if shouldReplacePod {
+	if shouldReplacePod || lostData {
 		// Get the HostID for pod if it has one
 		nodeStatus, ok := dc.Status.NodeStatuses[pod.Name]
 		hostId := ""
@@ -1770,15 +2744,18 @@ func (rc *ReconciliationContext) startCassandra(endpointData httphelper.CassMeta
 		var err error
 		if hostId != "" {
 			replaceAddress, err = FindIpForHostId(endpointData, hostId)
-			if err != nil {
+			if err != nil && shouldReplacePod {
 				return fmt.Errorf("Failed to start replace of cassandra node %s for pod %s due to error: %w", hostId, pod.Name, err)
+			} else if err != nil {
+				// No other node knows the node anymore, it bootstraps again
+				replaceAddress = ""
 			}
 		}
 	}
 
 	var err error
 
-	if shouldReplacePod && replaceAddress != "" {
+	if (shouldReplacePod || lostData) && replaceAddress != "" {
 		// If we have a replace address that means the cassandra node did
 		// join the ring previously and is marked for replacement, so we
 		// start it accordingly
@@ -1831,6 +2808,9 @@ func (rc *ReconciliationContext) startOneNodePerRack(endpointData httphelper.Cas
 		externalSeedPoints = len(existingEndpoints.Subsets[0].Addresses)
 	}
 
+	// the ready seeds of the other datacenters of the cluster are reached through the shared seed service
+	externalSeedPoints += rc.countOtherDatacentersReadySeeds()
+
 	labelSeedBeforeStart := readySeeds == 0 && len(rc.Datacenter.Spec.AdditionalSeeds) == 0 && externalSeedPoints == 0
 
 	rackThatNeedsNode := ""
@@ -1847,7 +2827,11 @@ func (rc *ReconciliationContext) startOneNodePerRack(endpointData httphelper.Cas
 			podRack := pod.Labels[api.RackLabel]
 			if podRack == rackName {
 				// this is the one exception to all seed labelling happening in labelSeedPods()
-				if labelSeedBeforeStart {
+				if labelSeedBeforeStart && rc.Datacenter.Spec.ManagedSeedEndpoints {
+					if err := rc.addSeedEndpoint(pod); err != nil {
+						return "", err
+					}
+				} else if labelSeedBeforeStart {
 					patch := client.MergeFrom(pod.DeepCopy())
 					pod.Labels[api.SeedNodeLabel] = "true"
 					if err := rc.Client.Patch(rc.Ctx, pod, patch); err != nil {
@@ -1871,12 +2855,212 @@ func (rc *ReconciliationContext) startOneNodePerRack(endpointData httphelper.Cas
 	return rackThatNeedsNode, nil
 }
 
+// startBootstrappedNodes starts Cassandra on all the pods whose node already joined the ring at once, and
+// returns whether any was started
+func (rc *ReconciliationContext) startBootstrappedNodes(endpointData httphelper.CassMetadataEndpoints) (bool, error) {
+	rc.ReqLogger.Info("reconcile_racks::startBootstrappedNodes")
+
+	started := false
+	for _, pod := range rc.dcPods {
+		if isMgmtApiRunning(pod) && isServerReadyToStart(pod) && rc.hasNodeBootstrapped(pod) {
+			if err := rc.startCassandra(endpointData, pod); err != nil {
+				return started, err
+			}
+			started = true
+		}
+	}
+
+	return started, nil
+}
+
+// hasNodeBootstrapped returns true if the node of the pod joined the ring before, with the data it still
+// has, and is not being replaced
+func (rc *ReconciliationContext) hasNodeBootstrapped(pod *corev1.Pod) bool {
+	dc := rc.Datacenter
+	if nodeStatus, ok := dc.Status.NodeStatuses[pod.Name]; !ok || nodeStatus.HostID == "" {
+		return false
+	}
+	if utils.IndexOfString(dc.Status.NodeReplacements, pod.Name) > -1 {
+		return false
+	}
+	return !dc.IsEphemeralStorageEnabled() || hasServerContainerRestarted(pod)
+}
+
+// startNodesInFlight starts more nodes while others are starting, up to maxNodesInFlight nodes starting in
+// the cluster and one per rack, once every rack has a ready node and the cluster is healthy, and only while
+// the replicas of every keyspace are in different racks, see areReplicasInDistinctRacks. Returns whether any
+// node was started.
+func (rc *ReconciliationContext) startNodesInFlight(endpointData httphelper.CassMetadataEndpoints) (bool, error) {
+	maxNodesInFlight := int(rc.Datacenter.Spec.MaxNodesInFlight)
+	if maxNodesInFlight <= 1 {
+		return false, nil
+	}
+
+	// the ready nodes still joining the ring are in flight as well
+	inFlight := len(FilterPodListByCassNodeState(rc.clusterPods, stateStarting))
+	for _, pod := range rc.dcPods {
+		if isServerStarted(pod) && isNodeJoining(rc.Datacenter, pod, endpointData) {
+			inFlight++
+		}
+	}
+	if inFlight >= maxNodesInFlight {
+		return false, nil
+	}
+
+	rc.ReqLogger.Info("reconcile_racks::startNodesInFlight")
+
+	racksInFlight := map[string]bool{}
+	racksReady := map[string]bool{}
+	for _, pod := range rc.dcPods {
+		rackName := pod.Labels[api.RackLabel]
+		if isServerStarting(pod) || isNodeJoining(rc.Datacenter, pod, endpointData) {
+			racksInFlight[rackName] = true
+		} else if isServerReady(pod) {
+			racksReady[rackName] = true
+		}
+	}
+	for _, rackInfo := range rc.desiredRackInformation {
+		if !racksReady[rackInfo.RackName] {
+			return false, nil
+		}
+	}
+
+	if rc.remainingNodeStartDelay() > 0 || !rc.isClusterHealthy() {
+		return false, nil
+	}
+
+	if distinct, err := rc.areReplicasInDistinctRacks(); err != nil {
+		return false, err
+	} else if !distinct {
+		rc.ReqLogger.Info("Starting one node at a time, some keyspaces have more replicas in the datacenter than racks")
+		return false, nil
+	}
+
+	started := false
+	for _, pod := range rc.podsByRackProgress() {
+		if inFlight >= maxNodesInFlight {
+			break
+		}
+		rackName := pod.Labels[api.RackLabel]
+		if racksInFlight[rackName] || !isMgmtApiRunning(pod) || isServerReady(pod) || !isServerReadyToStart(pod) {
+			continue
+		}
+		if err := rc.startCassandra(endpointData, pod); err != nil {
+			return started, err
+		}
+		racksInFlight[rackName] = true
+		inFlight++
+		started = true
+	}
+
+	return started, nil
+}
+
+// areReplicasInDistinctRacks returns whether no keyspace has more replicas in the datacenter than it has
+// racks, so that NetworkTopologyStrategy places the replicas of a range in distinct racks. The nodes
+// bootstrapping without consistent range movements, one per rack, then never stream the same range from
+// one another. SimpleStrategy ignores the racks, the keyspaces using it must have a single replica.
+func (rc *ReconciliationContext) areReplicasInDistinctRacks() (bool, error) {
+	var pod *corev1.Pod
+	for _, dcPod := range rc.dcPods {
+		if isServerReady(dcPod) && isMgmtApiRunning(dcPod) {
+			pod = dcPod
+			break
+		}
+	}
+	if pod == nil {
+		return false, nil
+	}
+
+	keyspaces, err := rc.NodeMgmtClient.ListKeyspaces(pod)
+	if err != nil {
+		return false, err
+	}
+	for _, keyspace := range keyspaces {
+		replication, err := rc.NodeMgmtClient.GetKeyspaceReplication(pod, keyspace)
+		if err != nil {
+			return false, err
+		}
+		rf, replicated := getDatacenterReplicationFactor(replication, rc.Datacenter.DatacenterName())
+		if !replicated {
+			continue
+		}
+		if strings.HasSuffix(replication["class"], "SimpleStrategy") && rf > 1 {
+			return false, nil
+		}
+		if rf > len(rc.desiredRackInformation) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// findJoiningPod returns the first started pod of the datacenter whose node is still joining the ring,
+// readiness being reported before the node completed its bootstrap
+func (rc *ReconciliationContext) findJoiningPod(endpointData httphelper.CassMetadataEndpoints) *corev1.Pod {
+	for _, pod := range rc.dcPods {
+		if isServerStarted(pod) && isNodeJoining(rc.Datacenter, pod, endpointData) {
+			return pod
+		}
+	}
+	return nil
+}
+
+// remainingNodeStartDelay returns how long to wait before starting the next node, nodeStartDelaySeconds
+// after a pod of the datacenter last became ready
+func (rc *ReconciliationContext) remainingNodeStartDelay() time.Duration {
+	delay := time.Duration(rc.Datacenter.Spec.NodeStartDelaySeconds) * time.Second
+	if delay <= 0 {
+		return 0
+	}
+
+	var lastReady time.Time
+	for _, pod := range rc.dcPods {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue && condition.LastTransitionTime.After(lastReady) {
+				lastReady = condition.LastTransitionTime.Time
+			}
+		}
+	}
+
+	if remaining := delay - time.Since(lastReady); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// podsByRackProgress returns the pods of the datacenter, those of the racks with the fewest ready or
+// starting nodes first, for the racks to stay within one node of each other while their nodes are started
+func (rc *ReconciliationContext) podsByRackProgress() []*corev1.Pod {
+	rackProgress := map[string]int{}
+	for _, pod := range rc.dcPods {
+		if isServerReady(pod) || isServerStarting(pod) {
+			rackProgress[pod.Labels[api.RackLabel]]++
+		}
+	}
+
+	pods := make([]*corev1.Pod, len(rc.dcPods))
+	copy(pods, rc.dcPods)
+	sort.SliceStable(pods, func(i, j int) bool {
+		return rackProgress[pods[i].Labels[api.RackLabel]] < rackProgress[pods[j].Labels[api.RackLabel]]
+	})
+	return pods
+}
+
 // returns whether one or more server nodes is not running or ready
 func (rc *ReconciliationContext) startAllNodes(endpointData httphelper.CassMetadataEndpoints) (bool, error) {
 	rc.ReqLogger.Info("reconcile_racks::startAllNodes")
 
-	for _, pod := range rc.dcPods {
+	for _, pod := range rc.podsByRackProgress() {
 		if isMgmtApiRunning(pod) && !isServerReady(pod) && !isServerStarted(pod) {
+			if joiningPod := rc.findJoiningPod(endpointData); joiningPod != nil {
+				rc.ReqLogger.Info("waiting for the node to finish joining the ring", "pod", joiningPod.Name)
+				return true, nil
+			}
+			if delay := rc.remainingNodeStartDelay(); delay > 0 {
+				rc.ReqLogger.Info("waiting before starting the next node", "pod", pod.Name, "delay", delay)
+				return true, nil
+			}
 			if err := rc.startCassandra(endpointData, pod); err != nil {
 				return false, err
 			}
@@ -1956,6 +3140,17 @@ func didServerLoseReadiness(pod *corev1.Pod) bool {
 	return false
 }
 
+// hasServerContainerRestarted returns true when the cassandra container of the pod restarted, keeping
+// the volumes of the pod
+func hasServerContainerRestarted(pod *corev1.Pod) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == "cassandra" {
+			return status.RestartCount > 0
+		}
+	}
+	return false
+}
+
 func isServerReady(pod *corev1.Pod) bool {
 	status := pod.Status
 	statuses := status.ContainerStatuses
@@ -2032,6 +3227,12 @@ func (rc *ReconciliationContext) CheckRollingRestart() result.ReconcileResult {
 	for _, pod := range rc.dcPods {
 		podStartTime := pod.GetCreationTimestamp()
 		if podStartTime.Before(cutoff) {
+			// Only take down the next node if every other node can still serve LOCAL_QUORUM
+			if !rc.isClusterHealthy() {
+				logger.Info("cluster isn't healthy, postponing rolling restart", "pod", pod.Name)
+				return rc.requeueWithBackoff(5)
+			}
+
 			rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.RestartingCassandra,
 				"Restarting Cassandra for pod %s", pod.Name)
 
@@ -2111,6 +3312,11 @@ func (rc *ReconciliationContext) CheckCassandraNodeStatuses() result.ReconcileRe
 }
 
 func (rc *ReconciliationContext) cleanupAfterScaling() result.ReconcileResult {
+	if val, found := rc.Datacenter.Annotations[api.NoAutomatedCleanupAnnotation]; found && val == "true" {
+		rc.ReqLogger.Info(api.NoAutomatedCleanupAnnotation + " is set, skipping the cleanup after scaling up")
+		return result.Continue()
+	}
+
 	// Verify if the cleanup task has completed before moving on the with ScalingUp finished
 	task, err := rc.findActiveTask(taskapi.CommandCleanup)
 	if err != nil {
@@ -2122,11 +3328,66 @@ func (rc *ReconciliationContext) cleanupAfterScaling() result.ReconcileResult {
 	}
 
 	// Create the cleanup task
-	err = rc.createTask(taskapi.CommandCleanup)
+	err = rc.createTask(taskapi.CommandCleanup, taskapi.JobArguments{})
+	if err != nil {
+		return result.Error(err)
+	}
+
+	return result.RequeueSoon(10)
+}
+
+// CheckRebuild When the RebuildFromAnnotation is set, rebuilds the nodes of the datacenter from
+// the source datacenter once they are all up, using a rebuild CassandraTask which tracks the
+// completion of each node. The annotation is removed once the task completed on all the nodes, a task which
+// failed on some nodes is reported and retried.
+func (rc *ReconciliationContext) CheckRebuild() result.ReconcileResult {
+	dc := rc.Datacenter
+	sourceDatacenter := dc.Annotations[api.RebuildFromAnnotation]
+	if sourceDatacenter == "" || dc.Spec.Stopped {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_racks::CheckRebuild")
+
+	task, err := rc.findActiveTask(taskapi.CommandRebuild)
+	if err != nil {
+		return result.Error(err)
+	}
+
+	if task != nil {
+		if task.Status.CompletionTime == nil {
+			return result.RequeueSoon(10)
+		}
+
+		if task.Status.Failed > 0 {
+			// Keep the annotation, the next pass creates a new task once this one is no longer tracked
+			rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.RebuildingDatacenter,
+				"Task %s failed to rebuild %d nodes from %s, retrying", task.Name, task.Status.Failed, sourceDatacenter)
+			if res := rc.activeTaskCompleted(task); res.Completed() {
+				return res
+			}
+			return result.RequeueSoon(60)
+		}
+
+		// Remove the annotation before the task is untracked, a new rebuild would be started otherwise
+		patch := client.MergeFrom(dc.DeepCopy())
+		delete(dc.Annotations, api.RebuildFromAnnotation)
+		if err := rc.Client.Patch(rc.Ctx, dc, patch); err != nil {
+			rc.ReqLogger.Error(err, "error removing the rebuild annotation")
+			return result.Error(err)
+		}
+
+		return rc.activeTaskCompleted(task)
+	}
+
+	err = rc.createTask(taskapi.CommandRebuild, taskapi.JobArguments{SourceDatacenter: sourceDatacenter})
 	if err != nil {
 		return result.Error(err)
 	}
 
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.RebuildingDatacenter,
+		"Rebuilding datacenter from %s", sourceDatacenter)
+
 	return result.RequeueSoon(10)
 }
 
@@ -2166,7 +3427,7 @@ func (rc *ReconciliationContext) findActiveTask(command taskapi.CassandraCommand
 	return nil, nil
 }
 
-func (rc *ReconciliationContext) createTask(command taskapi.CassandraCommand) error {
+func (rc *ReconciliationContext) createTask(command taskapi.CassandraCommand, arguments taskapi.JobArguments) error {
 	generatedName := fmt.Sprintf("%s-%d", command, time.Now().Unix())
 	dc := rc.Datacenter
 
@@ -2183,8 +3444,9 @@ func (rc *ReconciliationContext) createTask(command taskapi.CassandraCommand) er
 			},
 			Jobs: []taskapi.CassandraJob{
 				{
-					Name:    fmt.Sprintf("%s-%s", command, rc.Datacenter.Name),
-					Command: command,
+					Name:      fmt.Sprintf("%s-%s", command, rc.Datacenter.Name),
+					Command:   command,
+					Arguments: arguments,
 				},
 			},
 		},
@@ -2284,6 +3546,30 @@ func (rc *ReconciliationContext) CheckForInvalidState() result.ReconcileResult {
 	return result.Continue()
 }
 
+// CheckServerVersionUpgrade refuses to roll out a serverVersion the nodes can not be upgraded to from the
+// version they run, reported in status.serverVersion, by setting the Valid condition to false until the
+// serverVersion is fixed. The webhook rejects such changes, but it can be bypassed.
+func (rc *ReconciliationContext) CheckServerVersionUpgrade() result.ReconcileResult {
+	dc := rc.Datacenter
+	if err := api.ValidateServerVersionUpgrade(dc.Status.ServerVersion, dc.Spec.ServerVersion); err != nil {
+		if cond, found := dc.GetCondition(api.DatacenterValid); !found || cond.Reason != unsupportedServerUpgrade {
+			rc.Recorder.Event(dc, corev1.EventTypeWarning, events.InvalidDatacenterSpec, err.Error())
+		}
+		if patchErr := rc.patchValidCondition(corev1.ConditionFalse, unsupportedServerUpgrade, err.Error()); patchErr != nil {
+			return result.Error(patchErr)
+		}
+		return result.Error(err)
+	}
+
+	if cond, found := dc.GetCondition(api.DatacenterValid); found && cond.Reason == unsupportedServerUpgrade {
+		if err := rc.patchValidCondition(corev1.ConditionTrue, "", ""); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	return result.Continue()
+}
+
 func (rc *ReconciliationContext) CheckStatefulSetControllerCaughtUp() result.ReconcileResult {
 	if hasStatefulSetControllerCaughtUp(rc.statefulSets, rc.dcPods) {
 		// We do this here instead of in CheckPodsReady where we fix stuck pods
@@ -2336,6 +3622,11 @@ func (rc *ReconciliationContext) fixMissingPVC() (bool, error) {
 func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 	rc.ReqLogger.Info("reconciliationContext::reconcileAllRacks")
 
+	// Runs before CheckForInvalidState to clear the Valid condition it sets once the version is fixed
+	if recResult := rc.CheckServerVersionUpgrade(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckForInvalidState(); recResult.Completed() {
 		return recResult.Output()
 	}
@@ -2353,12 +3644,13 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 	rc.dcPods = FilterPodListByLabels(rc.clusterPods, dcSelector)
 
 	endpointData := rc.getCassMetadataEndpoints()
+	rc.gossipDatacenters = getGossipDatacenters(endpointData)
 
 	if recResult := rc.CheckStatefulSetControllerCaughtUp(); recResult.Completed() {
 		return recResult.Output()
 	}
 
-	if recResult := rc.UpdateStatus(); recResult.Completed() {
+	if recResult := rc.UpdateStatus(endpointData); recResult.Completed() {
 		return recResult.Output()
 	}
 
@@ -2366,10 +3658,70 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckConfigMap(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckMetricsExporterConfigMap(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckServiceMonitor(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckPrometheusRule(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckGrafanaDashboards(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckNetworkPolicy(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckServiceAccount(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckCertificates(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckInternodeKeystores(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckClientKeystore(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckPodServices(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckBroadcastAddresses(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckRestoredVolumes(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckRackCreation(); recResult.Completed() {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckVolumeClaimSizes(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckVolumeClaimStorageClass(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckRackLabels(); recResult.Completed() {
 		return recResult.Output()
 	}
@@ -2378,6 +3730,10 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckOrphanedPVCs(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckSuperuserSecretCreation(); recResult.Completed() {
 		return recResult.Output()
 	}
@@ -2408,10 +3764,26 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckLostWorkers(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckPodsReady(endpointData); recResult.Completed() {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckCordonedWorkers(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckStorageMigration(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckRackPodZones(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckCassandraNodeStatuses(); recResult.Completed() {
 		return recResult.Output()
 	}
@@ -2428,7 +3800,11 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		return recResult.Output()
 	}
 
-	if recResult := rc.CheckRackPodTemplate(); recResult.Completed() {
+	if recResult := rc.CheckPreUpgradeSnapshot(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckRackPodTemplate(endpointData); recResult.Completed() {
 		return recResult.Output()
 	}
 
@@ -2440,6 +3816,10 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckSystemKeyspacesReplication(endpointData); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckClearActionConditions(); recResult.Completed() {
 		return recResult.Output()
 	}
@@ -2448,6 +3828,10 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckRebuild(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckFullQueryLogging(); recResult.Completed() {
 		return recResult.Output()
 	}
@@ -2456,6 +3840,14 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		return result.Error(err).Output()
 	}
 
+	if recResult := rc.CheckReaper(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckSSTablesUpgrade(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if err := rc.enableQuietPeriod(5); err != nil {
 		logger.Error(
 			err,
@@ -4,11 +4,15 @@ package reconciliation
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
 	"github.com/k8ssandra/cass-operator/pkg/httphelper"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func mapContains(base map[string]string, submap map[string]string) bool {
@@ -95,3 +99,84 @@ func MapPodsToEndpointDataByName(pods []*v1.Pod, epData httphelper.CassMetadataE
 
 	return result
 }
+
+// findPodNotUpAndNormal returns the first pod that the ring does not report as alive and in the
+// NORMAL state, or nil if every pod has (re)joined the ring.
+func findPodNotUpAndNormal(dc *api.CassandraDatacenter, pods []*corev1.Pod, epData httphelper.CassMetadataEndpoints) *corev1.Pod {
+	for _, pod := range pods {
+		ip := getRpcAddress(dc, pod)
+		upAndNormal := false
+		for idx := range epData.Entity {
+			ep := &epData.Entity[idx]
+			if ep.GetRpcAddress() == ip {
+				upAndNormal = ep.IsAlive == "true" && ep.HasStatus(httphelper.StatusNormal)
+				break
+			}
+		}
+		if !upAndNormal {
+			return pod
+		}
+	}
+	return nil
+}
+
+// isNodeJoining returns true if the endpoints report the node of the pod as still joining the ring, for
+// instance streaming the data of its token ranges while its pod is already ready
+func isNodeJoining(dc *api.CassandraDatacenter, pod *corev1.Pod, epData httphelper.CassMetadataEndpoints) bool {
+	ip := getRpcAddress(dc, pod)
+	for idx := range epData.Entity {
+		ep := &epData.Entity[idx]
+		if ip != "" && ep.GetRpcAddress() == ip {
+			return ep.GetOperationMode() == "JOINING"
+		}
+	}
+	return false
+}
+
+// hasUpdatePartition returns true if the StatefulSet holds back pods from rolling updates
+func hasUpdatePartition(sts *appsv1.StatefulSet) bool {
+	rollingUpdate := sts.Spec.UpdateStrategy.RollingUpdate
+	return rollingUpdate != nil && rollingUpdate.Partition != nil && *rollingUpdate.Partition > 0
+}
+
+// IsStatefulSetUpdated returns true once the StatefulSet controller observed the latest spec and every
+// replica runs the update revision. The StatefulSet controller only advances currentRevision and
+// currentReplicas with the RollingUpdate strategy, with OnDelete the updated replicas, counted from the
+// controller-revision-hash of the pods, are the only indicator.
+func IsStatefulSetUpdated(sts *appsv1.StatefulSet) bool {
+	status := sts.Status
+	if sts.Generation != status.ObservedGeneration || status.UpdatedReplicas != status.Replicas {
+		return false
+	}
+	if sts.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		return true
+	}
+	return status.CurrentRevision == status.UpdateRevision && status.CurrentReplicas == status.Replicas
+}
+
+// getPodOrdinal returns the ordinal of the pod in the StatefulSet, or -1 if it is not one of its pods
+func getPodOrdinal(sts *appsv1.StatefulSet, pod *corev1.Pod) int {
+	if ordinal, err := strconv.Atoi(strings.TrimPrefix(pod.Name, sts.Name+"-")); err == nil && ordinal >= 0 {
+		return ordinal
+	}
+	return -1
+}
+
+// getVolumeClaimTemplateSize returns the storage request of the named volumeClaimTemplate
+func getVolumeClaimTemplateSize(sts *appsv1.StatefulSet, name string) (resource.Quantity, bool) {
+	for _, vct := range sts.Spec.VolumeClaimTemplates {
+		if vct.Name == name {
+			size, found := vct.Spec.Resources.Requests[corev1.ResourceStorage]
+			return size, found
+		}
+	}
+	return resource.Quantity{}, false
+}
+
+// getNodeZone returns the zone of a k8s node, preferring the GA topology label over the deprecated one
+func getNodeZone(node *corev1.Node) string {
+	if zone, found := node.Labels[topologyZoneLabel]; found {
+		return zone
+	}
+	return node.Labels[zoneLabel]
+}
@@ -6,6 +6,10 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/httphelper"
 )
 
 func TestMapContains(t *testing.T) {
@@ -65,3 +69,29 @@ func TestPodPrtsFromPodList(t *testing.T) {
 	assert.ElementsMatch(t, expectedNames, actualNames)
 
 }
+
+func TestFindPodNotUpAndNormal(t *testing.T) {
+	dc := &api.CassandraDatacenter{}
+	pods := []*corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod-0"}, Status: corev1.PodStatus{PodIP: "10.0.0.1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod-1"}, Status: corev1.PodStatus{PodIP: "10.0.0.2"}},
+	}
+
+	epData := httphelper.CassMetadataEndpoints{
+		Entity: []httphelper.EndpointState{
+			{RpcAddress: "10.0.0.1", IsAlive: "true", Status: "NORMAL"},
+			{RpcAddress: "10.0.0.2", IsAlive: "false", Status: "NORMAL"},
+		},
+	}
+	assert.Equal(t, "pod-1", findPodNotUpAndNormal(dc, pods, epData).Name)
+
+	epData.Entity[1].IsAlive = "true"
+	epData.Entity[1].Status = "LEAVING"
+	assert.Equal(t, "pod-1", findPodNotUpAndNormal(dc, pods, epData).Name)
+
+	epData.Entity[1].Status = "NORMAL"
+	assert.Nil(t, findPodNotUpAndNormal(dc, pods, epData))
+
+	epData.Entity = epData.Entity[:1]
+	assert.Equal(t, "pod-1", findPodNotUpAndNormal(dc, pods, epData).Name)
+}
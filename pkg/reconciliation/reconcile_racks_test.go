@@ -9,12 +9,16 @@ import (
 	"io"
 	"net/http"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/go-logr/logr/funcr"
 	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
 	taskapi "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
 	"github.com/k8ssandra/cass-operator/pkg/httphelper"
 	"github.com/k8ssandra/cass-operator/pkg/internal/result"
 	"github.com/k8ssandra/cass-operator/pkg/mocks"
@@ -24,10 +28,18 @@ import (
 	"github.com/stretchr/testify/mock"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -277,7 +289,7 @@ func TestCheckRackPodTemplate_SetControllerRefOnStatefulSet(t *testing.T) {
 	}
 	rc.Datacenter.Spec.PodTemplateSpec = podTemplateSpec
 
-	result = rc.CheckRackPodTemplate()
+	result = rc.CheckRackPodTemplate(httphelper.CassMetadataEndpoints{})
 	assert.True(t, result.Completed())
 
 	assert.Equal(t, 1, invocations)
@@ -306,7 +318,7 @@ func TestCheckRackPodTemplate_SetControllerRefOnStatefulSet(t *testing.T) {
 // 		t.Fatalf("failed to add rack to cassandradatacenter: %s", err)
 // 	}
 
-// 	result = rc.CheckRackPodTemplate()
+// 	result = rc.CheckRackPodTemplate(httphelper.CassMetadataEndpoints{})
 // 	_, err := result.Output()
 
 // 	assert.True(t, result.Completed())
@@ -317,7 +329,7 @@ func TestCheckRackPodTemplate_SetControllerRefOnStatefulSet(t *testing.T) {
 // 	rc.Datacenter.Spec.CanaryUpgradeCount = 1
 // 	rc.Datacenter.Spec.ServerVersion = "6.8.3"
 
-// 	result = rc.CheckRackPodTemplate()
+// 	result = rc.CheckRackPodTemplate(httphelper.CassMetadataEndpoints{})
 // 	_, err = result.Output()
 
 // 	assert.True(t, result.Completed())
@@ -340,7 +352,7 @@ func TestCheckRackPodTemplate_SetControllerRefOnStatefulSet(t *testing.T) {
 // 	rc.statefulSets[0].Status.CurrentReplicas = 1
 // 	rc.statefulSets[0].Status.UpdatedReplicas = 1
 
-// 	result = rc.CheckRackPodTemplate()
+// 	result = rc.CheckRackPodTemplate(httphelper.CassMetadataEndpoints{})
 
 // 	assert.EqualValues(t, previousLabels, rc.statefulSets[0].Spec.Template.Labels)
 
@@ -450,6 +462,56 @@ func TestReconcilePods_WithVolumes(t *testing.T) {
 	assert.NoErrorf(t, err, "Should not have returned an error")
 }
 
+func TestReconcilePods_AdditionalAnnotations(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	statefulSet, err := newStatefulSetForCassandraDatacenter(
+		nil,
+		"default",
+		rc.Datacenter,
+		2)
+	assert.NoErrorf(t, err, "error occurred creating statefulset")
+	statefulSet.Status.Replicas = int32(1)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "cassandradatacenter-example-cluster-cassandradatacenter-example-default-sts-0",
+			Namespace:   statefulSet.Namespace,
+			Annotations: map[string]string{"existing": "annotation"},
+		},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{
+				Name: "server-data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: "server-data-cassandradatacenter-example-cluster-cassandradatacenter-example-default-sts-0",
+					},
+				},
+			}},
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Spec.Volumes[0].PersistentVolumeClaim.ClaimName,
+			Namespace: statefulSet.Namespace,
+		},
+	}
+
+	rc.Datacenter.Spec.AdditionalAnnotations = map[string]string{"cost-center": "storage"}
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(pod, pvc).Build()
+	err = rc.ReconcilePods(statefulSet)
+	assert.NoErrorf(t, err, "Should not have returned an error")
+
+	updatedPod := &corev1.Pod{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, updatedPod))
+	assert.Equal(t, map[string]string{"existing": "annotation", "cost-center": "storage"}, updatedPod.Annotations)
+
+	updatedPvc := &corev1.PersistentVolumeClaim{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, updatedPvc))
+	assert.Equal(t, map[string]string{"cost-center": "storage"}, updatedPvc.Annotations)
+}
+
 // Note: getStatefulSetForRack is currently just a query,
 // and there is really no logic to test.
 // We can add a unit test later, if needed.
@@ -502,6 +564,30 @@ func TestReconcileNextRack_CreateError(t *testing.T) {
 	assert.Errorf(t, err, "Should have returned an error while calculating reconciliation actions")
 }
 
+func TestCheckRackCreation_CreatesAllRacks(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Size = 3
+	rc.Datacenter.Spec.Racks = []api.Rack{
+		{Name: "rack1"}, {Name: "rack2"}, {Name: "rack3"},
+	}
+
+	if err := rc.CalculateRackInformation(); err != nil {
+		t.Fatalf("failed to calculate rack information: %s", err)
+	}
+
+	result := rc.CheckRackCreation()
+	assert.False(t, result.Completed(), "CheckRackCreation did not complete as expected")
+
+	for idx, rack := range rc.Datacenter.Spec.Racks {
+		sts := &appsv1.StatefulSet{}
+		err := rc.Client.Get(rc.Ctx, newNamespacedNameForStatefulSet(rc.Datacenter, rack.Name), sts)
+		assert.NoError(t, err, "StatefulSet of rack %s was not created", rack.Name)
+		assert.Equal(t, sts.Name, rc.statefulSets[idx].Name)
+	}
+}
+
 func TestCalculateRackInformation(t *testing.T) {
 	rc, _, cleanupMockScr := setupTest()
 	defer cleanupMockScr()
@@ -555,6 +641,73 @@ func TestCalculateRackInformation_MultiRack(t *testing.T) {
 	// TODO add more RackInformation validation
 }
 
+func TestCalculateRackInformation_NotEnoughNodes(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Racks = []api.Rack{{Name: "rack0"}, {Name: "rack1"}, {Name: "rack2"}}
+	rc.Datacenter.Spec.Size = 2
+
+	err := rc.CalculateRackInformation()
+	assert.Error(t, err)
+	cond, found := rc.Datacenter.GetCondition(api.DatacenterValid)
+	assert.True(t, found)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, notEnoughNodesForRacks, cond.Reason)
+
+	fakeRecorder := rc.Recorder.(*record.FakeRecorder)
+	assert.Equal(t, 1, len(fakeRecorder.Events))
+	assert.Contains(t, <-fakeRecorder.Events, events.InvalidDatacenterSpec)
+
+	// Fixing the size makes the Datacenter valid again
+	rc.Datacenter.Spec.Size = 3
+	err = rc.CalculateRackInformation()
+	assert.NoError(t, err)
+	assert.Equal(t, corev1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterValid))
+}
+
+func TestCalculateRackInformation_SeedCounts(t *testing.T) {
+	tests := []struct {
+		name            string
+		size            int32
+		rackCount       int
+		seedCount       int32
+		minSeedsPerRack int32
+		want            []int
+	}{
+		{name: "default", size: 6, rackCount: 2, want: []int{2, 1}},
+		{name: "default with less than three nodes", size: 2, rackCount: 1, want: []int{2}},
+		{name: "default with more than three racks", size: 8, rackCount: 4, want: []int{1, 1, 1, 1}},
+		{name: "seed count", size: 9, rackCount: 3, seedCount: 5, want: []int{2, 2, 1}},
+		{name: "seed count above size", size: 2, rackCount: 1, seedCount: 5, want: []int{2}},
+		{name: "min seeds per rack", size: 9, rackCount: 3, minSeedsPerRack: 2, want: []int{2, 2, 2}},
+		{name: "min seeds per rack above rack size", size: 4, rackCount: 2, seedCount: 1, minSeedsPerRack: 3, want: []int{2, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc, _, cleanupMockScr := setupTest()
+			defer cleanupMockScr()
+
+			rc.Datacenter.Spec.Size = tt.size
+			rc.Datacenter.Spec.SeedCount = tt.seedCount
+			rc.Datacenter.Spec.MinSeedsPerRack = tt.minSeedsPerRack
+			rc.Datacenter.Spec.Racks = nil
+			for i := 0; i < tt.rackCount; i++ {
+				rc.Datacenter.Spec.Racks = append(rc.Datacenter.Spec.Racks, api.Rack{Name: fmt.Sprintf("rack%d", i)})
+			}
+
+			assert.NoError(t, rc.CalculateRackInformation())
+
+			var seedCounts []int
+			for _, rackInfo := range rc.desiredRackInformation {
+				seedCounts = append(seedCounts, rackInfo.SeedCount)
+			}
+			assert.Equal(t, tt.want, seedCounts)
+		})
+	}
+}
+
 func TestReconcileRacks(t *testing.T) {
 	rc, _, cleanupMockScr := setupTest()
 	defer cleanupMockScr()
@@ -1537,6 +1690,100 @@ func TestCleanupAfterScaling(t *testing.T) {
 	assert.Equal(0, len(rc.Datacenter.Status.TrackedTasks))
 }
 
+func TestCleanupAfterScalingDisabled(t *testing.T) {
+	rc, cleanupMockScr := setupTestEnv()
+	defer cleanupMockScr()
+
+	metav1.SetMetaDataAnnotation(&rc.Datacenter.ObjectMeta, api.NoAutomatedCleanupAnnotation, "true")
+
+	// No cleanup task is created
+	r := rc.cleanupAfterScaling()
+	assert.Equal(t, result.Continue(), r, "expected result of result.Continue()")
+	assert.Equal(t, 0, len(rc.Datacenter.Status.TrackedTasks))
+	rc.Client.(*mocks.Client).AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// TestDisableDefaultSuperuser verifies the default superuser is dropped once, unless it is the superuser of
+// the operator
+func TestDisableDefaultSuperuser(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mgmtClient := &fakeNodeMgmtClient{features: []httphelper.Feature{httphelper.DropRole}}
+	rc.NodeMgmtClient = mgmtClient
+	notReadyPod := makeReloadTestPod()
+	readyPod := makeReloadTestPod()
+	readyPod.Name = "mypod-ready"
+	readyPod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "cassandra", Ready: true}}
+	rc.dcPods = []*corev1.Pod{notReadyPod}
+
+	secret, err := rc.retrieveSuperuserSecretOrCreateDefault()
+	assert.NoError(t, err)
+
+	disabled, err := rc.disableDefaultSuperuser()
+	assert.NoError(t, err)
+	assert.False(t, disabled)
+
+	// The role is dropped through a ready pod
+	rc.Datacenter.Spec.DisableDefaultSuperuser = true
+	_, err = rc.disableDefaultSuperuser()
+	assert.Error(t, err)
+	assert.Empty(t, mgmtClient.droppedRoles)
+
+	rc.dcPods = []*corev1.Pod{notReadyPod, readyPod}
+	disabled, err = rc.disableDefaultSuperuser()
+	assert.NoError(t, err)
+	assert.True(t, disabled)
+	assert.Equal(t, []string{"cassandra"}, mgmtClient.droppedRoles)
+	assert.Equal(t, []string{"mypod-ready"}, mgmtClient.roleDropPods)
+	assert.NotEqual(t, corev1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterDefaultSuperuserKept))
+
+	rc.Datacenter.Status.DefaultSuperuserDisabled = metav1.Now()
+	disabled, err = rc.disableDefaultSuperuser()
+	assert.NoError(t, err)
+	assert.False(t, disabled)
+	assert.Len(t, mgmtClient.droppedRoles, 1)
+
+	// The superuser of the operator is never dropped
+	rc.Datacenter.Status.DefaultSuperuserDisabled = metav1.Time{}
+	secret.Data["username"] = []byte("cassandra")
+	assert.NoError(t, rc.Client.Update(rc.Ctx, secret))
+	disabled, err = rc.disableDefaultSuperuser()
+	assert.NoError(t, err)
+	assert.False(t, disabled)
+	assert.Len(t, mgmtClient.droppedRoles, 1)
+}
+
+// TestDisableDefaultSuperuserNotSupported verifies the default superuser is kept, with a condition instead
+// of an error, when the management API can not drop roles
+func TestDisableDefaultSuperuserNotSupported(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mgmtClient := &fakeNodeMgmtClient{}
+	rc.NodeMgmtClient = mgmtClient
+	readyPod := makeReloadTestPod()
+	readyPod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "cassandra", Ready: true}}
+	rc.dcPods = []*corev1.Pod{readyPod}
+	rc.Datacenter.Spec.DisableDefaultSuperuser = true
+
+	_, err := rc.retrieveSuperuserSecretOrCreateDefault()
+	assert.NoError(t, err)
+
+	disabled, err := rc.disableDefaultSuperuser()
+	assert.NoError(t, err)
+	assert.False(t, disabled)
+	assert.Empty(t, mgmtClient.droppedRoles)
+	assert.Equal(t, corev1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterDefaultSuperuserKept))
+
+	// The role is dropped once the management API supports it
+	mgmtClient.features = []httphelper.Feature{httphelper.DropRole}
+	disabled, err = rc.disableDefaultSuperuser()
+	assert.NoError(t, err)
+	assert.True(t, disabled)
+	assert.Equal(t, corev1.ConditionFalse, rc.Datacenter.GetConditionStatus(api.DatacenterDefaultSuperuserKept))
+}
+
 func TestStripPassword(t *testing.T) {
 	rc, _, cleanupMockScr := setupTest()
 	defer cleanupMockScr()
@@ -1621,6 +1868,32 @@ func TestNodereplacements(t *testing.T) {
 	assert.Equal(0, len(rc.Datacenter.Spec.ReplaceNodes))
 }
 
+// TestNodereplacementsLogsRejectedEntries verifies only the ReplaceNodes entries without a pod are logged as rejected
+func TestNodereplacementsLogsRejectedEntries(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	var rejected []string
+	rc.ReqLogger = funcr.New(func(prefix, args string) {
+		if strings.Contains(args, "Rejected ReplaceNode entry") {
+			rejected = append(rejected, args)
+		}
+	}, funcr.Options{})
+
+	rc.dcPods = []*corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "dc1-default-sts-0"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "dc1-default-sts-1"}},
+	}
+
+	rc.Datacenter.Spec.ReplaceNodes = []string{"dc1-default-sts-0", "dc1-default-sts-3", "dc1-default-sts-1"}
+	err := rc.startReplacePodsIfReplacePodsSpecified()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dc1-default-sts-0", "dc1-default-sts-1"}, rc.Datacenter.Status.NodeReplacements)
+
+	assert.Len(t, rejected, 1)
+	assert.Contains(t, rejected[0], `"PodName"="dc1-default-sts-3"`)
+}
+
 // TestFailedStart verifies the pod is deleted if nodeMgmtClient start fails
 func TestFailedStart(t *testing.T) {
 	rc, _, cleanupMockScr := setupTest()
@@ -1651,7 +1924,7 @@ func TestFailedStart(t *testing.T) {
 		Protocol: "http",
 	}
 
-	rc.NodeMgmtClient = client
+	rc.NodeMgmtClient = &client
 
 	epData := httphelper.CassMetadataEndpoints{
 		Entity: []httphelper.EndpointState{},
@@ -1671,3 +1944,1418 @@ func TestFailedStart(t *testing.T) {
 
 	mockClient.AssertExpectations(t)
 }
+
+// TestRollingRestartWaitsForHealthyCluster verifies no pod is deleted while the cluster fails the LOCAL_QUORUM check
+func TestRollingRestartWaitsForHealthyCluster(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mockClient := &mocks.Client{}
+	rc.Client = mockClient
+	// The datacenters of the cluster are listed for the replication factor of the check
+	mockClient.On("List", mock.Anything, mock.AnythingOfType("*v1beta1.CassandraDatacenterList"), mock.Anything).
+		Return(nil).
+		Once()
+
+	res := &http.Response{
+		StatusCode: http.StatusInternalServerError,
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/probes/cluster"
+			})).
+		Return(res, nil).
+		Once()
+
+	rc.NodeMgmtClient = &httphelper.NodeMgmtClient{
+		Client:   mockHttpClient,
+		Log:      rc.ReqLogger,
+		Protocol: "http",
+	}
+
+	pod := makeReloadTestPod()
+	pod.Status.PodIP = "1.2.3.4"
+	pod.Labels[api.CassNodeState] = stateStarted
+	pod.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	rc.dcPods = []*corev1.Pod{pod}
+	rc.clusterPods = rc.dcPods
+
+	rc.Datacenter.Status.LastRollingRestart = metav1.Now()
+	assert.False(t, rc.IsBackoffRequested())
+
+	r := rc.CheckRollingRestart()
+	assert.Equal(t, result.RequeueSoon(5), r)
+	assert.True(t, rc.IsBackoffRequested())
+
+	// No Delete was expected on the k8s client
+	mockClient.AssertExpectations(t)
+	mockHttpClient.AssertExpectations(t)
+}
+
+func TestIsCanaryUpgradeActive(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	assert.False(t, rc.isCanaryUpgradeActive())
+
+	rc.Datacenter.Spec.CanaryUpgrade = true
+	assert.True(t, rc.isCanaryUpgradeActive())
+
+	rc.Datacenter.Spec.CanaryUpgradePauseSeconds = 600
+	assert.True(t, rc.isCanaryUpgradeActive(), "the pause can't elapse if no update is in progress")
+
+	updating := api.NewDatacenterCondition(api.DatacenterUpdating, corev1.ConditionTrue)
+	updating.LastTransitionTime = metav1.NewTime(time.Now().Add(-5 * time.Minute))
+	rc.Datacenter.Status.SetCondition(*updating)
+	assert.True(t, rc.isCanaryUpgradeActive())
+
+	updating.LastTransitionTime = metav1.NewTime(time.Now().Add(-15 * time.Minute))
+	rc.Datacenter.Status.SetCondition(*updating)
+	assert.False(t, rc.isCanaryUpgradeActive())
+}
+
+func TestCheckVolumeClaimSizes(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Racks = []api.Rack{
+		{Name: "rack1"},
+	}
+
+	if err := rc.CalculateRackInformation(); err != nil {
+		t.Fatalf("failed to calculate rack information: %s", err)
+	}
+
+	result := rc.CheckRackCreation()
+	assert.False(t, result.Completed(), "CheckRackCreation did not complete as expected")
+
+	result = rc.CheckVolumeClaimSizes()
+	assert.False(t, result.Completed(), "no resize should happen with an unchanged storage size")
+
+	allowExpansion := true
+	storageClass := &storagev1.StorageClass{
+		ObjectMeta:           metav1.ObjectMeta{Name: *rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.StorageClassName},
+		AllowVolumeExpansion: &allowExpansion,
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-0", PvcName, rc.statefulSets[0].Name),
+			Namespace: rc.Datacenter.Namespace,
+			Labels:    rc.Datacenter.GetRackLabels("rack1"),
+		},
+		Spec: *rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.DeepCopy(),
+	}
+	for _, obj := range []client.Object{storageClass, pvc} {
+		if err := rc.Client.Create(rc.Ctx, obj); err != nil {
+			t.Fatalf("failed to create %s: %s", obj.GetName(), err)
+		}
+	}
+
+	rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("2Gi")
+
+	result = rc.CheckVolumeClaimSizes()
+	assert.True(t, result.Completed())
+
+	actualPvc := &corev1.PersistentVolumeClaim{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, client.ObjectKeyFromObject(pvc), actualPvc))
+	assert.Equal(t, "2Gi", actualPvc.Spec.Resources.Requests.Storage().String())
+
+	err := rc.Client.Get(rc.Ctx, client.ObjectKeyFromObject(rc.statefulSets[0]), &appsv1.StatefulSet{})
+	assert.True(t, errors.IsNotFound(err), "the StatefulSet should have been deleted to be recreated")
+}
+
+func TestCheckVolumeClaimSizes_commitLog(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Racks = []api.Rack{
+		{Name: "rack1"},
+	}
+	rc.Datacenter.Spec.StorageConfig.CommitLogVolumeClaimSpec = rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.DeepCopy()
+	if err := rc.Client.Update(rc.Ctx, rc.Datacenter); err != nil {
+		t.Fatalf("failed to update the datacenter: %s", err)
+	}
+
+	if err := rc.CalculateRackInformation(); err != nil {
+		t.Fatalf("failed to calculate rack information: %s", err)
+	}
+
+	result := rc.CheckRackCreation()
+	assert.False(t, result.Completed(), "CheckRackCreation did not complete as expected")
+
+	allowExpansion := true
+	storageClass := &storagev1.StorageClass{
+		ObjectMeta:           metav1.ObjectMeta{Name: *rc.Datacenter.Spec.StorageConfig.CommitLogVolumeClaimSpec.StorageClassName},
+		AllowVolumeExpansion: &allowExpansion,
+	}
+	dataPvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-0", PvcName, rc.statefulSets[0].Name),
+			Namespace: rc.Datacenter.Namespace,
+			Labels:    rc.Datacenter.GetRackLabels("rack1"),
+		},
+		Spec: *rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.DeepCopy(),
+	}
+	commitLogPvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-0", api.CommitLogVolumeName, rc.statefulSets[0].Name),
+			Namespace: rc.Datacenter.Namespace,
+			Labels:    rc.Datacenter.GetRackLabels("rack1"),
+		},
+		Spec: *rc.Datacenter.Spec.StorageConfig.CommitLogVolumeClaimSpec.DeepCopy(),
+	}
+	for _, obj := range []client.Object{storageClass, dataPvc, commitLogPvc} {
+		if err := rc.Client.Create(rc.Ctx, obj); err != nil {
+			t.Fatalf("failed to create %s: %s", obj.GetName(), err)
+		}
+	}
+
+	rc.Datacenter.Spec.StorageConfig.CommitLogVolumeClaimSpec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("2Gi")
+
+	result = rc.CheckVolumeClaimSizes()
+	assert.True(t, result.Completed())
+
+	actualPvc := &corev1.PersistentVolumeClaim{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, client.ObjectKeyFromObject(commitLogPvc), actualPvc))
+	assert.Equal(t, "2Gi", actualPvc.Spec.Resources.Requests.Storage().String())
+	assert.NoError(t, rc.Client.Get(rc.Ctx, client.ObjectKeyFromObject(dataPvc), actualPvc))
+	assert.Equal(t, "1Gi", actualPvc.Spec.Resources.Requests.Storage().String(), "the data PVC should keep its size")
+
+	err := rc.Client.Get(rc.Ctx, client.ObjectKeyFromObject(rc.statefulSets[0]), &appsv1.StatefulSet{})
+	assert.True(t, errors.IsNotFound(err), "the StatefulSet should have been deleted to be recreated")
+}
+
+func TestCheckVolumeClaimSizes_expansionNotAllowed(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Racks = []api.Rack{
+		{Name: "rack1"},
+	}
+
+	if err := rc.CalculateRackInformation(); err != nil {
+		t.Fatalf("failed to calculate rack information: %s", err)
+	}
+
+	result := rc.CheckRackCreation()
+	assert.False(t, result.Completed(), "CheckRackCreation did not complete as expected")
+
+	storageClass := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{Name: *rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.StorageClassName},
+	}
+	if err := rc.Client.Create(rc.Ctx, storageClass); err != nil {
+		t.Fatalf("failed to create %s: %s", storageClass.Name, err)
+	}
+	fakeRecorder := record.NewFakeRecorder(10)
+	rc.Recorder = fakeRecorder
+
+	rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("2Gi")
+	if err := rc.Client.Update(rc.Ctx, rc.Datacenter); err != nil {
+		t.Fatalf("failed to update the datacenter: %s", err)
+	}
+
+	// The warning is only recorded when the condition is set
+	for i := 0; i < 2; i++ {
+		result = rc.CheckVolumeClaimSizes()
+		assert.False(t, result.Completed())
+	}
+	assert.Equal(t, corev1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterVolumeResizeBlocked))
+	assert.Equal(t, 1, len(fakeRecorder.Events))
+
+	err := rc.Client.Get(rc.Ctx, client.ObjectKeyFromObject(rc.statefulSets[0]), &appsv1.StatefulSet{})
+	assert.NoError(t, err, "the StatefulSet should not be recreated")
+
+	// The condition is cleared once the storage request is reverted
+	rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("1Gi")
+	if err := rc.Client.Update(rc.Ctx, rc.Datacenter); err != nil {
+		t.Fatalf("failed to update the datacenter: %s", err)
+	}
+	result = rc.CheckVolumeClaimSizes()
+	assert.False(t, result.Completed())
+	assert.Equal(t, corev1.ConditionFalse, rc.Datacenter.GetConditionStatus(api.DatacenterVolumeResizeBlocked))
+}
+
+func TestCheckRackPodZones(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Racks = []api.Rack{
+		{Name: "rack1", Zone: "zone-1"},
+	}
+
+	if err := rc.CalculateRackInformation(); err != nil {
+		t.Fatalf("failed to calculate rack information: %s", err)
+	}
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node1",
+			Labels: map[string]string{topologyZoneLabel: "zone-2"},
+		},
+	}
+	if err := rc.Client.Create(rc.Ctx, node); err != nil {
+		t.Fatalf("failed to create node: %s", err)
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "pod1",
+			Labels: rc.Datacenter.GetRackLabels("rack1"),
+		},
+		Spec: corev1.PodSpec{NodeName: node.Name},
+	}
+	rc.dcPods = []*corev1.Pod{pod}
+
+	result := rc.CheckRackPodZones()
+	assert.False(t, result.Completed())
+	cond, found := rc.Datacenter.GetCondition(api.DatacenterRackZoneMismatch)
+	assert.True(t, found)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "pod1")
+
+	node.Labels[topologyZoneLabel] = "zone-1"
+	if err := rc.Client.Update(rc.Ctx, node); err != nil {
+		t.Fatalf("failed to update node: %s", err)
+	}
+
+	result = rc.CheckRackPodZones()
+	assert.False(t, result.Completed())
+	assert.Equal(t, corev1.ConditionFalse, rc.Datacenter.GetConditionStatus(api.DatacenterRackZoneMismatch))
+}
+
+// TestLabelSeedPodsContinuesPastErrors verifies a failure to label one pod does not prevent the
+// remaining pods of the rack from being labeled or unlabeled
+func TestLabelSeedPodsContinuesPastErrors(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mockClient := &mocks.Client{}
+	rc.Client = mockClient
+
+	rackInfo := &RackInformation{RackName: "default", NodeCount: 3, SeedCount: 2}
+
+	rc.dcPods = nil
+	for i, ready := range []bool{true, true, false} {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", i),
+				Namespace: rc.Datacenter.Namespace,
+				Labels:    rc.Datacenter.GetRackLabels(rackInfo.RackName),
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: ready}},
+			},
+		}
+		if !ready {
+			pod.Labels[api.SeedNodeLabel] = "true"
+		}
+		rc.dcPods = append(rc.dcPods, pod)
+	}
+
+	k8sMockClientPatch(mockClient, fmt.Errorf("failed to patch pod"))
+	k8sMockClientPatch(mockClient, nil).Twice()
+
+	count, err := rc.labelSeedPods(rackInfo)
+	assert.Error(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.Equal(t, "true", rc.dcPods[1].Labels[api.SeedNodeLabel])
+	assert.NotContains(t, rc.dcPods[2].Labels, api.SeedNodeLabel)
+	mockClient.AssertExpectations(t)
+}
+
+func TestUpdateSeedsStatus(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.dcPods = nil
+	for i, seed := range []bool{true, false, true} {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   fmt.Sprintf("pod-%d", i),
+				Labels: rc.Datacenter.GetRackLabels("default"),
+			},
+			Status: corev1.PodStatus{PodIP: fmt.Sprintf("10.0.0.%d", 3-i)},
+		}
+		if seed {
+			pod.Labels[api.SeedNodeLabel] = "true"
+		}
+		rc.dcPods = append(rc.dcPods, pod)
+	}
+
+	assert.True(t, rc.updateSeedsStatus())
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.3"}, rc.Datacenter.Status.Seeds)
+	assert.False(t, rc.updateSeedsStatus())
+
+	rc.dcPods = nil
+	assert.True(t, rc.updateSeedsStatus())
+	assert.Empty(t, rc.Datacenter.Status.Seeds)
+}
+
+func TestUpdateOperationModes(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.dcPods = nil
+	rc.Datacenter.Status.NodeStatuses = api.CassandraStatusMap{}
+	for i := 0; i < 3; i++ {
+		pod := makeReloadTestPod()
+		pod.Name = fmt.Sprintf("pod-%d", i)
+		pod.Status.PodIP = fmt.Sprintf("10.0.0.%d", i)
+		rc.dcPods = append(rc.dcPods, pod)
+		rc.Datacenter.Status.NodeStatuses[pod.Name] = api.CassandraNodeStatus{HostID: fmt.Sprintf("host-%d", i), OperationMode: "NORMAL"}
+	}
+
+	// Without any endpoint data, the modes are kept
+	rc.updateOperationModes(nil)
+	assert.Equal(t, "NORMAL", rc.Datacenter.Status.NodeStatuses["pod-2"].OperationMode)
+
+	rc.updateOperationModes([]httphelper.EndpointState{
+		{RpcAddress: "10.0.0.0", Status: "NORMAL,-1"},
+		{RpcAddress: "10.0.0.1", Status: "LEAVING,2"},
+	})
+	assert.Equal(t, api.CassandraStatusMap{
+		"pod-0": {HostID: "host-0", OperationMode: "NORMAL"},
+		"pod-1": {HostID: "host-1", OperationMode: "LEAVING"},
+		"pod-2": {HostID: "host-2"},
+	}, rc.Datacenter.Status.NodeStatuses)
+}
+
+func TestCheckSuperuserSecretCreation_SetsStatus(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	result := rc.CheckSuperuserSecretCreation()
+	assert.False(t, result.Completed())
+
+	secretName := rc.Datacenter.GetSuperuserSecretNamespacedName()
+	assert.Equal(t, secretName.Name, rc.Datacenter.Status.SuperUserSecretName)
+
+	secret := &corev1.Secret{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, secretName, secret))
+	assert.NotEmpty(t, secret.Data["password"])
+}
+
+// fakeNodeMgmtClient fails the LOCAL_QUORUM check for the given pods, records the drained, started pods and
+// the pods queried for the endpoints metadata, other calls are not implemented
+type fakeNodeMgmtClient struct {
+	NodeMgmtClient
+	unhealthyPods map[string]bool
+	mu            sync.Mutex
+	probedPods    []string
+	probeSettings string
+	drainedPods   []string
+	endpoints     httphelper.CassMetadataEndpoints
+	metadataPods  []string
+	startedPods   []string
+	droppedRoles  []string
+	roleDropPods  []string
+	features      []httphelper.Feature
+	replications  map[string]map[string]string
+}
+
+func (c *fakeNodeMgmtClient) ListKeyspaces(pod *corev1.Pod) ([]string, error) {
+	keyspaces := make([]string, 0, len(c.replications))
+	for keyspace := range c.replications {
+		keyspaces = append(keyspaces, keyspace)
+	}
+	sort.Strings(keyspaces)
+	return keyspaces, nil
+}
+
+func (c *fakeNodeMgmtClient) GetKeyspaceReplication(pod *corev1.Pod, keyspaceName string) (map[string]string, error) {
+	return c.replications[keyspaceName], nil
+}
+
+func (c *fakeNodeMgmtClient) AlterKeyspace(pod *corev1.Pod, keyspaceName string, replicationSettings []map[string]string) error {
+	replication := map[string]string{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy"}
+	for _, setting := range replicationSettings {
+		replication[setting["dc_name"]] = setting["replication_factor"]
+	}
+	c.replications[keyspaceName] = replication
+	return nil
+}
+
+func (c *fakeNodeMgmtClient) FeatureSet(pod *corev1.Pod) (*httphelper.FeatureSet, error) {
+	features := &httphelper.FeatureSet{Features: map[string]struct{}{}}
+	for _, feature := range c.features {
+		features.Features[string(feature)] = struct{}{}
+	}
+	return features, nil
+}
+
+func (c *fakeNodeMgmtClient) CallMetadataEndpointsEndpoint(pod *corev1.Pod) (httphelper.CassMetadataEndpoints, error) {
+	c.metadataPods = append(c.metadataPods, pod.Name)
+	return c.endpoints, nil
+}
+
+func (c *fakeNodeMgmtClient) CallProbeClusterEndpoint(pod *corev1.Pod, consistencyLevel string, rfPerDc int) error {
+	c.mu.Lock()
+	c.probedPods = append(c.probedPods, pod.Name)
+	c.probeSettings = fmt.Sprintf("%s/%d", consistencyLevel, rfPerDc)
+	c.mu.Unlock()
+	if c.unhealthyPods[pod.Name] {
+		return fmt.Errorf("pod %s failed the %s check", pod.Name, consistencyLevel)
+	}
+	return nil
+}
+
+func (c *fakeNodeMgmtClient) CallDrainEndpoint(pod *corev1.Pod) error {
+	c.drainedPods = append(c.drainedPods, pod.Name)
+	return nil
+}
+
+func (c *fakeNodeMgmtClient) CallDropRoleEndpoint(pod *corev1.Pod, username string) error {
+	c.droppedRoles = append(c.droppedRoles, username)
+	c.roleDropPods = append(c.roleDropPods, pod.Name)
+	return nil
+}
+
+func (c *fakeNodeMgmtClient) CallLifecycleStartEndpoint(pod *corev1.Pod) error {
+	c.startedPods = append(c.startedPods, pod.Name)
+	return nil
+}
+
+func (c *fakeNodeMgmtClient) CallLifecycleStartEndpointWithReplaceIp(pod *corev1.Pod, replaceIp string) error {
+	c.startedPods = append(c.startedPods, pod.Name+"/"+replaceIp)
+	return nil
+}
+
+// TestStartCassandraEphemeralStorage verifies a new pod of the ephemeral storage mode replaces the node
+// of the pod it succeeds, while a restarted container starts the node with its data
+func TestStartCassandraEphemeralStorage(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.StorageConfig = api.StorageConfig{
+		EphemeralDataVolume: &api.EphemeralDataVolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	}
+	assert.NoError(t, rc.Client.Update(rc.Ctx, rc.Datacenter))
+	rc.Datacenter.Status.NodeStatuses = api.CassandraStatusMap{"pod-0": {HostID: "host-0"}}
+	assert.NoError(t, rc.Client.Status().Update(rc.Ctx, rc.Datacenter))
+
+	mgmtClient := &fakeNodeMgmtClient{}
+	rc.NodeMgmtClient = mgmtClient
+	endpoints := httphelper.CassMetadataEndpoints{
+		Entity: []httphelper.EndpointState{{HostID: "host-0", RpcAddress: "10.0.0.1"}},
+	}
+
+	pod := makeReloadTestPod()
+	pod.Name = "pod-0"
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "cassandra"}}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+
+	assert.NoError(t, rc.startCassandra(endpoints, pod))
+	assert.Equal(t, []string{"pod-0/10.0.0.1"}, mgmtClient.startedPods)
+
+	pod.Status.ContainerStatuses[0].RestartCount = 1
+	assert.NoError(t, rc.startCassandra(endpoints, pod))
+	assert.Equal(t, []string{"pod-0/10.0.0.1", "pod-0"}, mgmtClient.startedPods)
+}
+
+// TestStartBootstrappedNodes verifies the nodes which joined the ring before are started at once, while
+// a new node is left to be started on its own
+func TestStartBootstrappedNodes(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Status.NodeStatuses = api.CassandraStatusMap{
+		"pod-0": {HostID: "host-0"},
+		"pod-1": {HostID: "host-1"},
+		"pod-2": {HostID: "host-2"},
+	}
+	rc.Datacenter.Status.NodeReplacements = []string{"pod-2"}
+	assert.NoError(t, rc.Client.Status().Update(rc.Ctx, rc.Datacenter))
+
+	mgmtClient := &fakeNodeMgmtClient{}
+	rc.NodeMgmtClient = mgmtClient
+
+	startedAt := metav1.NewTime(time.Now().Add(-time.Minute))
+	rc.dcPods = nil
+	for _, name := range []string{"pod-0", "pod-1", "pod-2", "pod-3"} {
+		pod := makeReloadTestPod()
+		pod.Name = name
+		pod.Labels[api.CassNodeState] = stateReadyToStart
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:  "cassandra",
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: startedAt}},
+		}}
+		assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+		rc.dcPods = append(rc.dcPods, pod)
+	}
+
+	started, err := rc.startBootstrappedNodes(httphelper.CassMetadataEndpoints{})
+	assert.NoError(t, err)
+	assert.True(t, started)
+	assert.Equal(t, []string{"pod-0", "pod-1"}, mgmtClient.startedPods)
+	assert.Equal(t, stateStarting, rc.dcPods[0].Labels[api.CassNodeState])
+	assert.Equal(t, stateReadyToStart, rc.dcPods[3].Labels[api.CassNodeState])
+
+	started, err = rc.startBootstrappedNodes(httphelper.CassMetadataEndpoints{})
+	assert.NoError(t, err)
+	assert.False(t, started)
+	assert.Len(t, mgmtClient.startedPods, 2)
+}
+
+// TestStartAllNodesBalancesRacks verifies the next node started is one of the rack with the fewest ready
+// nodes
+func TestStartAllNodesBalancesRacks(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mgmtClient := &fakeNodeMgmtClient{}
+	rc.NodeMgmtClient = mgmtClient
+
+	startedAt := metav1.NewTime(time.Now().Add(-time.Minute))
+	newPod := func(name, rackName, state string, ready bool) *corev1.Pod {
+		pod := makeReloadTestPod()
+		pod.Name = name
+		pod.Labels[api.RackLabel] = rackName
+		pod.Labels[api.CassNodeState] = state
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:  "cassandra",
+			Ready: ready,
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: startedAt}},
+		}}
+		assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+		return pod
+	}
+	rc.dcPods = []*corev1.Pod{
+		newPod("rack1-0", "rack1", stateStarted, true),
+		newPod("rack1-1", "rack1", stateStarted, true),
+		newPod("rack1-2", "rack1", stateReadyToStart, false),
+		newPod("rack2-0", "rack2", stateStarted, true),
+		newPod("rack2-1", "rack2", stateReadyToStart, false),
+	}
+
+	needsMoreNodes, err := rc.startAllNodes(httphelper.CassMetadataEndpoints{})
+	assert.NoError(t, err)
+	assert.True(t, needsMoreNodes)
+	assert.Equal(t, []string{"rack2-1"}, mgmtClient.startedPods)
+}
+
+// TestStartAllNodesDelay verifies the next node is only started nodeStartDelaySeconds after the last one
+// became ready
+func TestStartAllNodesDelay(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.NodeStartDelaySeconds = 120
+	mgmtClient := &fakeNodeMgmtClient{}
+	rc.NodeMgmtClient = mgmtClient
+
+	startedAt := metav1.NewTime(time.Now().Add(-time.Hour))
+	readyPod := makeReloadTestPod()
+	readyPod.Name = "pod-0"
+	readyPod.Labels[api.CassNodeState] = stateStarted
+	readyPod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "cassandra",
+		Ready: true,
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: startedAt}},
+	}}
+	readyPod.Status.Conditions = []corev1.PodCondition{{
+		Type:               corev1.PodReady,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Minute)),
+	}}
+	newPod := makeReloadTestPod()
+	newPod.Name = "pod-1"
+	newPod.Labels[api.CassNodeState] = stateReadyToStart
+	newPod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "cassandra",
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: startedAt}},
+	}}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, newPod))
+	rc.dcPods = []*corev1.Pod{readyPod, newPod}
+
+	needsMoreNodes, err := rc.startAllNodes(httphelper.CassMetadataEndpoints{})
+	assert.NoError(t, err)
+	assert.True(t, needsMoreNodes)
+	assert.Empty(t, mgmtClient.startedPods)
+
+	readyPod.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-3 * time.Minute))
+	needsMoreNodes, err = rc.startAllNodes(httphelper.CassMetadataEndpoints{})
+	assert.NoError(t, err)
+	assert.True(t, needsMoreNodes)
+	assert.Equal(t, []string{"pod-1"}, mgmtClient.startedPods)
+}
+
+// TestStartAllNodesWaitsForJoiningNode verifies the next node is only started once the ready ones completed
+// their bootstrap
+func TestStartAllNodesWaitsForJoiningNode(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mgmtClient := &fakeNodeMgmtClient{}
+	rc.NodeMgmtClient = mgmtClient
+
+	startedAt := metav1.NewTime(time.Now().Add(-time.Hour))
+	readyPod := makeReloadTestPod()
+	readyPod.Name = "pod-0"
+	readyPod.Labels[api.CassNodeState] = stateStarted
+	readyPod.Status.PodIP = "10.0.0.1"
+	readyPod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "cassandra",
+		Ready: true,
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: startedAt}},
+	}}
+	newPod := makeReloadTestPod()
+	newPod.Name = "pod-1"
+	newPod.Labels[api.CassNodeState] = stateReadyToStart
+	newPod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "cassandra",
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: startedAt}},
+	}}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, newPod))
+	rc.dcPods = []*corev1.Pod{readyPod, newPod}
+
+	epData := httphelper.CassMetadataEndpoints{
+		Entity: []httphelper.EndpointState{{RpcAddress: "10.0.0.1", IsAlive: "true", Status: "BOOT"}},
+	}
+	needsMoreNodes, err := rc.startAllNodes(epData)
+	assert.NoError(t, err)
+	assert.True(t, needsMoreNodes)
+	assert.Empty(t, mgmtClient.startedPods)
+
+	epData.Entity[0].Status = "NORMAL"
+	needsMoreNodes, err = rc.startAllNodes(epData)
+	assert.NoError(t, err)
+	assert.True(t, needsMoreNodes)
+	assert.Equal(t, []string{"pod-1"}, mgmtClient.startedPods)
+}
+
+// TestStartNodesInFlight verifies more nodes are started while others are starting, one per rack and up
+// to maxNodesInFlight, once every rack has a ready node and while the replicas are in distinct racks
+func TestStartNodesInFlight(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Size = 9
+	rc.Datacenter.Spec.Racks = []api.Rack{{Name: "rack1"}, {Name: "rack2"}, {Name: "rack3"}}
+	rc.Datacenter.Spec.MaxNodesInFlight = 3
+	assert.NoError(t, rc.CalculateRackInformation())
+
+	mgmtClient := &fakeNodeMgmtClient{}
+	rc.NodeMgmtClient = mgmtClient
+
+	startedAt := metav1.NewTime(time.Now().Add(-time.Minute))
+	newPod := func(name, rackName, state string, ready bool) *corev1.Pod {
+		pod := makeReloadTestPod()
+		pod.Name = name
+		pod.Labels[api.RackLabel] = rackName
+		pod.Labels[api.CassNodeState] = state
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:  "cassandra",
+			Ready: ready,
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: startedAt}},
+		}}
+		assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+		return pod
+	}
+	rc.dcPods = []*corev1.Pod{
+		newPod("rack1-0", "rack1", stateStarted, true),
+		newPod("rack1-1", "rack1", stateStarting, false),
+		newPod("rack1-2", "rack1", stateReadyToStart, false),
+		newPod("rack2-0", "rack2", stateStarted, true),
+		newPod("rack2-1", "rack2", stateReadyToStart, false),
+		newPod("rack2-2", "rack2", stateReadyToStart, false),
+		newPod("rack3-0", "rack3", stateReadyToStart, false),
+	}
+	rc.clusterPods = rc.dcPods
+
+	// The first node of each rack is started on its own
+	started, err := rc.startNodesInFlight(httphelper.CassMetadataEndpoints{})
+	assert.NoError(t, err)
+	assert.False(t, started)
+	assert.Empty(t, mgmtClient.startedPods)
+
+	rc.dcPods[6].Labels[api.CassNodeState] = stateStarted
+	rc.dcPods[6].Status.ContainerStatuses[0].Ready = true
+	rc.dcPods = append(rc.dcPods, newPod("rack3-1", "rack3", stateReadyToStart, false))
+	rc.clusterPods = rc.dcPods
+
+	// The replicas of a range would not all be in distinct racks
+	dcName := rc.Datacenter.DatacenterName()
+	for _, replication := range []map[string]string{
+		{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", dcName: "4"},
+		{"class": "org.apache.cassandra.locator.SimpleStrategy", "replication_factor": "2"},
+	} {
+		mgmtClient.replications = map[string]map[string]string{"ks1": replication}
+		started, err = rc.startNodesInFlight(httphelper.CassMetadataEndpoints{})
+		assert.NoError(t, err)
+		assert.False(t, started)
+		assert.Empty(t, mgmtClient.startedPods)
+	}
+
+	mgmtClient.replications = map[string]map[string]string{
+		"ks1":          {"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", dcName: "3", "dc2": "5"},
+		"ks2":          {"class": "org.apache.cassandra.locator.SimpleStrategy", "replication_factor": "1"},
+		"system_local": {"class": "org.apache.cassandra.locator.LocalStrategy"},
+	}
+	started, err = rc.startNodesInFlight(httphelper.CassMetadataEndpoints{})
+	assert.NoError(t, err)
+	assert.True(t, started)
+	assert.Equal(t, []string{"rack2-1", "rack3-1"}, mgmtClient.startedPods)
+
+	// The limit is reached
+	started, err = rc.startNodesInFlight(httphelper.CassMetadataEndpoints{})
+	assert.NoError(t, err)
+	assert.False(t, started)
+	assert.Len(t, mgmtClient.startedPods, 2)
+}
+
+func TestIsClusterHealthy(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.clusterPods = nil
+	for _, name := range []string{"pod-0", "pod-1", "pod-2"} {
+		pod := makeReloadTestPod()
+		pod.Name = name
+		pod.Labels[api.CassNodeState] = stateStarted
+		rc.clusterPods = append(rc.clusterPods, pod)
+	}
+
+	mgmtClient := &fakeNodeMgmtClient{}
+	rc.NodeMgmtClient = mgmtClient
+	assert.True(t, rc.isClusterHealthy())
+	assert.ElementsMatch(t, []string{"pod-0", "pod-1", "pod-2"}, mgmtClient.probedPods)
+
+	// All the pods are queried, and the failures are reported in a single event
+	mgmtClient = &fakeNodeMgmtClient{unhealthyPods: map[string]bool{"pod-1": true, "pod-2": true}}
+	rc.NodeMgmtClient = mgmtClient
+	assert.False(t, rc.isClusterHealthy())
+	assert.ElementsMatch(t, []string{"pod-0", "pod-1", "pod-2"}, mgmtClient.probedPods)
+
+	fakeRecorder := rc.Recorder.(*record.FakeRecorder)
+	assert.Equal(t, 1, len(fakeRecorder.Events))
+	assert.Contains(t, <-fakeRecorder.Events, "Pods pod-1, pod-2 failed the LOCAL_QUORUM check")
+}
+
+func TestIsClusterHealthy_CustomSettings(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	pod := makeReloadTestPod()
+	pod.Labels[api.CassNodeState] = stateStarted
+	rc.clusterPods = []*corev1.Pod{pod}
+
+	rc.Datacenter.Spec.Racks = []api.Rack{{Name: "rack1"}, {Name: "rack2"}, {Name: "rack3"}}
+	mgmtClient := &fakeNodeMgmtClient{}
+	rc.NodeMgmtClient = mgmtClient
+	assert.True(t, rc.isClusterHealthy())
+	assert.Equal(t, "LOCAL_QUORUM/3", mgmtClient.probeSettings)
+
+	rc.Datacenter.Spec.HealthCheckConsistencyLevel = "ONE"
+	rc.Datacenter.Spec.HealthCheckReplicationFactor = 1
+	assert.True(t, rc.isClusterHealthy())
+	assert.Equal(t, "ONE/1", mgmtClient.probeSettings)
+}
+
+func TestUpdateRackStatuses(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Size = 4
+	rc.Datacenter.Spec.Racks = []api.Rack{{Name: "rack1"}, {Name: "rack2"}, {Name: "rack3"}}
+	rc.Datacenter.Generation = 3
+	assert.NoError(t, rc.CalculateRackInformation())
+
+	// Only the first two racks exist
+	for _, rackInfo := range rc.desiredRackInformation[:2] {
+		sts, _, err := rc.GetStatefulSetForRack(rackInfo)
+		assert.NoError(t, err)
+		assert.NoError(t, rc.Client.Create(rc.Ctx, sts))
+	}
+
+	sts := &appsv1.StatefulSet{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, newNamespacedNameForStatefulSet(rc.Datacenter, "rack1"), sts))
+	sts.Status = appsv1.StatefulSetStatus{
+		ObservedGeneration: sts.Generation,
+		Replicas:           2,
+		ReadyReplicas:      2,
+		CurrentReplicas:    2,
+		UpdatedReplicas:    2,
+		CurrentRevision:    "1",
+		UpdateRevision:     "1",
+	}
+	assert.NoError(t, rc.Client.Status().Update(rc.Ctx, sts))
+
+	assert.NoError(t, rc.Client.Get(rc.Ctx, newNamespacedNameForStatefulSet(rc.Datacenter, "rack2"), sts))
+	sts.Status = appsv1.StatefulSetStatus{
+		ObservedGeneration: sts.Generation,
+		Replicas:           1,
+		ReadyReplicas:      1,
+		UpdatedReplicas:    0,
+		CurrentRevision:    "1",
+		UpdateRevision:     "2",
+	}
+	assert.NoError(t, rc.Client.Status().Update(rc.Ctx, sts))
+
+	assert.NoError(t, rc.UpdateRackStatuses())
+	assert.Equal(t, int64(3), rc.Datacenter.Status.ObservedGeneration)
+	assert.Equal(t, map[string]api.RackStatus{
+		"rack1": {DesiredNodes: 2, ReadyNodes: 2, UpdatedNodes: 2, Stage: api.RackStageReady},
+		"rack2": {DesiredNodes: 1, ReadyNodes: 1, UpdatedNodes: 0, Stage: api.RackStageUpdating},
+		"rack3": {DesiredNodes: 1, Stage: api.RackStagePending},
+	}, rc.Datacenter.Status.RackStatuses)
+	assert.Equal(t, int32(3), rc.Datacenter.Status.ReadyNodes)
+	selector, err := labels.Parse(rc.Datacenter.Status.Selector)
+	assert.NoError(t, err)
+	assert.True(t, selector.Matches(labels.Set(rc.Datacenter.GetRackLabels("rack1"))))
+
+	// Stopping the datacenter scales the racks down
+	rc.Datacenter.Spec.Stopped = true
+	assert.NoError(t, rc.UpdateRackStatuses())
+	assert.Equal(t, api.RackStageScaling, rc.Datacenter.Status.RackStatuses["rack1"].Stage)
+	assert.Equal(t, int32(0), rc.Datacenter.Status.RackStatuses["rack1"].DesiredNodes)
+}
+
+// TestUpdateRackStatusesServerVersion verifies the server version is only recorded once every rack
+// runs it
+func TestUpdateRackStatusesServerVersion(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	assert.NoError(t, rc.CalculateRackInformation())
+	rackInfo := rc.desiredRackInformation[0]
+
+	readyStatus := func(sts *appsv1.StatefulSet) appsv1.StatefulSetStatus {
+		return appsv1.StatefulSetStatus{
+			ObservedGeneration: sts.Generation,
+			Replicas:           *sts.Spec.Replicas,
+			ReadyReplicas:      *sts.Spec.Replicas,
+			CurrentReplicas:    *sts.Spec.Replicas,
+			UpdatedReplicas:    *sts.Spec.Replicas,
+			CurrentRevision:    "1",
+			UpdateRevision:     "1",
+		}
+	}
+
+	sts, _, err := rc.GetStatefulSetForRack(rackInfo)
+	assert.NoError(t, err)
+	assert.NoError(t, rc.Client.Create(rc.Ctx, sts))
+	sts.Status = readyStatus(sts)
+	assert.NoError(t, rc.Client.Status().Update(rc.Ctx, sts))
+
+	oldVersion := rc.Datacenter.Spec.ServerVersion
+	oldImage, err := makeImage(rc.Datacenter)
+	assert.NoError(t, err)
+	assert.NoError(t, rc.UpdateRackStatuses())
+	assert.Equal(t, oldVersion, rc.Datacenter.Status.ServerVersion)
+	assert.Equal(t, oldImage, rc.Datacenter.Status.ServerImage)
+
+	// The previous version is reported while the rack is updated
+	rc.Datacenter.Spec.ServerVersion = "6.8.26"
+	assert.NoError(t, rc.Client.Update(rc.Ctx, rc.Datacenter))
+	desiredSts, err := newStatefulSetForCassandraDatacenter(sts, rackInfo.RackName, rc.Datacenter, rackInfo.NodeCount)
+	assert.NoError(t, err)
+	sts.Spec = desiredSts.Spec
+	assert.NoError(t, rc.Client.Update(rc.Ctx, sts))
+	sts.Status.UpdateRevision = "2"
+	assert.NoError(t, rc.Client.Status().Update(rc.Ctx, sts))
+	assert.NoError(t, rc.UpdateRackStatuses())
+	assert.Equal(t, oldVersion, rc.Datacenter.Status.ServerVersion)
+
+	sts.Status = readyStatus(sts)
+	assert.NoError(t, rc.Client.Status().Update(rc.Ctx, sts))
+	assert.NoError(t, rc.UpdateRackStatuses())
+	assert.Equal(t, "6.8.26", rc.Datacenter.Status.ServerVersion)
+	newImage, err := makeImage(rc.Datacenter)
+	assert.NoError(t, err)
+	assert.Equal(t, newImage, rc.Datacenter.Status.ServerImage)
+	assert.Nil(t, rc.Datacenter.Status.SSTablesUpgrade)
+
+	// The sstables are upgraded after a new major version was rolled out
+	rc.Datacenter.Spec.UpgradeSSTables = &api.UpgradeSSTablesConfig{}
+	assert.NoError(t, rc.Client.Update(rc.Ctx, rc.Datacenter))
+	assert.NoError(t, rc.UpdateRackStatuses())
+	assert.Nil(t, rc.Datacenter.Status.SSTablesUpgrade)
+
+	rc.Datacenter.Status.ServerVersion = "5.1.20"
+	assert.NoError(t, rc.Client.Status().Update(rc.Ctx, rc.Datacenter))
+	assert.NoError(t, rc.UpdateRackStatuses())
+	assert.Equal(t, "6.8.26", rc.Datacenter.Status.ServerVersion)
+	if assert.NotNil(t, rc.Datacenter.Status.SSTablesUpgrade) {
+		assert.Equal(t, "6.8.26", rc.Datacenter.Status.SSTablesUpgrade.ServerVersion)
+	}
+}
+
+// TestReleaseNextPartitionedPod verifies the pods held back by the partition are released one at a time, once
+// the updated ones are ready and up in the ring
+func TestReleaseNextPartitionedPod(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	assert.NoError(t, rc.CalculateRackInformation())
+	rackInfo := rc.desiredRackInformation[0]
+
+	sts, _, err := rc.GetStatefulSetForRack(rackInfo)
+	assert.NoError(t, err)
+	partition := int32(2)
+	sts.Spec.Replicas = &partition
+	sts.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+		Type: appsv1.RollingUpdateStatefulSetStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
+			Partition: &partition,
+		},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, sts))
+	sts.Status = appsv1.StatefulSetStatus{
+		ObservedGeneration: sts.Generation,
+		Replicas:           2,
+		ReadyReplicas:      2,
+	}
+	assert.NoError(t, rc.Client.Status().Update(rc.Ctx, sts))
+
+	rackLabels := rc.Datacenter.GetRackLabels(rackInfo.RackName)
+	rc.dcPods = []*corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: sts.Name + "-0", Labels: rackLabels}, Status: corev1.PodStatus{PodIP: "10.0.0.1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: sts.Name + "-1", Labels: rackLabels}, Status: corev1.PodStatus{PodIP: "10.0.0.2"}},
+	}
+	epData := httphelper.CassMetadataEndpoints{
+		Entity: []httphelper.EndpointState{
+			{RpcAddress: "10.0.0.1", IsAlive: "true", Status: "NORMAL"},
+			{RpcAddress: "10.0.0.2", IsAlive: "true", Status: "NORMAL"},
+		},
+	}
+
+	partitionOf := func() int32 {
+		current := &appsv1.StatefulSet{}
+		assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: sts.Name, Namespace: sts.Namespace}, current))
+		return *current.Spec.UpdateStrategy.RollingUpdate.Partition
+	}
+
+	// The last pod is released first
+	assert.Equal(t, result.Done(), rc.releaseNextPartitionedPod(rackInfo.RackName, sts, epData))
+	assert.Equal(t, int32(1), partitionOf())
+
+	// The next one waits for it to be updated
+	assert.Equal(t, result.RequeueSoon(10), rc.releaseNextPartitionedPod(rackInfo.RackName, sts, epData))
+	assert.Equal(t, int32(1), partitionOf())
+
+	// and to rejoin the ring
+	sts.Status.UpdatedReplicas = 1
+	epData.Entity[1].IsAlive = "false"
+	assert.Equal(t, result.RequeueSoon(10), rc.releaseNextPartitionedPod(rackInfo.RackName, sts, epData))
+	assert.Equal(t, int32(1), partitionOf())
+
+	epData.Entity[1].IsAlive = "true"
+	assert.Equal(t, result.Done(), rc.releaseNextPartitionedPod(rackInfo.RackName, sts, epData))
+	assert.Equal(t, int32(0), partitionOf())
+	assert.False(t, hasUpdatePartition(sts))
+}
+
+// TestDeleteNextOutdatedPod verifies the outdated pods of an OnDelete StatefulSet are deleted one at a time,
+// non-seeds first, once the others are ready and up in the ring
+func TestDeleteNextOutdatedPod(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	assert.NoError(t, rc.CalculateRackInformation())
+	rackInfo := rc.desiredRackInformation[0]
+
+	sts, err := newStatefulSetForCassandraDatacenter(nil, rackInfo.RackName, rc.Datacenter, 3)
+	assert.NoError(t, err)
+	sts.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, sts))
+	sts.Status = appsv1.StatefulSetStatus{
+		ObservedGeneration: sts.Generation,
+		Replicas:           3,
+		ReadyReplicas:      3,
+		CurrentRevision:    "1",
+		UpdateRevision:     "2",
+	}
+
+	newPod := func(idx int32, revision string) *corev1.Pod {
+		labels := rc.Datacenter.GetRackLabels(rackInfo.RackName)
+		labels[appsv1.ControllerRevisionHashLabelKey] = revision
+		if idx == 0 {
+			labels[api.SeedNodeLabel] = "true"
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      getStatefulSetPodNameForIdx(sts, idx),
+				Namespace: rc.Datacenter.Namespace,
+				Labels:    labels,
+			},
+			Status: corev1.PodStatus{PodIP: fmt.Sprintf("10.0.0.%d", idx+1)},
+		}
+		assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+		return pod
+	}
+	rc.dcPods = []*corev1.Pod{newPod(0, "1"), newPod(1, "1"), newPod(2, "1")}
+
+	epData := httphelper.CassMetadataEndpoints{
+		Entity: []httphelper.EndpointState{
+			{RpcAddress: "10.0.0.1", IsAlive: "true", Status: "NORMAL"},
+			{RpcAddress: "10.0.0.2", IsAlive: "true", Status: "NORMAL"},
+			{RpcAddress: "10.0.0.3", IsAlive: "true", Status: "NORMAL"},
+		},
+	}
+
+	podExists := func(idx int32) bool {
+		pod := rc.dcPods[idx]
+		return rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, &corev1.Pod{}) == nil
+	}
+
+	// The non-seed with the highest ordinal goes first
+	assert.Equal(t, result.Done(), rc.deleteNextOutdatedPod(rackInfo.RackName, sts, epData))
+	assert.False(t, podExists(2))
+	assert.True(t, podExists(1))
+
+	// The next one waits for it to be recreated
+	now := metav1.Now()
+	rc.dcPods[2].DeletionTimestamp = &now
+	assert.Equal(t, result.RequeueSoon(10), rc.deleteNextOutdatedPod(rackInfo.RackName, sts, epData))
+	rc.dcPods = rc.dcPods[:2]
+	sts.Status.ReadyReplicas = 2
+	assert.Equal(t, result.RequeueSoon(10), rc.deleteNextOutdatedPod(rackInfo.RackName, sts, epData))
+	assert.True(t, podExists(1))
+
+	// and to rejoin the ring
+	rc.dcPods = append(rc.dcPods, newPod(2, "2"))
+	sts.Status.ReadyReplicas = 3
+	epData.Entity[2].IsAlive = "false"
+	assert.Equal(t, result.RequeueSoon(10), rc.deleteNextOutdatedPod(rackInfo.RackName, sts, epData))
+	assert.True(t, podExists(1))
+
+	epData.Entity[2].IsAlive = "true"
+	assert.Equal(t, result.Done(), rc.deleteNextOutdatedPod(rackInfo.RackName, sts, epData))
+	assert.False(t, podExists(1))
+	assert.True(t, podExists(0))
+
+	// The seed goes last
+	rc.dcPods[1] = newPod(1, "2")
+	assert.Equal(t, result.Done(), rc.deleteNextOutdatedPod(rackInfo.RackName, sts, epData))
+	assert.False(t, podExists(0))
+
+	rc.dcPods[0] = newPod(0, "2")
+	assert.Equal(t, result.Continue(), rc.deleteNextOutdatedPod(rackInfo.RackName, sts, epData))
+}
+
+// TestOnDeleteRolloutCompletes verifies an OnDelete StatefulSet is considered updated once all its pods run
+// the update revision, although the StatefulSet controller keeps reporting the previous currentRevision
+func TestOnDeleteRolloutCompletes(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.OnDeleteUpdates = true
+	assert.NoError(t, rc.CalculateRackInformation())
+	rackInfo := rc.desiredRackInformation[0]
+
+	sts, _, err := rc.GetStatefulSetForRack(rackInfo)
+	assert.NoError(t, err)
+	sts.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, sts))
+	replicas := *sts.Spec.Replicas
+	sts.Status = appsv1.StatefulSetStatus{
+		ObservedGeneration: sts.Generation,
+		Replicas:           replicas,
+		ReadyReplicas:      replicas,
+		CurrentReplicas:    0,
+		UpdatedReplicas:    replicas,
+		CurrentRevision:    "1",
+		UpdateRevision:     "2",
+	}
+	assert.NoError(t, rc.Client.Status().Update(rc.Ctx, sts))
+	rc.statefulSets = []*appsv1.StatefulSet{sts}
+
+	rc.dcPods = nil
+	for idx := int32(0); idx < replicas; idx++ {
+		labels := rc.Datacenter.GetRackLabels(rackInfo.RackName)
+		labels[appsv1.ControllerRevisionHashLabelKey] = "2"
+		rc.dcPods = append(rc.dcPods, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      getStatefulSetPodNameForIdx(sts, idx),
+				Namespace: rc.Datacenter.Namespace,
+				Labels:    labels,
+			},
+		})
+	}
+
+	assert.True(t, IsStatefulSetUpdated(sts))
+	assert.Equal(t, result.Continue(), rc.CheckRackPodTemplate(httphelper.CassMetadataEndpoints{}))
+
+	assert.NoError(t, rc.UpdateRackStatuses())
+	assert.Equal(t, api.RackStageReady, rc.Datacenter.Status.RackStatuses[rackInfo.RackName].Stage)
+	assert.Equal(t, rc.Datacenter.Spec.ServerVersion, rc.Datacenter.Status.ServerVersion)
+
+	// A pod still running the previous revision keeps the rack updating
+	sts.Status.UpdatedReplicas = replicas - 1
+	assert.NoError(t, rc.Client.Status().Update(rc.Ctx, sts))
+	assert.False(t, IsStatefulSetUpdated(sts))
+	assert.NoError(t, rc.UpdateRackStatuses())
+	assert.Equal(t, api.RackStageUpdating, rc.Datacenter.Status.RackStatuses[rackInfo.RackName].Stage)
+}
+
+// TestCheckServerVersionUpgrade verifies a downgrade of the running version invalidates the datacenter
+// until the serverVersion is fixed
+func TestCheckServerVersionUpgrade(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Status.ServerVersion = "6.8.26"
+	assert.NoError(t, rc.Client.Status().Update(rc.Ctx, rc.Datacenter))
+
+	rc.Datacenter.Spec.ServerVersion = "6.8.4"
+	assert.NoError(t, rc.Client.Update(rc.Ctx, rc.Datacenter))
+	assert.True(t, rc.CheckServerVersionUpgrade().Completed())
+	cond, found := rc.Datacenter.GetCondition(api.DatacenterValid)
+	assert.True(t, found)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, unsupportedServerUpgrade, cond.Reason)
+	assert.Contains(t, cond.Message, "downgrade serverVersion from 6.8.26 to 6.8.4")
+	assert.True(t, rc.CheckForInvalidState().Completed())
+
+	rc.Datacenter.Spec.ServerVersion = "6.8.26"
+	assert.NoError(t, rc.Client.Update(rc.Ctx, rc.Datacenter))
+	assert.False(t, rc.CheckServerVersionUpgrade().Completed())
+	assert.Equal(t, corev1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterValid))
+}
+
+// TestCheckDcPodDisruptionBudget verifies the budget follows the size of the datacenter and its
+// podDisruptionBudget config, and is updated in place
+func TestCheckDcPodDisruptionBudget(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Size = 3
+	assert.False(t, rc.CheckDcPodDisruptionBudget().Completed())
+
+	pdb := &policyv1.PodDisruptionBudget{}
+	key := types.NamespacedName{Namespace: rc.Datacenter.Namespace, Name: rc.Datacenter.Name + "-pdb"}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, pdb))
+	assert.Equal(t, intstr.FromInt(2), *pdb.Spec.MinAvailable)
+	uid := pdb.UID
+
+	rc.Datacenter.Spec.Size = 6
+	assert.False(t, rc.CheckDcPodDisruptionBudget().Completed())
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, pdb))
+	assert.Equal(t, intstr.FromInt(5), *pdb.Spec.MinAvailable)
+	assert.Equal(t, uid, pdb.UID)
+
+	maxUnavailable := intstr.FromString("34%")
+	rc.Datacenter.Spec.PodDisruptionBudget = &api.PodDisruptionBudgetConfig{MaxUnavailable: &maxUnavailable}
+	assert.False(t, rc.CheckDcPodDisruptionBudget().Completed())
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, pdb))
+	assert.Nil(t, pdb.Spec.MinAvailable)
+	assert.Equal(t, maxUnavailable, *pdb.Spec.MaxUnavailable)
+
+	minAvailable := intstr.FromInt(4)
+	rc.Datacenter.Spec.PodDisruptionBudget = &api.PodDisruptionBudgetConfig{MinAvailable: &minAvailable}
+	assert.False(t, rc.CheckDcPodDisruptionBudget().Completed())
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, pdb))
+	assert.Equal(t, minAvailable, *pdb.Spec.MinAvailable)
+	assert.Nil(t, pdb.Spec.MaxUnavailable)
+}
+
+// TestCheckRackForceUpgrade verifies the racks listed in ForceUpgradeRacks are updated without querying the
+// health of the cluster, and the list is cleared afterwards
+func TestCheckRackForceUpgrade(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Size = 2
+	rc.Datacenter.Spec.Racks = []api.Rack{{Name: "rack1"}, {Name: "rack2"}}
+	assert.NoError(t, rc.Client.Update(rc.Ctx, rc.Datacenter))
+	assert.NoError(t, rc.CalculateRackInformation())
+	assert.False(t, rc.CheckRackCreation().Completed())
+
+	rc.Datacenter.Spec.ServerVersion = "6.8.5"
+	rc.Datacenter.Spec.ForceUpgradeRacks = []string{"rack2"}
+
+	mgmtClient := &fakeNodeMgmtClient{unhealthyPods: map[string]bool{"pod-0": true}}
+	rc.NodeMgmtClient = mgmtClient
+
+	result := rc.CheckRackForceUpgrade()
+	assert.True(t, result.Completed())
+	assert.Empty(t, mgmtClient.probedPods)
+	assert.Empty(t, rc.Datacenter.Spec.ForceUpgradeRacks)
+	assert.Equal(t, corev1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterUpdating))
+
+	for idx, rack := range rc.Datacenter.Spec.Racks {
+		sts := &appsv1.StatefulSet{}
+		assert.NoError(t, rc.Client.Get(rc.Ctx, newNamespacedNameForStatefulSet(rc.Datacenter, rack.Name), sts))
+		image := sts.Spec.Template.Spec.Containers[0].Image
+		if idx == 0 {
+			assert.NotContains(t, image, "6.8.5")
+		} else {
+			assert.Contains(t, image, "6.8.5")
+		}
+	}
+}
+
+func TestCheckRackStoppedState_DrainsHighestOrdinalFirst(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Stopped = true
+	rc.desiredRackInformation = []*RackInformation{{RackName: "default", NodeCount: 0}}
+
+	sts, err := newStatefulSetForCassandraDatacenter(nil, "default", rc.Datacenter, 3)
+	assert.NoError(t, err)
+	assert.NoError(t, rc.Client.Create(rc.Ctx, sts))
+	rc.statefulSets = []*appsv1.StatefulSet{sts}
+
+	startedAt := metav1.NewTime(time.Now().Add(-time.Minute))
+	rc.dcPods = nil
+	for _, idx := range []int32{1, 0, 2} {
+		rc.dcPods = append(rc.dcPods, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      getStatefulSetPodNameForIdx(sts, idx),
+				Namespace: rc.Datacenter.Namespace,
+				Labels:    rc.Datacenter.GetRackLabels("default"),
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name: "cassandra",
+					State: corev1.ContainerState{
+						Running: &corev1.ContainerStateRunning{StartedAt: startedAt},
+					},
+				}},
+			},
+		})
+	}
+
+	mgmtClient := &fakeNodeMgmtClient{}
+	rc.NodeMgmtClient = mgmtClient
+
+	result := rc.CheckRackStoppedState()
+	assert.True(t, result.Completed())
+	assert.Equal(t, []string{
+		getStatefulSetPodNameForIdx(sts, 2),
+		getStatefulSetPodNameForIdx(sts, 1),
+		getStatefulSetPodNameForIdx(sts, 0),
+	}, mgmtClient.drainedPods)
+	assert.Equal(t, int32(0), *rc.statefulSets[0].Spec.Replicas)
+}
+
+func TestCheckRackScale_ResumesSeedsFirst(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Status.Conditions = []api.DatacenterCondition{
+		*api.NewDatacenterCondition(api.DatacenterStopped, corev1.ConditionTrue),
+	}
+	rc.desiredRackInformation = []*RackInformation{
+		{RackName: "rack1", NodeCount: 3, SeedCount: 2},
+		{RackName: "rack2", NodeCount: 3, SeedCount: 1},
+	}
+
+	rc.statefulSets = nil
+	for _, rackInfo := range rc.desiredRackInformation {
+		sts, err := newStatefulSetForCassandraDatacenter(nil, rackInfo.RackName, rc.Datacenter, 0)
+		assert.NoError(t, err)
+		assert.NoError(t, rc.Client.Create(rc.Ctx, sts))
+		rc.statefulSets = append(rc.statefulSets, sts)
+	}
+
+	result := rc.CheckRackScale()
+	assert.False(t, result.Completed())
+	assert.Equal(t, int32(2), *rc.statefulSets[0].Spec.Replicas)
+	assert.Equal(t, int32(1), *rc.statefulSets[1].Spec.Replicas)
+	assert.Equal(t, corev1.ConditionFalse, rc.Datacenter.GetConditionStatus(api.DatacenterStopped))
+	assert.Equal(t, corev1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterResuming))
+
+	// the seed nodes are up, the racks can be scaled to their full size
+	rc.dcPods = nil
+	for idx, sts := range rc.statefulSets {
+		for podIdx := int32(0); podIdx < *sts.Spec.Replicas; podIdx++ {
+			rc.dcPods = append(rc.dcPods, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      getStatefulSetPodNameForIdx(sts, podIdx),
+					Namespace: rc.Datacenter.Namespace,
+					Labels:    rc.Datacenter.GetRackLabels(rc.desiredRackInformation[idx].RackName),
+				},
+				Status: corev1.PodStatus{
+					ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}},
+				},
+			})
+		}
+	}
+
+	result = rc.CheckRackScale()
+	assert.False(t, result.Completed())
+	assert.Equal(t, int32(3), *rc.statefulSets[0].Spec.Replicas)
+	assert.Equal(t, int32(3), *rc.statefulSets[1].Spec.Replicas)
+}
+
+func TestCheckRebuild(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	assert := assert.New(t)
+
+	assert.NoError(taskapi.AddToScheme(scheme.Scheme))
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(rc.Datacenter).Build()
+
+	// Nothing to do without the annotation
+	assert.False(rc.CheckRebuild().Completed())
+	assert.Empty(rc.Datacenter.Status.TrackedTasks)
+
+	metav1.SetMetaDataAnnotation(&rc.Datacenter.ObjectMeta, api.RebuildFromAnnotation, "dc1")
+	assert.NoError(rc.Client.Update(rc.Ctx, rc.Datacenter))
+
+	r := rc.CheckRebuild()
+	assert.Equal(result.RequeueSoon(10), r)
+	assert.Equal(1, len(rc.Datacenter.Status.TrackedTasks))
+
+	task := &taskapi.CassandraTask{}
+	taskKey := types.NamespacedName{Name: rc.Datacenter.Status.TrackedTasks[0].Name, Namespace: rc.Datacenter.Namespace}
+	assert.NoError(rc.Client.Get(rc.Ctx, taskKey, task))
+	assert.Equal(taskapi.CommandRebuild, task.Spec.Jobs[0].Command)
+	assert.Equal("dc1", task.Spec.Jobs[0].Arguments.SourceDatacenter)
+
+	// Wait for the task to complete
+	r = rc.CheckRebuild()
+	assert.Equal(result.RequeueSoon(10), r)
+
+	now := metav1.Now()
+	task.Status.CompletionTime = &now
+	assert.NoError(rc.Client.Status().Update(rc.Ctx, task))
+
+	r = rc.CheckRebuild()
+	assert.Equal(result.Continue(), r)
+	assert.Empty(rc.Datacenter.Status.TrackedTasks)
+	assert.NotContains(rc.Datacenter.Annotations, api.RebuildFromAnnotation)
+}
+
+func TestCheckRebuildFailed(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	assert := assert.New(t)
+
+	assert.NoError(taskapi.AddToScheme(scheme.Scheme))
+	metav1.SetMetaDataAnnotation(&rc.Datacenter.ObjectMeta, api.RebuildFromAnnotation, "dc1")
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(rc.Datacenter).Build()
+
+	r := rc.CheckRebuild()
+	assert.Equal(result.RequeueSoon(10), r)
+	assert.Equal(1, len(rc.Datacenter.Status.TrackedTasks))
+
+	task := &taskapi.CassandraTask{}
+	taskKey := types.NamespacedName{Name: rc.Datacenter.Status.TrackedTasks[0].Name, Namespace: rc.Datacenter.Namespace}
+	assert.NoError(rc.Client.Get(rc.Ctx, taskKey, task))
+
+	now := metav1.Now()
+	task.Status.CompletionTime = &now
+	task.Status.Failed = 1
+	assert.NoError(rc.Client.Status().Update(rc.Ctx, task))
+
+	// The failed task is no longer tracked, but the annotation is kept to retry the rebuild
+	r = rc.CheckRebuild()
+	assert.Equal(result.RequeueSoon(60), r)
+	assert.Empty(rc.Datacenter.Status.TrackedTasks)
+	assert.Contains(rc.Datacenter.Annotations, api.RebuildFromAnnotation)
+
+	fakeRecorder := rc.Recorder.(*record.FakeRecorder)
+	assert.Equal(2, len(fakeRecorder.Events))
+	assert.Contains(<-fakeRecorder.Events, "Rebuilding datacenter from dc1")
+	assert.Contains(<-fakeRecorder.Events, "failed to rebuild 1 nodes from dc1")
+}
@@ -0,0 +1,445 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/images"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/pkg/reaper"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+)
+
+const (
+	reaperNameLabelValue = "reaper"
+	reaperPort           = 8080
+	reaperAdminPort      = 8081
+	reaperUIUsername     = "reaper"
+)
+
+// newReaperClient is a variable to allow mocking the Reaper REST API in tests
+var newReaperClient = reaper.NewClient
+
+// CheckReaper When the Reaper property is set, deploys the Reaper instance of the cluster and
+// registers the cluster in it. The datacenters of the cluster enabling Reaper all own the Reaper
+// deployment and service, which are deleted with the last of them. Their first owner manages
+// them, the Reaper property of the other datacenters is ignored. When the property is unset,
+// the datacenter releases its ownership.
+func (rc *ReconciliationContext) CheckReaper() result.ReconcileResult {
+	dc := rc.Datacenter
+	if dc.Spec.Reaper == nil {
+		if _, found := dc.GetCondition(api.DatacenterReaperRegistered); !found {
+			return result.Continue()
+		}
+		if err := rc.removeReaper(); err != nil {
+			return result.Error(err)
+		}
+		return result.Continue()
+	}
+
+	if dc.Spec.Stopped {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_reaper::CheckReaper")
+
+	if err := rc.createReaperKeyspace(); err != nil {
+		rc.ReqLogger.Error(err, "failed to create the reaper keyspace")
+		return result.RequeueSoon(10)
+	}
+
+	uiSecret, err := rc.checkReaperUISecret()
+	if err != nil {
+		return result.Error(err)
+	}
+
+	if err := rc.reconcileReaperObject(newReaperServiceForCassandraDatacenter(dc), &corev1.Service{}); err != nil {
+		return result.Error(err)
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := rc.reconcileReaperObject(newReaperDeploymentForCassandraDatacenter(dc), deployment); err != nil {
+		return result.Error(err)
+	}
+
+	// The condition tracks the datacenter owning the reaper objects until it is registered
+	switch dc.Status.GetConditionStatus(api.DatacenterReaperRegistered) {
+	case corev1.ConditionTrue:
+		return result.Continue()
+	case corev1.ConditionUnknown:
+		if err := rc.setReaperRegistered(corev1.ConditionFalse); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	if deployment.Status.AvailableReplicas < 1 {
+		rc.ReqLogger.Info("waiting for reaper to be available", "Deployment", deployment.Name)
+		return result.RequeueSoon(10)
+	}
+
+	reaperClient := newReaperClient(getReaperURL(dc), string(uiSecret.Data["username"]), string(uiSecret.Data["password"]))
+	if err := reaperClient.RegisterCluster(rc.Ctx, dc.Spec.ClusterName, dc.GetSeedServiceName(), dc.GetReaperJmxPort()); err != nil {
+		rc.ReqLogger.Error(err, "failed to register the cluster in reaper")
+		return result.RequeueSoon(10)
+	}
+
+	if err := rc.setReaperRegistered(corev1.ConditionTrue); err != nil {
+		return result.Error(err)
+	}
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.RegisteredInReaper,
+		"Registered cluster %s in reaper", dc.Spec.ClusterName)
+
+	return result.Continue()
+}
+
+// createReaperKeyspace Creates the keyspace of the Reaper schema if it does not exist yet,
+// replicated in this datacenter only.
+func (rc *ReconciliationContext) createReaperKeyspace() error {
+	var pod *corev1.Pod
+	for _, p := range rc.dcPods {
+		if isMgmtApiRunning(p) {
+			pod = p
+			break
+		}
+	}
+	if pod == nil {
+		return fmt.Errorf("no pod of datacenter %s is ready", rc.Datacenter.Name)
+	}
+
+	keyspace := rc.Datacenter.GetReaperKeyspace()
+	keyspaces, err := rc.NodeMgmtClient.GetKeyspace(pod, keyspace)
+	if err != nil {
+		return err
+	}
+	if len(keyspaces) > 0 {
+		return nil
+	}
+
+	replicationFactor := rc.Datacenter.Spec.Size
+	if replicationFactor > 3 {
+		replicationFactor = 3
+	}
+	rc.ReqLogger.Info("creating the reaper keyspace", "keyspace", keyspace)
+	return rc.NodeMgmtClient.CreateKeyspace(pod, keyspace, []map[string]string{{
+		"dc_name":            rc.Datacenter.DatacenterName(),
+		"replication_factor": strconv.Itoa(int(replicationFactor)),
+	}})
+}
+
+// checkReaperUISecret Creates the secret of the credentials of the Reaper UI and REST API if it does not
+// exist yet, with a generated password, and makes sure the datacenter is one of its owners. An existing
+// secret is never updated, so that its credentials can be changed.
+func (rc *ReconciliationContext) checkReaperUISecret() (*corev1.Secret, error) {
+	dc := rc.Datacenter
+	key := types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetReaperUISecretName()}
+	secret := &corev1.Secret{}
+	err := rc.Client.Get(rc.Ctx, key, secret)
+	if errors.IsNotFound(err) {
+		password, err := generateUtf8Password()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate the reaper UI password: %w", err)
+		}
+
+		labels := dc.GetClusterLabels()
+		oplabels.AddOperatorLabels(labels, dc)
+		labels[oplabels.NameLabel] = reaperNameLabelValue
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels:    labels,
+			},
+			Data: map[string][]byte{
+				"username": []byte(reaperUIUsername),
+				"password": []byte(password),
+			},
+		}
+		if err := controllerutil.SetOwnerReference(dc, secret, rc.Scheme); err != nil {
+			return nil, err
+		}
+		rc.ReqLogger.Info("creating reaper UI secret", "name", key.Name)
+		if err := rc.Client.Create(rc.Ctx, secret); err != nil {
+			return nil, err
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+			"Created reaper object %s", key.Name)
+		return secret, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !hasOwner(secret, dc) {
+		if err := controllerutil.SetOwnerReference(dc, secret, rc.Scheme); err != nil {
+			return nil, err
+		}
+		if err := rc.Client.Update(rc.Ctx, secret); err != nil {
+			return nil, err
+		}
+	}
+	return secret, nil
+}
+
+// reconcileReaperObject Creates the shared Reaper object, or updates it when its hash changed,
+// and makes sure the datacenter is one of its owners. The current state of the object is
+// loaded in current.
+func (rc *ReconciliationContext) reconcileReaperObject(desired, current client.Object) error {
+	dc := rc.Datacenter
+	key := types.NamespacedName{Namespace: desired.GetNamespace(), Name: desired.GetName()}
+	kind := fmt.Sprintf("%T", desired)
+
+	err := rc.Client.Get(rc.Ctx, key, current)
+	if errors.IsNotFound(err) {
+		if err := controllerutil.SetOwnerReference(dc, desired, rc.Scheme); err != nil {
+			return err
+		}
+		rc.ReqLogger.Info("creating reaper object", "kind", kind, "name", key.Name)
+		if err := rc.Client.Create(rc.Ctx, desired); err != nil {
+			return err
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+			"Created reaper object %s", key.Name)
+		return rc.Client.Get(rc.Ctx, key, current)
+	} else if err != nil {
+		return err
+	}
+
+	owners := len(current.GetOwnerReferences())
+	if err := controllerutil.SetOwnerReference(dc, current, rc.Scheme); err != nil {
+		return err
+	}
+	// Only the first owner updates the object, so that it does not flap between the specs of the
+	// datacenters when they differ
+	authoritative := current.GetOwnerReferences()[0].UID == dc.UID
+	sameHash := utils.ResourcesHaveSameHash(current, desired)
+	if !sameHash && !authoritative {
+		rc.ReqLogger.Info("the reaper object is managed by another datacenter, ignoring the reaper spec of this one",
+			"kind", kind, "name", key.Name, "owner", current.GetOwnerReferences()[0].Name)
+	}
+	if (sameHash || !authoritative) && owners == len(current.GetOwnerReferences()) {
+		return nil
+	}
+
+	if !sameHash && authoritative {
+		current.SetLabels(desired.GetLabels())
+		current.SetAnnotations(desired.GetAnnotations())
+		switch c := current.(type) {
+		case *corev1.Service:
+			d := desired.(*corev1.Service)
+			c.Spec.Ports = d.Spec.Ports
+			c.Spec.Selector = d.Spec.Selector
+		case *appsv1.Deployment:
+			c.Spec = desired.(*appsv1.Deployment).Spec
+		}
+	}
+
+	rc.ReqLogger.Info("updating reaper object", "kind", kind, "name", key.Name)
+	return rc.Client.Update(rc.Ctx, current)
+}
+
+// removeReaper Releases the ownership of the datacenter on the Reaper objects and removes the
+// ReaperRegistered condition. The datacenter unregisters the cluster from Reaper and deletes the
+// objects when it is their last owner.
+func (rc *ReconciliationContext) removeReaper() error {
+	dc := rc.Datacenter
+
+	deployment := &appsv1.Deployment{}
+	service := &corev1.Service{}
+	uiSecret := &corev1.Secret{}
+	objects := map[string]client.Object{
+		dc.GetReaperDeploymentName(): deployment,
+		dc.GetReaperServiceName():    service,
+		dc.GetReaperUISecretName():   uiSecret,
+	}
+	for name, obj := range objects {
+		err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: name}, obj)
+		if errors.IsNotFound(err) {
+			delete(objects, name)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if _, found := objects[dc.GetReaperDeploymentName()]; found && isOnlyOwner(deployment, dc) &&
+		dc.Status.GetConditionStatus(api.DatacenterReaperRegistered) == corev1.ConditionTrue {
+		// Reaper goes away with its deployment, removing the cluster keeps its schema clean
+		reaperClient := newReaperClient(getReaperURL(dc), string(uiSecret.Data["username"]), string(uiSecret.Data["password"]))
+		if err := reaperClient.UnregisterCluster(rc.Ctx, dc.Spec.ClusterName); err != nil {
+			rc.ReqLogger.Error(err, "failed to unregister the cluster from reaper")
+		}
+	}
+
+	for name, obj := range objects {
+		if !hasOwner(obj, dc) {
+			continue
+		}
+		if isOnlyOwner(obj, dc) {
+			rc.ReqLogger.Info("deleting reaper object", "name", name)
+			if err := rc.Client.Delete(rc.Ctx, obj); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+		var ownerRefs []metav1.OwnerReference
+		for _, ref := range obj.GetOwnerReferences() {
+			if ref.UID != dc.UID {
+				ownerRefs = append(ownerRefs, ref)
+			}
+		}
+		obj.SetOwnerReferences(ownerRefs)
+		if err := rc.Client.Update(rc.Ctx, obj); err != nil {
+			return err
+		}
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	dc.Status.RemoveCondition(api.DatacenterReaperRegistered)
+	return rc.Client.Status().Patch(rc.Ctx, dc, dcPatch)
+}
+
+func (rc *ReconciliationContext) setReaperRegistered(status corev1.ConditionStatus) error {
+	dcPatch := client.MergeFrom(rc.Datacenter.DeepCopy())
+	if updated := rc.setCondition(api.NewDatacenterCondition(api.DatacenterReaperRegistered, status)); updated {
+		if err := rc.Client.Status().Patch(rc.Ctx, rc.Datacenter, dcPatch); err != nil {
+			rc.ReqLogger.Error(err, "error patching datacenter status for reaper registration")
+			return err
+		}
+	}
+	return nil
+}
+
+func hasOwner(obj client.Object, dc *api.CassandraDatacenter) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == dc.UID {
+			return true
+		}
+	}
+	return false
+}
+
+func isOnlyOwner(obj client.Object, dc *api.CassandraDatacenter) bool {
+	return len(obj.GetOwnerReferences()) == 1 && hasOwner(obj, dc)
+}
+
+func getReaperURL(dc *api.CassandraDatacenter) string {
+	return fmt.Sprintf("http://%s.%s.svc:%d", dc.GetReaperServiceName(), dc.Namespace, reaperPort)
+}
+
+func getReaperLabels(dc *api.CassandraDatacenter) map[string]string {
+	labels := dc.GetClusterLabels()
+	labels[oplabels.NameLabel] = reaperNameLabelValue
+	return labels
+}
+
+func newReaperServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev1.Service {
+	labels := dc.GetClusterLabels()
+	oplabels.AddOperatorLabels(labels, dc)
+	labels[oplabels.NameLabel] = reaperNameLabelValue
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dc.GetReaperServiceName(),
+			Namespace: dc.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: getReaperLabels(dc),
+			Ports: []corev1.ServicePort{{
+				Name:       "app",
+				Port:       reaperPort,
+				TargetPort: intstr.FromString("app"),
+			}},
+		},
+	}
+
+	utils.AddHashAnnotation(service)
+	return service
+}
+
+func newReaperDeploymentForCassandraDatacenter(dc *api.CassandraDatacenter) *appsv1.Deployment {
+	labels := dc.GetClusterLabels()
+	oplabels.AddOperatorLabels(labels, dc)
+	labels[oplabels.NameLabel] = reaperNameLabelValue
+
+	image := dc.Spec.Reaper.Image
+	if image == "" {
+		image = images.GetReaperImage()
+	}
+
+	secretEnvVar := func(name, secretName, key string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+					Key:                  key,
+				},
+			},
+		}
+	}
+	superuserSecret := dc.GetSuperuserSecretNamespacedName().Name
+	uiSecret := dc.GetReaperUISecretName()
+
+	var replicas int32 = 1
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dc.GetReaperDeploymentName(),
+			Namespace: dc.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: getReaperLabels(dc)},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: utils.MergeMap(map[string]string{}, labels)},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:      "reaper",
+						Image:     image,
+						Resources: dc.Spec.Reaper.Resources,
+						Ports: []corev1.ContainerPort{
+							{Name: "app", ContainerPort: reaperPort},
+							{Name: "admin", ContainerPort: reaperAdminPort},
+						},
+						Env: []corev1.EnvVar{
+							{Name: "REAPER_STORAGE_TYPE", Value: "cassandra"},
+							{Name: "REAPER_AUTH_ENABLED", Value: "true"},
+							secretEnvVar("REAPER_AUTH_USER", uiSecret, "username"),
+							secretEnvVar("REAPER_AUTH_PASSWORD", uiSecret, "password"),
+							{Name: "REAPER_CASS_CLUSTER_NAME", Value: dc.Spec.ClusterName},
+							{Name: "REAPER_CASS_CONTACT_POINTS", Value: fmt.Sprintf(`[{"host": "%s", "port": 9042}]`, dc.GetSeedServiceName())},
+							{Name: "REAPER_CASS_KEYSPACE", Value: dc.GetReaperKeyspace()},
+							{Name: "REAPER_CASS_AUTH_ENABLED", Value: "true"},
+							secretEnvVar("REAPER_CASS_AUTH_USERNAME", superuserSecret, "username"),
+							secretEnvVar("REAPER_CASS_AUTH_PASSWORD", superuserSecret, "password"),
+						},
+						ReadinessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{
+								HTTPGet: &corev1.HTTPGetAction{Path: "/healthcheck", Port: intstr.FromString("admin")},
+							},
+							InitialDelaySeconds: 30,
+							PeriodSeconds:       15,
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	utils.AddHashAnnotation(deployment)
+	return deployment
+}
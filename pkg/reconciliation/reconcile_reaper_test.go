@@ -0,0 +1,211 @@
+package reconciliation
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/pkg/mocks"
+	"github.com/k8ssandra/cass-operator/pkg/reaper"
+)
+
+type fakeReaperClient struct {
+	registered   []string
+	unregistered []string
+	credentials  string
+}
+
+func (c *fakeReaperClient) RegisterCluster(ctx context.Context, clusterName, seedHost string, jmxPort int) error {
+	c.registered = append(c.registered, clusterName+"@"+seedHost)
+	return nil
+}
+
+func (c *fakeReaperClient) UnregisterCluster(ctx context.Context, clusterName string) error {
+	c.unregistered = append(c.unregistered, clusterName)
+	return nil
+}
+
+func setupReaperTest(t *testing.T) (*ReconciliationContext, *fakeReaperClient, *[]*http.Request) {
+	rc, _, cleanupMockScr := setupTest()
+	t.Cleanup(cleanupMockScr)
+
+	reaperClient := &fakeReaperClient{}
+	oldNewReaperClient := newReaperClient
+	newReaperClient = func(baseURL, username, password string) reaper.Client {
+		reaperClient.credentials = username + ":" + password
+		return reaperClient
+	}
+	t.Cleanup(func() { newReaperClient = oldNewReaperClient })
+
+	rc.Datacenter.UID = "dc-uid"
+	rc.Datacenter.Spec.Reaper = &api.ReaperSpec{}
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(rc.Datacenter).Build()
+
+	rc.dcPods = []*corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: rc.Datacenter.Namespace},
+		Status: corev1.PodStatus{
+			PodIP: "192.168.101.11",
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "cassandra",
+				State: corev1.ContainerState{
+					Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(time.Now().Add(-time.Minute))},
+				},
+			}},
+		},
+	}}
+
+	var requests []*http.Request
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do", mock.Anything).Return(func(req *http.Request) *http.Response {
+		requests = append(requests, req)
+		body := "OK"
+		if req.Method == http.MethodGet {
+			body = "[]"
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}
+	}, nil)
+	rc.NodeMgmtClient = &httphelper.NodeMgmtClient{Client: mockHttpClient, Log: rc.ReqLogger, Protocol: "http"}
+
+	return rc, reaperClient, &requests
+}
+
+func TestCheckReaper(t *testing.T) {
+	rc, reaperClient, requests := setupReaperTest(t)
+	dc := rc.Datacenter
+
+	res := rc.CheckReaper()
+	assert.True(t, res.Completed(), "should wait for reaper to be available")
+
+	require.Len(t, *requests, 2)
+	assert.Equal(t, "/api/v0/ops/keyspace", (*requests)[0].URL.Path)
+	assert.Equal(t, "keyspaceName=reaper_db", (*requests)[0].URL.RawQuery)
+	assert.Equal(t, "/api/v0/ops/keyspace/create", (*requests)[1].URL.Path)
+
+	service := &corev1.Service{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetReaperServiceName()}, service))
+	assert.Equal(t, int32(8080), service.Spec.Ports[0].Port)
+	assert.Equal(t, "reaper", service.Spec.Selector["app.kubernetes.io/name"])
+
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetReaperDeploymentName()}, deployment))
+	require.Len(t, deployment.OwnerReferences, 1)
+	assert.Equal(t, dc.UID, deployment.OwnerReferences[0].UID)
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assert.True(t, strings.HasPrefix(container.Image, "thelastpickle/cassandra-reaper:"))
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "REAPER_CASS_KEYSPACE", Value: "reaper_db"})
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "REAPER_AUTH_ENABLED", Value: "true"})
+
+	// The credentials of the UI and REST API are generated
+	uiSecret := &corev1.Secret{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetReaperUISecretName()}, uiSecret))
+	assert.Equal(t, "reaper", string(uiSecret.Data["username"]))
+	assert.NotEmpty(t, uiSecret.Data["password"])
+	for _, env := range container.Env {
+		if env.Name == "REAPER_AUTH_PASSWORD" {
+			assert.Equal(t, dc.GetReaperUISecretName(), env.ValueFrom.SecretKeyRef.Name)
+		}
+	}
+	assert.Equal(t, corev1.ConditionFalse, dc.GetConditionStatus(api.DatacenterReaperRegistered))
+	assert.Empty(t, reaperClient.registered)
+
+	deployment.Status.AvailableReplicas = 1
+	require.NoError(t, rc.Client.Status().Update(rc.Ctx, deployment))
+
+	res = rc.CheckReaper()
+	assert.False(t, res.Completed())
+	assert.Equal(t, []string{dc.Spec.ClusterName + "@" + dc.GetSeedServiceName()}, reaperClient.registered)
+	assert.Equal(t, "reaper:"+string(uiSecret.Data["password"]), reaperClient.credentials)
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterReaperRegistered))
+
+	// Registered clusters are not registered again
+	res = rc.CheckReaper()
+	assert.False(t, res.Completed())
+	assert.Len(t, reaperClient.registered, 1)
+
+	// The datacenter is the last owner of reaper
+	dc.Spec.Reaper = nil
+	res = rc.CheckReaper()
+	assert.False(t, res.Completed())
+	assert.Equal(t, []string{dc.Spec.ClusterName}, reaperClient.unregistered)
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetReaperDeploymentName()}, deployment)
+	assert.True(t, errors.IsNotFound(err))
+	err = rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetReaperServiceName()}, service)
+	assert.True(t, errors.IsNotFound(err))
+	err = rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetReaperUISecretName()}, uiSecret)
+	assert.True(t, errors.IsNotFound(err))
+	_, found := dc.GetCondition(api.DatacenterReaperRegistered)
+	assert.False(t, found)
+}
+
+func TestCheckReaper_SharedWithOtherDatacenter(t *testing.T) {
+	rc, reaperClient, _ := setupReaperTest(t)
+	dc := rc.Datacenter
+
+	res := rc.CheckReaper()
+	assert.True(t, res.Completed())
+
+	// Another datacenter of the cluster enables reaper
+	otherOwner := metav1.OwnerReference{APIVersion: api.GroupVersion.String(), Kind: "CassandraDatacenter", Name: "dc2", UID: "dc2-uid"}
+	deployment := &appsv1.Deployment{}
+	deploymentKey := types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetReaperDeploymentName()}
+	require.NoError(t, rc.Client.Get(rc.Ctx, deploymentKey, deployment))
+	deployment.OwnerReferences = append(deployment.OwnerReferences, otherOwner)
+	require.NoError(t, rc.Client.Update(rc.Ctx, deployment))
+
+	dc.Spec.Reaper = nil
+	res = rc.CheckReaper()
+	assert.False(t, res.Completed())
+	assert.Empty(t, reaperClient.unregistered)
+
+	require.NoError(t, rc.Client.Get(rc.Ctx, deploymentKey, deployment))
+	assert.Equal(t, []metav1.OwnerReference{otherOwner}, deployment.OwnerReferences)
+	// The service is only owned by the datacenter
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetReaperServiceName()}, &corev1.Service{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestCheckReaper_FirstOwnerManagesDeployment(t *testing.T) {
+	rc, _, _ := setupReaperTest(t)
+	dc := rc.Datacenter
+
+	res := rc.CheckReaper()
+	assert.True(t, res.Completed())
+
+	// Another datacenter of the cluster created the deployment first
+	otherOwner := metav1.OwnerReference{APIVersion: api.GroupVersion.String(), Kind: "CassandraDatacenter", Name: "dc2", UID: "dc2-uid"}
+	deployment := &appsv1.Deployment{}
+	deploymentKey := types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetReaperDeploymentName()}
+	require.NoError(t, rc.Client.Get(rc.Ctx, deploymentKey, deployment))
+	deployment.OwnerReferences = append([]metav1.OwnerReference{otherOwner}, deployment.OwnerReferences...)
+	require.NoError(t, rc.Client.Update(rc.Ctx, deployment))
+	image := deployment.Spec.Template.Spec.Containers[0].Image
+
+	// The reaper spec of this datacenter is ignored
+	dc.Spec.Reaper.Image = "thelastpickle/cassandra-reaper:custom"
+	rc.CheckReaper()
+	require.NoError(t, rc.Client.Get(rc.Ctx, deploymentKey, deployment))
+	assert.Equal(t, image, deployment.Spec.Template.Spec.Containers[0].Image)
+	assert.Len(t, deployment.OwnerReferences, 2)
+
+	// until it is the first owner
+	deployment.OwnerReferences = deployment.OwnerReferences[1:]
+	require.NoError(t, rc.Client.Update(rc.Ctx, deployment))
+	rc.CheckReaper()
+	require.NoError(t, rc.Client.Get(rc.Ctx, deploymentKey, deployment))
+	assert.Equal(t, "thelastpickle/cassandra-reaper:custom", deployment.Spec.Template.Spec.Containers[0].Image)
+}
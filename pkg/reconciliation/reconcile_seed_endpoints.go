@@ -0,0 +1,253 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/oplabels"
+)
+
+// isSeedPod Returns whether the pod is a seed of the cluster, either listed in the Endpoints of the seed
+// service when the operator manages them, or labeled as a seed
+func (rc *ReconciliationContext) isSeedPod(pod *corev1.Pod) bool {
+	if rc.Datacenter.Spec.ManagedSeedEndpoints {
+		if rc.seedEndpointPods == nil {
+			rc.loadSeedEndpointPods()
+		}
+		return rc.seedEndpointPods[pod.Name]
+	}
+	return pod.Labels[api.SeedNodeLabel] == "true"
+}
+
+// loadSeedEndpointPods reads the pods of the Endpoints of the seed service, for the reconcile passes
+// which did not update them yet. They are considered empty if the Endpoints cannot be read.
+func (rc *ReconciliationContext) loadSeedEndpointPods() {
+	rc.seedEndpointPods = map[string]bool{}
+	current, exists, err := rc.getSeedEndpoints()
+	if err != nil {
+		rc.ReqLogger.Error(err, "Unable to get endpoints for seed service")
+		return
+	}
+	if !exists {
+		return
+	}
+	for _, address := range getEndpointsAddresses(current) {
+		if address.TargetRef != nil {
+			rc.seedEndpointPods[address.TargetRef.Name] = true
+		}
+	}
+}
+
+// getRackSeedPods Returns the ready pods of the rack, by name, up to its number of seeds. These are the
+// pods labelSeedPods labels as seeds.
+func (rc *ReconciliationContext) getRackSeedPods(rackInfo *RackInformation) []*corev1.Pod {
+	rackPods := FilterPodListByLabels(rc.dcPods, rc.Datacenter.GetRackLabels(rackInfo.RackName))
+	sort.SliceStable(rackPods, func(i, j int) bool {
+		return rackPods[i].Name < rackPods[j].Name
+	})
+	var seeds []*corev1.Pod
+	for _, pod := range rackPods {
+		if len(seeds) >= rackInfo.SeedCount {
+			break
+		}
+		if isServerReady(pod) {
+			seeds = append(seeds, pod)
+		}
+	}
+	return seeds
+}
+
+// checkSeedEndpoints sets the seeds of the datacenter in the Endpoints of the seed service, instead of
+// labeling the seed pods. The seeds that are still starting are kept, as labelSeedPods keeps their label,
+// and so are the seeds of the other datacenters of the cluster, which share the service.
+// Returns the number of ready seeds.
+func (rc *ReconciliationContext) checkSeedEndpoints() (int, error) {
+	dcPods := map[string]*corev1.Pod{}
+	for _, pod := range rc.dcPods {
+		dcPods[pod.Name] = pod
+	}
+
+	current, exists, err := rc.getSeedEndpoints()
+	if err != nil {
+		return 0, err
+	}
+
+	seeds := map[string]*corev1.Pod{}
+	for _, address := range getEndpointsAddresses(current) {
+		if address.TargetRef == nil {
+			continue
+		}
+		if pod, found := dcPods[address.TargetRef.Name]; found && isServerStarting(pod) {
+			seeds[pod.Name] = pod
+		}
+	}
+
+	count := 0
+	for _, rackInfo := range rc.desiredRackInformation {
+		for _, pod := range rc.getRackSeedPods(rackInfo) {
+			seeds[pod.Name] = pod
+			count++
+		}
+	}
+
+	return count, rc.applySeedEndpoints(current, exists, seeds)
+}
+
+// addSeedEndpoint adds the pod to the seeds of the Endpoints of the seed service. This is the analog of
+// labeling the first node of the cluster as a seed before it starts.
+func (rc *ReconciliationContext) addSeedEndpoint(pod *corev1.Pod) error {
+	current, exists, err := rc.getSeedEndpoints()
+	if err != nil {
+		return err
+	}
+
+	seeds := map[string]*corev1.Pod{pod.Name: pod}
+	dcPods := map[string]*corev1.Pod{}
+	for _, dcPod := range rc.dcPods {
+		dcPods[dcPod.Name] = dcPod
+	}
+	for _, address := range getEndpointsAddresses(current) {
+		if address.TargetRef == nil {
+			continue
+		}
+		if dcPod, found := dcPods[address.TargetRef.Name]; found {
+			seeds[dcPod.Name] = dcPod
+		}
+	}
+
+	return rc.applySeedEndpoints(current, exists, seeds)
+}
+
+// applySeedEndpoints sets the addresses of the seeds of the datacenter in the Endpoints of the seed service,
+// along with the addresses of the pods of the other datacenters of the cluster
+func (rc *ReconciliationContext) applySeedEndpoints(current *corev1.Endpoints, exists bool, seeds map[string]*corev1.Pod) error {
+	dc := rc.Datacenter
+	dcLabel := dc.GetDatacenterLabels()[api.DatacenterLabel]
+	otherPods := map[string]bool{}
+	for _, pod := range rc.clusterPods {
+		if pod.Labels[api.DatacenterLabel] != dcLabel {
+			otherPods[pod.Name] = true
+		}
+	}
+
+	var addresses []corev1.EndpointAddress
+	for _, address := range getEndpointsAddresses(current) {
+		if address.TargetRef != nil && otherPods[address.TargetRef.Name] {
+			addresses = append(addresses, address)
+		}
+	}
+	for _, pod := range seeds {
+		if pod.Status.PodIP == "" {
+			continue
+		}
+		addresses = append(addresses, corev1.EndpointAddress{
+			IP:       pod.Status.PodIP,
+			NodeName: &pod.Spec.NodeName,
+			TargetRef: &corev1.ObjectReference{
+				Kind:      "Pod",
+				Namespace: pod.Namespace,
+				Name:      pod.Name,
+				UID:       pod.UID,
+			},
+		})
+	}
+	sort.SliceStable(addresses, func(i, j int) bool {
+		return addresses[i].TargetRef.Name < addresses[j].TargetRef.Name
+	})
+
+	rc.seedEndpointPods = map[string]bool{}
+	for _, address := range addresses {
+		rc.seedEndpointPods[address.TargetRef.Name] = true
+	}
+
+	if exists && seedEndpointsEqual(getEndpointsAddresses(current), addresses) {
+		return nil
+	}
+
+	labels := dc.GetClusterLabels()
+	oplabels.AddOperatorLabels(labels, dc)
+	for k, v := range labels {
+		if current.Labels == nil {
+			current.Labels = map[string]string{}
+		}
+		current.Labels[k] = v
+	}
+	current.Subsets = nil
+	if len(addresses) > 0 {
+		current.Subsets = []corev1.EndpointSubset{{Addresses: addresses}}
+	}
+
+	names := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		names = append(names, address.TargetRef.Name)
+	}
+
+	if exists {
+		if err := rc.Client.Update(rc.Ctx, current); err != nil {
+			rc.ReqLogger.Error(err, "Unable to update endpoints for seed service")
+			return err
+		}
+	} else {
+		if err := setControllerReference(dc, current, rc.Scheme); err != nil {
+			return err
+		}
+		if err := rc.Client.Create(rc.Ctx, current); err != nil {
+			rc.ReqLogger.Error(err, "Unable to create endpoints for seed service")
+			return err
+		}
+	}
+
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.UpdatedSeedEndpoints,
+		"Updated the seeds of endpoints %s to %s", current.Name, strings.Join(names, ", "))
+	return nil
+}
+
+// getSeedEndpoints Returns the Endpoints of the seed service, or a new one if it does not exist yet
+func (rc *ReconciliationContext) getSeedEndpoints() (*corev1.Endpoints, bool, error) {
+	dc := rc.Datacenter
+	endpoints := &corev1.Endpoints{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetSeedServiceName()}, endpoints)
+	if err == nil {
+		return endpoints, true, nil
+	}
+	if !errors.IsNotFound(err) {
+		return nil, false, err
+	}
+	endpoints.Name = dc.GetSeedServiceName()
+	endpoints.Namespace = dc.Namespace
+	return endpoints, false, nil
+}
+
+func getEndpointsAddresses(endpoints *corev1.Endpoints) []corev1.EndpointAddress {
+	var addresses []corev1.EndpointAddress
+	for _, subset := range endpoints.Subsets {
+		addresses = append(addresses, subset.Addresses...)
+		addresses = append(addresses, subset.NotReadyAddresses...)
+	}
+	return addresses
+}
+
+func seedEndpointsEqual(current []corev1.EndpointAddress, desired []corev1.EndpointAddress) bool {
+	if len(current) != len(desired) {
+		return false
+	}
+	desiredIPs := map[string]string{}
+	for _, address := range desired {
+		desiredIPs[address.TargetRef.Name] = address.IP
+	}
+	for _, address := range current {
+		if address.TargetRef == nil || desiredIPs[address.TargetRef.Name] != address.IP {
+			return false
+		}
+	}
+	return true
+}
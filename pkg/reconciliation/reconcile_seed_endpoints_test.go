@@ -0,0 +1,132 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+)
+
+func TestCheckSeedEndpoints(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.ManagedSeedEndpoints = true
+	rackInfo := &RackInformation{RackName: "default", NodeCount: 3, SeedCount: 2}
+	rc.desiredRackInformation = []*RackInformation{rackInfo}
+
+	rc.dcPods = nil
+	for i, ready := range []bool{false, true, true} {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", i),
+				Namespace: dc.Namespace,
+				Labels:    dc.GetRackLabels(rackInfo.RackName),
+			},
+			Status: corev1.PodStatus{
+				PodIP:             fmt.Sprintf("10.0.0.%d", i),
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: ready}},
+			},
+		}
+		rc.dcPods = append(rc.dcPods, pod)
+	}
+	// A seed of another datacenter of the cluster
+	otherPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "other-pod-0",
+			Namespace: dc.Namespace,
+			Labels:    map[string]string{api.ClusterLabel: dc.Spec.ClusterName, api.DatacenterLabel: "dc2"},
+		},
+	}
+	rc.clusterPods = append([]*corev1.Pod{otherPod}, rc.dcPods...)
+
+	current := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: dc.GetSeedServiceName(), Namespace: dc.Namespace},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{
+				{IP: "10.1.0.0", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: otherPod.Name}},
+				{IP: "10.0.0.0", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "pod-0"}},
+				{IP: "10.0.0.9", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "pod-9"}},
+			},
+		}},
+	}
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(current).Build()
+
+	count, err := rc.checkSeedEndpoints()
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	endpoints := &corev1.Endpoints{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetSeedServiceName()}, endpoints))
+	var names []string
+	for _, address := range getEndpointsAddresses(endpoints) {
+		names = append(names, address.TargetRef.Name)
+	}
+	// The seed that is not ready is replaced, the one of the other datacenter is kept
+	assert.Equal(t, []string{"other-pod-0", "pod-1", "pod-2"}, names)
+	assert.False(t, rc.isSeedPod(rc.dcPods[0]))
+	assert.True(t, rc.isSeedPod(rc.dcPods[1]))
+	assert.True(t, rc.isSeedPod(otherPod))
+	for _, pod := range rc.dcPods {
+		assert.NotContains(t, pod.Labels, api.SeedNodeLabel)
+	}
+
+	// The first node of the cluster is a seed before it starts
+	rc.dcPods[0].Labels[api.CassNodeState] = stateStarting
+	require.NoError(t, rc.addSeedEndpoint(rc.dcPods[0]))
+	count, err = rc.checkSeedEndpoints()
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.True(t, rc.isSeedPod(rc.dcPods[0]))
+}
+
+func TestUpdateSeedsStatus_managedSeedEndpoints(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.ManagedSeedEndpoints = true
+	rc.dcPods = nil
+	for i := 0; i < 2; i++ {
+		rc.dcPods = append(rc.dcPods, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), Namespace: dc.Namespace},
+			Status:     corev1.PodStatus{PodIP: fmt.Sprintf("10.0.0.%d", i)},
+		})
+	}
+	current := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: dc.GetSeedServiceName(), Namespace: dc.Namespace},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{
+				{IP: "10.0.0.1", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "pod-1"}},
+			},
+		}},
+	}
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(current).Build()
+
+	// The seeds are read from the Endpoints when the pass did not update them
+	dc.Status.Seeds = []string{"10.0.0.1"}
+	assert.False(t, rc.updateSeedsStatus())
+	assert.Equal(t, []string{"10.0.0.1"}, dc.Status.Seeds)
+}
+
+func TestNewSeedServiceForCassandraDatacenter_managedSeedEndpoints(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "test"},
+		Spec:       api.CassandraDatacenterSpec{ClusterName: "cluster1"},
+	}
+	assert.NotEmpty(t, newSeedServiceForCassandraDatacenter(dc).Spec.Selector)
+
+	dc.Spec.ManagedSeedEndpoints = true
+	assert.Nil(t, newSeedServiceForCassandraDatacenter(dc).Spec.Selector)
+}
@@ -0,0 +1,126 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+)
+
+// CheckServiceAccount When CreateServiceAccount is set, creates the service account of the server
+// pods, and the role and role binding granting it read access to the pods, endpoints and services
+// of the namespace. The objects are owned by the datacenter.
+func (rc *ReconciliationContext) CheckServiceAccount() result.ReconcileResult {
+	dc := rc.Datacenter
+	if !dc.Spec.CreateServiceAccount {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_service_account::CheckServiceAccount")
+
+	for _, desired := range []client.Object{
+		newServiceAccountForCassandraDatacenter(dc),
+		newRoleForCassandraDatacenter(dc),
+		newRoleBindingForCassandraDatacenter(dc),
+	} {
+		if err := rc.reconcileServiceAccountObject(desired); err != nil {
+			rc.ReqLogger.Error(err, "failed to reconcile service account object", "name", desired.GetName())
+			return result.Error(err)
+		}
+	}
+
+	return result.Continue()
+}
+
+// reconcileServiceAccountObject Creates the object when it does not exist. Only the rules of the
+// role are updated afterwards, the service account and the role binding do not change.
+func (rc *ReconciliationContext) reconcileServiceAccountObject(desired client.Object) error {
+	dc := rc.Datacenter
+	key := types.NamespacedName{Namespace: desired.GetNamespace(), Name: desired.GetName()}
+	kind := fmt.Sprintf("%T", desired)
+
+	current := desired.DeepCopyObject().(client.Object)
+	err := rc.Client.Get(rc.Ctx, key, current)
+	if errors.IsNotFound(err) {
+		if err := rc.SetDatacenterAsOwner(desired); err != nil {
+			return err
+		}
+		rc.ReqLogger.Info("creating service account object", "kind", kind, "name", key.Name)
+		if err := rc.Client.Create(rc.Ctx, desired); err != nil {
+			return err
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+			"Created %s %s", kind, key.Name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	role, isRole := current.(*rbacv1.Role)
+	if !isRole || utils.ResourcesHaveSameHash(current, desired) {
+		return nil
+	}
+
+	role.Rules = desired.(*rbacv1.Role).Rules
+	role.Annotations = utils.MergeMap(map[string]string{}, role.Annotations, desired.GetAnnotations())
+	rc.ReqLogger.Info("updating service account role", "name", key.Name)
+	return rc.Client.Update(rc.Ctx, role)
+}
+
+func getServiceAccountObjectMeta(dc *api.CassandraDatacenter, name string) metav1.ObjectMeta {
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddOperatorLabels(labels, dc)
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: dc.Namespace,
+		Labels:    labels,
+	}
+}
+
+func newServiceAccountForCassandraDatacenter(dc *api.CassandraDatacenter) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{ObjectMeta: getServiceAccountObjectMeta(dc, dc.GetServiceAccountName())}
+}
+
+// newRoleForCassandraDatacenter The role only reads the objects the nodes may look up to discover
+// their peers
+func newRoleForCassandraDatacenter(dc *api.CassandraDatacenter) *rbacv1.Role {
+	role := &rbacv1.Role{
+		ObjectMeta: getServiceAccountObjectMeta(dc, dc.GetServiceAccountRoleName()),
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{""},
+			Resources: []string{"pods", "endpoints", "services"},
+			Verbs:     []string{"get", "list", "watch"},
+		}},
+	}
+	utils.AddHashAnnotation(role)
+	return role
+}
+
+func newRoleBindingForCassandraDatacenter(dc *api.CassandraDatacenter) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: getServiceAccountObjectMeta(dc, dc.GetServiceAccountRoleName()),
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     dc.GetServiceAccountRoleName(),
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      dc.GetServiceAccountName(),
+			Namespace: dc.Namespace,
+		}},
+	}
+}
@@ -0,0 +1,79 @@
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckServiceAccount(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.CreateServiceAccount = true
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(dc).Build()
+
+	result := rc.CheckServiceAccount()
+	assert.False(t, result.Completed())
+
+	name := "cassandradatacenter-example-cluster-cassandradatacenter-example-service-account"
+	assert.Equal(t, name, dc.GetServiceAccountName())
+	key := types.NamespacedName{Namespace: dc.Namespace, Name: name}
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, &corev1.ServiceAccount{}))
+
+	role := &rbacv1.Role{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, role))
+	require.Len(t, role.Rules, 1)
+	assert.Equal(t, []string{"get", "list", "watch"}, role.Rules[0].Verbs)
+
+	roleBinding := &rbacv1.RoleBinding{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, roleBinding))
+	assert.Equal(t, name, roleBinding.RoleRef.Name)
+	assert.Equal(t, []rbacv1.Subject{{Kind: "ServiceAccount", Name: name, Namespace: dc.Namespace}}, roleBinding.Subjects)
+
+	// The role is updated when its rules change
+	role.Rules = nil
+	role.Annotations = nil
+	require.NoError(t, rc.Client.Update(rc.Ctx, role))
+	result = rc.CheckServiceAccount()
+	assert.False(t, result.Completed())
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, role))
+	assert.Len(t, role.Rules, 1)
+
+	// The objects are up to date
+	resourceVersion := role.ResourceVersion
+	result = rc.CheckServiceAccount()
+	assert.False(t, result.Completed())
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, role))
+	assert.Equal(t, resourceVersion, role.ResourceVersion)
+}
+
+func TestCheckServiceAccount_shared(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.CreateServiceAccount = true
+	dc.Spec.ServiceAccount = "shared"
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(dc).Build()
+
+	result := rc.CheckServiceAccount()
+	assert.False(t, result.Completed())
+
+	require.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: "shared"}, &corev1.ServiceAccount{}))
+
+	// The role and role binding are named after the datacenter
+	key := types.NamespacedName{Namespace: dc.Namespace, Name: "cassandradatacenter-example-cluster-cassandradatacenter-example-service-account"}
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, &rbacv1.Role{}))
+
+	roleBinding := &rbacv1.RoleBinding{}
+	require.NoError(t, rc.Client.Get(rc.Ctx, key, roleBinding))
+	assert.Equal(t, key.Name, roleBinding.RoleRef.Name)
+	assert.Equal(t, []rbacv1.Subject{{Kind: "ServiceAccount", Name: "shared", Namespace: dc.Namespace}}, roleBinding.Subjects)
+}
@@ -4,11 +4,14 @@
 package reconciliation
 
 import (
+	"reflect"
+
 	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
 	"github.com/k8ssandra/cass-operator/pkg/internal/result"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/k8ssandra/cass-operator/pkg/utils"
 )
@@ -67,6 +70,10 @@ func (rc *ReconciliationContext) CheckHeadlessServices() result.ReconcileResult
 		services = append(services, nodePortService)
 	}
 
+	if dc.IsSeedExportLoadBalancerEnabled() {
+		services = append(services, newSeedExportServiceForCassandraDatacenter(dc))
+	}
+
 	createNeeded := []*corev1.Service{}
 
 	for idx := range services {
@@ -129,3 +136,57 @@ func (rc *ReconciliationContext) CheckHeadlessServices() result.ReconcileResult
 
 	return result.Continue()
 }
+
+// CheckSeedExport Publishes the addresses of the seeds exported to the other Kubernetes clusters in the
+// status, the ones of the LoadBalancer service once assigned, or the static ones. The LoadBalancer service
+// is deleted when the seeds are no longer exported through it.
+func (rc *ReconciliationContext) CheckSeedExport() result.ReconcileResult {
+	dc := rc.Datacenter
+
+	service := &corev1.Service{}
+	key := types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetSeedExportServiceName()}
+	err := rc.Client.Get(rc.Ctx, key, service)
+	if err != nil && !errors.IsNotFound(err) {
+		return result.Error(err)
+	}
+	exists := err == nil
+
+	var exportedSeeds []string
+	if dc.IsSeedExportLoadBalancerEnabled() {
+		if exists {
+			for _, ingress := range service.Status.LoadBalancer.Ingress {
+				if ingress.IP != "" {
+					exportedSeeds = append(exportedSeeds, ingress.IP)
+				} else if ingress.Hostname != "" {
+					exportedSeeds = append(exportedSeeds, ingress.Hostname)
+				}
+			}
+		}
+		if len(exportedSeeds) == 0 {
+			rc.ReqLogger.Info("waiting for the address of the seed export service", "service", key.Name)
+		}
+	} else {
+		if exists {
+			rc.ReqLogger.Info("deleting seed export service", "service", key.Name)
+			if err := rc.Client.Delete(rc.Ctx, service); err != nil && !errors.IsNotFound(err) {
+				return result.Error(err)
+			}
+		}
+		if networking := dc.Spec.Networking; networking != nil && networking.SeedExport != nil {
+			exportedSeeds = networking.SeedExport.Addresses
+		}
+	}
+
+	if reflect.DeepEqual(exportedSeeds, dc.Status.ExportedSeeds) {
+		return result.Continue()
+	}
+
+	patch := client.MergeFrom(dc.DeepCopy())
+	dc.Status.ExportedSeeds = exportedSeeds
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, patch); err != nil {
+		rc.ReqLogger.Error(err, "error updating the exported seeds")
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
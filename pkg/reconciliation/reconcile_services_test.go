@@ -10,9 +10,13 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
 	"github.com/k8ssandra/cass-operator/pkg/mocks"
 	"github.com/k8ssandra/cass-operator/pkg/utils"
 )
@@ -189,3 +193,39 @@ func TestCreateHeadlessService_ClientReturnsError(t *testing.T) {
 
 	mockClient.AssertExpectations(t)
 }
+
+func TestCheckSeedExport(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Networking = &api.NetworkingConfig{
+		SeedExport: &api.SeedExportConfig{Type: api.SeedExportLoadBalancer},
+	}
+	service := newSeedExportServiceForCassandraDatacenter(dc)
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(dc, service).Build()
+
+	// The load balancer has no address yet
+	recResult := rc.CheckSeedExport()
+	assert.False(t, recResult.Completed())
+	assert.Empty(t, dc.Status.ExportedSeeds)
+
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}}
+	require.NoError(t, rc.Client.Status().Update(rc.Ctx, service))
+	recResult = rc.CheckSeedExport()
+	assert.False(t, recResult.Completed())
+	assert.Equal(t, []string{"203.0.113.10"}, dc.Status.ExportedSeeds)
+
+	// Static addresses replace the load balancer
+	dc.Spec.Networking.SeedExport = &api.SeedExportConfig{Type: api.SeedExportStatic, Addresses: []string{"198.51.100.1", "198.51.100.2"}}
+	recResult = rc.CheckSeedExport()
+	assert.False(t, recResult.Completed())
+	assert.Equal(t, []string{"198.51.100.1", "198.51.100.2"}, dc.Status.ExportedSeeds)
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: dc.GetSeedExportServiceName()}, &corev1.Service{})
+	assert.True(t, errors.IsNotFound(err))
+
+	dc.Spec.Networking.SeedExport = nil
+	recResult = rc.CheckSeedExport()
+	assert.False(t, recResult.Completed())
+	assert.Empty(t, dc.Status.ExportedSeeds)
+}
@@ -0,0 +1,230 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/utils"
+)
+
+// CheckVolumeClaimStorageClass recreates the StatefulSets whose server data volumeClaimTemplate is not in
+// the storageClassName of the Datacenter spec. Like in CheckVolumeClaimSizes, the StatefulSet is deleted
+// without its pods and recreated by CheckRackCreation with the new template, which is then used by the
+// pods replaced in CheckStorageMigration.
+func (rc *ReconciliationContext) CheckVolumeClaimStorageClass() result.ReconcileResult {
+	storageClassName := rc.getMigrationStorageClassName()
+	if storageClassName == "" {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_storage_migration::CheckVolumeClaimStorageClass")
+
+	for idx := range rc.desiredRackInformation {
+		statefulSet := rc.statefulSets[idx]
+		if statefulSet == nil || getVolumeClaimTemplateStorageClassName(statefulSet, PvcName) == storageClassName {
+			continue
+		}
+
+		rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.MigratingStorage,
+			"Recreating StatefulSet of rack %s with StorageClass %s", rc.desiredRackInformation[idx].RackName, storageClassName)
+		if err := rc.deleteStatefulSet(statefulSet); err != nil {
+			return result.Error(err)
+		}
+
+		return result.Done()
+	}
+
+	return result.Continue()
+}
+
+// CheckStorageMigration migrates the server data volumes of the nodes to the storageClassName of the
+// Datacenter spec once it was changed. Once all the pods are ready, the node of one pod whose PVC is in
+// another StorageClass is replaced, deleting its PVC and pod, so that the new pod gets a PVC of the new
+// StorageClass and streams the data from the other replicas. The stage of each node is reported in
+// status.storageMigration and the MigratingStorage condition is set until all the nodes were migrated.
+// Replacing a node loses the data it alone holds, so while keyspaces have a single replica in the
+// datacenter, as read through the management API, no node is replaced and the StorageMigrationBlocked
+// condition is set instead.
+func (rc *ReconciliationContext) CheckStorageMigration() result.ReconcileResult {
+	dc := rc.Datacenter
+	storageClassName := rc.getMigrationStorageClassName()
+	if storageClassName == "" {
+		return result.Continue()
+	}
+
+	logger := rc.ReqLogger
+	logger.Info("reconcile_storage_migration::CheckStorageMigration")
+
+	stages := map[string]api.StorageMigrationStage{}
+	var pendingPods []string
+	for _, pod := range rc.dcPods {
+		stage, err := rc.getStorageMigrationStage(pod, storageClassName)
+		if err != nil {
+			return result.Error(err)
+		}
+		stages[pod.Name] = stage
+		if stage == api.StorageMigrationPending {
+			pendingPods = append(pendingPods, pod.Name)
+		}
+	}
+	sort.Strings(pendingPods)
+
+	migrating := false
+	for _, stage := range stages {
+		migrating = migrating || stage != api.StorageMigrationMigrated
+	}
+
+	migration := dc.Status.StorageMigration
+	if (migration == nil || migration.StorageClassName != storageClassName) && len(pendingPods) == 0 {
+		// No volume was created in another StorageClass
+		return result.Continue()
+	}
+
+	// The pod replaced next is reported as migrating right away
+	canMigrate := len(pendingPods) > 0 && len(findAllPodsNotReady(rc.dcPods)) == 0 &&
+		len(dc.Spec.ReplaceNodes) == 0 && len(dc.Status.NodeReplacements) == 0
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	updated := false
+	if canMigrate {
+		// Replacing a node streams its data from the other replicas of the datacenter
+		singleReplicaKeyspaces, err := rc.getSingleReplicaKeyspaces()
+		if err != nil {
+			logger.Error(err, "error getting the replication of the keyspaces")
+			return result.Error(err)
+		}
+		if len(singleReplicaKeyspaces) > 0 {
+			canMigrate = false
+			message := fmt.Sprintf("Not migrating the server data volumes to StorageClass %s, keyspaces %s have a single replica in the datacenter",
+				storageClassName, strings.Join(singleReplicaKeyspaces, ", "))
+			if rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterStorageMigrationBlocked,
+				corev1.ConditionTrue, "singleReplicaKeyspaces", message)) {
+				rc.Recorder.Event(dc, corev1.EventTypeWarning, events.MigratingStorage, message)
+				updated = true
+			}
+		} else {
+			stages[pendingPods[0]] = api.StorageMigrationMigrating
+		}
+	}
+	if canMigrate || len(pendingPods) == 0 {
+		if dc.GetConditionStatus(api.DatacenterStorageMigrationBlocked) == corev1.ConditionTrue {
+			updated = rc.setCondition(api.NewDatacenterCondition(api.DatacenterStorageMigrationBlocked, corev1.ConditionFalse)) || updated
+		}
+	}
+	newMigration := &api.StorageMigrationStatus{StorageClassName: storageClassName, Nodes: stages}
+	if !reflect.DeepEqual(migration, newMigration) {
+		dc.Status.StorageMigration = newMigration
+		updated = true
+	}
+	if migrating {
+		updated = rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterMigratingStorage, corev1.ConditionTrue,
+			"StorageClassChanged", fmt.Sprintf("Migrating the server data volumes to StorageClass %s", storageClassName))) || updated
+	} else {
+		updated = rc.setCondition(api.NewDatacenterCondition(api.DatacenterMigratingStorage, corev1.ConditionFalse)) || updated
+	}
+
+	if updated {
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			logger.Error(err, "error patching datacenter status for storage migration")
+			return result.Error(err)
+		}
+	}
+
+	if !canMigrate {
+		return result.Continue()
+	}
+
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.MigratingStorage,
+		"Replacing node of pod %s onto a volume of StorageClass %s", pendingPods[0], storageClassName)
+	if err := rc.StartNodeReplace(pendingPods[0]); err != nil {
+		return result.Error(err)
+	}
+
+	return result.RequeueSoon(10)
+}
+
+// getSingleReplicaKeyspaces Returns the keyspaces with at most one replica in the datacenter, whose data
+// would be lost by replacing the node holding it
+func (rc *ReconciliationContext) getSingleReplicaKeyspaces() ([]string, error) {
+	var pod *corev1.Pod
+	for _, dcPod := range rc.dcPods {
+		if isServerReady(dcPod) {
+			pod = dcPod
+			break
+		}
+	}
+	if pod == nil {
+		return nil, fmt.Errorf("no ready pod to get the replication of the keyspaces")
+	}
+
+	rfs, err := rc.getDatacenterReplicationFactors(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyspaces []string
+	for keyspace, rf := range rfs {
+		// The keyspaces with no replica in the datacenter have no data on its nodes
+		if rf == 1 {
+			keyspaces = append(keyspaces, keyspace)
+		}
+	}
+	sort.Strings(keyspaces)
+	return keyspaces, nil
+}
+
+// getMigrationStorageClassName Returns the storageClassName the server data volumes must be in, or an empty
+// string when it is not set and the volumes are not migrated
+func (rc *ReconciliationContext) getMigrationStorageClassName() string {
+	claimSpec := rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec
+	if rc.Datacenter.IsEphemeralStorageEnabled() || claimSpec == nil || claimSpec.StorageClassName == nil {
+		return ""
+	}
+	return *claimSpec.StorageClassName
+}
+
+// getStorageMigrationStage Returns the migration stage of the server data volume of the pod
+func (rc *ReconciliationContext) getStorageMigrationStage(pod *corev1.Pod, storageClassName string) (api.StorageMigrationStage, error) {
+	dc := rc.Datacenter
+	if utils.IndexOfString(dc.Spec.ReplaceNodes, pod.Name) > -1 || utils.IndexOfString(dc.Status.NodeReplacements, pod.Name) > -1 {
+		return api.StorageMigrationMigrating, nil
+	}
+
+	pvc, err := rc.GetPodPVC(pod.Namespace, pod.Name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// The pod was deleted with its PVC and is being recreated
+			return api.StorageMigrationMigrating, nil
+		}
+		return "", err
+	}
+
+	if pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName == storageClassName {
+		return api.StorageMigrationMigrated, nil
+	}
+	return api.StorageMigrationPending, nil
+}
+
+// getVolumeClaimTemplateStorageClassName Returns the storageClassName of the named volumeClaimTemplate of
+// the StatefulSet, or an empty string
+func getVolumeClaimTemplateStorageClassName(sts *appsv1.StatefulSet, name string) string {
+	for _, vct := range sts.Spec.VolumeClaimTemplates {
+		if vct.Name == name && vct.Spec.StorageClassName != nil {
+			return *vct.Spec.StorageClassName
+		}
+	}
+	return ""
+}
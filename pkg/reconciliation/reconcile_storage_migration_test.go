@@ -0,0 +1,119 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+)
+
+func TestCheckVolumeClaimStorageClass(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	oldStorageClass := "old"
+	rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec = &corev1.PersistentVolumeClaimSpec{
+		StorageClassName: &oldStorageClass,
+		AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+		},
+	}
+	sts, err := newStatefulSetForCassandraDatacenter(nil, "default", rc.Datacenter, 1)
+	require.NoError(t, err)
+	require.NoError(t, rc.Client.Create(rc.Ctx, sts))
+	rc.statefulSets = []*appsv1.StatefulSet{sts}
+	rc.desiredRackInformation = []*RackInformation{{RackName: "default", NodeCount: 1}}
+
+	recResult := rc.CheckVolumeClaimStorageClass()
+	assert.False(t, recResult.Completed())
+
+	// The StatefulSet is recreated once the StorageClass changed
+	newStorageClass := "new"
+	rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.StorageClassName = &newStorageClass
+	recResult = rc.CheckVolumeClaimStorageClass()
+	assert.True(t, recResult.Completed())
+	err = rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: sts.Namespace, Name: sts.Name}, &appsv1.StatefulSet{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestCheckStorageMigration(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	newStorageClass := "new"
+	rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec = &corev1.PersistentVolumeClaimSpec{
+		StorageClassName: &newStorageClass,
+	}
+	require.NoError(t, rc.Client.Update(rc.Ctx, rc.Datacenter))
+
+	mgmtClient := &fakeNodeMgmtClient{replications: map[string]map[string]string{
+		"system":    {"class": "org.apache.cassandra.locale.LocalStrategy"},
+		"ks1":       {"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", rc.Datacenter.DatacenterName(): "1", "other": "3"},
+		"ks2":       {"class": "org.apache.cassandra.locator.SimpleStrategy", "replication_factor": "3"},
+		"other_dc1": {"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "other": "1"},
+	}}
+	rc.NodeMgmtClient = mgmtClient
+
+	rc.dcPods = nil
+	for _, podClass := range [][]string{{"pod-a", "old"}, {"pod-b", "new"}} {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      podClass[0],
+				Namespace: rc.Datacenter.Namespace,
+				Labels:    rc.Datacenter.GetRackLabels("default"),
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}},
+			},
+		}
+		require.NoError(t, rc.Client.Create(rc.Ctx, pod))
+		rc.dcPods = append(rc.dcPods, pod)
+
+		storageClass := podClass[1]
+		require.NoError(t, rc.Client.Create(rc.Ctx, &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: PvcName + "-" + pod.Name, Namespace: pod.Namespace},
+			Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &storageClass},
+		}))
+	}
+
+	// No node is replaced while a keyspace has a single replica in the datacenter
+	recResult := rc.CheckStorageMigration()
+	assert.False(t, recResult.Completed())
+	assert.Equal(t, corev1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterStorageMigrationBlocked))
+	condition, _ := rc.Datacenter.GetCondition(api.DatacenterStorageMigrationBlocked)
+	assert.Contains(t, condition.Message, "keyspaces ks1 have")
+	assert.Equal(t, api.StorageMigrationPending, rc.Datacenter.Status.StorageMigration.Nodes["pod-a"])
+	assert.Empty(t, rc.Datacenter.Spec.ReplaceNodes)
+
+	mgmtClient.replications["ks1"][rc.Datacenter.DatacenterName()] = "3"
+	recResult = rc.CheckStorageMigration()
+	assert.True(t, recResult.Completed())
+	assert.Equal(t, corev1.ConditionFalse, rc.Datacenter.GetConditionStatus(api.DatacenterStorageMigrationBlocked))
+	assert.Equal(t, &api.StorageMigrationStatus{
+		StorageClassName: "new",
+		Nodes: map[string]api.StorageMigrationStage{
+			"pod-a": api.StorageMigrationMigrating,
+			"pod-b": api.StorageMigrationMigrated,
+		},
+	}, rc.Datacenter.Status.StorageMigration)
+	assert.Equal(t, corev1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterMigratingStorage))
+	assert.Equal(t, []string{"pod-a"}, rc.Datacenter.Spec.ReplaceNodes)
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: rc.Datacenter.Namespace, Name: PvcName + "-pod-a"}, &corev1.PersistentVolumeClaim{})
+	assert.True(t, errors.IsNotFound(err))
+
+	// The next node waits for the replacement to complete
+	recResult = rc.CheckStorageMigration()
+	assert.False(t, recResult.Completed())
+}
@@ -0,0 +1,285 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	taskapi "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+)
+
+// systemKeyspaces are the keyspaces of the server that every datacenter of the cluster needs a replica of
+var systemKeyspaces = []string{"system_auth", "system_distributed", "system_traces"}
+
+// maxSystemKeyspaceReplicationFactor caps the replication factor of a datacenter added to the
+// system keyspaces
+const maxSystemKeyspaceReplicationFactor = 3
+
+// gossipDatacenter is the state of a datacenter of the cluster in gossip
+type gossipDatacenter struct {
+	// The number of nodes of the datacenter that did not leave the ring, whatever their state
+	nodes int
+	// Whether a node of the datacenter joined the ring
+	joined bool
+}
+
+// CheckSystemKeyspacesReplication Keeps the system keyspaces replicated to all the datacenters of the
+// cluster as seen by gossip. The datacenters that joined the ring are added, the ones whose nodes all
+// left it, or that the operator decommissioned, are removed, and the replication factor of the other
+// ones is kept. Stopped and bootstrapping datacenters keep their replicas. The keyspaces of a single
+// datacenter cluster are left as they are. A keyspace whose replication factor is raised is repaired, one
+// keyspace at a time: the replication is not changed again until the repair task completed.
+func (rc *ReconciliationContext) CheckSystemKeyspacesReplication(epData httphelper.CassMetadataEndpoints) result.ReconcileResult {
+	dc := rc.Datacenter
+	gossipDcs := getGossipDatacenterStates(epData)
+	if gossipDc, found := gossipDcs[dc.DatacenterName()]; !found || !gossipDc.joined {
+		// The endpoints are unknown, or the datacenter did not join the ring yet
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_system_keyspaces::CheckSystemKeyspacesReplication")
+
+	// The sizes of the datacenters of the operator are known before all their nodes joined
+	decommissioned := map[string]bool{}
+	if dcs, err := rc.listClusterDatacenters(); err == nil {
+		for _, other := range dcs {
+			if other.GetConditionStatus(api.DatacenterDecommission) == corev1.ConditionTrue {
+				decommissioned[other.DatacenterName()] = true
+			} else if gossipDc, found := gossipDcs[other.DatacenterName()]; found && gossipDc.nodes > 0 {
+				gossipDc.nodes = int(other.Spec.Size)
+			}
+		}
+	} else {
+		rc.ReqLogger.Error(err, "error listing the datacenters of the cluster")
+	}
+
+	var pod *corev1.Pod
+	for _, dcPod := range rc.dcPods {
+		if isServerReady(dcPod) {
+			pod = dcPod
+			break
+		}
+	}
+	if pod == nil {
+		return result.Continue()
+	}
+
+	task, err := rc.findActiveTask(taskapi.CommandRepair)
+	if err != nil {
+		return result.Error(err)
+	}
+	if task != nil {
+		if task.Status.CompletionTime == nil {
+			return result.Continue()
+		}
+		if res := rc.activeTaskCompleted(task); res.Completed() {
+			return res
+		}
+	}
+
+	for _, keyspace := range systemKeyspaces {
+		current, err := rc.NodeMgmtClient.GetKeyspaceReplication(pod, keyspace)
+		if err != nil {
+			rc.ReqLogger.Error(err, "error getting the replication of the system keyspace", "keyspace", keyspace)
+			return result.Error(err)
+		}
+
+		desired, repair := getDesiredSystemKeyspaceReplication(current, gossipDcs, decommissioned)
+		if desired == nil {
+			continue
+		}
+
+		rc.ReqLogger.Info("updating the replication of the system keyspace", "keyspace", keyspace, "replication", desired)
+		if err := rc.NodeMgmtClient.AlterKeyspace(pod, keyspace, desired); err != nil {
+			rc.ReqLogger.Error(err, "error updating the replication of the system keyspace", "keyspace", keyspace)
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.UpdatedKeyspaceReplication,
+			"Updated the replication of keyspace %s to %s", keyspace, formatReplicationSettings(desired))
+
+		if repair {
+			// The new replicas are empty until the keyspace is repaired
+			if err := rc.createTask(taskapi.CommandRepair, taskapi.JobArguments{KeyspaceName: keyspace}); err != nil {
+				return result.Error(err)
+			}
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.UpdatedKeyspaceReplication,
+				"Repairing keyspace %s after its replication factor was raised", keyspace)
+			return result.Continue()
+		}
+	}
+
+	return result.Continue()
+}
+
+// getGossipDatacenterStates Returns the datacenters in gossip, with the number of their nodes that did not
+// leave the ring, in any state: stopped and bootstrapping nodes are counted. A datacenter whose nodes
+// all left the ring is returned with no nodes.
+func getGossipDatacenterStates(epData httphelper.CassMetadataEndpoints) map[string]*gossipDatacenter {
+	dcs := map[string]*gossipDatacenter{}
+	for _, ep := range epData.Entity {
+		if ep.Datacenter == "" {
+			continue
+		}
+		gossipDc, found := dcs[ep.Datacenter]
+		if !found {
+			gossipDc = &gossipDatacenter{}
+			dcs[ep.Datacenter] = gossipDc
+		}
+		if ep.HasStatus(httphelper.StatusLeft) || ep.HasStatus(httphelper.StatusRemoved) {
+			continue
+		}
+		gossipDc.nodes++
+		if ep.HasStatus(httphelper.StatusNormal) {
+			gossipDc.joined = true
+		}
+	}
+	return dcs
+}
+
+// getDesiredSystemKeyspaceReplication Returns the replication settings of the keyspace for the datacenters
+// in the ring, or nil when the current replication already covers the same datacenters. A datacenter is
+// only removed when all its nodes left the ring or the operator decommissioned it, and only added once
+// it joined the ring. A SimpleStrategy keyspace is only converted to NetworkTopologyStrategy once the
+// cluster has several datacenters, keeping its replication factor in each of them, within their number of
+// nodes. It is raised when lower than maxSystemKeyspaceReplicationFactor, and true is then returned for
+// the keyspace to be repaired.
+func getDesiredSystemKeyspaceReplication(current map[string]string, gossipDcs map[string]*gossipDatacenter, decommissioned map[string]bool) ([]map[string]string, bool) {
+	joined := make([]string, 0, len(gossipDcs))
+	for dcName, gossipDc := range gossipDcs {
+		if gossipDc.joined && !decommissioned[dcName] {
+			joined = append(joined, dcName)
+		}
+	}
+
+	rfs := map[string]int{}
+	changed := false
+	converted := false
+	if strings.HasSuffix(current["class"], "NetworkTopologyStrategy") {
+		for dcName, value := range current {
+			if dcName == "class" {
+				continue
+			}
+			if gossipDc, found := gossipDcs[dcName]; decommissioned[dcName] || (found && gossipDc.nodes == 0) {
+				// All the nodes of the datacenter left the ring
+				changed = true
+				continue
+			}
+			rf, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, false
+			}
+			rfs[dcName] = rf
+		}
+	} else if len(joined) < 2 {
+		return nil, false
+	} else {
+		changed = true
+		converted = true
+		simpleRf, err := strconv.Atoi(current["replication_factor"])
+		if err != nil {
+			return nil, false
+		}
+		for _, dcName := range joined {
+			rfs[dcName] = simpleRf
+			if nodes := gossipDcs[dcName].nodes; rfs[dcName] > nodes {
+				rfs[dcName] = nodes
+			}
+		}
+	}
+
+	repair := false
+	for _, dcName := range joined {
+		rf := gossipDcs[dcName].nodes
+		if rf > maxSystemKeyspaceReplicationFactor {
+			rf = maxSystemKeyspaceReplicationFactor
+		}
+		if currentRf, found := rfs[dcName]; found {
+			if !converted || currentRf >= rf {
+				continue
+			}
+			repair = true
+		}
+		rfs[dcName] = rf
+		changed = true
+	}
+
+	if !changed || len(rfs) == 0 {
+		return nil, false
+	}
+
+	dcNames := make([]string, 0, len(rfs))
+	for dcName := range rfs {
+		dcNames = append(dcNames, dcName)
+	}
+	sort.Strings(dcNames)
+
+	settings := make([]map[string]string, 0, len(dcNames))
+	for _, dcName := range dcNames {
+		settings = append(settings, map[string]string{
+			"dc_name":            dcName,
+			"replication_factor": strconv.Itoa(rfs[dcName]),
+		})
+	}
+	return settings, repair
+}
+
+func formatReplicationSettings(settings []map[string]string) string {
+	parts := make([]string, 0, len(settings))
+	for _, setting := range settings {
+		parts = append(parts, fmt.Sprintf("%s:%s", setting["dc_name"], setting["replication_factor"]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// getDatacenterReplicationFactors Returns the replication factor in the datacenter of the keyspaces
+// replicated across the nodes, the keyspaces local to every node are left out
+func (rc *ReconciliationContext) getDatacenterReplicationFactors(pod *corev1.Pod) (map[string]int, error) {
+	keyspaces, err := rc.NodeMgmtClient.ListKeyspaces(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	rfs := map[string]int{}
+	for _, keyspace := range keyspaces {
+		replication, err := rc.NodeMgmtClient.GetKeyspaceReplication(pod, keyspace)
+		if err != nil {
+			return nil, err
+		}
+		if rf, replicated := getDatacenterReplicationFactor(replication, rc.Datacenter.DatacenterName()); replicated {
+			rfs[keyspace] = rf
+		}
+	}
+	return rfs, nil
+}
+
+// getDatacenterReplicationFactor Returns the number of full replicas of the keyspace in the datacenter, and
+// false when the keyspace is not replicated across nodes, like with LocalStrategy
+func getDatacenterReplicationFactor(replication map[string]string, dcName string) (int, bool) {
+	var value string
+	switch class := replication["class"]; {
+	case strings.HasSuffix(class, "NetworkTopologyStrategy"):
+		value = replication[dcName]
+	case strings.HasSuffix(class, "SimpleStrategy"):
+		value = replication["replication_factor"]
+	default:
+		return 0, false
+	}
+
+	// Transient replicas, as in 3/1, hold no full copy of the data
+	value = strings.Split(value, "/")[0]
+	rf, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, true
+	}
+	return rf, true
+}
@@ -0,0 +1,243 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	taskapi "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+	"github.com/k8ssandra/cass-operator/pkg/httphelper"
+)
+
+func TestGetGossipDatacenterStates(t *testing.T) {
+	epData := httphelper.CassMetadataEndpoints{Entity: []httphelper.EndpointState{
+		{Datacenter: "dc1", Status: "NORMAL,123"},
+		{Datacenter: "dc1", Status: "NORMAL,456"},
+		{Datacenter: "dc2", Status: "BOOT,789"},
+		{Datacenter: "dc3", Status: "LEFT,321,1"},
+		{Datacenter: "dc4", Status: "NORMAL,654"},
+		{Datacenter: "dc4", Status: "BOOT,987"},
+		{Datacenter: "dc5", Status: "shutdown,true"},
+	}}
+
+	assert.Equal(t, map[string]*gossipDatacenter{
+		"dc1": {nodes: 2, joined: true},
+		"dc2": {nodes: 1},
+		"dc3": {},
+		"dc4": {nodes: 2, joined: true},
+		"dc5": {nodes: 1},
+	}, getGossipDatacenterStates(epData))
+}
+
+func TestGetDesiredSystemKeyspaceReplication(t *testing.T) {
+	tests := []struct {
+		name           string
+		current        map[string]string
+		gossipDcs      map[string]*gossipDatacenter
+		decommissioned map[string]bool
+		want           []map[string]string
+		repair         bool
+	}{
+		{
+			name:      "single datacenter with SimpleStrategy",
+			current:   map[string]string{"class": "org.apache.cassandra.locator.SimpleStrategy", "replication_factor": "1"},
+			gossipDcs: map[string]*gossipDatacenter{"dc1": {nodes: 3, joined: true}},
+		},
+		{
+			name:      "SimpleStrategy with a second datacenter",
+			current:   map[string]string{"class": "org.apache.cassandra.locator.SimpleStrategy", "replication_factor": "1"},
+			gossipDcs: map[string]*gossipDatacenter{"dc1": {nodes: 5, joined: true}, "dc2": {nodes: 2, joined: true}},
+			want: []map[string]string{
+				{"dc_name": "dc1", "replication_factor": "3"},
+				{"dc_name": "dc2", "replication_factor": "2"},
+			},
+			repair: true,
+		},
+		{
+			name:      "SimpleStrategy replication factor kept",
+			current:   map[string]string{"class": "org.apache.cassandra.locator.SimpleStrategy", "replication_factor": "5"},
+			gossipDcs: map[string]*gossipDatacenter{"dc1": {nodes: 5, joined: true}, "dc2": {nodes: 2, joined: true}},
+			want: []map[string]string{
+				{"dc_name": "dc1", "replication_factor": "5"},
+				{"dc_name": "dc2", "replication_factor": "2"},
+			},
+		},
+		{
+			name:      "datacenter added",
+			current:   map[string]string{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc1": "1"},
+			gossipDcs: map[string]*gossipDatacenter{"dc1": {nodes: 3, joined: true}, "dc2": {nodes: 3, joined: true}},
+			want: []map[string]string{
+				{"dc_name": "dc1", "replication_factor": "1"},
+				{"dc_name": "dc2", "replication_factor": "3"},
+			},
+		},
+		{
+			name:      "bootstrapping datacenter not added yet",
+			current:   map[string]string{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc1": "1"},
+			gossipDcs: map[string]*gossipDatacenter{"dc1": {nodes: 3, joined: true}, "dc2": {nodes: 1}},
+		},
+		{
+			name:      "datacenter removed",
+			current:   map[string]string{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc1": "3", "dc2": "3"},
+			gossipDcs: map[string]*gossipDatacenter{"dc1": {nodes: 3, joined: true}, "dc2": {}},
+			want: []map[string]string{
+				{"dc_name": "dc1", "replication_factor": "3"},
+			},
+		},
+		{
+			name:           "datacenter decommissioned",
+			current:        map[string]string{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc1": "3", "dc2": "3"},
+			gossipDcs:      map[string]*gossipDatacenter{"dc1": {nodes: 3, joined: true}, "dc2": {nodes: 3, joined: true}},
+			decommissioned: map[string]bool{"dc2": true},
+			want: []map[string]string{
+				{"dc_name": "dc1", "replication_factor": "3"},
+			},
+		},
+		{
+			name:      "stopped and bootstrapping datacenters kept",
+			current:   map[string]string{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc1": "3", "dc2": "3", "dc3": "3"},
+			gossipDcs: map[string]*gossipDatacenter{"dc1": {nodes: 3, joined: true}, "dc2": {nodes: 3}, "dc3": {nodes: 1}},
+		},
+		{
+			name:      "datacenter unknown to gossip kept",
+			current:   map[string]string{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc1": "3", "dc2": "3"},
+			gossipDcs: map[string]*gossipDatacenter{"dc1": {nodes: 3, joined: true}},
+		},
+		{
+			name:      "up to date",
+			current:   map[string]string{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc1": "2", "dc2": "3"},
+			gossipDcs: map[string]*gossipDatacenter{"dc1": {nodes: 3, joined: true}, "dc2": {nodes: 3, joined: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired, repair := getDesiredSystemKeyspaceReplication(tt.current, tt.gossipDcs, tt.decommissioned)
+			assert.Equal(t, tt.want, desired)
+			assert.Equal(t, tt.repair, repair)
+		})
+	}
+}
+
+func TestCheckSystemKeyspacesReplication_repair(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	require.NoError(t, taskapi.AddToScheme(clientgoscheme.Scheme))
+	dc := rc.Datacenter
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: dc.Namespace, Labels: dc.GetRackLabels("default")},
+		Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}}},
+	}
+	task := &taskapi.CassandraTask{
+		ObjectMeta: metav1.ObjectMeta{Name: "repair-system-auth", Namespace: dc.Namespace},
+		Spec: taskapi.CassandraTaskSpec{
+			Jobs: []taskapi.CassandraJob{{Command: taskapi.CommandRepair, Arguments: taskapi.JobArguments{KeyspaceName: "system_auth"}}},
+		},
+	}
+	dc.Status.AddTaskToTrack(task.ObjectMeta)
+	rc.dcPods = []*corev1.Pod{pod}
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(dc, pod, task).Build()
+
+	// system_auth was converted and is being repaired
+	simpleStrategy := map[string]string{"class": "org.apache.cassandra.locator.SimpleStrategy", "replication_factor": "1"}
+	mgmtClient := &fakeNodeMgmtClient{replications: map[string]map[string]string{
+		"system_auth":        {"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", dc.DatacenterName(): "2", "dc2": "1"},
+		"system_distributed": simpleStrategy,
+		"system_traces":      simpleStrategy,
+	}}
+	rc.NodeMgmtClient = mgmtClient
+
+	epData := httphelper.CassMetadataEndpoints{Entity: []httphelper.EndpointState{
+		{Datacenter: dc.DatacenterName(), Status: "NORMAL,1"},
+		{Datacenter: dc.DatacenterName(), Status: "NORMAL,2"},
+		{Datacenter: "dc2", Status: "NORMAL,3"},
+	}}
+
+	// The next keyspace waits for the repair
+	recResult := rc.CheckSystemKeyspacesReplication(epData)
+	assert.False(t, recResult.Completed())
+	assert.Equal(t, simpleStrategy, mgmtClient.replications["system_distributed"])
+
+	now := metav1.Now()
+	task.Status.CompletionTime = &now
+	require.NoError(t, rc.Client.Status().Update(rc.Ctx, task))
+
+	// A single keyspace is raised at a time, and repaired
+	recResult = rc.CheckSystemKeyspacesReplication(epData)
+	assert.False(t, recResult.Completed())
+	assert.Equal(t, "2", mgmtClient.replications["system_distributed"][dc.DatacenterName()])
+	assert.Equal(t, simpleStrategy, mgmtClient.replications["system_traces"])
+	require.Len(t, rc.Datacenter.Status.TrackedTasks, 1)
+
+	taskKey := types.NamespacedName{Name: rc.Datacenter.Status.TrackedTasks[0].Name, Namespace: dc.Namespace}
+	require.NoError(t, rc.Client.Get(rc.Ctx, taskKey, task))
+	assert.Equal(t, taskapi.CommandRepair, task.Spec.Jobs[0].Command)
+	assert.Equal(t, "system_distributed", task.Spec.Jobs[0].Arguments.KeyspaceName)
+}
+
+func TestGetHealthCheckSettings_clusterDatacenters(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Racks = []api.Rack{{Name: "r1"}, {Name: "r2"}, {Name: "r3"}}
+	otherDc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc2", Namespace: dc.Namespace},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: dc.Spec.ClusterName,
+			Size:        2,
+			Racks:       []api.Rack{{Name: "r1"}, {Name: "r2"}},
+		},
+	}
+	otherCluster := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc3", Namespace: dc.Namespace},
+		Spec:       api.CassandraDatacenterSpec{ClusterName: "other-cluster", Size: 1},
+	}
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+	rc.Client = fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(dc, otherDc, otherCluster).Build()
+
+	_, rfPerDc := rc.getHealthCheckSettings()
+	assert.Equal(t, 2, rfPerDc)
+
+	dc.Spec.HealthCheckReplicationFactor = 3
+	_, rfPerDc = rc.getHealthCheckSettings()
+	assert.Equal(t, 3, rfPerDc)
+}
+
+func TestGetHealthCheckSettings_remoteDatacenters(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.HealthCheckConsistencyLevel = "QUORUM"
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, api.AddToScheme(scheme))
+	rc.Client = fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(dc).Build()
+
+	rc.gossipDatacenters = []string{dc.DatacenterName()}
+	consistencyLevel, _ := rc.getHealthCheckSettings()
+	assert.Equal(t, "QUORUM", consistencyLevel)
+
+	// The datacenter of the other Kubernetes cluster may not be reachable
+	rc.gossipDatacenters = []string{dc.DatacenterName(), "remote-dc"}
+	consistencyLevel, _ = rc.getHealthCheckSettings()
+	assert.Equal(t, "LOCAL_QUORUM", consistencyLevel)
+
+	dc.Spec.HealthCheckConsistencyLevel = "ONE"
+	consistencyLevel, _ = rc.getHealthCheckSettings()
+	assert.Equal(t, "LOCAL_ONE", consistencyLevel)
+}
@@ -0,0 +1,169 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	taskapi "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/oplabels"
+)
+
+// CheckPreUpgradeSnapshot holds the rollout of a new server version or image, with preUpgradeSnapshot, until
+// a CassandraBackup took a snapshot of all the nodes on the version they run. The backup is recorded in
+// status.preUpgradeSnapshot along with the version it was taken on, so that it is taken once per upgrade,
+// and the tag of the snapshots once the backup completed.
+func (rc *ReconciliationContext) CheckPreUpgradeSnapshot() result.ReconcileResult {
+	dc := rc.Datacenter
+	if !dc.Spec.PreUpgradeSnapshot || dc.Status.ServerVersion == "" || len(rc.desiredRackInformation) == 0 {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_upgrade::CheckPreUpgradeSnapshot")
+
+	rackInfo := rc.desiredRackInformation[0]
+	desiredSts, err := newStatefulSetForCassandraDatacenter(nil, rackInfo.RackName, dc, rackInfo.NodeCount)
+	if err != nil {
+		return result.Error(err)
+	}
+	serverVersion, serverImage, ok := getServerVersionAndImage([]*appsv1.StatefulSet{desiredSts})
+	if !ok || (serverVersion == dc.Status.ServerVersion && serverImage == dc.Status.ServerImage) {
+		return result.Continue()
+	}
+
+	snapshot := dc.Status.PreUpgradeSnapshot
+	if snapshot != nil && snapshot.ServerVersion == dc.Status.ServerVersion && snapshot.ServerImage == dc.Status.ServerImage {
+		if snapshot.SnapshotName != "" {
+			return result.Continue()
+		}
+
+		backup := &taskapi.CassandraBackup{}
+		err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: snapshot.BackupName}, backup)
+		if err == nil {
+			if backup.Status.CompletionTime == nil {
+				rc.ReqLogger.Info("Waiting for the snapshot before the upgrade", "backup", backup.Name)
+				return result.RequeueSoon(10)
+			}
+
+			dcPatch := client.MergeFrom(dc.DeepCopy())
+			dc.Status.PreUpgradeSnapshot.SnapshotName = backup.Status.SnapshotName
+			if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+				return result.Error(err)
+			}
+			return result.Continue()
+		} else if !errors.IsNotFound(err) {
+			return result.Error(err)
+		}
+		// The backup was deleted before it completed, take a new one
+	}
+
+	backup := newPreUpgradeBackup(dc)
+	if err := rc.Client.Create(rc.Ctx, backup); err != nil {
+		return result.Error(err)
+	}
+
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.SnapshottingBeforeUpgrade,
+		"Taking snapshot %s of version %s before the upgrade to %s", backup.Name, dc.Status.ServerVersion, serverVersion)
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	dc.Status.PreUpgradeSnapshot = &api.PreUpgradeSnapshotStatus{
+		BackupName:    backup.Name,
+		ServerVersion: dc.Status.ServerVersion,
+		ServerImage:   dc.Status.ServerImage,
+	}
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		return result.Error(err)
+	}
+
+	return result.RequeueSoon(10)
+}
+
+// CheckSSTablesUpgrade runs the upgradesstables CassandraTask requested by UpdateRackStatuses in
+// status.sstablesUpgrade once a rolling upgrade to a new major version completed. The task upgrades one node
+// at a time, and its progress is copied to status.sstablesUpgrade until it completed on every node. A task
+// which failed on some nodes is reported and replaced by a new one, upgradesstables skipping the sstables
+// already upgraded.
+func (rc *ReconciliationContext) CheckSSTablesUpgrade() result.ReconcileResult {
+	dc := rc.Datacenter
+	sstablesUpgrade := dc.Status.SSTablesUpgrade
+	if dc.Spec.UpgradeSSTables == nil || sstablesUpgrade == nil || sstablesUpgrade.CompletionTime != nil {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_upgrade::CheckSSTablesUpgrade")
+
+	task, err := rc.findActiveTask(taskapi.CommandUpgradeSSTables)
+	if err != nil {
+		return result.Error(err)
+	}
+
+	if task == nil {
+		err := rc.createTask(taskapi.CommandUpgradeSSTables, taskapi.JobArguments{Jobs: dc.Spec.UpgradeSSTables.Jobs})
+		if err != nil {
+			return result.Error(err)
+		}
+
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.UpgradingSSTables,
+			"Upgrading the sstables to version %s", sstablesUpgrade.ServerVersion)
+		return result.RequeueSoon(10)
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	dc.Status.SSTablesUpgrade.TaskName = task.Name
+	dc.Status.SSTablesUpgrade.UpgradedNodes = task.Status.Succeeded
+	if task.Status.CompletionTime != nil && task.Status.Failed > 0 {
+		// The next pass creates a new task once this one is no longer tracked
+		dc.Status.SSTablesUpgrade.FailedNodes = task.Status.Failed
+		dc.Status.RemoveTrackedTask(task.ObjectMeta)
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			return result.Error(err)
+		}
+
+		rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.UpgradingSSTables,
+			"Task %s failed to upgrade the sstables of %d nodes to version %s, retrying", task.Name, task.Status.Failed,
+			sstablesUpgrade.ServerVersion)
+		return result.RequeueSoon(60)
+	}
+	dc.Status.SSTablesUpgrade.CompletionTime = task.Status.CompletionTime
+	if task.Status.CompletionTime != nil {
+		dc.Status.SSTablesUpgrade.FailedNodes = 0
+	}
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		return result.Error(err)
+	}
+
+	return rc.activeTaskCompleted(task)
+}
+
+// newPreUpgradeBackup returns a CassandraBackup of all the keyspaces of the Datacenter. It is not owned by the
+// Datacenter, to be available for a rollback.
+func newPreUpgradeBackup(dc *api.CassandraDatacenter) *taskapi.CassandraBackup {
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddOperatorLabels(labels, dc)
+
+	return &taskapi.CassandraBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-pre-upgrade-%d", dc.Name, time.Now().Unix()),
+			Namespace: dc.Namespace,
+			Labels:    labels,
+		},
+		Spec: taskapi.CassandraBackupSpec{
+			Datacenter: corev1.ObjectReference{
+				Name:      dc.Name,
+				Namespace: dc.Namespace,
+			},
+		},
+	}
+}
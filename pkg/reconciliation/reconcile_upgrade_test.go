@@ -0,0 +1,167 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	taskapi "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+)
+
+func TestCheckPreUpgradeSnapshot(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	require.NoError(t, taskapi.AddToScheme(scheme.Scheme))
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(rc.Datacenter).Build()
+	require.NoError(t, rc.CalculateRackInformation())
+
+	listBackups := func() []taskapi.CassandraBackup {
+		backups := &taskapi.CassandraBackupList{}
+		require.NoError(t, rc.Client.List(rc.Ctx, backups, client.InNamespace(rc.Datacenter.Namespace)))
+		return backups.Items
+	}
+
+	oldVersion := rc.Datacenter.Spec.ServerVersion
+	oldImage, err := makeImage(rc.Datacenter)
+	require.NoError(t, err)
+	rc.Datacenter.Spec.PreUpgradeSnapshot = true
+	require.NoError(t, rc.Client.Update(rc.Ctx, rc.Datacenter))
+	rc.Datacenter.Status.ServerVersion = oldVersion
+	rc.Datacenter.Status.ServerImage = oldImage
+	require.NoError(t, rc.Client.Status().Update(rc.Ctx, rc.Datacenter))
+
+	// No snapshot without an upgrade
+	assert.Equal(t, result.Continue(), rc.CheckPreUpgradeSnapshot())
+	assert.Empty(t, listBackups())
+
+	// The upgrade waits for the backup
+	rc.Datacenter.Spec.ServerVersion = "6.8.26"
+	require.NoError(t, rc.Client.Update(rc.Ctx, rc.Datacenter))
+	assert.Equal(t, result.RequeueSoon(10), rc.CheckPreUpgradeSnapshot())
+	backups := listBackups()
+	require.Len(t, backups, 1)
+	assert.Equal(t, rc.Datacenter.Name, backups[0].Spec.Datacenter.Name)
+	snapshot := rc.Datacenter.Status.PreUpgradeSnapshot
+	require.NotNil(t, snapshot)
+	assert.Equal(t, backups[0].Name, snapshot.BackupName)
+	assert.Equal(t, oldVersion, snapshot.ServerVersion)
+	assert.Equal(t, oldImage, snapshot.ServerImage)
+	assert.Empty(t, snapshot.SnapshotName)
+
+	assert.Equal(t, result.RequeueSoon(10), rc.CheckPreUpgradeSnapshot())
+	assert.Len(t, listBackups(), 1)
+
+	// and rolls out once it completed
+	backup := backups[0]
+	now := metav1.Now()
+	backup.Status.CompletionTime = &now
+	backup.Status.SnapshotName = backup.Name
+	require.NoError(t, rc.Client.Status().Update(rc.Ctx, &backup))
+
+	assert.Equal(t, result.Continue(), rc.CheckPreUpgradeSnapshot())
+	assert.Equal(t, backup.Name, rc.Datacenter.Status.PreUpgradeSnapshot.SnapshotName)
+	assert.Equal(t, result.Continue(), rc.CheckPreUpgradeSnapshot())
+	assert.Len(t, listBackups(), 1)
+}
+
+func TestCheckSSTablesUpgrade(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	require.NoError(t, taskapi.AddToScheme(scheme.Scheme))
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(rc.Datacenter).Build()
+
+	listTasks := func() []taskapi.CassandraTask {
+		tasks := &taskapi.CassandraTaskList{}
+		require.NoError(t, rc.Client.List(rc.Ctx, tasks, client.InNamespace(rc.Datacenter.Namespace)))
+		return tasks.Items
+	}
+
+	rc.Datacenter.Spec.UpgradeSSTables = &api.UpgradeSSTablesConfig{Jobs: 2}
+	require.NoError(t, rc.Client.Update(rc.Ctx, rc.Datacenter))
+
+	// Nothing to do before a major upgrade
+	assert.Equal(t, result.Continue(), rc.CheckSSTablesUpgrade())
+	assert.Empty(t, listTasks())
+
+	rc.Datacenter.Status.SSTablesUpgrade = &api.SSTablesUpgradeStatus{ServerVersion: "4.0.1"}
+	require.NoError(t, rc.Client.Status().Update(rc.Ctx, rc.Datacenter))
+
+	assert.Equal(t, result.RequeueSoon(10), rc.CheckSSTablesUpgrade())
+	tasks := listTasks()
+	require.Len(t, tasks, 1)
+	require.Len(t, tasks[0].Spec.Jobs, 1)
+	assert.Equal(t, taskapi.CommandUpgradeSSTables, tasks[0].Spec.Jobs[0].Command)
+	assert.Equal(t, 2, tasks[0].Spec.Jobs[0].Arguments.Jobs)
+
+	// The progress of the task is reported
+	task := tasks[0]
+	task.Status.Succeeded = 1
+	require.NoError(t, rc.Client.Status().Update(rc.Ctx, &task))
+
+	assert.Equal(t, result.RequeueSoon(10), rc.CheckSSTablesUpgrade())
+	assert.Equal(t, task.Name, rc.Datacenter.Status.SSTablesUpgrade.TaskName)
+	assert.Equal(t, 1, rc.Datacenter.Status.SSTablesUpgrade.UpgradedNodes)
+	assert.Nil(t, rc.Datacenter.Status.SSTablesUpgrade.CompletionTime)
+	assert.Len(t, listTasks(), 1)
+
+	// until it completed
+	now := metav1.Now()
+	task.Status.Succeeded = 3
+	task.Status.CompletionTime = &now
+	require.NoError(t, rc.Client.Status().Update(rc.Ctx, &task))
+
+	assert.Equal(t, result.Continue(), rc.CheckSSTablesUpgrade())
+	assert.Equal(t, 3, rc.Datacenter.Status.SSTablesUpgrade.UpgradedNodes)
+	assert.NotNil(t, rc.Datacenter.Status.SSTablesUpgrade.CompletionTime)
+	assert.Empty(t, rc.Datacenter.Status.TrackedTasks)
+	assert.Equal(t, result.Continue(), rc.CheckSSTablesUpgrade())
+	assert.Len(t, listTasks(), 1)
+}
+
+func TestCheckSSTablesUpgradeFailed(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	require.NoError(t, taskapi.AddToScheme(scheme.Scheme))
+
+	now := metav1.Now()
+	failedTask := &taskapi.CassandraTask{
+		ObjectMeta: metav1.ObjectMeta{Name: "upgradesstables-1", Namespace: rc.Datacenter.Namespace},
+		Spec: taskapi.CassandraTaskSpec{
+			Jobs: []taskapi.CassandraJob{{Name: "upgradesstables-dc1", Command: taskapi.CommandUpgradeSSTables}},
+		},
+		Status: taskapi.CassandraTaskStatus{Succeeded: 2, Failed: 1, CompletionTime: &now},
+	}
+	rc.Datacenter.Spec.UpgradeSSTables = &api.UpgradeSSTablesConfig{}
+	rc.Datacenter.Status.SSTablesUpgrade = &api.SSTablesUpgradeStatus{ServerVersion: "4.0.1"}
+	rc.Datacenter.Status.AddTaskToTrack(failedTask.ObjectMeta)
+	rc.Client = fake.NewClientBuilder().WithRuntimeObjects(rc.Datacenter, failedTask).Build()
+
+	// The failure is reported and the task is no longer tracked, without completing the upgrade
+	assert.Equal(t, result.RequeueSoon(60), rc.CheckSSTablesUpgrade())
+	assert.Equal(t, "upgradesstables-1", rc.Datacenter.Status.SSTablesUpgrade.TaskName)
+	assert.Equal(t, 2, rc.Datacenter.Status.SSTablesUpgrade.UpgradedNodes)
+	assert.Equal(t, 1, rc.Datacenter.Status.SSTablesUpgrade.FailedNodes)
+	assert.Nil(t, rc.Datacenter.Status.SSTablesUpgrade.CompletionTime)
+	assert.Empty(t, rc.Datacenter.Status.TrackedTasks)
+
+	// A new task is created to retry
+	assert.Equal(t, result.RequeueSoon(10), rc.CheckSSTablesUpgrade())
+	tasks := &taskapi.CassandraTaskList{}
+	require.NoError(t, rc.Client.List(rc.Ctx, tasks, client.InNamespace(rc.Datacenter.Namespace)))
+	assert.Len(t, tasks.Items, 2)
+	assert.Len(t, rc.Datacenter.Status.TrackedTasks, 1)
+	assert.NotEqual(t, "upgradesstables-1", rc.Datacenter.Status.TrackedTasks[0].Name)
+}
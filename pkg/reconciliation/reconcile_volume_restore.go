@@ -0,0 +1,124 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+	"github.com/k8ssandra/cass-operator/pkg/oplabels"
+)
+
+var volumeSnapshotGVK = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+
+// CheckRestoredVolumes provisions the server data volumes of the racks not created yet from the VolumeSnapshots
+// of the dataSourceBackup, to restore in place the Datacenter the backup was taken from. The
+// PersistentVolumeClaims are created before the StatefulSets, with the names of their volumeClaimTemplates,
+// so that the pods of the StatefulSets adopt them.
+func (rc *ReconciliationContext) CheckRestoredVolumes() result.ReconcileResult {
+	dc := rc.Datacenter
+	if !dc.IsRestoredFromBackup() || dc.Spec.StorageConfig.CassandraDataVolumeClaimSpec == nil {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_volume_restore::CheckRestoredVolumes")
+
+	for _, rackInfo := range rc.desiredRackInformation {
+		stsName := newNamespacedNameForStatefulSet(dc, rackInfo.RackName)
+		if err := rc.Client.Get(rc.Ctx, stsName, &appsv1.StatefulSet{}); err == nil {
+			continue
+		} else if !errors.IsNotFound(err) {
+			return result.Error(err)
+		}
+
+		for i := 0; i < rackInfo.NodeCount; i++ {
+			podName := fmt.Sprintf("%s-%d", stsName.Name, i)
+			if err := rc.createRestoredPVC(rackInfo.RackName, podName); err != nil {
+				return result.Error(err)
+			}
+		}
+	}
+
+	return result.Continue()
+}
+
+// createRestoredPVC creates the server data PVC of the pod from the VolumeSnapshot taken of the pod of the
+// same name by the dataSourceBackup. The pods without a PVC or a VolumeSnapshot are left to the StatefulSet.
+// The VolumeSnapshots of another Datacenter are refused, as the nodes would start on the tokens and host IDs
+// of the nodes of that Datacenter.
+func (rc *ReconciliationContext) createRestoredPVC(rackName, podName string) error {
+	dc := rc.Datacenter
+	pvcName := types.NamespacedName{Namespace: dc.Namespace, Name: fmt.Sprintf("%s-%s", PvcName, podName)}
+	if err := rc.Client.Get(rc.Ctx, pvcName, &corev1.PersistentVolumeClaim{}); err == nil {
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	snapshotName := fmt.Sprintf("%s-%s", dc.Spec.StorageConfig.DataSourceBackup, podName)
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	if err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: dc.Namespace, Name: snapshotName}, snapshot); err != nil {
+		if errors.IsNotFound(err) {
+			rc.ReqLogger.Info("No VolumeSnapshot for the pod, it will bootstrap", "pod", podName, "volumeSnapshot", snapshotName)
+			return nil
+		}
+		return err
+	}
+
+	snapshotLabels := snapshot.GetLabels()
+	for key, value := range dc.GetDatacenterLabels() {
+		if snapshotLabels[key] != value {
+			err := fmt.Errorf("the VolumeSnapshot %s was not taken of this datacenter, %s is %q instead of %q",
+				snapshotName, key, snapshotLabels[key], value)
+			rc.Recorder.Event(dc, corev1.EventTypeWarning, events.InvalidDatacenterSpec, err.Error())
+			return err
+		}
+	}
+
+	pvc := newRestoredPVCForCassandraDatacenter(dc, rackName, pvcName.Name, snapshotName)
+	if err := rc.Client.Create(rc.Ctx, pvc); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+		"Created PersistentVolumeClaim %s from VolumeSnapshot %s", pvc.Name, snapshotName)
+	return nil
+}
+
+func newRestoredPVCForCassandraDatacenter(dc *api.CassandraDatacenter, rackName, pvcName, snapshotName string) *corev1.PersistentVolumeClaim {
+	labels := dc.GetRackLabels(rackName)
+	oplabels.AddOperatorLabels(labels, dc)
+
+	annotations := map[string]string{}
+	oplabels.AddOperatorAnnotations(annotations, dc)
+
+	apiGroup := volumeSnapshotGVK.Group
+	spec := dc.Spec.StorageConfig.CassandraDataVolumeClaimSpec.DeepCopy()
+	spec.DataSource = &corev1.TypedLocalObjectReference{
+		APIGroup: &apiGroup,
+		Kind:     volumeSnapshotGVK.Kind,
+		Name:     snapshotName,
+	}
+
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pvcName,
+			Namespace:   dc.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: *spec,
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/pkg/internal/result"
+)
+
+func TestCheckRestoredVolumes(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.StorageConfig.DataSourceBackup = "backup1"
+	rc.desiredRackInformation = []*RackInformation{
+		{RackName: "rack1", NodeCount: 2},
+		{RackName: "rack2", NodeCount: 1},
+	}
+
+	stsName := func(rackName string) string {
+		return newNamespacedNameForStatefulSet(rc.Datacenter, rackName).Name
+	}
+
+	// rack2 already exists, its volumes are left to its StatefulSet
+	sts, err := newStatefulSetForCassandraDatacenter(nil, "rack2", rc.Datacenter, 1)
+	require.NoError(t, err)
+	require.NoError(t, rc.Client.Create(rc.Ctx, sts))
+
+	// The second pod of rack1 has no snapshot
+	for _, podName := range []string{stsName("rack1") + "-0", stsName("rack2") + "-0"} {
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+		snapshot.SetNamespace(rc.Datacenter.Namespace)
+		snapshot.SetName("backup1-" + podName)
+		snapshot.SetLabels(rc.Datacenter.GetDatacenterLabels())
+		require.NoError(t, rc.Client.Create(rc.Ctx, snapshot))
+	}
+
+	assert.Equal(t, result.Continue(), rc.CheckRestoredVolumes())
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	pvcName := types.NamespacedName{Namespace: rc.Datacenter.Namespace, Name: PvcName + "-" + stsName("rack1") + "-0"}
+	require.NoError(t, rc.Client.Get(rc.Ctx, pvcName, pvc))
+	require.NotNil(t, pvc.Spec.DataSource)
+	assert.Equal(t, "snapshot.storage.k8s.io", *pvc.Spec.DataSource.APIGroup)
+	assert.Equal(t, "VolumeSnapshot", pvc.Spec.DataSource.Kind)
+	assert.Equal(t, "backup1-"+stsName("rack1")+"-0", pvc.Spec.DataSource.Name)
+	assert.Equal(t, rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.StorageClassName, pvc.Spec.StorageClassName)
+	for k, v := range rc.Datacenter.GetRackLabels("rack1") {
+		assert.Equal(t, v, pvc.Labels[k])
+	}
+
+	for _, podName := range []string{stsName("rack1") + "-1", stsName("rack2") + "-0"} {
+		pvcName := types.NamespacedName{Namespace: rc.Datacenter.Namespace, Name: PvcName + "-" + podName}
+		err := rc.Client.Get(rc.Ctx, pvcName, &corev1.PersistentVolumeClaim{})
+		assert.True(t, errors.IsNotFound(err), "unexpected PVC for %s", podName)
+	}
+
+	// The existing PVCs are kept
+	assert.Equal(t, result.Continue(), rc.CheckRestoredVolumes())
+}
+
+func TestCheckRestoredVolumesOtherDatacenter(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.StorageConfig.DataSourceBackup = "backup1"
+	rc.desiredRackInformation = []*RackInformation{{RackName: "rack1", NodeCount: 1}}
+	podName := newNamespacedNameForStatefulSet(rc.Datacenter, "rack1").Name + "-0"
+
+	// The snapshot was taken of a datacenter of another cluster
+	labels := rc.Datacenter.GetDatacenterLabels()
+	labels[api.ClusterLabel] = "other"
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	snapshot.SetNamespace(rc.Datacenter.Namespace)
+	snapshot.SetName("backup1-" + podName)
+	snapshot.SetLabels(labels)
+	require.NoError(t, rc.Client.Create(rc.Ctx, snapshot))
+
+	recResult := rc.CheckRestoredVolumes()
+	assert.True(t, recResult.Completed())
+	_, err := recResult.Output()
+	assert.Error(t, err)
+
+	pvcName := types.NamespacedName{Namespace: rc.Datacenter.Namespace, Name: PvcName + "-" + podName}
+	err = rc.Client.Get(rc.Ctx, pvcName, &corev1.PersistentVolumeClaim{})
+	assert.True(t, errors.IsNotFound(err))
+}
@@ -19,6 +19,9 @@ import (
 	"github.com/k8ssandra/cass-operator/pkg/utils"
 )
 
+// defaultSuperuserName is the superuser Cassandra creates when it starts without any role
+const defaultSuperuserName = "cassandra"
+
 func generateUtf8Password() (string, error) {
 	// Note that bcrypt has a maximum password length of 55 characters:
 	//
@@ -120,7 +123,8 @@ func (rc *ReconciliationContext) retrieveSuperuserSecretOrCreateDefault() (*core
 	secret, retrieveErr := rc.retrieveSuperuserSecret()
 	if retrieveErr != nil {
 		if errors.IsNotFound(retrieveErr) {
-			secret, err := buildDefaultSuperuserSecret(dc)
+			var err error
+			secret, err = buildDefaultSuperuserSecret(dc)
 
 			if err == nil && secret == nil {
 				return nil, retrieveErr
@@ -129,7 +129,7 @@ func CreateMockReconciliationContext(
 			})).
 		Return(res, nil)
 
-	rc.NodeMgmtClient = httphelper.NodeMgmtClient{Client: mockHttpClient, Log: reqLogger, Protocol: "http"}
+	rc.NodeMgmtClient = &httphelper.NodeMgmtClient{Client: mockHttpClient, Log: reqLogger, Protocol: "http"}
 
 	rc.PSPHealthUpdater = &psp.NoOpUpdater{}
 
@@ -0,0 +1,284 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+)
+
+const (
+	// Keys of the credentials secret of Azure storages, the account key is base64 encoded
+	AzureAccountNameKey = "account_name"
+	AzureAccountKeyKey  = "account_key"
+
+	azureAPIVersion = "2020-10-02"
+
+	// A block blob has up to 50000 blocks
+	azureMaxBlocks = 50000
+)
+
+// The blobs larger than a block, which can not exceed 5000 MiB in a single Put Blob, are uploaded in blocks
+var azureBlockSize int64 = 64 << 20
+
+// azureWriter puts and gets block blobs with requests authorized with the shared key of the storage account
+type azureWriter struct {
+	endpoint    *url.URL
+	container   string
+	prefix      string
+	accountName string
+	accountKey  []byte
+	now         func() time.Time
+}
+
+func newAzureWriter(storage *api.BackupStorage, credentials map[string][]byte) (*azureWriter, error) {
+	accountName, err := credential(credentials, AzureAccountNameKey)
+	if err != nil {
+		return nil, err
+	}
+	encodedKey, err := credential(credentials, AzureAccountKeyKey)
+	if err != nil {
+		return nil, err
+	}
+	accountKey, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Azure account key: %w", err)
+	}
+
+	rawEndpoint := storage.Endpoint
+	if rawEndpoint == "" {
+		rawEndpoint = fmt.Sprintf("https://%s.blob.core.windows.net", accountName)
+	}
+	endpoint, err := url.Parse(rawEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Azure endpoint '%s': %w", rawEndpoint, err)
+	}
+
+	return &azureWriter{
+		endpoint:    endpoint,
+		container:   storage.Bucket,
+		prefix:      storage.Prefix,
+		accountName: accountName,
+		accountKey:  accountKey,
+		now:         time.Now,
+	}, nil
+}
+
+func (w *azureWriter) Write(ctx context.Context, key string, data []byte) error {
+	return w.WriteFrom(ctx, key, bytes.NewReader(data), int64(len(data)))
+}
+
+func (w *azureWriter) WriteFrom(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	if size > azureBlockSize {
+		return w.writeBlocks(ctx, key, body, size)
+	}
+
+	req, err := newUploadRequest(ctx, http.MethodPut, w.blobURL(key), body, size)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	req.Header.Set("x-ms-date", w.now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	w.sign(req, size)
+
+	return doRequest(httpClient, req)
+}
+
+// writeBlocks stages the blocks of the blob then commits their list, see
+// https://learn.microsoft.com/en-us/rest/api/storageservices/understanding-block-blobs--append-blobs--and-page-blobs
+func (w *azureWriter) writeBlocks(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	blobURL := w.blobURL(key)
+
+	blockList := struct {
+		XMLName xml.Name `xml:"BlockList"`
+		Latest  []string `xml:"Latest"`
+	}{}
+
+	blockLength := partSize(size, azureBlockSize, azureMaxBlocks)
+	for offset := int64(0); offset < size; offset += blockLength {
+		// The IDs of the blocks of a blob have the same length
+		blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%05d", len(blockList.Latest))))
+		query := url.Values{"comp": {"block"}, "blockid": {blockID}}
+		length := minInt64(blockLength, size-offset)
+
+		req, err := newUploadRequest(ctx, http.MethodPut, blobURL+"?"+query.Encode(), io.LimitReader(body, length), length)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("x-ms-date", w.now().UTC().Format(http.TimeFormat))
+		req.Header.Set("x-ms-version", azureAPIVersion)
+		w.sign(req, length)
+		if err := doRequest(httpClient, req); err != nil {
+			return err
+		}
+		blockList.Latest = append(blockList.Latest, blockID)
+	}
+
+	// The staged blocks which are not committed are deleted after a week
+	payload, err := xml.Marshal(blockList)
+	if err != nil {
+		return err
+	}
+	payload = append([]byte(xml.Header), payload...)
+	req, err := newUploadRequest(ctx, http.MethodPut, blobURL+"?comp=blocklist", bytes.NewReader(payload), int64(len(payload)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+	req.Header.Set("x-ms-blob-content-type", "application/octet-stream")
+	req.Header.Set("x-ms-date", w.now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	w.sign(req, int64(len(payload)))
+
+	return doRequest(httpClient, req)
+}
+
+func (w *azureWriter) ReadTo(ctx context.Context, key string, out io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.blobURL(key), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-ms-date", w.now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	w.sign(req, 0)
+
+	return doDownload(httpClient, req, out)
+}
+
+func (w *azureWriter) Delete(ctx context.Context, prefix string) error {
+	var listed struct {
+		Blobs struct {
+			Blob []struct {
+				Name string
+			}
+		}
+		NextMarker string
+	}
+
+	query := url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {objectKey(w.prefix, prefix)}}
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.containerURL()+"?"+query.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("x-ms-date", w.now().UTC().Format(http.TimeFormat))
+		req.Header.Set("x-ms-version", azureAPIVersion)
+		w.sign(req, 0)
+
+		var response bytes.Buffer
+		if err := doDownload(httpClient, req, &response); err != nil {
+			return err
+		}
+		listed.Blobs.Blob, listed.NextMarker = nil, ""
+		if err := xml.Unmarshal(response.Bytes(), &listed); err != nil {
+			return err
+		}
+
+		// The names of the listing include the prefix of the storage
+		for _, blob := range listed.Blobs.Blob {
+			req, err := http.NewRequestWithContext(ctx, http.MethodDelete, w.nameURL(blob.Name), nil)
+			if err != nil {
+				return err
+			}
+			req.Header.Set("x-ms-date", w.now().UTC().Format(http.TimeFormat))
+			req.Header.Set("x-ms-version", azureAPIVersion)
+			w.sign(req, 0)
+			if err := doRequest(httpClient, req); err != nil {
+				return err
+			}
+		}
+
+		if listed.NextMarker == "" {
+			return nil
+		}
+		query.Set("marker", listed.NextMarker)
+	}
+}
+
+// blobURL returns the URL of the blob with the key in the container
+func (w *azureWriter) blobURL(key string) string {
+	return w.nameURL(objectKey(w.prefix, key))
+}
+
+// containerURL returns the URL of the container
+func (w *azureWriter) containerURL() string {
+	return w.nameURL("")
+}
+
+// nameURL returns the URL of the blob with the name, which includes the prefix of the storage, or of the
+// container if the name is empty
+func (w *azureWriter) nameURL(name string) string {
+	u := *w.endpoint
+	u.Path = strings.TrimSuffix(w.endpoint.Path, "/") + "/" + w.container
+	if name != "" {
+		u.Path += "/" + name
+	}
+	return u.String()
+}
+
+// sign adds the Shared Key authorization header to the request, see
+// https://learn.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func (w *azureWriter) sign(req *http.Request, contentLength int64) {
+	length := ""
+	if contentLength > 0 {
+		length = strconv.FormatInt(contentLength, 10)
+	}
+
+	var msHeaders []string
+	for name := range req.Header {
+		if name = strings.ToLower(name); strings.HasPrefix(name, "x-ms-") {
+			msHeaders = append(msHeaders, name)
+		}
+	}
+	sort.Strings(msHeaders)
+	var canonicalHeaders strings.Builder
+	for _, name := range msHeaders {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, strings.TrimSpace(req.Header.Get(name)))
+	}
+
+	var canonicalResource strings.Builder
+	canonicalResource.WriteString("/" + w.accountName + req.URL.EscapedPath())
+	query := req.URL.Query()
+	var params []string
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		fmt.Fprintf(&canonicalResource, "\n%s:%s", strings.ToLower(name), strings.Join(query[name], ","))
+	}
+
+	stringToSign := strings.Join([]string{
+		req.Method,
+		"", // Content-Encoding
+		"", // Content-Language
+		length,
+		"", // Content-MD5
+		req.Header.Get("Content-Type"),
+		"", // Date, replaced by x-ms-date
+		"", // If-Modified-Since
+		"", // If-Match
+		"", // If-None-Match
+		"", // If-Unmodified-Since
+		"", // Range
+		canonicalHeaders.String() + canonicalResource.String(),
+	}, "\n")
+
+	signature := base64.StdEncoding.EncodeToString(hmacSHA256(w.accountKey, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", w.accountName, signature))
+}
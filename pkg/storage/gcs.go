@@ -0,0 +1,138 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+)
+
+const (
+	// Key of the credentials secret of GCS storages, with the service account key
+	GCSCredentialsKey = "credentials.json"
+
+	gcsDefaultEndpoint = "https://storage.googleapis.com"
+	gcsScope           = "https://www.googleapis.com/auth/devstorage.read_write"
+)
+
+// gcsWriter uploads and downloads objects with the JSON API, authenticated with a service account key
+type gcsWriter struct {
+	client   *http.Client
+	endpoint string
+	bucket   string
+	prefix   string
+}
+
+func newGCSWriter(ctx context.Context, storage *api.BackupStorage, credentials map[string][]byte) (*gcsWriter, error) {
+	serviceAccountKey, err := credential(credentials, GCSCredentialsKey)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := google.CredentialsFromJSON(ctx, []byte(serviceAccountKey), gcsScope)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GCS service account key: %w", err)
+	}
+
+	endpoint := storage.Endpoint
+	if endpoint == "" {
+		endpoint = gcsDefaultEndpoint
+	}
+
+	// The token source outlives the context of the first request
+	client := oauth2.NewClient(context.WithValue(context.Background(), oauth2.HTTPClient, httpClient), creds.TokenSource)
+
+	return &gcsWriter{
+		client:   client,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		bucket:   storage.Bucket,
+		prefix:   storage.Prefix,
+	}, nil
+}
+
+func (w *gcsWriter) Write(ctx context.Context, key string, data []byte) error {
+	return w.WriteFrom(ctx, key, bytes.NewReader(data), int64(len(data)))
+}
+
+func (w *gcsWriter) WriteFrom(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	query := url.Values{}
+	query.Set("uploadType", "media")
+	query.Set("name", objectKey(w.prefix, key))
+	uploadURL := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?%s", w.endpoint, url.PathEscape(w.bucket), query.Encode())
+
+	req, err := newUploadRequest(ctx, http.MethodPost, uploadURL, body, size)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	return doRequest(w.client, req)
+}
+
+func (w *gcsWriter) ReadTo(ctx context.Context, key string, out io.Writer) error {
+	downloadURL := fmt.Sprintf("%s/storage/v1/b/%s/o/%s?alt=media", w.endpoint, url.PathEscape(w.bucket),
+		url.PathEscape(objectKey(w.prefix, key)))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return err
+	}
+
+	return doDownload(w.client, req, out)
+}
+
+func (w *gcsWriter) Delete(ctx context.Context, prefix string) error {
+	var listed struct {
+		Items []struct {
+			Name string `json:"name"`
+		} `json:"items"`
+		NextPageToken string `json:"nextPageToken"`
+	}
+
+	query := url.Values{"prefix": {objectKey(w.prefix, prefix)}, "fields": {"items(name),nextPageToken"}}
+	for {
+		listURL := fmt.Sprintf("%s/storage/v1/b/%s/o?%s", w.endpoint, url.PathEscape(w.bucket), query.Encode())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, listURL, nil)
+		if err != nil {
+			return err
+		}
+
+		var response bytes.Buffer
+		if err := doDownload(w.client, req, &response); err != nil {
+			return err
+		}
+		listed.Items, listed.NextPageToken = nil, ""
+		if err := json.Unmarshal(response.Bytes(), &listed); err != nil {
+			return err
+		}
+
+		// The names of the listing include the prefix of the storage
+		for _, object := range listed.Items {
+			objectURL := fmt.Sprintf("%s/storage/v1/b/%s/o/%s", w.endpoint, url.PathEscape(w.bucket), url.PathEscape(object.Name))
+			req, err := http.NewRequestWithContext(ctx, http.MethodDelete, objectURL, nil)
+			if err != nil {
+				return err
+			}
+			if err := doRequest(w.client, req); err != nil {
+				return err
+			}
+		}
+
+		if listed.NextPageToken == "" {
+			return nil
+		}
+		query.Set("pageToken", listed.NextPageToken)
+	}
+}
@@ -0,0 +1,355 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+)
+
+const (
+	// Keys of the credentials secret of S3 storages
+	S3AccessKeyIDKey     = "access_key_id"
+	S3SecretAccessKeyKey = "secret_access_key"
+
+	s3DefaultRegion = "us-east-1"
+
+	// A multipart upload has up to 10000 parts
+	s3MaxParts = 10000
+)
+
+// The objects larger than a part, which can not exceed 5 GiB in a single PUT, are uploaded in parts
+var s3PartSize int64 = 64 << 20
+
+// s3Writer puts and gets objects with requests signed with AWS Signature Version 4
+type s3Writer struct {
+	endpoint        *url.URL
+	pathStyle       bool
+	bucket          string
+	prefix          string
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	now             func() time.Time
+}
+
+func newS3Writer(storage *api.BackupStorage, credentials map[string][]byte) (*s3Writer, error) {
+	accessKeyID, err := credential(credentials, S3AccessKeyIDKey)
+	if err != nil {
+		return nil, err
+	}
+	secretAccessKey, err := credential(credentials, S3SecretAccessKeyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	region := storage.Region
+	if region == "" {
+		region = s3DefaultRegion
+	}
+
+	// S3-compatible stores are addressed with path-style URLs, AWS with virtual-hosted ones
+	rawEndpoint, pathStyle := storage.Endpoint, true
+	if rawEndpoint == "" {
+		rawEndpoint, pathStyle = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", storage.Bucket, region), false
+	}
+	endpoint, err := url.Parse(rawEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint '%s': %w", rawEndpoint, err)
+	}
+
+	return &s3Writer{
+		endpoint:        endpoint,
+		pathStyle:       pathStyle,
+		bucket:          storage.Bucket,
+		prefix:          storage.Prefix,
+		region:          region,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		now:             time.Now,
+	}, nil
+}
+
+func (w *s3Writer) Write(ctx context.Context, key string, data []byte) error {
+	return w.WriteFrom(ctx, key, bytes.NewReader(data), int64(len(data)))
+}
+
+func (w *s3Writer) WriteFrom(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	if size > s3PartSize {
+		return w.writeMultipart(ctx, key, body, size)
+	}
+	_, err := w.put(ctx, w.objectURL(key), body, size)
+	return err
+}
+
+// writeMultipart uploads the object in parts, see
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/mpuoverview.html
+func (w *s3Writer) writeMultipart(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	objectURL := w.objectURL(key)
+
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := w.post(ctx, objectURL+"?uploads=", nil, &initiated); err != nil {
+		return err
+	}
+	uploadQuery := url.Values{"uploadId": {initiated.UploadID}}
+
+	type completedPart struct {
+		PartNumber int
+		ETag       string
+	}
+	completed := struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{}
+
+	partLength := partSize(size, s3PartSize, s3MaxParts)
+	for offset := int64(0); offset < size; offset += partLength {
+		partNumber := len(completed.Parts) + 1
+		partQuery := url.Values{"partNumber": {fmt.Sprint(partNumber)}, "uploadId": {initiated.UploadID}}
+		header, err := w.put(ctx, objectURL+"?"+s3Query(partQuery), body, minInt64(partLength, size-offset))
+		if err != nil {
+			w.abortMultipart(ctx, objectURL, uploadQuery)
+			return err
+		}
+		completed.Parts = append(completed.Parts, completedPart{PartNumber: partNumber, ETag: header.Get("ETag")})
+	}
+
+	payload, err := xml.Marshal(completed)
+	if err != nil {
+		w.abortMultipart(ctx, objectURL, uploadQuery)
+		return err
+	}
+	// The completion can fail after the status of the response was sent, the error is then in its body
+	var result struct {
+		XMLName xml.Name
+		Code    string
+		Message string
+	}
+	if err := w.post(ctx, objectURL+"?"+s3Query(uploadQuery), payload, &result); err != nil {
+		w.abortMultipart(ctx, objectURL, uploadQuery)
+		return err
+	}
+	if result.XMLName.Local == "Error" {
+		w.abortMultipart(ctx, objectURL, uploadQuery)
+		return fmt.Errorf("completing the upload of %s failed: %s: %s", objectKey(w.prefix, key), result.Code, result.Message)
+	}
+	return nil
+}
+
+// abortMultipart deletes the parts of a failed upload, on a best effort basis since a lifecycle rule of the
+// bucket can also delete them
+func (w *s3Writer) abortMultipart(ctx context.Context, objectURL string, uploadQuery url.Values) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, objectURL+"?"+s3Query(uploadQuery), nil)
+	if err != nil {
+		return
+	}
+	w.sign(req, sha256Hex(nil))
+	_ = doRequest(httpClient, req)
+}
+
+// put sends the size bytes of the body from its current offset, the payload is signed so it is read once to
+// hash it before being sent
+func (w *s3Writer) put(ctx context.Context, url string, body io.ReadSeeker, size int64) (http.Header, error) {
+	start, err := body.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	if _, err := io.CopyN(hash, body, size); err != nil {
+		return nil, err
+	}
+	if _, err := body.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	req, err := newUploadRequest(ctx, http.MethodPut, url, io.LimitReader(body, size), size)
+	if err != nil {
+		return nil, err
+	}
+	w.sign(req, hex.EncodeToString(hash.Sum(nil)))
+
+	return send(httpClient, req, io.Discard)
+}
+
+// post sends the payload and decodes the XML body of the response into result
+func (w *s3Writer) post(ctx context.Context, url string, payload []byte, result interface{}) error {
+	req, err := newUploadRequest(ctx, http.MethodPost, url, bytes.NewReader(payload), int64(len(payload)))
+	if err != nil {
+		return err
+	}
+	w.sign(req, sha256Hex(payload))
+
+	var response bytes.Buffer
+	if _, err := send(httpClient, req, &response); err != nil {
+		return err
+	}
+	return xml.Unmarshal(response.Bytes(), result)
+}
+
+func (w *s3Writer) ReadTo(ctx context.Context, key string, out io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	w.sign(req, sha256Hex(nil))
+
+	return doDownload(httpClient, req, out)
+}
+
+func (w *s3Writer) Delete(ctx context.Context, prefix string) error {
+	var listed struct {
+		Contents []struct {
+			Key string
+		}
+		NextContinuationToken string
+	}
+
+	query := url.Values{"list-type": {"2"}, "prefix": {objectKey(w.prefix, prefix)}}
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.bucketURL()+"?"+s3Query(query), nil)
+		if err != nil {
+			return err
+		}
+		w.sign(req, sha256Hex(nil))
+
+		var response bytes.Buffer
+		if err := doDownload(httpClient, req, &response); err != nil {
+			return err
+		}
+		listed.Contents, listed.NextContinuationToken = nil, ""
+		if err := xml.Unmarshal(response.Bytes(), &listed); err != nil {
+			return err
+		}
+
+		// The keys of the listing include the prefix of the storage
+		for _, object := range listed.Contents {
+			req, err := http.NewRequestWithContext(ctx, http.MethodDelete, w.keyURL(object.Key), nil)
+			if err != nil {
+				return err
+			}
+			w.sign(req, sha256Hex(nil))
+			if err := doRequest(httpClient, req); err != nil {
+				return err
+			}
+		}
+
+		if listed.NextContinuationToken == "" {
+			return nil
+		}
+		query.Set("continuation-token", listed.NextContinuationToken)
+	}
+}
+
+// objectURL returns the URL of the object with the key, path-style or virtual-hosted
+func (w *s3Writer) objectURL(key string) string {
+	return w.keyURL(objectKey(w.prefix, key))
+}
+
+// bucketURL returns the URL of the bucket, path-style or virtual-hosted
+func (w *s3Writer) bucketURL() string {
+	return w.keyURL("")
+}
+
+// keyURL returns the URL of the object with the key, which includes the prefix of the storage
+func (w *s3Writer) keyURL(key string) string {
+	objectPath := "/" + key
+	if w.pathStyle {
+		objectPath = "/" + w.bucket + objectPath
+	}
+	objectPath = strings.TrimSuffix(w.endpoint.Path, "/") + objectPath
+
+	u := *w.endpoint
+	u.Path = objectPath
+	u.RawPath = s3URIEncode(objectPath)
+	return u.String()
+}
+
+// sign adds the Signature Version 4 authorization header to the request, see
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (w *s3Writer) sign(req *http.Request, payloadHash string) {
+	t := w.now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, w.region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signature := hex.EncodeToString(hmacSHA256(s3SigningKey(w.secretAccessKey, date, w.region, "s3"), stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		w.accessKeyID, scope, signedHeaders, signature))
+}
+
+// s3Query encodes the query as expected in the canonical request, with sorted keys and spaces encoded as %20
+func s3Query(query url.Values) string {
+	return strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+func s3SigningKey(secretAccessKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// s3URIEncode encodes every byte of the path but the unreserved characters and the slashes, as
+// expected in the canonical request
+func s3URIEncode(path string) string {
+	var sb strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
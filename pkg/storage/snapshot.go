@@ -0,0 +1,215 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+)
+
+const (
+	// UploadSnapshotCommand is the argument of the operator binary which uploads the snapshot of a node,
+	// run by the upload jobs of the backups
+	UploadSnapshotCommand = "upload-snapshot"
+
+	// DownloadSnapshotCommand is the argument of the operator binary which downloads the snapshot of a node
+	// when it is not on its data volume anymore, run by the restore jobs of the backups with a storage
+	DownloadSnapshotCommand = "download-snapshot"
+
+	// snapshotIndexKey is the key, relative to the prefix of the files of a node, of the list of the keys
+	// of its files
+	snapshotIndexKey = "files.json"
+
+	// The environment of the upload jobs: the JSON BackupStorage, the tag of the snapshot and the prefix
+	// of the keys of the uploaded files
+	StorageEnv     = "BACKUP_STORAGE"
+	SnapshotEnv    = "SNAPSHOT_NAME"
+	KeyPrefixEnv   = "BACKUP_KEY_PREFIX"
+	DataDirEnv     = "CASSANDRA_DATA_DIR"
+	CredentialsDir = "/etc/backup-credentials"
+)
+
+// UploadSnapshot uploads the files of the snapshot of every table found in the data directory of a node,
+// under the keys <keyPrefix>/<keyspace>/<table>/<file>, followed by the index listing them. It returns the
+// number of uploaded files.
+func UploadSnapshot(ctx context.Context, writer Writer, dataDir, snapshotName, keyPrefix string) (int, error) {
+	snapshotDirs, err := filepath.Glob(filepath.Join(dataDir, "*", "*", "snapshots", snapshotName))
+	if err != nil {
+		return 0, err
+	}
+
+	uploaded := 0
+	index := []string{}
+	for _, snapshotDir := range snapshotDirs {
+		tableDir := filepath.Dir(filepath.Dir(snapshotDir))
+		keyspace, table := filepath.Base(filepath.Dir(tableDir)), filepath.Base(tableDir)
+
+		// The snapshots of the secondary indexes are in subdirectories
+		err := filepath.WalkDir(snapshotDir, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			relative, err := filepath.Rel(snapshotDir, file)
+			if err != nil {
+				return err
+			}
+			key := path.Join(keyspace, table, filepath.ToSlash(relative))
+			if err := uploadFile(ctx, writer, file, path.Join(keyPrefix, key)); err != nil {
+				return fmt.Errorf("failed to upload %s: %w", file, err)
+			}
+			index = append(index, key)
+			uploaded++
+			return nil
+		})
+		if err != nil {
+			return uploaded, err
+		}
+	}
+
+	indexData, err := json.Marshal(index)
+	if err != nil {
+		return uploaded, err
+	}
+	return uploaded, writer.Write(ctx, path.Join(keyPrefix, snapshotIndexKey), indexData)
+}
+
+// DownloadSnapshot downloads the files listed in the index of the snapshot of a node back to the snapshot
+// directories of its tables, unless the snapshot is still in the data directory. It returns the number of
+// downloaded files.
+func DownloadSnapshot(ctx context.Context, reader Reader, dataDir, snapshotName, keyPrefix string) (int, error) {
+	snapshotDirs, err := filepath.Glob(filepath.Join(dataDir, "*", "*", "snapshots", snapshotName))
+	if err != nil || len(snapshotDirs) > 0 {
+		return 0, err
+	}
+
+	var indexData bytes.Buffer
+	if err := reader.ReadTo(ctx, path.Join(keyPrefix, snapshotIndexKey), &indexData); err != nil {
+		return 0, fmt.Errorf("failed to download the index of the snapshot: %w", err)
+	}
+	var index []string
+	if err := json.Unmarshal(indexData.Bytes(), &index); err != nil {
+		return 0, fmt.Errorf("invalid index of the snapshot: %w", err)
+	}
+
+	downloaded := 0
+	for _, key := range index {
+		parts := strings.SplitN(path.Clean(key), "/", 3)
+		if len(parts) != 3 || parts[0] == ".." || parts[1] == ".." || strings.HasPrefix(parts[2], "../") {
+			return downloaded, fmt.Errorf("invalid key %s in the index of the snapshot", key)
+		}
+		file := filepath.Join(dataDir, parts[0], parts[1], "snapshots", snapshotName, filepath.FromSlash(parts[2]))
+		if err := downloadFile(ctx, reader, path.Join(keyPrefix, key), file); err != nil {
+			return downloaded, fmt.Errorf("failed to download %s: %w", key, err)
+		}
+		downloaded++
+	}
+	return downloaded, nil
+}
+
+func downloadFile(ctx context.Context, reader Reader, key, file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := reader.ReadTo(ctx, key, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func uploadFile(ctx context.Context, writer Writer, file, key string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return writer.WriteFrom(ctx, key, f, info.Size())
+}
+
+// RunUploadSnapshot uploads the snapshot of the node whose data directory is mounted in the upload job,
+// configured by its environment and the credentials secret mounted in CredentialsDir
+func RunUploadSnapshot(ctx context.Context) error {
+	storage, credentials, err := readJobStorage()
+	if err != nil {
+		return err
+	}
+
+	writer, err := NewWriter(ctx, storage, credentials)
+	if err != nil {
+		return err
+	}
+
+	uploaded, err := UploadSnapshot(ctx, writer, os.Getenv(DataDirEnv), os.Getenv(SnapshotEnv), os.Getenv(KeyPrefixEnv))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("uploaded %d files of snapshot %s\n", uploaded, os.Getenv(SnapshotEnv))
+	return nil
+}
+
+// RunDownloadSnapshot downloads the snapshot of the node whose data directory is mounted in the restore
+// job, configured like the upload jobs
+func RunDownloadSnapshot(ctx context.Context) error {
+	storage, credentials, err := readJobStorage()
+	if err != nil {
+		return err
+	}
+
+	reader, err := NewReader(ctx, storage, credentials)
+	if err != nil {
+		return err
+	}
+
+	downloaded, err := DownloadSnapshot(ctx, reader, os.Getenv(DataDirEnv), os.Getenv(SnapshotEnv), os.Getenv(KeyPrefixEnv))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("downloaded %d files of snapshot %s\n", downloaded, os.Getenv(SnapshotEnv))
+	return nil
+}
+
+// readJobStorage reads the storage of the environment of the job, and its credentials from the secret
+// mounted in CredentialsDir
+func readJobStorage() (*api.BackupStorage, map[string][]byte, error) {
+	storage := &api.BackupStorage{}
+	if err := json.Unmarshal([]byte(os.Getenv(StorageEnv)), storage); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %w", StorageEnv, err)
+	}
+
+	credentials := make(map[string][]byte)
+	entries, err := os.ReadDir(CredentialsDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, entry := range entries {
+		// The files of a secret volume are symlinks, the hidden entries are the directories they point to
+		if entry.IsDir() || entry.Name()[0] == '.' {
+			continue
+		}
+		value, err := os.ReadFile(filepath.Join(CredentialsDir, entry.Name()))
+		if err != nil {
+			return nil, nil, err
+		}
+		credentials[entry.Name()] = value
+	}
+	return storage, credentials, nil
+}
@@ -0,0 +1,114 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+)
+
+// memoryWriter keeps the objects written to it
+type memoryWriter map[string]string
+
+func (w memoryWriter) Write(ctx context.Context, key string, data []byte) error {
+	return w.WriteFrom(ctx, key, bytes.NewReader(data), int64(len(data)))
+}
+
+func (w memoryWriter) WriteFrom(_ context.Context, key string, body io.ReadSeeker, _ int64) error {
+	data, err := io.ReadAll(body)
+	w[key] = string(data)
+	return err
+}
+
+func (w memoryWriter) Delete(_ context.Context, prefix string) error {
+	for key := range w {
+		if strings.HasPrefix(key, prefix) {
+			delete(w, key)
+		}
+	}
+	return nil
+}
+
+func (w memoryWriter) ReadTo(_ context.Context, key string, out io.Writer) error {
+	data, found := w[key]
+	if !found {
+		return fmt.Errorf("no object %s", key)
+	}
+	_, err := io.WriteString(out, data)
+	return err
+}
+
+func TestUploadSnapshot(t *testing.T) {
+	dataDir := t.TempDir()
+	for file, content := range map[string]string{
+		"ks1/table1-1234/snapshots/backup1/nb-1-big-Data.db":              "data",
+		"ks1/table1-1234/snapshots/backup1/.table1_idx/nb-1-big-Index.db": "index",
+		"ks1/table1-1234/snapshots/other/nb-1-big-Data.db":                "other snapshot",
+		"ks1/table1-1234/nb-2-big-Data.db":                                "live data",
+		"system_auth/roles-5678/snapshots/backup1/manifest.json":          "{}",
+	} {
+		path := filepath.Join(dataDir, filepath.FromSlash(file))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	writer := memoryWriter{}
+	uploaded, err := UploadSnapshot(context.Background(), writer, dataDir, "backup1", "backup1/pod-0")
+	require.NoError(t, err)
+	assert.Equal(t, 3, uploaded)
+	assert.Equal(t, memoryWriter{
+		"backup1/pod-0/ks1/table1-1234/nb-1-big-Data.db":              "data",
+		"backup1/pod-0/ks1/table1-1234/.table1_idx/nb-1-big-Index.db": "index",
+		"backup1/pod-0/system_auth/roles-5678/manifest.json":          "{}",
+		"backup1/pod-0/files.json": `["ks1/table1-1234/.table1_idx/nb-1-big-Index.db","ks1/table1-1234/nb-1-big-Data.db",` +
+			`"system_auth/roles-5678/manifest.json"]`,
+	}, writer)
+
+	// The snapshot is downloaded back once it is not on the node anymore
+	downloaded, err := DownloadSnapshot(context.Background(), writer, dataDir, "backup1", "backup1/pod-0")
+	require.NoError(t, err)
+	assert.Equal(t, 0, downloaded)
+
+	require.NoError(t, os.RemoveAll(filepath.Join(dataDir, "ks1", "table1-1234", "snapshots", "backup1")))
+	require.NoError(t, os.RemoveAll(filepath.Join(dataDir, "system_auth")))
+	downloaded, err = DownloadSnapshot(context.Background(), writer, dataDir, "backup1", "backup1/pod-0")
+	require.NoError(t, err)
+	assert.Equal(t, 3, downloaded)
+	data, err := os.ReadFile(filepath.Join(dataDir, "ks1", "table1-1234", "snapshots", "backup1", ".table1_idx", "nb-1-big-Index.db"))
+	require.NoError(t, err)
+	assert.Equal(t, "index", string(data))
+
+	// The keys of the index stay in the data directory
+	writer["backup2/pod-0/files.json"] = `["../../etc/passwd"]`
+	_, err = DownloadSnapshot(context.Background(), writer, dataDir, "backup2", "backup2/pod-0")
+	assert.Error(t, err)
+}
+
+func TestS3Writer_WriteFrom(t *testing.T) {
+	var requests []recordedRequest
+	server := recordingServer(t, &requests)
+
+	writer, err := newS3Writer(&api.BackupStorage{Type: api.StorageS3, Bucket: "bucket1", Endpoint: server.URL}, map[string][]byte{
+		S3AccessKeyIDKey:     []byte("AKIDEXAMPLE"),
+		S3SecretAccessKeyKey: []byte("secret"),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, writer.WriteFrom(context.Background(), "backup1/pod-0/ks1/table1/nb-1-big-Data.db", bytes.NewReader([]byte("data")), 4))
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, "data", requests[0].body)
+	assert.Equal(t, sha256Hex([]byte("data")), requests[0].header.Get("X-Amz-Content-Sha256"))
+}
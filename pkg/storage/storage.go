@@ -0,0 +1,144 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package storage writes backup objects to the object storages modeled by the BackupStorage spec
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+)
+
+// Writer uploads objects to a backup storage
+type Writer interface {
+	// Write creates or replaces the object with the given key, relative to the prefix of the storage
+	Write(ctx context.Context, key string, data []byte) error
+
+	// WriteFrom creates or replaces the object with the given key with the size bytes of the body, which
+	// is streamed rather than held in memory
+	WriteFrom(ctx context.Context, key string, body io.ReadSeeker, size int64) error
+
+	// Delete deletes the objects whose keys, relative to the prefix of the storage, start with the given
+	// prefix, such as "<backup>/" for the files of a backup
+	Delete(ctx context.Context, prefix string) error
+}
+
+// Reader downloads objects from a backup storage
+type Reader interface {
+	// ReadTo streams the content of the object with the given key, relative to the prefix of the storage,
+	// to out
+	ReadTo(ctx context.Context, key string, out io.Writer) error
+}
+
+// The requests are bounded by their context, uploading a large file can take longer than any fixed timeout
+var httpClient = &http.Client{}
+
+// NewWriter returns the Writer of the storage, authenticated with the data of its credentials secret
+func NewWriter(ctx context.Context, storage *api.BackupStorage, credentials map[string][]byte) (Writer, error) {
+	switch storage.Type {
+	case api.StorageS3:
+		return newS3Writer(storage, credentials)
+	case api.StorageGCS:
+		return newGCSWriter(ctx, storage, credentials)
+	case api.StorageAzure:
+		return newAzureWriter(storage, credentials)
+	default:
+		return nil, fmt.Errorf("unsupported backup storage type '%s'", storage.Type)
+	}
+}
+
+// NewReader returns the Reader of the storage, authenticated with the data of its credentials secret
+func NewReader(ctx context.Context, storage *api.BackupStorage, credentials map[string][]byte) (Reader, error) {
+	switch storage.Type {
+	case api.StorageS3:
+		return newS3Writer(storage, credentials)
+	case api.StorageGCS:
+		return newGCSWriter(ctx, storage, credentials)
+	case api.StorageAzure:
+		return newAzureWriter(storage, credentials)
+	default:
+		return nil, fmt.Errorf("unsupported backup storage type '%s'", storage.Type)
+	}
+}
+
+// objectKey prepends the prefix of the storage to the key
+func objectKey(prefix, key string) string {
+	prefix = strings.Trim(prefix, "/")
+	key = strings.TrimLeft(key, "/")
+	if prefix == "" {
+		return key
+	}
+	return prefix + "/" + key
+}
+
+func credential(credentials map[string][]byte, key string) (string, error) {
+	value, found := credentials[key]
+	if !found || len(value) == 0 {
+		return "", fmt.Errorf("the credentials secret has no '%s' key", key)
+	}
+	return string(value), nil
+}
+
+// doRequest sends the request and turns the responses which are not successful into errors
+func doRequest(client *http.Client, req *http.Request) error {
+	_, err := send(client, req, io.Discard)
+	return err
+}
+
+// doDownload sends the request and copies the body of the response to out, the responses which are not
+// successful are turned into errors
+func doDownload(client *http.Client, req *http.Request, out io.Writer) error {
+	_, err := send(client, req, out)
+	return err
+}
+
+// send sends the request, copies the body of the response to out and returns its headers, the responses
+// which are not successful are turned into errors
+func send(client *http.Client, req *http.Request, out io.Writer) (http.Header, error) {
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("%s %s failed with status %d: %s", req.Method, req.URL.Redacted(), res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	_, err = io.Copy(out, res.Body)
+	return res.Header, err
+}
+
+// newUploadRequest returns a request sending the size bytes of the body
+func newUploadRequest(ctx context.Context, method, url string, body io.Reader, size int64) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, io.NopCloser(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	return req, nil
+}
+
+// partSize returns the size of the parts of an upload of size bytes, at least minPartSize and large enough
+// to need no more than maxParts parts
+func partSize(size, minPartSize, maxParts int64) int64 {
+	if fitting := (size + maxParts - 1) / maxParts; fitting > minPartSize {
+		return fitting
+	}
+	return minPartSize
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,403 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	api "github.com/k8ssandra/cass-operator/apis/control/v1alpha1"
+)
+
+type recordedRequest struct {
+	method string
+	uri    string
+	header http.Header
+	body   string
+}
+
+func recordingServer(t *testing.T, requests *[]recordedRequest) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		*requests = append(*requests, recordedRequest{r.Method, r.RequestURI, r.Header, string(body)})
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func fixedTime() time.Time {
+	return time.Date(2022, time.June, 15, 10, 30, 0, 0, time.UTC)
+}
+
+func TestObjectKey(t *testing.T) {
+	assert.Equal(t, "backup1/manifest.json", objectKey("", "backup1/manifest.json"))
+	assert.Equal(t, "prod/backup1/manifest.json", objectKey("prod", "backup1/manifest.json"))
+	assert.Equal(t, "prod/backup1/manifest.json", objectKey("/prod/", "/backup1/manifest.json"))
+}
+
+func TestNewWriter_Invalid(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := NewWriter(ctx, &api.BackupStorage{Type: "ftp", Bucket: "bucket1"}, nil)
+	assert.Error(t, err)
+
+	_, err = NewWriter(ctx, &api.BackupStorage{Type: api.StorageS3, Bucket: "bucket1"}, map[string][]byte{S3AccessKeyIDKey: []byte("key")})
+	assert.Error(t, err)
+
+	_, err = NewWriter(ctx, &api.BackupStorage{Type: api.StorageGCS, Bucket: "bucket1"}, map[string][]byte{GCSCredentialsKey: []byte("{}")})
+	assert.Error(t, err)
+
+	_, err = NewWriter(ctx, &api.BackupStorage{Type: api.StorageAzure, Bucket: "container1"},
+		map[string][]byte{AzureAccountNameKey: []byte("account1"), AzureAccountKeyKey: []byte("not base64!")})
+	assert.Error(t, err)
+}
+
+func TestS3SigningKey(t *testing.T) {
+	// Example of the AWS Signature Version 4 documentation
+	key := s3SigningKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	assert.Equal(t, "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d", hex.EncodeToString(key))
+}
+
+func TestS3Writer(t *testing.T) {
+	var requests []recordedRequest
+	server := recordingServer(t, &requests)
+
+	writer, err := NewWriter(context.Background(), &api.BackupStorage{
+		Type:     api.StorageS3,
+		Bucket:   "bucket1",
+		Prefix:   "prod",
+		Endpoint: server.URL,
+		Region:   "eu-west-1",
+	}, map[string][]byte{
+		S3AccessKeyIDKey:     []byte("AKIDEXAMPLE"),
+		S3SecretAccessKeyKey: []byte("secret"),
+	})
+	require.NoError(t, err)
+	writer.(*s3Writer).now = fixedTime
+
+	require.NoError(t, writer.Write(context.Background(), "backup1/manifest $1.json", []byte("{}")))
+
+	require.Len(t, requests, 1)
+	req := requests[0]
+	assert.Equal(t, http.MethodPut, req.method)
+	assert.Equal(t, "/bucket1/prod/backup1/manifest%20%241.json", req.uri)
+	assert.Equal(t, "{}", req.body)
+	assert.Equal(t, "20220615T103000Z", req.header.Get("X-Amz-Date"))
+	assert.Equal(t, sha256Hex([]byte("{}")), req.header.Get("X-Amz-Content-Sha256"))
+	assert.Regexp(t, "^AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20220615/eu-west-1/s3/aws4_request, "+
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=[0-9a-f]{64}$", req.header.Get("Authorization"))
+}
+
+func s3MultipartServer(t *testing.T, requests *[]recordedRequest, completion string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		*requests = append(*requests, recordedRequest{r.Method, r.RequestURI, r.Header, string(body)})
+		switch {
+		case r.Method == http.MethodPost && r.URL.Query().Has("uploads"):
+			_, _ = w.Write([]byte("<InitiateMultipartUploadResult><UploadId>upload 1</UploadId></InitiateMultipartUploadResult>"))
+		case r.Method == http.MethodPut:
+			w.Header().Set("ETag", fmt.Sprintf(`"etag-%s"`, r.URL.Query().Get("partNumber")))
+		case r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(completion))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestS3Writer_Multipart(t *testing.T) {
+	defer func(size int64) { s3PartSize = size }(s3PartSize)
+	s3PartSize = 4
+
+	var requests []recordedRequest
+	server := s3MultipartServer(t, &requests, "<CompleteMultipartUploadResult></CompleteMultipartUploadResult>")
+
+	writer, err := newS3Writer(&api.BackupStorage{Type: api.StorageS3, Bucket: "bucket1", Endpoint: server.URL}, map[string][]byte{
+		S3AccessKeyIDKey:     []byte("AKIDEXAMPLE"),
+		S3SecretAccessKeyKey: []byte("secret"),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, writer.Write(context.Background(), "backup1/nb-1-big-Data.db", []byte("0123456789")))
+
+	require.Len(t, requests, 5)
+	assert.Equal(t, http.MethodPost, requests[0].method)
+	assert.Equal(t, "/bucket1/backup1/nb-1-big-Data.db?uploads=", requests[0].uri)
+	for i, part := range []string{"0123", "4567", "89"} {
+		req := requests[i+1]
+		assert.Equal(t, http.MethodPut, req.method)
+		assert.Equal(t, fmt.Sprintf("/bucket1/backup1/nb-1-big-Data.db?partNumber=%d&uploadId=upload%%201", i+1), req.uri)
+		assert.Equal(t, part, req.body)
+		assert.Equal(t, sha256Hex([]byte(part)), req.header.Get("X-Amz-Content-Sha256"))
+	}
+	assert.Equal(t, http.MethodPost, requests[4].method)
+	assert.Equal(t, "/bucket1/backup1/nb-1-big-Data.db?uploadId=upload%201", requests[4].uri)
+	assert.Equal(t, "<CompleteMultipartUpload>"+
+		"<Part><PartNumber>1</PartNumber><ETag>&#34;etag-1&#34;</ETag></Part>"+
+		"<Part><PartNumber>2</PartNumber><ETag>&#34;etag-2&#34;</ETag></Part>"+
+		"<Part><PartNumber>3</PartNumber><ETag>&#34;etag-3&#34;</ETag></Part>"+
+		"</CompleteMultipartUpload>", requests[4].body)
+}
+
+func TestS3Writer_MultipartCompletionError(t *testing.T) {
+	defer func(size int64) { s3PartSize = size }(s3PartSize)
+	s3PartSize = 4
+
+	var requests []recordedRequest
+	server := s3MultipartServer(t, &requests, "<Error><Code>InternalError</Code><Message>We encountered an internal error</Message></Error>")
+
+	writer, err := newS3Writer(&api.BackupStorage{Type: api.StorageS3, Bucket: "bucket1", Endpoint: server.URL}, map[string][]byte{
+		S3AccessKeyIDKey:     []byte("AKIDEXAMPLE"),
+		S3SecretAccessKeyKey: []byte("secret"),
+	})
+	require.NoError(t, err)
+
+	err = writer.Write(context.Background(), "backup1/nb-1-big-Data.db", []byte("012345"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "InternalError")
+
+	require.Len(t, requests, 5)
+	assert.Equal(t, http.MethodDelete, requests[4].method)
+	assert.Equal(t, "/bucket1/backup1/nb-1-big-Data.db?uploadId=upload%201", requests[4].uri)
+}
+
+func TestS3Writer_VirtualHosted(t *testing.T) {
+	writer, err := newS3Writer(&api.BackupStorage{Type: api.StorageS3, Bucket: "bucket1"}, map[string][]byte{
+		S3AccessKeyIDKey:     []byte("AKIDEXAMPLE"),
+		S3SecretAccessKeyKey: []byte("secret"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://bucket1.s3.us-east-1.amazonaws.com", writer.endpoint.String())
+	assert.False(t, writer.pathStyle)
+}
+
+func TestGCSWriter(t *testing.T) {
+	var requests []recordedRequest
+	server := recordingServer(t, &requests)
+
+	writer := &gcsWriter{client: http.DefaultClient, endpoint: server.URL, bucket: "bucket1", prefix: "prod"}
+	require.NoError(t, writer.Write(context.Background(), "backup1/manifest.json", []byte("{}")))
+
+	require.Len(t, requests, 1)
+	req := requests[0]
+	assert.Equal(t, http.MethodPost, req.method)
+	assert.Equal(t, "/upload/storage/v1/b/bucket1/o?name=prod%2Fbackup1%2Fmanifest.json&uploadType=media", req.uri)
+	assert.Equal(t, "{}", req.body)
+}
+
+func TestAzureWriter(t *testing.T) {
+	var requests []recordedRequest
+	server := recordingServer(t, &requests)
+
+	writer, err := NewWriter(context.Background(), &api.BackupStorage{
+		Type:     api.StorageAzure,
+		Bucket:   "container1",
+		Endpoint: server.URL + "/account1",
+	}, map[string][]byte{
+		AzureAccountNameKey: []byte("account1"),
+		AzureAccountKeyKey:  []byte(base64.StdEncoding.EncodeToString([]byte("secret"))),
+	})
+	require.NoError(t, err)
+	writer.(*azureWriter).now = fixedTime
+
+	require.NoError(t, writer.Write(context.Background(), "backup1/manifest.json", []byte("{}")))
+
+	require.Len(t, requests, 1)
+	req := requests[0]
+	assert.Equal(t, http.MethodPut, req.method)
+	assert.Equal(t, "/account1/container1/backup1/manifest.json", req.uri)
+	assert.Equal(t, "{}", req.body)
+	assert.Equal(t, "BlockBlob", req.header.Get("x-ms-blob-type"))
+	assert.Equal(t, "Wed, 15 Jun 2022 10:30:00 GMT", req.header.Get("x-ms-date"))
+	assert.Regexp(t, "^SharedKey account1:[A-Za-z0-9+/]{43}=$", req.header.Get("Authorization"))
+}
+
+func TestAzureWriter_Blocks(t *testing.T) {
+	defer func(size int64) { azureBlockSize = size }(azureBlockSize)
+	azureBlockSize = 4
+
+	var requests []recordedRequest
+	server := recordingServer(t, &requests)
+
+	writer, err := newAzureWriter(&api.BackupStorage{Type: api.StorageAzure, Bucket: "container1", Endpoint: server.URL + "/account1"},
+		map[string][]byte{
+			AzureAccountNameKey: []byte("account1"),
+			AzureAccountKeyKey:  []byte(base64.StdEncoding.EncodeToString([]byte("secret"))),
+		})
+	require.NoError(t, err)
+
+	require.NoError(t, writer.Write(context.Background(), "backup1/nb-1-big-Data.db", []byte("0123456789")))
+
+	require.Len(t, requests, 4)
+	for i, block := range []string{"0123", "4567", "89"} {
+		req := requests[i]
+		blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%05d", i)))
+		assert.Equal(t, http.MethodPut, req.method)
+		assert.Equal(t, "/account1/container1/backup1/nb-1-big-Data.db?"+url.Values{"blockid": {blockID}, "comp": {"block"}}.Encode(), req.uri)
+		assert.Equal(t, block, req.body)
+		assert.Empty(t, req.header.Get("x-ms-blob-type"))
+	}
+	req := requests[3]
+	assert.Equal(t, http.MethodPut, req.method)
+	assert.Equal(t, "/account1/container1/backup1/nb-1-big-Data.db?comp=blocklist", req.uri)
+	assert.Equal(t, xml.Header+"<BlockList><Latest>MDAwMDA=</Latest><Latest>MDAwMDE=</Latest><Latest>MDAwMDI=</Latest></BlockList>", req.body)
+	assert.Equal(t, "application/octet-stream", req.header.Get("x-ms-blob-content-type"))
+}
+
+func TestAzureWriter_SignsQuery(t *testing.T) {
+	writer := &azureWriter{container: "container1", accountName: "account1", accountKey: []byte("secret"), now: fixedTime}
+	writer.endpoint, _ = url.Parse("https://account1.blob.core.windows.net")
+
+	signature := func(rawURL string) string {
+		req, err := http.NewRequest(http.MethodPut, rawURL, nil)
+		require.NoError(t, err)
+		req.Header.Set("x-ms-date", fixedTime().Format(http.TimeFormat))
+		writer.sign(req, 0)
+		return req.Header.Get("Authorization")
+	}
+	blobURL := writer.blobURL("backup1/nb-1-big-Data.db")
+	assert.NotEqual(t, signature(blobURL+"?comp=block&blockid=MDAwMDA%3D"), signature(blobURL+"?comp=block&blockid=MDAwMDE%3D"))
+	assert.Equal(t, signature(blobURL+"?comp=block&blockid=MDAwMDA%3D"), signature(blobURL+"?blockid=MDAwMDA%3D&comp=block"))
+}
+
+// listingServer answers the GET requests with the listing of their page, keyed by their query, and records
+// all the requests
+func listingServer(t *testing.T, requests *[]recordedRequest, pages map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, recordedRequest{r.Method, r.RequestURI, r.Header, ""})
+		if r.Method == http.MethodGet {
+			page, found := pages[r.URL.RawQuery]
+			require.True(t, found, "unexpected listing %s", r.URL.RawQuery)
+			_, _ = w.Write([]byte(page))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestS3Writer_Delete(t *testing.T) {
+	var requests []recordedRequest
+	server := listingServer(t, &requests, map[string]string{
+		"list-type=2&prefix=prod%2Fbackup1%2F": "<ListBucketResult><Contents><Key>prod/backup1/manifest.json</Key></Contents>" +
+			"<NextContinuationToken>token 1</NextContinuationToken></ListBucketResult>",
+		"continuation-token=token%201&list-type=2&prefix=prod%2Fbackup1%2F": "<ListBucketResult><Contents><Key>prod/backup1/pod-0/nb 1.db</Key></Contents></ListBucketResult>",
+	})
+
+	writer, err := newS3Writer(&api.BackupStorage{Type: api.StorageS3, Bucket: "bucket1", Prefix: "prod", Endpoint: server.URL}, map[string][]byte{
+		S3AccessKeyIDKey:     []byte("AKIDEXAMPLE"),
+		S3SecretAccessKeyKey: []byte("secret"),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, writer.Delete(context.Background(), "backup1/"))
+
+	require.Len(t, requests, 4)
+	assert.Equal(t, http.MethodGet, requests[0].method)
+	assert.Equal(t, "/bucket1/?list-type=2&prefix=prod%2Fbackup1%2F", requests[0].uri)
+	assert.Equal(t, http.MethodDelete, requests[1].method)
+	assert.Equal(t, "/bucket1/prod/backup1/manifest.json", requests[1].uri)
+	assert.Equal(t, http.MethodGet, requests[2].method)
+	assert.Equal(t, http.MethodDelete, requests[3].method)
+	assert.Equal(t, "/bucket1/prod/backup1/pod-0/nb%201.db", requests[3].uri)
+}
+
+func TestGCSWriter_Delete(t *testing.T) {
+	var requests []recordedRequest
+	server := listingServer(t, &requests, map[string]string{
+		"fields=items%28name%29%2CnextPageToken&prefix=prod%2Fbackup1%2F": `{"items": [{"name": "prod/backup1/manifest.json"}]}`,
+	})
+
+	writer := &gcsWriter{client: http.DefaultClient, endpoint: server.URL, bucket: "bucket1", prefix: "prod"}
+	require.NoError(t, writer.Delete(context.Background(), "backup1/"))
+
+	require.Len(t, requests, 2)
+	assert.Equal(t, http.MethodGet, requests[0].method)
+	assert.Equal(t, http.MethodDelete, requests[1].method)
+	assert.Equal(t, "/storage/v1/b/bucket1/o/prod%2Fbackup1%2Fmanifest.json", requests[1].uri)
+}
+
+func TestAzureWriter_Delete(t *testing.T) {
+	var requests []recordedRequest
+	server := listingServer(t, &requests, map[string]string{
+		"comp=list&prefix=backup1%2F&restype=container": "<EnumerationResults><Blobs><Blob><Name>backup1/manifest.json</Name></Blob></Blobs>" +
+			"<NextMarker /></EnumerationResults>",
+	})
+
+	writer, err := newAzureWriter(&api.BackupStorage{Type: api.StorageAzure, Bucket: "container1", Endpoint: server.URL + "/account1"},
+		map[string][]byte{
+			AzureAccountNameKey: []byte("account1"),
+			AzureAccountKeyKey:  []byte(base64.StdEncoding.EncodeToString([]byte("secret"))),
+		})
+	require.NoError(t, err)
+
+	require.NoError(t, writer.Delete(context.Background(), "backup1/"))
+
+	require.Len(t, requests, 2)
+	assert.Equal(t, http.MethodGet, requests[0].method)
+	assert.Equal(t, "/account1/container1?comp=list&prefix=backup1%2F&restype=container", requests[0].uri)
+	assert.Equal(t, http.MethodDelete, requests[1].method)
+	assert.Equal(t, "/account1/container1/backup1/manifest.json", requests[1].uri)
+	assert.Regexp(t, "^SharedKey account1:", requests[1].header.Get("Authorization"))
+}
+
+func TestWriter_ErrorResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("AccessDenied"))
+	}))
+	defer server.Close()
+
+	writer := &gcsWriter{client: http.DefaultClient, endpoint: server.URL, bucket: "bucket1"}
+	err := writer.Write(context.Background(), "backup1/manifest.json", []byte("{}"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+	assert.Contains(t, err.Error(), "AccessDenied")
+}
+
+func TestReaders(t *testing.T) {
+	var requests []recordedRequest
+	server := recordingServer(t, &requests)
+	ctx := context.Background()
+
+	s3Reader, err := NewReader(ctx, &api.BackupStorage{Type: api.StorageS3, Bucket: "bucket1", Endpoint: server.URL}, map[string][]byte{
+		S3AccessKeyIDKey:     []byte("AKIDEXAMPLE"),
+		S3SecretAccessKeyKey: []byte("secret"),
+	})
+	require.NoError(t, err)
+	azureReader, err := NewReader(ctx, &api.BackupStorage{Type: api.StorageAzure, Bucket: "container1", Endpoint: server.URL + "/account1"}, map[string][]byte{
+		AzureAccountNameKey: []byte("account1"),
+		AzureAccountKeyKey:  []byte(base64.StdEncoding.EncodeToString([]byte("secret"))),
+	})
+	require.NoError(t, err)
+	gcsReader := &gcsWriter{client: http.DefaultClient, endpoint: server.URL, bucket: "bucket1", prefix: "prod"}
+
+	for _, reader := range []Reader{s3Reader, azureReader, gcsReader} {
+		require.NoError(t, reader.ReadTo(ctx, "backup1/pod-0/files.json", io.Discard))
+	}
+
+	require.Len(t, requests, 3)
+	for _, req := range requests {
+		assert.Equal(t, http.MethodGet, req.method)
+	}
+	assert.Equal(t, "/bucket1/backup1/pod-0/files.json", requests[0].uri)
+	assert.Equal(t, sha256Hex(nil), requests[0].header.Get("X-Amz-Content-Sha256"))
+	assert.Equal(t, "/account1/container1/backup1/pod-0/files.json", requests[1].uri)
+	assert.Regexp(t, "^SharedKey account1:", requests[1].header.Get("Authorization"))
+	assert.Equal(t, "/storage/v1/b/bucket1/o/prod%2Fbackup1%2Fpod-0%2Ffiles.json?alt=media", requests[2].uri)
+}
@@ -0,0 +1,60 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronSchedule_Next(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2022, time.June, 15, 10, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		expression string
+		want       time.Time
+	}{
+		{"* * * * *", time.Date(2022, time.June, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2022, time.June, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2022, time.June, 16, 2, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2022, time.June, 16, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2022, time.June, 15, 11, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2022, time.June, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2022, time.June, 19, 0, 0, 0, 0, time.UTC)},
+		{"30 4 1,15 * *", time.Date(2022, time.July, 1, 4, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * 1-5", time.Date(2022, time.June, 15, 13, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		// Either of the restricted days matches
+		{"0 0 20 * 5", time.Date(2022, time.June, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseCronSchedule(tt.expression)
+		assert.NoError(t, err, tt.expression)
+		assert.Equal(t, tt.want, schedule.Next(from), tt.expression)
+	}
+}
+
+func TestParseCronSchedule_Invalid(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@reboot",
+	} {
+		_, err := ParseCronSchedule(expression)
+		assert.Error(t, err, expression)
+	}
+}
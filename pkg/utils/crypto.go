@@ -84,6 +84,18 @@ func prepare_ca(ca *corev1.Secret) (ca_cert_bytes []byte, ca_certificate *x509.C
 }
 
 func GenerateJKS(ca *corev1.Secret, podname, dcname string) (jksblob []byte, err error) {
+	name := fmt.Sprintf("%s.%s.cassdc", podname, ca.ObjectMeta.Namespace)
+	// Internode connections authenticate both ends
+	return generateSignedJKS(ca, name, []string{name}, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}, dcname)
+}
+
+// GenerateServerJKS returns a JKS holding a key whose certificate, signed by the CA, authenticates a
+// server reached through the DNS names, the first one being its common name
+func GenerateServerJKS(ca *corev1.Secret, dnsNames []string, password string) ([]byte, error) {
+	return generateSignedJKS(ca, dnsNames[0], dnsNames, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, password)
+}
+
+func generateSignedJKS(ca *corev1.Secret, commonName string, dnsNames []string, extKeyUsage []x509.ExtKeyUsage, password string) ([]byte, error) {
 	serialNumber, notBefore, priv, _, notAfter, err := setupKey()
 	if err != nil {
 		return nil, err
@@ -91,7 +103,7 @@ func GenerateJKS(ca *corev1.Secret, podname, dcname string) (jksblob []byte, err
 	newCert := x509.Certificate{
 		SerialNumber: serialNumber,
 		Subject: pkix.Name{
-			CommonName:   fmt.Sprintf("%s.%s.cassdc", podname, ca.ObjectMeta.Namespace),
+			CommonName:   commonName,
 			Organization: []string{"Cassandra Kubernetes Operator By Datastax"},
 		},
 		NotBefore: notBefore,
@@ -99,9 +111,9 @@ func GenerateJKS(ca *corev1.Secret, podname, dcname string) (jksblob []byte, err
 
 		IsCA:                  false,
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		ExtKeyUsage:           extKeyUsage,
 		BasicConstraintsValid: true,
-		DNSNames:              []string{fmt.Sprintf("%s.%s.cassdc", podname, ca.ObjectMeta.Namespace)},
+		DNSNames:              dnsNames,
 	}
 	var derBytes []byte
 	ca_cert_bytes, ca_certificate, ca_key, err := prepare_ca(ca)
@@ -116,7 +128,7 @@ func GenerateJKS(ca *corev1.Secret, podname, dcname string) (jksblob []byte, err
 		}
 		buffer := bytes.NewBufferString("")
 		store := keystore.KeyStore{
-			fmt.Sprintf("%s.%s.cassdc", podname, ca.ObjectMeta.Namespace): &keystore.PrivateKeyEntry{
+			commonName: &keystore.PrivateKeyEntry{
 				Entry:   keystore.Entry{CreationDate: time.Now()},
 				PrivKey: asn1_bytes,
 				CertChain: []keystore.Certificate{{
@@ -134,13 +146,130 @@ func GenerateJKS(ca *corev1.Secret, podname, dcname string) (jksblob []byte, err
 					Content: ca_cert_bytes,
 				},
 			}}
-		err = keystore.Encode(buffer, store, []byte(dcname))
+		err = keystore.Encode(buffer, store, []byte(password))
 		return buffer.Bytes(), err
 	}
 	return nil, err
 
 }
 
+// GenerateTruststore returns a JKS trusting the certificates of the CA secrets
+func GenerateTruststore(cas []*corev1.Secret, password string) ([]byte, error) {
+	caPems := make(map[string][]byte, len(cas))
+	for _, ca := range cas {
+		caPems[ca.Name] = ca.Data["cert"]
+	}
+	return GenerateTruststoreFromPEM(caPems, password)
+}
+
+// GenerateTruststoreFromPEM returns a JKS trusting the PEM encoded certificates, keyed by alias
+func GenerateTruststoreFromPEM(caPems map[string][]byte, password string) ([]byte, error) {
+	store := keystore.KeyStore{}
+	for alias, caPem := range caPems {
+		block, _ := pem.Decode(caPem)
+		if block == nil {
+			return nil, fmt.Errorf("no certificate for %s", alias)
+		}
+		store[alias] = &keystore.TrustedCertificateEntry{
+			Entry: keystore.Entry{CreationDate: time.Now()},
+			Certificate: keystore.Certificate{
+				Type:    "X509",
+				Content: block.Bytes,
+			},
+		}
+	}
+
+	buffer := bytes.NewBufferString("")
+	err := keystore.Encode(buffer, store, []byte(password))
+	return buffer.Bytes(), err
+}
+
+// GenerateJKSFromPEM returns a JKS holding the PEM encoded key and certificate chain, like the ones of the
+// secrets issued by cert-manager, and trusting the PEM encoded CA certificate
+func GenerateJKSFromPEM(alias string, certPem, keyPem, caPem []byte, password string) ([]byte, error) {
+	keyBlock, _ := pem.Decode(keyPem)
+	if keyBlock == nil {
+		return nil, fmt.Errorf("no private key in PEM data")
+	}
+	var key interface{}
+	var err error
+	switch keyBlock.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(keyBlock.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var chain []keystore.Certificate
+	for block, rest := pem.Decode(certPem); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			chain = append(chain, keystore.Certificate{Type: "X509", Content: block.Bytes})
+		}
+	}
+	caBlock, _ := pem.Decode(caPem)
+	if len(chain) == 0 || caBlock == nil {
+		return nil, fmt.Errorf("no certificate in PEM data")
+	}
+
+	store := keystore.KeyStore{
+		alias: &keystore.PrivateKeyEntry{
+			Entry:     keystore.Entry{CreationDate: time.Now()},
+			PrivKey:   pkcs8,
+			CertChain: chain,
+		},
+		"ca": &keystore.TrustedCertificateEntry{
+			Entry:       keystore.Entry{CreationDate: time.Now()},
+			Certificate: keystore.Certificate{Type: "X509", Content: caBlock.Bytes},
+		},
+	}
+	buffer := bytes.NewBufferString("")
+	err = keystore.Encode(buffer, store, []byte(password))
+	return buffer.Bytes(), err
+}
+
+// GetJKSRootCertificate returns in PEM the last certificate of the chain of a key of the JKS, which is
+// the one clients need to trust
+func GetJKSRootCertificate(jks []byte, password string) ([]byte, error) {
+	store, err := keystore.Decode(bytes.NewReader(jks), []byte(password))
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range store {
+		if key, ok := entry.(*keystore.PrivateKeyEntry); ok && len(key.CertChain) > 0 {
+			root := key.CertChain[len(key.CertChain)-1]
+			return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Content}), nil
+		}
+	}
+	return nil, fmt.Errorf("no key in the keystore")
+}
+
+// GetJKSNotAfter returns the expiry date of the certificate of a key of the JKS
+func GetJKSNotAfter(jks []byte, password string) (time.Time, error) {
+	store, err := keystore.Decode(bytes.NewReader(jks), []byte(password))
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, entry := range store {
+		if key, ok := entry.(*keystore.PrivateKeyEntry); ok && len(key.CertChain) > 0 {
+			cert, err := x509.ParseCertificate(key.CertChain[0].Content)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return cert.NotAfter, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no key in the keystore")
+}
+
 type pkcs8Key struct {
 	Version             int
 	PrivateKeyAlgorithm []asn1.ObjectIdentifier
@@ -1,13 +1,17 @@
 package utils
 
 import (
+	"bytes"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
 	"os"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/pavel-v-chernykh/keystore-go"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -92,3 +96,106 @@ func Test_GetJKS(t *testing.T) {
 		t.Errorf("WriteFile error: %e", err)
 	}
 }
+
+func Test_GenerateTruststore(t *testing.T) {
+	pem_key, cert, err := GetNewCAandKey("someclusterca", "somenamespace")
+	if err != nil {
+		t.Errorf("Got an error: %e", err)
+	}
+	ca := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "somedcname-ca-keystore", Namespace: "somenamespace"},
+		Data:       map[string][]byte{"cert": []byte(cert), "key": []byte(pem_key)},
+	}
+	jks, err := GenerateTruststore([]*corev1.Secret{ca}, "somedcname")
+	if err != nil {
+		t.Errorf("Got an error: %e", err)
+	}
+	store, err := keystore.Decode(bytes.NewReader(jks), []byte("somedcname"))
+	if err != nil {
+		t.Errorf("Decoding the truststore failed: %e", err)
+	}
+	if _, ok := store["somedcname-ca-keystore"].(*keystore.TrustedCertificateEntry); !ok {
+		t.Errorf("Error: the CA is not trusted")
+	}
+
+	if _, err = GenerateTruststore([]*corev1.Secret{{ObjectMeta: metav1.ObjectMeta{Name: "empty"}}}, "somedcname"); err == nil {
+		t.Errorf("Error: CA secrets without certificate should be rejected")
+	}
+}
+
+func Test_GenerateServerJKS(t *testing.T) {
+	pem_key, cert, err := GetNewCAandKey("someclusterca", "somenamespace")
+	if err != nil {
+		t.Errorf("Got an error: %e", err)
+	}
+	ca := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "somedcname-ca-keystore", Namespace: "somenamespace"},
+		Data:       map[string][]byte{"cert": []byte(cert), "key": []byte(pem_key)},
+	}
+	dnsNames := []string{"somedc-service.somenamespace.svc", "*.somedc-all-pods-service.somenamespace.svc"}
+	jks, err := GenerateServerJKS(ca, dnsNames, "somedcname")
+	if err != nil {
+		t.Errorf("Got an error: %e", err)
+	}
+
+	store, err := keystore.Decode(bytes.NewReader(jks), []byte("somedcname"))
+	if err != nil {
+		t.Errorf("Got an error: %e", err)
+	}
+	key, ok := store[dnsNames[0]].(*keystore.PrivateKeyEntry)
+	if !ok {
+		t.Fatalf("Error: no key with the alias %s", dnsNames[0])
+	}
+	certificate, err := x509.ParseCertificate(key.CertChain[0].Content)
+	if err != nil {
+		t.Errorf("Got an error: %e", err)
+	}
+	if certificate.Subject.CommonName != dnsNames[0] || !reflect.DeepEqual(certificate.DNSNames, dnsNames) {
+		t.Errorf("Error: unexpected names %s %v", certificate.Subject.CommonName, certificate.DNSNames)
+	}
+	if _, err = GetJKSRootCertificate(jks, "wrong"); err == nil {
+		t.Errorf("Error: a wrong password should be rejected")
+	}
+
+	root, err := GetJKSRootCertificate(jks, "somedcname")
+	if err != nil {
+		t.Errorf("Got an error: %e", err)
+	}
+	if string(root) != cert {
+		t.Errorf("Error: the root certificate is not the CA: %s", root)
+	}
+
+	notAfter, err := GetJKSNotAfter(jks, "somedcname")
+	if err != nil {
+		t.Errorf("Got an error: %e", err)
+	}
+	if notAfter.Before(time.Now().Add(364*24*time.Hour)) || notAfter.After(time.Now().Add(365*24*time.Hour)) {
+		t.Errorf("Error: the keystore should be valid for a year, not until %s", notAfter)
+	}
+}
+
+func Test_GenerateJKSFromPEM(t *testing.T) {
+	pem_key, cert, err := GetNewCAandKey("someclusterca", "somenamespace")
+	if err != nil {
+		t.Errorf("Got an error: %e", err)
+	}
+
+	jks, err := GenerateJKSFromPEM("somepodname", []byte(cert), []byte(pem_key), []byte(cert), "somedcname")
+	if err != nil {
+		t.Errorf("Got an error: %e", err)
+	}
+	store, err := keystore.Decode(bytes.NewReader(jks), []byte("somedcname"))
+	if err != nil {
+		t.Errorf("Decoding failed: %e", err)
+	}
+	if _, ok := store["somepodname"].(*keystore.PrivateKeyEntry); !ok {
+		t.Errorf("Error: no key in the keystore")
+	}
+	if _, ok := store["ca"].(*keystore.TrustedCertificateEntry); !ok {
+		t.Errorf("Error: the CA is not trusted by the keystore")
+	}
+
+	if _, err = GenerateJKSFromPEM("somepodname", []byte(cert), []byte("not a key"), []byte(cert), "somedcname"); err == nil {
+		t.Errorf("Error: an invalid key should be rejected")
+	}
+}